@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"auto_upload_tiktok/internal/delivery/httpapi"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+	sqliterepo "auto_upload_tiktok/internal/repository/sqlite"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// runAccount dispatches the account subcommands
+func runAccount(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("account needs a subcommand: add or list")
+	}
+	switch args[0] {
+	case "add":
+		return runAccountAdd(args[1:])
+	case "list":
+		return runAccountList(args[1:])
+	default:
+		return fmt.Errorf("unknown account subcommand %q (want add or list)", args[0])
+	}
+}
+
+// runAccountAdd creates an account mapping, like POST /api/accounts
+func runAccountAdd(args []string) error {
+	fs := flag.NewFlagSet("account add", flag.ExitOnError)
+	youtubeChannelID := fs.String("youtube", "", "YouTube channel ID, @handle or channel URL to download from (required)")
+	tiktokAccountID := fs.String("tiktok", "", "TikTok account (open_id) to post to; empty fills it in on first authorization")
+	tiktokToken := fs.String("token", "", "TikTok access token (required)")
+	remote := fs.String("remote", "", remoteFlagUsage)
+	fs.Parse(args)
+	if *youtubeChannelID == "" || *tiktokToken == "" {
+		return usageError(fs, "-youtube and -token are required")
+	}
+
+	if *remote != "" {
+		client, err := newRemoteClient(*remote)
+		if err != nil {
+			return err
+		}
+		var account httpapi.AccountResponse
+		body := &httpapi.CreateAccountRequest{
+			YouTubeChannelID: *youtubeChannelID,
+			TikTokAccountID:  *tiktokAccountID,
+			TikTokToken:      *tiktokToken,
+		}
+		if err := client.call(context.Background(), http.MethodPost, "/api/accounts", body, &account); err != nil {
+			return err
+		}
+		fmt.Println(account.ID)
+		return nil
+	}
+
+	cfg, closeLogs := setup()
+	defer closeLogs()
+
+	db, err := sqliterepo.Open(cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	accountManager := usecase.NewAccountManager(cfg, sqliterepo.NewAccountRepository(db))
+	accountManager.SetEventLog(usecase.NewAccountEventLog(sqliterepo.NewAccountEventRepository(db)))
+	youtubeService := youtube.NewService(cfg, httpclient.NewHTTPClient(cfg))
+	accountManager.SetChannelResolver(usecase.NewChannelResolver(youtubeService, sqliterepo.NewChannelHandleRepository(db)))
+	account, err := accountManager.CreateAccountMapping(context.Background(), *youtubeChannelID, *tiktokAccountID, *tiktokToken)
+	if err != nil {
+		return err
+	}
+
+	logger.Info().Printf("Created account %s: YouTube %s -> TikTok %s", account.ID, account.YouTubeChannelID, account.TikTokAccountID)
+	fmt.Println(account.ID)
+	return nil
+}
+
+// accountRow is one line of the account list, read from the database or from GET /api/accounts
+type accountRow struct {
+	ID               string
+	YouTubeChannelID string
+	TikTokAccountID  string
+	IsActive         bool
+	HasAccessToken   bool
+	NeedsReauth      bool
+	MissingScopes    []string
+	LastCheckedAt    *time.Time
+}
+
+// runAccountList prints the account mappings as a table
+func runAccountList(args []string) error {
+	fs := flag.NewFlagSet("account list", flag.ExitOnError)
+	remote := fs.String("remote", "", remoteFlagUsage)
+	fs.Parse(args)
+
+	var rows []accountRow
+	if *remote != "" {
+		client, err := newRemoteClient(*remote)
+		if err != nil {
+			return err
+		}
+		var accounts []*httpapi.AccountResponse
+		if err := client.call(context.Background(), http.MethodGet, "/api/accounts", nil, &accounts); err != nil {
+			return err
+		}
+		for _, account := range accounts {
+			rows = append(rows, accountRow{
+				ID:               account.ID,
+				YouTubeChannelID: account.YouTubeChannelID,
+				TikTokAccountID:  account.TikTokAccountID,
+				IsActive:         account.IsActive,
+				HasAccessToken:   account.HasAccessToken,
+				NeedsReauth:      account.NeedsReauth,
+				MissingScopes:    account.MissingScopes,
+				LastCheckedAt:    account.LastCheckedAt,
+			})
+		}
+	} else {
+		var err error
+		if rows, err = loadAccountRows(); err != nil {
+			return err
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tYOUTUBE CHANNEL\tTIKTOK ACCOUNT\tACTIVE\tTOKEN\tLAST CHECKED")
+	for _, row := range rows {
+		token := "missing"
+		if row.HasAccessToken {
+			token = "stored"
+		}
+		if len(row.MissingScopes) > 0 {
+			token = "missing scope " + strings.Join(row.MissingScopes, ",")
+		}
+		if row.NeedsReauth {
+			token = "needs re-authorization"
+		}
+		lastChecked := "never"
+		if row.LastCheckedAt != nil && !row.LastCheckedAt.IsZero() {
+			lastChecked = row.LastCheckedAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\t%s\n",
+			row.ID, row.YouTubeChannelID, row.TikTokAccountID, row.IsActive, token, lastChecked)
+	}
+	return w.Flush()
+}
+
+// loadAccountRows reads the account mappings from the database
+func loadAccountRows() ([]accountRow, error) {
+	cfg, closeLogs := setup()
+	defer closeLogs()
+
+	db, err := sqliterepo.Open(cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	accountManager := usecase.NewAccountManager(cfg, sqliterepo.NewAccountRepository(db))
+	accounts, err := accountManager.GetAllAccountMappings(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]accountRow, 0, len(accounts))
+	for _, account := range accounts {
+		row := accountRow{
+			ID:               account.ID,
+			YouTubeChannelID: account.YouTubeChannelID,
+			TikTokAccountID:  account.TikTokAccountID,
+			IsActive:         account.IsActive,
+			HasAccessToken:   usecase.HasAccessToken(account),
+			NeedsReauth:      account.NeedsReauthorization,
+			MissingScopes:    account.MissingScopes,
+		}
+		if !account.LastCheckedAt.IsZero() {
+			row.LastCheckedAt = &account.LastCheckedAt
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/downloader"
+	"auto_upload_tiktok/internal/infrastructure/hooks"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/infrastructure/moderation"
+	"auto_upload_tiktok/internal/infrastructure/notify"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/infrastructure/translate"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+	sqliterepo "auto_upload_tiktok/internal/repository/sqlite"
+	"auto_upload_tiktok/internal/usecase"
+	"auto_upload_tiktok/internal/version"
+)
+
+// app holds the repositories, services and use cases that serve and process-once share
+type app struct {
+	db             *sql.DB
+	httpClient     *httpclient.HTTPClient
+	videoRepo      domain.VideoRepository
+	oauthStateRepo domain.OAuthStateRepository
+	youtubeService *youtube.Service
+	tiktokService  *tiktok.Service
+
+	accountManager      *usecase.AccountManager
+	inviteManager       *usecase.InviteManager
+	accountBootstrapper *usecase.AccountBootstrapper
+	clipManager         *usecase.ClipManager
+	experimentManager   *usecase.ExperimentManager
+	publicPageManager   *usecase.PublicPageManager
+	transferMeter       *usecase.TransferMeter
+	accountMonitor      *usecase.AccountMonitor
+	videoProcessor      *usecase.VideoProcessor
+	webSessionManager   *usecase.WebSessionManager
+	pipelineSwitch      *usecase.PipelineSwitch
+	videoAdmin          *usecase.VideoAdmin
+	healthChecker       *usecase.HealthChecker
+	contentSafety       *usecase.ContentSafety
+	dbMaintenance       *usecase.DatabaseMaintenance
+}
+
+// requireAPIKeys checks the credentials monitoring and uploading cannot run without
+func requireAPIKeys(cfg *config.Config) error {
+	if cfg.YouTubeAPIKey == "" {
+		return errors.New("YOUTUBE_API_KEY is required")
+	}
+	if cfg.TikTokAPIKey == "" {
+		return errors.New("TIKTOK_API_KEY is required")
+	}
+	if cfg.TikTokAPISecret == "" {
+		return errors.New("TIKTOK_API_SECRET is required")
+	}
+	return nil
+}
+
+// logStartupBanner logs which build runs with which config file, database and yt-dlp, so logs
+// from different deployments can be told apart
+func logStartupBanner(cfg *config.Config, downloadService *downloader.Service) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ytDlp, err := downloadService.YtDlpVersion(ctx)
+	if err != nil {
+		ytDlp = "unknown"
+		logger.Error().Printf("Warning: %v", err)
+	}
+	logger.Info().Printf("Starting %s", version.Get())
+	logger.Info().Printf("Config %s, database sqlite (%s), yt-dlp %s", config.GetManager().Path(), cfg.DatabaseURL, ytDlp)
+}
+
+// newApp opens the database and wires the pipeline. Immediate processing of discovered videos
+// is left to the caller (see AccountMonitor.SetDispatcher).
+func newApp(cfg *config.Config) (*app, error) {
+	// Initialize HTTP client
+	httpClient := httpclient.NewHTTPClient(cfg)
+
+	// Initialize persistent repositories
+	db, err := sqliterepo.Open(cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	accountRepo := sqliterepo.NewAccountRepository(db)
+	videoRepo := sqliterepo.NewVideoRepository(db)
+	inviteRepo := sqliterepo.NewInviteRepository(db)
+	experimentRepo := sqliterepo.NewExperimentRepository(db)
+	transferRepo := sqliterepo.NewTransferUsageRepository(db)
+	oauthStateRepo := sqliterepo.NewOAuthStateRepository(db)
+	channelHandleRepo := sqliterepo.NewChannelHandleRepository(db)
+
+	// A pause from before the restart must hold before anything is processed
+	pipelineSwitch := usecase.NewPipelineSwitch(sqliterepo.NewPipelineStateRepository(db))
+	if err := pipelineSwitch.Load(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Initialize services
+	youtubeService := youtube.NewService(cfg, httpClient)
+	youtubeService.SetPlaylistCache(sqliterepo.NewYouTubePlaylistRepository(db))
+	downloadService, err := downloader.NewService(cfg, httpClient)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create download service: %w", err)
+	}
+	logStartupBanner(cfg, downloadService)
+	tiktokService := tiktok.NewService(cfg, httpClient)
+	notifier := notify.NewNotifier(cfg, httpClient)
+	translator, err := translate.NewTranslator(cfg, httpClient)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create caption translator: %w", err)
+	}
+
+	// Initialize use cases
+	accountManager := usecase.NewAccountManager(cfg, accountRepo)
+	accountEvents := usecase.NewAccountEventLog(sqliterepo.NewAccountEventRepository(db))
+	accountManager.SetEventLog(accountEvents)
+	inviteManager := usecase.NewInviteManager(cfg, inviteRepo, accountRepo, notifier)
+	reauthAlerter := usecase.NewReauthAlerter(cfg, accountRepo, notifier)
+	reauthAlerter.SetInviteManager(inviteManager)
+	accountManager.SetReauthAlerter(reauthAlerter)
+	accountManager.SetChannelResolver(usecase.NewChannelResolver(youtubeService, channelHandleRepo))
+	failureTracker := usecase.NewFailureTracker(cfg, accountRepo, notifier)
+
+	accountBootstrapper := usecase.NewAccountBootstrapper(accountManager, accountRepo)
+	accountBootstrapper.Apply(usecase.WithAccountEventSource(context.Background(), domain.AccountEventSourceBootstrap), cfg.BootstrapAccounts, cfg.AccountsBootstrapMode)
+	accountMonitor := usecase.NewAccountMonitor(cfg, accountRepo, videoRepo, youtubeService)
+	videoProcessor := usecase.NewVideoProcessor(
+		cfg,
+		videoRepo,
+		accountRepo,
+		youtubeService,
+		downloadService,
+		tiktokService,
+	)
+	transferMeter := usecase.NewTransferMeter(cfg, transferRepo)
+	videoProcessor.SetTransferMeter(transferMeter)
+	videoProcessor.SetHookRunner(hooks.NewRunner(httpClient))
+	videoProcessor.SetReauthAlerter(reauthAlerter)
+	videoProcessor.SetFailureTracker(failureTracker)
+	videoProcessor.SetPipelineSwitch(pipelineSwitch)
+	videoProcessor.SetAccountEventLog(accountEvents)
+	if translator != nil {
+		videoProcessor.SetTranslator(translator)
+		logger.Info().Printf("Caption translation enabled via %s", translator.Name())
+	}
+	webSessionManager := usecase.NewWebSessionManager(cfg, accountRepo, sqliterepo.NewWebSessionRepository(db), tiktokService)
+	webSessionManager.SetNotifier(notifier)
+	videoProcessor.SetWebSessionManager(webSessionManager)
+	contentSafety := usecase.NewContentSafety(cfg, moderation.NewModerator(cfg, httpClient), downloadService)
+	videoProcessor.SetContentSafety(contentSafety)
+	downloadService.SetProtectedFiles(func() []string {
+		files, err := videoProcessor.PublishingFiles(context.Background())
+		if err != nil {
+			logger.Error().Printf("Failed to list files of publishing videos: %v", err)
+		}
+		return files
+	})
+
+	transactor := sqliterepo.NewTransactor(db)
+	accountMonitor.SetTransactor(transactor)
+	videoAdmin := usecase.NewVideoAdmin(videoRepo, sqliterepo.NewAuditRepository(db), videoProcessor)
+	videoAdmin.SetTransactor(transactor)
+	accountMonitor.SetFailureTracker(failureTracker)
+	accountMonitor.SetNotifier(notifier)
+
+	// Database, yt-dlp, disk and credentials are needed for every upload; the API checks only
+	// report the outcome of the last real call
+	healthChecker := usecase.NewHealthChecker(cfg)
+	healthChecker.Add(usecase.HealthCheckDatabase, true, func(ctx context.Context) (string, error) {
+		return "ok", sqliterepo.Ping(ctx, db)
+	})
+	healthChecker.Add(usecase.HealthCheckYtDlp, true, func(context.Context) (string, error) {
+		return downloadService.CheckYtDlp()
+	})
+	healthChecker.Add(usecase.HealthCheckDiskSpace, true, func(context.Context) (string, error) {
+		return downloadService.CheckFreeSpace()
+	})
+	healthChecker.Add(usecase.HealthCheckTikTokCredentials, true, usecase.TikTokCredentialsCheck(cfg))
+	healthChecker.Add(usecase.HealthCheckYouTubeSources, false, usecase.SourceHealthCheck(accountRepo))
+	if metrics := httpClient.Metrics(); metrics != nil {
+		healthChecker.Add(usecase.HealthCheckYouTubeAPI, false, usecase.UpstreamHealthCheck(metrics, "youtube"))
+		healthChecker.Add(usecase.HealthCheckTikTokAPI, false, usecase.UpstreamHealthCheck(metrics, "tiktok"))
+	}
+
+	return &app{
+		db:             db,
+		httpClient:     httpClient,
+		videoRepo:      videoRepo,
+		oauthStateRepo: oauthStateRepo,
+		youtubeService: youtubeService,
+		tiktokService:  tiktokService,
+
+		accountManager:      accountManager,
+		inviteManager:       inviteManager,
+		accountBootstrapper: accountBootstrapper,
+		clipManager:         usecase.NewClipManager(videoRepo),
+		experimentManager:   usecase.NewExperimentManager(experimentRepo, videoRepo, accountRepo),
+		publicPageManager:   usecase.NewPublicPageManager(cfg, accountRepo, videoRepo),
+		transferMeter:       transferMeter,
+		accountMonitor:      accountMonitor,
+		videoProcessor:      videoProcessor,
+		webSessionManager:   webSessionManager,
+		pipelineSwitch:      pipelineSwitch,
+		videoAdmin:          videoAdmin,
+		healthChecker:       healthChecker,
+		contentSafety:       contentSafety,
+		dbMaintenance:       usecase.NewDatabaseMaintenance(cfg, videoRepo, accountRepo, sqliterepo.NewMaintainer(db)),
+	}, nil
+}
+
+// Close closes the database
+func (a *app) Close() error {
+	return a.db.Close()
+}
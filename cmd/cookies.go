@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"auto_upload_tiktok/config"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/logger"
+	sqliterepo "auto_upload_tiktok/internal/repository/sqlite"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// runCookies dispatches the cookies subcommands
+func runCookies(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("cookies needs a subcommand: import")
+	}
+	switch args[0] {
+	case "import":
+		return runCookiesImport(args[1:])
+	default:
+		return fmt.Errorf("unknown cookies subcommand %q", args[0])
+	}
+}
+
+// runCookiesImport checks an exported cookies file against TikTok and stores it as the web
+// upload cookies
+func runCookiesImport(args []string) error {
+	fs := flag.NewFlagSet("cookies import", flag.ExitOnError)
+	file := fs.String("file", "", "JSON cookie export to import (required)")
+	accountID := fs.String("account", "", "Account mapping the cookies are for; refused if they belong to another TikTok account (optional)")
+	force := fs.Bool("force", false, "Import the cookies even if they belong to another TikTok account than -account posts to")
+	fs.Parse(args)
+
+	if *file == "" {
+		return usageError(fs, "-file is required")
+	}
+
+	cfg, closeLogs := setup()
+	defer closeLogs()
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("failed to read cookies: %w", err)
+	}
+
+	db, err := sqliterepo.Open(cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	session, err := newWebSessionManager(cfg, db).ImportCookies(context.Background(), *accountID, data, *force)
+	if errors.Is(err, usecase.ErrWebSessionMismatch) {
+		return fmt.Errorf("%w (use -force to import anyway)", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info().Printf("Imported cookies of @%s (%s) to %s", session.Username, session.Nickname, session.CookiesPath)
+	return nil
+}
+
+// newWebSessionManager builds the cookie checks for the commands that store web upload cookies
+func newWebSessionManager(cfg *config.Config, db *sql.DB) *usecase.WebSessionManager {
+	tiktokService := tiktok.NewService(cfg, httpclient.NewHTTPClient(cfg))
+	return usecase.NewWebSessionManager(cfg, sqliterepo.NewAccountRepository(db), sqliterepo.NewWebSessionRepository(db), tiktokService)
+}
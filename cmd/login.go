@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/logger"
+	sqliterepo "auto_upload_tiktok/internal/repository/sqlite"
+)
+
+// runLogin opens a visible browser to sign in to TikTok and saves the web upload cookies.
+// The cookies file is shared by every account; with -account the login is checked against the
+// TikTok account the mapping posts to before the cookies are saved.
+func runLogin(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	accountID := fs.String("account", "", "Account mapping whose TikTok login to sign in with (optional)")
+	force := fs.Bool("force", false, "Save the cookies even if they belong to another TikTok account than -account posts to")
+	fs.Parse(args)
+
+	cfg, closeLogs := setup()
+	defer closeLogs()
+
+	logger.Info().Println("Starting interactive login mode...")
+
+	if cfg.TikTokCookiesPath == "" {
+		return errors.New("tiktok.cookies_path is not set in config.yaml")
+	}
+
+	db, err := sqliterepo.Open(cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if *accountID != "" {
+		account, err := sqliterepo.NewAccountRepository(db).GetByID(ctx, *accountID)
+		if err != nil {
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+		if account == nil {
+			return fmt.Errorf("account %s not found", *accountID)
+		}
+		login := account.TikTokAccountID
+		if account.TikTokDisplayName != "" {
+			login = fmt.Sprintf("%s (%s)", account.TikTokDisplayName, account.TikTokAccountID)
+		}
+		logger.Info().Printf("Sign in as TikTok account %s, which account %s posts to", login, account.ID)
+	}
+
+	// Create web uploader in non-headless mode
+	uploader := tiktok.NewWebUploader(cfg.TikTokCookiesPath, false)
+	uploader.SetProxies(cfg.TikTokProxies)
+
+	data, err := uploader.CaptureLoginCookies(ctx)
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	session, err := newWebSessionManager(cfg, db).ImportCookies(ctx, *accountID, data, *force)
+	if err != nil {
+		return fmt.Errorf("cookies not saved: %w", err)
+	}
+
+	logger.Info().Printf("Login successful as @%s (%s)! Cookies saved. You can now run the tool normally.", session.Username, session.Nickname)
+	return nil
+}
@@ -1,251 +1,152 @@
 package main
 
 import (
-	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
+	"sort"
+	"strings"
 
 	"auto_upload_tiktok/config"
-	"auto_upload_tiktok/internal/delivery/cron"
-	"auto_upload_tiktok/internal/delivery/httpapi"
-	"auto_upload_tiktok/internal/domain"
-	"auto_upload_tiktok/internal/infrastructure/downloader"
-	httpclient "auto_upload_tiktok/internal/infrastructure/http"
-	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
-	"auto_upload_tiktok/internal/infrastructure/youtube"
 	"auto_upload_tiktok/internal/logger"
-	sqliterepo "auto_upload_tiktok/internal/repository/sqlite"
-	"auto_upload_tiktok/internal/usecase"
+	"auto_upload_tiktok/internal/version"
 )
 
-func main() {
-	// Parse command line flags
-	loginMode := flag.Bool("login", false, "Run in interactive login mode to save TikTok cookies")
-	flag.Parse()
+const usageText = `Usage: auto_upload_tiktok [-config path] <command> [flags]
 
-	// Load configuration from YAML file
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
-	}
+Commands:
+  serve                   Run the scheduler and HTTP API (default)
+  login [-account id]     Sign in to TikTok in a browser and save the web upload cookies
+  cookies import          Check and store exported web upload cookies (-file path [-account id])
+  account add             Create an account mapping (-youtube channel -token token [-tiktok account])
+  account list            List account mappings
+  video enqueue           Queue a YouTube video for an account (-account id -id youtube_video_id
+                          [-privacy level -disable-comment -disable-duet -disable-stitch])
+  video export            Write the video history as NDJSON or CSV (-o file [-format csv -status s -cursor id])
+  process-once            Run one monitoring and processing pass, then exit
+  version                 Print the version, commit, build date and Go version (also -version)
 
-	if _, err := logger.Initialize(cfg); err != nil {
-		log.Fatalf("Failed to initialize logging: %v", err)
-	}
-	defer func() {
-		if err := logger.Close(); err != nil {
-			log.Printf("Failed to close log files: %v", err)
-		}
-	}()
+account and video enqueue take -remote host:port or -remote unix:///path/to.sock to call a
+running serve's HTTP API instead of opening the database.
 
-	// Handle login mode
-	if *loginMode {
-		handleLoginMode(cfg)
-		return
-	}
+Without -config, config/config.yaml is used if it exists, else config.yaml. YAML files in a
+config.d directory next to the config file are merged over it in name order.
 
-	// Validate required configuration
-	if cfg.YouTubeAPIKey == "" {
-		logger.Error().Fatal("YOUTUBE_API_KEY is required")
-	}
-	if cfg.TikTokAPIKey == "" {
-		logger.Error().Fatal("TIKTOK_API_KEY is required")
+Run "auto_upload_tiktok <command> -h" for the flags of a command.
+`
+
+func main() {
+	configPath, args, err := configFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n%s", err, usageText)
+		os.Exit(2)
 	}
-	if cfg.TikTokAPISecret == "" {
-		logger.Error().Fatal("TIKTOK_API_SECRET is required")
+	if configPath != "" {
+		config.UseConfigFile(configPath)
 	}
 
-	// Initialize HTTP client
-	httpClient := httpclient.NewHTTPClient(cfg)
+	// Flags without a command (e.g. the old -login) keep meaning serve
+	command := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	} else if len(args) > 0 && (args[0] == "-version" || args[0] == "--version") {
+		command = "version"
+	}
 
-	// Initialize persistent repositories
-	db, err := sqliterepo.Open(cfg.DatabaseURL)
+	switch command {
+	case "serve":
+		err = runServe(args)
+	case "login":
+		err = runLogin(args)
+	case "cookies":
+		err = runCookies(args)
+	case "account":
+		err = runAccount(args)
+	case "video":
+		err = runVideo(args)
+	case "process-once":
+		err = runProcessOnce(args)
+	case "version":
+		fmt.Println(version.Get())
+	case "help":
+		fmt.Print(usageText)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", command, usageText)
+		os.Exit(2)
+	}
 	if err != nil {
-		logger.Error().Fatalf("Failed to open database: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-	defer db.Close()
-
-	accountRepo := sqliterepo.NewAccountRepository(db)
-	videoRepo := sqliterepo.NewVideoRepository(db)
+}
 
-	// Initialize services
-	youtubeService := youtube.NewService(cfg, httpClient)
-	downloadService, err := downloader.NewService(cfg, httpClient)
+// setup loads the configuration and starts logging; the returned function closes the log files
+func setup() (*config.Config, func()) {
+	// Load configuration from YAML file
+	cfg, err := config.Load()
 	if err != nil {
-		logger.Error().Fatalf("Failed to create download service: %v", err)
-	}
-	tiktokService := tiktok.NewService(cfg, httpClient)
-
-	// Initialize use cases
-	accountManager := usecase.NewAccountManager(accountRepo)
-
-	bootstrapAccounts(cfg, accountManager, accountRepo)
-	accountMonitor := usecase.NewAccountMonitor(cfg, accountRepo, videoRepo, youtubeService)
-	videoProcessor := usecase.NewVideoProcessor(
-		cfg,
-		videoRepo,
-		accountRepo,
-		youtubeService,
-		downloadService,
-		tiktokService,
-	)
-
-	// Set video processor in account monitor for immediate processing
-	accountMonitor.SetVideoProcessor(videoProcessor)
-
-	// Initialize and start cron scheduler
-	scheduler := cron.NewScheduler(cfg, accountMonitor, videoProcessor)
-	if err := scheduler.Start(); err != nil {
-		logger.Error().Fatalf("Failed to start scheduler: %v", err)
+		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Start HTTP API server for runtime management
-	apiServer := httpapi.NewServer(cfg, accountManager, videoRepo, tiktokService)
-	if err := apiServer.Start(); err != nil {
-		logger.Error().Fatalf("Failed to start HTTP API server: %v", err)
+	if _, err := logger.Initialize(cfg); err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
 	}
 
-	// Wait for interrupt signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	logger.Info().Println("Application started. Press Ctrl+C to stop.")
-	<-sigChan
-
-	// Graceful shutdown
-	logger.Info().Println("Shutting down...")
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	scheduler.Stop()
-	if err := apiServer.Shutdown(shutdownCtx); err != nil {
-		logger.Error().Printf("HTTP API shutdown error: %v", err)
+	overrides := config.GetManager().EnvOverrides()
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
 	}
-	logger.Info().Println("Application stopped.")
-}
-
-func bootstrapAccounts(cfg *config.Config, accountManager *usecase.AccountManager, repo domain.AccountRepository) {
-	if len(cfg.BootstrapAccounts) == 0 {
-		return
+	sort.Strings(keys)
+	for _, key := range keys {
+		logger.Info().Printf("Config %s is set from %s", key, overrides[key])
+	}
+	dropIns := config.GetManager().DropInOverrides()
+	keys = keys[:0]
+	for key := range dropIns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		logger.Info().Printf("Config %s is set from %s", key, dropIns[key])
 	}
 
-	for _, acc := range cfg.BootstrapAccounts {
-		// Validate required fields (token is optional - can be set via exchange-code API)
-		if acc.YouTubeChannelID == "" || acc.TikTokAccountID == "" {
-			logger.Error().Printf("Skipping invalid bootstrap mapping (missing YouTubeChannelID or TikTokAccountID): %+v", acc)
-			continue
-		}
-		// Token is optional - if missing, we'll use placeholder and user must update via exchange-code API
-
-		var existing *domain.Account
-		var err error
-
-		if acc.TikTokAccountID != "" {
-			existing, err = repo.GetByTikTokAccountID(acc.TikTokAccountID)
-			if err != nil {
-				logger.Error().Printf("Failed to lookup TikTok account %s: %v", acc.TikTokAccountID, err)
-				continue
-			}
-		}
-		if existing == nil && acc.YouTubeChannelID != "" {
-			existing, err = repo.GetByYouTubeChannelID(acc.YouTubeChannelID)
-			if err != nil {
-				logger.Error().Printf("Failed to lookup YouTube channel %s: %v", acc.YouTubeChannelID, err)
-				continue
-			}
+	return cfg, func() {
+		if err := logger.Close(); err != nil {
+			log.Printf("Failed to close log files: %v", err)
 		}
+	}
+}
 
-		if existing == nil {
-			// Create account even without token - token can be set later via exchange-code API
-			// But CreateAccountMapping requires a token, so we'll use a placeholder
-			token := acc.TikTokAccessToken
-			if token == "" {
-				// Use placeholder token - user must update via exchange-code API
-				token = "PLACEHOLDER_TOKEN_UPDATE_VIA_EXCHANGE_CODE_API"
-				logger.Info().Printf("Creating account for channel %s without token. Token must be set via exchange-code API.", acc.YouTubeChannelID)
-			}
-			account, err := accountManager.CreateAccountMapping(acc.YouTubeChannelID, acc.TikTokAccountID, token)
-			if err != nil {
-				logger.Error().Printf("Failed to bootstrap mapping for channel %s: %v", acc.YouTubeChannelID, err)
-				continue
-			}
-			logger.Info().Printf("Bootstrapped mapping %s -> %s (Note: Token from config has no refresh token. Use exchange-code API to get refresh token.)", acc.YouTubeChannelID, acc.TikTokAccountID)
-			if acc.IsActive != nil && !*acc.IsActive {
-				if err := accountManager.DeactivateAccountMapping(account.ID); err != nil {
-					logger.Error().Printf("Failed to deactivate mapping for channel %s: %v", acc.YouTubeChannelID, err)
-				}
-			}
+// configFlag takes the global -config flag (-config path, --config=path) out of the arguments,
+// wherever it appears, and returns its value with the remaining arguments
+func configFlag(args []string) (string, []string, error) {
+	var path string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "-config" && name != "--config" {
+			rest = append(rest, args[i])
 			continue
 		}
-
-		youtubeID := ""
-		tiktokID := ""
-		token := ""
-		var activePtr *bool
-		needsUpdate := false
-
-		if acc.YouTubeChannelID != "" && acc.YouTubeChannelID != existing.YouTubeChannelID {
-			youtubeID = acc.YouTubeChannelID
-			needsUpdate = true
-		}
-		if acc.TikTokAccountID != "" && acc.TikTokAccountID != existing.TikTokAccountID {
-			tiktokID = acc.TikTokAccountID
-			needsUpdate = true
-		}
-		// Only update token from config if:
-		// 1. Account has no token in database, OR
-		// 2. Account has no refresh token (old token that can't be refreshed)
-		// This prevents overwriting tokens that were updated via API exchange code
-		if acc.TikTokAccessToken != "" && acc.TikTokAccessToken != existing.TikTokAccessToken {
-			if existing.TikTokAccessToken == "" {
-				// No token in database, use config token
-				token = acc.TikTokAccessToken
-				needsUpdate = true
-			} else if existing.TikTokRefreshToken == "" {
-				// Has token but no refresh token, update with config token
-				// (but this is still not ideal - better to use exchange code API)
-				logger.Info().Printf("Account %s has token but no refresh token. Consider using exchange-code API to get a refresh token instead of config token.", existing.ID)
-				// Don't update from config - let user use exchange-code API instead
-				// token = acc.TikTokAccessToken
-				// needsUpdate = true
-			} else {
-				// Account has token with refresh token - don't overwrite with config
-				// Token from database (obtained via API) takes precedence
-				logger.Info().Printf("Account %s already has token with refresh token. Skipping token update from config. Use exchange-code API if token needs updating.", existing.ID)
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("flag %s needs a path", name)
 			}
+			i++
+			value = args[i]
 		}
-		if acc.IsActive != nil && existing.IsActive != *acc.IsActive {
-			activePtr = acc.IsActive
-			needsUpdate = true
-		}
-
-		if needsUpdate {
-			if _, err := accountManager.UpdateAccountMapping(existing.ID, youtubeID, tiktokID, token, activePtr); err != nil {
-				logger.Error().Printf("Failed to update bootstrap mapping for channel %s: %v", existing.YouTubeChannelID, err)
-			} else {
-				logger.Info().Printf("Updated bootstrap mapping %s -> %s", existing.YouTubeChannelID, existing.TikTokAccountID)
-			}
+		if value == "" {
+			return "", nil, fmt.Errorf("flag %s needs a path", name)
 		}
+		path = value
 	}
+	return path, rest, nil
 }
 
-func handleLoginMode(cfg *config.Config) {
-	logger.Info().Println("Starting interactive login mode...")
-
-	if cfg.TikTokCookiesPath == "" {
-		logger.Error().Fatal("tiktok.cookies_path is not set in config.yaml")
-	}
-
-	// Create web uploader in non-headless mode
-	uploader := tiktok.NewWebUploader(cfg.TikTokCookiesPath, false)
-
-	ctx := context.Background()
-	if err := uploader.LoginAndSaveCookies(ctx); err != nil {
-		logger.Error().Fatalf("Login failed: %v", err)
-	}
-
-	logger.Info().Println("Login successful! Cookies saved. You can now run the tool normally.")
+// usageError reports a missing or invalid flag together with the command's flags
+func usageError(fs *flag.FlagSet, format string, args ...any) error {
+	fs.Usage()
+	return fmt.Errorf(format, args...)
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"auto_upload_tiktok/internal/delivery/cron"
+)
+
+// runProcessOnce runs one monitoring pass and one processing pass with the cron jobs' time
+// limits, then exits, for running under an external scheduler such as a systemd timer.
+// SIGINT or SIGTERM cancels the pass; interrupted videos go back to pending.
+func runProcessOnce(args []string) error {
+	fs := flag.NewFlagSet("process-once", flag.ExitOnError)
+	skipMonitor := fs.Bool("skip-monitor", false, "Only process videos already queued")
+	fs.Parse(args)
+
+	cfg, closeLogs := setup()
+	defer closeLogs()
+
+	if err := requireAPIKeys(cfg); err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The monitor does not start processing itself; the processing pass below picks up new videos
+	var errs []error
+	if !*skipMonitor {
+		monitorCtx, cancel := context.WithTimeout(ctx, cron.MonitorTimeout)
+		if err := a.accountMonitor.MonitorAllAccounts(monitorCtx); err != nil {
+			errs = append(errs, fmt.Errorf("account monitoring failed: %w", err))
+		}
+		// Videos held for min_views that got there are processed in this run too
+		if _, err := a.accountMonitor.CheckViewGates(monitorCtx); err != nil {
+			errs = append(errs, fmt.Errorf("view gate check failed: %w", err))
+		}
+		cancel()
+	}
+
+	processCtx, cancel := context.WithTimeout(ctx, cron.ProcessTimeout)
+	defer cancel()
+	if err := a.videoProcessor.ProcessPendingVideos(processCtx); err != nil {
+		errs = append(errs, fmt.Errorf("video processing failed: %w", err))
+	}
+
+	// Uploads still publishing stay so until a later run or the server sees their outcome
+	publishCtx, cancelPublish := context.WithTimeout(ctx, cron.PublishCheckTimeout)
+	defer cancelPublish()
+	if err := a.videoProcessor.CheckPublishing(publishCtx); err != nil {
+		errs = append(errs, fmt.Errorf("publish status check failed: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
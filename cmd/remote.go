@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/config"
+)
+
+// remoteTimeout bounds one call to a running instance's API
+const remoteTimeout = 2 * time.Minute
+
+// remoteFlagUsage documents the -remote flag of the commands that can call a running instance
+const remoteFlagUsage = "Call the HTTP API of a running serve at host:port, http(s)://host:port or unix:///path/to.sock instead of opening the database"
+
+// remoteClient calls the HTTP API of a running serve, over TCP or the Unix socket of server.listen
+type remoteClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// newRemoteClient parses the -remote address: host:port, an http(s) URL or unix:///path/to.sock
+func newRemoteClient(addr string) (*remoteClient, error) {
+	if path, ok := strings.CutPrefix(addr, config.UnixListenPrefix); ok {
+		if path == "" {
+			return nil, fmt.Errorf("-remote %q: the socket path is missing", addr)
+		}
+		var dialer net.Dialer
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		}
+		// The host is ignored when dialing the socket
+		return &remoteClient{baseURL: "http://unix", client: &http.Client{Transport: transport, Timeout: remoteTimeout}}, nil
+	}
+
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("-remote %q: want host:port, http(s)://host:port or %s/path/to.sock", addr, config.UnixListenPrefix)
+	}
+	return &remoteClient{baseURL: strings.TrimRight(u.String(), "/"), client: &http.Client{Timeout: remoteTimeout}}, nil
+}
+
+// call sends body (if not nil) as JSON to the API path and decodes the response into out. Error
+// responses return the API's error message.
+func (c *remoteClient) call(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s %s: %s", method, path, apiErr.Error)
+		}
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/delivery/cron"
+	"auto_upload_tiktok/internal/delivery/httpapi"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// shutdownFlushTimeout is how long cancelled videos get to record their status after the grace period
+const shutdownFlushTimeout = 15 * time.Second
+
+// runServe runs the scheduler and the HTTP API until SIGINT or SIGTERM
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	loginMode := fs.Bool("login", false, "Deprecated: use the login command")
+	fs.Parse(args)
+
+	// Handle login mode
+	if *loginMode {
+		return runLogin(fs.Args())
+	}
+
+	cfg, closeLogs := setup()
+	defer closeLogs()
+
+	// Validate required configuration
+	if err := requireAPIKeys(cfg); err != nil {
+		logger.Error().Fatal(err)
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		logger.Error().Fatalf("%v", err)
+	}
+	defer a.Close()
+
+	// New videos are marked for the dispatcher instead of waiting for the processing job
+	dispatcher := usecase.NewImmediateDispatcher(cfg, a.videoRepo, a.videoProcessor)
+	a.accountMonitor.SetDispatcher(dispatcher)
+
+	// Videos a killed process left mid-step would otherwise never be picked up again
+	if _, err := a.videoProcessor.RequeueInterrupted(context.Background()); err != nil {
+		logger.Error().Printf("%v", err)
+	}
+
+	// Initialize and start cron scheduler
+	scheduler := cron.NewScheduler(cfg, a.accountMonitor, a.videoProcessor)
+	scheduler.SetDispatcher(dispatcher)
+	scheduler.SetPipelineSwitch(a.pipelineSwitch)
+	scheduler.SetDatabaseMaintenance(a.dbMaintenance)
+	if err := scheduler.Start(); err != nil {
+		logger.Error().Fatalf("Failed to start scheduler: %v", err)
+	}
+
+	// Start HTTP API server for runtime management, unless the CLI alone manages this instance
+	var apiServer *httpapi.Server
+	if cfg.ServerEnabled {
+		apiServer = newAPIServer(cfg, a, scheduler)
+		if err := apiServer.Start(); err != nil {
+			logger.Error().Fatalf("Failed to start HTTP API server: %v", err)
+		}
+	} else {
+		logger.Info().Println("HTTP API disabled (server.enabled: false); manage accounts and videos with the CLI commands")
+	}
+
+	// Wait for interrupt signal
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	logger.Info().Println("Application started. Press Ctrl+C to stop.")
+	<-sigChan
+
+	// Graceful shutdown: stop accepting work, then let in-flight downloads/uploads finish
+	logger.Info().Println("Shutting down...")
+	inFlight := scheduler.Stop()
+
+	// The API stops taking connections now; requests already running get the same grace period
+	graceCtx, cancelGrace := context.WithTimeout(context.Background(), cfg.ShutdownGrace)
+	defer cancelGrace()
+	apiDone := make(chan error, 1)
+	go func() {
+		var err error
+		if apiServer != nil {
+			err = apiServer.Shutdown(graceCtx)
+		}
+		apiDone <- err
+	}()
+
+	if inFlight > 0 {
+		logger.Info().Printf("Waiting up to %s for %d in-flight videos...", cfg.ShutdownGrace, inFlight)
+	}
+	if err := a.videoProcessor.WaitForIdle(graceCtx); err != nil {
+		abandoned := a.videoProcessor.InFlight()
+		logger.Info().Printf("Grace period over, cancelling %d videos; they go back to pending", abandoned)
+		scheduler.CancelWork()
+
+		// Cancelled videos record their resumable status before the process exits
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+		if err := a.videoProcessor.WaitForIdle(flushCtx); err != nil {
+			logger.Error().Printf("%d videos did not record their status within %s; they are requeued on next start", a.videoProcessor.InFlight(), shutdownFlushTimeout)
+		}
+		cancelFlush()
+		logger.Info().Printf("Shutdown drain finished: %d completed, %d interrupted", max(inFlight-abandoned, 0), abandoned)
+	} else {
+		logger.Info().Printf("Shutdown drain finished: %d completed", inFlight)
+	}
+
+	if err := <-apiDone; err != nil {
+		logger.Error().Printf("HTTP API shutdown error: %v", err)
+	}
+	logger.Info().Println("Application stopped.")
+	return nil
+}
+
+// newAPIServer creates the HTTP API server with the app's optional features
+func newAPIServer(cfg *config.Config, a *app, scheduler *cron.Scheduler) *httpapi.Server {
+	apiServer := httpapi.NewServer(cfg, a.accountManager, a.videoRepo, a.tiktokService)
+	apiServer.SetInviteManager(a.inviteManager)
+	apiServer.SetAccountBootstrapper(a.accountBootstrapper, config.GetManager())
+	apiServer.SetClipManager(a.clipManager)
+	apiServer.SetExperimentManager(a.experimentManager)
+	apiServer.SetAccountMonitor(a.accountMonitor)
+	apiServer.SetPublicPageManager(a.publicPageManager)
+	apiServer.SetTransferMeter(a.transferMeter)
+	apiServer.SetOAuthStateRepository(a.oauthStateRepo)
+	apiServer.SetYouTubeService(a.youtubeService)
+	apiServer.SetScheduler(scheduler)
+	apiServer.SetDatabaseMaintenance(a.dbMaintenance)
+	apiServer.SetFileReconciler(usecase.NewFileReconciler(cfg, a.videoRepo))
+	apiServer.SetUpstreamMetrics(a.httpClient.Metrics())
+	apiServer.SetWebSessionManager(a.webSessionManager)
+	apiServer.SetPipelineSwitch(a.pipelineSwitch)
+	apiServer.SetVideoAdmin(a.videoAdmin)
+	apiServer.SetUploadPreviewer(a.videoProcessor)
+	apiServer.SetHealthChecker(a.healthChecker)
+	apiServer.SetContentSafety(a.contentSafety)
+	return apiServer
+}
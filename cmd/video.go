@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"auto_upload_tiktok/internal/delivery/httpapi"
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+	sqliterepo "auto_upload_tiktok/internal/repository/sqlite"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// runVideo dispatches the video subcommands
+func runVideo(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("video needs a subcommand: enqueue or export")
+	}
+	switch args[0] {
+	case "enqueue":
+		return runVideoEnqueue(args[1:])
+	case "export":
+		return runVideoExport(args[1:])
+	default:
+		return fmt.Errorf("unknown video subcommand %q (want enqueue or export)", args[0])
+	}
+}
+
+// runVideoEnqueue queues one YouTube video for an account; the next processing run uploads it
+func runVideoEnqueue(args []string) error {
+	fs := flag.NewFlagSet("video enqueue", flag.ExitOnError)
+	accountID := fs.String("account", "", "Account mapping to upload with (required)")
+	youtubeVideoID := fs.String("id", "", "YouTube video ID (required)")
+	privacy := fs.String("privacy", "", "TikTok privacy level for this video, overriding the account's")
+	disableComment := fs.Bool("disable-comment", false, "Turn comments off (or on with =false) for this video")
+	disableDuet := fs.Bool("disable-duet", false, "Turn duets off (or on with =false) for this video")
+	disableStitch := fs.Bool("disable-stitch", false, "Turn stitches off (or on with =false) for this video")
+	remote := fs.String("remote", "", remoteFlagUsage)
+	fs.Parse(args)
+	if *accountID == "" || *youtubeVideoID == "" {
+		return usageError(fs, "-account and -id are required")
+	}
+
+	// Only the flags given override the account's post settings
+	options := &domain.PostOptions{PrivacyLevel: *privacy}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "disable-comment":
+			options.DisableComment = disableComment
+		case "disable-duet":
+			options.DisableDuet = disableDuet
+		case "disable-stitch":
+			options.DisableStitch = disableStitch
+		}
+	})
+
+	if *remote != "" {
+		client, err := newRemoteClient(*remote)
+		if err != nil {
+			return err
+		}
+		body := &httpapi.EnqueueVideoRequest{YouTubeVideoID: *youtubeVideoID, PostOptions: options}
+		path := "/api/accounts/" + url.PathEscape(*accountID) + "/videos"
+		return client.call(context.Background(), http.MethodPost, path, body, nil)
+	}
+
+	cfg, closeLogs := setup()
+	defer closeLogs()
+
+	db, err := sqliterepo.Open(cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	youtubeService := youtube.NewService(cfg, httpclient.NewHTTPClient(cfg))
+	accountMonitor := usecase.NewAccountMonitor(cfg, sqliterepo.NewAccountRepository(db), sqliterepo.NewVideoRepository(db), youtubeService)
+	video, err := accountMonitor.EnqueueVideo(context.Background(), *accountID, *youtubeVideoID, options)
+	if err != nil {
+		return err
+	}
+
+	logger.Info().Printf("Queued video %s (%s) for account %s", video.YouTubeVideoID, video.Title, video.AccountID)
+	return nil
+}
+
+// runVideoExport writes the video history to a file in the format of GET /api/videos/export.
+// With -cursor the export resumes after that video ID and is appended to the file.
+func runVideoExport(args []string) error {
+	fs := flag.NewFlagSet("video export", flag.ExitOnError)
+	format := fs.String("format", httpapi.ExportNDJSON, "Output format: ndjson or csv")
+	status := fs.String("status", "", "Only export videos with this status")
+	cursor := fs.String("cursor", "", "Resume after this video ID, appending to the file")
+	output := fs.String("o", "", "File to write (required)")
+	fs.Parse(args)
+	if *output == "" {
+		return usageError(fs, "-o is required")
+	}
+	if *format != httpapi.ExportNDJSON && *format != httpapi.ExportCSV {
+		return usageError(fs, "-format must be ndjson or csv")
+	}
+	filter := domain.VideoFilter{Status: domain.VideoStatus(*status)}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return usageError(fs, "invalid -status %q", *status)
+	}
+
+	cfg, closeLogs := setup()
+	defer closeLogs()
+
+	db, err := sqliterepo.Open(cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if *cursor != "" {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(*output, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", *output, err)
+	}
+
+	count, err := httpapi.ExportVideos(context.Background(), sqliterepo.NewVideoRepository(db), file, *format, filter, *cursor, nil)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("export stopped after %d videos: %w", count, err)
+	}
+
+	logger.Info().Printf("Exported %d videos to %s", count, *output)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,47 +14,189 @@ import (
 // Config holds all application configuration
 type Config struct {
 	// Server configuration
-	ServerPort string `yaml:"server.port"`
+	ServerPort      string `yaml:"server.port"`
+	ServerPublicURL string `yaml:"server.public_url"` // Base URL used in links handed to account owners
+	// ServerEnabled starts the HTTP API; when off the CLI commands work on the database directly
+	ServerEnabled bool `yaml:"server.enabled"`
+	// ServerListen is the API's host:port or unix:///path/to.sock; empty listens on server.port
+	ServerListen string `yaml:"server.listen"`
+	// ShutdownGrace is how long shutdown waits for in-flight downloads/uploads
+	ShutdownGrace    time.Duration `yaml:"-"`
+	ShutdownGraceStr string        `yaml:"server.shutdown_grace"`
+	// WriteRetryBudget is how long API writes retry while the database is locked before answering 503
+	WriteRetryBudget    time.Duration `yaml:"-"`
+	WriteRetryBudgetStr string        `yaml:"server.write_retry_budget"`
+	// PublicPagesEnabled serves the read-only per-account status pages under /public/accounts/
+	PublicPagesEnabled bool `yaml:"server.public_pages"`
+	// AdminToken is the bearer token administrative endpoints (forcing video outcomes) require;
+	// empty disables them
+	AdminToken string `yaml:"server.admin_token"`
+	// ServerLocale is the web UI language for browsers whose Accept-Language names no
+	// supported one (en, vi, ja)
+	ServerLocale string `yaml:"server.locale"`
+	// TrustedProxies are the reverse proxies (IPs, CIDR ranges, or unix for the socket listener)
+	// whose X-Forwarded-For names the client the public pages rate limit and the audit log record
+	TrustedProxies []string `yaml:"server.trusted_proxies"`
 
 	// YouTube API configuration
-	YouTubeAPIKey string `yaml:"youtube.api_key"`
+	YouTubeAPIKey        string `yaml:"youtube.api_key"`
+	YouTubeDiscoveryMode string `yaml:"youtube.discovery_mode"` // api (Data API) or rss (quota-free feed)
+
+	// YouTubeQuotaCooloff is how long monitoring pauses after the Data API reports exhausted quota;
+	// 0 pauses until the daily quota reset at midnight Pacific time
+	YouTubeQuotaCooloff    time.Duration `yaml:"-"`
+	YouTubeQuotaCooloffStr string        `yaml:"youtube.quota_cooloff"`
+
+	// YouTubeStatusInterval is how often each channel's status is checked with channels.list,
+	// and how often a suspended, terminated or private channel is checked again instead of being
+	// monitored; 0 disables the checks
+	YouTubeStatusInterval    time.Duration `yaml:"-"`
+	YouTubeStatusIntervalStr string        `yaml:"youtube.channel_status_interval"`
+
+	// YouTube OAuth client (scope youtube.force-ssl) for accounts that update their YouTube
+	// descriptions with the TikTok link; empty disables the feature
+	YouTubeOAuthClientID     string `yaml:"youtube.oauth_client_id"`
+	YouTubeOAuthClientSecret string `yaml:"youtube.oauth_client_secret"`
+	YouTubeRedirectURI       string `yaml:"youtube.redirect_uri"` // Defaults to /api/youtube/callback on this server
 
 	// TikTok API configuration
-	TikTokAPIKey         string `yaml:"tiktok.api_key"`
-	TikTokAPISecret      string `yaml:"tiktok.api_secret"`
-	TikTokRegion         string `yaml:"tiktok.region"`
-	TikTokBaseURL        string `yaml:"tiktok.base_url"`
-	TikTokUploadInitPath string `yaml:"tiktok.upload_init_path"`
-	TikTokPublishPath    string `yaml:"tiktok.publish_path"`
-	TikTokRedirectURI    string `yaml:"tiktok.redirect_uri"` // OAuth redirect URI
-	TikTokEnableWeb      bool   `yaml:"tiktok.enable_web"`   // Enable web upload via browser automation
-	TikTokCookiesPath    string `yaml:"tiktok.cookies_path"` // Path to cookies file for web upload
+	TikTokAPIKey    string `yaml:"tiktok.api_key"`
+	TikTokAPISecret string `yaml:"tiktok.api_secret"`
+	// TikTokApps are extra named credential sets for accounts authorized under other developer apps
+	TikTokApps            []TikTokApp `yaml:"tiktok.apps"`
+	TikTokRegion          string      `yaml:"tiktok.region"`
+	TikTokBaseURL         string      `yaml:"tiktok.base_url"`
+	TikTokUploadInitPath  string      `yaml:"tiktok.upload_init_path"`
+	TikTokPublishPath     string      `yaml:"tiktok.publish_path"`
+	TikTokInboxInitPath   string      `yaml:"tiktok.inbox_init_path"`   // v2 inbox (draft) upload init
+	TikTokUploadMethod    string      `yaml:"tiktok.upload_method"`     // multipart or put; init response hints take precedence
+	TikTokUploadFieldName string      `yaml:"tiktok.upload_field_name"` // Multipart file field name
+	TikTokRedirectURI     string      `yaml:"tiktok.redirect_uri"`      // OAuth redirect URI
+	TikTokEnableWeb       bool        `yaml:"tiktok.enable_web"`        // Enable web upload via browser automation
+	TikTokCookiesPath     string      `yaml:"tiktok.cookies_path"`      // Path to cookies file for web upload
+	TikTokProxy           string      `yaml:"tiktok.proxy"`             // Proxy of the TikTok API and web uploader; empty uses performance.proxy
+
+	// TikTokPublishStatusPath is the v2 endpoint used to check an interrupted upload before retrying it
+	TikTokPublishStatusPath string `yaml:"tiktok.publish_status_path"`
+	// TikTokCreatorInfoPath is the v2 endpoint reporting the privacy levels and interaction
+	// settings a creator can post with, checked before each API post
+	TikTokCreatorInfoPath string `yaml:"tiktok.creator_info_path"`
+
+	// Post-publish comment configuration
+	TikTokCommentPath           string        `yaml:"tiktok.comment_path"` // API path for comment creation (empty = web only)
+	TikTokCommentMinInterval    time.Duration `yaml:"-"`
+	TikTokCommentMinIntervalStr string        `yaml:"tiktok.comment_min_interval"`
+
+	// Largest file (bytes) and longest video each upload path accepts, unless an account overrides
+	// them; videos over the preferred path's limits are routed to the other path
+	TikTokAPIMaxSize        int64         `yaml:"tiktok.api_limits.max_size"`
+	TikTokAPIMaxDuration    time.Duration `yaml:"-"`
+	TikTokAPIMaxDurationStr string        `yaml:"tiktok.api_limits.max_duration"`
+	TikTokWebMaxSize        int64         `yaml:"tiktok.web_limits.max_size"`
+	TikTokWebMaxDuration    time.Duration `yaml:"-"`
+	TikTokWebMaxDurationStr string        `yaml:"tiktok.web_limits.max_duration"`
+
+	// Cookies claims: each web upload records this host as the user of the cookies in the
+	// database, since TikTok signs a session out everywhere when it is used from two addresses.
+	// Another host's use within the window is logged and notified, and refused with refuse; a
+	// window of 0 turns the claims off.
+	TikTokCookiesClaimHost      string        `yaml:"tiktok.cookies_claim.host"` // Empty uses the hostname
+	TikTokCookiesClaimWindow    time.Duration `yaml:"-"`
+	TikTokCookiesClaimWindowStr string        `yaml:"tiktok.cookies_claim.window"`
+	TikTokCookiesClaimRefuse    bool          `yaml:"tiktok.cookies_claim.refuse"`
 
 	// Cron schedule configuration
 	CronSchedule string `yaml:"cron.schedule"`
+	CronTimezone string `yaml:"cron.timezone"` // IANA name; empty uses the server's local time
+	// CronMinInterval is the shortest run interval accepted when the schedule is changed through the API
+	CronMinInterval    time.Duration `yaml:"-"`
+	CronMinIntervalStr string        `yaml:"cron.min_interval"`
+
+	// CronFreshWindow raises the queue priority of videos discovered within this long of their
+	// YouTube publish time, so a backlog does not delay them; 0 disables it
+	CronFreshWindow    time.Duration `yaml:"-"`
+	CronFreshWindowStr string        `yaml:"cron.fresh_window"`
 
 	// Download configuration
 	DownloadDir            string        `yaml:"download.dir"`
 	MaxConcurrentDownloads int           `yaml:"download.max_concurrent"`
+	MaxDownloadsPerAccount int           `yaml:"download.max_concurrent_per_account"` // Within max_concurrent, so one busy channel cannot take every slot; 0 = no cap
 	DownloadTimeout        time.Duration `yaml:"-"`
 	DownloadTimeoutStr     string        `yaml:"download.timeout"`
 	YtDlpPath              string        `yaml:"download.yt_dlp_path"`
-	YoutubeCookiesPath     string        `yaml:"download.youtube_cookies_path"`
+	FFmpegPath             string        `yaml:"download.ffmpeg_path"` // Used to cut clips; defaults to ffmpeg on PATH
+	// InvidiousInstances are tried in order when yt-dlp hits bot detection; empty uses the built-in list
+	InvidiousInstances  []string      `yaml:"download.invidious_instances"`
+	InvidiousTimeout    time.Duration `yaml:"-"` // Bounds the URL lookup on each instance
+	InvidiousTimeoutStr string        `yaml:"download.invidious_timeout"`
+	// MinFreeSpace is the free space (bytes) that must remain on the download filesystem; 0 disables the check
+	MinFreeSpace int64 `yaml:"download.min_free_space"`
+	// MaxDownloadDirSize caps the download directory (bytes), removing the oldest downloads first; 0 disables it
+	MaxDownloadDirSize int64  `yaml:"download.max_dir_size"`
+	YoutubeCookiesPath string `yaml:"download.youtube_cookies_path"`
+	// yt-dlp tuning, kept in config because YouTube changes keep breaking it. Per-call
+	// DownloadOptions override these.
+	YtDlpExtraArgs    []string `yaml:"download.ytdlp_extra_args"` // Passed before the format and URL; nil uses the defaults
+	DownloadFormat    string   `yaml:"download.format"`           // yt-dlp -f selector, or a container (mp4) combined with a quality
+	DownloadUserAgent string   `yaml:"download.user_agent"`       // Empty keeps yt-dlp's own and a desktop Chrome UA for fallbacks
+	// DownloadMaxHeight and DownloadFPS cap the resolution (e.g. 1080) and frame rate picked when
+	// download.format is a container; 0 leaves them open. Accounts can override both and the container.
+	DownloadMaxHeight int `yaml:"download.max_height"`
+	DownloadFPS       int `yaml:"download.fps"`
+	// DownloadProxy is a proxy URL or a comma-separated list rotated per yt-dlp download;
+	// empty uses performance.proxy and "direct" connects without one
+	DownloadProxy   string `yaml:"download.proxy"`
+	DownloadRetries int    `yaml:"download.retries"` // yt-dlp --retries and --fragment-retries
+	// DownloadTranscode re-encodes downloads TikTok cannot take (not MP4 with H.264/H.265 and AAC)
+	// with ffmpeg; when off such videos fail
+	DownloadTranscode bool `yaml:"download.transcode"`
+	// Resource limits for yt-dlp and ffmpeg (a cgroup v2 per process on Linux, a Job Object on
+	// Windows); 0 leaves a limit off
+	SubprocessCPUWeight    int    `yaml:"download.resource_limits.cpu_weight"`    // cgroup cpu.weight 1-10000 (100 is normal)
+	SubprocessMemoryMax    int64  `yaml:"download.resource_limits.memory_max"`    // Bytes; the process is stopped above it
+	SubprocessCgroupParent string `yaml:"download.resource_limits.cgroup_parent"` // Delegated cgroup to create process cgroups in; empty uses our own
 
 	// Upload configuration
 	MaxConcurrentUploads int           `yaml:"upload.max_concurrent"`
+	MaxUploadsPerAccount int           `yaml:"upload.max_concurrent_per_account"` // Per TikTok account (default 1); 0 = no cap
 	UploadTimeout        time.Duration `yaml:"-"`
 	UploadTimeoutStr     string        `yaml:"upload.timeout"`
+	// FailoverCooldown is how long an account stays on its fallback upload path before the primary is retried
+	FailoverCooldown    time.Duration `yaml:"-"`
+	FailoverCooldownStr string        `yaml:"upload.failover_cooldown"`
+
+	// Daily transfer caps (bytes per local calendar day) for metered connections; 0 disables a cap.
+	// Videos stay pending once a cap is reached and resume the next day.
+	DailyTransferCap int64 `yaml:"transfer.daily_cap"`          // Downloaded plus uploaded
+	DailyDownloadCap int64 `yaml:"transfer.daily_download_cap"` // Downloaded only
+	DailyUploadCap   int64 `yaml:"transfer.daily_upload_cap"`   // Uploaded only
 
 	// Database configuration
 	DatabaseURL string `yaml:"database.url"`
 
+	// DatabaseRetentionDays is how long completed and failed videos are kept before the
+	// maintenance job deletes them; 0 keeps them forever
+	DatabaseRetentionDays int `yaml:"database.retention_days"`
+	// DatabaseMaintenanceSchedule is the cron schedule of the maintenance job (weekly by default)
+	DatabaseMaintenanceSchedule string `yaml:"database.maintenance_schedule"`
+	// DatabaseVacuumFreePercent is the share of free pages above which maintenance runs VACUUM;
+	// -1 never vacuums
+	DatabaseVacuumFreePercent int `yaml:"database.vacuum_free_percent"`
+
 	// Performance tuning
 	WorkerPoolSize       int           `yaml:"performance.worker_pool_size"`
 	HTTPClientTimeout    time.Duration `yaml:"-"`
 	HTTPClientTimeoutStr string        `yaml:"performance.http_client_timeout"`
 	MaxIdleConns         int           `yaml:"performance.max_idle_conns"`
 	MaxConnsPerHost      int           `yaml:"performance.max_conns_per_host"`
+	// HTTPRetries is how many times idempotent requests are retried (0 = default of 2, -1 disables)
+	HTTPRetries         int           `yaml:"performance.http_retries"`
+	HTTPRetryBackoff    time.Duration `yaml:"-"`
+	HTTPRetryBackoffStr string        `yaml:"performance.http_retry_backoff"`
+	// HTTPProxy is the proxy of outgoing requests without a purpose-specific one (empty = direct)
+	HTTPProxy string `yaml:"performance.proxy"`
+	// RateLimits caps requests per second by upstream host, subdomains included; 0 disables a host
+	RateLimits map[string]float64 `yaml:"performance.rate_limits"`
 
 	// I/O optimization
 	DownloadBufferSize int `yaml:"download.buffer_size"`
@@ -64,75 +207,319 @@ type Config struct {
 	LogDirectory  string `yaml:"logging.dir"`
 	LogOutputFile string `yaml:"logging.output_file"`
 	LogErrorFile  string `yaml:"logging.error_file"`
+	// Log rotation: files are renamed to <name>.1, .2, ... once they reach LogMaxSizeMB
+	LogMaxSizeMB  int `yaml:"logging.max_size_mb"`  // 0 disables rotation
+	LogMaxBackups int `yaml:"logging.max_backups"`  // Rotated files kept per log; 0 keeps all
+	LogMaxAgeDays int `yaml:"logging.max_age_days"` // Rotated files older than this are removed; 0 keeps them
+
+	// Notification configuration
+	NotifyWebhookURL string `yaml:"notify.webhook_url"` // Optional: POST JSON events to this URL
+
+	// Caption translation (off unless a provider is set); accounts choose their language in
+	// settings.caption_language
+	TranslationProvider string `yaml:"translation.provider"` // "deepl" or "google"
+	TranslationAPIKey   string `yaml:"translation.api_key"`
+	TranslationAPIURL   string `yaml:"translation.api_url"` // Optional endpoint override
+
+	// Caption cleanup before upload: links are always removed and whitespace collapsed
+	CaptionUseDescription bool `yaml:"caption.use_description"` // false posts the title only
+	CaptionStripHashtags  bool `yaml:"caption.strip_hashtags"`  // Remove hashtags the YouTube text already has
+	CaptionMaxLength      int  `yaml:"caption.max_length"`      // Characters; longer text is cut and ends in "…"
+
+	// Content-safety moderation service asked before publishing videos of accounts with
+	// settings.safety_moderation; SafetyFrames frames are extracted from each video for it
+	SafetyModerationURL   string        `yaml:"safety.moderation_url"`
+	SafetyModerationToken string        `yaml:"safety.moderation_token"` // Optional bearer token
+	SafetyFrames          int           `yaml:"safety.frames"`
+	SafetyTimeout         time.Duration `yaml:"-"`
+	SafetyTimeoutStr      string        `yaml:"safety.timeout"`
+
+	// Health endpoint: the checks GET /api/health runs (empty runs all) and how long their
+	// results are reused, so frequent probes do not hit the dependencies each time
+	HealthChecks      []string      `yaml:"health.checks"`
+	HealthCacheTTL    time.Duration `yaml:"-"`
+	HealthCacheTTLStr string        `yaml:"health.cache_ttl"`
+
+	// Account invite configuration
+	InviteSecret string        `yaml:"invites.secret"` // HMAC key for invite links (defaults to the TikTok API secret)
+	InviteTTL    time.Duration `yaml:"-"`
+	InviteTTLStr string        `yaml:"invites.ttl"`
+
+	// Hooks are custom processing steps that accounts opt into through settings.hooks
+	Hooks []Hook `yaml:"hooks"`
 
 	// Bootstrap account mappings
 	BootstrapAccounts []AccountBootstrap `yaml:"accounts"`
+
+	// AccountsBootstrapMode controls how YAML accounts are applied on startup
+	AccountsBootstrapMode string `yaml:"accounts_bootstrap"`
+
+	// AccountsAllowSharedTikTok lets several YouTube channels post to the same TikTok account
+	AccountsAllowSharedTikTok bool `yaml:"accounts_allow_shared_tiktok"`
+
+	// AccountsAutoDisableAfter deactivates an account after this many consecutive hard failures
+	// (0 never deactivates)
+	AccountsAutoDisableAfter int `yaml:"accounts_auto_disable_after"`
+}
+
+// Account bootstrap modes
+const (
+	BootstrapModeSync       = "sync"        // Create missing accounts and update existing ones from YAML
+	BootstrapModeCreateOnly = "create_only" // Only create missing accounts; the database wins afterwards
+)
+
+// YouTube discovery modes
+const (
+	DiscoveryModeAPI = "api" // YouTube Data API (costs quota)
+	DiscoveryModeRSS = "rss" // Public channel Atom feed (quota-free, latest 15 uploads)
+)
+
+// Content-safety moderation defaults: frames sent per video and the time the service has to answer
+const (
+	defaultSafetyFrames  = 3
+	defaultSafetyTimeout = 30 * time.Second
+)
+
+// defaultCaptionMaxLength is TikTok's caption limit in characters
+const defaultCaptionMaxLength = 2200
+
+// defaultHealthCacheTTL is how long health check results are reused unless configured
+const defaultHealthCacheTTL = 30 * time.Second
+
+// defaultMinFreeSpace is the free space kept on the download filesystem unless configured
+const defaultMinFreeSpace = 512 * 1024 * 1024
+
+// Upload path limits published by TikTok: the Content Posting API takes up to 4 GB and 10 minutes,
+// the web uploader up to 10 GB and 60 minutes
+const (
+	defaultAPIMaxSize     = 4 << 30
+	defaultAPIMaxDuration = 10 * time.Minute
+	defaultWebMaxSize     = 10 << 30
+	defaultWebMaxDuration = 60 * time.Minute
+)
+
+// defaultCookiesClaimWindow is how recently another host must have used the web upload cookies
+// to count as using them at the same time
+const defaultCookiesClaimWindow = 30 * time.Minute
+
+// defaultChannelStatusInterval is how often channel status is checked by default
+const defaultChannelStatusInterval = 24 * time.Hour
+
+// defaultFreshWindow is how recently published a discovered video must be to be queued first
+const defaultFreshWindow = 6 * time.Hour
+
+// Database maintenance defaults: weekly on Sunday at 04:00, vacuuming when a fifth of the pages
+// are free
+const (
+	defaultMaintenanceSchedule = "0 4 * * 0"
+	defaultVacuumFreePercent   = 20
+)
+
+// yt-dlp defaults, matching what the downloader used before they were configurable
+const (
+	defaultDownloadFormat  = "mp4"
+	defaultDownloadRetries = 5
+)
+
+// defaultUploadsPerAccount keeps posts to one TikTok account sequential, as TikTok throttles
+// parallel ones
+const defaultUploadsPerAccount = 1
+
+// Log rotation defaults; rotation is on unless logging.max_size_mb is 0
+const (
+	defaultLogMaxSizeMB  = 100
+	defaultLogMaxBackups = 5
+)
+
+// defaultYtDlpExtraArgs prefers the android clients, which are less often bot-checked, and skips
+// the HLS/DASH manifests
+func defaultYtDlpExtraArgs() []string {
+	return []string{
+		"--extractor-args", "youtube:player_client=android_creator,android,ios",
+		"--extractor-args", "youtube:skip=hls,dash",
+	}
 }
 
 // AccountBootstrap defines an account mapping loaded from config
 type AccountBootstrap struct {
-	YouTubeChannelID  string `yaml:"youtube_channel_id"`
-	TikTokAccountID   string `yaml:"tiktok_account_id"`
-	TikTokAccessToken string `yaml:"tiktok_access_token"`
-	IsActive          *bool  `yaml:"is_active,omitempty"`
+	YouTubeChannelID  string `yaml:"youtube_channel_id" json:"youtube_channel_id"` // Channel ID, @handle or youtube.com channel URL
+	TikTokAccountID   string `yaml:"tiktok_account_id" json:"tiktok_account_id"`
+	TikTokAccessToken string `yaml:"tiktok_access_token" json:"tiktok_access_token,omitempty"`
+	IsActive          *bool  `yaml:"is_active,omitempty" json:"is_active,omitempty"`
+
+	// Publishing settings; unset fields leave the account's settings alone
+	PrivacyLevel string `yaml:"privacy_level,omitempty" json:"privacy_level,omitempty"`
+	PostAsDraft  *bool  `yaml:"post_as_draft,omitempty" json:"post_as_draft,omitempty"`
 }
 
 // configFile represents the YAML structure
 type configFile struct {
 	Server struct {
-		Port string `yaml:"port"`
+		Enabled          *bool    `yaml:"enabled"`
+		Port             string   `yaml:"port"`
+		Listen           string   `yaml:"listen"`
+		PublicURL        string   `yaml:"public_url"`
+		ShutdownGrace    string   `yaml:"shutdown_grace" env:"duration"`
+		WriteRetryBudget string   `yaml:"write_retry_budget" env:"duration"`
+		PublicPages      bool     `yaml:"public_pages"`
+		AdminToken       string   `yaml:"admin_token"`
+		Locale           string   `yaml:"locale"`
+		TrustedProxies   []string `yaml:"trusted_proxies"`
 	} `yaml:"server"`
 	YouTube struct {
-		APIKey string `yaml:"api_key"`
+		APIKey        string `yaml:"api_key"`
+		DiscoveryMode string `yaml:"discovery_mode"`
+		QuotaCooloff  string `yaml:"quota_cooloff" env:"duration"`
+		ChannelStatus string `yaml:"channel_status_interval" env:"duration"`
+
+		OAuthClientID     string `yaml:"oauth_client_id"`
+		OAuthClientSecret string `yaml:"oauth_client_secret"`
+		RedirectURI       string `yaml:"redirect_uri"`
 	} `yaml:"youtube"`
 	TikTok struct {
-		APIKey         string `yaml:"api_key"`
-		APISecret      string `yaml:"api_secret"`
-		Region         string `yaml:"region"`
-		BaseURL        string `yaml:"base_url"`
-		UploadInitPath string `yaml:"upload_init_path"`
-		PublishPath    string `yaml:"publish_path"`
-		RedirectURI    string `yaml:"redirect_uri"`
-		EnableWeb      bool   `yaml:"enable_web"`
-		CookiesPath    string `yaml:"cookies_path"`
+		APIKey             string      `yaml:"api_key"`
+		APISecret          string      `yaml:"api_secret"`
+		Apps               []TikTokApp `yaml:"apps"`
+		Region             string      `yaml:"region"`
+		BaseURL            string      `yaml:"base_url"`
+		UploadInitPath     string      `yaml:"upload_init_path"`
+		PublishPath        string      `yaml:"publish_path"`
+		InboxInitPath      string      `yaml:"inbox_init_path"`
+		PublishStatusPath  string      `yaml:"publish_status_path"`
+		CreatorInfoPath    string      `yaml:"creator_info_path"`
+		UploadMethod       string      `yaml:"upload_method"`
+		UploadFieldName    string      `yaml:"upload_field_name"`
+		RedirectURI        string      `yaml:"redirect_uri"`
+		EnableWeb          bool        `yaml:"enable_web"`
+		CookiesPath        string      `yaml:"cookies_path"`
+		Proxy              string      `yaml:"proxy"`
+		CommentPath        string      `yaml:"comment_path"`
+		CommentMinInterval string      `yaml:"comment_min_interval" env:"duration"`
+		APILimits          struct {
+			MaxSize     int64  `yaml:"max_size"`
+			MaxDuration string `yaml:"max_duration" env:"duration"`
+		} `yaml:"api_limits"`
+		WebLimits struct {
+			MaxSize     int64  `yaml:"max_size"`
+			MaxDuration string `yaml:"max_duration" env:"duration"`
+		} `yaml:"web_limits"`
+		CookiesClaim struct {
+			Host   string `yaml:"host"`
+			Window string `yaml:"window" env:"duration"`
+			Refuse bool   `yaml:"refuse"`
+		} `yaml:"cookies_claim"`
 	} `yaml:"tiktok"`
 	Cron struct {
-		Schedule string `yaml:"schedule"`
+		Schedule    string `yaml:"schedule"`
+		Timezone    string `yaml:"timezone"`
+		MinInterval string `yaml:"min_interval" env:"duration"`
+		FreshWindow string `yaml:"fresh_window" env:"duration"`
 	} `yaml:"cron"`
 	Download struct {
-		Dir                string `yaml:"dir"`
-		MaxConcurrent      int    `yaml:"max_concurrent"`
-		Timeout            string `yaml:"timeout"`
-		BufferSize         int    `yaml:"buffer_size"`
-		YtDlpPath          string `yaml:"yt_dlp_path"`
-		YoutubeCookiesPath string `yaml:"youtube_cookies_path"`
+		Dir                string   `yaml:"dir"`
+		MaxConcurrent      int      `yaml:"max_concurrent"`
+		MaxPerAccount      int      `yaml:"max_concurrent_per_account"`
+		Timeout            string   `yaml:"timeout" env:"duration"`
+		BufferSize         int      `yaml:"buffer_size"`
+		YtDlpPath          string   `yaml:"yt_dlp_path"`
+		FFmpegPath         string   `yaml:"ffmpeg_path"`
+		InvidiousInstances []string `yaml:"invidious_instances"`
+		InvidiousTimeout   string   `yaml:"invidious_timeout" env:"duration"`
+		MinFreeSpace       *int64   `yaml:"min_free_space"`
+		MaxDirSize         int64    `yaml:"max_dir_size"`
+		YoutubeCookiesPath string   `yaml:"youtube_cookies_path"`
+		YtDlpExtraArgs     []string `yaml:"ytdlp_extra_args"`
+		Format             string   `yaml:"format"`
+		MaxHeight          int      `yaml:"max_height"`
+		FPS                int      `yaml:"fps"`
+		UserAgent          string   `yaml:"user_agent"`
+		Proxy              string   `yaml:"proxy"`
+		Retries            *int     `yaml:"retries"`
+		Transcode          bool     `yaml:"transcode"`
+
+		ResourceLimits struct {
+			CPUWeight    int    `yaml:"cpu_weight"`
+			MemoryMax    int64  `yaml:"memory_max"`
+			CgroupParent string `yaml:"cgroup_parent"`
+		} `yaml:"resource_limits"`
 	} `yaml:"download"`
 	Upload struct {
-		MaxConcurrent int    `yaml:"max_concurrent"`
-		Timeout       string `yaml:"timeout"`
-		BufferSize    int    `yaml:"buffer_size"`
+		MaxConcurrent    int    `yaml:"max_concurrent"`
+		MaxPerAccount    *int   `yaml:"max_concurrent_per_account"`
+		Timeout          string `yaml:"timeout" env:"duration"`
+		BufferSize       int    `yaml:"buffer_size"`
+		FailoverCooldown string `yaml:"failover_cooldown" env:"duration"`
 	} `yaml:"upload"`
+	Transfer struct {
+		DailyCap         int64 `yaml:"daily_cap"`
+		DailyDownloadCap int64 `yaml:"daily_download_cap"`
+		DailyUploadCap   int64 `yaml:"daily_upload_cap"`
+	} `yaml:"transfer"`
 	Database struct {
-		URL string `yaml:"url"`
+		URL                 string `yaml:"url"`
+		RetentionDays       int    `yaml:"retention_days"`
+		MaintenanceSchedule string `yaml:"maintenance_schedule"`
+		VacuumFreePercent   int    `yaml:"vacuum_free_percent"`
 	} `yaml:"database"`
 	Performance struct {
-		WorkerPoolSize    int    `yaml:"worker_pool_size"`
-		HTTPClientTimeout string `yaml:"http_client_timeout"`
-		MaxIdleConns      int    `yaml:"max_idle_conns"`
-		MaxConnsPerHost   int    `yaml:"max_conns_per_host"`
-		MaxConcurrentIO   int    `yaml:"max_concurrent_io"`
+		WorkerPoolSize    int                `yaml:"worker_pool_size"`
+		HTTPClientTimeout string             `yaml:"http_client_timeout" env:"duration"`
+		MaxIdleConns      int                `yaml:"max_idle_conns"`
+		MaxConnsPerHost   int                `yaml:"max_conns_per_host"`
+		MaxConcurrentIO   int                `yaml:"max_concurrent_io"`
+		HTTPRetries       int                `yaml:"http_retries"`
+		HTTPRetryBackoff  string             `yaml:"http_retry_backoff" env:"duration"`
+		Proxy             string             `yaml:"proxy"`
+		RateLimits        map[string]float64 `yaml:"rate_limits"`
 	} `yaml:"performance"`
 	Logging struct {
 		Directory  string `yaml:"dir"`
 		OutputFile string `yaml:"output_file"`
 		ErrorFile  string `yaml:"error_file"`
+		MaxSizeMB  *int   `yaml:"max_size_mb"`
+		MaxBackups *int   `yaml:"max_backups"`
+		MaxAgeDays int    `yaml:"max_age_days"`
 	} `yaml:"logging"`
+	Notify struct {
+		WebhookURL string `yaml:"webhook_url"`
+	} `yaml:"notify"`
+	Translation struct {
+		Provider string `yaml:"provider"`
+		APIKey   string `yaml:"api_key"`
+		APIURL   string `yaml:"api_url"`
+	} `yaml:"translation"`
+	Caption struct {
+		UseDescription *bool `yaml:"use_description"`
+		StripHashtags  bool  `yaml:"strip_hashtags"`
+		MaxLength      int   `yaml:"max_length"`
+	} `yaml:"caption"`
+	Safety struct {
+		ModerationURL   string `yaml:"moderation_url"`
+		ModerationToken string `yaml:"moderation_token"`
+		Frames          *int   `yaml:"frames"`
+		Timeout         string `yaml:"timeout" env:"duration"`
+	} `yaml:"safety"`
+	Health struct {
+		Checks   []string `yaml:"checks"`
+		CacheTTL string   `yaml:"cache_ttl" env:"duration"`
+	} `yaml:"health"`
+	Invites struct {
+		Secret string `yaml:"secret"`
+		TTL    string `yaml:"ttl" env:"duration"`
+	} `yaml:"invites"`
+	Hooks    []Hook `yaml:"hooks"`
 	Accounts []struct {
 		YouTubeChannelID  string `yaml:"youtube_channel_id"`
 		TikTokAccountID   string `yaml:"tiktok_account_id"`
 		TikTokAccessToken string `yaml:"tiktok_access_token"`
-		IsActive          *bool  `yaml:"is_active"`
+		IsActive          *bool  `yaml:"is_active,omitempty"`
+		PrivacyLevel      string `yaml:"privacy_level,omitempty"`
+		PostAsDraft       *bool  `yaml:"post_as_draft,omitempty"`
 	} `yaml:"accounts"`
+	AccountsBootstrap string `yaml:"accounts_bootstrap"`
+
+	AccountsAllowSharedTikTok bool `yaml:"accounts_allow_shared_tiktok"`
+	AccountsAutoDisableAfter  int  `yaml:"accounts_auto_disable_after"`
 }
 
 // Manager handles configuration loading and saving
@@ -140,6 +527,16 @@ type Manager struct {
 	mu         sync.RWMutex
 	config     *Config
 	configPath string
+
+	// Environment overrides: the keys they set and the file's own values for those keys
+	envPrefixValue string
+	envPrefixSet   bool
+	envKeys        map[string]string
+	fileValues     *configFile
+
+	// Drop-ins from config.d: the keys they set and the base file's own values for those keys
+	dropInKeys map[string]string
+	baseValues *configFile
 }
 
 // NewManager creates a new configuration manager
@@ -152,19 +549,35 @@ func NewManager(configPath string) *Manager {
 	}
 }
 
+// Path returns the config file the manager reads and writes
+func (m *Manager) Path() string {
+	return m.configPath
+}
+
 // Load reads configuration from YAML file
 func (m *Manager) Load() (*Config, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.envKeys = nil
+	m.fileValues = nil
+	m.dropInKeys = nil
+	m.baseValues = nil
+
 	// Read YAML file
 	data, err := os.ReadFile(m.configPath)
 	if err != nil {
-		// If file doesn't exist, create default config
-		if os.IsNotExist(err) {
-			return m.createDefaultConfig()
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		// If file doesn't exist, create default config; overrides then apply to the new file
+		cfg, err := m.createDefaultConfig()
+		if err != nil {
+			return nil, err
+		}
+		if data, err = os.ReadFile(m.configPath); err != nil {
+			return cfg, nil
 		}
-		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	// Parse YAML
@@ -173,56 +586,195 @@ func (m *Manager) Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	// Files in config.d are merged over the base file in name order
+	files, err := dropInFiles(m.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		merged, dropInKeys, err := mergeDropIns(data, files)
+		if err != nil {
+			return nil, err
+		}
+		baseValues := cfgFile
+		cfgFile = configFile{}
+		if err := yaml.Unmarshal(merged, &cfgFile); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		if len(dropInKeys) > 0 {
+			m.dropInKeys = dropInKeys
+			m.baseValues = &baseValues
+		}
+	}
+
+	// Environment variables win over the file, e.g. for secrets kept out of it
+	fileValues := cfgFile
+	envKeys, err := applyEnvOverrides(&cfgFile, m.envPrefix())
+	if err != nil {
+		return nil, err
+	}
+	if len(envKeys) > 0 {
+		m.envKeys = envKeys
+		m.fileValues = &fileValues
+	}
+
 	// Convert to Config struct
 	cfg := &Config{
-		ServerPort:             cfgFile.Server.Port,
-		YouTubeAPIKey:          cfgFile.YouTube.APIKey,
-		TikTokAPIKey:           cfgFile.TikTok.APIKey,
-		TikTokAPISecret:        cfgFile.TikTok.APISecret,
-		TikTokRegion:           cfgFile.TikTok.Region,
-		TikTokBaseURL:          cfgFile.TikTok.BaseURL,
-		TikTokUploadInitPath:   cfgFile.TikTok.UploadInitPath,
-		TikTokPublishPath:      cfgFile.TikTok.PublishPath,
-		TikTokRedirectURI:      cfgFile.TikTok.RedirectURI,
-		TikTokEnableWeb:        cfgFile.TikTok.EnableWeb,
-		TikTokCookiesPath:      cfgFile.TikTok.CookiesPath,
-		CronSchedule:           cfgFile.Cron.Schedule,
-		DownloadDir:            cfgFile.Download.Dir,
-		MaxConcurrentDownloads: cfgFile.Download.MaxConcurrent,
-		DownloadTimeoutStr:     cfgFile.Download.Timeout,
-		YtDlpPath:              cfgFile.Download.YtDlpPath,
-		YoutubeCookiesPath:     cfgFile.Download.YoutubeCookiesPath,
-		MaxConcurrentUploads:   cfgFile.Upload.MaxConcurrent,
-		UploadTimeoutStr:       cfgFile.Upload.Timeout,
-		DatabaseURL:            cfgFile.Database.URL,
-		WorkerPoolSize:         cfgFile.Performance.WorkerPoolSize,
-		HTTPClientTimeoutStr:   cfgFile.Performance.HTTPClientTimeout,
-		MaxIdleConns:           cfgFile.Performance.MaxIdleConns,
-		MaxConnsPerHost:        cfgFile.Performance.MaxConnsPerHost,
-		DownloadBufferSize:     cfgFile.Download.BufferSize,
-		UploadBufferSize:       cfgFile.Upload.BufferSize,
-		MaxConcurrentIO:        cfgFile.Performance.MaxConcurrentIO,
-		LogDirectory:           cfgFile.Logging.Directory,
-		LogOutputFile:          cfgFile.Logging.OutputFile,
-		LogErrorFile:           cfgFile.Logging.ErrorFile,
+		ServerEnabled:               cfgFile.Server.Enabled == nil || *cfgFile.Server.Enabled,
+		ServerPort:                  cfgFile.Server.Port,
+		ServerListen:                strings.TrimSpace(cfgFile.Server.Listen),
+		ServerPublicURL:             cfgFile.Server.PublicURL,
+		PublicPagesEnabled:          cfgFile.Server.PublicPages,
+		AdminToken:                  cfgFile.Server.AdminToken,
+		ServerLocale:                cfgFile.Server.Locale,
+		TrustedProxies:              cfgFile.Server.TrustedProxies,
+		ShutdownGraceStr:            cfgFile.Server.ShutdownGrace,
+		WriteRetryBudgetStr:         cfgFile.Server.WriteRetryBudget,
+		YouTubeAPIKey:               cfgFile.YouTube.APIKey,
+		YouTubeDiscoveryMode:        cfgFile.YouTube.DiscoveryMode,
+		YouTubeQuotaCooloffStr:      cfgFile.YouTube.QuotaCooloff,
+		YouTubeStatusIntervalStr:    cfgFile.YouTube.ChannelStatus,
+		YouTubeOAuthClientID:        cfgFile.YouTube.OAuthClientID,
+		YouTubeOAuthClientSecret:    cfgFile.YouTube.OAuthClientSecret,
+		YouTubeRedirectURI:          cfgFile.YouTube.RedirectURI,
+		TikTokAPIKey:                cfgFile.TikTok.APIKey,
+		TikTokAPISecret:             cfgFile.TikTok.APISecret,
+		TikTokRegion:                cfgFile.TikTok.Region,
+		TikTokBaseURL:               cfgFile.TikTok.BaseURL,
+		TikTokUploadInitPath:        cfgFile.TikTok.UploadInitPath,
+		TikTokPublishPath:           cfgFile.TikTok.PublishPath,
+		TikTokInboxInitPath:         cfgFile.TikTok.InboxInitPath,
+		TikTokPublishStatusPath:     cfgFile.TikTok.PublishStatusPath,
+		TikTokCreatorInfoPath:       cfgFile.TikTok.CreatorInfoPath,
+		TikTokUploadMethod:          cfgFile.TikTok.UploadMethod,
+		TikTokUploadFieldName:       cfgFile.TikTok.UploadFieldName,
+		TikTokRedirectURI:           cfgFile.TikTok.RedirectURI,
+		TikTokEnableWeb:             cfgFile.TikTok.EnableWeb,
+		TikTokCookiesPath:           cfgFile.TikTok.CookiesPath,
+		TikTokProxy:                 cfgFile.TikTok.Proxy,
+		TikTokCommentPath:           cfgFile.TikTok.CommentPath,
+		TikTokCommentMinIntervalStr: cfgFile.TikTok.CommentMinInterval,
+		TikTokAPIMaxSize:            cfgFile.TikTok.APILimits.MaxSize,
+		TikTokAPIMaxDurationStr:     cfgFile.TikTok.APILimits.MaxDuration,
+		TikTokWebMaxSize:            cfgFile.TikTok.WebLimits.MaxSize,
+		TikTokWebMaxDurationStr:     cfgFile.TikTok.WebLimits.MaxDuration,
+		TikTokCookiesClaimHost:      cfgFile.TikTok.CookiesClaim.Host,
+		TikTokCookiesClaimWindowStr: cfgFile.TikTok.CookiesClaim.Window,
+		TikTokCookiesClaimRefuse:    cfgFile.TikTok.CookiesClaim.Refuse,
+		CronSchedule:                cfgFile.Cron.Schedule,
+		CronTimezone:                cfgFile.Cron.Timezone,
+		CronMinIntervalStr:          cfgFile.Cron.MinInterval,
+		CronFreshWindowStr:          cfgFile.Cron.FreshWindow,
+		DownloadDir:                 cfgFile.Download.Dir,
+		MaxConcurrentDownloads:      cfgFile.Download.MaxConcurrent,
+		MaxDownloadsPerAccount:      max(cfgFile.Download.MaxPerAccount, 0),
+		DownloadTimeoutStr:          cfgFile.Download.Timeout,
+		YtDlpPath:                   cfgFile.Download.YtDlpPath,
+		FFmpegPath:                  cfgFile.Download.FFmpegPath,
+		DownloadTranscode:           cfgFile.Download.Transcode,
+		DownloadFormat:              cfgFile.Download.Format,
+		DownloadMaxHeight:           max(cfgFile.Download.MaxHeight, 0),
+		DownloadFPS:                 max(cfgFile.Download.FPS, 0),
+		DownloadUserAgent:           cfgFile.Download.UserAgent,
+		DownloadProxy:               cfgFile.Download.Proxy,
+		InvidiousInstances:          cfgFile.Download.InvidiousInstances,
+		InvidiousTimeoutStr:         cfgFile.Download.InvidiousTimeout,
+		MaxDownloadDirSize:          cfgFile.Download.MaxDirSize,
+		YoutubeCookiesPath:          cfgFile.Download.YoutubeCookiesPath,
+		SubprocessCPUWeight:         cfgFile.Download.ResourceLimits.CPUWeight,
+		SubprocessMemoryMax:         cfgFile.Download.ResourceLimits.MemoryMax,
+		SubprocessCgroupParent:      cfgFile.Download.ResourceLimits.CgroupParent,
+		MaxConcurrentUploads:        cfgFile.Upload.MaxConcurrent,
+		UploadTimeoutStr:            cfgFile.Upload.Timeout,
+		FailoverCooldownStr:         cfgFile.Upload.FailoverCooldown,
+		DatabaseURL:                 cfgFile.Database.URL,
+		DatabaseRetentionDays:       cfgFile.Database.RetentionDays,
+		DatabaseMaintenanceSchedule: cfgFile.Database.MaintenanceSchedule,
+		DatabaseVacuumFreePercent:   cfgFile.Database.VacuumFreePercent,
+		WorkerPoolSize:              cfgFile.Performance.WorkerPoolSize,
+		HTTPClientTimeoutStr:        cfgFile.Performance.HTTPClientTimeout,
+		MaxIdleConns:                cfgFile.Performance.MaxIdleConns,
+		MaxConnsPerHost:             cfgFile.Performance.MaxConnsPerHost,
+		DownloadBufferSize:          cfgFile.Download.BufferSize,
+		UploadBufferSize:            cfgFile.Upload.BufferSize,
+		DailyTransferCap:            cfgFile.Transfer.DailyCap,
+		DailyDownloadCap:            cfgFile.Transfer.DailyDownloadCap,
+		DailyUploadCap:              cfgFile.Transfer.DailyUploadCap,
+		MaxConcurrentIO:             cfgFile.Performance.MaxConcurrentIO,
+		HTTPRetries:                 cfgFile.Performance.HTTPRetries,
+		HTTPRetryBackoffStr:         cfgFile.Performance.HTTPRetryBackoff,
+		HTTPProxy:                   cfgFile.Performance.Proxy,
+		RateLimits:                  cfgFile.Performance.RateLimits,
+		LogDirectory:                cfgFile.Logging.Directory,
+		LogOutputFile:               cfgFile.Logging.OutputFile,
+		LogErrorFile:                cfgFile.Logging.ErrorFile,
+		LogMaxAgeDays:               cfgFile.Logging.MaxAgeDays,
+		NotifyWebhookURL:            cfgFile.Notify.WebhookURL,
+		TranslationProvider:         cfgFile.Translation.Provider,
+		TranslationAPIKey:           cfgFile.Translation.APIKey,
+		TranslationAPIURL:           cfgFile.Translation.APIURL,
+		CaptionUseDescription:       cfgFile.Caption.UseDescription == nil || *cfgFile.Caption.UseDescription,
+		CaptionStripHashtags:        cfgFile.Caption.StripHashtags,
+		SafetyModerationURL:         cfgFile.Safety.ModerationURL,
+		SafetyModerationToken:       cfgFile.Safety.ModerationToken,
+		SafetyTimeoutStr:            cfgFile.Safety.Timeout,
+		HealthChecks:                cfgFile.Health.Checks,
+		HealthCacheTTLStr:           cfgFile.Health.CacheTTL,
+		InviteSecret:                cfgFile.Invites.Secret,
+		InviteTTLStr:                cfgFile.Invites.TTL,
+	}
+
+	if err := validateTikTokApps(cfgFile.TikTok.Apps); err != nil {
+		return nil, err
+	}
+	if err := validateProxies(map[string]string{
+		"performance.proxy": cfg.HTTPProxy,
+		"download.proxy":    cfg.DownloadProxy,
+		"tiktok.proxy":      cfg.TikTokProxy,
+	}); err != nil {
+		return nil, err
+	}
+	if len(cfgFile.TikTok.Apps) > 0 {
+		cfg.TikTokApps = append([]TikTokApp(nil), cfgFile.TikTok.Apps...)
+	}
+
+	if err := validateHooks(cfgFile.Hooks); err != nil {
+		return nil, err
+	}
+	// Checked before the redirect URIs default to this server
+	if err := validateServerListen(cfg); err != nil {
+		return nil, err
+	}
+	if _, _, err := ParseTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+	if len(cfgFile.Hooks) > 0 {
+		cfg.Hooks = append([]Hook(nil), cfgFile.Hooks...)
 	}
 
 	if len(cfgFile.Accounts) > 0 {
-		cfg.BootstrapAccounts = make([]AccountBootstrap, 0, len(cfgFile.Accounts))
-		for _, acc := range cfgFile.Accounts {
-			cfg.BootstrapAccounts = append(cfg.BootstrapAccounts, AccountBootstrap{
-				YouTubeChannelID:  acc.YouTubeChannelID,
-				TikTokAccountID:   acc.TikTokAccountID,
-				TikTokAccessToken: acc.TikTokAccessToken,
-				IsActive:          acc.IsActive,
-			})
-		}
+		cfg.BootstrapAccounts = cfgFile.bootstrapAccounts()
 	}
+	cfg.AccountsBootstrapMode = cfgFile.AccountsBootstrap
+	cfg.AccountsAllowSharedTikTok = cfgFile.AccountsAllowSharedTikTok
+	cfg.AccountsAutoDisableAfter = cfgFile.AccountsAutoDisableAfter
 
 	// Set defaults if empty
 	if cfg.ServerPort == "" {
 		cfg.ServerPort = "8080"
 	}
+	if cfg.ServerPublicURL == "" {
+		cfg.ServerPublicURL = fmt.Sprintf("http://localhost:%s", cfg.ServerPort)
+	}
+	if cfg.ServerLocale == "" {
+		cfg.ServerLocale = "en"
+	}
+	if cfg.YouTubeDiscoveryMode == "" {
+		cfg.YouTubeDiscoveryMode = DiscoveryModeAPI
+	}
+	if cfg.AccountsBootstrapMode == "" {
+		cfg.AccountsBootstrapMode = BootstrapModeSync
+	}
 	if cfg.TikTokRegion == "" {
 		cfg.TikTokRegion = "JP"
 	}
@@ -235,6 +787,21 @@ func (m *Manager) Load() (*Config, error) {
 	if cfg.TikTokPublishPath == "" {
 		cfg.TikTokPublishPath = "/video/publish/"
 	}
+	if cfg.TikTokInboxInitPath == "" {
+		cfg.TikTokInboxInitPath = "/v2/post/publish/inbox/video/init/"
+	}
+	if cfg.TikTokPublishStatusPath == "" {
+		cfg.TikTokPublishStatusPath = "/v2/post/publish/status/fetch/"
+	}
+	if cfg.TikTokCreatorInfoPath == "" {
+		cfg.TikTokCreatorInfoPath = "/v2/post/publish/creator_info/query/"
+	}
+	if cfg.TikTokUploadMethod == "" {
+		cfg.TikTokUploadMethod = "multipart"
+	}
+	if cfg.TikTokUploadFieldName == "" {
+		cfg.TikTokUploadFieldName = "video"
+	}
 	if cfg.TikTokRedirectURI == "" {
 		// Default to localhost callback, but can be overridden in config
 		cfg.TikTokRedirectURI = fmt.Sprintf("http://localhost:%s/api/tiktok/callback", cfg.ServerPort)
@@ -245,12 +812,39 @@ func (m *Manager) Load() (*Config, error) {
 	if cfg.CronSchedule == "" {
 		cfg.CronSchedule = "* * * * * *"
 	}
+	if cfg.CronMinIntervalStr != "" {
+		if d, err := time.ParseDuration(cfg.CronMinIntervalStr); err == nil {
+			cfg.CronMinInterval = d
+		} else {
+			cfg.CronMinInterval = time.Minute
+		}
+	} else {
+		cfg.CronMinInterval = time.Minute
+	}
+	if cfg.CronFreshWindowStr != "" {
+		if d, err := time.ParseDuration(cfg.CronFreshWindowStr); err == nil {
+			cfg.CronFreshWindow = d
+		} else {
+			cfg.CronFreshWindow = defaultFreshWindow
+		}
+	} else {
+		cfg.CronFreshWindow = defaultFreshWindow
+	}
 	if cfg.DownloadDir == "" {
 		cfg.DownloadDir = "./downloads"
 	}
 	if cfg.DatabaseURL == "" {
 		cfg.DatabaseURL = "sqlite3:./data.db"
 	}
+	if cfg.DatabaseRetentionDays < 0 {
+		cfg.DatabaseRetentionDays = 0
+	}
+	if cfg.DatabaseMaintenanceSchedule == "" {
+		cfg.DatabaseMaintenanceSchedule = defaultMaintenanceSchedule
+	}
+	if cfg.DatabaseVacuumFreePercent == 0 || cfg.DatabaseVacuumFreePercent > 100 {
+		cfg.DatabaseVacuumFreePercent = defaultVacuumFreePercent
+	}
 	if cfg.LogDirectory == "" {
 		cfg.LogDirectory = "./logs"
 	}
@@ -260,8 +854,51 @@ func (m *Manager) Load() (*Config, error) {
 	if cfg.LogErrorFile == "" {
 		cfg.LogErrorFile = "app.error.log"
 	}
+	// 0 is meaningful for both (no rotation, keep every backup), so only missing keys get defaults
+	cfg.LogMaxSizeMB = defaultLogMaxSizeMB
+	if cfgFile.Logging.MaxSizeMB != nil && *cfgFile.Logging.MaxSizeMB >= 0 {
+		cfg.LogMaxSizeMB = *cfgFile.Logging.MaxSizeMB
+	}
+	cfg.LogMaxBackups = defaultLogMaxBackups
+	if cfgFile.Logging.MaxBackups != nil && *cfgFile.Logging.MaxBackups >= 0 {
+		cfg.LogMaxBackups = *cfgFile.Logging.MaxBackups
+	}
 
 	// Parse durations
+	// An explicit 0 disables the free space check, so only a missing key gets the default
+	if cfgFile.Download.MinFreeSpace != nil {
+		cfg.MinFreeSpace = *cfgFile.Download.MinFreeSpace
+	} else {
+		cfg.MinFreeSpace = defaultMinFreeSpace
+	}
+	// Likewise an explicit empty list or 0 retries is honoured
+	if cfgFile.Download.YtDlpExtraArgs != nil {
+		cfg.YtDlpExtraArgs = cfgFile.Download.YtDlpExtraArgs
+	} else {
+		cfg.YtDlpExtraArgs = defaultYtDlpExtraArgs()
+	}
+	if cfgFile.Download.Retries != nil && *cfgFile.Download.Retries >= 0 {
+		cfg.DownloadRetries = *cfgFile.Download.Retries
+	} else {
+		cfg.DownloadRetries = defaultDownloadRetries
+	}
+	if cfgFile.Upload.MaxPerAccount != nil && *cfgFile.Upload.MaxPerAccount >= 0 {
+		cfg.MaxUploadsPerAccount = *cfgFile.Upload.MaxPerAccount
+	} else {
+		cfg.MaxUploadsPerAccount = defaultUploadsPerAccount
+	}
+	if cfg.DownloadFormat == "" {
+		cfg.DownloadFormat = defaultDownloadFormat
+	}
+	if cfg.InvidiousTimeoutStr != "" {
+		if d, err := time.ParseDuration(cfg.InvidiousTimeoutStr); err == nil && d > 0 {
+			cfg.InvidiousTimeout = d
+		} else {
+			cfg.InvidiousTimeout = 20 * time.Second
+		}
+	} else {
+		cfg.InvidiousTimeout = 20 * time.Second
+	}
 	if cfg.DownloadTimeoutStr != "" {
 		if d, err := time.ParseDuration(cfg.DownloadTimeoutStr); err == nil {
 			cfg.DownloadTimeout = d
@@ -282,6 +919,27 @@ func (m *Manager) Load() (*Config, error) {
 		cfg.UploadTimeout = 15 * time.Minute
 	}
 
+	if cfg.YouTubeQuotaCooloffStr != "" {
+		if d, err := time.ParseDuration(cfg.YouTubeQuotaCooloffStr); err == nil && d > 0 {
+			cfg.YouTubeQuotaCooloff = d
+		}
+	}
+	if d, err := time.ParseDuration(cfg.YouTubeStatusIntervalStr); err == nil && d >= 0 {
+		cfg.YouTubeStatusInterval = d
+	} else {
+		cfg.YouTubeStatusInterval = defaultChannelStatusInterval
+	}
+
+	if cfg.FailoverCooldownStr != "" {
+		if d, err := time.ParseDuration(cfg.FailoverCooldownStr); err == nil {
+			cfg.FailoverCooldown = d
+		} else {
+			cfg.FailoverCooldown = time.Hour
+		}
+	} else {
+		cfg.FailoverCooldown = time.Hour
+	}
+
 	if cfg.HTTPClientTimeoutStr != "" {
 		if d, err := time.ParseDuration(cfg.HTTPClientTimeoutStr); err == nil {
 			cfg.HTTPClientTimeout = d
@@ -292,6 +950,98 @@ func (m *Manager) Load() (*Config, error) {
 		cfg.HTTPClientTimeout = 30 * time.Second
 	}
 
+	if cfg.TikTokCommentMinIntervalStr != "" {
+		if d, err := time.ParseDuration(cfg.TikTokCommentMinIntervalStr); err == nil {
+			cfg.TikTokCommentMinInterval = d
+		} else {
+			cfg.TikTokCommentMinInterval = 2 * time.Minute
+		}
+	} else {
+		cfg.TikTokCommentMinInterval = 2 * time.Minute
+	}
+
+	if cfg.TikTokAPIMaxSize <= 0 {
+		cfg.TikTokAPIMaxSize = defaultAPIMaxSize
+	}
+	if d, err := time.ParseDuration(cfg.TikTokAPIMaxDurationStr); err == nil && d > 0 {
+		cfg.TikTokAPIMaxDuration = d
+	} else {
+		cfg.TikTokAPIMaxDuration = defaultAPIMaxDuration
+	}
+	if cfg.TikTokWebMaxSize <= 0 {
+		cfg.TikTokWebMaxSize = defaultWebMaxSize
+	}
+	if d, err := time.ParseDuration(cfg.TikTokWebMaxDurationStr); err == nil && d > 0 {
+		cfg.TikTokWebMaxDuration = d
+	} else {
+		cfg.TikTokWebMaxDuration = defaultWebMaxDuration
+	}
+	if d, err := time.ParseDuration(cfg.TikTokCookiesClaimWindowStr); err == nil && d >= 0 {
+		cfg.TikTokCookiesClaimWindow = d
+	} else {
+		cfg.TikTokCookiesClaimWindow = defaultCookiesClaimWindow
+	}
+
+	if cfg.WriteRetryBudgetStr != "" {
+		if d, err := time.ParseDuration(cfg.WriteRetryBudgetStr); err == nil && d >= 0 {
+			cfg.WriteRetryBudget = d
+		} else {
+			cfg.WriteRetryBudget = 10 * time.Second
+		}
+	} else {
+		cfg.WriteRetryBudget = 10 * time.Second
+	}
+	if cfg.ShutdownGraceStr != "" {
+		if d, err := time.ParseDuration(cfg.ShutdownGraceStr); err == nil {
+			cfg.ShutdownGrace = d
+		} else {
+			cfg.ShutdownGrace = 2 * time.Minute
+		}
+	} else {
+		cfg.ShutdownGrace = 2 * time.Minute
+	}
+
+	if n := cfgFile.Caption.MaxLength; n > 0 && n <= defaultCaptionMaxLength {
+		cfg.CaptionMaxLength = n
+	} else {
+		cfg.CaptionMaxLength = defaultCaptionMaxLength
+	}
+
+	if cfgFile.Safety.Frames != nil && *cfgFile.Safety.Frames >= 0 {
+		cfg.SafetyFrames = *cfgFile.Safety.Frames
+	} else {
+		cfg.SafetyFrames = defaultSafetyFrames
+	}
+	if cfg.SafetyTimeoutStr != "" {
+		if d, err := time.ParseDuration(cfg.SafetyTimeoutStr); err == nil && d > 0 {
+			cfg.SafetyTimeout = d
+		} else {
+			cfg.SafetyTimeout = defaultSafetyTimeout
+		}
+	} else {
+		cfg.SafetyTimeout = defaultSafetyTimeout
+	}
+
+	if cfg.HealthCacheTTLStr != "" {
+		if d, err := time.ParseDuration(cfg.HealthCacheTTLStr); err == nil && d >= 0 {
+			cfg.HealthCacheTTL = d
+		} else {
+			cfg.HealthCacheTTL = defaultHealthCacheTTL
+		}
+	} else {
+		cfg.HealthCacheTTL = defaultHealthCacheTTL
+	}
+
+	if cfg.InviteTTLStr != "" {
+		if d, err := time.ParseDuration(cfg.InviteTTLStr); err == nil {
+			cfg.InviteTTL = d
+		} else {
+			cfg.InviteTTL = 72 * time.Hour
+		}
+	} else {
+		cfg.InviteTTL = 72 * time.Hour
+	}
+
 	// Auto-calculate worker pool size if 0
 	if cfg.WorkerPoolSize == 0 {
 		cfg.WorkerPoolSize = runtime.NumCPU() * 4
@@ -319,6 +1069,26 @@ func (m *Manager) Load() (*Config, error) {
 	if cfg.MaxConcurrentIO == 0 {
 		cfg.MaxConcurrentIO = cfg.MaxConcurrentDownloads + cfg.MaxConcurrentUploads
 	}
+	if cfg.HTTPRetries == 0 {
+		cfg.HTTPRetries = 2
+	}
+	if cfg.HTTPRetryBackoffStr != "" {
+		if d, err := time.ParseDuration(cfg.HTTPRetryBackoffStr); err == nil {
+			cfg.HTTPRetryBackoff = d
+		} else {
+			cfg.HTTPRetryBackoff = 500 * time.Millisecond
+		}
+	} else {
+		cfg.HTTPRetryBackoff = 500 * time.Millisecond
+	}
+	// Without the key, discovery of many accounts at once stays under the APIs' burst limits;
+	// an empty map turns limiting off
+	if cfg.RateLimits == nil {
+		cfg.RateLimits = map[string]float64{
+			"googleapis.com":      5,
+			"open.tiktokapis.com": 2,
+		}
+	}
 
 	m.config = cfg
 	return cfg, nil
@@ -335,94 +1105,320 @@ func (m *Manager) Save(cfg *Config) error {
 // saveUnlocked persists config assuming caller already holds the write lock.
 func (m *Manager) saveUnlocked(cfg *Config) error {
 	// Convert Config to configFile
+	serverEnabled := cfg.ServerEnabled
+	minFreeSpace := cfg.MinFreeSpace
+	retries := cfg.DownloadRetries
+	uploadsPerAccount := cfg.MaxUploadsPerAccount
+	maxSizeMB, maxBackups := cfg.LogMaxSizeMB, cfg.LogMaxBackups
+	useDescription := cfg.CaptionUseDescription
+	safetyFrames := cfg.SafetyFrames
 	cfgFile := configFile{
 		Server: struct {
-			Port string `yaml:"port"`
+			Enabled          *bool    `yaml:"enabled"`
+			Port             string   `yaml:"port"`
+			Listen           string   `yaml:"listen"`
+			PublicURL        string   `yaml:"public_url"`
+			ShutdownGrace    string   `yaml:"shutdown_grace" env:"duration"`
+			WriteRetryBudget string   `yaml:"write_retry_budget" env:"duration"`
+			PublicPages      bool     `yaml:"public_pages"`
+			AdminToken       string   `yaml:"admin_token"`
+			Locale           string   `yaml:"locale"`
+			TrustedProxies   []string `yaml:"trusted_proxies"`
 		}{
-			Port: cfg.ServerPort,
+			Enabled:          &serverEnabled,
+			Port:             cfg.ServerPort,
+			Listen:           cfg.ServerListen,
+			PublicURL:        cfg.ServerPublicURL,
+			ShutdownGrace:    cfg.ShutdownGrace.String(),
+			WriteRetryBudget: cfg.WriteRetryBudget.String(),
+			PublicPages:      cfg.PublicPagesEnabled,
+			AdminToken:       cfg.AdminToken,
+			Locale:           cfg.ServerLocale,
+			TrustedProxies:   cfg.TrustedProxies,
 		},
 		YouTube: struct {
-			APIKey string `yaml:"api_key"`
+			APIKey        string `yaml:"api_key"`
+			DiscoveryMode string `yaml:"discovery_mode"`
+			QuotaCooloff  string `yaml:"quota_cooloff" env:"duration"`
+			ChannelStatus string `yaml:"channel_status_interval" env:"duration"`
+
+			OAuthClientID     string `yaml:"oauth_client_id"`
+			OAuthClientSecret string `yaml:"oauth_client_secret"`
+			RedirectURI       string `yaml:"redirect_uri"`
 		}{
-			APIKey: cfg.YouTubeAPIKey,
+			APIKey:            cfg.YouTubeAPIKey,
+			DiscoveryMode:     cfg.YouTubeDiscoveryMode,
+			QuotaCooloff:      cfg.YouTubeQuotaCooloffStr,
+			ChannelStatus:     cfg.YouTubeStatusInterval.String(),
+			OAuthClientID:     cfg.YouTubeOAuthClientID,
+			OAuthClientSecret: cfg.YouTubeOAuthClientSecret,
+			RedirectURI:       cfg.YouTubeRedirectURI,
 		},
 		TikTok: struct {
-			APIKey         string `yaml:"api_key"`
-			APISecret      string `yaml:"api_secret"`
-			Region         string `yaml:"region"`
-			BaseURL        string `yaml:"base_url"`
-			UploadInitPath string `yaml:"upload_init_path"`
-			PublishPath    string `yaml:"publish_path"`
-			RedirectURI    string `yaml:"redirect_uri"`
-			EnableWeb      bool   `yaml:"enable_web"`
-			CookiesPath    string `yaml:"cookies_path"`
+			APIKey             string      `yaml:"api_key"`
+			APISecret          string      `yaml:"api_secret"`
+			Apps               []TikTokApp `yaml:"apps"`
+			Region             string      `yaml:"region"`
+			BaseURL            string      `yaml:"base_url"`
+			UploadInitPath     string      `yaml:"upload_init_path"`
+			PublishPath        string      `yaml:"publish_path"`
+			InboxInitPath      string      `yaml:"inbox_init_path"`
+			PublishStatusPath  string      `yaml:"publish_status_path"`
+			CreatorInfoPath    string      `yaml:"creator_info_path"`
+			UploadMethod       string      `yaml:"upload_method"`
+			UploadFieldName    string      `yaml:"upload_field_name"`
+			RedirectURI        string      `yaml:"redirect_uri"`
+			EnableWeb          bool        `yaml:"enable_web"`
+			CookiesPath        string      `yaml:"cookies_path"`
+			Proxy              string      `yaml:"proxy"`
+			CommentPath        string      `yaml:"comment_path"`
+			CommentMinInterval string      `yaml:"comment_min_interval" env:"duration"`
+			APILimits          struct {
+				MaxSize     int64  `yaml:"max_size"`
+				MaxDuration string `yaml:"max_duration" env:"duration"`
+			} `yaml:"api_limits"`
+			WebLimits struct {
+				MaxSize     int64  `yaml:"max_size"`
+				MaxDuration string `yaml:"max_duration" env:"duration"`
+			} `yaml:"web_limits"`
+			CookiesClaim struct {
+				Host   string `yaml:"host"`
+				Window string `yaml:"window" env:"duration"`
+				Refuse bool   `yaml:"refuse"`
+			} `yaml:"cookies_claim"`
 		}{
-			APIKey:         cfg.TikTokAPIKey,
-			APISecret:      cfg.TikTokAPISecret,
-			Region:         cfg.TikTokRegion,
-			BaseURL:        cfg.TikTokBaseURL,
-			UploadInitPath: cfg.TikTokUploadInitPath,
-			PublishPath:    cfg.TikTokPublishPath,
-			RedirectURI:    cfg.TikTokRedirectURI,
-			EnableWeb:      cfg.TikTokEnableWeb,
-			CookiesPath:    cfg.TikTokCookiesPath,
+			APIKey:             cfg.TikTokAPIKey,
+			APISecret:          cfg.TikTokAPISecret,
+			Apps:               cfg.TikTokApps,
+			Region:             cfg.TikTokRegion,
+			BaseURL:            cfg.TikTokBaseURL,
+			UploadInitPath:     cfg.TikTokUploadInitPath,
+			PublishPath:        cfg.TikTokPublishPath,
+			InboxInitPath:      cfg.TikTokInboxInitPath,
+			PublishStatusPath:  cfg.TikTokPublishStatusPath,
+			CreatorInfoPath:    cfg.TikTokCreatorInfoPath,
+			UploadMethod:       cfg.TikTokUploadMethod,
+			UploadFieldName:    cfg.TikTokUploadFieldName,
+			RedirectURI:        cfg.TikTokRedirectURI,
+			EnableWeb:          cfg.TikTokEnableWeb,
+			CookiesPath:        cfg.TikTokCookiesPath,
+			Proxy:              cfg.TikTokProxy,
+			CommentPath:        cfg.TikTokCommentPath,
+			CommentMinInterval: cfg.TikTokCommentMinInterval.String(),
+			APILimits: struct {
+				MaxSize     int64  `yaml:"max_size"`
+				MaxDuration string `yaml:"max_duration" env:"duration"`
+			}{
+				MaxSize:     cfg.TikTokAPIMaxSize,
+				MaxDuration: cfg.TikTokAPIMaxDuration.String(),
+			},
+			WebLimits: struct {
+				MaxSize     int64  `yaml:"max_size"`
+				MaxDuration string `yaml:"max_duration" env:"duration"`
+			}{
+				MaxSize:     cfg.TikTokWebMaxSize,
+				MaxDuration: cfg.TikTokWebMaxDuration.String(),
+			},
+			CookiesClaim: struct {
+				Host   string `yaml:"host"`
+				Window string `yaml:"window" env:"duration"`
+				Refuse bool   `yaml:"refuse"`
+			}{
+				Host:   cfg.TikTokCookiesClaimHost,
+				Window: cfg.TikTokCookiesClaimWindow.String(),
+				Refuse: cfg.TikTokCookiesClaimRefuse,
+			},
 		},
 		Cron: struct {
-			Schedule string `yaml:"schedule"`
+			Schedule    string `yaml:"schedule"`
+			Timezone    string `yaml:"timezone"`
+			MinInterval string `yaml:"min_interval" env:"duration"`
+			FreshWindow string `yaml:"fresh_window" env:"duration"`
 		}{
-			Schedule: cfg.CronSchedule,
+			Schedule:    cfg.CronSchedule,
+			Timezone:    cfg.CronTimezone,
+			MinInterval: cfg.CronMinInterval.String(),
+			FreshWindow: cfg.CronFreshWindow.String(),
 		},
 		Download: struct {
-			Dir                string `yaml:"dir"`
-			MaxConcurrent      int    `yaml:"max_concurrent"`
-			Timeout            string `yaml:"timeout"`
-			BufferSize         int    `yaml:"buffer_size"`
-			YtDlpPath          string `yaml:"yt_dlp_path"`
-			YoutubeCookiesPath string `yaml:"youtube_cookies_path"`
+			Dir                string   `yaml:"dir"`
+			MaxConcurrent      int      `yaml:"max_concurrent"`
+			MaxPerAccount      int      `yaml:"max_concurrent_per_account"`
+			Timeout            string   `yaml:"timeout" env:"duration"`
+			BufferSize         int      `yaml:"buffer_size"`
+			YtDlpPath          string   `yaml:"yt_dlp_path"`
+			FFmpegPath         string   `yaml:"ffmpeg_path"`
+			InvidiousInstances []string `yaml:"invidious_instances"`
+			InvidiousTimeout   string   `yaml:"invidious_timeout" env:"duration"`
+			MinFreeSpace       *int64   `yaml:"min_free_space"`
+			MaxDirSize         int64    `yaml:"max_dir_size"`
+			YoutubeCookiesPath string   `yaml:"youtube_cookies_path"`
+			YtDlpExtraArgs     []string `yaml:"ytdlp_extra_args"`
+			Format             string   `yaml:"format"`
+			MaxHeight          int      `yaml:"max_height"`
+			FPS                int      `yaml:"fps"`
+			UserAgent          string   `yaml:"user_agent"`
+			Proxy              string   `yaml:"proxy"`
+			Retries            *int     `yaml:"retries"`
+			Transcode          bool     `yaml:"transcode"`
+
+			ResourceLimits struct {
+				CPUWeight    int    `yaml:"cpu_weight"`
+				MemoryMax    int64  `yaml:"memory_max"`
+				CgroupParent string `yaml:"cgroup_parent"`
+			} `yaml:"resource_limits"`
 		}{
 			Dir:                cfg.DownloadDir,
 			MaxConcurrent:      cfg.MaxConcurrentDownloads,
+			MaxPerAccount:      cfg.MaxDownloadsPerAccount,
 			Timeout:            cfg.DownloadTimeout.String(),
 			BufferSize:         cfg.DownloadBufferSize,
 			YtDlpPath:          cfg.YtDlpPath,
+			FFmpegPath:         cfg.FFmpegPath,
+			InvidiousInstances: cfg.InvidiousInstances,
+			InvidiousTimeout:   cfg.InvidiousTimeout.String(),
+			MinFreeSpace:       &minFreeSpace,
+			MaxDirSize:         cfg.MaxDownloadDirSize,
 			YoutubeCookiesPath: cfg.YoutubeCookiesPath,
+			YtDlpExtraArgs:     cfg.YtDlpExtraArgs,
+			Format:             cfg.DownloadFormat,
+			MaxHeight:          cfg.DownloadMaxHeight,
+			FPS:                cfg.DownloadFPS,
+			UserAgent:          cfg.DownloadUserAgent,
+			Proxy:              cfg.DownloadProxy,
+			Retries:            &retries,
+			Transcode:          cfg.DownloadTranscode,
+			ResourceLimits: struct {
+				CPUWeight    int    `yaml:"cpu_weight"`
+				MemoryMax    int64  `yaml:"memory_max"`
+				CgroupParent string `yaml:"cgroup_parent"`
+			}{
+				CPUWeight:    cfg.SubprocessCPUWeight,
+				MemoryMax:    cfg.SubprocessMemoryMax,
+				CgroupParent: cfg.SubprocessCgroupParent,
+			},
 		},
 		Upload: struct {
-			MaxConcurrent int    `yaml:"max_concurrent"`
-			Timeout       string `yaml:"timeout"`
-			BufferSize    int    `yaml:"buffer_size"`
+			MaxConcurrent    int    `yaml:"max_concurrent"`
+			MaxPerAccount    *int   `yaml:"max_concurrent_per_account"`
+			Timeout          string `yaml:"timeout" env:"duration"`
+			BufferSize       int    `yaml:"buffer_size"`
+			FailoverCooldown string `yaml:"failover_cooldown" env:"duration"`
+		}{
+			MaxConcurrent:    cfg.MaxConcurrentUploads,
+			MaxPerAccount:    &uploadsPerAccount,
+			Timeout:          cfg.UploadTimeout.String(),
+			BufferSize:       cfg.UploadBufferSize,
+			FailoverCooldown: cfg.FailoverCooldown.String(),
+		},
+		Transfer: struct {
+			DailyCap         int64 `yaml:"daily_cap"`
+			DailyDownloadCap int64 `yaml:"daily_download_cap"`
+			DailyUploadCap   int64 `yaml:"daily_upload_cap"`
 		}{
-			MaxConcurrent: cfg.MaxConcurrentUploads,
-			Timeout:       cfg.UploadTimeout.String(),
-			BufferSize:    cfg.UploadBufferSize,
+			DailyCap:         cfg.DailyTransferCap,
+			DailyDownloadCap: cfg.DailyDownloadCap,
+			DailyUploadCap:   cfg.DailyUploadCap,
 		},
 		Database: struct {
-			URL string `yaml:"url"`
+			URL                 string `yaml:"url"`
+			RetentionDays       int    `yaml:"retention_days"`
+			MaintenanceSchedule string `yaml:"maintenance_schedule"`
+			VacuumFreePercent   int    `yaml:"vacuum_free_percent"`
 		}{
-			URL: cfg.DatabaseURL,
+			URL:                 cfg.DatabaseURL,
+			RetentionDays:       cfg.DatabaseRetentionDays,
+			MaintenanceSchedule: cfg.DatabaseMaintenanceSchedule,
+			VacuumFreePercent:   cfg.DatabaseVacuumFreePercent,
 		},
 		Performance: struct {
-			WorkerPoolSize    int    `yaml:"worker_pool_size"`
-			HTTPClientTimeout string `yaml:"http_client_timeout"`
-			MaxIdleConns      int    `yaml:"max_idle_conns"`
-			MaxConnsPerHost   int    `yaml:"max_conns_per_host"`
-			MaxConcurrentIO   int    `yaml:"max_concurrent_io"`
+			WorkerPoolSize    int                `yaml:"worker_pool_size"`
+			HTTPClientTimeout string             `yaml:"http_client_timeout" env:"duration"`
+			MaxIdleConns      int                `yaml:"max_idle_conns"`
+			MaxConnsPerHost   int                `yaml:"max_conns_per_host"`
+			MaxConcurrentIO   int                `yaml:"max_concurrent_io"`
+			HTTPRetries       int                `yaml:"http_retries"`
+			HTTPRetryBackoff  string             `yaml:"http_retry_backoff" env:"duration"`
+			Proxy             string             `yaml:"proxy"`
+			RateLimits        map[string]float64 `yaml:"rate_limits"`
 		}{
 			WorkerPoolSize:    cfg.WorkerPoolSize,
 			HTTPClientTimeout: cfg.HTTPClientTimeout.String(),
 			MaxIdleConns:      cfg.MaxIdleConns,
 			MaxConnsPerHost:   cfg.MaxConnsPerHost,
 			MaxConcurrentIO:   cfg.MaxConcurrentIO,
+			HTTPRetries:       cfg.HTTPRetries,
+			HTTPRetryBackoff:  cfg.HTTPRetryBackoff.String(),
+			Proxy:             cfg.HTTPProxy,
+			RateLimits:        cfg.RateLimits,
 		},
 		Logging: struct {
 			Directory  string `yaml:"dir"`
 			OutputFile string `yaml:"output_file"`
 			ErrorFile  string `yaml:"error_file"`
+			MaxSizeMB  *int   `yaml:"max_size_mb"`
+			MaxBackups *int   `yaml:"max_backups"`
+			MaxAgeDays int    `yaml:"max_age_days"`
 		}{
 			Directory:  cfg.LogDirectory,
 			OutputFile: cfg.LogOutputFile,
 			ErrorFile:  cfg.LogErrorFile,
+			MaxSizeMB:  &maxSizeMB,
+			MaxBackups: &maxBackups,
+			MaxAgeDays: cfg.LogMaxAgeDays,
+		},
+		Notify: struct {
+			WebhookURL string `yaml:"webhook_url"`
+		}{
+			WebhookURL: cfg.NotifyWebhookURL,
+		},
+		Translation: struct {
+			Provider string `yaml:"provider"`
+			APIKey   string `yaml:"api_key"`
+			APIURL   string `yaml:"api_url"`
+		}{
+			Provider: cfg.TranslationProvider,
+			APIKey:   cfg.TranslationAPIKey,
+			APIURL:   cfg.TranslationAPIURL,
+		},
+		Caption: struct {
+			UseDescription *bool `yaml:"use_description"`
+			StripHashtags  bool  `yaml:"strip_hashtags"`
+			MaxLength      int   `yaml:"max_length"`
+		}{
+			UseDescription: &useDescription,
+			StripHashtags:  cfg.CaptionStripHashtags,
+			MaxLength:      cfg.CaptionMaxLength,
 		},
+		Safety: struct {
+			ModerationURL   string `yaml:"moderation_url"`
+			ModerationToken string `yaml:"moderation_token"`
+			Frames          *int   `yaml:"frames"`
+			Timeout         string `yaml:"timeout" env:"duration"`
+		}{
+			ModerationURL:   cfg.SafetyModerationURL,
+			ModerationToken: cfg.SafetyModerationToken,
+			Frames:          &safetyFrames,
+			Timeout:         cfg.SafetyTimeout.String(),
+		},
+		Health: struct {
+			Checks   []string `yaml:"checks"`
+			CacheTTL string   `yaml:"cache_ttl" env:"duration"`
+		}{
+			Checks:   cfg.HealthChecks,
+			CacheTTL: cfg.HealthCacheTTL.String(),
+		},
+		Invites: struct {
+			Secret string `yaml:"secret"`
+			TTL    string `yaml:"ttl" env:"duration"`
+		}{
+			Secret: cfg.InviteSecret,
+			TTL:    cfg.InviteTTL.String(),
+		},
+		Hooks:                     cfg.Hooks,
+		AccountsBootstrap:         cfg.AccountsBootstrapMode,
+		AccountsAllowSharedTikTok: cfg.AccountsAllowSharedTikTok,
+		AccountsAutoDisableAfter:  cfg.AccountsAutoDisableAfter,
 	}
 
 	if len(cfg.BootstrapAccounts) > 0 {
@@ -430,23 +1426,34 @@ func (m *Manager) saveUnlocked(cfg *Config) error {
 			YouTubeChannelID  string `yaml:"youtube_channel_id"`
 			TikTokAccountID   string `yaml:"tiktok_account_id"`
 			TikTokAccessToken string `yaml:"tiktok_access_token"`
-			IsActive          *bool  `yaml:"is_active"`
+			IsActive          *bool  `yaml:"is_active,omitempty"`
+			PrivacyLevel      string `yaml:"privacy_level,omitempty"`
+			PostAsDraft       *bool  `yaml:"post_as_draft,omitempty"`
 		}, 0, len(cfg.BootstrapAccounts))
 		for _, acc := range cfg.BootstrapAccounts {
 			cfgFile.Accounts = append(cfgFile.Accounts, struct {
 				YouTubeChannelID  string `yaml:"youtube_channel_id"`
 				TikTokAccountID   string `yaml:"tiktok_account_id"`
 				TikTokAccessToken string `yaml:"tiktok_access_token"`
-				IsActive          *bool  `yaml:"is_active"`
+				IsActive          *bool  `yaml:"is_active,omitempty"`
+				PrivacyLevel      string `yaml:"privacy_level,omitempty"`
+				PostAsDraft       *bool  `yaml:"post_as_draft,omitempty"`
 			}{
 				YouTubeChannelID:  acc.YouTubeChannelID,
 				TikTokAccountID:   acc.TikTokAccountID,
 				TikTokAccessToken: acc.TikTokAccessToken,
 				IsActive:          acc.IsActive,
+				PrivacyLevel:      acc.PrivacyLevel,
+				PostAsDraft:       acc.PostAsDraft,
 			})
 		}
 	}
 
+	// Values from environment variables stay out of the file
+	keepFileValues(&cfgFile, m.fileValues, m.envKeys)
+	// and so do values from config.d
+	keepBaseValues(&cfgFile, m.baseValues, m.dropInKeys)
+
 	// Marshal to YAML
 	data, err := yaml.Marshal(&cfgFile)
 	if err != nil {
@@ -477,18 +1484,137 @@ func (m *Manager) Update(updates map[string]interface{}) error {
 	if m.config == nil {
 		return fmt.Errorf("config not loaded, call Load() first")
 	}
+	if err := m.validateScheduleUpdate(updates); err != nil {
+		return err
+	}
+	if value, ok := updates["tiktok.apps"]; ok {
+		apps, ok := value.([]TikTokApp)
+		if !ok {
+			return fmt.Errorf("tiktok.apps must be a list of credential sets")
+		}
+		if err := validateTikTokApps(apps); err != nil {
+			return err
+		}
+	}
+	proxies := make(map[string]string)
+	for _, key := range []string{"performance.proxy", "download.proxy", "tiktok.proxy"} {
+		if value, ok := updates[key]; ok {
+			setting, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s must be a string", key)
+			}
+			proxies[key] = setting
+		}
+	}
+	if err := validateProxies(proxies); err != nil {
+		return err
+	}
+	if value, ok := updates["hooks"]; ok {
+		hooks, ok := value.([]Hook)
+		if !ok {
+			return fmt.Errorf("hooks must be a list of hooks")
+		}
+		if err := validateHooks(hooks); err != nil {
+			return err
+		}
+	}
+	if value, ok := updates["server.listen"]; ok {
+		listen, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("server.listen must be a string")
+		}
+		probe := *m.config
+		probe.ServerListen = strings.TrimSpace(listen)
+		if err := validateServerListen(&probe); err != nil {
+			return err
+		}
+	}
+	if value, ok := updates["server.trusted_proxies"]; ok {
+		proxies, ok := stringList(value)
+		if !ok {
+			return fmt.Errorf("server.trusted_proxies must be a list of strings")
+		}
+		if _, _, err := ParseTrustedProxies(proxies); err != nil {
+			return err
+		}
+	}
 
 	// Apply updates
 	for key, value := range updates {
 		switch key {
+		case "server.enabled":
+			if v, ok := value.(bool); ok {
+				m.config.ServerEnabled = v
+			}
 		case "server.port":
 			m.config.ServerPort = value.(string)
+		case "server.listen":
+			if v, ok := value.(string); ok {
+				m.config.ServerListen = strings.TrimSpace(v)
+			}
+		case "server.public_url":
+			m.config.ServerPublicURL = value.(string)
+		case "server.public_pages":
+			if v, ok := value.(bool); ok {
+				m.config.PublicPagesEnabled = v
+			}
+		case "server.admin_token":
+			if v, ok := value.(string); ok {
+				m.config.AdminToken = v
+			}
+		case "server.locale":
+			if v, ok := value.(string); ok && v != "" {
+				m.config.ServerLocale = v
+			}
+		case "server.trusted_proxies":
+			if list, ok := stringList(value); ok {
+				m.config.TrustedProxies = list
+			}
+		case "server.write_retry_budget":
+			if str, ok := value.(string); ok {
+				m.config.WriteRetryBudgetStr = str
+				if d, err := time.ParseDuration(str); err == nil && d >= 0 {
+					m.config.WriteRetryBudget = d
+				}
+			}
+		case "server.shutdown_grace":
+			if str, ok := value.(string); ok {
+				m.config.ShutdownGraceStr = str
+				if d, err := time.ParseDuration(str); err == nil {
+					m.config.ShutdownGrace = d
+				}
+			}
 		case "youtube.api_key":
 			m.config.YouTubeAPIKey = value.(string)
+		case "youtube.discovery_mode":
+			m.config.YouTubeDiscoveryMode = value.(string)
+		case "youtube.quota_cooloff":
+			if str, ok := value.(string); ok {
+				m.config.YouTubeQuotaCooloffStr = str
+				m.config.YouTubeQuotaCooloff = 0
+				if d, err := time.ParseDuration(str); err == nil && d > 0 {
+					m.config.YouTubeQuotaCooloff = d
+				}
+			}
+		case "youtube.channel_status_interval":
+			if str, ok := value.(string); ok {
+				if d, err := time.ParseDuration(str); err == nil && d >= 0 {
+					m.config.YouTubeStatusIntervalStr = str
+					m.config.YouTubeStatusInterval = d
+				}
+			}
+		case "youtube.oauth_client_id":
+			m.config.YouTubeOAuthClientID = value.(string)
+		case "youtube.oauth_client_secret":
+			m.config.YouTubeOAuthClientSecret = value.(string)
+		case "youtube.redirect_uri":
+			m.config.YouTubeRedirectURI = value.(string)
 		case "tiktok.api_key":
 			m.config.TikTokAPIKey = value.(string)
 		case "tiktok.api_secret":
 			m.config.TikTokAPISecret = value.(string)
+		case "tiktok.apps":
+			m.config.TikTokApps = value.([]TikTokApp)
 		case "tiktok.region":
 			m.config.TikTokRegion = value.(string)
 		case "tiktok.base_url":
@@ -497,18 +1623,96 @@ func (m *Manager) Update(updates map[string]interface{}) error {
 			m.config.TikTokUploadInitPath = value.(string)
 		case "tiktok.publish_path":
 			m.config.TikTokPublishPath = value.(string)
+		case "tiktok.inbox_init_path":
+			m.config.TikTokInboxInitPath = value.(string)
+		case "tiktok.publish_status_path":
+			m.config.TikTokPublishStatusPath = value.(string)
+		case "tiktok.creator_info_path":
+			m.config.TikTokCreatorInfoPath = value.(string)
+		case "tiktok.upload_method":
+			m.config.TikTokUploadMethod = value.(string)
+		case "tiktok.upload_field_name":
+			m.config.TikTokUploadFieldName = value.(string)
 		case "tiktok.enable_web":
 			if v, ok := value.(bool); ok {
 				m.config.TikTokEnableWeb = v
 			}
 		case "tiktok.cookies_path":
 			m.config.TikTokCookiesPath = value.(string)
+		case "tiktok.proxy":
+			m.config.TikTokProxy = value.(string)
+		case "tiktok.comment_path":
+			m.config.TikTokCommentPath = value.(string)
+		case "tiktok.comment_min_interval":
+			if str, ok := value.(string); ok {
+				m.config.TikTokCommentMinIntervalStr = str
+				if d, err := time.ParseDuration(str); err == nil {
+					m.config.TikTokCommentMinInterval = d
+				}
+			}
+		case "tiktok.api_limits.max_size":
+			if n, ok := value.(int); ok && n > 0 {
+				m.config.TikTokAPIMaxSize = int64(n)
+			}
+		case "tiktok.api_limits.max_duration":
+			if str, ok := value.(string); ok {
+				if d, err := time.ParseDuration(str); err == nil && d > 0 {
+					m.config.TikTokAPIMaxDurationStr = str
+					m.config.TikTokAPIMaxDuration = d
+				}
+			}
+		case "tiktok.web_limits.max_size":
+			if n, ok := value.(int); ok && n > 0 {
+				m.config.TikTokWebMaxSize = int64(n)
+			}
+		case "tiktok.web_limits.max_duration":
+			if str, ok := value.(string); ok {
+				if d, err := time.ParseDuration(str); err == nil && d > 0 {
+					m.config.TikTokWebMaxDurationStr = str
+					m.config.TikTokWebMaxDuration = d
+				}
+			}
+		case "tiktok.cookies_claim.host":
+			if host, ok := value.(string); ok {
+				m.config.TikTokCookiesClaimHost = host
+			}
+		case "tiktok.cookies_claim.window":
+			if str, ok := value.(string); ok {
+				if d, err := time.ParseDuration(str); err == nil && d >= 0 {
+					m.config.TikTokCookiesClaimWindowStr = str
+					m.config.TikTokCookiesClaimWindow = d
+				}
+			}
+		case "tiktok.cookies_claim.refuse":
+			if v, ok := value.(bool); ok {
+				m.config.TikTokCookiesClaimRefuse = v
+			}
 		case "cron.schedule":
-			m.config.CronSchedule = value.(string)
+			m.config.CronSchedule = NormalizeSchedule(value.(string))
+		case "cron.timezone":
+			m.config.CronTimezone = value.(string)
+		case "cron.min_interval":
+			if str, ok := value.(string); ok {
+				m.config.CronMinIntervalStr = str
+				if d, err := time.ParseDuration(str); err == nil {
+					m.config.CronMinInterval = d
+				}
+			}
+		case "cron.fresh_window":
+			if str, ok := value.(string); ok {
+				m.config.CronFreshWindowStr = str
+				if d, err := time.ParseDuration(str); err == nil {
+					m.config.CronFreshWindow = d
+				}
+			}
 		case "download.dir":
 			m.config.DownloadDir = value.(string)
 		case "download.max_concurrent":
 			m.config.MaxConcurrentDownloads = value.(int)
+		case "download.max_concurrent_per_account":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.MaxDownloadsPerAccount = n
+			}
 		case "download.timeout":
 			if str, ok := value.(string); ok {
 				m.config.DownloadTimeoutStr = str
@@ -522,8 +1726,91 @@ func (m *Manager) Update(updates map[string]interface{}) error {
 			if path, ok := value.(string); ok {
 				m.config.YtDlpPath = path
 			}
+		case "download.ffmpeg_path":
+			if path, ok := value.(string); ok {
+				m.config.FFmpegPath = path
+			}
+		case "download.transcode":
+			if v, ok := value.(bool); ok {
+				m.config.DownloadTranscode = v
+			}
+		case "download.invidious_instances":
+			switch list := value.(type) {
+			case []string:
+				m.config.InvidiousInstances = list
+			case []interface{}:
+				instances := make([]string, 0, len(list))
+				for _, item := range list {
+					if str, ok := item.(string); ok && str != "" {
+						instances = append(instances, str)
+					}
+				}
+				m.config.InvidiousInstances = instances
+			}
+		case "download.ytdlp_extra_args":
+			switch list := value.(type) {
+			case []string:
+				m.config.YtDlpExtraArgs = list
+			case []interface{}:
+				args := make([]string, 0, len(list))
+				for _, item := range list {
+					if str, ok := item.(string); ok {
+						args = append(args, str)
+					}
+				}
+				m.config.YtDlpExtraArgs = args
+			}
+		case "download.format":
+			if format, ok := value.(string); ok {
+				m.config.DownloadFormat = format
+			}
+		case "download.max_height":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.DownloadMaxHeight = n
+			}
+		case "download.fps":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.DownloadFPS = n
+			}
+		case "download.user_agent":
+			if ua, ok := value.(string); ok {
+				m.config.DownloadUserAgent = ua
+			}
+		case "download.proxy":
+			m.config.DownloadProxy = value.(string)
+		case "download.retries":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.DownloadRetries = n
+			}
+		case "download.min_free_space":
+			if n, ok := value.(int); ok {
+				m.config.MinFreeSpace = int64(n)
+			}
+		case "download.max_dir_size":
+			if n, ok := value.(int); ok {
+				m.config.MaxDownloadDirSize = int64(n)
+			}
+		case "download.resource_limits.cpu_weight":
+			m.config.SubprocessCPUWeight = value.(int)
+		case "download.resource_limits.memory_max":
+			if n, ok := value.(int); ok {
+				m.config.SubprocessMemoryMax = int64(n)
+			}
+		case "download.resource_limits.cgroup_parent":
+			m.config.SubprocessCgroupParent = value.(string)
+		case "download.invidious_timeout":
+			if str, ok := value.(string); ok {
+				m.config.InvidiousTimeoutStr = str
+				if d, err := time.ParseDuration(str); err == nil && d > 0 {
+					m.config.InvidiousTimeout = d
+				}
+			}
 		case "upload.max_concurrent":
 			m.config.MaxConcurrentUploads = value.(int)
+		case "upload.max_concurrent_per_account":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.MaxUploadsPerAccount = n
+			}
 		case "upload.timeout":
 			if str, ok := value.(string); ok {
 				m.config.UploadTimeoutStr = str
@@ -533,6 +1820,37 @@ func (m *Manager) Update(updates map[string]interface{}) error {
 			}
 		case "upload.buffer_size":
 			m.config.UploadBufferSize = value.(int)
+		case "upload.failover_cooldown":
+			if str, ok := value.(string); ok {
+				m.config.FailoverCooldownStr = str
+				if d, err := time.ParseDuration(str); err == nil {
+					m.config.FailoverCooldown = d
+				}
+			}
+		case "database.retention_days":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.DatabaseRetentionDays = n
+			}
+		case "database.maintenance_schedule":
+			if str, ok := value.(string); ok && str != "" {
+				m.config.DatabaseMaintenanceSchedule = str
+			}
+		case "database.vacuum_free_percent":
+			if n, ok := value.(int); ok && n >= -1 && n != 0 && n <= 100 {
+				m.config.DatabaseVacuumFreePercent = n
+			}
+		case "transfer.daily_cap":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.DailyTransferCap = int64(n)
+			}
+		case "transfer.daily_download_cap":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.DailyDownloadCap = int64(n)
+			}
+		case "transfer.daily_upload_cap":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.DailyUploadCap = int64(n)
+			}
 		case "performance.worker_pool_size":
 			m.config.WorkerPoolSize = value.(int)
 		case "performance.http_client_timeout":
@@ -548,16 +1866,118 @@ func (m *Manager) Update(updates map[string]interface{}) error {
 			m.config.MaxConnsPerHost = value.(int)
 		case "performance.max_concurrent_io":
 			m.config.MaxConcurrentIO = value.(int)
+		case "performance.http_retries":
+			m.config.HTTPRetries = value.(int)
+		case "performance.http_retry_backoff":
+			if str, ok := value.(string); ok {
+				m.config.HTTPRetryBackoffStr = str
+				if d, err := time.ParseDuration(str); err == nil {
+					m.config.HTTPRetryBackoff = d
+				}
+			}
+		case "performance.proxy":
+			m.config.HTTPProxy = value.(string)
+		case "performance.rate_limits":
+			// Applied to the HTTP client at the next start
+			if limits, ok := value.(map[string]interface{}); ok {
+				rateLimits := make(map[string]float64, len(limits))
+				for host, rps := range limits {
+					switch n := rps.(type) {
+					case float64:
+						rateLimits[host] = n
+					case int:
+						rateLimits[host] = float64(n)
+					}
+				}
+				m.config.RateLimits = rateLimits
+			}
 		case "logging.dir":
 			m.config.LogDirectory = value.(string)
 		case "logging.output_file":
 			m.config.LogOutputFile = value.(string)
 		case "logging.error_file":
 			m.config.LogErrorFile = value.(string)
+		case "logging.max_size_mb":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.LogMaxSizeMB = n
+			}
+		case "logging.max_backups":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.LogMaxBackups = n
+			}
+		case "logging.max_age_days":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.LogMaxAgeDays = n
+			}
+		case "notify.webhook_url":
+			m.config.NotifyWebhookURL = value.(string)
+		case "translation.provider":
+			m.config.TranslationProvider = value.(string)
+		case "translation.api_key":
+			m.config.TranslationAPIKey = value.(string)
+		case "translation.api_url":
+			m.config.TranslationAPIURL = value.(string)
+		case "caption.use_description":
+			if v, ok := value.(bool); ok {
+				m.config.CaptionUseDescription = v
+			}
+		case "caption.strip_hashtags":
+			if v, ok := value.(bool); ok {
+				m.config.CaptionStripHashtags = v
+			}
+		case "caption.max_length":
+			if n, ok := value.(int); ok && n > 0 && n <= defaultCaptionMaxLength {
+				m.config.CaptionMaxLength = n
+			}
+		case "safety.moderation_url":
+			m.config.SafetyModerationURL = value.(string)
+		case "safety.moderation_token":
+			m.config.SafetyModerationToken = value.(string)
+		case "safety.frames":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.SafetyFrames = n
+			}
+		case "safety.timeout":
+			if str, ok := value.(string); ok {
+				m.config.SafetyTimeoutStr = str
+				if d, err := time.ParseDuration(str); err == nil && d > 0 {
+					m.config.SafetyTimeout = d
+				}
+			}
+		case "health.checks":
+			if list, ok := stringList(value); ok {
+				m.config.HealthChecks = list
+			}
+		case "health.cache_ttl":
+			if str, ok := value.(string); ok {
+				m.config.HealthCacheTTLStr = str
+				if d, err := time.ParseDuration(str); err == nil && d >= 0 {
+					m.config.HealthCacheTTL = d
+				}
+			}
+		case "invites.secret":
+			m.config.InviteSecret = value.(string)
+		case "invites.ttl":
+			if str, ok := value.(string); ok {
+				m.config.InviteTTLStr = str
+				if d, err := time.ParseDuration(str); err == nil {
+					m.config.InviteTTL = d
+				}
+			}
+		case "accounts_bootstrap":
+			m.config.AccountsBootstrapMode = value.(string)
+		case "accounts_allow_shared_tiktok":
+			m.config.AccountsAllowSharedTikTok = value.(bool)
+		case "accounts_auto_disable_after":
+			if n, ok := value.(int); ok && n >= 0 {
+				m.config.AccountsAutoDisableAfter = n
+			}
 		case "accounts":
 			if accounts, ok := value.([]AccountBootstrap); ok {
 				m.config.BootstrapAccounts = accounts
 			}
+		case "hooks":
+			m.config.Hooks = value.([]Hook)
 		}
 	}
 
@@ -565,6 +1985,127 @@ func (m *Manager) Update(updates map[string]interface{}) error {
 	return m.saveUnlocked(m.config)
 }
 
+// ReadBootstrap reads the account bootstrap section straight from the config file
+// without replacing the loaded configuration. It is used to compare the file on
+// disk with the database while the application is running.
+func (m *Manager) ReadBootstrap() ([]AccountBootstrap, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfgFile configFile
+	if err := yaml.Unmarshal(data, &cfgFile); err != nil {
+		return nil, "", fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	mode := cfgFile.AccountsBootstrap
+	if mode == "" {
+		mode = BootstrapModeSync
+	}
+	return cfgFile.bootstrapAccounts(), mode, nil
+}
+
+// bootstrapAccounts converts the accounts section of the file to account bootstraps
+func (f *configFile) bootstrapAccounts() []AccountBootstrap {
+	accounts := make([]AccountBootstrap, 0, len(f.Accounts))
+	for _, acc := range f.Accounts {
+		accounts = append(accounts, AccountBootstrap{
+			YouTubeChannelID:  acc.YouTubeChannelID,
+			TikTokAccountID:   acc.TikTokAccountID,
+			TikTokAccessToken: acc.TikTokAccessToken,
+			IsActive:          acc.IsActive,
+			PrivacyLevel:      acc.PrivacyLevel,
+			PostAsDraft:       acc.PostAsDraft,
+		})
+	}
+	return accounts
+}
+
+// stringList reads a list setting from an update, as decoded JSON ([]interface{}) or from Go
+// callers ([]string); empty and non-string items are dropped
+func stringList(value interface{}) ([]string, bool) {
+	switch list := value.(type) {
+	case []string:
+		return list, true
+	case []interface{}:
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			if str, ok := item.(string); ok && str != "" {
+				items = append(items, str)
+			}
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// validateScheduleUpdate checks cron changes in an update before anything is applied, so a bad
+// schedule or timezone never reaches the config file. Must be called with m.mu held.
+func (m *Manager) validateScheduleUpdate(updates map[string]interface{}) error {
+	if value, ok := updates["database.maintenance_schedule"]; ok {
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("database.maintenance_schedule must be a string")
+		}
+		loc, err := m.config.ScheduleLocation()
+		if err != nil {
+			return err
+		}
+		if _, err := CheckSchedule(str, loc, 0, 0); err != nil {
+			return fmt.Errorf("database.maintenance_schedule: %w", err)
+		}
+	}
+
+	_, hasSchedule := updates["cron.schedule"]
+	_, hasTimezone := updates["cron.timezone"]
+	_, hasFloor := updates["cron.min_interval"]
+	if !hasSchedule && !hasTimezone && !hasFloor {
+		return nil
+	}
+
+	candidate := *m.config
+	if value, ok := updates["cron.schedule"]; ok {
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("cron.schedule must be a string")
+		}
+		candidate.CronSchedule = str
+	}
+	if value, ok := updates["cron.timezone"]; ok {
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("cron.timezone must be a string")
+		}
+		candidate.CronTimezone = str
+	}
+	if value, ok := updates["cron.min_interval"]; ok {
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("cron.min_interval must be a duration string")
+		}
+		d, err := time.ParseDuration(str)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid cron.min_interval %q", str)
+		}
+		candidate.CronMinInterval = d
+	}
+
+	loc, err := candidate.ScheduleLocation()
+	if err != nil {
+		return err
+	}
+	if !hasSchedule {
+		// Only the schedule itself is held to the floor; a timezone change just has to load
+		return nil
+	}
+	_, err = CheckSchedule(candidate.CronSchedule, loc, candidate.CronMinInterval, 0)
+	return err
+}
+
 // Reload reloads configuration from file
 func (m *Manager) Reload() (*Config, error) {
 	return m.Load()
@@ -573,26 +2114,66 @@ func (m *Manager) Reload() (*Config, error) {
 // createDefaultConfig creates a default configuration file
 func (m *Manager) createDefaultConfig() (*Config, error) {
 	cfg := &Config{
-		ServerPort:             "8080",
-		TikTokRegion:           "JP",
-		TikTokBaseURL:          "https://open-api.tiktok.com",
-		TikTokUploadInitPath:   "/video/upload/",
-		TikTokPublishPath:      "/video/publish/",
-		CronSchedule:           "* * * * * *",
-		DownloadDir:            "./downloads",
-		DatabaseURL:            "sqlite3:./data.db",
-		MaxConcurrentDownloads: 5,
-		MaxConcurrentUploads:   3,
-		DownloadTimeout:        10 * time.Minute,
-		UploadTimeout:          15 * time.Minute,
-		HTTPClientTimeout:      60 * time.Second, // Increased from 30s
-		MaxIdleConns:           300,              // Increased from 200
-		MaxConnsPerHost:        100,              // Increased from 50
-		DownloadBufferSize:     4 * 1024 * 1024,  // 4MB instead of 1MB
-		UploadBufferSize:       1024 * 1024,
-		LogDirectory:           "./logs",
-		LogOutputFile:          "app.log",
-		LogErrorFile:           "app.error.log",
+		ServerEnabled:               true,
+		ServerPort:                  "8080",
+		ServerLocale:                "en",
+		YouTubeDiscoveryMode:        DiscoveryModeAPI,
+		YouTubeStatusInterval:       defaultChannelStatusInterval,
+		TikTokRegion:                "JP",
+		TikTokBaseURL:               "https://open-api.tiktok.com",
+		TikTokUploadInitPath:        "/video/upload/",
+		TikTokPublishPath:           "/video/publish/",
+		TikTokInboxInitPath:         "/v2/post/publish/inbox/video/init/",
+		TikTokPublishStatusPath:     "/v2/post/publish/status/fetch/",
+		TikTokCreatorInfoPath:       "/v2/post/publish/creator_info/query/",
+		TikTokUploadMethod:          "multipart",
+		TikTokUploadFieldName:       "video",
+		TikTokCookiesClaimWindow:    defaultCookiesClaimWindow,
+		CronSchedule:                "* * * * * *",
+		CronMinInterval:             time.Minute,
+		CronFreshWindow:             defaultFreshWindow,
+		DownloadDir:                 "./downloads",
+		DatabaseURL:                 "sqlite3:./data.db",
+		DatabaseMaintenanceSchedule: defaultMaintenanceSchedule,
+		DatabaseVacuumFreePercent:   defaultVacuumFreePercent,
+		MaxConcurrentDownloads:      5,
+		MaxConcurrentUploads:        3,
+		MaxUploadsPerAccount:        defaultUploadsPerAccount,
+		DownloadTimeout:             10 * time.Minute,
+		UploadTimeout:               15 * time.Minute,
+		FailoverCooldown:            time.Hour,
+		InvidiousTimeout:            20 * time.Second,
+		MinFreeSpace:                defaultMinFreeSpace,
+		YtDlpExtraArgs:              defaultYtDlpExtraArgs(),
+		DownloadFormat:              defaultDownloadFormat,
+		DownloadRetries:             defaultDownloadRetries,
+		TikTokCommentMinInterval:    2 * time.Minute,
+		TikTokAPIMaxSize:            defaultAPIMaxSize,
+		TikTokAPIMaxDuration:        defaultAPIMaxDuration,
+		TikTokWebMaxSize:            defaultWebMaxSize,
+		TikTokWebMaxDuration:        defaultWebMaxDuration,
+		InviteTTL:                   72 * time.Hour,
+		HealthCacheTTL:              defaultHealthCacheTTL,
+		SafetyFrames:                defaultSafetyFrames,
+		SafetyTimeout:               defaultSafetyTimeout,
+		CaptionUseDescription:       true,
+		CaptionMaxLength:            defaultCaptionMaxLength,
+		ShutdownGrace:               2 * time.Minute,
+		WriteRetryBudget:            10 * time.Second,
+		HTTPClientTimeout:           60 * time.Second, // Increased from 30s
+		MaxIdleConns:                300,              // Increased from 200
+		MaxConnsPerHost:             100,              // Increased from 50
+		HTTPRetries:                 2,
+		HTTPRetryBackoff:            500 * time.Millisecond,
+		DownloadBufferSize:          4 * 1024 * 1024, // 4MB instead of 1MB
+		UploadBufferSize:            1024 * 1024,
+		LogDirectory:                "./logs",
+		LogOutputFile:               "app.log",
+		LogErrorFile:                "app.error.log",
+		LogMaxSizeMB:                defaultLogMaxSizeMB,
+		LogMaxBackups:               defaultLogMaxBackups,
+		ServerPublicURL:             "http://localhost:8080",
+		AccountsBootstrapMode:       BootstrapModeSync,
 	}
 
 	// Auto-calculate worker pool size
@@ -617,6 +2198,12 @@ func (m *Manager) createDefaultConfig() (*Config, error) {
 // Global config manager instance
 var globalManager *Manager
 
+// UseConfigFile makes Load and GetManager read the given file instead of discovering
+// config/config.yaml or config.yaml; call it before either
+func UseConfigFile(path string) {
+	globalManager = NewManager(path)
+}
+
 // Load loads configuration from YAML file (backward compatibility)
 func Load() (*Config, error) {
 	if globalManager == nil {
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestManager writes yaml to a config file in a temporary directory and returns a manager for
+// it that ignores the environment of the test run
+func newTestManager(t *testing.T, yaml string) *Manager {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	manager := NewManager(path)
+	manager.SetEnvPrefix("AUTO_UPLOAD_CONFIG_TEST")
+	return manager
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	manager := newTestManager(t, `
+server:
+  port: "9090"
+  public_url: https://uploads.example.com
+  trusted_proxies: [10.0.0.0/8, unix]
+tiktok:
+  api_key: ck
+  comment_path: /v2/comment/create/
+  comment_min_interval: 5m
+  api_limits:
+    max_size: 1000
+    max_duration: 10m
+  cookies_claim:
+    host: worker-1
+    refuse: true
+download:
+  resource_limits:
+    cpu_weight: 50
+logging:
+  max_backups: 0
+accounts:
+  - youtube_channel_id: UC1
+    tiktok_account_id: tt1
+    tiktok_access_token: act.1
+    is_active: false
+    privacy_level: SELF_ONLY
+    post_as_draft: true
+  - youtube_channel_id: UC2
+    tiktok_account_id: tt2
+`)
+	cfg, err := manager.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := manager.Save(cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	saved, err := manager.Reload()
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"server.port", saved.ServerPort, "9090"},
+		{"server.public_url", saved.ServerPublicURL, "https://uploads.example.com"},
+		{"server.trusted_proxies", saved.TrustedProxies, []string{"10.0.0.0/8", "unix"}},
+		{"tiktok.comment_path", saved.TikTokCommentPath, "/v2/comment/create/"},
+		{"tiktok.comment_min_interval", saved.TikTokCommentMinInterval, 5 * time.Minute},
+		{"tiktok.api_limits.max_size", saved.TikTokAPIMaxSize, int64(1000)},
+		{"tiktok.api_limits.max_duration", saved.TikTokAPIMaxDuration, 10 * time.Minute},
+		{"tiktok.cookies_claim.host", saved.TikTokCookiesClaimHost, "worker-1"},
+		{"tiktok.cookies_claim.refuse", saved.TikTokCookiesClaimRefuse, true},
+		{"download.resource_limits.cpu_weight", saved.SubprocessCPUWeight, 50},
+		{"logging.max_backups", saved.LogMaxBackups, 0},
+		{"accounts", saved.BootstrapAccounts, cfg.BootstrapAccounts},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s after save = %#v, want %#v", c.name, c.got, c.want)
+		}
+	}
+
+	accounts, mode, err := manager.ReadBootstrap()
+	if err != nil {
+		t.Fatalf("ReadBootstrap() error = %v", err)
+	}
+	if mode != BootstrapModeSync {
+		t.Errorf("ReadBootstrap() mode = %q, want %q", mode, BootstrapModeSync)
+	}
+	if len(accounts) != 2 {
+		t.Fatalf("ReadBootstrap() returned %d accounts, want 2", len(accounts))
+	}
+	first := accounts[0]
+	if first.TikTokAccessToken != "act.1" || first.PrivacyLevel != "SELF_ONLY" ||
+		first.IsActive == nil || *first.IsActive || first.PostAsDraft == nil || !*first.PostAsDraft {
+		t.Errorf("ReadBootstrap() first account = %+v", first)
+	}
+}
+
+func TestTrustedProxiesValidated(t *testing.T) {
+	if _, err := newTestManager(t, "server:\n  trusted_proxies: [10.0.0.300]\n").Load(); err == nil ||
+		!strings.Contains(err.Error(), "server.trusted_proxies") {
+		t.Errorf("Load() with a bad trusted proxy error = %v", err)
+	}
+
+	manager := newTestManager(t, "server:\n  trusted_proxies: [127.0.0.1]\n")
+	if _, err := manager.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := manager.Update(map[string]interface{}{"server.trusted_proxies": []interface{}{"proxy.internal"}}); err == nil {
+		t.Error("Update() with a host name as trusted proxy succeeded")
+	}
+	if err := manager.Update(map[string]interface{}{"server.trusted_proxies": []interface{}{"::1", "fd00::/8"}}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := manager.Get().TrustedProxies; !reflect.DeepEqual(got, []string{"::1", "fd00::/8"}) {
+		t.Errorf("TrustedProxies after update = %v", got)
+	}
+}
+
+func TestLoadDropIns(t *testing.T) {
+	manager := newTestManager(t, `
+server:
+  port: "9090"
+tiktok:
+  api_key: ck
+  comment_path: /v2/comment/create/
+  api_limits:
+    max_size: 1000
+    max_duration: 10m
+accounts:
+  - youtube_channel_id: UC1
+    tiktok_account_id: tt1
+  - youtube_channel_id: UC2
+    tiktok_account_id: tt2
+`)
+	dir := filepath.Join(filepath.Dir(manager.configPath), DropInDir)
+	dropIns := map[string]string{
+		"10-accounts.yaml": `
+accounts:
+  - youtube_channel_id: UC3
+    tiktok_account_id: tt3
+`,
+		"20-secrets.yml": `
+tiktok:
+  api_secret: first
+  api_limits:
+    max_size: 2000
+`,
+		"30-later.yaml": `
+tiktok:
+  api_secret: cs
+`,
+		"notes.txt": "tiktok: [not yaml",
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range dropIns {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := manager.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		// Nested sections merge key by key: siblings of an overridden key keep the base values
+		{"server.port", cfg.ServerPort, "9090"},
+		{"tiktok.api_key", cfg.TikTokAPIKey, "ck"},
+		{"tiktok.comment_path", cfg.TikTokCommentPath, "/v2/comment/create/"},
+		{"tiktok.api_secret", cfg.TikTokAPISecret, "cs"},
+		{"tiktok.api_limits.max_size", cfg.TikTokAPIMaxSize, int64(2000)},
+		{"tiktok.api_limits.max_duration", cfg.TikTokAPIMaxDuration, 10 * time.Minute},
+		// Lists are replaced, not appended to
+		{"accounts", len(cfg.BootstrapAccounts), 1},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %#v, want %#v", c.name, c.got, c.want)
+		}
+	}
+	if len(cfg.BootstrapAccounts) == 1 && cfg.BootstrapAccounts[0].YouTubeChannelID != "UC3" {
+		t.Errorf("accounts = %+v, want only UC3 from config.d", cfg.BootstrapAccounts)
+	}
+
+	wantOverrides := map[string]string{
+		"accounts":                   filepath.Join(dir, "10-accounts.yaml"),
+		"tiktok.api_secret":          filepath.Join(dir, "30-later.yaml"),
+		"tiktok.api_limits.max_size": filepath.Join(dir, "20-secrets.yml"),
+	}
+	if got := manager.DropInOverrides(); !reflect.DeepEqual(got, wantOverrides) {
+		t.Errorf("DropInOverrides() = %v, want %v", got, wantOverrides)
+	}
+
+	// Saving writes the base file's own values, not the merged ones
+	cfg.ServerPort = "9191"
+	if err := manager.Save(cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	base, err := manager.Reload()
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if base.ServerPort != "9191" || base.TikTokAPISecret != "" || base.TikTokAPIMaxSize != 1000 || len(base.BootstrapAccounts) != 2 {
+		t.Errorf("base file after save: port %s, api_secret %q, max_size %d, %d accounts; want 9191, \"\", 1000, 2",
+			base.ServerPort, base.TikTokAPISecret, base.TikTokAPIMaxSize, len(base.BootstrapAccounts))
+	}
+}
+
+func TestLoadDropInParseError(t *testing.T) {
+	manager := newTestManager(t, "server:\n  port: \"9090\"\n")
+	dir := filepath.Join(filepath.Dir(manager.configPath), DropInDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("tiktok: [unclosed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.Load(); err == nil || !strings.Contains(err.Error(), "broken.yaml") {
+		t.Errorf("Load() error = %v, want it to name broken.yaml", err)
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DropInDir is the directory next to the config file whose YAML files are merged over it
+const DropInDir = "config.d"
+
+// dropInFiles returns the .yaml and .yml files of the config.d directory next to configPath,
+// sorted by name. A missing directory has no files.
+func dropInFiles(configPath string) ([]string, error) {
+	dir := filepath.Join(filepath.Dir(configPath), DropInDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// mergeDropIns merges the drop-in files over the base YAML and returns the merged YAML with the
+// keys the drop-ins set, mapped to the file that set each. Sections merge key by key and later
+// files win; lists (accounts, hooks, tiktok.apps) and scalars are replaced as a whole.
+func mergeDropIns(base []byte, files []string) ([]byte, map[string]string, error) {
+	merged := make(map[string]any)
+	if err := yaml.Unmarshal(base, &merged); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if merged == nil {
+		merged = make(map[string]any)
+	}
+
+	keys := make(map[string]string)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		var overlay map[string]any
+		if err := yaml.Unmarshal(data, &overlay); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		mergeYAMLMaps(merged, overlay)
+		for _, key := range overlayKeys(reflect.TypeOf(configFile{}), overlay, "") {
+			keys[key] = file
+		}
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to merge %s: %w", DropInDir, err)
+	}
+	return data, keys, nil
+}
+
+// mergeYAMLMaps copies overlay into dst, merging nested maps and replacing everything else
+func mergeYAMLMaps(dst, overlay map[string]any) {
+	for key, value := range overlay {
+		src, ok := value.(map[string]any)
+		existing, isMap := dst[key].(map[string]any)
+		if ok && isMap {
+			mergeYAMLMaps(existing, src)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// overlayKeys returns the dotted keys of the YAML structure a drop-in sets: nested sections are
+// followed down to their fields, and lists and maps (performance.rate_limits) count as one key.
+// Keys the structure does not know are left out.
+func overlayKeys(t reflect.Type, overlay map[string]any, prefix string) []string {
+	var keys []string
+	for name, value := range overlay {
+		for i := 0; i < t.NumField(); i++ {
+			if strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0] != name {
+				continue
+			}
+			key := name
+			if prefix != "" {
+				key = prefix + "." + name
+			}
+			section, ok := value.(map[string]any)
+			if t.Field(i).Type.Kind() == reflect.Struct && ok {
+				keys = append(keys, overlayKeys(t.Field(i).Type, section, key)...)
+			} else {
+				keys = append(keys, key)
+			}
+			break
+		}
+	}
+	return keys
+}
+
+// keepBaseValues puts the base file's values back for keys set by drop-ins, so saving writes the
+// base file without copying config.d into it
+func keepBaseValues(cfgFile *configFile, baseValues *configFile, dropInKeys map[string]string) {
+	if len(dropInKeys) == 0 || baseValues == nil {
+		return
+	}
+	current := reflect.ValueOf(cfgFile).Elem()
+	original := reflect.ValueOf(baseValues).Elem()
+	for key := range dropInKeys {
+		configField(current, key).Set(configField(original, key))
+	}
+}
+
+// DropInOverrides returns the config keys set by files in config.d, with the file that set each
+func (m *Manager) DropInOverrides() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	overrides := make(map[string]string, len(m.dropInKeys))
+	for key, file := range m.dropInKeys {
+		overrides[key] = file
+	}
+	return overrides
+}
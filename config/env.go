@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEnvPrefix starts the environment variables that override config keys
+const DefaultEnvPrefix = "AUTOUPLOAD"
+
+// EnvPrefixVar changes the prefix, e.g. CONFIG_ENV_PREFIX=MYAPP reads MYAPP_TIKTOK_API_SECRET
+const EnvPrefixVar = "CONFIG_ENV_PREFIX"
+
+// EnvVarName maps a config key to its environment variable: dots become underscores and the key is
+// upper-cased, so tiktok.api_secret is AUTOUPLOAD_TIKTOK_API_SECRET with the default prefix
+func EnvVarName(prefix, key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+// envPrefix returns the prefix set with SetEnvPrefix, CONFIG_ENV_PREFIX or the default
+func (m *Manager) envPrefix() string {
+	if m.envPrefixSet {
+		return m.envPrefixValue
+	}
+	if prefix, ok := os.LookupEnv(EnvPrefixVar); ok {
+		return prefix
+	}
+	return DefaultEnvPrefix
+}
+
+// SetEnvPrefix sets the prefix of override variables; an empty prefix uses bare key names
+func (m *Manager) SetEnvPrefix(prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.envPrefixValue = prefix
+	m.envPrefixSet = true
+}
+
+// EnvOverrides returns the config keys whose values came from environment variables, with the
+// variable that set each
+func (m *Manager) EnvOverrides() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	overrides := make(map[string]string, len(m.envKeys))
+	for key, name := range m.envKeys {
+		overrides[key] = name
+	}
+	return overrides
+}
+
+// applyEnvOverrides replaces YAML values with set, non-empty environment variables and returns the
+// overridden keys with their variable names. Lists of structs (tiktok.apps, hooks, accounts) and
+// maps (performance.rate_limits) can only be set in the file; []string keys take comma-separated
+// values.
+func applyEnvOverrides(cfgFile *configFile, prefix string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	err := walkConfigFields(reflect.ValueOf(cfgFile).Elem(), "", func(key string, field reflect.Value, duration bool) error {
+		name := EnvVarName(prefix, key)
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			return nil
+		}
+		if err := setEnvValue(field, value, duration); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		overrides[key] = name
+		return nil
+	})
+	return overrides, err
+}
+
+// keepFileValues puts the values read from the YAML file back for env-sourced keys, so saving
+// never writes secrets that only exist in the environment
+func keepFileValues(cfgFile *configFile, fileValues *configFile, envKeys map[string]string) {
+	if len(envKeys) == 0 || fileValues == nil {
+		return
+	}
+	original := reflect.ValueOf(fileValues).Elem()
+	_ = walkConfigFields(reflect.ValueOf(cfgFile).Elem(), "", func(key string, field reflect.Value, _ bool) error {
+		if _, ok := envKeys[key]; ok {
+			field.Set(configField(original, key))
+		}
+		return nil
+	})
+}
+
+// walkConfigFields calls fn for every leaf field of the YAML structure with its dotted key.
+// duration is set for fields tagged env:"duration".
+func walkConfigFields(v reflect.Value, prefix string, fn func(key string, field reflect.Value, duration bool) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := walkConfigFields(field, key, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if (field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.String) || field.Kind() == reflect.Map {
+			continue
+		}
+		if err := fn(key, field, t.Field(i).Tag.Get("env") == "duration"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configField returns the field of the YAML structure with the dotted key
+func configField(v reflect.Value, key string) reflect.Value {
+	for _, part := range strings.Split(key, ".") {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0] == part {
+				v = v.Field(i)
+				break
+			}
+		}
+	}
+	return v
+}
+
+// setEnvValue parses an environment value into a YAML field
+func setEnvValue(field reflect.Value, value string, duration bool) error {
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setEnvValue(ptr.Elem(), value, duration); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		if duration {
+			if _, err := time.ParseDuration(value); err != nil {
+				return fmt.Errorf("%q is not a duration (e.g. 90s, 15m, 2h)", value)
+			}
+		}
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean (true or false)", value)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
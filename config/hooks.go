@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Hook phases
+const (
+	HookPhasePostDownload = "post_download" // File is downloaded (or cut, for clips); hooks may replace it
+	HookPhasePreUpload    = "pre_upload"    // Right before the upload; hooks may replace the file
+	HookPhasePostPublish  = "post_publish"  // Upload succeeded; results are informational only
+)
+
+// defaultHookTimeout bounds a hook without its own timeout
+const defaultHookTimeout = time.Minute
+
+// Hook is a custom processing step: an external command or an HTTP endpoint that receives the
+// video as JSON at one phase. Hooks only run for accounts that list them in settings.hooks.
+type Hook struct {
+	Name  string `yaml:"name"`
+	Phase string `yaml:"phase"`
+
+	// Command is run directly (no shell) with the payload on stdin; URL receives it as a POST body.
+	// Exactly one of the two is set.
+	Command []string `yaml:"command,omitempty"`
+	URL     string   `yaml:"url,omitempty"`
+
+	// Timeout bounds one run, e.g. "2m" (default 1m)
+	Timeout string `yaml:"timeout,omitempty"`
+
+	// Env adds variables for commands; the service's own environment (API keys, tokens) is not passed
+	Env map[string]string `yaml:"env,omitempty"`
+
+	// AbortOnFailure fails the video when the hook errors, times out or exits non-zero;
+	// otherwise the failure is logged and processing continues
+	AbortOnFailure bool `yaml:"abort_on_failure,omitempty"`
+}
+
+// TimeoutDuration returns the hook's run timeout
+func (h Hook) TimeoutDuration() time.Duration {
+	if d, err := time.ParseDuration(h.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return defaultHookTimeout
+}
+
+// HookByName returns the configured hook with the given name
+func (c *Config) HookByName(name string) (Hook, bool) {
+	for _, hook := range c.Hooks {
+		if hook.Name == name {
+			return hook, true
+		}
+	}
+	return Hook{}, false
+}
+
+// validateHooks rejects unnamed, duplicate or incomplete hooks
+func validateHooks(hooks []Hook) error {
+	seen := make(map[string]bool, len(hooks))
+	for i, hook := range hooks {
+		name := strings.TrimSpace(hook.Name)
+		switch {
+		case name == "":
+			return fmt.Errorf("hooks[%d]: name is required", i)
+		case seen[name]:
+			return fmt.Errorf("hooks[%d]: duplicate name %q", i, name)
+		case hook.Phase != HookPhasePostDownload && hook.Phase != HookPhasePreUpload && hook.Phase != HookPhasePostPublish:
+			return fmt.Errorf("hooks[%d] (%s): phase must be %s, %s or %s", i, name,
+				HookPhasePostDownload, HookPhasePreUpload, HookPhasePostPublish)
+		case (len(hook.Command) == 0) == (hook.URL == ""):
+			return fmt.Errorf("hooks[%d] (%s): set either command or url", i, name)
+		}
+		if hook.URL != "" {
+			if u, err := url.Parse(hook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("hooks[%d] (%s): url must be an absolute http(s) URL", i, name)
+			}
+		}
+		if hook.Timeout != "" {
+			if d, err := time.ParseDuration(hook.Timeout); err != nil || d <= 0 {
+				return fmt.Errorf("hooks[%d] (%s): invalid timeout %q", i, name, hook.Timeout)
+			}
+		}
+		seen[name] = true
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// UnixListenPrefix starts a server.listen value naming a Unix domain socket, e.g.
+// unix:///run/auto_upload_tiktok/api.sock
+const UnixListenPrefix = "unix://"
+
+// ServerListenAddr returns the TCP address the HTTP API listens on: server.listen, or all
+// interfaces on server.port when it is unset. It is empty when the API listens on a Unix socket.
+func (c *Config) ServerListenAddr() string {
+	if _, ok := c.ServerSocketPath(); ok {
+		return ""
+	}
+	if c.ServerListen != "" {
+		return c.ServerListen
+	}
+	return ":" + c.ServerPort
+}
+
+// ServerSocketPath returns the socket path of server.listen when it names a Unix domain socket
+func (c *Config) ServerSocketPath() (string, bool) {
+	path, ok := strings.CutPrefix(c.ServerListen, UnixListenPrefix)
+	return path, ok
+}
+
+// validateServerListen checks server.listen. TikTok and YouTube redirect the browser to the OAuth
+// callbacks over TCP, so with a socket listener the redirect URIs must not default to this server.
+func validateServerListen(cfg *Config) error {
+	if cfg.ServerListen == "" {
+		return nil
+	}
+	path, ok := cfg.ServerSocketPath()
+	if !ok {
+		if _, _, err := net.SplitHostPort(cfg.ServerListen); err != nil {
+			return fmt.Errorf("server.listen %q: want host:port or %s/path/to.sock", cfg.ServerListen, UnixListenPrefix)
+		}
+		return nil
+	}
+	if path == "" {
+		return fmt.Errorf("server.listen %q: the socket path is missing", cfg.ServerListen)
+	}
+	if !cfg.ServerEnabled {
+		return nil
+	}
+
+	if !reachableRedirect(cfg.TikTokRedirectURI) {
+		return fmt.Errorf("server.listen is the Unix socket %s, but TikTok can only redirect to the "+
+			"OAuth callback (/api/tiktok/callback, used by authorize and invite links) over TCP: set "+
+			"tiktok.redirect_uri to a URL that reaches it, e.g. through a reverse proxy, or listen on host:port", path)
+	}
+	if cfg.YouTubeOAuthClientID != "" && !reachableRedirect(cfg.YouTubeRedirectURI) {
+		return fmt.Errorf("server.listen is the Unix socket %s, but YouTube can only redirect to the "+
+			"OAuth callback over TCP: set youtube.redirect_uri to a URL that reaches it, or clear "+
+			"youtube.oauth_client_id", path)
+	}
+	return nil
+}
+
+// reachableRedirect reports whether an OAuth redirect URI is set and does not name this host,
+// which has no TCP listener when the API is on a Unix socket
+func reachableRedirect(redirectURI string) bool {
+	if redirectURI == "" {
+		return false
+	}
+	u, err := url.Parse(redirectURI)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return false
+	}
+	return true
+}
+
+// TrustedProxyUnix in server.trusted_proxies trusts connections on the Unix socket listener,
+// which have no address of their own
+const TrustedProxyUnix = "unix"
+
+// ParseTrustedProxies parses server.trusted_proxies: IP addresses, CIDR ranges and unix
+func ParseTrustedProxies(entries []string) (nets []*net.IPNet, unix bool, err error) {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == TrustedProxyUnix {
+			unix = true
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, false, fmt.Errorf("server.trusted_proxies %q: want an IP address, a CIDR range or %s", entry, TrustedProxyUnix)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, unix, nil
+}
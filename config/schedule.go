@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cron "github.com/robfig/cron/v3"
+)
+
+// schedulePreviewRuns is how many upcoming runs are scanned for the minimum interval check
+const schedulePreviewRuns = 100
+
+// scheduleParser matches cron.WithSeconds so validation agrees with the scheduler
+var scheduleParser = cron.NewParser(
+	cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// ScheduleCheck is the result of validating a cron expression
+type ScheduleCheck struct {
+	Normalized  string        // Expression as the scheduler will use it
+	Location    string        // Timezone the expression is evaluated in
+	NextRuns    []time.Time   // Upcoming fire times
+	MinInterval time.Duration // Shortest gap between upcoming runs
+}
+
+// NormalizeSchedule makes standard five-field expressions compatible with a seconds field
+func NormalizeSchedule(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if len(strings.Fields(expr)) == 5 {
+		return "0 " + expr
+	}
+	return expr
+}
+
+// ScheduleLocation returns the timezone cron schedules run in (cron.timezone, default local time)
+func (c *Config) ScheduleLocation() (*time.Location, error) {
+	if c.CronTimezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.CronTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron.timezone %q: %w", c.CronTimezone, err)
+	}
+	return loc, nil
+}
+
+// CheckSchedule parses a cron expression and previews its next runs in loc. Expressions that
+// fire more often than floor are rejected (a zero floor disables the check).
+func CheckSchedule(expr string, loc *time.Location, floor time.Duration, previewCount int) (*ScheduleCheck, error) {
+	normalized := NormalizeSchedule(expr)
+	if normalized == "" {
+		return nil, fmt.Errorf("cron schedule is empty")
+	}
+
+	schedule, err := scheduleParser.Parse(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron schedule %q: %w", expr, err)
+	}
+
+	check := &ScheduleCheck{
+		Normalized: normalized,
+		Location:   loc.String(),
+	}
+
+	last := time.Now().In(loc)
+	fired := 0
+	for i := 0; i < schedulePreviewRuns; i++ {
+		next := schedule.Next(last)
+		if next.IsZero() {
+			break
+		}
+		if i > 0 {
+			if gap := next.Sub(last); check.MinInterval == 0 || gap < check.MinInterval {
+				check.MinInterval = gap
+			}
+		}
+		if i < previewCount {
+			check.NextRuns = append(check.NextRuns, next)
+		}
+		fired++
+		last = next
+	}
+
+	if fired == 0 {
+		return nil, fmt.Errorf("cron schedule %q never fires", expr)
+	}
+	if floor > 0 && check.MinInterval > 0 && check.MinInterval < floor {
+		return nil, fmt.Errorf("cron schedule %q fires every %s, more often than the %s minimum (cron.min_interval)",
+			expr, check.MinInterval, floor)
+	}
+	return check, nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultTikTokApp names the credential set built from tiktok.api_key and tiktok.api_secret
+const DefaultTikTokApp = "default"
+
+// ErrUnknownTikTokApp is returned when a credential set name is not configured
+var ErrUnknownTikTokApp = errors.New("unknown TikTok credential set")
+
+// TikTokApp is one TikTok developer app's credentials. Tokens issued under one app can only be
+// refreshed with that app's client key and secret.
+type TikTokApp struct {
+	Name      string `yaml:"name"`
+	APIKey    string `yaml:"api_key"`
+	APISecret string `yaml:"api_secret"`
+}
+
+// TikTokAppByName returns the named credential set; an empty name is the default set
+func (c *Config) TikTokAppByName(name string) (TikTokApp, error) {
+	if name == "" || name == DefaultTikTokApp {
+		return TikTokApp{Name: DefaultTikTokApp, APIKey: c.TikTokAPIKey, APISecret: c.TikTokAPISecret}, nil
+	}
+	for _, app := range c.TikTokApps {
+		if app.Name == name {
+			return app, nil
+		}
+	}
+	return TikTokApp{}, fmt.Errorf("%w %q", ErrUnknownTikTokApp, name)
+}
+
+// TikTokAppNames lists the configured credential set names, default first
+func (c *Config) TikTokAppNames() []string {
+	names := []string{DefaultTikTokApp}
+	for _, app := range c.TikTokApps {
+		names = append(names, app.Name)
+	}
+	return names
+}
+
+// validateTikTokApps rejects unnamed, duplicate or incomplete credential sets
+func validateTikTokApps(apps []TikTokApp) error {
+	seen := make(map[string]bool, len(apps))
+	for i, app := range apps {
+		name := strings.TrimSpace(app.Name)
+		switch {
+		case name == "":
+			return fmt.Errorf("tiktok.apps[%d]: name is required", i)
+		case name == DefaultTikTokApp:
+			return fmt.Errorf("tiktok.apps[%d]: %q is reserved for tiktok.api_key/api_secret", i, DefaultTikTokApp)
+		case seen[name]:
+			return fmt.Errorf("tiktok.apps[%d]: duplicate name %q", i, name)
+		case app.APIKey == "" || app.APISecret == "":
+			return fmt.Errorf("tiktok.apps[%d] (%s): api_key and api_secret are required", i, name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
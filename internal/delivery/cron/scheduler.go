@@ -2,8 +2,9 @@ package cron
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"sync"
 	"time"
 
 	cron "github.com/robfig/cron/v3"
@@ -13,14 +14,77 @@ import (
 	"auto_upload_tiktok/internal/usecase"
 )
 
+// Job names reported by Jobs and accepted by RunJob
+const (
+	JobMonitorAccounts     = "monitor_accounts"
+	JobProcessVideos       = "process_videos"
+	JobBackfillPublishedAt = "backfill_published_at"
+	JobCheckPublishing     = "check_publishing"
+	JobDatabaseMaintenance = "database_maintenance"
+	JobCheckViewGates      = "check_view_gates"
+)
+
+// Time limits of a single job run; process-once applies the same ones
+const (
+	MonitorTimeout      = 5 * time.Minute
+	ProcessTimeout      = 10 * time.Minute
+	PublishCheckTimeout = 2 * time.Minute
+	MaintenanceTimeout  = 30 * time.Minute
+)
+
+var (
+	// ErrUnknownJob is returned by RunJob for a name no job is registered under
+	ErrUnknownJob = errors.New("unknown job")
+
+	// ErrJobRunning is returned by RunJob while the job is still running
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// JobStatus describes a registered job and its most recent run
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	Running        bool       `json:"running"`
+	Runs           int        `json:"runs"`
+	LastStart      *time.Time `json:"last_start,omitempty"`
+	LastFinish     *time.Time `json:"last_finish,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextRun        *time.Time `json:"next_run,omitempty"`
+}
+
+// job is a registered cron entry with the metadata of its runs
+type job struct {
+	name     string
+	schedule string
+	entryID  cron.EntryID
+	run      func()
+
+	running      int
+	requested    bool // RunJob started it and it has not recorded its start yet
+	runs         int
+	lastStart    time.Time
+	lastFinish   time.Time
+	lastDuration time.Duration
+	lastError    string
+}
+
 // Scheduler manages cron jobs for the application
 type Scheduler struct {
 	cron           *cron.Cron
 	config         *config.Config
 	accountMonitor *usecase.AccountMonitor
 	videoProcessor *usecase.VideoProcessor
+	dispatcher     *usecase.ImmediateDispatcher // Optional: processes newly discovered videos right away
+	pipeline       *usecase.PipelineSwitch      // Optional: global pause that skips monitoring and processing
+	maintenance    *usecase.DatabaseMaintenance // Optional: scheduled database pruning and vacuuming
 	ctx            context.Context
 	cancel         context.CancelFunc
+	workCtx        context.Context // Parent of video processing; outlives Stop so in-flight work can drain
+	cancelWork     context.CancelFunc
+
+	mu   sync.Mutex
+	jobs []*job // In registration order
 }
 
 // NewScheduler creates a new cron scheduler
@@ -31,13 +95,16 @@ func NewScheduler(
 ) *Scheduler {
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
+	workCtx, cancelWork := context.WithCancel(context.Background())
 
-	if accountMonitor != nil {
-		accountMonitor.SetBaseContext(ctx)
+	loc, err := cfg.ScheduleLocation()
+	if err != nil {
+		logger.Error().Printf("%v, using local time", err)
+		loc = time.Local
 	}
 
-	// Create cron with seconds support
-	c := cron.New(cron.WithSeconds())
+	// Create cron with seconds support in the configured timezone
+	c := cron.New(cron.WithSeconds(), cron.WithLocation(loc))
 
 	return &Scheduler{
 		cron:           c,
@@ -46,27 +113,83 @@ func NewScheduler(
 		videoProcessor: videoProcessor,
 		ctx:            ctx,
 		cancel:         cancel,
+		workCtx:        workCtx,
+		cancelWork:     cancelWork,
 	}
 }
 
+// SetDispatcher runs the immediate dispatcher between Start and Stop; its videos drain and are
+// cancelled together with the processing job's.
+func (s *Scheduler) SetDispatcher(dispatcher *usecase.ImmediateDispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetPipelineSwitch skips the monitoring and processing jobs while the whole pipeline is paused
+func (s *Scheduler) SetPipelineSwitch(pipeline *usecase.PipelineSwitch) {
+	s.pipeline = pipeline
+}
+
+// SetDatabaseMaintenance schedules database maintenance on database.maintenance_schedule
+func (s *Scheduler) SetDatabaseMaintenance(maintenance *usecase.DatabaseMaintenance) {
+	s.maintenance = maintenance
+}
+
 // Start starts the cron scheduler
 func (s *Scheduler) Start() error {
 	// Schedule account monitoring job
-	monitorSchedule := normalizeSchedule(s.config.CronSchedule)
-	monitorJobID, err := s.cron.AddFunc(monitorSchedule, s.monitorAccountsJob)
+	monitorSchedule := config.NormalizeSchedule(s.config.CronSchedule)
+	if _, err := config.CheckSchedule(monitorSchedule, s.cron.Location(), s.config.CronMinInterval, 0); err != nil {
+		// Existing config files may predate the floor; only API changes are rejected
+		logger.Error().Printf("Warning: %v", err)
+	}
+	monitorJobID, err := s.addJob(JobMonitorAccounts, monitorSchedule, s.monitorAccountsJob)
 	if err != nil {
 		return fmt.Errorf("failed to schedule monitor job: %w", err)
 	}
 	logger.Info().Printf("Scheduled account monitoring job with ID: %d, schedule: %s", monitorJobID, monitorSchedule)
 
 	// Schedule video processing job (runs more frequently)
-	processSchedule := normalizeSchedule("*/2 * * * *") // Every 2 minutes
-	processJobID, err := s.cron.AddFunc(processSchedule, s.processVideosJob)
+	processSchedule := config.NormalizeSchedule("*/2 * * * *") // Every 2 minutes
+	processJobID, err := s.addJob(JobProcessVideos, processSchedule, s.processVideosJob)
 	if err != nil {
 		return fmt.Errorf("failed to schedule process job: %w", err)
 	}
 	logger.Info().Printf("Scheduled video processing job with ID: %d, schedule: %s", processJobID, processSchedule)
 
+	// Fill in published dates missing from older rows (hourly; costs one quota unit per 50 videos)
+	backfillSchedule := config.NormalizeSchedule("17 * * * *")
+	backfillJobID, err := s.addJob(JobBackfillPublishedAt, backfillSchedule, s.backfillPublishedAtJob)
+	if err != nil {
+		return fmt.Errorf("failed to schedule published date backfill job: %w", err)
+	}
+	logger.Info().Printf("Scheduled published date backfill job with ID: %d, schedule: %s", backfillJobID, backfillSchedule)
+
+	// Follow API uploads TikTok is still publishing until they complete or fail
+	publishSchedule := config.NormalizeSchedule("* * * * *") // Every minute
+	publishJobID, err := s.addJob(JobCheckPublishing, publishSchedule, s.checkPublishingJob)
+	if err != nil {
+		return fmt.Errorf("failed to schedule publish status job: %w", err)
+	}
+	logger.Info().Printf("Scheduled publish status job with ID: %d, schedule: %s", publishJobID, publishSchedule)
+
+	// Re-read the views of videos held for min_views (costs one quota unit per 50 gated videos)
+	viewGateSchedule := config.NormalizeSchedule("*/30 * * * *")
+	viewGateJobID, err := s.addJob(JobCheckViewGates, viewGateSchedule, s.checkViewGatesJob)
+	if err != nil {
+		return fmt.Errorf("failed to schedule view gate job: %w", err)
+	}
+	logger.Info().Printf("Scheduled view gate job with ID: %d, schedule: %s", viewGateJobID, viewGateSchedule)
+
+	// Prune old videos and give back the space they held (weekly by default; not run at startup)
+	if s.maintenance != nil {
+		maintenanceSchedule := config.NormalizeSchedule(s.config.DatabaseMaintenanceSchedule)
+		maintenanceJobID, err := s.addJob(JobDatabaseMaintenance, maintenanceSchedule, s.databaseMaintenanceJob)
+		if err != nil {
+			return fmt.Errorf("failed to schedule database maintenance job: %w", err)
+		}
+		logger.Info().Printf("Scheduled database maintenance job with ID: %d, schedule: %s", maintenanceJobID, maintenanceSchedule)
+	}
+
 	// Start cron
 	s.cron.Start()
 	logger.Info().Println("Cron scheduler started")
@@ -74,28 +197,157 @@ func (s *Scheduler) Start() error {
 	// Run initial jobs immediately
 	go s.monitorAccountsJob()
 	go s.processVideosJob()
+	go s.backfillPublishedAtJob()
+	go s.checkPublishingJob()
+
+	// Also picks up videos marked immediate before a restart
+	if s.dispatcher != nil {
+		go s.dispatcher.Run(s.ctx, s.workCtx)
+	}
 
 	return nil
 }
 
-// Stop stops the cron scheduler gracefully
-func (s *Scheduler) Stop() {
+// Stop stops the cron scheduler gracefully. Scheduling and discovery stop at once and
+// the video processor stops accepting new work; videos already in flight keep running
+// until they finish or CancelWork is called. It returns the number of videos in flight once
+// no new work can start.
+func (s *Scheduler) Stop() int {
 	logger.Info().Println("Stopping cron scheduler...")
+	inFlight := 0
+	if s.videoProcessor != nil {
+		inFlight = s.videoProcessor.BeginShutdown()
+	}
 	s.cancel()
 	s.cron.Stop()
 	logger.Info().Println("Cron scheduler stopped")
+	return inFlight
+}
+
+// CancelWork aborts in-flight video processing (used when the shutdown grace period runs out)
+func (s *Scheduler) CancelWork() {
+	s.cancelWork()
+}
+
+// addJob registers a cron entry and keeps it for Jobs and RunJob
+func (s *Scheduler) addJob(name, schedule string, run func()) (cron.EntryID, error) {
+	id, err := s.cron.AddFunc(schedule, run)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &job{name: name, schedule: schedule, entryID: id, run: run})
+	return id, nil
+}
+
+// Jobs reports every registered job with its last run and next scheduled run
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		status := JobStatus{
+			Name:           j.name,
+			Schedule:       j.schedule,
+			Running:        j.running > 0 || j.requested,
+			Runs:           j.runs,
+			LastDurationMs: j.lastDuration.Milliseconds(),
+			LastError:      j.lastError,
+		}
+		if !j.lastStart.IsZero() {
+			lastStart := j.lastStart
+			status.LastStart = &lastStart
+		}
+		if !j.lastFinish.IsZero() {
+			lastFinish := j.lastFinish
+			status.LastFinish = &lastFinish
+		}
+		if next := s.cron.Entry(j.entryID).Next; !next.IsZero() {
+			status.NextRun = &next
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// RunJob starts the named job now, outside its schedule. A job that is still running is not
+// started twice.
+func (s *Scheduler) RunJob(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if j.name != name {
+			continue
+		}
+		if j.running > 0 || j.requested {
+			return fmt.Errorf("%w: %s", ErrJobRunning, name)
+		}
+		if s.ctx.Err() != nil {
+			return fmt.Errorf("scheduler is stopped")
+		}
+		logger.Info().Printf("Running job %s on request", name)
+		j.requested = true
+		go j.run()
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+}
+
+// jobStarted records the start of a run
+func (s *Scheduler) jobStarted(name string, startTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if j.name == name {
+			j.running++
+			j.requested = false
+			j.lastStart = startTime
+		}
+	}
+}
+
+// jobFinished records the outcome of a run
+func (s *Scheduler) jobFinished(name string, startTime time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if j.name != name {
+			continue
+		}
+		j.running--
+		j.runs++
+		j.lastFinish = time.Now()
+		j.lastDuration = j.lastFinish.Sub(startTime)
+		j.lastError = ""
+		if err != nil {
+			j.lastError = err.Error()
+		}
+	}
 }
 
 // monitorAccountsJob is the job function for monitoring accounts
 // This job scans all YouTube channels and creates video tasks for each YouTube->TikTok mapping
 func (s *Scheduler) monitorAccountsJob() {
+	if s.pipeline.AllPaused() {
+		logger.Info().Println("Skipping account monitoring job: pipeline is paused")
+		return
+	}
 	logger.Info().Println("Starting account monitoring job...")
 	startTime := time.Now()
+	s.jobStarted(JobMonitorAccounts, startTime)
 
-	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Minute)
+	ctx, cancel := context.WithTimeout(s.ctx, MonitorTimeout)
 	defer cancel()
 
-	if err := s.accountMonitor.MonitorAllAccounts(ctx); err != nil {
+	err := s.accountMonitor.MonitorAllAccounts(ctx)
+	s.jobFinished(JobMonitorAccounts, startTime, err)
+	if err != nil {
 		logger.Error().Printf("Account monitoring job failed: %v", err)
 		return
 	}
@@ -107,13 +359,20 @@ func (s *Scheduler) monitorAccountsJob() {
 // processVideosJob is the job function for processing videos
 // Each video is processed according to its account mapping (YouTube channel -> TikTok account)
 func (s *Scheduler) processVideosJob() {
+	if s.pipeline.AllPaused() {
+		logger.Info().Println("Skipping video processing job: pipeline is paused")
+		return
+	}
 	logger.Info().Println("Starting video processing job...")
 	startTime := time.Now()
+	s.jobStarted(JobProcessVideos, startTime)
 
-	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Minute)
+	ctx, cancel := context.WithTimeout(s.workCtx, ProcessTimeout)
 	defer cancel()
 
-	if err := s.videoProcessor.ProcessPendingVideos(ctx); err != nil {
+	err := s.videoProcessor.ProcessPendingVideos(ctx)
+	s.jobFinished(JobProcessVideos, startTime, err)
+	if err != nil {
 		logger.Error().Printf("Video processing job failed: %v", err)
 		return
 	}
@@ -122,11 +381,62 @@ func (s *Scheduler) processVideosJob() {
 	logger.Info().Printf("Video processing job completed in %v (processed videos for all active YouTube->TikTok mappings)", duration)
 }
 
-// normalizeSchedule ensures cron expressions are compatible with cron.WithSeconds
-func normalizeSchedule(expr string) string {
-	fields := strings.Fields(expr)
-	if len(fields) == 5 {
-		return "0 " + expr
+// backfillPublishedAtJob is the job function for filling in missing YouTube publish dates
+func (s *Scheduler) backfillPublishedAtJob() {
+	startTime := time.Now()
+	s.jobStarted(JobBackfillPublishedAt, startTime)
+
+	ctx, cancel := context.WithTimeout(s.ctx, MonitorTimeout)
+	defer cancel()
+
+	_, err := s.accountMonitor.BackfillPublishedAt(ctx)
+	s.jobFinished(JobBackfillPublishedAt, startTime, err)
+	if err != nil {
+		logger.Error().Printf("Published date backfill job failed: %v", err)
+	}
+}
+
+// checkViewGatesJob is the job function for queuing or expiring videos held for min_views
+func (s *Scheduler) checkViewGatesJob() {
+	startTime := time.Now()
+	s.jobStarted(JobCheckViewGates, startTime)
+
+	ctx, cancel := context.WithTimeout(s.ctx, MonitorTimeout)
+	defer cancel()
+
+	_, err := s.accountMonitor.CheckViewGates(ctx)
+	s.jobFinished(JobCheckViewGates, startTime, err)
+	if err != nil {
+		logger.Error().Printf("View gate job failed: %v", err)
+	}
+}
+
+// checkPublishingJob is the job function for following up on uploads TikTok is still publishing
+func (s *Scheduler) checkPublishingJob() {
+	startTime := time.Now()
+	s.jobStarted(JobCheckPublishing, startTime)
+
+	ctx, cancel := context.WithTimeout(s.workCtx, PublishCheckTimeout)
+	defer cancel()
+
+	err := s.videoProcessor.CheckPublishing(ctx)
+	s.jobFinished(JobCheckPublishing, startTime, err)
+	if err != nil {
+		logger.Error().Printf("Publish status job failed: %v", err)
+	}
+}
+
+// databaseMaintenanceJob is the job function for pruning and compacting the database
+func (s *Scheduler) databaseMaintenanceJob() {
+	startTime := time.Now()
+	s.jobStarted(JobDatabaseMaintenance, startTime)
+
+	ctx, cancel := context.WithTimeout(s.ctx, MaintenanceTimeout)
+	defer cancel()
+
+	_, err := s.maintenance.Run(ctx)
+	s.jobFinished(JobDatabaseMaintenance, startTime, err)
+	if err != nil {
+		logger.Error().Printf("Database maintenance job failed: %v", err)
 	}
-	return expr
 }
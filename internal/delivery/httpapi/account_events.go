@@ -0,0 +1,60 @@
+package httpapi
+
+import (
+	"net/http"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// accountEventResponse is one recorded change of an account
+type accountEventResponse struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Source    string    `json:"source"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccountEventsResponse is the body of GET /api/accounts/{id}/events
+type AccountEventsResponse struct {
+	AccountID string                  `json:"account_id"`
+	Events    []*accountEventResponse `json:"events"`
+}
+
+func toAccountEventResponse(event *domain.AccountEvent) *accountEventResponse {
+	return &accountEventResponse{
+		ID:        event.ID,
+		Type:      string(event.Type),
+		Source:    string(event.Source),
+		Summary:   event.Summary,
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+// getAccountEvents serves GET /api/accounts/{id}/events: the account's changes, newest first.
+// The history of a deleted account stays readable.
+func (s *Server) getAccountEvents(w http.ResponseWriter, r *http.Request, id string) {
+	events, err := s.accountManager.AccountEvents(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(events) == 0 {
+		account, err := s.accountManager.GetAccountMapping(r.Context(), id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if account == nil {
+			respondError(w, http.StatusNotFound, "account not found")
+			return
+		}
+	}
+
+	resp := AccountEventsResponse{AccountID: id, Events: make([]*accountEventResponse, 0, len(events))}
+	for _, event := range events {
+		resp.Events = append(resp.Events, toAccountEventResponse(event))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,316 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// Account import/export formats
+const (
+	AccountFormatJSON = "json" // An array of account_bootstrap entries
+	AccountFormatCSV  = "csv"  // A header row naming accountCSVHeader columns, then one row per account
+)
+
+// maxAccountImportBody caps the size of an account import upload
+const maxAccountImportBody = 10 << 20
+
+// accountCSVHeader names the CSV columns, matching the JSON field names
+var accountCSVHeader = []string{
+	"youtube_channel_id", "tiktok_account_id", "tiktok_access_token", "is_active", "privacy_level", "post_as_draft",
+}
+
+// accountImportRow is one parsed entry of an import, or why it could not be parsed
+type accountImportRow struct {
+	entry config.AccountBootstrap
+	err   error
+}
+
+// handleAccountImport applies a JSON array or CSV of account entries the way accounts_bootstrap
+// does, reporting created/updated/skipped/error per row. A bad row does not stop the batch.
+func (s *Server) handleAccountImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	if s.bootstrapper == nil {
+		respondError(w, http.StatusServiceUnavailable, "account import is not enabled")
+		return
+	}
+
+	query := r.URL.Query()
+	mode := query.Get("mode")
+	if mode == "" {
+		mode = config.BootstrapModeSync
+	}
+	if mode != config.BootstrapModeSync && mode != config.BootstrapModeCreateOnly {
+		respondError(w, http.StatusBadRequest, "mode must be sync or create_only")
+		return
+	}
+
+	body, format, err := accountImportBody(w, r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if v := query.Get("format"); v != "" {
+		format = v
+	}
+
+	var rows []accountImportRow
+	switch format {
+	case AccountFormatJSON:
+		rows, err = parseAccountJSON(body)
+	case AccountFormatCSV:
+		rows, err = parseAccountCSV(body)
+	default:
+		err = fmt.Errorf("format must be json or csv")
+	}
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Rows that parsed are applied together; the rest are reported in place
+	results := make([]usecase.ImportResult, len(rows))
+	var entries []config.AccountBootstrap
+	var positions []int
+	for i, row := range rows {
+		if row.err != nil {
+			results[i] = usecase.ImportResult{
+				Row:              i + 1,
+				YouTubeChannelID: row.entry.YouTubeChannelID,
+				TikTokAccountID:  row.entry.TikTokAccountID,
+				Status:           usecase.ImportStatusError,
+				Error:            row.err.Error(),
+			}
+			continue
+		}
+		entries = append(entries, row.entry)
+		positions = append(positions, i)
+	}
+	for i, result := range s.bootstrapper.Import(r.Context(), entries, mode) {
+		result.Row = positions[i] + 1
+		results[positions[i]] = result
+	}
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	logger.InfoContext(r.Context()).Printf("Imported %d accounts: %d created, %d updated, %d skipped, %d failed",
+		len(results), counts[usecase.ImportStatusCreated], counts[usecase.ImportStatusUpdated],
+		counts[usecase.ImportStatusSkipped], counts[usecase.ImportStatusError])
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"mode":    mode,
+		"counts":  counts,
+		"results": results,
+	})
+}
+
+// accountImportBody returns the uploaded document and the format its content type names. A
+// multipart form is read from its "file" field.
+func accountImportBody(w http.ResponseWriter, r *http.Request) (io.Reader, string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxAccountImportBody)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("multipart upload needs a file field: %w", err)
+		}
+		format := AccountFormatJSON
+		partType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+		if partType == "text/csv" || strings.HasSuffix(strings.ToLower(header.Filename), ".csv") {
+			format = AccountFormatCSV
+		}
+		return file, format, nil
+	case "text/csv":
+		return r.Body, AccountFormatCSV, nil
+	default:
+		return r.Body, AccountFormatJSON, nil
+	}
+}
+
+// parseAccountJSON reads a JSON array of entries; an entry of the wrong shape fails only its row
+func parseAccountJSON(body io.Reader) ([]accountImportRow, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: expected an array of accounts: %w", err)
+	}
+	rows := make([]accountImportRow, len(raw))
+	for i, item := range raw {
+		rows[i].err = json.Unmarshal(item, &rows[i].entry)
+	}
+	return rows, nil
+}
+
+// parseAccountCSV reads a CSV with a header row. Columns may come in any order; only
+// youtube_channel_id and tiktok_account_id are required, and empty cells leave a field unset.
+func parseAccountCSV(body io.Reader) ([]accountImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("CSV has no header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(accountCSVHeader, name) {
+			return nil, fmt.Errorf("unknown CSV column %q (want %s)", name, strings.Join(accountCSVHeader, ", "))
+		}
+		columns[name] = i
+	}
+	for _, required := range accountCSVHeader[:2] {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV is missing the %s column", required)
+		}
+	}
+
+	var rows []accountImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to read CSV: %w", err)
+			}
+			// A malformed line fails its row only
+			rows = append(rows, accountImportRow{err: err})
+			continue
+		}
+		rows = append(rows, parseAccountRecord(record, columns))
+	}
+}
+
+// parseAccountRecord converts one CSV record into an entry
+func parseAccountRecord(record []string, columns map[string]int) accountImportRow {
+	cell := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	flag := func(name string) (*bool, error) {
+		v := cell(name)
+		if v == "" {
+			return nil, nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a boolean", name, v)
+		}
+		return &b, nil
+	}
+
+	var row accountImportRow
+	row.entry = config.AccountBootstrap{
+		YouTubeChannelID:  cell("youtube_channel_id"),
+		TikTokAccountID:   cell("tiktok_account_id"),
+		TikTokAccessToken: cell("tiktok_access_token"),
+		PrivacyLevel:      cell("privacy_level"),
+	}
+	if row.entry.IsActive, row.err = flag("is_active"); row.err != nil {
+		return row
+	}
+	row.entry.PostAsDraft, row.err = flag("post_as_draft")
+	return row
+}
+
+// handleAccountExport writes every account in the import format. Access tokens are redacted
+// unless include_tokens=true, which needs the admin token.
+func (s *Server) handleAccountExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if s.bootstrapper == nil {
+		respondError(w, http.StatusServiceUnavailable, "account export is not enabled")
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = AccountFormatJSON
+	}
+	if format != AccountFormatJSON && format != AccountFormatCSV {
+		respondError(w, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+	includeTokens := false
+	if v := query.Get("include_tokens"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "include_tokens must be true or false")
+			return
+		}
+		includeTokens = parsed
+	}
+	if includeTokens && !s.requireAdmin(w, r) {
+		return
+	}
+
+	entries, err := s.bootstrapper.Export(r.Context(), includeTokens)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if includeTokens {
+		logger.InfoContext(r.Context()).Printf("Exported %d accounts with access tokens", len(entries))
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="accounts.%s"`, format))
+	if format == AccountFormatJSON {
+		respondJSON(w, http.StatusOK, entries)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	writer := csv.NewWriter(w)
+	_ = writer.Write(accountCSVHeader)
+	for _, entry := range entries {
+		_ = writer.Write([]string{
+			entry.YouTubeChannelID,
+			entry.TikTokAccountID,
+			entry.TikTokAccessToken,
+			csvFlag(entry.IsActive),
+			entry.PrivacyLevel,
+			csvFlag(entry.PostAsDraft),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logger.ErrorContext(r.Context()).Printf("Account export failed: %v", err)
+	}
+}
+
+// csvFlag formats an optional boolean CSV cell; unset is empty
+func csvFlag(v *bool) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatBool(*v)
+}
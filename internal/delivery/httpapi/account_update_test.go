@@ -0,0 +1,72 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// TestPatchAccountRacesMonitor edits an account through the API while the monitor records its
+// checks; neither may undo the other
+func TestPatchAccountRacesMonitor(t *testing.T) {
+	const rounds = 50
+	repos := sqlitetest.OpenTest(t)
+	ctx := context.Background()
+	account := &domain.Account{ID: "acc-1", YouTubeChannelID: "UC-1", TikTokAccountID: "tt-1", IsActive: true}
+	if err := repos.Accounts.Save(ctx, account); err != nil {
+		t.Fatalf("save account: %v", err)
+	}
+	cfg := &config.Config{WriteRetryBudget: 5 * time.Second, HTTPClientTimeout: 5 * time.Second}
+	s := NewServer(cfg, usecase.NewAccountManager(cfg, repos.Accounts), repos.Videos, tiktok.NewService(cfg, httpclient.NewHTTPClient(cfg)))
+
+	checkedAt := time.Now().UTC().Truncate(time.Second)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range rounds {
+			if err := repos.Accounts.UpdateLastChecked(ctx, "acc-1", fmt.Sprintf("yt-%d", i), checkedAt.Add(time.Duration(i)*time.Second)); err != nil {
+				t.Errorf("UpdateLastChecked() error = %v", err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range rounds {
+			body := fmt.Sprintf(`{"comment_template":"edit %d"}`, i)
+			req := httptest.NewRequest(http.MethodPatch, "/api/accounts/acc-1", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			s.server.Handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("PATCH %d status = %d: %s", i, rec.Code, rec.Body)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	stored, err := repos.Accounts.GetByID(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	last := rounds - 1
+	if want := fmt.Sprintf("yt-%d", last); stored.LastVideoID != want || !stored.LastCheckedAt.Equal(checkedAt.Add(time.Duration(last)*time.Second)) {
+		t.Errorf("last checked = %q %v, want the monitor's last check %s", stored.LastVideoID, stored.LastCheckedAt, want)
+	}
+	if want := fmt.Sprintf("edit %d", last); stored.CommentTemplate != want {
+		t.Errorf("CommentTemplate = %q, want %q", stored.CommentTemplate, want)
+	}
+}
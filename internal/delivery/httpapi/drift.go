@@ -0,0 +1,49 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// SetAccountBootstrapper enables the config/database drift report and account import/export.
+func (s *Server) SetAccountBootstrapper(bootstrapper *usecase.AccountBootstrapper, manager *config.Manager) {
+	s.bootstrapper = bootstrapper
+	s.configManager = manager
+}
+
+// handleAccountDrift compares the YAML bootstrap accounts on disk with the database
+func (s *Server) handleAccountDrift(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if s.bootstrapper == nil {
+		respondError(w, http.StatusServiceUnavailable, "drift report is not enabled")
+		return
+	}
+
+	// Prefer the file on disk so edits made since startup are visible
+	entries, mode := s.cfg.BootstrapAccounts, s.cfg.AccountsBootstrapMode
+	if s.configManager != nil {
+		fileEntries, fileMode, err := s.configManager.ReadBootstrap()
+		if err != nil {
+			logger.ErrorContext(r.Context()).Printf("Failed to read config for drift report, using loaded config: %v", err)
+		} else {
+			entries, mode = fileEntries, fileMode
+		}
+	}
+
+	report, err := s.bootstrapper.Drift(r.Context(), entries, mode)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"mode":     mode,
+		"accounts": report,
+	})
+}
@@ -0,0 +1,180 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// SetExperimentManager enables caption A/B experiments.
+func (s *Server) SetExperimentManager(manager *usecase.ExperimentManager) {
+	s.experiments = manager
+}
+
+type experimentResponse struct {
+	ID            string                 `json:"id"`
+	Name          string                 `json:"name,omitempty"`
+	SourceVideoID string                 `json:"source_video_id"`
+	Arms          []domain.ExperimentArm `json:"arms"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+type experimentArmResultResponse struct {
+	domain.ExperimentArm
+	Status        domain.VideoStatus `json:"status,omitempty"`
+	TikTokVideoID string             `json:"tiktok_video_id,omitempty"`
+	CompletedAt   *time.Time         `json:"completed_at,omitempty"`
+	ErrorMessage  string             `json:"error_message,omitempty"`
+}
+
+func toExperimentResponse(experiment *domain.Experiment) *experimentResponse {
+	return &experimentResponse{
+		ID:            experiment.ID,
+		Name:          experiment.Name,
+		SourceVideoID: experiment.SourceVideoID,
+		Arms:          experiment.Arms,
+		CreatedAt:     experiment.CreatedAt,
+	}
+}
+
+// handleExperiments serves GET (list) and POST (create) on /api/experiments
+func (s *Server) handleExperiments(w http.ResponseWriter, r *http.Request) {
+	if s.experiments == nil {
+		respondError(w, http.StatusServiceUnavailable, "experiments are not enabled")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		experiments, err := s.experiments.ListExperiments(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp := make([]*experimentResponse, 0, len(experiments))
+		for _, experiment := range experiments {
+			resp = append(resp, toExperimentResponse(experiment))
+		}
+		respondJSON(w, http.StatusOK, resp)
+	case http.MethodPost:
+		s.createExperiment(w, r)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (s *Server) createExperiment(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		SourceVideoID string `json:"source_video_id"`
+		Name          string `json:"name"`
+		Arms          []struct {
+			Label     string `json:"label"`
+			AccountID string `json:"account_id"`
+			Caption   string `json:"caption"`
+		} `json:"arms"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	specs := make([]usecase.ExperimentArmSpec, 0, len(payload.Arms))
+	for _, arm := range payload.Arms {
+		specs = append(specs, usecase.ExperimentArmSpec{
+			Label:     arm.Label,
+			AccountID: arm.AccountID,
+			Caption:   arm.Caption,
+		})
+	}
+
+	experiment, err := s.experiments.CreateExperiment(r.Context(), payload.SourceVideoID, payload.Name, specs)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidExperiment):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, usecase.ErrExperimentNotAllowed):
+			respondError(w, http.StatusConflict, err.Error())
+		default:
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, toExperimentResponse(experiment))
+}
+
+// handleExperimentActions serves /api/experiments/{id} and /api/experiments/{id}/results
+func (s *Server) handleExperimentActions(w http.ResponseWriter, r *http.Request) {
+	if s.experiments == nil {
+		respondError(w, http.StatusServiceUnavailable, "experiments are not enabled")
+		return
+	}
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/experiments/")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 0 || parts[0] == "" {
+		respondError(w, http.StatusNotFound, "not found")
+		return
+	}
+	id := parts[0]
+
+	switch {
+	case len(parts) == 1:
+		experiment, err := s.experiments.GetExperiment(r.Context(), id)
+		if err != nil {
+			respondExperimentError(w, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, toExperimentResponse(experiment))
+	case len(parts) == 2 && parts[1] == "results":
+		s.getExperimentResults(w, r, id)
+	default:
+		respondError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) getExperimentResults(w http.ResponseWriter, r *http.Request, id string) {
+	results, err := s.experiments.GetResults(r.Context(), id)
+	if err != nil {
+		respondExperimentError(w, err)
+		return
+	}
+
+	arms := make([]experimentArmResultResponse, 0, len(results.Arms))
+	for _, arm := range results.Arms {
+		resp := experimentArmResultResponse{
+			ExperimentArm: arm.ExperimentArm,
+			Status:        arm.Status,
+			TikTokVideoID: arm.TikTokVideoID,
+			ErrorMessage:  arm.ErrorMessage,
+		}
+		if !arm.CompletedAt.IsZero() {
+			t := arm.CompletedAt
+			resp.CompletedAt = &t
+		}
+		arms = append(arms, resp)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"experiment": toExperimentResponse(results.Experiment),
+		"arms":       arms,
+		"counts":     results.Counts,
+	})
+}
+
+func respondExperimentError(w http.ResponseWriter, err error) {
+	if errors.Is(err, usecase.ErrExperimentNotFound) {
+		respondError(w, http.StatusNotFound, "experiment not found")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,178 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// Video export formats
+const (
+	ExportNDJSON = "ndjson" // One JSON object per line, shaped like the video list's entries
+	ExportCSV    = "csv"    // A header row, then one row per video
+)
+
+// exportFlushEvery is how many videos are written between flushes of the output
+const exportFlushEvery = 100
+
+// exportCSVHeader names the CSV columns, written when an export starts from the beginning
+var exportCSVHeader = []string{
+	"id", "account_id", "youtube_video_id", "title", "status", "priority", "error_message",
+	"tiktok_video_id", "tiktok_post_url", "upload_route", "parent_video_id", "clip_count",
+	"safety_decision", "created_at", "updated_at", "published_at", "downloaded_at", "uploaded_at",
+	"completed_at", "download_duration_ms", "upload_duration_ms",
+}
+
+// ExportVideos writes the videos matching filter's status with an ID after the cursor to w, in ID
+// order, and returns how many it wrote. Videos are read through VideoRepository.Iterate, so the
+// history is never held in memory, and a write blocks the iteration until the reader catches up.
+// An interrupted export resumes from the ID of the last complete line; the CSV header is only
+// written when after is empty. flush, if not nil, is called every few videos and at the end.
+func ExportVideos(
+	ctx context.Context,
+	videoRepo domain.VideoRepository,
+	w io.Writer,
+	format string,
+	filter domain.VideoFilter,
+	after string,
+	flush func() error,
+) (int, error) {
+	var write func(video *domain.Video) error
+	var flushWriter func() error
+	switch format {
+	case ExportNDJSON:
+		encoder := json.NewEncoder(w)
+		write = func(video *domain.Video) error { return encoder.Encode(toVideoResponse(video)) }
+		flushWriter = func() error { return nil }
+	case ExportCSV:
+		writer := csv.NewWriter(w)
+		if after == "" {
+			if err := writer.Write(exportCSVHeader); err != nil {
+				return 0, err
+			}
+		}
+		write = func(video *domain.Video) error { return writer.Write(exportCSVRow(video)) }
+		flushWriter = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	default:
+		return 0, fmt.Errorf("unknown export format %q (want %s or %s)", format, ExportNDJSON, ExportCSV)
+	}
+
+	count := 0
+	err := videoRepo.Iterate(ctx, filter, after, func(video *domain.Video) error {
+		if err := write(video); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := flushWriter(); err != nil {
+				return err
+			}
+			if flush != nil {
+				return flush()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	if err := flushWriter(); err != nil {
+		return count, err
+	}
+	if flush != nil {
+		return count, flush()
+	}
+	return count, nil
+}
+
+// exportCSVRow returns a video's CSV columns in exportCSVHeader order
+func exportCSVRow(video *domain.Video) []string {
+	return []string{
+		video.ID,
+		video.AccountID,
+		video.YouTubeVideoID,
+		video.Title,
+		string(video.Status),
+		strconv.Itoa(video.Priority),
+		video.ErrorMessage,
+		video.TikTokVideoID,
+		video.TikTokPostURL,
+		string(video.UploadRoute),
+		video.ParentVideoID,
+		strconv.Itoa(video.ClipCount),
+		string(video.SafetyDecision),
+		exportTime(video.CreatedAt),
+		exportTime(video.UpdatedAt),
+		exportTime(video.PublishedAt),
+		exportTime(video.DownloadedAt),
+		exportTime(video.UploadedAt),
+		exportTime(video.CompletedAt),
+		strconv.FormatInt(video.DownloadDuration.Milliseconds(), 10),
+		strconv.FormatInt(video.UploadDuration.Milliseconds(), 10),
+	}
+}
+
+// exportTime formats a CSV time column; unset times are empty
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// handleVideoExport streams the video history as NDJSON (default) or CSV. It takes the list's
+// status filter and a cursor, the ID of the last video received, to resume an interrupted export.
+func (s *Server) handleVideoExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = ExportNDJSON
+	}
+	var contentType string
+	switch format {
+	case ExportNDJSON:
+		contentType = "application/x-ndjson"
+	case ExportCSV:
+		contentType = "text/csv; charset=utf-8"
+	default:
+		respondError(w, http.StatusBadRequest, "format must be ndjson or csv")
+		return
+	}
+	var filter domain.VideoFilter
+	if v := query.Get("status"); v != "" {
+		status := domain.VideoStatus(v)
+		if !status.IsValid() {
+			respondError(w, http.StatusBadRequest, "invalid status")
+			return
+		}
+		filter.Status = status
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="videos.%s"`, format))
+	w.WriteHeader(http.StatusOK)
+
+	controller := http.NewResponseController(w)
+	count, err := ExportVideos(r.Context(), s.videoRepo, w, format, filter, query.Get("cursor"), controller.Flush)
+	if err != nil {
+		// The status is already sent; cutting the response off tells the client the export is
+		// incomplete, and it can resume from the last complete line
+		logger.ErrorContext(r.Context()).Printf("Video export stopped after %d videos: %v", count, err)
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,37 @@
+package httpapi
+
+import (
+	"time"
+
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// SetHealthChecker makes GET /api/health run dependency checks
+func (s *Server) SetHealthChecker(checker *usecase.HealthChecker) {
+	s.healthChecker = checker
+}
+
+type healthCheckResponse struct {
+	Status    string `json:"status"` // ok or fail
+	Critical  bool   `json:"critical"`
+	Message   string `json:"message,omitempty"`
+	CheckedAt string `json:"checked_at"`
+}
+
+// toHealthChecksResponse keys the results by check name
+func toHealthChecksResponse(results []usecase.HealthCheckResult) map[string]healthCheckResponse {
+	resp := make(map[string]healthCheckResponse, len(results))
+	for _, result := range results {
+		status := "ok"
+		if !result.Healthy {
+			status = "fail"
+		}
+		resp[result.Name] = healthCheckResponse{
+			Status:    status,
+			Critical:  result.Critical,
+			Message:   result.Message,
+			CheckedAt: result.CheckedAt.Format(time.RFC3339),
+		}
+	}
+	return resp
+}
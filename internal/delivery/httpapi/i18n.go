@@ -0,0 +1,116 @@
+package httpapi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// defaultLocale is the catalog every other one falls back to for keys it does not translate
+const defaultLocale = "en"
+
+// messageCatalogs maps a locale (en, vi, ja) to its message keys and fmt format strings
+var messageCatalogs = mustLoadCatalogs(localeFiles)
+
+// mustLoadCatalogs reads one JSON catalog per locale, named after it (locales/vi.json)
+func mustLoadCatalogs(fsys fs.FS) map[string]map[string]string {
+	files, err := fs.Glob(fsys, "locales/*.json")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("parse message catalog %s: %v", file, err))
+		}
+		catalogs[strings.TrimSuffix(path.Base(file), ".json")] = messages
+	}
+	if _, ok := catalogs[defaultLocale]; !ok {
+		panic("message catalog " + defaultLocale + ".json is missing")
+	}
+	return catalogs
+}
+
+// localizer translates the web UI's messages into one locale
+type localizer struct {
+	locale string
+}
+
+// T returns the message for key formatted with args, falling back to English for keys the
+// locale does not translate and to the key itself for unknown keys
+func (l localizer) T(key string, args ...any) string {
+	msg, ok := messageCatalogs[l.locale][key]
+	if !ok {
+		msg, ok = messageCatalogs[defaultLocale][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// localizerFor picks the first supported language of the request's Accept-Language header,
+// then server.locale, then English
+func (s *Server) localizerFor(r *http.Request) localizer {
+	for _, lang := range acceptedLanguages(r.Header.Get("Accept-Language")) {
+		if _, ok := messageCatalogs[lang]; ok {
+			return localizer{locale: lang}
+		}
+	}
+	if _, ok := messageCatalogs[s.cfg.ServerLocale]; ok {
+		return localizer{locale: s.cfg.ServerLocale}
+	}
+	return localizer{locale: defaultLocale}
+}
+
+// acceptedLanguages returns the primary language subtags of an Accept-Language header ("ja-JP"
+// is "ja"), most preferred first; languages with q=0 are left out
+func acceptedLanguages(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			langs = append(langs, weighted{lang: lang, q: q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	result := make([]string, 0, len(langs))
+	for _, l := range langs {
+		result = append(result, l.lang)
+	}
+	return result
+}
@@ -0,0 +1,286 @@
+package httpapi
+
+import (
+	"context"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/memory"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// formatVerb matches the fmt verbs of a catalog message
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// templateKey matches the constant message keys the templates translate
+var templateKey = regexp.MustCompile(`\bt "([^"]+)"`)
+
+// newPageTestServer returns a server whose accounts and videos cover every token badge, source
+// state and video status the pages show
+func newPageTestServer(t *testing.T) *Server {
+	t.Helper()
+	ctx := context.Background()
+	cfg := &config.Config{}
+	accounts := memory.NewAccountRepository()
+	videos := memory.NewVideoRepository()
+
+	now := time.Now()
+	soon, past, later := now.Add(time.Hour), now.Add(-time.Hour), now.Add(72*time.Hour)
+	accountList := []*domain.Account{
+		{ID: "no-token", TikTokAccountID: "tt-1"},
+		{ID: "wrong-login", TikTokAccountID: "tt-2", TikTokAccessToken: "act.2", NeedsReauthorization: true},
+		{ID: "missing-scope", TikTokAccountID: "tt-3", TikTokAccessToken: "act.3", MissingScopes: []string{"video.publish"}},
+		{ID: "unknown-expiry", TikTokAccountID: "tt-4", TikTokAccessToken: "act.4"},
+		{ID: "refreshable", TikTokAccountID: "tt-5", TikTokAccessToken: "act.5", TikTokRefreshToken: "rft.5"},
+		{ID: "expired", TikTokAccountID: "tt-6", TikTokAccessToken: "act.6", TikTokTokenExpiresAt: &past},
+		{ID: "expired-refresh", TikTokAccountID: "tt-7", TikTokAccessToken: "act.7", TikTokRefreshToken: "rft.7", TikTokTokenExpiresAt: &past},
+		{ID: "expiring", TikTokAccountID: "tt-8", TikTokAccessToken: "act.8", TikTokTokenExpiresAt: &soon, IsActive: true},
+		{ID: "ok", TikTokAccountID: "tt-9", TikTokAccessToken: "act.9", TikTokTokenExpiresAt: &later, IsActive: true,
+			TikTokDisplayName: "Creator <b>", TikTokAvatarURL: "https://p16.tiktokcdn.com/a.jpg"},
+	}
+	for _, state := range []string{domain.SourceSuspended, domain.SourceTerminated, domain.SourcePrivate} {
+		accountList = append(accountList, &domain.Account{
+			ID: "source-" + state, TikTokAccountID: "tt-source-" + state, TikTokAccessToken: "act.s", IsActive: true,
+			SourceStatus: domain.SourceStatus{Unavailable: state, Reason: "channel " + state},
+		})
+	}
+	for _, account := range accountList {
+		account.YouTubeChannelID = "UC-" + account.ID
+		if err := accounts.Save(ctx, account); err != nil {
+			t.Fatalf("save account %s: %v", account.ID, err)
+		}
+	}
+
+	statuses := []domain.VideoStatus{
+		domain.VideoStatusPending, domain.VideoStatusDownloading, domain.VideoStatusDownloaded,
+		domain.VideoStatusUploading, domain.VideoStatusPublishing, domain.VideoStatusCompleted, domain.VideoStatusFailed,
+		domain.VideoStatusSkipped, domain.VideoStatusAwaitingReview, domain.VideoStatusGated, domain.VideoStatusWaitingWindow,
+	}
+	for _, status := range statuses {
+		video := &domain.Video{
+			ID: "v-" + string(status), YouTubeVideoID: "yt-" + string(status), AccountID: "ok", Status: status,
+			Title: "Video " + string(status), Progress: 40,
+		}
+		if status == domain.VideoStatusFailed {
+			video.ErrorMessage = "upload rejected"
+		}
+		if status == domain.VideoStatusCompleted {
+			video.TikTokPostURL = "https://www.tiktok.com/@me/video/7311111111111111111"
+		}
+		if err := videos.Save(ctx, video); err != nil {
+			t.Fatalf("save video: %v", err)
+		}
+	}
+	clip := &domain.Video{ID: "source", YouTubeVideoID: "yt-source", AccountID: "ok", Status: domain.VideoStatusCompleted, ClipCount: 3}
+	if err := videos.Save(ctx, clip); err != nil {
+		t.Fatalf("save video: %v", err)
+	}
+
+	return NewServer(cfg, usecase.NewAccountManager(cfg, accounts), videos, nil)
+}
+
+// localeNames returns the locales with a catalog, sorted
+func localeNames() []string {
+	var locales []string
+	for locale := range messageCatalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// checkTranslated fails the test if a page shows a message key instead of its message
+func checkTranslated(t *testing.T, page string) {
+	t.Helper()
+	for key := range messageCatalogs[defaultLocale] {
+		if strings.Contains(page, ">"+key+"<") || strings.Contains(page, `"`+key+`"`) {
+			t.Errorf("page shows the message key %s", key)
+		}
+	}
+	for _, prefix := range []string{"status.", "accounts.source_", "token.", "callback."} {
+		if strings.Contains(page, ">"+prefix) {
+			t.Errorf("page shows an untranslated %s* key", prefix)
+		}
+	}
+}
+
+func TestPagesRenderInEveryLocale(t *testing.T) {
+	s := newPageTestServer(t)
+	pages := []struct {
+		name string
+		path string
+		want string // a value from the data the page must show
+	}{
+		{"accounts", "/", "UC-missing-scope"},
+		{"videos", "/videos", "Video awaiting_review"},
+		{"queue", "/videos/queue", "upload rejected"},
+		{"queue filtered", "/videos/queue?status=failed", "upload rejected"},
+	}
+	for _, locale := range localeNames() {
+		for _, page := range pages {
+			t.Run(locale+"/"+page.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, page.path, nil)
+				req.Header.Set("Accept-Language", locale+";q=0.9, xx")
+				rec := httptest.NewRecorder()
+				s.server.Handler.ServeHTTP(rec, req)
+
+				if rec.Code != http.StatusOK {
+					t.Fatalf("GET %s = %d: %s", page.path, rec.Code, rec.Body)
+				}
+				if got := rec.Header().Get("Content-Language"); got != locale {
+					t.Errorf("Content-Language = %q, want %q", got, locale)
+				}
+				body := rec.Body.String()
+				if page.path == "/" || page.path == "/videos" {
+					if !strings.Contains(body, `lang="`+locale+`"`) {
+						t.Errorf("page is not marked lang=%q", locale)
+					}
+				}
+				if !strings.Contains(body, page.want) {
+					t.Errorf("page does not show %q", page.want)
+				}
+				if strings.Contains(body, "Creator <b>") {
+					t.Error("TikTok display name is not escaped")
+				}
+				checkTranslated(t, body)
+			})
+		}
+	}
+}
+
+func TestCallbackPageRendersInEveryLocale(t *testing.T) {
+	s := NewServer(&config.Config{}, nil, memory.NewVideoRepository(), nil)
+	var keys []string
+	for key := range messageCatalogs[defaultLocale] {
+		if strings.HasPrefix(key, "callback.") && !strings.HasPrefix(key, "callback.status") &&
+			key != "callback.title" && key != "callback.account_id" && key != "callback.close" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, locale := range localeNames() {
+		for _, key := range keys {
+			success := strings.HasPrefix(key, "callback.token_updated")
+			t.Run(locale+"/"+key, func(t *testing.T) {
+				var args []any
+				for range formatVerb.FindAllString(messageCatalogs[defaultLocale][key], -1) {
+					args = append(args, "<script>x</script>")
+				}
+				req := httptest.NewRequest(http.MethodGet, "/api/tiktok/callback", nil)
+				req.Header.Set("Accept-Language", locale)
+				rec := httptest.NewRecorder()
+				s.renderCallbackPage(rec, req, success, "acc-1", key, args...)
+
+				if rec.Code != http.StatusOK {
+					t.Fatalf("callback page = %d: %s", rec.Code, rec.Body)
+				}
+				body := rec.Body.String()
+				if strings.Contains(body, "<script>x</script>") {
+					t.Error("message argument is not escaped")
+				}
+				message := template.HTMLEscapeString(localizer{locale: locale}.T(key, args...))
+				if !strings.Contains(body, "acc-1") || !strings.Contains(body, message) {
+					t.Errorf("callback page does not show the account and %q:\n%s", message, body)
+				}
+				checkTranslated(t, body)
+			})
+		}
+	}
+}
+
+// TestCatalogsMatchEnglish checks every key the templates use has an English message, and that
+// the other catalogs translate only known keys with the same format verbs
+func TestCatalogsMatchEnglish(t *testing.T) {
+	english := messageCatalogs[defaultLocale]
+	files, err := fs.Glob(templateFiles, "templates/*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(templateFiles, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range templateKey.FindAllStringSubmatch(string(data), -1) {
+			if _, ok := english[match[1]]; !ok {
+				t.Errorf("%s uses %s, which en.json does not have", file, match[1])
+			}
+		}
+	}
+	for _, status := range []domain.VideoStatus{
+		domain.VideoStatusPending, domain.VideoStatusDownloading, domain.VideoStatusDownloaded,
+		domain.VideoStatusUploading, domain.VideoStatusPublishing, domain.VideoStatusCompleted, domain.VideoStatusFailed,
+		domain.VideoStatusSkipped, domain.VideoStatusAwaitingReview, domain.VideoStatusGated, domain.VideoStatusWaitingWindow,
+	} {
+		if _, ok := english["status."+string(status)]; !ok {
+			t.Errorf("en.json has no badge for status %s", status)
+		}
+	}
+
+	for _, locale := range localeNames() {
+		for key, msg := range messageCatalogs[locale] {
+			want, ok := english[key]
+			if !ok {
+				t.Errorf("%s.json has %s, which en.json does not", locale, key)
+				continue
+			}
+			if got, wantVerbs := formatVerb.FindAllString(msg, -1), formatVerb.FindAllString(want, -1); strings.Join(got, " ") != strings.Join(wantVerbs, " ") {
+				t.Errorf("%s.json %s has verbs %v, en.json %v", locale, key, got, wantVerbs)
+			}
+		}
+	}
+}
+
+func TestLocalizerFallback(t *testing.T) {
+	const key = "test.only_in_english"
+	messageCatalogs[defaultLocale][key] = "only %s"
+	t.Cleanup(func() { delete(messageCatalogs[defaultLocale], key) })
+
+	for _, locale := range localeNames() {
+		loc := localizer{locale: locale}
+		if got := loc.T(key, "English"); got != "only English" {
+			t.Errorf("%s: T(%s) = %q, want the English message", locale, key, got)
+		}
+		if got := loc.T("test.nowhere"); got != "test.nowhere" {
+			t.Errorf("%s: T of an unknown key = %q, want the key", locale, got)
+		}
+	}
+	if got := (localizer{locale: "xx"}).T("status.completed"); got != messageCatalogs[defaultLocale]["status.completed"] {
+		t.Errorf("T for a locale without a catalog = %q, want English", got)
+	}
+}
+
+func TestLocalizerFor(t *testing.T) {
+	tests := []struct {
+		header, serverLocale, want string
+	}{
+		{"", "", "en"},
+		{"", "ja", "ja"},
+		{"", "fr", "en"},
+		{"vi-VN,vi;q=0.9,en;q=0.8", "", "vi"},
+		{"fr-FR, ja;q=0.5, vi;q=0.7", "", "vi"},
+		{"ja-JP;q=0, en;q=0.1", "vi", "en"},
+		{"de, fr", "ja", "ja"},
+		{"*", "vi", "vi"},
+		{"JA", "", "ja"},
+		{"en;q=bad, vi", "", "vi"},
+	}
+	for _, tt := range tests {
+		s := &Server{cfg: &config.Config{ServerLocale: tt.serverLocale}}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Language", tt.header)
+		if got := s.localizerFor(req).locale; got != tt.want {
+			t.Errorf("localizerFor(%q, server %q) = %s, want %s", tt.header, tt.serverLocale, got, tt.want)
+		}
+	}
+}
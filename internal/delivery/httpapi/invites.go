@@ -0,0 +1,281 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// inviteStatePrefix marks OAuth state values that carry an invite token instead of an account ID
+const inviteStatePrefix = "invite:"
+
+// SetInviteManager enables the account owner invite endpoints.
+func (s *Server) SetInviteManager(manager *usecase.InviteManager) {
+	s.inviteManager = manager
+}
+
+// handleAccountInvites serves /api/accounts/{id}/invite and /api/accounts/{id}/invites[/{invite_id}]
+func (s *Server) handleAccountInvites(w http.ResponseWriter, r *http.Request, accountID string, parts []string) {
+	if s.inviteManager == nil {
+		respondError(w, http.StatusServiceUnavailable, "invites are not enabled")
+		return
+	}
+
+	switch {
+	case parts[0] == "invite" && len(parts) == 1:
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w)
+			return
+		}
+		var (
+			invite *domain.Invite
+			token  string
+		)
+		err := s.retryWrite(r.Context(), func(ctx context.Context) error {
+			var err error
+			invite, token, err = s.inviteManager.CreateInvite(ctx, accountID)
+			return err
+		})
+		if err != nil {
+			s.respondWriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		resp := toInviteResponse(invite)
+		resp.URL = s.inviteManager.InviteURL(token)
+		respondJSON(w, http.StatusCreated, resp)
+
+	case parts[0] == "invites" && len(parts) == 1:
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		invites, err := s.inviteManager.ListInvites(accountID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp := make([]*inviteResponse, 0, len(invites))
+		for _, invite := range invites {
+			resp = append(resp, toInviteResponse(invite))
+		}
+		respondJSON(w, http.StatusOK, resp)
+
+	case parts[0] == "invites" && len(parts) == 2:
+		if r.Method != http.MethodDelete {
+			methodNotAllowed(w)
+			return
+		}
+		if err := s.retryWrite(r.Context(), func(context.Context) error {
+			return s.inviteManager.RevokeInvite(accountID, parts[1])
+		}); err != nil {
+			s.respondWriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleInviteAuthorize starts the TikTok OAuth flow for the account bound to an invite
+func (s *Server) handleInviteAuthorize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if s.inviteManager == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, "/authorize/")
+	invite, err := s.inviteManager.ResolveInvite(token)
+	if err != nil {
+		if !errors.Is(err, usecase.ErrInviteInvalid) {
+			logger.ErrorContext(r.Context()).Printf("Failed to resolve invite: %v", err)
+		}
+		s.renderCallbackPage(w, r, false, "", "invite.invalid")
+		return
+	}
+
+	account, app, err := s.inviteApp(r.Context(), invite)
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to resolve credential set for invite %s: %v", invite.ID, err)
+		s.renderCallbackPage(w, r, false, "", "invite.unavailable")
+		return
+	}
+	authURL := tiktok.AuthorizeURL(app, s.exchangeRedirectURI(), inviteStatePrefix+token, account.MissingScopes...)
+
+	logger.InfoContext(r.Context()).Printf("Invite %s opened for account %s", invite.ID, invite.AccountID)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handleInviteCallback completes the token exchange for an invite-bound OAuth callback
+func (s *Server) handleInviteCallback(w http.ResponseWriter, r *http.Request, token string) {
+	if s.inviteManager == nil {
+		respondError(w, http.StatusBadRequest, "invites are not enabled")
+		return
+	}
+
+	invite, err := s.inviteManager.ResolveInvite(token)
+	if err != nil {
+		if !errors.Is(err, usecase.ErrInviteInvalid) {
+			logger.ErrorContext(r.Context()).Printf("Failed to resolve invite: %v", err)
+		}
+		s.renderCallbackPage(w, r, false, "", "invite.invalid")
+		return
+	}
+
+	if errorParam := r.URL.Query().Get("error"); errorParam != "" {
+		errorDesc := r.URL.Query().Get("error_description")
+		logger.ErrorContext(r.Context()).Printf("TikTok authorization error for invite %s: %s - %s", invite.ID, errorParam, errorDesc)
+		s.renderCallbackPage(w, r, false, invite.AccountID, "callback.authorization_failed", errorDesc)
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		respondError(w, http.StatusBadRequest, "authorization code is missing")
+		return
+	}
+
+	// Invites always use the account's own credential set, as the authorize step did
+	_, app, err := s.inviteApp(r.Context(), invite)
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to resolve credential set for invite %s: %v", invite.ID, err)
+		s.renderCallbackPage(w, r, false, invite.AccountID, "invite.retry")
+		return
+	}
+
+	// Claim the invite before exchanging the code so two callbacks for one link cannot both
+	// connect an account; every failure below that stores no token hands it back
+	if err := s.inviteManager.ClaimInvite(invite); err != nil {
+		if !errors.Is(err, usecase.ErrInviteInvalid) {
+			logger.ErrorContext(r.Context()).Printf("Failed to claim invite %s: %v", invite.ID, err)
+		}
+		s.renderCallbackPage(w, r, false, "", "invite.invalid")
+		return
+	}
+
+	tokenResp, err := s.tiktokService.ExchangeCodeForToken(app, code, s.exchangeRedirectURI())
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to exchange code for invite %s: %v", invite.ID, err)
+		s.reopenInvite(r.Context(), invite)
+		s.renderCallbackPage(w, r, false, invite.AccountID, "invite.unavailable")
+		return
+	}
+	account, err := s.accountManager.GetAccountMapping(r.Context(), invite.AccountID)
+	if err != nil || account == nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to load account for invite %s: %v", invite.ID, err)
+		s.reopenInvite(r.Context(), invite)
+		s.renderCallbackPage(w, r, false, invite.AccountID, "callback.save_failed")
+		return
+	}
+	if _, err := s.claimExchangedToken(r.Context(), account, tokenResp); err != nil {
+		logger.ErrorContext(r.Context()).Printf("Rejected invite %s: %v", invite.ID, err)
+		s.reopenInvite(r.Context(), invite)
+		if errors.Is(err, usecase.ErrTikTokAccountMismatch) {
+			s.renderCallbackPage(w, r, false, invite.AccountID, "invite.wrong_login")
+		} else {
+			s.renderCallbackPage(w, r, false, invite.AccountID, "callback.save_failed")
+		}
+		return
+	}
+
+	expiresIn := tokenResp.Data.ExpiresIn
+	if _, err := s.accountManager.UpdateAccountTokens(
+		usecase.WithAccountEventSource(r.Context(), domain.AccountEventSourceExchange),
+		invite.AccountID,
+		app,
+		tokenResp.Data.AccessToken,
+		tokenResp.Data.RefreshToken,
+		&expiresIn,
+	); err != nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to update account tokens for invite %s: %v", invite.ID, err)
+		// A failure after the tokens were written still connected the account
+		stored, _ := s.accountManager.GetAccountMapping(r.Context(), invite.AccountID)
+		if stored == nil || stored.TikTokAccessToken != tokenResp.Data.AccessToken {
+			s.reopenInvite(r.Context(), invite)
+		}
+		s.renderCallbackPage(w, r, false, invite.AccountID, "callback.save_failed")
+		return
+	}
+
+	s.inviteManager.CompleteInvite(r.Context(), invite)
+
+	logger.InfoContext(r.Context()).Printf("Successfully updated tokens for account %s via invite %s", invite.AccountID, invite.ID)
+	s.renderCallbackPage(w, r, true, invite.AccountID, "invite.connected")
+}
+
+// reopenInvite hands a claimed invite back after a callback that stored no token, so the client
+// can open the same link again
+func (s *Server) reopenInvite(ctx context.Context, invite *domain.Invite) {
+	if err := s.inviteManager.ReleaseInvite(invite); err != nil {
+		logger.ErrorContext(ctx).Printf("Failed to reopen invite %s: %v", invite.ID, err)
+	}
+}
+
+// inviteApp resolves the account an invite is bound to and its credential set
+func (s *Server) inviteApp(ctx context.Context, invite *domain.Invite) (*domain.Account, config.TikTokApp, error) {
+	account, err := s.accountManager.GetAccountMapping(ctx, invite.AccountID)
+	if err != nil {
+		return nil, config.TikTokApp{}, err
+	}
+	if account == nil {
+		return nil, config.TikTokApp{}, fmt.Errorf("account not found: %s", invite.AccountID)
+	}
+	app, err := s.authorizationApp(account, "")
+	return account, app, err
+}
+
+// exchangeRedirectURI returns the configured OAuth redirect URI without query parameters
+func (s *Server) exchangeRedirectURI() string {
+	redirectURI := s.cfg.TikTokRedirectURI
+	if redirectURI == "" {
+		redirectURI = fmt.Sprintf("http://localhost:%s/api/tiktok/callback", s.cfg.ServerPort)
+	}
+	return strings.Split(redirectURI, "?")[0]
+}
+
+type inviteResponse struct {
+	ID        string     `json:"id"`
+	AccountID string     `json:"account_id"`
+	URL       string     `json:"url,omitempty"`
+	Status    string     `json:"status"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func toInviteResponse(invite *domain.Invite) *inviteResponse {
+	status := "active"
+	switch {
+	case invite.RevokedAt != nil:
+		status = "revoked"
+	case invite.UsedAt != nil:
+		status = "used"
+	case !time.Now().Before(invite.ExpiresAt):
+		status = "expired"
+	}
+
+	return &inviteResponse{
+		ID:        invite.ID,
+		AccountID: invite.AccountID,
+		Status:    status,
+		ExpiresAt: invite.ExpiresAt,
+		UsedAt:    invite.UsedAt,
+		RevokedAt: invite.RevokedAt,
+		CreatedAt: invite.CreatedAt,
+	}
+}
@@ -0,0 +1,118 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/repository/memory"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// failingAccounts fails the account writes a test picks
+type failingAccounts struct {
+	domain.AccountRepository
+	failProfile bool
+	failSave    bool
+}
+
+func (r *failingAccounts) UpdateTikTokProfile(ctx context.Context, id, displayName, avatarURL string) error {
+	if r.failProfile {
+		return errors.New("database is locked")
+	}
+	return r.AccountRepository.UpdateTikTokProfile(ctx, id, displayName, avatarURL)
+}
+
+func (r *failingAccounts) Save(ctx context.Context, account *domain.Account) error {
+	if r.failSave {
+		return errors.New("database is locked")
+	}
+	return r.AccountRepository.Save(ctx, account)
+}
+
+func TestInviteCallbackReopensInviteWithoutToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		exchange    int // status of the token endpoint
+		openID      string
+		failProfile bool
+		failSave    bool
+		wantMessage string
+		wantReopen  bool
+	}{
+		{name: "exchange fails", exchange: http.StatusBadGateway, wantMessage: "invite.unavailable", wantReopen: true},
+		{name: "wrong login", exchange: http.StatusOK, openID: "tt-other", wantMessage: "invite.wrong_login", wantReopen: true},
+		{name: "login not recorded", exchange: http.StatusOK, openID: "tt-1", failProfile: true, wantMessage: "callback.save_failed", wantReopen: true},
+		{name: "tokens not saved", exchange: http.StatusOK, openID: "tt-1", failSave: true, wantMessage: "callback.save_failed", wantReopen: true},
+		{name: "connected", exchange: http.StatusOK, openID: "tt-1", wantMessage: "invite.connected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v2/oauth/token/":
+					w.WriteHeader(tt.exchange)
+					w.Write([]byte(`{"data":{"access_token":"act.new","refresh_token":"rft.new","expires_in":86400,"open_id":"` + tt.openID + `"}}`))
+				case "/user/info/":
+					w.Write([]byte(`{"data":{"user":{"open_id":"` + tt.openID + `","display_name":"Owner"}},"error":{"code":"ok"}}`))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(api.Close)
+
+			cfg := &config.Config{
+				TikTokBaseURL:     api.URL,
+				TikTokAPIKey:      "ck",
+				TikTokAPISecret:   "cs",
+				TikTokRedirectURI: "https://uploader.example/api/tiktok/callback",
+				HTTPClientTimeout: 5 * time.Second,
+				InviteSecret:      "invite-secret",
+				InviteTTL:         time.Hour,
+			}
+			stored := memory.NewAccountRepository()
+			if err := stored.Save(ctx, &domain.Account{ID: "acc-1", YouTubeChannelID: "UC-1", TikTokAccountID: "tt-1"}); err != nil {
+				t.Fatalf("save account: %v", err)
+			}
+			accounts := &failingAccounts{AccountRepository: stored}
+			invites := usecase.NewInviteManager(cfg, memory.NewInviteRepository(), stored, nil)
+			s := NewServer(cfg, usecase.NewAccountManager(cfg, accounts), memory.NewVideoRepository(), tiktok.NewService(cfg, httpclient.NewHTTPClient(cfg)))
+			s.SetInviteManager(invites)
+			_, token, err := invites.CreateInvite(ctx, "acc-1")
+			if err != nil {
+				t.Fatalf("CreateInvite() error = %v", err)
+			}
+			accounts.failProfile, accounts.failSave = tt.failProfile, tt.failSave
+
+			query := url.Values{"code": {"auth-code"}, "state": {inviteStatePrefix + token}}
+			req := httptest.NewRequest(http.MethodGet, "/api/tiktok/callback?"+query.Encode(), nil)
+			rec := httptest.NewRecorder()
+			s.server.Handler.ServeHTTP(rec, req)
+			if want := (localizer{locale: defaultLocale}).T(tt.wantMessage); !strings.Contains(rec.Body.String(), want) {
+				t.Errorf("callback page does not show %s (%q):\n%s", tt.wantMessage, want, rec.Body)
+			}
+
+			_, err = invites.ResolveInvite(token)
+			if tt.wantReopen && err != nil {
+				t.Errorf("ResolveInvite() after the failed callback error = %v, want the invite usable again", err)
+			}
+			if !tt.wantReopen && !errors.Is(err, usecase.ErrInviteInvalid) {
+				t.Errorf("ResolveInvite() after connecting error = %v, want %v", err, usecase.ErrInviteInvalid)
+			}
+			account, _ := stored.GetByID(ctx, "acc-1")
+			if connected := account.TikTokAccessToken == "act.new"; connected == tt.wantReopen {
+				t.Errorf("token stored = %v with the invite reopened = %v", connected, tt.wantReopen)
+			}
+		})
+	}
+}
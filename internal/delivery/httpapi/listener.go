@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// socketFileMode lets the owner and group of the socket, e.g. the CLI run by an operator in the
+// service's group, call the API; others cannot connect
+const socketFileMode = 0660
+
+// staleSocketProbe is how long a dial may take to tell a live socket from one left behind
+const staleSocketProbe = time.Second
+
+// listen opens the API's listener: a TCP address, or the Unix socket of server.listen. Closing a
+// Unix listener removes its socket file, so Shutdown cleans it up.
+func (s *Server) listen() (net.Listener, error) {
+	path, ok := s.cfg.ServerSocketPath()
+	if !ok {
+		listener, err := net.Listen("tcp", s.server.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", s.server.Addr, err)
+		}
+		return listener, nil
+	}
+
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on unix socket %s: %w", path, err)
+	}
+	if err := os.Chmod(path, socketFileMode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set permissions of %s: %w", path, err)
+	}
+	return listener, nil
+}
+
+// removeStaleSocket deletes a socket file a killed process left behind. A socket another process
+// still answers on, or a file that is not a socket, is reported instead of being removed.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check %s: %w", path, err)
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("server.listen: %s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, staleSocketProbe); err == nil {
+		conn.Close()
+		return fmt.Errorf("server.listen: another process is listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+	return nil
+}
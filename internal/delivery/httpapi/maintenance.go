@@ -0,0 +1,220 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// SetDatabaseMaintenance enables the manual database maintenance endpoint.
+func (s *Server) SetDatabaseMaintenance(maintenance *usecase.DatabaseMaintenance) {
+	s.dbMaintenance = maintenance
+}
+
+// SetFileReconciler enables the download directory report and reconcile endpoints.
+func (s *Server) SetFileReconciler(reconciler *usecase.FileReconciler) {
+	s.fileReconciler = reconciler
+}
+
+// fileIssueResponse is one mismatch between the download directory and the video records
+type fileIssueResponse struct {
+	Kind        string     `json:"kind"`
+	Path        string     `json:"path"`
+	SizeBytes   int64      `json:"size_bytes"`
+	ModifiedAt  *time.Time `json:"modified_at,omitempty"`
+	VideoID     string     `json:"video_id,omitempty"`
+	VideoStatus string     `json:"video_status,omitempty"`
+	Action      string     `json:"action"`
+	Protected   bool       `json:"protected"`
+	Detail      string     `json:"detail"`
+}
+
+type fileReportResponse struct {
+	Dir         string              `json:"dir"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Files       int                 `json:"files"`
+	TotalBytes  int64               `json:"total_bytes"`
+	Issues      []fileIssueResponse `json:"issues"`
+}
+
+type fileFixResponse struct {
+	fileIssueResponse
+	Applied bool   `json:"applied"`
+	Result  string `json:"result"`
+}
+
+type fileReconcileResponse struct {
+	DryRun  bool              `json:"dry_run"`
+	Applied int               `json:"applied"`
+	Fixes   []fileFixResponse `json:"fixes"`
+}
+
+func toFileIssueResponse(issue usecase.FileIssue) fileIssueResponse {
+	resp := fileIssueResponse{
+		Kind:        string(issue.Kind),
+		Path:        issue.Path,
+		SizeBytes:   issue.Size,
+		VideoID:     issue.VideoID,
+		VideoStatus: string(issue.VideoStatus),
+		Action:      string(issue.Action),
+		Protected:   issue.Protected,
+		Detail:      issue.Detail,
+	}
+	if !issue.ModTime.IsZero() {
+		modTime := issue.ModTime
+		resp.ModifiedAt = &modTime
+	}
+	return resp
+}
+
+// handleFileReport serves GET /api/maintenance/file-report
+func (s *Server) handleFileReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if s.fileReconciler == nil {
+		respondError(w, http.StatusServiceUnavailable, "file reconciliation is not enabled")
+		return
+	}
+
+	report, err := s.fileReconciler.Report(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := fileReportResponse{
+		Dir:         report.Dir,
+		GeneratedAt: report.GeneratedAt,
+		Files:       report.Files,
+		TotalBytes:  report.TotalBytes,
+		Issues:      make([]fileIssueResponse, 0, len(report.Issues)),
+	}
+	for _, issue := range report.Issues {
+		resp.Issues = append(resp.Issues, toFileIssueResponse(issue))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// handleFileReconcile serves POST /api/maintenance/file-reconcile. It is a dry run unless
+// the body sets "dry_run": false.
+func (s *Server) handleFileReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	if s.fileReconciler == nil {
+		respondError(w, http.StatusServiceUnavailable, "file reconciliation is not enabled")
+		return
+	}
+
+	var payload struct {
+		DryRun                     *bool `json:"dry_run"`
+		Relink                     bool  `json:"relink"`
+		ClearDeadPaths             bool  `json:"clear_dead_paths"`
+		DeleteOrphansOlderThanDays int   `json:"delete_orphans_older_than_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if payload.DeleteOrphansOlderThanDays < 0 {
+		respondError(w, http.StatusBadRequest, "delete_orphans_older_than_days must not be negative")
+		return
+	}
+
+	opts := usecase.FileReconcileOptions{
+		DryRun:                 payload.DryRun == nil || *payload.DryRun,
+		Relink:                 payload.Relink,
+		ClearDeadPaths:         payload.ClearDeadPaths,
+		DeleteOrphansOlderThan: time.Duration(payload.DeleteOrphansOlderThanDays) * 24 * time.Hour,
+	}
+	result, err := s.fileReconciler.Reconcile(r.Context(), opts)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := fileReconcileResponse{DryRun: result.DryRun, Fixes: make([]fileFixResponse, 0, len(result.Fixes))}
+	for _, fix := range result.Fixes {
+		if fix.Applied {
+			resp.Applied++
+		}
+		resp.Fixes = append(resp.Fixes, fileFixResponse{
+			fileIssueResponse: toFileIssueResponse(fix.Issue),
+			Applied:           fix.Applied,
+			Result:            fix.Result,
+		})
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// handleTikTokPostURLBackfill serves POST /api/maintenance/tiktok-post-urls: records the TikTok
+// post URL of completed videos uploaded before URLs were stored
+func (s *Server) handleTikTokPostURLBackfill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var result *usecase.PostURLBackfill
+	err := s.retryWrite(r.Context(), func(ctx context.Context) error {
+		var err error
+		result, err = usecase.BackfillTikTokPostURLs(ctx, s.videoRepo)
+		return err
+	})
+	if err != nil {
+		s.respondWriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"updated":    result.Updated,
+		"unresolved": result.Unresolved,
+	})
+}
+
+// handleDatabaseMaintenance serves POST /api/admin/maintenance: runs database maintenance now and
+// reports what it did
+func (s *Server) handleDatabaseMaintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.dbMaintenance == nil {
+		respondError(w, http.StatusServiceUnavailable, "database maintenance is not enabled")
+		return
+	}
+
+	result, err := s.dbMaintenance.Run(r.Context())
+	if errors.Is(err, usecase.ErrMaintenanceRunning) {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := map[string]any{
+		"rows_deleted":      result.RowsDeleted,
+		"vacuumed":          result.Vacuumed,
+		"free_percent":      result.FreePercent,
+		"size_before_bytes": result.SizeBefore,
+		"size_after_bytes":  result.SizeAfter,
+		"bytes_reclaimed":   result.BytesReclaimed(),
+		"duration_ms":       result.Duration.Milliseconds(),
+	}
+	if !result.PruneCutoff.IsZero() {
+		resp["prune_cutoff"] = result.PruneCutoff
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
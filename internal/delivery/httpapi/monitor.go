@@ -0,0 +1,111 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// checkNowTimeout bounds an on-demand check; YouTube discovery is the slow part
+const checkNowTimeout = 60 * time.Second
+
+// SetAccountMonitor enables on-demand account checks.
+func (s *Server) SetAccountMonitor(monitor *usecase.AccountMonitor) {
+	s.accountMonitor = monitor
+}
+
+// checkAccountNow runs one monitoring pass for an account instead of waiting for the cron
+func (s *Server) checkAccountNow(w http.ResponseWriter, r *http.Request, id string) {
+	if s.accountMonitor == nil {
+		respondError(w, http.StatusServiceUnavailable, "account checks are not enabled")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), checkNowTimeout)
+	defer cancel()
+
+	result, err := s.accountMonitor.MonitorAccount(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrAccountNotFound):
+			respondError(w, http.StatusNotFound, "account not found")
+		case errors.Is(err, usecase.ErrAccountInactive), errors.Is(err, usecase.ErrMonitorInProgress),
+			errors.Is(err, usecase.ErrSourceUnavailable):
+			respondError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, youtube.ErrQuotaExceeded):
+			if until := s.accountMonitor.QuotaPausedUntil(); !until.IsZero() {
+				w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+			}
+			respondError(w, http.StatusServiceUnavailable, err.Error())
+		case errors.Is(err, context.DeadlineExceeded):
+			respondError(w, http.StatusGatewayTimeout, "account check timed out")
+		default:
+			respondError(w, http.StatusBadGateway, err.Error())
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"new_videos":         result.NewVideos,
+		"skipped_videos":     result.SkippedVideos,
+		"gated_videos":       result.GatedVideos,
+		"processing_started": result.ProcessingStarted,
+	})
+}
+
+// EnqueueVideoRequest is the body of POST /api/accounts/{id}/videos
+type EnqueueVideoRequest struct {
+	YouTubeVideoID string              `json:"youtube_video_id"`
+	PostOptions    *domain.PostOptions `json:"post_options"` // Optional: overrides the account's post settings
+}
+
+// enqueueVideo serves POST /api/accounts/{id}/videos: queues one YouTube video for the account
+// by hand, optionally with post options overriding the account's TikTok post settings
+func (s *Server) enqueueVideo(w http.ResponseWriter, r *http.Request, id string) {
+	if s.accountMonitor == nil {
+		respondError(w, http.StatusServiceUnavailable, "account checks are not enabled")
+		return
+	}
+
+	var payload EnqueueVideoRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	payload.YouTubeVideoID = strings.TrimSpace(payload.YouTubeVideoID)
+	if payload.YouTubeVideoID == "" {
+		respondError(w, http.StatusBadRequest, "youtube_video_id is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), checkNowTimeout)
+	defer cancel()
+
+	video, err := s.accountMonitor.EnqueueVideo(ctx, id, payload.YouTubeVideoID, payload.PostOptions)
+	if err != nil {
+		switch {
+		case errors.Is(err, tiktok.ErrInvalidPrivacyLevel):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, usecase.ErrAccountNotFound):
+			respondError(w, http.StatusNotFound, "account not found")
+		case errors.Is(err, usecase.ErrVideoNotFound):
+			respondError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, usecase.ErrVideoAlreadyQueued):
+			respondError(w, http.StatusConflict, err.Error())
+		default:
+			respondError(w, http.StatusBadGateway, err.Error())
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, toVideoResponse(video))
+}
@@ -0,0 +1,259 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+const (
+	// oauthStateTTL bounds how long an operator has to finish the consent screen
+	oauthStateTTL = 10 * time.Minute
+
+	// oauthStateRetention keeps consumed and expired states this long past their expiry, so
+	// replays are reported as already used and show up in the metrics, before the sweep purges them
+	oauthStateRetention = time.Hour
+
+	// oauthStateSweepInterval is how often expired states are purged
+	oauthStateSweepInterval = 5 * time.Minute
+
+	// maxPendingOAuthStates caps simultaneous unfinished authorizations per account
+	maxPendingOAuthStates = 5
+
+	// oauthStateCookieSuffix names the cookie binding a state to the browser that started the flow,
+	// after the provider (tiktok_oauth_state, youtube_oauth_state)
+	oauthStateCookieSuffix = "_oauth_state"
+)
+
+var (
+	errOAuthStateUnknown  = errors.New("authorization request is unknown or was already used, please start again")
+	errOAuthStateExpired  = errors.New("authorization request expired, please start again")
+	errOAuthStateMismatch = errors.New("authorization was started in a different browser, please start again from this browser")
+	errOAuthStateLimit    = fmt.Errorf("too many authorizations in progress for this account (at most %d), finish one or wait %s", maxPendingOAuthStates, oauthStateTTL)
+)
+
+// oauthStateStore issues and consumes single-use OAuth state tokens mapped to the account being
+// authorized. Records live in a repository so a restart does not break flows in progress.
+type oauthStateStore struct {
+	repo     domain.OAuthStateRepository
+	issueMu  sync.Mutex   // Serializes the per-account cap check with the insert
+	rejected atomic.Int64 // Callbacks with unknown, reused or expired states
+	purged   atomic.Int64 // States removed by the sweep
+}
+
+type oauthState struct {
+	accountID string
+	provider  string           // domain.OAuthProviderTikTok or domain.OAuthProviderYouTube
+	app       config.TikTokApp // Credential set whose client key started the authorization (no secret)
+	expiresAt time.Time
+}
+
+func newOAuthStateStore(repo domain.OAuthStateRepository) *oauthStateStore {
+	return &oauthStateStore{repo: repo}
+}
+
+// SetOAuthStateRepository stores pending OAuth states in repo instead of memory; call before Start
+func (s *Server) SetOAuthStateRepository(repo domain.OAuthStateRepository) {
+	s.oauthStates = newOAuthStateStore(repo)
+}
+
+// Issue returns a new random state for the account authorizing with the provider; app is the
+// TikTok credential set and is empty for other providers
+func (s *oauthStateStore) Issue(ctx context.Context, accountID, provider string, app config.TikTokApp) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	state := base64.RawURLEncoding.EncodeToString(buf)
+
+	s.issueMu.Lock()
+	defer s.issueMu.Unlock()
+
+	now := time.Now()
+	pending, err := s.repo.CountPending(ctx, accountID, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to count pending authorizations: %w", err)
+	}
+	if pending >= maxPendingOAuthStates {
+		return "", errOAuthStateLimit
+	}
+
+	err = s.repo.Save(ctx, &domain.OAuthState{
+		State:     state,
+		AccountID: accountID,
+		Provider:  provider,
+		AppName:   app.Name,
+		ClientKey: app.APIKey,
+		ExpiresAt: now.Add(oauthStateTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save state: %w", err)
+	}
+	return state, nil
+}
+
+// Consume uses the state; each state is accepted at most once, even by concurrent callbacks
+func (s *oauthStateStore) Consume(ctx context.Context, state string) (oauthState, error) {
+	entry, err := s.repo.Get(ctx, state)
+	if err != nil {
+		return oauthState{}, fmt.Errorf("failed to look up state: %w", err)
+	}
+	if entry == nil || entry.ConsumedAt != nil {
+		s.rejected.Add(1)
+		return oauthState{}, errOAuthStateUnknown
+	}
+
+	now := time.Now()
+	if !now.Before(entry.ExpiresAt) {
+		s.rejected.Add(1)
+		return oauthState{}, errOAuthStateExpired
+	}
+
+	// Only the callback that flips consumed_at wins; a racing tab sees the state as used
+	consumed, err := s.repo.MarkConsumed(ctx, state, now)
+	if err != nil {
+		return oauthState{}, fmt.Errorf("failed to consume state: %w", err)
+	}
+	if !consumed {
+		s.rejected.Add(1)
+		return oauthState{}, errOAuthStateUnknown
+	}
+
+	provider := entry.Provider
+	if provider == "" {
+		provider = domain.OAuthProviderTikTok
+	}
+	return oauthState{
+		accountID: entry.AccountID,
+		provider:  provider,
+		app:       config.TikTokApp{Name: entry.AppName, APIKey: entry.ClientKey},
+		expiresAt: entry.ExpiresAt,
+	}, nil
+}
+
+// Sweep purges states that expired more than oauthStateRetention ago
+func (s *oauthStateStore) Sweep(ctx context.Context) (int, error) {
+	removed, err := s.repo.DeleteExpiredBefore(ctx, time.Now().Add(-oauthStateRetention))
+	if err != nil {
+		return 0, err
+	}
+	s.purged.Add(int64(removed))
+	return removed, nil
+}
+
+// oauthStateMetrics is the OAuth state section of /api/metrics
+type oauthStateMetrics struct {
+	Outstanding int   `json:"outstanding"`
+	Consumed    int   `json:"consumed"`
+	Expired     int   `json:"expired"`
+	Rejected    int64 `json:"rejected"` // Since start: unknown, reused or expired callbacks
+	Purged      int64 `json:"purged"`   // Since start: states removed by the sweep
+}
+
+// Metrics counts stored states by lifecycle stage
+func (s *oauthStateStore) Metrics(ctx context.Context) (*oauthStateMetrics, error) {
+	stats, err := s.repo.Stats(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &oauthStateMetrics{
+		Outstanding: stats.Outstanding,
+		Consumed:    stats.Consumed,
+		Expired:     stats.Expired,
+		Rejected:    s.rejected.Load(),
+		Purged:      s.purged.Load(),
+	}, nil
+}
+
+// sweepOAuthStates purges expired states until the server shuts down
+func (s *Server) sweepOAuthStates(done <-chan struct{}) {
+	ticker := time.NewTicker(oauthStateSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			removed, err := s.oauthStates.Sweep(ctx)
+			cancel()
+			if err != nil {
+				logger.Error().Printf("Failed to sweep expired OAuth states: %v", err)
+			} else if removed > 0 {
+				logger.Info().Printf("Purged %d expired OAuth states", removed)
+			}
+		}
+	}
+}
+
+// oauthCallbackPath is where a provider redirects back to after consent
+func oauthCallbackPath(provider string) string {
+	return "/api/" + provider + "/callback"
+}
+
+// oauthRedirectURI returns the redirect URI registered with the provider
+func (s *Server) oauthRedirectURI(provider string) string {
+	if provider == domain.OAuthProviderYouTube {
+		return s.youtubeRedirectURI()
+	}
+	return s.exchangeRedirectURI()
+}
+
+// setOAuthStateCookie remembers the state in the operator's browser for the callback check
+func (s *Server) setOAuthStateCookie(w http.ResponseWriter, provider, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     provider + oauthStateCookieSuffix,
+		Value:    state,
+		Path:     oauthCallbackPath(provider),
+		MaxAge:   int(oauthStateTTL / time.Second),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(s.oauthRedirectURI(provider), "https://"),
+		SameSite: http.SameSiteLaxMode, // Sent on the top-level redirect back from the provider
+	})
+}
+
+// verifyOAuthState checks the state returned to a provider's callback against the browser cookie
+// and the store
+func (s *Server) verifyOAuthState(w http.ResponseWriter, r *http.Request, provider, state string) (oauthState, error) {
+	// Clear the cookie whatever the outcome; a state is never reused
+	http.SetCookie(w, &http.Cookie{
+		Name:   provider + oauthStateCookieSuffix,
+		Value:  "",
+		Path:   oauthCallbackPath(provider),
+		MaxAge: -1,
+	})
+
+	if state == "" {
+		return oauthState{}, errOAuthStateUnknown
+	}
+	cookie, err := r.Cookie(provider + oauthStateCookieSuffix)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		// Consume anyway so a leaked state cannot be replayed from the right browser later
+		s.oauthStates.Consume(r.Context(), state)
+		return oauthState{}, errOAuthStateMismatch
+	}
+	issued, err := s.oauthStates.Consume(r.Context(), state)
+	if err != nil {
+		return oauthState{}, err
+	}
+	// A state issued for one provider never authorizes the other
+	if issued.provider != provider {
+		s.oauthStates.rejected.Add(1)
+		return oauthState{}, errOAuthStateUnknown
+	}
+	return issued, nil
+}
@@ -0,0 +1,138 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/memory"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+)
+
+// oauthStateBackends returns each kind of state repository, with the account acc-1 the states
+// belong to
+func oauthStateBackends(t *testing.T) map[string]domain.OAuthStateRepository {
+	t.Helper()
+	repos := sqlitetest.OpenTest(t)
+	account := &domain.Account{ID: "acc-1", YouTubeChannelID: "UC-1", TikTokAccountID: "tt-1"}
+	if err := repos.Accounts.Save(context.Background(), account); err != nil {
+		t.Fatalf("save account: %v", err)
+	}
+	return map[string]domain.OAuthStateRepository{
+		"memory": memory.NewOAuthStateRepository(),
+		"sqlite": repos.OAuthStates,
+	}
+}
+
+func TestOAuthStateConsumeConcurrent(t *testing.T) {
+	const callbacks = 16
+	for name, repo := range oauthStateBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			store := newOAuthStateStore(repo)
+			ctx := context.Background()
+			state, err := store.Issue(ctx, "acc-1", domain.OAuthProviderTikTok, config.TikTokApp{Name: "main", APIKey: "ck"})
+			if err != nil {
+				t.Fatalf("Issue() error = %v", err)
+			}
+
+			var (
+				wg    sync.WaitGroup
+				start = make(chan struct{})
+				errs  = make(chan error, callbacks)
+			)
+			for range callbacks {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					entry, err := store.Consume(ctx, state)
+					if err == nil && entry.accountID != "acc-1" {
+						t.Errorf("Consume() account = %q, want acc-1", entry.accountID)
+					}
+					errs <- err
+				}()
+			}
+			close(start)
+			wg.Wait()
+			close(errs)
+
+			accepted := 0
+			for err := range errs {
+				switch {
+				case err == nil:
+					accepted++
+				case !errors.Is(err, errOAuthStateUnknown):
+					t.Errorf("Consume() error = %v, want %v", err, errOAuthStateUnknown)
+				}
+			}
+			if accepted != 1 {
+				t.Errorf("%d callbacks accepted the state, want 1", accepted)
+			}
+			if got := store.rejected.Load(); got != callbacks-1 {
+				t.Errorf("rejected = %d, want %d", got, callbacks-1)
+			}
+			if _, err := store.Consume(ctx, state); !errors.Is(err, errOAuthStateUnknown) {
+				t.Errorf("Consume() after the race error = %v, want %v", err, errOAuthStateUnknown)
+			}
+		})
+	}
+}
+
+func TestOAuthStateConsume(t *testing.T) {
+	now := time.Now()
+	consumed := now.Add(-time.Minute)
+	tests := []struct {
+		name    string
+		state   *domain.OAuthState
+		consume string
+		wantErr error
+	}{
+		{
+			name:    "pending",
+			state:   &domain.OAuthState{State: "s1", ExpiresAt: now.Add(time.Minute)},
+			consume: "s1",
+		},
+		{
+			name:    "unknown",
+			state:   &domain.OAuthState{State: "s1", ExpiresAt: now.Add(time.Minute)},
+			consume: "other",
+			wantErr: errOAuthStateUnknown,
+		},
+		{
+			name:    "expired",
+			state:   &domain.OAuthState{State: "s1", ExpiresAt: now.Add(-time.Second)},
+			consume: "s1",
+			wantErr: errOAuthStateExpired,
+		},
+		{
+			name:    "already consumed",
+			state:   &domain.OAuthState{State: "s1", ExpiresAt: now.Add(time.Minute), ConsumedAt: &consumed},
+			consume: "s1",
+			wantErr: errOAuthStateUnknown,
+		},
+	}
+	for _, tt := range tests {
+		for name, repo := range oauthStateBackends(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				state := *tt.state
+				state.AccountID = "acc-1"
+				state.Provider = domain.OAuthProviderYouTube
+				if err := repo.Save(context.Background(), &state); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+
+				entry, err := newOAuthStateStore(repo).Consume(context.Background(), tt.consume)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Consume() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil && entry.provider != domain.OAuthProviderYouTube {
+					t.Errorf("Consume() provider = %q, want %q", entry.provider, domain.OAuthProviderYouTube)
+				}
+			})
+		}
+	}
+}
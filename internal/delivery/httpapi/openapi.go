@@ -0,0 +1,487 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/version"
+)
+
+// schema is an OpenAPI schema written out by hand, for responses that have no Go type
+type schema map[string]any
+
+// apiOperation is one endpoint of the OpenAPI document
+type apiOperation struct {
+	method    string
+	path      string // Path parameters in braces, e.g. /api/accounts/{id}
+	tag       string
+	summary   string
+	query     []apiParam
+	body      any // Zero value of the request body type; nil when there is none
+	responses []apiResponse
+}
+
+// apiParam is a query parameter
+type apiParam struct {
+	name        string
+	typ         string // OpenAPI type: string, integer or boolean
+	description string
+}
+
+// apiResponse is one documented answer. body is the zero value of the JSON body type, a schema,
+// or nil for answers without a JSON body.
+type apiResponse struct {
+	status      int
+	description string
+	body        any
+	contentType string // Defaults to application/json
+}
+
+// apiOperations lists the documented API surface. Request and response bodies reference the Go
+// types the handlers encode and decode, so their schemas follow the code.
+var apiOperations = []apiOperation{
+	{method: http.MethodGet, path: "/api/health", tag: "health", summary: "Service heartbeat and dependency checks",
+		responses: []apiResponse{
+			{status: http.StatusOK, description: "Healthy or degraded", body: HealthResponse{}},
+			{status: http.StatusServiceUnavailable, description: "A critical check failed", body: HealthResponse{}},
+		}},
+	{method: http.MethodGet, path: "/api/version", tag: "health", summary: "The running build",
+		responses: []apiResponse{{status: http.StatusOK, body: versionResponse{}}}},
+	{method: http.MethodGet, path: "/api/metrics", tag: "metrics", summary: "Queue, transfer, OAuth state and upstream counters",
+		responses: []apiResponse{{status: http.StatusOK, body: MetricsResponse{}}}},
+	{method: http.MethodGet, path: "/api/metrics/upstreams", tag: "metrics", summary: "Requests to each upstream operation over the last hour",
+		responses: []apiResponse{{status: http.StatusOK, body: schema{
+			"type": "object",
+			"properties": map[string]any{
+				"window_seconds": schema{"type": "integer"},
+				"operations":     schema{"type": "array", "items": schemaRef(httpclient.OperationSummary{})},
+			},
+		}}}},
+	{method: http.MethodGet, path: "/api/videos/stats", tag: "metrics", summary: "Processing time percentiles of uploads completed in a window",
+		query: []apiParam{{name: "window", typ: "string", description: `Look-back as a Go duration ("36h") or whole days ("7d"); default 7d`}},
+		responses: []apiResponse{{status: http.StatusOK, body: schema{
+			"type": "object",
+			"properties": map[string]any{
+				"window":            schema{"type": "string"},
+				"since":             schema{"type": "string", "format": "date-time"},
+				"videos":            schema{"type": "integer"},
+				"publish_to_post":   schemaRef(durationStatsResponse{}),
+				"discovery_to_post": schemaRef(durationStatsResponse{}),
+				"download":          schemaRef(durationStatsResponse{}),
+				"upload":            schemaRef(durationStatsResponse{}),
+			},
+		}}}},
+
+	{method: http.MethodGet, path: "/api/accounts", tag: "accounts", summary: "List account mappings",
+		responses: []apiResponse{{status: http.StatusOK, body: []*AccountResponse{}}}},
+	{method: http.MethodPost, path: "/api/accounts", tag: "accounts", summary: "Create an account mapping",
+		body:      CreateAccountRequest{},
+		responses: []apiResponse{{status: http.StatusCreated, body: AccountResponse{}}}},
+	{method: http.MethodPatch, path: "/api/accounts/{id}", tag: "accounts", summary: "Update mapping fields, settings or activity",
+		body:      UpdateAccountRequest{},
+		responses: []apiResponse{{status: http.StatusOK, body: AccountResponse{}}}},
+	{method: http.MethodDelete, path: "/api/accounts/{id}", tag: "accounts", summary: "Delete an account mapping",
+		responses: []apiResponse{{status: http.StatusOK, body: statusSchema}}},
+	{method: http.MethodPost, path: "/api/accounts/{id}/activate", tag: "accounts", summary: "Resume monitoring and uploads",
+		responses: []apiResponse{{status: http.StatusOK, body: statusSchema}}},
+	{method: http.MethodPost, path: "/api/accounts/{id}/deactivate", tag: "accounts", summary: "Stop monitoring and uploads",
+		responses: []apiResponse{{status: http.StatusOK, body: statusSchema}}},
+	{method: http.MethodGet, path: "/api/accounts/{id}/videos", tag: "accounts", summary: "The account's videos, most recently updated first",
+		query:     videoListParams,
+		responses: []apiResponse{{status: http.StatusOK, body: AccountVideosResponse{}}}},
+	{method: http.MethodPost, path: "/api/accounts/{id}/videos", tag: "accounts", summary: "Queue one YouTube video for the account",
+		body: EnqueueVideoRequest{},
+		responses: []apiResponse{
+			{status: http.StatusCreated, body: VideoResponse{}},
+			{status: http.StatusConflict, description: "The video is already queued", body: ErrorResponse{}},
+		}},
+	{method: http.MethodGet, path: "/api/accounts/{id}/token-status", tag: "accounts", summary: "Check the stored TikTok token live; token values are never returned",
+		responses: []apiResponse{{status: http.StatusOK, body: schema{
+			"type": "object",
+			"properties": map[string]any{
+				"account_id":            schema{"type": "string"},
+				"has_access_token":      schema{"type": "boolean"},
+				"has_refresh_token":     schema{"type": "boolean"},
+				"token_expires_at":      schema{"type": "string", "format": "date-time", "nullable": true},
+				"expired":               schema{"type": "boolean"},
+				"valid":                 schema{"type": "boolean"},
+				"display_name":          schema{"type": "string"},
+				"tiktok_app":            schema{"type": "string"},
+				"needs_reauthorization": schema{"type": "boolean"},
+				"missing_scopes":        schema{"type": "array", "items": schema{"type": "string"}},
+				"tiktok_app_mismatch":   schema{"type": "string"},
+			},
+		}}}},
+	{method: http.MethodGet, path: "/api/accounts/{id}/events", tag: "accounts", summary: "Changes to the mapping and its tokens, newest first; token values are redacted",
+		responses: []apiResponse{
+			{status: http.StatusOK, body: AccountEventsResponse{}},
+			{status: http.StatusNotFound, description: "No such account and no recorded history", body: ErrorResponse{}},
+		}},
+	{method: http.MethodGet, path: "/api/accounts/{id}/usage", tag: "accounts", summary: "Processing cost of the account's videos in a calendar month",
+		query: []apiParam{{name: "month", typ: "string", description: "YYYY-MM in local time; default the current month"}},
+		responses: []apiResponse{{status: http.StatusOK, body: schema{
+			"type": "object",
+			"properties": map[string]any{
+				"account_id":       schema{"type": "string"},
+				"month":            schema{"type": "string"},
+				"videos":           schema{"type": "integer"},
+				"completed_videos": schema{"type": "integer"},
+				"cost":             schemaRef(costResponse{}),
+			},
+		}}}},
+
+	{method: http.MethodGet, path: "/api/videos", tag: "videos", summary: "Videos of all accounts, most recently updated first",
+		query:     videoListParams,
+		responses: []apiResponse{{status: http.StatusOK, body: VideoListResponse{}}}},
+	{method: http.MethodGet, path: "/api/videos/pending", tag: "videos", summary: "The next pending videos in processing order",
+		query:     []apiParam{{name: "limit", typ: "integer", description: "At most 100; default 20"}},
+		responses: []apiResponse{{status: http.StatusOK, body: PendingVideosResponse{}}}},
+	{method: http.MethodGet, path: "/api/videos/{id}", tag: "videos", summary: "One video with its clips",
+		responses: []apiResponse{{status: http.StatusOK, body: schema{
+			"type": "object",
+			"properties": map[string]any{
+				"video": schemaRef(VideoResponse{}),
+				"clips": schema{"type": "array", "items": schemaRef(VideoResponse{})},
+			},
+		}}}},
+	{method: http.MethodPatch, path: "/api/videos/{id}", tag: "videos", summary: "Change the video's queue priority",
+		body:      schema{"type": "object", "required": []string{"priority"}, "properties": map[string]any{"priority": schema{"type": "integer"}}},
+		responses: []apiResponse{{status: http.StatusOK, body: schema{"type": "object"}}}},
+	{method: http.MethodGet, path: "/api/videos/{id}/upload-preview", tag: "videos", summary: "What uploading the video now would send to TikTok, without sending it",
+		responses: []apiResponse{
+			{status: http.StatusOK, body: UploadPreviewResponse{}},
+			{status: http.StatusNotFound, description: "No such video", body: schema{"type": "object"}},
+		}},
+	{method: http.MethodGet, path: "/api/videos/export", tag: "videos", summary: "The video history as NDJSON or CSV",
+		query: []apiParam{
+			{name: "format", typ: "string", description: "ndjson (default) or csv"},
+			{name: "status", typ: "string", description: "Only videos with this status"},
+			{name: "cursor", typ: "string", description: "Resume after this video ID"},
+		},
+		responses: []apiResponse{
+			{status: http.StatusOK, description: "One video per line", contentType: "application/x-ndjson"},
+		}},
+
+	{method: http.MethodPost, path: "/api/tiktok/exchange-code", tag: "tiktok", summary: "Exchange a TikTok authorization code and store the tokens",
+		body: ExchangeCodeRequest{},
+		responses: []apiResponse{
+			{status: http.StatusOK, body: ExchangeCodeResponse{}},
+			{status: http.StatusConflict, description: "The code belongs to another TikTok account than the mapping's", body: schema{"type": "object"}},
+		}},
+	{method: http.MethodGet, path: "/api/tiktok/authorize/{id}", tag: "tiktok", summary: "Start TikTok authorization for the account",
+		query: []apiParam{{name: "app", typ: "string", description: "Credential set to authorize under (default: the account's)"}},
+		responses: []apiResponse{
+			{status: http.StatusFound, description: "Redirect to TikTok's authorization page"},
+		}},
+	{method: http.MethodGet, path: "/api/tiktok/callback", tag: "tiktok", summary: "OAuth redirect target; TikTok sends the browser here",
+		query: []apiParam{
+			{name: "code", typ: "string"},
+			{name: "state", typ: "string"},
+			{name: "error", typ: "string"},
+			{name: "error_description", typ: "string"},
+		},
+		responses: []apiResponse{
+			{status: http.StatusOK, description: "Result page", contentType: "text/html"},
+		}},
+}
+
+// videoListParams are the query parameters of the video listings
+var videoListParams = []apiParam{
+	{name: "status", typ: "string", description: "Only videos with this status"},
+	{name: "limit", typ: "integer", description: "At most 200; default 50"},
+	{name: "offset", typ: "integer"},
+}
+
+// statusSchema is the {"status": "..."} answer of simple actions
+var statusSchema = schema{"type": "object", "properties": map[string]any{"status": schema{"type": "string"}}}
+
+// pathParam matches the path parameters of apiOperation paths
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// goType is a Go type inside a hand-written schema; it is replaced by the type's schema
+type goType struct {
+	value any
+}
+
+// schemaRef refers to the schema of v's type from a hand-written schema
+func schemaRef(v any) goType {
+	return goType{value: v}
+}
+
+// schemaBuilder turns Go types into OpenAPI schemas, registering named structs as components
+type schemaBuilder struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+// schemaOf returns the schema of a value: a schema is used as written, anything else by its type
+func (b *schemaBuilder) schemaOf(value any) any {
+	switch value.(type) {
+	case schema, goType:
+		return b.resolve(value)
+	default:
+		return b.typeSchema(reflect.TypeOf(value))
+	}
+}
+
+// resolve replaces the schemaRef values inside a hand-written schema with their types' schemas
+func (b *schemaBuilder) resolve(value any) any {
+	switch v := value.(type) {
+	case goType:
+		return b.typeSchema(reflect.TypeOf(v.value))
+	case schema:
+		out := make(schema, len(v))
+		for key, item := range v {
+			out[key] = b.resolve(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = b.resolve(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// typeSchema follows encoding/json: pointers are their element, time.Time and custom marshalers
+// (durations) are strings, and named structs are references to components
+func (b *schemaBuilder) typeSchema(t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return schema{"type": "string", "format": "date-time"}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		return schema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return schema{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return schema{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return schema{"type": "string", "format": "byte"}
+		}
+		return schema{"type": "array", "items": b.typeSchema(t.Elem())}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": b.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return schema{"$ref": "#/components/schemas/" + b.component(t)}
+	default:
+		return schema{}
+	}
+}
+
+// component registers a named struct once and returns its component name
+func (b *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := exportedName(t.Name())
+	if _, taken := b.components[name]; taken {
+		// Same name in another package, e.g. domain and httpapi
+		name = exportedName(path.Base(t.PkgPath())) + name
+	}
+	b.names[t] = name
+	b.components[name] = schema{} // Placeholder for self-referencing types
+	b.components[name] = b.structSchema(t)
+	return name
+}
+
+// exportedName capitalizes a Go identifier, so unexported response types read like the rest
+func exportedName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// structSchema lists a struct's JSON fields; fields without omitempty are required unless they
+// are pointers, and embedded structs contribute their fields as encoding/json does
+func (b *schemaBuilder) structSchema(t reflect.Type) schema {
+	props := make(map[string]any)
+	var required []string
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				collect(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			props[name] = b.typeSchema(field.Type)
+			if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+				required = append(required, name)
+			}
+		}
+	}
+	collect(t)
+
+	s := schema{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s
+}
+
+// openAPIDocument builds the OpenAPI 3 document of apiOperations
+func openAPIDocument() map[string]any {
+	b := &schemaBuilder{components: make(map[string]any), names: make(map[reflect.Type]string)}
+	errorSchema := b.schemaOf(ErrorResponse{})
+
+	paths := make(map[string]map[string]any)
+	for _, op := range apiOperations {
+		var params []any
+		for _, match := range pathParam.FindAllStringSubmatch(op.path, -1) {
+			params = append(params, schema{"name": match[1], "in": "path", "required": true, "schema": schema{"type": "string"}})
+		}
+		for _, p := range op.query {
+			param := schema{"name": p.name, "in": "query", "schema": schema{"type": p.typ}}
+			if p.description != "" {
+				param["description"] = p.description
+			}
+			params = append(params, param)
+		}
+
+		responses := map[string]any{
+			"default": schema{
+				"description": "Error",
+				"content":     schema{"application/json": schema{"schema": errorSchema}},
+			},
+		}
+		for _, resp := range op.responses {
+			description := resp.description
+			if description == "" {
+				description = http.StatusText(resp.status)
+			}
+			out := schema{"description": description}
+			switch {
+			case resp.contentType != "":
+				out["content"] = schema{resp.contentType: schema{}}
+			case resp.body != nil:
+				out["content"] = schema{"application/json": schema{"schema": b.schemaOf(resp.body)}}
+			}
+			responses[strconv.Itoa(resp.status)] = out
+		}
+
+		operation := schema{
+			"summary":     op.summary,
+			"tags":        []string{op.tag},
+			"operationId": operationID(op),
+			"responses":   responses,
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.body != nil {
+			operation["requestBody"] = schema{
+				"required": true,
+				"content":  schema{"application/json": schema{"schema": b.schemaOf(op.body)}},
+			}
+		}
+		if paths[op.path] == nil {
+			paths[op.path] = make(map[string]any)
+		}
+		paths[op.path][strings.ToLower(op.method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": schema{
+			"title":       "auto_upload_tiktok API",
+			"version":     version.Get().Version,
+			"description": "Runtime management of YouTube to TikTok account mappings and the video queue.",
+		},
+		"paths":      paths,
+		"components": schema{"schemas": b.components},
+	}
+}
+
+// operationID names an operation after its method and path, e.g. patchApiAccountsId
+func operationID(op apiOperation) string {
+	id := strings.ToLower(op.method)
+	for _, part := range strings.FieldsFunc(op.path, func(r rune) bool { return r == '/' || r == '-' || r == '{' || r == '}' }) {
+		id += exportedName(part)
+	}
+	return id
+}
+
+// openAPIJSON is the encoded document; the API surface only changes with the build
+var openAPIJSON = sync.OnceValues(func() ([]byte, error) {
+	return json.MarshalIndent(openAPIDocument(), "", "  ")
+})
+
+// handleOpenAPI serves GET /api/openapi.json
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	doc, err := openAPIJSON()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(doc)
+}
+
+// apiDocsPage renders /api/openapi.json with Redoc
+const apiDocsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>auto_upload_tiktok API</title>
+</head>
+<body>
+  <redoc spec-url="/api/openapi.json"></redoc>
+  <script src="https://cdn.jsdelivr.net/npm/redoc@2.1.5/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// handleAPIDocs serves GET /api/docs, a browsable view of the OpenAPI document
+func (s *Server) handleAPIDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(apiDocsPage))
+}
@@ -0,0 +1,109 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// SetPipelineSwitch enables the pipeline pause and resume endpoints and the pause state in
+// /api/health
+func (s *Server) SetPipelineSwitch(pipeline *usecase.PipelineSwitch) {
+	s.pipeline = pipeline
+}
+
+// pipelineResponse is the pipeline's pause state
+type pipelineResponse struct {
+	Paused    bool       `json:"paused"`
+	Scope     string     `json:"scope,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func toPipelineResponse(state domain.PipelineState) *pipelineResponse {
+	resp := &pipelineResponse{
+		Paused: state.Paused(),
+		Scope:  string(state.Scope),
+		Reason: state.Reason,
+	}
+	if !state.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &state.UpdatedAt
+	}
+	return resp
+}
+
+// handlePipeline serves GET /api/pipeline: whether and what part of the pipeline is paused
+func (s *Server) handlePipeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if s.pipeline == nil {
+		respondError(w, http.StatusServiceUnavailable, "pipeline switch is not available")
+		return
+	}
+	respondJSON(w, http.StatusOK, toPipelineResponse(s.pipeline.State()))
+}
+
+// handlePipelinePause serves POST /api/pipeline/pause. The scope (uploads, downloads or all, the
+// default) comes from the scope query parameter or {"scope": "...", "reason": "..."} in the body.
+func (s *Server) handlePipelinePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	if s.pipeline == nil {
+		respondError(w, http.StatusServiceUnavailable, "pipeline switch is not available")
+		return
+	}
+
+	var payload struct {
+		Scope  string `json:"scope"`
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+	if scope := r.URL.Query().Get("scope"); scope != "" {
+		payload.Scope = scope
+	}
+	if payload.Scope == "" {
+		payload.Scope = string(domain.PauseScopeAll)
+	}
+
+	state, err := s.pipeline.Pause(r.Context(), domain.PauseScope(payload.Scope), payload.Reason)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidPauseScope):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	default:
+		respondJSON(w, http.StatusOK, toPipelineResponse(state))
+	}
+}
+
+// handlePipelineResume serves POST /api/pipeline/resume, lifting any pause
+func (s *Server) handlePipelineResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	if s.pipeline == nil {
+		respondError(w, http.StatusServiceUnavailable, "pipeline switch is not available")
+		return
+	}
+
+	state, err := s.pipeline.Resume(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toPipelineResponse(state))
+}
@@ -0,0 +1,255 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// Public page limits
+const (
+	publicPageRequestsPerMinute = 30
+	publicPageMaxAge            = 5 * time.Minute
+)
+
+// SetPublicPageManager enables the public account status pages.
+func (s *Server) SetPublicPageManager(manager *usecase.PublicPageManager) {
+	s.publicPages = manager
+	s.publicLimiter = newRateLimiter(publicPageRequestsPerMinute, time.Minute)
+}
+
+// handleAccountPublicPage serves POST (create or rotate) and DELETE (revoke) on /api/accounts/{id}/public-page
+func (s *Server) handleAccountPublicPage(w http.ResponseWriter, r *http.Request, accountID string) {
+	if s.publicPages == nil || !s.cfg.PublicPagesEnabled {
+		respondError(w, http.StatusServiceUnavailable, "public pages are not enabled")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var slug string
+		err := s.retryWrite(r.Context(), func(ctx context.Context) error {
+			var err error
+			slug, err = s.publicPages.EnablePublicPage(ctx, accountID)
+			return err
+		})
+		if err != nil {
+			s.respondWriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		respondJSON(w, http.StatusCreated, map[string]string{
+			"slug": slug,
+			"url":  s.publicPages.PublicPageURL(slug),
+		})
+	case http.MethodDelete:
+		if err := s.retryWrite(r.Context(), func(ctx context.Context) error {
+			return s.publicPages.DisablePublicPage(ctx, accountID)
+		}); err != nil {
+			s.respondWriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// handlePublicPage renders GET /public/accounts/{slug}, as HTML or with ?format=json as JSON
+func (s *Server) handlePublicPage(w http.ResponseWriter, r *http.Request) {
+	if s.publicPages == nil || !s.cfg.PublicPagesEnabled {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		methodNotAllowed(w)
+		return
+	}
+	if !s.publicLimiter.Allow(s.clientIP(r)) {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+
+	slug := strings.TrimPrefix(r.URL.Path, "/public/accounts/")
+	if slug == "" || strings.Contains(slug, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	page, err := s.publicPages.GetPublicPage(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, usecase.ErrPublicPageNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		logger.ErrorContext(r.Context()).Printf("Failed to build public page: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// ?format=json serves the same fields for embedding in the clients' own sites
+	var body bytes.Buffer
+	contentType := "text/html; charset=utf-8"
+	if r.URL.Query().Get("format") == "json" {
+		contentType = "application/json"
+		err = json.NewEncoder(&body).Encode(page)
+	} else {
+		err = publicPageTemplate.Execute(&body, page)
+	}
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to render public page: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Unchanged listings revalidate as 304 once the cache max-age runs out
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(publicPageMaxAge/time.Second)))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Robots-Tag", "noindex")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		w.Write(body.Bytes())
+	}
+}
+
+// clientIP returns the address of the request's client: the remote IP without the port, or,
+// when that is a trusted proxy (server.trusted_proxies), the last X-Forwarded-For hop no trusted
+// proxy added. Proxies append to the header, so only its right end can be believed.
+func (s *Server) clientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	trusted, trustUnix, err := config.ParseTrustedProxies(s.cfg.TrustedProxies)
+	if err != nil || (len(trusted) == 0 && !trustUnix) {
+		return remote
+	}
+	isTrusted := func(ip net.IP) bool {
+		for _, ipNet := range trusted {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	// Connections on the Unix socket listener have no IP
+	proxied := trustUnix
+	if ip := net.ParseIP(remote); ip != nil {
+		proxied = isTrusted(ip)
+	}
+	if !proxied {
+		return remote
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		client = ip.String()
+		if !isTrusted(ip) {
+			break
+		}
+	}
+	return client
+}
+
+// rateLimiter allows a fixed number of requests per key in each window
+type rateLimiter struct {
+	mu          sync.Mutex
+	limit       int
+	window      time.Duration
+	windowStart time.Time
+	counts      map[string]int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+		counts: make(map[string]int),
+	}
+}
+
+// Allow records a request for key and reports whether it is within the limit
+func (l *rateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.windowStart) >= l.window {
+		// Starting a fresh map each window keeps memory bounded by one window's clients
+		l.windowStart = now
+		l.counts = make(map[string]int)
+	}
+
+	l.counts[key]++
+	return l.counts[key] <= l.limit
+}
+
+var publicPageTemplate = template.Must(template.New("public").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<meta name="robots" content="noindex">
+	<title>Mirrored videos</title>
+	<style>
+		body { font-family: Arial, sans-serif; max-width: 900px; margin: 20px auto; padding: 20px; background: #f5f5f5; }
+		.container { background: white; padding: 30px; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
+		table { width: 100%; border-collapse: collapse; }
+		th, td { text-align: left; padding: 8px; border-bottom: 1px solid #eee; }
+		.muted { color: #888; font-size: 0.9em; }
+	</style>
+</head>
+<body>
+	<div class="container">
+		<h1>Mirrored videos</h1>
+		<p>Recent uploads from <a href="{{.YouTubeChannelURL}}" rel="noopener">this YouTube channel</a> and where they were posted on TikTok.</p>
+		{{if .Videos}}
+		<table>
+			<tr><th>Video</th><th>YouTube</th><th>TikTok</th><th>Published</th><th>Posted to TikTok</th></tr>
+			{{range .Videos}}
+			<tr>
+				<td>{{.Title}}</td>
+				<td><a href="{{.YouTubeURL}}" rel="noopener">Watch</a></td>
+				<td>{{if .TikTokURL}}<a href="{{.TikTokURL}}" rel="noopener">Watch</a>{{else}}<span class="muted">posted</span>{{end}}</td>
+				<td>{{if not .PublishedAt.IsZero}}{{.PublishedAt.Format "2006-01-02"}}{{end}}</td>
+				<td>{{if not .MirroredAt.IsZero}}{{.MirroredAt.Format "2006-01-02 15:04"}}{{end}}</td>
+			</tr>
+			{{end}}
+		</table>
+		{{else}}
+		<p class="muted">No videos have been mirrored yet.</p>
+		{{end}}
+	</div>
+</body>
+</html>
+`))
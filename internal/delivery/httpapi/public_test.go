@@ -0,0 +1,188 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/repository/memory"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// newPublicPageServer serves the public page of an account acc-1 and returns its path
+func newPublicPageServer(t *testing.T, cfg *config.Config, videos ...*domain.Video) (*Server, string) {
+	t.Helper()
+	ctx := context.Background()
+	cfg.PublicPagesEnabled = true
+	accounts := memory.NewAccountRepository()
+	account := &domain.Account{
+		ID:                 "acc-1",
+		YouTubeChannelID:   "UC-1",
+		TikTokAccountID:    "open-id-private",
+		TikTokAccessToken:  testAccessToken,
+		TikTokRefreshToken: testRefreshToken,
+		TikTokClientKey:    "ck-private",
+		IsActive:           true,
+		FailureStreak: domain.FailureStreak{
+			ConsecutiveFailures: 2,
+			LastError:           "upload rejected: access_token_invalid for open-id-private",
+			LastErrorSource:     domain.FailureSourceVideo,
+		},
+	}
+	if err := accounts.Save(ctx, account); err != nil {
+		t.Fatalf("save account: %v", err)
+	}
+	videoRepo := memory.NewVideoRepository()
+	for _, video := range videos {
+		if err := videoRepo.Save(ctx, video); err != nil {
+			t.Fatalf("save video: %v", err)
+		}
+	}
+
+	s := NewServer(cfg, usecase.NewAccountManager(cfg, accounts), videoRepo, tiktok.NewService(cfg, httpclient.NewHTTPClient(cfg)))
+	pages := usecase.NewPublicPageManager(cfg, accounts, videoRepo)
+	s.SetPublicPageManager(pages)
+	slug, err := pages.EnablePublicPage(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("EnablePublicPage() error = %v", err)
+	}
+	return s, "/public/accounts/" + slug
+}
+
+func TestPublicPageShowsOnlyPublicFields(t *testing.T) {
+	completedAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	s, path := newPublicPageServer(t, &config.Config{},
+		&domain.Video{
+			ID:             "vid-internal-1",
+			AccountID:      "acc-1",
+			YouTubeVideoID: "yt-1",
+			Title:          "Mirrored title",
+			Status:         domain.VideoStatusCompleted,
+			TikTokVideoID:  "7300000000000000001",
+			LocalFilePath:  "/var/lib/uploader/downloads/yt-1.mp4",
+			ErrorMessage:   "retried after: refresh_token expired for open-id-private",
+			CommentError:   "comment failed: scope missing",
+			PublishedAt:    completedAt.Add(-time.Hour),
+			CompletedAt:    completedAt,
+		},
+		&domain.Video{
+			ID:             "vid-internal-2",
+			AccountID:      "acc-1",
+			YouTubeVideoID: "yt-2",
+			Title:          "Failed title",
+			Status:         domain.VideoStatusFailed,
+			ErrorMessage:   "upload failed: spam_risk_too_many_posts",
+		},
+	)
+	private := []string{
+		testAccessToken, testRefreshToken, "open-id-private", "ck-private", "access_token_invalid",
+		"vid-internal-", "/var/lib/uploader", "refresh_token expired", "comment failed", "spam_risk", "Failed title",
+	}
+
+	for _, format := range []string{"", "json"} {
+		t.Run("format="+format, func(t *testing.T) {
+			target := path
+			if format != "" {
+				target += "?format=" + format
+			}
+			rec := httptest.NewRecorder()
+			s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d: %s", target, rec.Code, rec.Body)
+			}
+			body := rec.Body.String()
+			for _, value := range private {
+				if strings.Contains(body, value) {
+					t.Errorf("page shows %q:\n%s", value, body)
+				}
+			}
+			for _, value := range []string{"Mirrored title", "https://www.youtube.com/watch?v=yt-1", "https://www.youtube.com/channel/UC-1"} {
+				if !strings.Contains(body, value) {
+					t.Errorf("page does not show %q:\n%s", value, body)
+				}
+			}
+			if format != "json" {
+				return
+			}
+
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var page struct {
+				Videos []map[string]any `json:"videos"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decode page: %v", err)
+			}
+			if len(page.Videos) != 1 {
+				t.Fatalf("videos = %v, want only the completed one", page.Videos)
+			}
+			for field := range page.Videos[0] {
+				switch field {
+				case "title", "youtube_url", "tiktok_url", "published_at", "mirrored_at":
+				default:
+					t.Errorf("video has the field %q", field)
+				}
+			}
+		})
+	}
+}
+
+func TestPublicPageRateLimitKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		remote  string
+		// The forwarded chain of the first client, of its later requests claiming another
+		// address to the left, and of a second client
+		first, spoofed, second string
+		wantSecond             int
+	}{
+		// Without trusted proxies the header is ignored, so everything behind the proxy shares a budget
+		{name: "untrusted", remote: "10.0.0.2:40000", first: "203.0.113.1", spoofed: "198.51.100.9, 203.0.113.1",
+			second: "203.0.113.2", wantSecond: http.StatusTooManyRequests},
+		{name: "trusted proxy", proxies: []string{"10.0.0.0/8"}, remote: "10.0.0.2:40000", first: "203.0.113.1",
+			spoofed: "198.51.100.9, 203.0.113.1", second: "203.0.113.2", wantSecond: http.StatusOK},
+		{name: "proxy chain", proxies: []string{"10.0.0.2", "192.0.2.10"}, remote: "10.0.0.2:40000",
+			first: "203.0.113.1, 192.0.2.10", spoofed: "198.51.100.9, 203.0.113.1, 192.0.2.10",
+			second: "203.0.113.2, 192.0.2.10", wantSecond: http.StatusOK},
+		{name: "unix socket", proxies: []string{config.TrustedProxyUnix}, remote: "@", first: "203.0.113.1",
+			spoofed: "198.51.100.9, 203.0.113.1", second: "203.0.113.2", wantSecond: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, path := newPublicPageServer(t, &config.Config{TrustedProxies: tt.proxies})
+			fetch := func(forwarded string) int {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				req.RemoteAddr = tt.remote
+				req.Header.Set("X-Forwarded-For", forwarded)
+				rec := httptest.NewRecorder()
+				s.server.Handler.ServeHTTP(rec, req)
+				return rec.Code
+			}
+
+			for i := 0; i < publicPageRequestsPerMinute; i++ {
+				if code := fetch(tt.first); code != http.StatusOK {
+					t.Fatalf("request %d = %d, want %d", i+1, code, http.StatusOK)
+				}
+			}
+			if code := fetch(tt.first); code != http.StatusTooManyRequests {
+				t.Errorf("request over the limit = %d, want %d", code, http.StatusTooManyRequests)
+			}
+			if code := fetch(tt.spoofed); code != http.StatusTooManyRequests {
+				t.Errorf("request with a made-up forwarded address = %d, want %d", code, http.StatusTooManyRequests)
+			}
+			if code := fetch(tt.second); code != tt.wantSecond {
+				t.Errorf("another client's request = %d, want %d", code, tt.wantSecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/repository/memory"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// Credentials the requests below carry; credentialShape finds any of them left in the output
+const (
+	testAccessToken  = "act.1a2b3c4d5e6f7g8h9i0jSECRET"
+	testRefreshToken = "rft.9z8y7x6w5v4u3t2s1rSECRET"
+	testClientSecret = "cs_0123456789abcdefSECRET"
+	testOAuthCode    = "oauthcode_0123456789SECRET"
+)
+
+var credentialShape = regexp.MustCompile(`(act|rft)\.[A-Za-z0-9]{8,}|cs_[A-Za-z0-9]{8,}|oauthcode_[A-Za-z0-9]+|SECRET`)
+
+// echoingTikTok is a TikTok API that rejects every request and quotes the request back in its
+// error, the way a verbose gateway would
+func echoingTikTok(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("rejected " + r.URL.RequestURI() + " " + string(body)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// closingTikTok is a TikTok API that drops every connection, so requests fail with a transport
+// error naming the URL
+func closingTikTok(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestNoTokensInFormattedOutput drives the error and log paths that see credentials with
+// requests carrying them and fails on anything that still looks like one of them
+func TestNoTokensInFormattedOutput(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	ctx := context.Background()
+	video := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(video, []byte("video"), 0644); err != nil {
+		t.Fatalf("write video: %v", err)
+	}
+	app := config.TikTokApp{Name: config.DefaultTikTokApp, APIKey: "ck", APISecret: testClientSecret}
+	outputs := make(map[string]string)
+
+	for name, api := range map[string]*httptest.Server{"rejected": echoingTikTok(t), "transport": closingTikTok(t)} {
+		cfg := &config.Config{TikTokBaseURL: api.URL, TikTokUploadInitPath: "/video/upload/", HTTPClientTimeout: 5 * time.Second}
+		service := tiktok.NewService(cfg, httpclient.NewHTTPClient(cfg))
+
+		_, err := service.UploadVideoAPI(ctx, &tiktok.UploadRequest{AccessToken: testAccessToken, OpenID: "open-1", VideoPath: video})
+		if err == nil {
+			t.Fatalf("%s: UploadVideoAPI() succeeded", name)
+		}
+		outputs[name+" upload init error"] = err.Error()
+
+		_, err = service.ExchangeCodeForToken(app, testOAuthCode, "https://uploader.example/api/tiktok/callback")
+		if err == nil {
+			t.Fatalf("%s: ExchangeCodeForToken() succeeded", name)
+		}
+		outputs[name+" exchange error"] = err.Error()
+	}
+
+	// The bootstrap logs entries it skips and token drift it leaves alone
+	accounts := memory.NewAccountRepository()
+	existing := &domain.Account{ID: "acc-1", YouTubeChannelID: "UC-1", TikTokAccountID: "tt-1",
+		TikTokAccessToken: "act.stored0000000", TikTokRefreshToken: testRefreshToken, IsActive: true}
+	if err := accounts.Save(ctx, existing); err != nil {
+		t.Fatalf("save account: %v", err)
+	}
+	manager := usecase.NewAccountManager(&config.Config{}, accounts)
+	usecase.NewAccountBootstrapper(manager, accounts).Apply(ctx, []config.AccountBootstrap{
+		{YouTubeChannelID: "UC-2", TikTokAccessToken: testAccessToken},
+		{YouTubeChannelID: "UC-1", TikTokAccountID: "tt-1", TikTokAccessToken: testAccessToken},
+	}, config.BootstrapModeSync)
+	outputs["bootstrap log"] = logs.String()
+	logs.Reset()
+
+	// The access log records every request, including the ones that carry credentials
+	cfg := &config.Config{TikTokBaseURL: echoingTikTok(t).URL, TikTokAPIKey: "ck", TikTokAPISecret: testClientSecret, HTTPClientTimeout: 5 * time.Second}
+	s := NewServer(cfg, manager, memory.NewVideoRepository(), tiktok.NewService(cfg, httpclient.NewHTTPClient(cfg)))
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/tiktok/callback?"+url.Values{
+			"code": {testOAuthCode}, "state": {"unknown"}, "scopes": {"video.upload"}}.Encode(), nil),
+		httptest.NewRequest(http.MethodGet, "/api/accounts?access_token="+testAccessToken+"&refresh_token="+testRefreshToken, nil),
+		httptest.NewRequest(http.MethodPost, "/api/tiktok/exchange-code", strings.NewReader(
+			`{"account_id":"acc-1","code":"`+testOAuthCode+`","redirect_uri":"https://uploader.example/api/tiktok/callback"}`)),
+	}
+	for _, req := range requests {
+		req.Header.Set("Authorization", "Bearer "+testAccessToken)
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, req)
+		outputs[req.Method+" "+req.URL.Path+" response"] = rec.Body.String()
+	}
+	outputs["access log"] = logs.String()
+
+	for name, out := range outputs {
+		if leak := credentialShape.FindString(out); leak != "" {
+			t.Errorf("%s leaks %q: %s", name, leak, out)
+		}
+	}
+	for _, path := range []string{"/api/tiktok/callback", "/api/accounts", "/api/tiktok/exchange-code"} {
+		if !strings.Contains(outputs["access log"], path) {
+			t.Errorf("access log has no line for %s:\n%s", path, outputs["access log"])
+		}
+	}
+	if !strings.Contains(outputs["bootstrap log"], "UC-2") {
+		t.Errorf("bootstrap log does not mention the skipped entry:\n%s", outputs["bootstrap log"])
+	}
+}
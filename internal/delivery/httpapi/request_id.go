@@ -0,0 +1,71 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"auto_upload_tiktok/internal/logger"
+)
+
+// requestIDHeader carries the request ID in both directions: a caller's ID is kept so its logs
+// and ours can be matched, otherwise one is generated
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds caller-supplied IDs, which end up in every log line of the request
+const maxRequestIDLength = 128
+
+// requestID returns the caller's X-Request-ID when it is short and printable, or a new UUID
+func requestID(r *http.Request) string {
+	id := r.Header.Get(requestIDHeader)
+	if id == "" || len(id) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return uuid.NewString()
+		}
+	}
+	return id
+}
+
+// withRequestID tags the request with its ID: in the response header, which error responses
+// repeat in their body, and in the context for the handlers' log lines
+func withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := requestID(r)
+	w.Header().Set(requestIDHeader, id)
+	return r.WithContext(logger.WithRequestID(r.Context(), id))
+}
+
+// statusRecorder remembers the status code a handler wrote
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Status returns the written status code; a handler that wrote nothing answered 200
+func (rec *statusRecorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
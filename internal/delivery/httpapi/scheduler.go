@@ -0,0 +1,117 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/delivery/cron"
+)
+
+// schedulePreviewCount is how many upcoming runs the validate endpoint returns
+const schedulePreviewCount = 5
+
+// SetScheduler enables the scheduler status and manual run endpoints
+func (s *Server) SetScheduler(scheduler *cron.Scheduler) {
+	s.scheduler = scheduler
+}
+
+// handleScheduler serves GET /api/scheduler: each cron job with its schedule, last run and next run
+func (s *Server) handleScheduler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if s.scheduler == nil {
+		respondError(w, http.StatusServiceUnavailable, "scheduler is not available")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"timezone": s.cfg.CronTimezone,
+		"jobs":     s.scheduler.Jobs(),
+	})
+}
+
+// handleSchedulerRun serves POST /api/scheduler/run, starting a job now. The job is named by the
+// job query parameter or {"job": "..."} in the body.
+func (s *Server) handleSchedulerRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	if s.scheduler == nil {
+		respondError(w, http.StatusServiceUnavailable, "scheduler is not available")
+		return
+	}
+
+	name := r.URL.Query().Get("job")
+	if name == "" && r.ContentLength != 0 {
+		var payload struct {
+			Job string `json:"job"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		name = payload.Job
+	}
+	if name == "" {
+		respondError(w, http.StatusBadRequest, "job is required ("+cron.JobMonitorAccounts+", "+cron.JobProcessVideos+" or "+cron.JobBackfillPublishedAt+")")
+		return
+	}
+
+	err := s.scheduler.RunJob(name)
+	switch {
+	case errors.Is(err, cron.ErrUnknownJob):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, cron.ErrJobRunning):
+		respondError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		respondError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		respondJSON(w, http.StatusAccepted, map[string]string{"status": "started", "job": name})
+	}
+}
+
+// handleSchedulerValidate serves POST /api/scheduler/validate, previewing a cron expression
+// with the same rules config updates apply
+func (s *Server) handleSchedulerValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var payload struct {
+		Schedule string `json:"schedule"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	loc, err := s.cfg.ScheduleLocation()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	check, err := config.CheckSchedule(payload.Schedule, loc, s.cfg.CronMinInterval, schedulePreviewCount)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	nextRuns := make([]string, 0, len(check.NextRuns))
+	for _, run := range check.NextRuns {
+		nextRuns = append(nextRuns, run.Format(time.RFC3339))
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"schedule":     check.Normalized,
+		"timezone":     check.Location,
+		"next_runs":    nextRuns,
+		"min_interval": check.MinInterval.String(),
+	})
+}
@@ -6,16 +6,23 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/delivery/cron"
 	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
 	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
 	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/redact"
+	"auto_upload_tiktok/internal/repository/memory"
 	"auto_upload_tiktok/internal/usecase"
+	"auto_upload_tiktok/internal/version"
 )
 
 // Server exposes a lightweight REST API for account management and queue visibility.
@@ -24,7 +31,32 @@ type Server struct {
 	accountManager *usecase.AccountManager
 	videoRepo      domain.VideoRepository
 	tiktokService  *tiktok.Service
+	inviteManager  *usecase.InviteManager // Optional: account owner invite links
+	bootstrapper   *usecase.AccountBootstrapper
+	configManager  *config.Manager
+	clipManager    *usecase.ClipManager         // Optional: splitting videos into clips
+	experiments    *usecase.ExperimentManager   // Optional: caption A/B experiments
+	accountMonitor *usecase.AccountMonitor      // Optional: on-demand account checks
+	publicPages    *usecase.PublicPageManager   // Optional: public account status pages
+	transferMeter  *usecase.TransferMeter       // Optional: daily data usage
+	youtubeService *youtube.Service             // Optional: YouTube authorization for description updates
+	scheduler      *cron.Scheduler              // Optional: job status and manual runs
+	fileReconciler *usecase.FileReconciler      // Optional: download directory recovery
+	dbMaintenance  *usecase.DatabaseMaintenance // Optional: manual database maintenance
+	webSessions    *usecase.WebSessionManager   // Optional: web upload cookie checks
+	pipeline       *usecase.PipelineSwitch      // Optional: global pause of the pipeline
+	videoAdmin     *usecase.VideoAdmin          // Optional: forcing video outcomes
+	previewer      *usecase.VideoProcessor      // Optional: upload previews
+	healthChecker  *usecase.HealthChecker       // Optional: dependency checks of the health endpoint
+	contentSafety  *usecase.ContentSafety       // Optional: content-safety decision counts
+	publicLimiter  *rateLimiter
+	oauthStates    *oauthStateStore
+	stopSweep      chan struct{}
+	lockContention atomic.Int64 // API writes that hit a locked database
 	server         *http.Server
+
+	// Optional: TikTok and YouTube request metrics
+	upstreamMetrics *httpclient.UpstreamMetrics
 }
 
 // NewServer creates a new HTTP server.
@@ -35,37 +67,75 @@ func NewServer(cfg *config.Config, accountManager *usecase.AccountManager, video
 		accountManager: accountManager,
 		videoRepo:      videoRepo,
 		tiktokService:  tiktokService,
+		oauthStates:    newOAuthStateStore(memory.NewOAuthStateRepository()),
 	}
 
 	mux.HandleFunc("/api/health", s.handleHealth)
+	mux.HandleFunc("/api/version", s.handleVersion)
+	mux.HandleFunc("/api/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/api/docs", s.handleAPIDocs)
 	mux.HandleFunc("/api/accounts", s.handleAccounts)
 	mux.HandleFunc("/api/accounts/", s.handleAccountActions)
+	mux.HandleFunc("/api/accounts/drift", s.handleAccountDrift)
+	mux.HandleFunc("/api/accounts/import", s.handleAccountImport)
+	mux.HandleFunc("/api/accounts/export", s.handleAccountExport)
 	mux.HandleFunc("/api/tiktok/exchange-code", s.handleExchangeCode)
 	mux.HandleFunc("/api/tiktok/authorize/", s.handleAuthorize)
 	mux.HandleFunc("/api/tiktok/callback", s.handleCallback)
+	mux.HandleFunc("/api/youtube/authorize/", s.handleYouTubeAuthorize)
+	mux.HandleFunc("/api/youtube/callback", s.handleYouTubeCallback)
+	mux.HandleFunc("/api/experiments", s.handleExperiments)
+	mux.HandleFunc("/api/experiments/", s.handleExperimentActions)
+	mux.HandleFunc("/api/pipeline", s.handlePipeline)
+	mux.HandleFunc("/api/pipeline/pause", s.handlePipelinePause)
+	mux.HandleFunc("/api/pipeline/resume", s.handlePipelineResume)
+	mux.HandleFunc("/api/scheduler", s.handleScheduler)
+	mux.HandleFunc("/api/scheduler/run", s.handleSchedulerRun)
+	mux.HandleFunc("/api/scheduler/validate", s.handleSchedulerValidate)
+	mux.HandleFunc("/api/videos", s.handleVideos)
 	mux.HandleFunc("/api/videos/pending", s.handlePendingVideos)
+	mux.HandleFunc("/api/videos/export", s.handleVideoExport)
+	mux.HandleFunc("/api/metrics", s.handleVideoMetrics)
+	mux.HandleFunc("/api/metrics/upstreams", s.handleUpstreamMetrics)
 	mux.HandleFunc("/api/videos/metrics", s.handleVideoMetrics)
+	mux.HandleFunc("/api/videos/stats", s.handleVideoStats)
+	mux.HandleFunc("/api/videos/", s.handleVideoActions)
+	mux.HandleFunc("/api/maintenance/file-report", s.handleFileReport)
+	mux.HandleFunc("/api/maintenance/file-reconcile", s.handleFileReconcile)
+	mux.HandleFunc("/api/maintenance/tiktok-post-urls", s.handleTikTokPostURLBackfill)
+	mux.HandleFunc("/api/admin/maintenance", s.handleDatabaseMaintenance)
+	mux.HandleFunc("/authorize/", s.handleInviteAuthorize)
+	mux.HandleFunc("/public/accounts/", s.handlePublicPage)
+	mux.HandleFunc("/videos", s.handleVideosPage)
+	mux.HandleFunc("/videos/queue", s.handleQueueFragment)
 	mux.HandleFunc("/", s.handleWebUI)
 
 	s.server = &http.Server{
-		Addr:    ":" + cfg.ServerPort,
-		Handler: loggingMiddleware(mux),
+		Addr:    cfg.ServerListenAddr(),
+		Handler: serverHeader(loggingMiddleware(mux)),
 	}
 	return s
 }
 
-// Start begins serving HTTP requests in a separate goroutine.
+// Start listens on server.listen (host:port or a Unix socket) and serves HTTP requests in a
+// separate goroutine.
 func (s *Server) Start() error {
 	if s.cfg.ServerPort == "" {
 		s.cfg.ServerPort = "8080"
 	}
 
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
 	go func() {
-		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Error().Printf("http api server stopped with error: %v", err)
 		}
 	}()
-	logger.Info().Printf("HTTP API server listening on %s", s.server.Addr)
+	s.stopSweep = make(chan struct{})
+	go s.sweepOAuthStates(s.stopSweep)
+	logger.Info().Printf("HTTP API server listening on %s", listener.Addr())
 	return nil
 }
 
@@ -74,15 +144,52 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	if s.server == nil {
 		return nil
 	}
+	if s.stopSweep != nil {
+		close(s.stopSweep)
+		s.stopSweep = nil
+	}
 	return s.server.Shutdown(ctx)
 }
 
+// HealthResponse is the body of GET /api/health
+type HealthResponse struct {
+	Status                  string                         `json:"status"` // ok, degraded or fail
+	Version                 *versionResponse               `json:"version"`
+	Checks                  map[string]healthCheckResponse `json:"checks,omitempty"`
+	YouTubeQuotaPausedUntil string                         `json:"youtube_quota_paused_until,omitempty"`
+	Pipeline                *pipelineResponse              `json:"pipeline,omitempty"`
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		methodNotAllowed(w)
 		return
 	}
-	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	resp := &HealthResponse{Status: "ok", Version: toVersionResponse(version.Get())}
+	status := http.StatusOK
+	if s.healthChecker != nil {
+		// A broken critical dependency answers 503 so load balancers take the instance out;
+		// failing non-critical checks only mark it degraded
+		results, healthy := s.healthChecker.Check(r.Context())
+		resp.Checks = toHealthChecksResponse(results)
+		switch {
+		case !healthy:
+			resp.Status = "fail"
+			status = http.StatusServiceUnavailable
+		case slices.ContainsFunc(results, func(result usecase.HealthCheckResult) bool { return !result.Healthy }):
+			resp.Status = "degraded"
+		}
+	}
+	if s.accountMonitor != nil {
+		// Monitoring is paused, not broken, while the YouTube quota is exhausted
+		if until := s.accountMonitor.QuotaPausedUntil(); !until.IsZero() {
+			resp.YouTubeQuotaPausedUntil = until.Format(time.RFC3339)
+		}
+	}
+	if s.pipeline != nil {
+		resp.Pipeline = toPipelineResponse(s.pipeline.State())
+	}
+	respondJSON(w, status, resp)
 }
 
 func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
@@ -118,28 +225,108 @@ func (s *Server) handleAccountActions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if parts[1] == "invite" || parts[1] == "invites" {
+		s.handleAccountInvites(w, r, id, parts[1:])
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "videos" {
+		switch r.Method {
+		case http.MethodGet:
+			s.listAccountVideos(w, r, id)
+		case http.MethodPost:
+			s.enqueueVideo(w, r, id)
+		default:
+			methodNotAllowed(w)
+		}
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "public-page" {
+		s.handleAccountPublicPage(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "token-status" {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		s.getTokenStatus(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "usage" {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		s.getAccountUsage(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "events" {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		s.getAccountEvents(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "cookies" {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w)
+			return
+		}
+		s.importAccountCookies(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "upload-health" {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		s.getUploadHealth(w, r, id)
+		return
+	}
+
 	if len(parts) == 2 && r.Method == http.MethodPost {
 		switch parts[1] {
 		case "activate":
-			if err := s.accountManager.ActivateAccountMapping(id); err != nil {
-				respondError(w, http.StatusBadRequest, err.Error())
+			if err := s.retryWrite(r.Context(), func(ctx context.Context) error {
+				return s.accountManager.ActivateAccountMapping(ctx, id)
+			}); err != nil {
+				s.respondWriteError(w, http.StatusBadRequest, err)
 				return
 			}
 			respondJSON(w, http.StatusOK, map[string]string{"status": "activated"})
 			return
 		case "deactivate":
-			if err := s.accountManager.DeactivateAccountMapping(id); err != nil {
-				respondError(w, http.StatusBadRequest, err.Error())
+			if err := s.retryWrite(r.Context(), func(ctx context.Context) error {
+				return s.accountManager.DeactivateAccountMapping(ctx, id)
+			}); err != nil {
+				s.respondWriteError(w, http.StatusBadRequest, err)
 				return
 			}
 			respondJSON(w, http.StatusOK, map[string]string{"status": "deactivated"})
 			return
+		case "check-now":
+			s.checkAccountNow(w, r, id)
+			return
 		}
 	}
 
 	http.NotFound(w, r)
 }
 
+// PendingVideosResponse is the body of GET /api/videos/pending
+type PendingVideosResponse struct {
+	PendingVideos []*VideoResponse `json:"pending_videos"`
+	Count         int              `json:"count"`
+}
+
 func (s *Server) handlePendingVideos(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		methodNotAllowed(w)
@@ -156,86 +343,244 @@ func (s *Server) handlePendingVideos(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	videos, err := s.videoRepo.GetPendingVideos(limit)
+	videos, err := s.videoRepo.GetPendingVideos(r.Context(), limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]*VideoResponse, 0, len(videos))
+	for _, video := range videos {
+		resp = append(resp, toVideoResponse(video))
+	}
+
+	respondJSON(w, http.StatusOK, &PendingVideosResponse{PendingVideos: resp, Count: len(resp)})
+}
+
+// AccountVideosResponse is the body of GET /api/accounts/{id}/videos
+type AccountVideosResponse struct {
+	VideoListResponse
+	Counts map[domain.VideoStatus]int `json:"counts"` // The account's videos per status
+}
+
+func (s *Server) listAccountVideos(w http.ResponseWriter, r *http.Request, id string) {
+	account, err := s.accountManager.GetAccountMapping(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		respondError(w, http.StatusNotFound, "account not found")
+		return
+	}
+
+	filter, ok := parseVideoFilter(w, r)
+	if !ok {
+		return
+	}
+
+	videos, err := s.videoRepo.GetByAccountID(r.Context(), id, filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	counts, err := s.videoRepo.CountByStatus(r.Context(), id)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	resp := make([]*videoResponse, 0, len(videos))
+	resp := make([]*VideoResponse, 0, len(videos))
 	for _, video := range videos {
 		resp = append(resp, toVideoResponse(video))
 	}
 
-	respondJSON(w, http.StatusOK, map[string]any{
-		"pending_videos": resp,
-		"count":          len(resp),
+	respondJSON(w, http.StatusOK, &AccountVideosResponse{
+		VideoListResponse: VideoListResponse{Videos: resp, Limit: filter.Limit, Offset: filter.Offset},
+		Counts:            counts,
 	})
 }
 
+// getUploadHealth reports which upload path an account is using, why, and per-path counters
+func (s *Server) getUploadHealth(w http.ResponseWriter, r *http.Request, id string) {
+	account, err := s.accountManager.GetAccountMapping(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		respondError(w, http.StatusNotFound, "account not found")
+		return
+	}
+
+	health := account.UploadHealth
+	primary := account.Settings.PrimaryUploadPath(s.cfg.TikTokEnableWeb)
+	active := primary
+	if health.ActivePath != "" {
+		active = health.ActivePath
+	}
+
+	resp := map[string]any{
+		"primary_path":     primary,
+		"fallback_path":    account.Settings.FallbackPath(s.cfg.TikTokEnableWeb),
+		"active_path":      active,
+		"reason":           health.Reason,
+		"retry_primary_at": health.RetryPrimaryAt,
+		"paths":            health.Paths,
+	}
+	if s.webSessions != nil {
+		session, err := s.webSessions.Session(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if session != nil {
+			resp["web_session"] = toWebSessionResponse(account, session)
+		}
+		claim, err := s.webSessions.Claim(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if claim != nil {
+			resp["web_session_claim"] = s.toWebSessionClaimResponse(claim)
+		}
+		if state, err := s.webSessions.CookiesHealth(); state != "" {
+			cookies := map[string]string{"state": state}
+			if err != nil {
+				cookies["error"] = err.Error()
+			}
+			resp["web_cookies"] = cookies
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// MetricsResponse is the body of GET /api/metrics
+type MetricsResponse struct {
+	Pending          int64                          `json:"pending"`
+	DBLockContention int64                          `json:"db_lock_contention"` // API writes that hit a locked database
+	Transfer         *transferResponse              `json:"transfer,omitempty"`
+	OAuthStates      *oauthStateMetrics             `json:"oauth_states"`
+	Upstreams        []httpclient.OperationCounters `json:"upstreams,omitempty"`
+	ContentSafety    map[string]int64               `json:"content_safety,omitempty"`
+	YouTubeCache     *youtube.PlaylistCacheCounters `json:"youtube_cache,omitempty"`
+}
+
 func (s *Server) handleVideoMetrics(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		methodNotAllowed(w)
 		return
 	}
 
-	count, err := s.videoRepo.CountPending()
+	count, err := s.videoRepo.CountPending(r.Context())
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]int{"pending": count})
+	resp := &MetricsResponse{
+		Pending:          int64(count),
+		DBLockContention: s.lockContention.Load(),
+	}
+	if s.transferMeter != nil {
+		budget, err := s.transferMeter.Usage(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp.Transfer = toTransferResponse(budget)
+	}
+	oauthMetrics, err := s.oauthStates.Metrics(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.OAuthStates = oauthMetrics
+	if s.upstreamMetrics != nil {
+		resp.Upstreams = s.upstreamMetrics.Counters()
+	}
+	if s.contentSafety != nil {
+		resp.ContentSafety = s.contentSafety.Counts()
+	}
+	if s.youtubeService != nil {
+		counters := s.youtubeService.PlaylistCacheCounters()
+		resp.YouTubeCache = &counters
+	}
+	respondJSON(w, http.StatusOK, resp)
 }
 
 func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
-	accounts, err := s.accountManager.GetAllAccountMappings()
+	accounts, err := s.accountManager.GetAllAccountMappings(r.Context())
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	resp := make([]*accountResponse, 0, len(accounts))
+	resp := make([]*AccountResponse, 0, len(accounts))
 	for _, account := range accounts {
-		resp = append(resp, toAccountResponse(account))
+		resp = append(resp, s.toAccountResponse(account))
 	}
 
 	respondJSON(w, http.StatusOK, resp)
 }
 
+// CreateAccountRequest is the body of POST /api/accounts
+type CreateAccountRequest struct {
+	YouTubeChannelID string `json:"youtube_channel_id"` // Channel ID, @handle or youtube.com channel URL
+	TikTokAccountID  string `json:"tiktok_account_id"`  // Optional: filled in on first authorization
+	TikTokToken      string `json:"tiktok_access_token"`
+}
+
 func (s *Server) createAccount(w http.ResponseWriter, r *http.Request) {
-	var payload struct {
-		YouTubeChannelID string `json:"youtube_channel_id"`
-		TikTokAccountID  string `json:"tiktok_account_id"`
-		TikTokToken      string `json:"tiktok_access_token"`
-	}
+	var payload CreateAccountRequest
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		respondError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	account, err := s.accountManager.CreateAccountMapping(payload.YouTubeChannelID, payload.TikTokAccountID, payload.TikTokToken)
+	var account *domain.Account
+	err := s.retryWrite(r.Context(), func(ctx context.Context) error {
+		var err error
+		account, err = s.accountManager.CreateAccountMapping(ctx, payload.YouTubeChannelID, payload.TikTokAccountID, payload.TikTokToken)
+		return err
+	})
 	if err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		s.respondWriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, toAccountResponse(account))
+	respondJSON(w, http.StatusCreated, s.toAccountResponse(account))
+}
+
+// UpdateAccountRequest is the body of PATCH /api/accounts/{id}; fields left out are unchanged
+type UpdateAccountRequest struct {
+	YouTubeChannelID *string                 `json:"youtube_channel_id"`
+	TikTokAccountID  *string                 `json:"tiktok_account_id"`
+	TikTokToken      *string                 `json:"tiktok_access_token"`
+	IsActive         *bool                   `json:"is_active"`
+	CommentTemplate  *string                 `json:"comment_template"`
+	Settings         *domain.AccountSettings `json:"settings"`
+	TikTokApp        *string                 `json:"tiktok_app"`
 }
 
 func (s *Server) updateAccount(w http.ResponseWriter, r *http.Request, id string) {
-	var payload struct {
-		YouTubeChannelID *string `json:"youtube_channel_id"`
-		TikTokAccountID  *string `json:"tiktok_account_id"`
-		TikTokToken      *string `json:"tiktok_access_token"`
-		IsActive         *bool   `json:"is_active"`
-	}
+	var payload UpdateAccountRequest
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		respondError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	account, err := s.accountManager.GetAccountMapping(id)
+	var app config.TikTokApp
+	if payload.TikTokApp != nil {
+		var err error
+		if app, err = s.cfg.TikTokAppByName(*payload.TikTokApp); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	account, err := s.accountManager.GetAccountMapping(r.Context(), id)
 	if err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
@@ -258,23 +603,105 @@ func (s *Server) updateAccount(w http.ResponseWriter, r *http.Request, id string
 		token = *payload.TikTokToken
 	}
 
-	updated, err := s.accountManager.UpdateAccountMapping(id, youtubeID, tiktokID, token, payload.IsActive)
+	var updated *domain.Account
+	err = s.retryWrite(r.Context(), func(ctx context.Context) error {
+		var err error
+		updated, err = s.accountManager.UpdateAccountMapping(ctx, id, youtubeID, tiktokID, token, payload.IsActive)
+		return err
+	})
 	if err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		s.respondWriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, toAccountResponse(updated))
+	if payload.CommentTemplate != nil {
+		err = s.retryWrite(r.Context(), func(ctx context.Context) error {
+			var err error
+			updated, err = s.accountManager.SetCommentTemplate(ctx, id, *payload.CommentTemplate)
+			return err
+		})
+		if err != nil {
+			s.respondWriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if payload.Settings != nil {
+		for _, name := range payload.Settings.Hooks {
+			if _, ok := s.cfg.HookByName(name); !ok {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown hook %q in settings.hooks", name))
+				return
+			}
+		}
+		denylist, err := usecase.ValidateSafetyDenylist(payload.Settings.SafetyDenylist)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		payload.Settings.SafetyDenylist = denylist
+		if payload.Settings.SafetyModeration && s.cfg.SafetyModerationURL == "" {
+			respondError(w, http.StatusBadRequest, "settings.safety_moderation needs safety.moderation_url")
+			return
+		}
+		err = s.retryWrite(r.Context(), func(ctx context.Context) error {
+			var err error
+			updated, err = s.accountManager.UpdateAccountSettings(ctx, id, *payload.Settings)
+			return err
+		})
+		if err != nil {
+			s.respondWriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if payload.TikTokApp != nil {
+		err = s.retryWrite(r.Context(), func(ctx context.Context) error {
+			var err error
+			updated, err = s.accountManager.SetTikTokApp(ctx, id, app)
+			return err
+		})
+		if err != nil {
+			s.respondWriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	respondJSON(w, http.StatusOK, s.toAccountResponse(updated))
 }
 
 func (s *Server) deleteAccount(w http.ResponseWriter, r *http.Request, id string) {
-	if err := s.accountManager.DeleteAccountMapping(id); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+	if err := s.retryWrite(r.Context(), func(ctx context.Context) error {
+		return s.accountManager.DeleteAccountMapping(ctx, id)
+	}); err != nil {
+		s.respondWriteError(w, http.StatusBadRequest, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
+// ExchangeCodeRequest is the body of POST /api/tiktok/exchange-code
+type ExchangeCodeRequest struct {
+	Code         string `json:"code"`
+	RedirectURI  string `json:"redirect_uri"`
+	AccountID    string `json:"account_id"`     // Optional: if provided, update this account
+	TikTokUserID string `json:"tiktok_user_id"` // Optional: if provided, find account by TikTok user ID
+	TikTokApp    string `json:"tiktok_app"`     // Optional: credential set that issued the code (default: the account's)
+}
+
+// ExchangeCodeResponse answers a successful code exchange; token values are never returned
+type ExchangeCodeResponse struct {
+	Status            string           `json:"status"`
+	Account           *AccountResponse `json:"account"`
+	ExpiresIn         int              `json:"expires_in"`
+	TokenType         string           `json:"token_type"`
+	Scope             string           `json:"scope"`
+	HasRefreshToken   bool             `json:"has_refresh_token"`
+	TikTokOpenID      string           `json:"tiktok_open_id"`
+	TikTokDisplayName string           `json:"tiktok_display_name,omitempty"`
+	Discovered        bool             `json:"tiktok_account_id_discovered,omitempty"` // The account's TikTok ID was filled in by this exchange
+	Warning           string           `json:"warning,omitempty"`
+}
+
 // handleExchangeCode exchanges TikTok authorization code for access token and automatically updates account
 func (s *Server) handleExchangeCode(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -282,13 +709,7 @@ func (s *Server) handleExchangeCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var payload struct {
-		Code         string `json:"code"`
-		RedirectURI  string `json:"redirect_uri"`
-		AccountID    string `json:"account_id"`     // Optional: if provided, update this account
-		TikTokUserID string `json:"tiktok_user_id"` // Optional: if provided, find account by TikTok user ID
-	}
-
+	var payload ExchangeCodeRequest
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		respondError(w, http.StatusBadRequest, "invalid request body")
 		return
@@ -304,18 +725,13 @@ func (s *Server) handleExchangeCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Exchange code for token
-	tokenResp, err := s.tiktokService.ExchangeCodeForToken(payload.Code, payload.RedirectURI)
-	if err != nil {
-		logger.Error().Printf("Failed to exchange code for token: %v", err)
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("failed to exchange code: %v", err))
-		return
-	}
-
 	// Find account to update
-	var account *domain.Account
+	var (
+		account *domain.Account
+		err     error
+	)
 	if payload.AccountID != "" {
-		account, err = s.accountManager.GetAccountMapping(payload.AccountID)
+		account, err = s.accountManager.GetAccountMapping(r.Context(), payload.AccountID)
 		if err != nil {
 			respondError(w, http.StatusBadRequest, fmt.Sprintf("failed to get account: %v", err))
 			return
@@ -326,7 +742,7 @@ func (s *Server) handleExchangeCode(w http.ResponseWriter, r *http.Request) {
 		}
 	} else if payload.TikTokUserID != "" {
 		// Find by TikTok user ID (OpenID)
-		accounts, err := s.accountManager.GetAllAccountMappings()
+		accounts, err := s.accountManager.GetAllAccountMappings(r.Context())
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get accounts: %v", err))
 			return
@@ -346,42 +762,80 @@ func (s *Server) handleExchangeCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The code is only valid for the client key that started the authorization
+	app, err := s.authorizationApp(account, payload.TikTokApp)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Exchange code for token
+	tokenResp, err := s.tiktokService.ExchangeCodeForToken(app, payload.Code, payload.RedirectURI)
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to exchange code for token with credential set %s: %v", app.Name, err)
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("failed to exchange code with credential set %q (was the code issued for this app?): %v", app.Name, err))
+		return
+	}
+	pending := usecase.PendingTikTokAccountID(account)
+	owner, err := s.claimExchangedToken(r.Context(), account, tokenResp)
+	if errors.Is(err, usecase.ErrTikTokAccountMismatch) {
+		logger.ErrorContext(r.Context()).Printf("Rejected code exchange: %v", err)
+		respondJSON(w, http.StatusConflict, tokenOwnerMismatch(account, owner, err))
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to record the TikTok login of account %s: %v", account.ID, err)
+		s.respondWriteError(w, http.StatusConflict, err)
+		return
+	}
+
 	// Update account with new tokens
 	expiresIn := tokenResp.Data.ExpiresIn
 	refreshToken := tokenResp.Data.RefreshToken
 	if refreshToken == "" {
-		logger.Info().Printf("WARNING: No refresh token received from TikTok API for account %s. Token will expire and need manual update.", account.ID)
+		logger.InfoContext(r.Context()).Printf("WARNING: No refresh token received from TikTok API for account %s. Token will expire and need manual update.", account.ID)
 	}
 
-	updated, err := s.accountManager.UpdateAccountTokens(
-		account.ID,
-		tokenResp.Data.AccessToken,
-		refreshToken,
-		&expiresIn,
-	)
+	// The code is single-use, so ride out a locked database instead of losing the tokens
+	var updated *domain.Account
+	err = s.retryWrite(r.Context(), func(ctx context.Context) error {
+		var err error
+		updated, err = s.accountManager.UpdateAccountTokens(
+			usecase.WithAccountEventSource(ctx, domain.AccountEventSourceExchange),
+			account.ID,
+			app,
+			tokenResp.Data.AccessToken,
+			refreshToken,
+			&expiresIn,
+		)
+		return err
+	})
 	if err != nil {
-		logger.Error().Printf("Failed to update account tokens: %v", err)
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update tokens: %v", err))
+		logger.ErrorContext(r.Context()).Printf("Failed to update account tokens: %v", err)
+		s.respondWriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to update tokens: %w", err))
 		return
 	}
 
-	logger.Info().Printf("Successfully updated tokens for account %s via code exchange", account.ID)
+	logger.InfoContext(r.Context()).Printf("Successfully updated tokens for account %s via code exchange (credential set %s)", account.ID, app.Name)
 	if refreshToken != "" {
-		logger.Info().Printf("Refresh token saved for account %s - token will auto-refresh when expired", account.ID)
+		logger.InfoContext(r.Context()).Printf("Refresh token saved for account %s - token will auto-refresh when expired", account.ID)
 	} else {
-		logger.Info().Printf("WARNING: No refresh token for account %s - token will need manual update when expired", account.ID)
+		logger.InfoContext(r.Context()).Printf("WARNING: No refresh token for account %s - token will need manual update when expired", account.ID)
 	}
 
-	response := map[string]interface{}{
-		"status":            "success",
-		"account":           toAccountResponse(updated),
-		"expires_in":        tokenResp.Data.ExpiresIn,
-		"token_type":        tokenResp.Data.TokenType,
-		"scope":             tokenResp.Data.Scope,
-		"has_refresh_token": refreshToken != "",
+	response := &ExchangeCodeResponse{
+		Status:            "success",
+		Account:           s.toAccountResponse(updated),
+		ExpiresIn:         tokenResp.Data.ExpiresIn,
+		TokenType:         tokenResp.Data.TokenType,
+		Scope:             tokenResp.Data.Scope,
+		HasRefreshToken:   refreshToken != "",
+		TikTokOpenID:      owner.OpenID,
+		TikTokDisplayName: owner.DisplayName,
+		Discovered:        pending && owner.OpenID != "",
 	}
 	if refreshToken == "" {
-		response["warning"] = "No refresh token received. Token will need manual update when expired."
+		response.Warning = "No refresh token received. Token will need manual update when expired."
 	}
 	respondJSON(w, http.StatusOK, response)
 }
@@ -401,7 +855,7 @@ func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
 	}
 
 	accountID := path
-	account, err := s.accountManager.GetAccountMapping(accountID)
+	account, err := s.accountManager.GetAccountMapping(r.Context(), accountID)
 	if err != nil {
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("failed to get account: %v", err))
 		return
@@ -411,27 +865,28 @@ func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build authorization URL
-	// Use redirect URI from config, or default to localhost callback
-	redirectURI := s.cfg.TikTokRedirectURI
-	if redirectURI == "" {
-		redirectURI = fmt.Sprintf("http://localhost:%s/api/tiktok/callback", s.cfg.ServerPort)
+	// ?app= authorizes under another credential set, e.g. when moving the account to a new app
+	app, err := s.authorizationApp(account, r.URL.Query().Get("app"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	// Append account_id to redirect URI if it's localhost callback
-	if strings.Contains(redirectURI, "localhost") && strings.Contains(redirectURI, "/api/tiktok/callback") {
-		redirectURI = fmt.Sprintf("%s?account_id=%s", redirectURI, accountID)
-	} else {
-		// For external redirect URIs, we need to handle callback differently
-		// Store account_id in state parameter
-		redirectURI = fmt.Sprintf("%s?account_id=%s", redirectURI, accountID)
+
+	// The state is an opaque single-use token; the callback maps it back to the account and app.
+	// The redirect URI is sent without query parameters so it matches the one registered with TikTok.
+	state, err := s.oauthStates.Issue(r.Context(), accountID, domain.OAuthProviderTikTok, app)
+	if errors.Is(err, errOAuthStateLimit) {
+		respondError(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
+	s.setOAuthStateCookie(w, domain.OAuthProviderTikTok, state)
 
-	authURL := fmt.Sprintf(
-		"https://www.tiktok.com/v2/auth/authorize/?client_key=%s&scope=user.info.basic,video.upload&response_type=code&redirect_uri=%s&state=%s",
-		s.cfg.TikTokAPIKey,
-		url.QueryEscape(redirectURI),
-		accountID,
-	)
+	// Scopes the old token turned out to lack are asked for explicitly
+	authURL := tiktok.AuthorizeURL(app, s.exchangeRedirectURI(), state, account.MissingScopes...)
 
 	// Redirect to TikTok authorization page
 	http.Redirect(w, r, authURL, http.StatusFound)
@@ -444,21 +899,29 @@ func (s *Server) handleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get code and account_id from query parameters
 	code := r.URL.Query().Get("code")
-	accountID := r.URL.Query().Get("account_id")
 	state := r.URL.Query().Get("state")
 	errorParam := r.URL.Query().Get("error")
 
-	// If account_id not in query, try state parameter (for external redirects)
-	if accountID == "" && state != "" {
-		accountID = state
+	// Invite links carry the invite token in state and bind the exchange to its account
+	if token, ok := strings.CutPrefix(state, inviteStatePrefix); ok {
+		s.handleInviteCallback(w, r, token)
+		return
+	}
+
+	// The account comes only from a state this server issued to this browser
+	issued, err := s.verifyOAuthState(w, r, domain.OAuthProviderTikTok, state)
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Rejected TikTok OAuth callback: %v", err)
+		s.renderCallbackPage(w, r, false, "", "callback.rejected", err)
+		return
 	}
+	accountID := issued.accountID
 
 	if errorParam != "" {
 		errorDesc := r.URL.Query().Get("error_description")
-		logger.Error().Printf("TikTok authorization error: %s - %s", errorParam, errorDesc)
-		s.renderCallbackPage(w, false, fmt.Sprintf("Authorization failed: %s", errorDesc), accountID)
+		logger.ErrorContext(r.Context()).Printf("TikTok authorization error: %s - %s", errorParam, errorDesc)
+		s.renderCallbackPage(w, r, false, accountID, "callback.authorization_failed", errorDesc)
 		return
 	}
 
@@ -467,38 +930,43 @@ func (s *Server) handleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if accountID == "" {
-		respondError(w, http.StatusBadRequest, "account_id is missing")
-		return
-	}
-
 	// Get account
-	account, err := s.accountManager.GetAccountMapping(accountID)
+	account, err := s.accountManager.GetAccountMapping(r.Context(), accountID)
 	if err != nil {
-		logger.Error().Printf("Failed to get account: %v", err)
-		s.renderCallbackPage(w, false, fmt.Sprintf("Failed to get account: %v", err), accountID)
+		logger.ErrorContext(r.Context()).Printf("Failed to get account: %v", err)
+		s.renderCallbackPage(w, r, false, accountID, "callback.get_account_failed", err)
 		return
 	}
 	if account == nil {
-		s.renderCallbackPage(w, false, "Account not found", accountID)
+		s.renderCallbackPage(w, r, false, accountID, "callback.account_not_found")
 		return
 	}
 
-	// Build redirect URI (must match the one used in authorization)
-	// For exchange, we need the base redirect URI without query parameters
-	baseRedirectURI := s.cfg.TikTokRedirectURI
-	if baseRedirectURI == "" {
-		baseRedirectURI = fmt.Sprintf("http://localhost:%s/api/tiktok/callback", s.cfg.ServerPort)
+	// The code belongs to the client key that started the flow; refuse if the set was since changed
+	app, err := s.issuedApp(issued)
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Rejected TikTok OAuth callback for account %s: %v", accountID, err)
+		s.renderCallbackPage(w, r, false, accountID, "callback.rejected", err)
+		return
 	}
-	// Remove query parameters for exchange (TikTok expects exact match)
-	redirectURIForExchange := strings.Split(baseRedirectURI, "?")[0]
 
-	// Exchange code for token
-	logger.Info().Printf("Exchanging code for token for account %s", accountID)
-	tokenResp, err := s.tiktokService.ExchangeCodeForToken(code, redirectURIForExchange)
+	// Exchange code for token (the redirect URI must match the one used in authorization)
+	logger.InfoContext(r.Context()).Printf("Exchanging code for token for account %s with credential set %s", accountID, app.Name)
+	tokenResp, err := s.tiktokService.ExchangeCodeForToken(app, code, s.exchangeRedirectURI())
 	if err != nil {
-		logger.Error().Printf("Failed to exchange code for token: %v", err)
-		s.renderCallbackPage(w, false, fmt.Sprintf("Failed to exchange code: %v", err), accountID)
+		logger.ErrorContext(r.Context()).Printf("Failed to exchange code for token: %v", err)
+		s.renderCallbackPage(w, r, false, accountID, "callback.exchange_failed", err)
+		return
+	}
+	owner, err := s.claimExchangedToken(r.Context(), account, tokenResp)
+	if errors.Is(err, usecase.ErrTikTokAccountMismatch) {
+		logger.ErrorContext(r.Context()).Printf("Rejected TikTok OAuth callback: %v", err)
+		s.renderCallbackPage(w, r, false, accountID, "callback.wrong_login", loginLabel(s.localizerFor(r), owner))
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to record the TikTok login of account %s: %v", accountID, err)
+		s.renderCallbackPage(w, r, false, accountID, "callback.save_failed")
 		return
 	}
 
@@ -506,250 +974,42 @@ func (s *Server) handleCallback(w http.ResponseWriter, r *http.Request) {
 	expiresIn := tokenResp.Data.ExpiresIn
 	refreshToken := tokenResp.Data.RefreshToken
 	_, err = s.accountManager.UpdateAccountTokens(
+		usecase.WithAccountEventSource(r.Context(), domain.AccountEventSourceExchange),
 		accountID,
+		app,
 		tokenResp.Data.AccessToken,
 		refreshToken,
 		&expiresIn,
 	)
 	if err != nil {
-		logger.Error().Printf("Failed to update account tokens: %v", err)
-		s.renderCallbackPage(w, false, fmt.Sprintf("Failed to update tokens: %v", err), accountID)
+		logger.ErrorContext(r.Context()).Printf("Failed to update account tokens: %v", err)
+		s.renderCallbackPage(w, r, false, accountID, "callback.update_tokens_failed", err)
 		return
 	}
 
-	logger.Info().Printf("Successfully updated tokens for account %s via OAuth callback", accountID)
+	logger.InfoContext(r.Context()).Printf("Successfully updated tokens for account %s via OAuth callback", accountID)
 	if refreshToken != "" {
-		logger.Info().Printf("Refresh token saved for account %s - token will auto-refresh when expired", accountID)
+		logger.InfoContext(r.Context()).Printf("Refresh token saved for account %s - token will auto-refresh when expired", accountID)
 	} else {
-		logger.Info().Printf("WARNING: No refresh token for account %s - token will need manual update when expired", accountID)
+		logger.InfoContext(r.Context()).Printf("WARNING: No refresh token for account %s - token will need manual update when expired", accountID)
 	}
 
-	s.renderCallbackPage(w, true, "Token updated successfully!", accountID)
-}
-
-// renderCallbackPage renders a simple HTML page to show the result
-func (s *Server) renderCallbackPage(w http.ResponseWriter, success bool, message string, accountID string) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-
-	statusColor := "red"
-	statusIcon := "❌"
-	if success {
-		statusColor = "green"
-		statusIcon = "✅"
-	}
-
-	html := fmt.Sprintf(`<!DOCTYPE html>
-<html>
-<head>
-	<meta charset="UTF-8">
-	<title>TikTok Token Update</title>
-	<style>
-		body {
-			font-family: Arial, sans-serif;
-			max-width: 600px;
-			margin: 50px auto;
-			padding: 20px;
-			background: #f5f5f5;
-		}
-		.container {
-			background: white;
-			padding: 30px;
-			border-radius: 8px;
-			box-shadow: 0 2px 4px rgba(0,0,0,0.1);
-		}
-		.status {
-			font-size: 24px;
-			margin-bottom: 20px;
-		}
-		.message {
-			font-size: 16px;
-			margin-bottom: 20px;
-			color: %s;
-		}
-		.info {
-			background: #f0f0f0;
-			padding: 15px;
-			border-radius: 4px;
-			margin-top: 20px;
-			font-size: 14px;
-		}
-		.close-btn {
-			background: #007bff;
-			color: white;
-			border: none;
-			padding: 10px 20px;
-			border-radius: 4px;
-			cursor: pointer;
-			font-size: 14px;
-			margin-top: 20px;
-		}
-		.close-btn:hover {
-			background: #0056b3;
-		}
-	</style>
-</head>
-<body>
-	<div class="container">
-		<div class="status">%s</div>
-		<div class="message">%s</div>
-		<div class="info">
-			<strong>Account ID:</strong> %s<br>
-			<strong>Status:</strong> %s
-		</div>
-		<button class="close-btn" onclick="window.close()">Close Window</button>
-	</div>
-</body>
-</html>`, statusColor, statusIcon, message, accountID, map[bool]string{true: "Token updated successfully", false: "Update failed"}[success])
-
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(html))
-}
-
-// handleWebUI renders a simple web interface for token management
-func (s *Server) handleWebUI(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
-		return
-	}
-
-	// Get all accounts
-	accounts, err := s.accountManager.GetAllAccountMappings()
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+	if owner.DisplayName != "" {
+		s.renderCallbackPage(w, r, true, accountID, "callback.token_updated_login", owner.DisplayName)
 		return
 	}
+	s.renderCallbackPage(w, r, true, accountID, "callback.token_updated")
+}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	html := `<!DOCTYPE html>
-<html>
-<head>
-	<meta charset="UTF-8">
-	<title>TikTok Token Manager</title>
-	<style>
-		body {
-			font-family: Arial, sans-serif;
-			max-width: 1000px;
-			margin: 20px auto;
-			padding: 20px;
-			background: #f5f5f5;
-		}
-		.container {
-			background: white;
-			padding: 30px;
-			border-radius: 8px;
-			box-shadow: 0 2px 4px rgba(0,0,0,0.1);
-		}
-		h1 {
-			color: #333;
-		}
-		table {
-			width: 100%%;
-			border-collapse: collapse;
-			margin-top: 20px;
-		}
-		th, td {
-			padding: 12px;
-			text-align: left;
-			border-bottom: 1px solid #ddd;
-		}
-		th {
-			background: #f8f9fa;
-			font-weight: bold;
-		}
-		.btn {
-			background: #007bff;
-			color: white;
-			border: none;
-			padding: 8px 16px;
-			border-radius: 4px;
-			cursor: pointer;
-			text-decoration: none;
-			display: inline-block;
-			font-size: 14px;
-		}
-		.btn:hover {
-			background: #0056b3;
-		}
-		.btn-success {
-			background: #28a745;
-		}
-		.btn-success:hover {
-			background: #218838;
-		}
-		.status-badge {
-			padding: 4px 8px;
-			border-radius: 4px;
-			font-size: 12px;
-			font-weight: bold;
-		}
-		.status-active {
-			background: #d4edda;
-			color: #155724;
-		}
-		.status-inactive {
-			background: #f8d7da;
-			color: #721c24;
-		}
-	</style>
-</head>
-<body>
-	<div class="container">
-		<h1>🔐 TikTok Token Manager</h1>
-		<p>Click "Authorize" to update token for an account. The system will automatically handle the rest.</p>
-		<table>
-			<thead>
-				<tr>
-					<th>Account ID</th>
-					<th>YouTube Channel</th>
-					<th>TikTok Account</th>
-					<th>Status</th>
-					<th>Action</th>
-				</tr>
-			</thead>
-			<tbody>`
-
-	for _, account := range accounts {
-		statusClass := "status-active"
-		statusText := "Active"
-		if !account.IsActive {
-			statusClass = "status-inactive"
-			statusText = "Inactive"
-		}
-
-		html += fmt.Sprintf(`
-				<tr>
-					<td><code>%s</code></td>
-					<td>%s</td>
-					<td>%s</td>
-					<td><span class="status-badge %s">%s</span></td>
-					<td><a href="/api/tiktok/authorize/%s" class="btn btn-success">🔑 Authorize & Update Token</a></td>
-				</tr>`,
-			account.ID,
-			account.YouTubeChannelID,
-			account.TikTokAccountID,
-			statusClass,
-			statusText,
-			account.ID,
-		)
-	}
-
-	html += `
-			</tbody>
-		</table>
-		<p style="margin-top: 30px; color: #666; font-size: 14px;">
-			<strong>How it works:</strong><br>
-			1. Click "Authorize & Update Token" for an account<br>
-			2. You will be redirected to TikTok to authorize<br>
-			3. After authorization, you'll be redirected back<br>
-			4. Token will be automatically updated with refresh token<br>
-			5. System will auto-refresh token when it expires
-		</p>
-	</div>
-</body>
-</html>`
-
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(html))
+// renderCallbackPage renders the result page of an OAuth flow in the browser's language; key
+// and args select the message from the catalog
+func (s *Server) renderCallbackPage(w http.ResponseWriter, r *http.Request, success bool, accountID string, key string, args ...any) {
+	loc := s.localizerFor(r)
+	renderPage(w, loc, "callback.html", map[string]any{
+		"Success":   success,
+		"Message":   loc.T(key, args...),
+		"AccountID": accountID,
+	})
 }
 
 func respondJSON(w http.ResponseWriter, status int, payload any) {
@@ -761,40 +1021,104 @@ func respondJSON(w http.ResponseWriter, status int, payload any) {
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
+// ErrorResponse is the body of every JSON error answer
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
 func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]string{"error": message})
+	respondJSON(w, status, &ErrorResponse{Error: redact.String(message), RequestID: w.Header().Get(requestIDHeader)})
 }
 
 func methodNotAllowed(w http.ResponseWriter) {
 	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 }
 
+// loggingMiddleware assigns each request its ID and logs it with the response status and duration
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		next.ServeHTTP(w, r)
-		logger.Info().Printf("%s %s %s", r.Method, r.URL.Path, time.Since(start))
+		r = withRequestID(w, r)
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		logger.InfoContext(r.Context()).Printf("%s %s %d %s", r.Method, redactedRequestURI(r), rec.Status(), time.Since(start))
 	})
 }
 
-type accountResponse struct {
-	ID               string     `json:"id"`
-	YouTubeChannelID string     `json:"youtube_channel_id"`
-	TikTokAccountID  string     `json:"tiktok_account_id"`
-	LastCheckedAt    *time.Time `json:"last_checked_at,omitempty"`
-	LastVideoID      string     `json:"last_video_id,omitempty"`
-	IsActive         bool       `json:"is_active"`
-	CreatedAt        time.Time  `json:"created_at"`
-	UpdatedAt        time.Time  `json:"updated_at"`
+// redactedRequestURI returns the request path and query with credential parameters masked
+func redactedRequestURI(r *http.Request) string {
+	if r.URL.RawQuery == "" {
+		return r.URL.Path
+	}
+	return r.URL.Path + "?" + redact.Query(r.URL.Query())
 }
 
-func toAccountResponse(account *domain.Account) *accountResponse {
-	resp := &accountResponse{
+// AccountResponse is an account mapping as the API returns it; token values are never included
+type AccountResponse struct {
+	ID               string                 `json:"id"`
+	YouTubeChannelID string                 `json:"youtube_channel_id"`
+	YouTubeHandle    string                 `json:"youtube_handle,omitempty"`
+	TikTokAccountID  string                 `json:"tiktok_account_id"`
+	TikTokName       string                 `json:"tiktok_display_name,omitempty"`
+	TikTokAvatarURL  string                 `json:"tiktok_avatar_url,omitempty"`
+	LastCheckedAt    *time.Time             `json:"last_checked_at,omitempty"`
+	LastVideoID      string                 `json:"last_video_id,omitempty"`
+	IsActive         bool                   `json:"is_active"`
+	State            string                 `json:"state"` // active, inactive or source_unavailable
+	SourceState      string                 `json:"source_unavailable,omitempty"`
+	SourceReason     string                 `json:"source_unavailable_reason,omitempty"`
+	SourceSince      *time.Time             `json:"source_unavailable_since,omitempty"`
+	SourceCheckedAt  *time.Time             `json:"source_checked_at,omitempty"`
+	CommentTemplate  string                 `json:"comment_template,omitempty"`
+	Settings         domain.AccountSettings `json:"settings"`
+	HasAccessToken   bool                   `json:"has_access_token"`
+	HasRefreshToken  bool                   `json:"has_refresh_token"`
+	TokenExpiresAt   *time.Time             `json:"token_expires_at,omitempty"`
+	TikTokApp        string                 `json:"tiktok_app"`
+	AppMismatch      string                 `json:"tiktok_app_mismatch,omitempty"`
+	NeedsReauth      bool                   `json:"needs_reauthorization"`
+	MissingScopes    []string               `json:"missing_scopes,omitempty"`
+	Failures         int                    `json:"consecutive_failures"`
+	LastError        string                 `json:"last_error,omitempty"`
+	LastErrorSource  string                 `json:"last_error_source,omitempty"`
+	LastFailureAt    *time.Time             `json:"last_failure_at,omitempty"`
+	DisabledReason   string                 `json:"disabled_reason,omitempty"`
+	HasYouTubeAuth   bool                   `json:"has_youtube_authorization"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
+}
+
+func (s *Server) toAccountResponse(account *domain.Account) *AccountResponse {
+	resp := &AccountResponse{
 		ID:               account.ID,
 		YouTubeChannelID: account.YouTubeChannelID,
+		YouTubeHandle:    account.YouTubeHandle,
 		TikTokAccountID:  account.TikTokAccountID,
+		TikTokName:       account.TikTokDisplayName,
+		TikTokAvatarURL:  account.TikTokAvatarURL,
 		LastVideoID:      account.LastVideoID,
 		IsActive:         account.IsActive,
+		State:            account.State(),
+		SourceState:      account.SourceStatus.Unavailable,
+		SourceReason:     account.SourceStatus.Reason,
+		SourceSince:      account.SourceStatus.Since,
+		SourceCheckedAt:  account.SourceStatus.CheckedAt,
+		CommentTemplate:  account.CommentTemplate,
+		Settings:         account.Settings,
+		HasAccessToken:   usecase.HasAccessToken(account),
+		HasRefreshToken:  account.TikTokRefreshToken != "",
+		TokenExpiresAt:   account.TikTokTokenExpiresAt,
+		TikTokApp:        usecase.TikTokAppName(account),
+		AppMismatch:      usecase.TikTokAppMismatch(s.cfg, account),
+		NeedsReauth:      account.NeedsReauthorization,
+		MissingScopes:    account.MissingScopes,
+		Failures:         account.FailureStreak.ConsecutiveFailures,
+		LastError:        account.FailureStreak.LastError,
+		LastErrorSource:  account.FailureStreak.LastErrorSource,
+		LastFailureAt:    account.FailureStreak.LastFailureAt,
+		DisabledReason:   account.FailureStreak.DisabledReason,
+		HasYouTubeAuth:   account.YouTubeAccessToken != "" || account.YouTubeRefreshToken != "",
 		CreatedAt:        account.CreatedAt,
 		UpdatedAt:        account.UpdatedAt,
 	}
@@ -805,30 +1129,108 @@ func toAccountResponse(account *domain.Account) *accountResponse {
 	return resp
 }
 
-type videoResponse struct {
-	ID             string     `json:"id"`
-	YouTubeVideoID string     `json:"youtube_video_id"`
-	AccountID      string     `json:"account_id"`
-	Status         string     `json:"status"`
-	ErrorMessage   string     `json:"error_message,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
-	PublishedAt    *time.Time `json:"published_at,omitempty"`
+// VideoResponse is a video of the queue or history as the API returns it
+type VideoResponse struct {
+	ID             string              `json:"id"`
+	YouTubeVideoID string              `json:"youtube_video_id"`
+	AccountID      string              `json:"account_id"`
+	Title          string              `json:"title,omitempty"`
+	TitleLang      string              `json:"title_language,omitempty"`
+	CaptionTitle   string              `json:"translated_title,omitempty"`
+	CaptionLang    string              `json:"translated_language,omitempty"`
+	Status         string              `json:"status"`
+	Progress       int                 `json:"progress,omitempty"` // Percent of the download or upload in progress
+	Priority       int                 `json:"priority"`
+	ErrorMessage   string              `json:"error_message,omitempty"`
+	TikTokVideoID  string              `json:"tiktok_video_id,omitempty"`
+	TikTokPostURL  string              `json:"tiktok_post_url,omitempty"`
+	PublishID      string              `json:"publish_id,omitempty"`
+	UploadRoute    string              `json:"upload_route,omitempty"`
+	RouteReason    string              `json:"upload_route_reason,omitempty"`
+	ParentVideoID  string              `json:"parent_video_id,omitempty"`
+	ClipStart      string              `json:"clip_start,omitempty"`
+	ClipEnd        string              `json:"clip_end,omitempty"`
+	ClipCount      int                 `json:"clip_count,omitempty"`
+	CommentPosted  bool                `json:"comment_posted"`
+	CommentError   string              `json:"comment_error,omitempty"`
+	SafetyDecision string              `json:"safety_decision,omitempty"`
+	SafetyRule     string              `json:"safety_rule,omitempty"`
+	PostOptions    *domain.PostOptions `json:"post_options,omitempty"`
+	ViewCount      *int64              `json:"view_count,omitempty"`
+	ViewsCheckedAt *time.Time          `json:"views_checked_at,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	PublishedAt    *time.Time          `json:"published_at,omitempty"`
+	DownloadedAt   *time.Time          `json:"downloaded_at,omitempty"`
+	UploadedAt     *time.Time          `json:"uploaded_at,omitempty"`
+	CompletedAt    *time.Time          `json:"completed_at,omitempty"`
+	DownloadMs     int64               `json:"download_duration_ms,omitempty"`
+	DownloadWidth  int                 `json:"download_width,omitempty"`
+	DownloadHeight int                 `json:"download_height,omitempty"`
+	DownloadFPS    float64             `json:"download_fps,omitempty"`
+	UploadMs       int64               `json:"upload_duration_ms,omitempty"`
+	Cost           costResponse        `json:"cost"`
 }
 
-func toVideoResponse(video *domain.Video) *videoResponse {
-	resp := &videoResponse{
+func toVideoResponse(video *domain.Video) *VideoResponse {
+	resp := &VideoResponse{
 		ID:             video.ID,
 		YouTubeVideoID: video.YouTubeVideoID,
 		AccountID:      video.AccountID,
+		Title:          video.Title,
+		TitleLang:      video.TitleLanguage,
+		CaptionTitle:   video.TranslatedTitle,
+		CaptionLang:    video.TranslatedLanguage,
 		Status:         string(video.Status),
+		Priority:       video.Priority,
 		ErrorMessage:   video.ErrorMessage,
+		TikTokVideoID:  video.TikTokVideoID,
+		TikTokPostURL:  video.TikTokPostURL,
+		PublishID:      video.UploadPublishID,
+		UploadRoute:    string(video.UploadRoute),
+		RouteReason:    video.UploadRouteReason,
+		ParentVideoID:  video.ParentVideoID,
+		ClipCount:      video.ClipCount,
+		CommentPosted:  video.CommentPosted,
+		CommentError:   video.CommentError,
+		SafetyDecision: string(video.SafetyDecision),
+		SafetyRule:     video.SafetyRule,
+		PostOptions:    video.PostOptions,
 		CreatedAt:      video.CreatedAt,
 		UpdatedAt:      video.UpdatedAt,
+		DownloadMs:     video.DownloadDuration.Milliseconds(),
+		DownloadWidth:  video.DownloadWidth,
+		DownloadHeight: video.DownloadHeight,
+		DownloadFPS:    video.DownloadFPS,
+		UploadMs:       video.UploadDuration.Milliseconds(),
+		Cost:           toCostResponse(video.Cost),
+	}
+	if video.ParentVideoID != "" && video.ClipEnd > 0 {
+		resp.ClipStart = usecase.FormatClipTimestamp(video.ClipStart)
+		resp.ClipEnd = usecase.FormatClipTimestamp(video.ClipEnd)
+	}
+	if video.Status == domain.VideoStatusDownloading || video.Status == domain.VideoStatusUploading {
+		resp.Progress = video.Progress
 	}
 	if !video.PublishedAt.IsZero() {
 		t := video.PublishedAt
 		resp.PublishedAt = &t
 	}
+	if !video.ViewsCheckedAt.IsZero() {
+		views, t := video.ViewCount, video.ViewsCheckedAt
+		resp.ViewCount, resp.ViewsCheckedAt = &views, &t
+	}
+	if !video.DownloadedAt.IsZero() {
+		t := video.DownloadedAt
+		resp.DownloadedAt = &t
+	}
+	if !video.UploadedAt.IsZero() {
+		t := video.UploadedAt
+		resp.UploadedAt = &t
+	}
+	if !video.CompletedAt.IsZero() {
+		t := video.CompletedAt
+		resp.CompletedAt = &t
+	}
 	return resp
 }
@@ -0,0 +1,30 @@
+package httpapi
+
+import (
+	"errors"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+)
+
+var errTikTokAppChanged = errors.New("TikTok credential set changed during authorization, please start again")
+
+// authorizationApp picks the credential set for a new authorization: the named override, else the
+// set that issued the account's current tokens
+func (s *Server) authorizationApp(account *domain.Account, override string) (config.TikTokApp, error) {
+	name := account.TikTokApp
+	if override != "" {
+		name = override
+	}
+	return s.cfg.TikTokAppByName(name)
+}
+
+// issuedApp returns the credential set an authorization was started with, provided it still uses
+// the same client key (the code is worthless under any other key)
+func (s *Server) issuedApp(issued oauthState) (config.TikTokApp, error) {
+	app, err := s.cfg.TikTokAppByName(issued.app.Name)
+	if err != nil || app.APIKey != issued.app.APIKey {
+		return config.TikTokApp{}, errTikTokAppChanged
+	}
+	return app, nil
+}
@@ -0,0 +1,187 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/redact"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// tokenExpiryWarning is how close to expiry a token is flagged yellow in the web UI
+const tokenExpiryWarning = 24 * time.Hour
+
+// getTokenStatus reports whether an account's TikTok token is present and still accepted by TikTok.
+// Token values are never returned.
+func (s *Server) getTokenStatus(w http.ResponseWriter, r *http.Request, id string) {
+	account, err := s.accountManager.GetAccountMapping(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		respondError(w, http.StatusNotFound, "account not found")
+		return
+	}
+
+	now := time.Now()
+	hasAccess := usecase.HasAccessToken(account)
+	resp := map[string]any{
+		"account_id":        account.ID,
+		"has_access_token":  hasAccess,
+		"has_refresh_token": account.TikTokRefreshToken != "",
+		"token_expires_at":  account.TikTokTokenExpiresAt,
+		"expired":           account.TikTokTokenExpiresAt != nil && !account.TikTokTokenExpiresAt.After(now),
+		"valid":             false,
+		"display_name":      "",
+		"tiktok_app":        usecase.TikTokAppName(account),
+	}
+	if account.NeedsReauthorization {
+		resp["needs_reauthorization"] = true
+	}
+	missingScopes := account.MissingScopes
+	if len(missingScopes) > 0 {
+		resp["missing_scopes"] = missingScopes
+	}
+	if mismatch := usecase.TikTokAppMismatch(s.cfg, account); mismatch != "" {
+		resp["tiktok_app_mismatch"] = mismatch
+	}
+
+	// No point asking TikTok about a missing or placeholder token
+	if !hasAccess {
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	info, valid, err := s.tiktokService.GetUserInfo(account.TikTokAccessToken)
+	var scopeErr *tiktok.ScopeError
+	if errors.As(err, &scopeErr) {
+		// The token works; it was just not granted the scope profile reads need
+		resp["valid"] = true
+		if !slices.Contains(missingScopes, scopeErr.Scope) {
+			resp["missing_scopes"] = append(slices.Clone(missingScopes), scopeErr.Scope)
+		}
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Token check failed for account %s: %v", account.ID, err)
+		respondError(w, http.StatusBadGateway, "failed to reach TikTok: "+err.Error())
+		return
+	}
+	resp["valid"] = valid
+	if info != nil {
+		resp["display_name"] = info.DisplayName
+		if valid && usecase.CheckTokenOwner(account, info.OpenID) != nil {
+			resp["open_id_mismatch"] = info.OpenID
+		}
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// exchangedTokenOwner asks /user/info/ which TikTok login a newly exchanged token belongs to. When
+// TikTok does not answer, only the open_id of the token response is known, which may be empty.
+func (s *Server) exchangedTokenOwner(ctx context.Context, account *domain.Account, tokenResp *tiktok.TokenResponse) *tiktok.UserInfo {
+	info, valid, err := s.tiktokService.GetUserInfo(tokenResp.Data.AccessToken)
+	if err != nil || !valid || info == nil {
+		logger.ErrorContext(ctx).Printf("Could not look up the TikTok profile of the new token for account %s (valid=%t): %v", account.ID, valid, err)
+		info = &tiktok.UserInfo{}
+	}
+	if info.OpenID == "" {
+		info.OpenID = tokenResp.Data.OpenID
+	}
+	return info
+}
+
+// claimExchangedToken refuses tokens issued to another TikTok account than the one the mapping
+// posts to, so a code from the wrong login is never stored, and records the login on the account:
+// a mapping created without a TikTok account ID takes its open_id. When the open_id is unknown the
+// token is accepted and checked again before the first upload. The login is returned either way.
+func (s *Server) claimExchangedToken(ctx context.Context, account *domain.Account, tokenResp *tiktok.TokenResponse) (*tiktok.UserInfo, error) {
+	owner := s.exchangedTokenOwner(ctx, account, tokenResp)
+	if !usecase.PendingTikTokAccountID(account) {
+		if err := usecase.CheckTokenOwner(account, owner.OpenID); err != nil {
+			return owner, err
+		}
+	}
+	if _, err := s.accountManager.RecordTikTokLogin(ctx, account.ID, owner.OpenID, owner.DisplayName, owner.AvatarURL); err != nil {
+		return owner, err
+	}
+	return owner, nil
+}
+
+// tokenOwnerMismatch is the 409 body for a code exchanged with the wrong TikTok login; it names the
+// login so a mistyped tiktok_account_id can be corrected
+func tokenOwnerMismatch(account *domain.Account, owner *tiktok.UserInfo, err error) map[string]any {
+	return map[string]any{
+		"error":               redact.String(err.Error()),
+		"tiktok_open_id":      owner.OpenID,
+		"tiktok_display_name": owner.DisplayName,
+		"warning": fmt.Sprintf("The code was issued to TikTok login %q (open_id %s), but the mapping posts to %s. "+
+			"Sign in to that TikTok account, or if %q is the right one, set the mapping's tiktok_account_id to %s and exchange a new code.",
+			owner.DisplayName, owner.OpenID, account.TikTokAccountID, owner.DisplayName, owner.OpenID),
+	}
+}
+
+// loginLabel names a TikTok login for callback pages
+func loginLabel(loc localizer, owner *tiktok.UserInfo) string {
+	if owner == nil || owner.DisplayName == "" {
+		return loc.T("callback.another_login")
+	}
+	return owner.DisplayName
+}
+
+// tokenBadge is the token health shown in the web UI accounts table
+type tokenBadge struct {
+	Color  string // green, yellow or red
+	Label  string
+	Detail string
+}
+
+// tokenBadgeFor grades an account's token from stored data only (no TikTok call):
+// red when it cannot upload, yellow when it relies on a refresh or expires soon, green otherwise.
+// appMismatch is the usecase.TikTokAppMismatch result for the account.
+func tokenBadgeFor(loc localizer, account *domain.Account, appMismatch string, now time.Time) tokenBadge {
+	hasRefresh := account.TikTokRefreshToken != ""
+	if !usecase.HasAccessToken(account) {
+		return tokenBadge{Color: "red", Label: loc.T("token.missing"), Detail: loc.T("token.missing_detail")}
+	}
+	if appMismatch != "" {
+		return tokenBadge{Color: "red", Label: loc.T("token.app_mismatch"), Detail: appMismatch}
+	}
+	if account.NeedsReauthorization {
+		return tokenBadge{Color: "red", Label: loc.T("token.wrong_account"), Detail: loc.T("token.wrong_account_detail")}
+	}
+	if len(account.MissingScopes) > 0 {
+		scopes := strings.Join(account.MissingScopes, ", ")
+		return tokenBadge{Color: "red", Label: loc.T("token.missing_scope"), Detail: loc.T("token.missing_scope_detail", scopes)}
+	}
+
+	expiresAt := account.TikTokTokenExpiresAt
+	if expiresAt == nil {
+		if hasRefresh {
+			return tokenBadge{Color: "green", Label: loc.T("token.ok"), Detail: loc.T("token.expiry_unknown_refresh")}
+		}
+		return tokenBadge{Color: "yellow", Label: loc.T("token.unknown"), Detail: loc.T("token.expiry_unknown")}
+	}
+
+	expiry := expiresAt.Format(time.RFC3339)
+	switch {
+	case !expiresAt.After(now) && !hasRefresh:
+		return tokenBadge{Color: "red", Label: loc.T("token.expired"), Detail: loc.T("token.expired_no_refresh", expiry)}
+	case !expiresAt.After(now):
+		return tokenBadge{Color: "yellow", Label: loc.T("token.expired"), Detail: loc.T("token.expired_refresh", expiry)}
+	case expiresAt.Sub(now) < tokenExpiryWarning:
+		return tokenBadge{Color: "yellow", Label: loc.T("token.expiring"), Detail: loc.T("token.expires", expiry)}
+	}
+	return tokenBadge{Color: "green", Label: loc.T("token.ok"), Detail: loc.T("token.expires", expiry)}
+}
@@ -0,0 +1,89 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// SetTransferMeter enables the data usage report in /api/metrics and the web UI.
+func (s *Server) SetTransferMeter(meter *usecase.TransferMeter) {
+	s.transferMeter = meter
+}
+
+// transferResponse is today's data usage; remaining values are -1 for disabled caps
+type transferResponse struct {
+	Day                    string    `json:"day"`
+	DownloadBytes          int64     `json:"download_bytes"`
+	UploadBytes            int64     `json:"upload_bytes"`
+	TotalBytes             int64     `json:"total_bytes"`
+	DailyCap               int64     `json:"daily_cap"`
+	DailyDownloadCap       int64     `json:"daily_download_cap"`
+	DailyUploadCap         int64     `json:"daily_upload_cap"`
+	RemainingBytes         int64     `json:"remaining_bytes"`
+	DownloadRemainingBytes int64     `json:"download_remaining_bytes"`
+	UploadRemainingBytes   int64     `json:"upload_remaining_bytes"`
+	ResetsAt               time.Time `json:"resets_at"`
+}
+
+func toTransferResponse(budget *usecase.TransferBudget) *transferResponse {
+	return &transferResponse{
+		Day:                    budget.Day,
+		DownloadBytes:          budget.DownloadBytes,
+		UploadBytes:            budget.UploadBytes,
+		TotalBytes:             budget.TotalBytes(),
+		DailyCap:               budget.DailyCap,
+		DailyDownloadCap:       budget.DownloadCap,
+		DailyUploadCap:         budget.UploadCap,
+		RemainingBytes:         budget.Remaining,
+		DownloadRemainingBytes: budget.DownloadRemaining,
+		UploadRemainingBytes:   budget.UploadRemaining,
+		ResetsAt:               budget.ResetsAt,
+	}
+}
+
+// transferUsageText is the data usage line of the web UI, or "" when metering is off
+func (s *Server) transferUsageText(ctx context.Context) string {
+	if s.transferMeter == nil {
+		return ""
+	}
+	budget, err := s.transferMeter.Usage(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx).Printf("Failed to get transfer usage: %v", err)
+		return ""
+	}
+
+	line := fmt.Sprintf("%s downloaded, %s uploaded",
+		formatBytes(budget.DownloadBytes), formatBytes(budget.UploadBytes))
+	for _, limit := range []struct {
+		name      string
+		cap       int64
+		remaining int64
+	}{
+		{"daily", budget.DailyCap, budget.Remaining},
+		{"download", budget.DownloadCap, budget.DownloadRemaining},
+		{"upload", budget.UploadCap, budget.UploadRemaining},
+	} {
+		if limit.cap > 0 {
+			line += fmt.Sprintf(" · %s left of %s %s cap", formatBytes(limit.remaining), formatBytes(limit.cap), limit.name)
+		}
+	}
+	return line
+}
+
+// formatBytes renders a byte count in binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,97 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// SetUploadPreviewer enables GET /api/videos/{id}/upload-preview
+func (s *Server) SetUploadPreviewer(processor *usecase.VideoProcessor) {
+	s.previewer = processor
+}
+
+// UploadPreviewRequest is the resolved upload request of a preview, without credentials
+type UploadPreviewRequest struct {
+	OpenID         string     `json:"open_id"`
+	VideoPath      string     `json:"video_path,omitempty"`
+	Title          string     `json:"title"`
+	Description    string     `json:"description,omitempty"`
+	PrivacyLevel   string     `json:"privacy_level"`
+	DisableComment bool       `json:"disable_comment"`
+	DisableDuet    bool       `json:"disable_duet"`
+	DisableStitch  bool       `json:"disable_stitch"`
+	PostAsDraft    bool       `json:"post_as_draft"`
+	ScheduleTime   *time.Time `json:"schedule_time,omitempty"`
+}
+
+// UploadPreviewResponse is what uploading a video now would send to TikTok
+type UploadPreviewResponse struct {
+	VideoID    string               `json:"video_id"`
+	AccountID  string               `json:"account_id"`
+	UploadPath string               `json:"upload_path"`
+	PathReason string               `json:"upload_path_reason"`
+	Request    UploadPreviewRequest `json:"request"`
+	PostInfo   map[string]any       `json:"post_info,omitempty"`
+	Caption    string               `json:"caption,omitempty"`
+	Comment    string               `json:"comment,omitempty"`
+	Sources    map[string]string    `json:"sources"`
+	Problems   []string             `json:"problems"`
+}
+
+func toUploadPreviewResponse(preview *usecase.UploadPreview) *UploadPreviewResponse {
+	req := preview.Request
+	resp := &UploadPreviewResponse{
+		VideoID:    preview.VideoID,
+		AccountID:  preview.AccountID,
+		UploadPath: string(preview.Path),
+		PathReason: preview.PathReason,
+		Request: UploadPreviewRequest{
+			OpenID:         req.OpenID,
+			VideoPath:      req.VideoPath,
+			Title:          req.Title,
+			Description:    req.Description,
+			PrivacyLevel:   req.PrivacyLevel,
+			DisableComment: req.DisableComment,
+			DisableDuet:    req.DisableDuet,
+			DisableStitch:  req.DisableStitch,
+			PostAsDraft:    req.PostAsDraft,
+		},
+		PostInfo: preview.PostInfo,
+		Caption:  preview.Caption,
+		Comment:  preview.Comment,
+		Sources:  preview.Sources,
+		Problems: preview.Problems,
+	}
+	if !req.ScheduleTime.IsZero() {
+		scheduleTime := req.ScheduleTime
+		resp.Request.ScheduleTime = &scheduleTime
+	}
+	if resp.Problems == nil {
+		resp.Problems = []string{}
+	}
+	return resp
+}
+
+// uploadPreview serves GET /api/videos/{id}/upload-preview: the caption, post settings and upload
+// path an upload started now would use, with the layer each came from and any problems with them
+func (s *Server) uploadPreview(w http.ResponseWriter, r *http.Request, id string) {
+	if s.previewer == nil {
+		respondError(w, http.StatusServiceUnavailable, "upload previews are not available")
+		return
+	}
+
+	preview, err := s.previewer.PreviewUpload(r.Context(), id)
+	switch {
+	case errors.Is(err, usecase.ErrVideoNotFound):
+		respondError(w, http.StatusNotFound, "video not found")
+	case errors.Is(err, usecase.ErrAccountNotFound):
+		respondError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	default:
+		respondJSON(w, http.StatusOK, toUploadPreviewResponse(preview))
+	}
+}
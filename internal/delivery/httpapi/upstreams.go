@@ -0,0 +1,30 @@
+package httpapi
+
+import (
+	"net/http"
+
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+)
+
+// SetUpstreamMetrics enables the TikTok and YouTube request metrics in /api/metrics and
+// /api/metrics/upstreams.
+func (s *Server) SetUpstreamMetrics(metrics *httpclient.UpstreamMetrics) {
+	s.upstreamMetrics = metrics
+}
+
+// handleUpstreamMetrics summarizes requests to each upstream operation over the last hour
+func (s *Server) handleUpstreamMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if s.upstreamMetrics == nil {
+		respondError(w, http.StatusServiceUnavailable, "upstream metrics are not available")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"window_seconds": int64(httpclient.UpstreamWindow.Seconds()),
+		"operations":     s.upstreamMetrics.Summary(),
+	})
+}
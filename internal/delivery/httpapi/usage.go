@@ -0,0 +1,66 @@
+package httpapi
+
+import (
+	"net/http"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// costResponse is what processing cost, per video or summed over an account's videos
+type costResponse struct {
+	YouTubeAPIUnits   int64 `json:"youtube_api_units"`
+	DownloadBytes     int64 `json:"download_bytes"`
+	UploadBytes       int64 `json:"upload_bytes"`
+	ProcessingSeconds int64 `json:"processing_seconds"`
+	Retries           int   `json:"retries"`
+}
+
+func toCostResponse(cost domain.VideoCost) costResponse {
+	return costResponse{
+		YouTubeAPIUnits:   cost.APIUnits,
+		DownloadBytes:     cost.DownloadBytes,
+		UploadBytes:       cost.UploadBytes,
+		ProcessingSeconds: int64(cost.ProcessingTime.Round(time.Second) / time.Second),
+		Retries:           cost.Retries,
+	}
+}
+
+// getAccountUsage sums the processing cost of an account's videos created in a calendar month
+// (?month=2025-01, local time, default the current month)
+func (s *Server) getAccountUsage(w http.ResponseWriter, r *http.Request, id string) {
+	account, err := s.accountManager.GetAccountMapping(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		respondError(w, http.StatusNotFound, "account not found")
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	if month := r.URL.Query().Get("month"); month != "" {
+		from, err = time.ParseInLocation("2006-01", month, time.Local)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "month must look like 2025-01")
+			return
+		}
+	}
+	to := from.AddDate(0, 1, 0)
+
+	usage, err := s.videoRepo.GetAccountUsage(r.Context(), id, from, to)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"account_id":       account.ID,
+		"month":            from.Format("2006-01"),
+		"videos":           usage.Videos,
+		"completed_videos": usage.Completed,
+		"cost":             toCostResponse(usage.Cost),
+	})
+}
@@ -0,0 +1,41 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"auto_upload_tiktok/internal/version"
+)
+
+type versionResponse struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+func toVersionResponse(info version.Info) *versionResponse {
+	return &versionResponse{
+		Version:   info.Version,
+		Commit:    info.Commit,
+		BuildDate: info.BuildDate,
+		GoVersion: info.GoVersion,
+	}
+}
+
+// handleVersion reports the running build
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	respondJSON(w, http.StatusOK, toVersionResponse(version.Get()))
+}
+
+// serverHeader names the build in the Server header of every response
+func serverHeader(next http.Handler) http.Handler {
+	header := "auto_upload_tiktok/" + version.Get().Version
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", header)
+		next.ServeHTTP(w, r)
+	})
+}
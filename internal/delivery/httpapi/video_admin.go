@@ -0,0 +1,162 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// SetVideoAdmin enables the force-complete, force-fail, approve and audit endpoints of videos
+func (s *Server) SetVideoAdmin(admin *usecase.VideoAdmin) {
+	s.videoAdmin = admin
+}
+
+// SetContentSafety enables the content-safety decision counts in /api/metrics
+func (s *Server) SetContentSafety(safety *usecase.ContentSafety) {
+	s.contentSafety = safety
+}
+
+// requireAdmin checks the request's bearer token against server.admin_token, answering 403
+// when administrative endpoints are disabled and 401 for a missing or wrong token
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.cfg.AdminToken == "" {
+		respondError(w, http.StatusForbidden, "administrative endpoints are disabled; set server.admin_token")
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AdminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		respondError(w, http.StatusUnauthorized, "invalid admin token")
+		return false
+	}
+	return true
+}
+
+// auditEntryResponse is an audit log entry
+type auditEntryResponse struct {
+	ID             string    `json:"id"`
+	Action         string    `json:"action"`
+	VideoID        string    `json:"video_id"`
+	AccountID      string    `json:"account_id,omitempty"`
+	Actor          string    `json:"actor"`
+	PreviousStatus string    `json:"previous_status"`
+	Detail         string    `json:"detail,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func toAuditEntryResponse(entry *domain.AuditEntry) *auditEntryResponse {
+	return &auditEntryResponse{
+		ID:             entry.ID,
+		Action:         entry.Action,
+		VideoID:        entry.VideoID,
+		AccountID:      entry.AccountID,
+		Actor:          entry.Actor,
+		PreviousStatus: string(entry.PreviousStatus),
+		Detail:         entry.Detail,
+		CreatedAt:      entry.CreatedAt,
+	}
+}
+
+// forceVideo serves POST /api/videos/{id}/force-complete ({"tiktok_video_id": "...", "url": "..."},
+// both optional) and POST /api/videos/{id}/force-fail ({"reason": "..."})
+func (s *Server) forceVideo(w http.ResponseWriter, r *http.Request, id, action string) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.videoAdmin == nil {
+		respondError(w, http.StatusServiceUnavailable, "video administration is not available")
+		return
+	}
+
+	var payload struct {
+		TikTokVideoID string `json:"tiktok_video_id"`
+		URL           string `json:"url"`
+		Reason        string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	actor := "api " + s.clientIP(r)
+	var (
+		video *domain.Video
+		err   error
+	)
+	if action == domain.AuditActionForceComplete {
+		video, err = s.videoAdmin.ForceComplete(r.Context(), id, strings.TrimSpace(payload.TikTokVideoID), strings.TrimSpace(payload.URL), actor)
+	} else {
+		video, err = s.videoAdmin.ForceFail(r.Context(), id, payload.Reason, actor)
+	}
+
+	switch {
+	case errors.Is(err, usecase.ErrVideoNotFound):
+		respondError(w, http.StatusNotFound, "video not found")
+	case errors.Is(err, usecase.ErrInvalidForce):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrForceNotAllowed):
+		respondError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		s.respondWriteError(w, http.StatusInternalServerError, err)
+	case video == nil:
+		respondError(w, http.StatusNotFound, "video not found")
+	default:
+		respondJSON(w, http.StatusOK, toVideoResponse(video))
+	}
+}
+
+// approveVideo serves POST /api/videos/{id}/approve: publishes a video the content-safety check
+// held for review or denied
+func (s *Server) approveVideo(w http.ResponseWriter, r *http.Request, id string) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.videoAdmin == nil {
+		respondError(w, http.StatusServiceUnavailable, "video administration is not available")
+		return
+	}
+
+	video, err := s.videoAdmin.Approve(r.Context(), id, "api "+s.clientIP(r))
+	switch {
+	case errors.Is(err, usecase.ErrVideoNotFound):
+		respondError(w, http.StatusNotFound, "video not found")
+	case errors.Is(err, usecase.ErrNotAwaitingApproval):
+		respondError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		s.respondWriteError(w, http.StatusInternalServerError, err)
+	case video == nil:
+		respondError(w, http.StatusNotFound, "video not found")
+	default:
+		respondJSON(w, http.StatusOK, toVideoResponse(video))
+	}
+}
+
+// videoAudit serves GET /api/videos/{id}/audit: the video's administrative changes, newest first
+func (s *Server) videoAudit(w http.ResponseWriter, r *http.Request, id string) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.videoAdmin == nil {
+		respondError(w, http.StatusServiceUnavailable, "video administration is not available")
+		return
+	}
+
+	entries, err := s.videoAdmin.AuditTrail(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]*auditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		resp = append(resp, toAuditEntryResponse(entry))
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"entries": resp})
+}
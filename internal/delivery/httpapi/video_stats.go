@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// defaultStatsWindow is the look-back of GET /api/videos/stats without ?window=
+const defaultStatsWindow = 7 * 24 * time.Hour
+
+type durationStatsResponse struct {
+	Count int   `json:"count"`
+	AvgMs int64 `json:"avg_ms"`
+	P50Ms int64 `json:"p50_ms"`
+	P90Ms int64 `json:"p90_ms"`
+	P95Ms int64 `json:"p95_ms"`
+	P99Ms int64 `json:"p99_ms"`
+	MaxMs int64 `json:"max_ms"`
+}
+
+func toDurationStatsResponse(stats usecase.DurationStats) durationStatsResponse {
+	return durationStatsResponse{
+		Count: stats.Count,
+		AvgMs: stats.Avg.Milliseconds(),
+		P50Ms: stats.P50.Milliseconds(),
+		P90Ms: stats.P90.Milliseconds(),
+		P95Ms: stats.P95.Milliseconds(),
+		P99Ms: stats.P99.Milliseconds(),
+		MaxMs: stats.Max.Milliseconds(),
+	}
+}
+
+// handleVideoStats reports processing time percentiles for uploads completed in a window
+func (s *Server) handleVideoStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	window := defaultStatsWindow
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		parsed, err := parseStatsWindow(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		window = parsed
+	}
+	since := time.Now().Add(-window)
+
+	videos, err := s.videoRepo.GetCompletedSince(r.Context(), since)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	stats := usecase.ComputeProcessingStats(videos)
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"window":            window.String(),
+		"since":             since.UTC(),
+		"videos":            stats.Videos,
+		"publish_to_post":   toDurationStatsResponse(stats.PublishToPost),
+		"discovery_to_post": toDurationStatsResponse(stats.DiscoveryToPost),
+		"download":          toDurationStatsResponse(stats.Download),
+		"upload":            toDurationStatsResponse(stats.Upload),
+	})
+}
+
+// parseStatsWindow accepts Go durations ("36h") and whole days ("7d")
+func parseStatsWindow(raw string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid window %q", raw)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window %q: use a duration like 24h or 7d", raw)
+	}
+	return d, nil
+}
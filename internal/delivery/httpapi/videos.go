@@ -0,0 +1,366 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// SetClipManager enables splitting videos into clips.
+func (s *Server) SetClipManager(manager *usecase.ClipManager) {
+	s.clipManager = manager
+}
+
+// handleVideoActions serves /api/videos/{id} and /api/videos/{id}/clips
+func (s *Server) handleVideoActions(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/videos/")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 0 || parts[0] == "" {
+		respondError(w, http.StatusNotFound, "not found")
+		return
+	}
+	id := parts[0]
+
+	switch {
+	case len(parts) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			s.getVideo(w, r, id)
+		case http.MethodPatch:
+			s.updateVideo(w, r, id)
+		default:
+			methodNotAllowed(w)
+		}
+	case len(parts) == 2 && parts[1] == "clips":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w)
+			return
+		}
+		s.createClips(w, r, id)
+	case len(parts) == 2 && parts[1] == "retry":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w)
+			return
+		}
+		s.retryVideo(w, r, id)
+	case len(parts) == 2 && (parts[1] == "force-complete" || parts[1] == "force-fail"):
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w)
+			return
+		}
+		action := domain.AuditActionForceComplete
+		if parts[1] == "force-fail" {
+			action = domain.AuditActionForceFail
+		}
+		s.forceVideo(w, r, id, action)
+	case len(parts) == 2 && parts[1] == "approve":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w)
+			return
+		}
+		s.approveVideo(w, r, id)
+	case len(parts) == 2 && parts[1] == "upload-preview":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		s.uploadPreview(w, r, id)
+	case len(parts) == 2 && parts[1] == "audit":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		s.videoAudit(w, r, id)
+	default:
+		respondError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// VideoListResponse is one page of a video listing
+type VideoListResponse struct {
+	Videos []*VideoResponse `json:"videos"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+}
+
+// handleVideos lists videos of all accounts, most recently updated first
+func (s *Server) handleVideos(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	filter, ok := parseVideoFilter(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := s.recentVideos(r.Context(), filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, &VideoListResponse{Videos: resp, Limit: filter.Limit, Offset: filter.Offset})
+}
+
+// recentVideos backs both the video list endpoint and the web UI queue
+func (s *Server) recentVideos(ctx context.Context, filter domain.VideoFilter) ([]*VideoResponse, error) {
+	videos, err := s.videoRepo.GetRecent(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*VideoResponse, 0, len(videos))
+	for _, video := range videos {
+		resp = append(resp, toVideoResponse(video))
+	}
+	return resp, nil
+}
+
+// parseVideoFilter reads the status, limit and offset query parameters of video listings,
+// responding with 400 and returning false on an unknown status
+func parseVideoFilter(w http.ResponseWriter, r *http.Request) (domain.VideoFilter, bool) {
+	query := r.URL.Query()
+	filter := domain.VideoFilter{Limit: 50}
+	if v := query.Get("status"); v != "" {
+		status := domain.VideoStatus(v)
+		if !status.IsValid() {
+			respondError(w, http.StatusBadRequest, "invalid status")
+			return filter, false
+		}
+		filter.Status = status
+	}
+	if v := query.Get("limit"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			if parsed > 200 {
+				parsed = 200
+			}
+			filter.Limit = parsed
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			filter.Offset = parsed
+		}
+	}
+	return filter, true
+}
+
+// retryVideo puts a failed video back to pending for the next processing run. A video split
+// into clips requeues its failed clips.
+func (s *Server) retryVideo(w http.ResponseWriter, r *http.Request, id string) {
+	video, err := s.videoRepo.GetByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if video == nil {
+		respondError(w, http.StatusNotFound, "video not found")
+		return
+	}
+	if video.Status != domain.VideoStatusFailed {
+		respondError(w, http.StatusConflict, "only failed videos can be retried")
+		return
+	}
+
+	err = s.retryWrite(r.Context(), func(ctx context.Context) error {
+		if video.ClipCount > 0 {
+			clips, err := s.videoRepo.GetClips(ctx, video.ID)
+			if err != nil {
+				return err
+			}
+			for _, clip := range clips {
+				if clip.Status != domain.VideoStatusFailed {
+					continue
+				}
+				if err := s.videoRepo.UpdateStatus(ctx, clip.ID, domain.VideoStatusPending, ""); err != nil {
+					return err
+				}
+			}
+		}
+		return s.videoRepo.UpdateStatus(ctx, video.ID, domain.VideoStatusPending, "")
+	})
+	if err != nil {
+		s.respondWriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	video, err = s.videoRepo.GetByID(r.Context(), id)
+	if err != nil || video == nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to reload video: %v", err))
+		return
+	}
+	respondJSON(w, http.StatusOK, toVideoResponse(video))
+}
+
+func (s *Server) getVideo(w http.ResponseWriter, r *http.Request, id string) {
+	video, err := s.videoRepo.GetByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if video == nil {
+		respondError(w, http.StatusNotFound, "video not found")
+		return
+	}
+
+	resp := map[string]any{"video": toVideoResponse(video)}
+	if video.ClipCount > 0 {
+		clips, err := s.videoRepo.GetClips(r.Context(), video.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		clipResp := make([]*VideoResponse, 0, len(clips))
+		for _, clip := range clips {
+			clipResp = append(clipResp, toVideoResponse(clip))
+		}
+		resp["clips"] = clipResp
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// updateVideo changes a video's queue priority, e.g. {"priority": 100} to upload it next. A video
+// split into clips passes the priority on to its clips, which are queued in its place.
+func (s *Server) updateVideo(w http.ResponseWriter, r *http.Request, id string) {
+	var payload struct {
+		Priority *int `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if payload.Priority == nil {
+		respondError(w, http.StatusBadRequest, "priority is required")
+		return
+	}
+
+	video, err := s.videoRepo.GetByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if video == nil {
+		respondError(w, http.StatusNotFound, "video not found")
+		return
+	}
+
+	err = s.retryWrite(r.Context(), func(ctx context.Context) error {
+		if video.ClipCount > 0 {
+			clips, err := s.videoRepo.GetClips(ctx, video.ID)
+			if err != nil {
+				return err
+			}
+			for _, clip := range clips {
+				if err := s.videoRepo.UpdatePriority(ctx, clip.ID, *payload.Priority); err != nil {
+					return err
+				}
+			}
+		}
+		return s.videoRepo.UpdatePriority(ctx, video.ID, *payload.Priority)
+	})
+	if err != nil {
+		s.respondWriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	video, err = s.videoRepo.GetByID(r.Context(), id)
+	if err != nil || video == nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to reload video: %v", err))
+		return
+	}
+	respondJSON(w, http.StatusOK, toVideoResponse(video))
+}
+
+// clipRequest accepts either start/end timestamps or a single "12:30-13:15" range
+type clipRequest struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Range string `json:"range"`
+	Title string `json:"title"`
+}
+
+func (s *Server) createClips(w http.ResponseWriter, r *http.Request, id string) {
+	if s.clipManager == nil {
+		respondError(w, http.StatusServiceUnavailable, "clips are not enabled")
+		return
+	}
+
+	var payload struct {
+		Clips []clipRequest `json:"clips"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	specs := make([]usecase.ClipSpec, 0, len(payload.Clips))
+	for _, clip := range payload.Clips {
+		spec, err := clip.toSpec()
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		specs = append(specs, spec)
+	}
+
+	video, err := s.videoRepo.GetByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if video == nil {
+		respondError(w, http.StatusNotFound, "video not found")
+		return
+	}
+
+	parent, clips, err := s.clipManager.CreateClips(r.Context(), video.ID, specs)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidClips):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, usecase.ErrClipsNotAllowed):
+			respondError(w, http.StatusConflict, err.Error())
+		default:
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	clipResp := make([]*VideoResponse, 0, len(clips))
+	for _, clip := range clips {
+		clipResp = append(clipResp, toVideoResponse(clip))
+	}
+	respondJSON(w, http.StatusCreated, map[string]any{
+		"video": toVideoResponse(parent),
+		"clips": clipResp,
+	})
+}
+
+func (c clipRequest) toSpec() (usecase.ClipSpec, error) {
+	spec := usecase.ClipSpec{Title: c.Title}
+	if c.Range != "" {
+		start, end, err := usecase.ParseClipRange(c.Range)
+		if err != nil {
+			return spec, err
+		}
+		spec.Start, spec.End = start, end
+		return spec, nil
+	}
+
+	start, err := usecase.ParseClipTimestamp(c.Start)
+	if err != nil {
+		return spec, err
+	}
+	end, err := usecase.ParseClipTimestamp(c.End)
+	if err != nil {
+		return spec, err
+	}
+	spec.Start, spec.End = start, end
+	return spec, nil
+}
@@ -0,0 +1,110 @@
+package httpapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/redact"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// maxCookiesBody caps the size of an uploaded cookie export
+const maxCookiesBody = 1 << 20
+
+// SetWebSessionManager enables cookie uploads and the web session in the upload health report.
+func (s *Server) SetWebSessionManager(manager *usecase.WebSessionManager) {
+	s.webSessions = manager
+}
+
+// webSessionResponse is the TikTok login of the web upload cookies, compared with one account
+type webSessionResponse struct {
+	UserID     string    `json:"user_id"`
+	Username   string    `json:"username"`
+	Nickname   string    `json:"nickname"`
+	AccountID  string    `json:"captured_for_account_id,omitempty"`
+	CapturedAt time.Time `json:"captured_at"`
+	Match      string    `json:"match"`
+}
+
+func toWebSessionResponse(account *domain.Account, session *domain.WebSession) *webSessionResponse {
+	return &webSessionResponse{
+		UserID:     session.UserID,
+		Username:   session.Username,
+		Nickname:   session.Nickname,
+		AccountID:  session.AccountID,
+		CapturedAt: session.CapturedAt,
+		Match:      usecase.MatchWebSession(account, session),
+	}
+}
+
+// webSessionClaimResponse is which host last used the web upload cookies, and whether another
+// host used them within tiktok.cookies_claim.window
+type webSessionClaimResponse struct {
+	Host         string     `json:"host"`
+	UsedAt       time.Time  `json:"used_at"`
+	ThisHost     bool       `json:"this_host"`
+	Conflict     bool       `json:"conflict"`
+	ConflictHost string     `json:"conflict_host,omitempty"`
+	ConflictAt   *time.Time `json:"conflict_at,omitempty"`
+}
+
+func (s *Server) toWebSessionClaimResponse(claim *domain.WebSessionClaim) *webSessionClaimResponse {
+	resp := &webSessionClaimResponse{
+		Host:     claim.Host,
+		UsedAt:   claim.UsedAt,
+		ThisHost: claim.Host == usecase.CookiesClaimHost(s.cfg),
+	}
+	if !claim.ConflictAt.IsZero() {
+		t := claim.ConflictAt
+		resp.ConflictHost, resp.ConflictAt = claim.ConflictHost, &t
+		resp.Conflict = time.Since(t) < s.cfg.TikTokCookiesClaimWindow
+	}
+	return resp
+}
+
+// importAccountCookies checks an uploaded JSON cookie export against the TikTok account the
+// mapping posts to and stores it as the web upload cookies. ?force=true stores mismatched cookies.
+func (s *Server) importAccountCookies(w http.ResponseWriter, r *http.Request, id string) {
+	if s.webSessions == nil {
+		respondError(w, http.StatusServiceUnavailable, "cookie uploads are not enabled")
+		return
+	}
+
+	account, err := s.accountManager.GetAccountMapping(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		respondError(w, http.StatusNotFound, "account not found")
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCookiesBody))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "failed to read cookies: "+err.Error())
+		return
+	}
+
+	session, err := s.webSessions.ImportCookies(r.Context(), id, data, r.URL.Query().Get("force") == "true")
+	switch {
+	case errors.Is(err, usecase.ErrWebSessionMismatch):
+		respondJSON(w, http.StatusConflict, map[string]any{
+			"error":       redact.String(err.Error()),
+			"web_session": toWebSessionResponse(account, session),
+		})
+		return
+	case errors.Is(err, tiktok.ErrInvalidCookies), errors.Is(err, tiktok.ErrCookiesSignedOut):
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toWebSessionResponse(account, session))
+}
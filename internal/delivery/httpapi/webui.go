@@ -0,0 +1,199 @@
+package httpapi
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+// webTemplates holds the web UI pages; the template engine escapes every value, including
+// titles and error messages that come from YouTube and TikTok. It is never executed itself:
+// renderPage binds the request's language to a clone.
+var webTemplates = template.Must(template.New("").Funcs(localizedFuncs(localizer{locale: defaultLocale})).
+	ParseFS(templateFiles, "templates/*.html"))
+
+// localizedFuncs are the template functions that depend on the page's language: t translates a
+// message key and lang names the language for the html element
+func localizedFuncs(loc localizer) template.FuncMap {
+	return template.FuncMap{
+		"t":    loc.T,
+		"lang": func() string { return loc.locale },
+	}
+}
+
+// videoProgress is the share of the pipeline a video in each status has passed
+var videoProgress = map[domain.VideoStatus]int{
+	domain.VideoStatusDownloading:   25,
+	domain.VideoStatusDownloaded:    50,
+	domain.VideoStatusWaitingWindow: 60,
+	domain.VideoStatusUploading:     75,
+	domain.VideoStatusPublishing:    90,
+	domain.VideoStatusCompleted:     100,
+}
+
+// queueProgress places the progress of a download or upload between the share its status starts
+// at and the share of the status that follows it
+func queueProgress(video *VideoResponse) int {
+	status := domain.VideoStatus(video.Status)
+	start := videoProgress[status]
+	end := start
+	switch status {
+	case domain.VideoStatusDownloading:
+		end = videoProgress[domain.VideoStatusDownloaded]
+	case domain.VideoStatusUploading:
+		end = videoProgress[domain.VideoStatusPublishing]
+	}
+	return start + (end-start)*video.Progress/100
+}
+
+// accountRow is an accounts table row: the API response plus what only the UI shows
+type accountRow struct {
+	*AccountResponse
+	PendingTikTokID bool
+	Token           tokenBadge
+	ConnectYouTube  bool
+}
+
+// queueRow is a video queue row: the API response plus what only the UI shows
+type queueRow struct {
+	*VideoResponse
+	SourceYouTubeID string
+	Progress        int
+	Retryable       bool
+	Previewable     bool
+}
+
+// handleWebUI renders the accounts page of the web interface for token management
+func (s *Server) handleWebUI(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	accounts, err := s.accountManager.GetAllAccountMappings(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	loc := s.localizerFor(r)
+	now := time.Now()
+	rows := make([]accountRow, 0, len(accounts))
+	for _, account := range accounts {
+		resp := s.toAccountResponse(account)
+		rows = append(rows, accountRow{
+			AccountResponse: resp,
+			PendingTikTokID: usecase.PendingTikTokAccountID(account),
+			Token:           tokenBadgeFor(loc, account, resp.AppMismatch, now),
+			ConnectYouTube:  account.Settings.UpdateYouTubeDescription && s.youtubeOAuthEnabled(),
+		})
+	}
+
+	renderPage(w, loc, "accounts.html", map[string]any{
+		"Accounts":  rows,
+		"DataUsage": s.transferUsageText(r.Context()),
+	})
+}
+
+// handleVideosPage renders the video queue page; ?status= narrows it to one status
+func (s *Server) handleVideosPage(w http.ResponseWriter, r *http.Request) {
+	rows, ok := s.queueRows(w, r)
+	if !ok {
+		return
+	}
+	statuses := []domain.VideoStatus{
+		domain.VideoStatusPending, domain.VideoStatusDownloading, domain.VideoStatusDownloaded,
+		domain.VideoStatusUploading, domain.VideoStatusPublishing, domain.VideoStatusCompleted, domain.VideoStatusFailed,
+		domain.VideoStatusSkipped, domain.VideoStatusAwaitingReview, domain.VideoStatusGated, domain.VideoStatusWaitingWindow,
+	}
+	renderPage(w, s.localizerFor(r), "videos.html", map[string]any{
+		"Queue":    rows,
+		"Statuses": statuses,
+	})
+}
+
+// handleQueueFragment renders only the queue table, for the page's periodic refresh
+func (s *Server) handleQueueFragment(w http.ResponseWriter, r *http.Request) {
+	rows, ok := s.queueRows(w, r)
+	if !ok {
+		return
+	}
+	renderPage(w, s.localizerFor(r), "queue", rows)
+}
+
+// queueRows loads the queue through the same path as GET /api/videos
+func (s *Server) queueRows(w http.ResponseWriter, r *http.Request) ([]queueRow, bool) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return nil, false
+	}
+	filter, ok := parseVideoFilter(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	videos, err := s.recentVideos(r.Context(), filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	rows := make([]queueRow, 0, len(videos))
+	for _, video := range videos {
+		source, _, _ := strings.Cut(video.YouTubeVideoID, "#")
+		rows = append(rows, queueRow{
+			VideoResponse:   video,
+			SourceYouTubeID: source,
+			Progress:        queueProgress(video),
+			Retryable:       video.Status == string(domain.VideoStatusFailed),
+			Previewable:     s.previewer != nil && awaitsUpload(domain.VideoStatus(video.Status)),
+		})
+	}
+	return rows, true
+}
+
+// awaitsUpload reports whether a video in the status has not been sent to TikTok yet, so its
+// upload can still be previewed
+func awaitsUpload(status domain.VideoStatus) bool {
+	switch status {
+	case domain.VideoStatusPending, domain.VideoStatusDownloading, domain.VideoStatusDownloaded,
+		domain.VideoStatusAwaitingReview, domain.VideoStatusGated, domain.VideoStatusWaitingWindow:
+		return true
+	}
+	return false
+}
+
+// renderPage executes a web UI template in the localizer's language into a buffer first, so a
+// template error still produces a clean 500 instead of half a page
+func renderPage(w http.ResponseWriter, loc localizer, name string, data any) {
+	var body bytes.Buffer
+	if err := executeLocalized(&body, loc, name, data); err != nil {
+		logger.Error().Printf("Failed to render %s: %v", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Language", loc.locale)
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// executeLocalized executes a web UI template with the t and lang functions of loc
+func executeLocalized(out io.Writer, loc localizer, name string, data any) error {
+	tmpl, err := webTemplates.Clone()
+	if err != nil {
+		return err
+	}
+	return tmpl.Funcs(localizedFuncs(loc)).ExecuteTemplate(out, name, data)
+}
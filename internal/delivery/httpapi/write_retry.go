@@ -0,0 +1,93 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/logger"
+)
+
+// Backoff between API write attempts while the database is locked
+const (
+	writeRetryInitialDelay = 50 * time.Millisecond
+	writeRetryMaxDelay     = time.Second
+)
+
+// errDatabaseBusy is returned when an API write is still blocked after the retry budget
+var errDatabaseBusy = errors.New("database is busy, please retry")
+
+// isDatabaseLocked reports whether err is SQLite lock contention rather than a real failure
+func isDatabaseLocked(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked") ||
+		strings.Contains(msg, "SQLITE_BUSY")
+}
+
+// retryWrite runs an API-originated write, retrying with jittered backoff while the database
+// is locked by pipeline writes. Once server.write_retry_budget is spent it returns errDatabaseBusy.
+// Pipeline writes do not go through here; they are retried by the next processing cycle.
+// Only wrap operations that are safe to replay: a multi-step write such as splitting a video
+// could be left half applied by a failed attempt.
+// A write locked out by another process waits for the connection's busy timeout before it sees
+// the budget, so the answer can come up to that much after the budget.
+func (s *Server) retryWrite(ctx context.Context, op func(ctx context.Context) error) error {
+	if s.cfg.WriteRetryBudget <= 0 {
+		err := op(ctx)
+		if isDatabaseLocked(err) {
+			s.lockContention.Add(1)
+			return fmt.Errorf("%w: %v", errDatabaseBusy, err)
+		}
+		return err
+	}
+
+	budgetCtx, cancel := context.WithTimeout(ctx, s.cfg.WriteRetryBudget)
+	defer cancel()
+
+	delay := writeRetryInitialDelay
+	for attempt := 1; ; attempt++ {
+		err := op(budgetCtx)
+		locked := isDatabaseLocked(err)
+		// Waiting for the connection can also run out the budget while a pipeline write holds it
+		if !locked && budgetCtx.Err() != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			locked = true
+		}
+		if !locked {
+			return err
+		}
+
+		s.lockContention.Add(1)
+		wait := delay/2 + rand.N(delay)
+		if deadline, ok := budgetCtx.Deadline(); ok && time.Until(deadline) < wait {
+			logger.ErrorContext(ctx).Printf("API write still blocked after %d attempts: %v", attempt, err)
+			return fmt.Errorf("%w: %v", errDatabaseBusy, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, writeRetryMaxDelay)
+	}
+}
+
+// respondWriteError answers 503 with Retry-After for exhausted write retries and status otherwise
+func (s *Server) respondWriteError(w http.ResponseWriter, status int, err error) {
+	if errors.Is(err, errDatabaseBusy) {
+		retryAfter := int(s.cfg.WriteRetryBudget.Round(time.Second) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		respondError(w, http.StatusServiceUnavailable, errDatabaseBusy.Error())
+		return
+	}
+	respondError(w, status, err.Error())
+}
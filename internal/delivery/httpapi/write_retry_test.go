@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// TestRetryWriteWhileDatabaseHeld deactivates an account while a pipeline write holds the
+// database, for longer than server.write_retry_budget and for less
+func TestRetryWriteWhileDatabaseHeld(t *testing.T) {
+	const budget = 500 * time.Millisecond
+	tests := []struct {
+		name       string
+		hold       time.Duration // before the write is released; 0 holds it past the budget
+		wantStatus int
+	}{
+		{name: "released within the budget", hold: 150 * time.Millisecond, wantStatus: http.StatusOK},
+		{name: "held past the budget", wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := sqlitetest.OpenTest(t)
+			ctx := context.Background()
+			account := &domain.Account{ID: "acc-1", YouTubeChannelID: "UC-1", TikTokAccountID: "tt-1", IsActive: true}
+			if err := repos.Accounts.Save(ctx, account); err != nil {
+				t.Fatalf("save account: %v", err)
+			}
+			cfg := &config.Config{WriteRetryBudget: budget, HTTPClientTimeout: 5 * time.Second}
+			s := NewServer(cfg, usecase.NewAccountManager(cfg, repos.Accounts), repos.Videos, tiktok.NewService(cfg, httpclient.NewHTTPClient(cfg)))
+
+			release := repos.HoldWrite(t)
+			if tt.hold > 0 {
+				time.AfterFunc(tt.hold, release)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/accounts/acc-1/deactivate", nil)
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			s.server.Handler.ServeHTTP(rec, req)
+			elapsed := time.Since(start)
+			release()
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if elapsed > budget+250*time.Millisecond {
+				t.Errorf("answered after %v, over the %v budget", elapsed, budget)
+			}
+			stored, err := repos.Accounts.GetByID(ctx, "acc-1")
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if stored.IsActive {
+					t.Error("account still active after the write went through")
+				}
+				return
+			}
+			if s.lockContention.Load() == 0 {
+				t.Error("lock contention not counted")
+			}
+			if rec.Header().Get("Retry-After") != "1" {
+				t.Errorf("Retry-After = %q, want 1", rec.Header().Get("Retry-After"))
+			}
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["error"] != errDatabaseBusy.Error() {
+				t.Errorf("body = %s, want the busy error", rec.Body)
+			}
+			if !stored.IsActive {
+				t.Error("account deactivated by a write that answered 503")
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/usecase"
+)
+
+// SetYouTubeService enables YouTube authorization for description updates.
+func (s *Server) SetYouTubeService(service *youtube.Service) {
+	s.youtubeService = service
+}
+
+// youtubeRedirectURI returns the configured Google redirect URI without query parameters
+func (s *Server) youtubeRedirectURI() string {
+	redirectURI := s.cfg.YouTubeRedirectURI
+	if redirectURI == "" {
+		redirectURI = fmt.Sprintf("http://localhost:%s%s", s.cfg.ServerPort, oauthCallbackPath(domain.OAuthProviderYouTube))
+	}
+	return strings.Split(redirectURI, "?")[0]
+}
+
+// youtubeOAuthEnabled reports whether the Google OAuth client is configured
+func (s *Server) youtubeOAuthEnabled() bool {
+	return s.youtubeService != nil && s.youtubeService.OAuthEnabled()
+}
+
+// handleYouTubeAuthorize starts the Google OAuth flow for the account's YouTube channel
+func (s *Server) handleYouTubeAuthorize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if !s.youtubeOAuthEnabled() {
+		respondError(w, http.StatusServiceUnavailable, "youtube.oauth_client_id and youtube.oauth_client_secret are not configured")
+		return
+	}
+
+	// Extract account ID from path: /api/youtube/authorize/{account_id}
+	accountID := strings.TrimPrefix(r.URL.Path, "/api/youtube/authorize/")
+	if accountID == "" {
+		respondError(w, http.StatusBadRequest, "account_id is required in path")
+		return
+	}
+
+	account, err := s.accountManager.GetAccountMapping(r.Context(), accountID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("failed to get account: %v", err))
+		return
+	}
+	if account == nil {
+		respondError(w, http.StatusNotFound, "account not found")
+		return
+	}
+
+	state, err := s.oauthStates.Issue(r.Context(), accountID, domain.OAuthProviderYouTube, config.TikTokApp{})
+	if errors.Is(err, errOAuthStateLimit) {
+		respondError(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.setOAuthStateCookie(w, domain.OAuthProviderYouTube, state)
+
+	http.Redirect(w, r, s.youtubeService.AuthorizeURL(s.youtubeRedirectURI(), state), http.StatusFound)
+}
+
+// handleYouTubeCallback receives the Google OAuth callback and stores the channel owner's tokens
+func (s *Server) handleYouTubeCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if !s.youtubeOAuthEnabled() {
+		respondError(w, http.StatusServiceUnavailable, "youtube.oauth_client_id and youtube.oauth_client_secret are not configured")
+		return
+	}
+
+	// The account comes only from a state this server issued to this browser
+	issued, err := s.verifyOAuthState(w, r, domain.OAuthProviderYouTube, r.URL.Query().Get("state"))
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Rejected YouTube OAuth callback: %v", err)
+		s.renderCallbackPage(w, r, false, "", "callback.rejected", err)
+		return
+	}
+	accountID := issued.accountID
+
+	if errorParam := r.URL.Query().Get("error"); errorParam != "" {
+		logger.ErrorContext(r.Context()).Printf("YouTube authorization error for account %s: %s", accountID, errorParam)
+		s.renderCallbackPage(w, r, false, accountID, "callback.authorization_failed", errorParam)
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		respondError(w, http.StatusBadRequest, "authorization code is missing")
+		return
+	}
+
+	// Without the scope the token could read but not edit descriptions
+	if scope := r.URL.Query().Get("scope"); scope != "" && !strings.Contains(scope, youtube.OAuthScope) {
+		s.renderCallbackPage(w, r, false, accountID, "youtube.scope_missing")
+		return
+	}
+
+	token, err := s.youtubeService.ExchangeCode(code, s.youtubeRedirectURI())
+	if err != nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to exchange YouTube code for account %s: %v", accountID, err)
+		s.renderCallbackPage(w, r, false, accountID, "callback.exchange_failed", err)
+		return
+	}
+
+	exchangeCtx := usecase.WithAccountEventSource(r.Context(), domain.AccountEventSourceExchange)
+	if _, err := s.accountManager.UpdateYouTubeTokens(exchangeCtx, accountID, token.AccessToken, token.RefreshToken, token.ExpiresIn); err != nil {
+		logger.ErrorContext(r.Context()).Printf("Failed to update YouTube tokens: %v", err)
+		s.renderCallbackPage(w, r, false, accountID, "callback.update_tokens_failed", err)
+		return
+	}
+
+	logger.InfoContext(r.Context()).Printf("Successfully stored YouTube tokens for account %s", accountID)
+	if token.RefreshToken == "" {
+		logger.InfoContext(r.Context()).Printf("WARNING: No YouTube refresh token for account %s - authorize again when the token expires", accountID)
+	}
+	s.renderCallbackPage(w, r, true, accountID, "youtube.connected")
+}
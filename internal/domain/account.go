@@ -1,6 +1,16 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+)
+
+// ErrStaleWrite is returned by AccountRepository.Save when the account was saved by someone else
+// since it was loaded. Reload the account, reapply the change and save again.
+var ErrStaleWrite = errors.New("account was changed concurrently")
 
 // Account represents a YouTube account to monitor
 type Account struct {
@@ -13,6 +23,10 @@ type Account struct {
 	// TikTokAccountID is the TikTok account ID where videos will be uploaded
 	TikTokAccountID string
 
+	// YouTubeHandle is the @handle the mapping was created with, kept for display (empty when it
+	// was created with a channel ID)
+	YouTubeHandle string
+
 	// TikTokAccessToken is the access token for TikTok API
 	TikTokAccessToken string
 
@@ -22,6 +36,19 @@ type Account struct {
 	// TikTokTokenExpiresAt is when the access token expires (optional)
 	TikTokTokenExpiresAt *time.Time
 
+	// TikTokApp is the credential set (config tiktok.apps) that issued the tokens; empty means the default set
+	TikTokApp string
+
+	// TikTokClientKey is the client key of that credential set when the tokens were issued (empty if unknown).
+	// Refreshes only work while the set still uses this key.
+	TikTokClientKey string
+
+	// YouTube OAuth tokens (scope youtube.force-ssl) used to add the TikTok link to the channel's
+	// video descriptions; empty until the channel owner authorizes through /api/youtube/authorize
+	YouTubeAccessToken    string
+	YouTubeRefreshToken   string
+	YouTubeTokenExpiresAt *time.Time
+
 	// LastCheckedAt is the timestamp of the last check for new videos
 	LastCheckedAt time.Time
 
@@ -31,6 +58,54 @@ type Account struct {
 	// IsActive indicates if the account monitoring is active
 	IsActive bool
 
+	// CommentTemplate is the first comment posted under each published TikTok video (optional).
+	// Supports the {youtube_url} and {title} placeholders.
+	CommentTemplate string
+
+	// Settings holds per-account discovery filters and options
+	Settings AccountSettings
+
+	// UploadHealth records per-path upload outcomes and the current failover state.
+	// It is written only through UpdateUploadHealth, never by Save.
+	UploadHealth UploadHealth
+
+	// PublicSlug is the unguessable path of the account's public status page (empty when disabled).
+	// It is written only through UpdatePublicSlug, never by Save.
+	PublicSlug string
+
+	// NeedsReauthorization is set when the stored token turned out to belong to another TikTok
+	// account than TikTokAccountID; API uploads are refused until the account is re-authorized.
+	// It is written only through UpdateNeedsReauthorization, never by Save.
+	NeedsReauthorization bool
+
+	// TikTokDisplayName and TikTokAvatarURL describe the TikTok login the tokens were issued to, as
+	// reported by /user/info/ at the last code exchange (empty until then). They are written only
+	// through UpdateTikTokProfile, never by Save.
+	TikTokDisplayName string
+	TikTokAvatarURL   string
+
+	// MissingScopes lists the scopes TikTok refused a request for although the token was valid,
+	// e.g. video.publish when the app was never approved for direct posting. Refreshing the token
+	// cannot add them; the next authorization asks for them. Empty once new tokens are stored.
+	// It is written only through UpdateMissingScopes, never by Save.
+	MissingScopes []string
+
+	// ReauthNotifiedAt is when the operator was last told the account needs re-authorization; nil
+	// once new tokens are stored. It is written only through UpdateReauthNotifiedAt, never by Save.
+	ReauthNotifiedAt *time.Time
+
+	// FailureStreak counts hard failures since the account last worked and why it was deactivated
+	// automatically. It is written only through UpdateFailureStreak, never by Save.
+	FailureStreak FailureStreak
+
+	// SourceStatus records whether the YouTube channel can still be read. It is written only
+	// through UpdateSourceStatus, never by Save.
+	SourceStatus SourceStatus
+
+	// Version is incremented by every Save. Save refuses to overwrite a stored account whose
+	// version differs from this one with ErrStaleWrite.
+	Version int64
+
 	// CreatedAt is the timestamp when the account was created
 	CreatedAt time.Time
 
@@ -38,33 +113,257 @@ type Account struct {
 	UpdatedAt time.Time
 }
 
+// Clone returns a copy of the account that shares no slices or maps with it, or nil for a nil
+// account. Copy new slice and map fields here.
+func (a *Account) Clone() *Account {
+	if a == nil {
+		return nil
+	}
+	clone := *a
+	clone.MissingScopes = slices.Clone(a.MissingScopes)
+	clone.Settings.TitleBlacklist = slices.Clone(a.Settings.TitleBlacklist)
+	clone.Settings.TitleWhitelist = slices.Clone(a.Settings.TitleWhitelist)
+	clone.Settings.UploadLimits = maps.Clone(a.Settings.UploadLimits)
+	clone.Settings.Hooks = slices.Clone(a.Settings.Hooks)
+	clone.Settings.SafetyDenylist = slices.Clone(a.Settings.SafetyDenylist)
+	if a.UploadHealth.Paths != nil {
+		clone.UploadHealth.Paths = make(map[UploadPath]*UploadPathStats, len(a.UploadHealth.Paths))
+		for path, stats := range a.UploadHealth.Paths {
+			if stats != nil {
+				statsCopy := *stats
+				stats = &statsCopy
+			}
+			clone.UploadHealth.Paths[path] = stats
+		}
+	}
+	return &clone
+}
+
+// Failure sources of an account's failure streak
+const (
+	FailureSourceDiscovery = "discovery" // Checking the YouTube channel failed
+	FailureSourceVideo     = "video"     // Downloading or uploading a video failed
+)
+
+// FailureStreak tracks consecutive hard failures of an account
+type FailureStreak struct {
+	// ConsecutiveFailures counts failures since the last success
+	ConsecutiveFailures int
+
+	// LastError is the most recent failure (empty when the streak is reset)
+	LastError string
+
+	// LastErrorSource is FailureSourceDiscovery or FailureSourceVideo; a success only resets a
+	// streak from the same source, so working discovery does not hide failing uploads
+	LastErrorSource string
+
+	// LastFailureAt is when the most recent failure happened
+	LastFailureAt *time.Time
+
+	// DisabledReason explains why the account was deactivated automatically (empty otherwise)
+	DisabledReason string
+}
+
+// States of an account as shown by the API and web UI
+const (
+	AccountStateActive            = "active"
+	AccountStateInactive          = "inactive"
+	AccountStateSourceUnavailable = "source_unavailable" // Active, but the YouTube channel cannot be read
+)
+
+// State returns AccountStateInactive for a deactivated account, AccountStateSourceUnavailable
+// while its YouTube channel is suspended, terminated or private, and AccountStateActive otherwise
+func (a *Account) State() string {
+	switch {
+	case !a.IsActive:
+		return AccountStateInactive
+	case a.SourceStatus.Unavailable != "":
+		return AccountStateSourceUnavailable
+	default:
+		return AccountStateActive
+	}
+}
+
+// Why a YouTube channel cannot be read
+const (
+	SourceSuspended  = "suspended"  // YouTube suspended the channel or its Google account
+	SourceTerminated = "terminated" // The channel was closed or deleted, so YouTube no longer returns it
+	SourcePrivate    = "private"    // The owner made the channel private
+)
+
+// SourceStatus is the outcome of the last channel status check of an account's YouTube channel
+type SourceStatus struct {
+	// Unavailable is SourceSuspended, SourceTerminated or SourcePrivate while the channel cannot
+	// be read, and empty while it is available
+	Unavailable string
+
+	// Reason describes the unavailability as YouTube reported it (empty while available)
+	Reason string
+
+	// Since is when the channel was first found unavailable (nil while available)
+	Since *time.Time
+
+	// CheckedAt is when the channel status was last checked (nil if never)
+	CheckedAt *time.Time
+}
+
+// AccountSettings holds per-account options stored alongside the account
+type AccountSettings struct {
+	// ShortsOnly mirrors only YouTube Shorts
+	ShortsOnly bool `json:"shorts_only,omitempty"`
+
+	// TitleBlacklist skips videos whose title contains any of these words (case-insensitive)
+	TitleBlacklist []string `json:"title_blacklist,omitempty"`
+
+	// TitleWhitelist, when not empty, requires the title to contain at least one of these words
+	TitleWhitelist []string `json:"title_whitelist,omitempty"`
+
+	// MaxUploadsPerDay caps completed uploads in any rolling 24h window (0 means no cap)
+	MaxUploadsPerDay int `json:"max_uploads_per_day,omitempty"`
+
+	// MinGapBetweenUploads is the minimum time between two uploads to the account (0 means no spacing)
+	MinGapBetweenUploads Duration `json:"min_gap_between_uploads,omitempty"`
+
+	// UploadPath is the preferred upload path; empty uses "web" when tiktok.enable_web is set, else "api"
+	UploadPath UploadPath `json:"upload_path,omitempty"`
+
+	// FallbackUploadPath is used while the preferred path fails with auth, scope or session
+	// errors (empty disables failover)
+	FallbackUploadPath UploadPath `json:"fallback_upload_path,omitempty"`
+
+	// UploadLimits overrides tiktok.api_limits and tiktok.web_limits per path; a video too large or
+	// too long for the preferred path is routed to the other one
+	UploadLimits map[UploadPath]UploadLimits `json:"upload_limits,omitempty"`
+
+	// CaptionLanguage translates titles into this language (ISO 639-1, e.g. "ja") for the caption
+	// when translation.provider is configured; empty posts titles as they are
+	CaptionLanguage string `json:"caption_language,omitempty"`
+
+	// PrivacyLevel is the TikTok privacy of posted videos (PUBLIC_TO_EVERYONE, MUTUAL_FOLLOW_FRIENDS,
+	// FOLLOWER_OF_CREATOR or SELF_ONLY); empty means public
+	PrivacyLevel string `json:"privacy_level,omitempty"`
+
+	// DisableComment, DisableDuet and DisableStitch turn off comments, duets and stitches on posted
+	// videos (API uploads only). Creators who turned one off in TikTok always post with it off.
+	DisableComment bool `json:"disable_comment,omitempty"`
+	DisableDuet    bool `json:"disable_duet,omitempty"`
+	DisableStitch  bool `json:"disable_stitch,omitempty"`
+
+	// PostAsDraft sends API uploads to the creator's TikTok drafts for manual review instead of
+	// publishing them
+	PostAsDraft bool `json:"post_as_draft,omitempty"`
+
+	// PublishDelay schedules API posts this long after upload (15m to 10 days; 0 publishes at once)
+	PublishDelay Duration `json:"publish_delay,omitempty"`
+
+	// Hooks names the configured hooks (see hooks in the config file) run for this account's videos,
+	// in this order within each phase
+	Hooks []string `json:"hooks,omitempty"`
+
+	// UpdateYouTubeDescription adds the TikTok link to the YouTube video's description after each
+	// publish; needs the account's YouTube authorization
+	UpdateYouTubeDescription bool `json:"update_youtube_description,omitempty"`
+
+	// SafetyDenylist stops videos whose title or description matches an entry before they are
+	// published: a word or phrase (case-insensitive), or a regular expression written as /expr/
+	SafetyDenylist []string `json:"safety_denylist,omitempty"`
+
+	// SafetyModeration sends each video's caption and a few frames to safety.moderation_url before
+	// it is published; the service allows, denies or holds it for review
+	SafetyModeration bool `json:"safety_moderation,omitempty"`
+
+	// MinViews holds discovered videos in gated until they reach this many YouTube views (0 queues
+	// them at once). Videos still short of it MinViewsWindow after publishing are skipped; an
+	// unset window means DefaultMinViewsWindow. Needs youtube.api_key.
+	MinViews       int64    `json:"min_views,omitempty"`
+	MinViewsWindow Duration `json:"min_views_window,omitempty"`
+
+	// DownloadMaxHeight and DownloadFPS cap the resolution (e.g. 1080) and frame rate of the
+	// account's downloads, and DownloadContainer picks the container (e.g. mp4); unset values use
+	// download.max_height, download.fps and download.format
+	DownloadMaxHeight int    `json:"download_max_height,omitempty"`
+	DownloadFPS       int    `json:"download_fps,omitempty"`
+	DownloadContainer string `json:"download_container,omitempty"`
+
+	// UploadWindowStart and UploadWindowEnd ("18:00", "22:00") limit uploads to those hours in
+	// Timezone (an IANA name; empty means the server's local time). Videos downloaded outside
+	// the window wait in waiting_window; an end before the start spans midnight.
+	UploadWindowStart string `json:"upload_window_start,omitempty"`
+	UploadWindowEnd   string `json:"upload_window_end,omitempty"`
+	Timezone          string `json:"timezone,omitempty"`
+}
+
+// DefaultMinViewsWindow is how long a video may wait for AccountSettings.MinViews by default
+const DefaultMinViewsWindow = 48 * time.Hour
+
+// ViewGateWindow returns how long videos wait for MinViews
+func (s AccountSettings) ViewGateWindow() time.Duration {
+	if s.MinViewsWindow <= 0 {
+		return DefaultMinViewsWindow
+	}
+	return time.Duration(s.MinViewsWindow)
+}
+
 // AccountRepository defines the interface for account data operations
 type AccountRepository interface {
 	// GetAll returns all accounts
-	GetAll() ([]*Account, error)
+	GetAll(ctx context.Context) ([]*Account, error)
 
 	// GetAllActive returns all active accounts
-	GetAllActive() ([]*Account, error)
+	GetAllActive(ctx context.Context) ([]*Account, error)
 
 	// GetByID returns an account by its ID
-	GetByID(id string) (*Account, error)
+	GetByID(ctx context.Context, id string) (*Account, error)
 
 	// GetByYouTubeChannelID returns an account by YouTube channel ID
-	GetByYouTubeChannelID(channelID string) (*Account, error)
+	GetByYouTubeChannelID(ctx context.Context, channelID string) (*Account, error)
+
+	// GetByTikTokAccountID returns an account by TikTok account ID (the oldest one when the
+	// TikTok account is shared by several channels)
+	GetByTikTokAccountID(ctx context.Context, tiktokID string) (*Account, error)
 
-	// GetByTikTokAccountID returns an account by TikTok account ID
-	GetByTikTokAccountID(tiktokID string) (*Account, error)
+	// ListByTikTokAccountID returns every account that posts to the TikTok account
+	ListByTikTokAccountID(ctx context.Context, tiktokID string) ([]*Account, error)
+
+	// GetByPublicSlug returns the account whose public status page uses the slug
+	GetByPublicSlug(ctx context.Context, slug string) (*Account, error)
 
 	// GetByYouTubeAndTikTok returns an account by both YouTube channel ID and TikTok account ID
-	GetByYouTubeAndTikTok(youtubeChannelID, tiktokAccountID string) (*Account, error)
+	GetByYouTubeAndTikTok(ctx context.Context, youtubeChannelID, tiktokAccountID string) (*Account, error)
 
 	// UpdateLastChecked updates the last checked timestamp and last video ID
-	UpdateLastChecked(id string, lastVideoID string, checkedAt time.Time) error
+	UpdateLastChecked(ctx context.Context, id string, lastVideoID string, checkedAt time.Time) error
+
+	// UpdateUploadHealth stores the account's upload path health
+	UpdateUploadHealth(ctx context.Context, id string, health UploadHealth) error
 
-	// Save creates or updates an account
-	Save(account *Account) error
+	// UpdatePublicSlug sets or, with an empty slug, revokes the account's public status page
+	UpdatePublicSlug(ctx context.Context, id string, slug string) error
+
+	// UpdateNeedsReauthorization sets or clears the account's re-authorization flag
+	UpdateNeedsReauthorization(ctx context.Context, id string, needs bool) error
+
+	// UpdateMissingScopes records or, with none, clears the scopes the account's token lacks
+	UpdateMissingScopes(ctx context.Context, id string, scopes []string) error
+
+	// UpdateTikTokProfile stores the display name and avatar of the account's TikTok login
+	UpdateTikTokProfile(ctx context.Context, id string, displayName, avatarURL string) error
+
+	// UpdateReauthNotifiedAt records or, with nil, clears the last re-authorization notification
+	UpdateReauthNotifiedAt(ctx context.Context, id string, notifiedAt *time.Time) error
+
+	// UpdateFailureStreak stores the account's failure streak
+	UpdateFailureStreak(ctx context.Context, id string, streak FailureStreak) error
+
+	// UpdateSourceStatus stores the outcome of the account's last channel status check
+	UpdateSourceStatus(ctx context.Context, id string, status SourceStatus) error
+
+	// Save creates or updates an account and increments its Version. It returns ErrStaleWrite
+	// when the stored account has another version, i.e. it was saved since it was loaded.
+	// Fields with their own Update method, such as the last checked video, are only written when
+	// the account is created; the Update methods leave the version alone.
+	Save(ctx context.Context, account *Account) error
 
 	// Delete removes an account
-	Delete(id string) error
+	Delete(ctx context.Context, id string) error
 }
-
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AccountEventType is the kind of change an account event records
+type AccountEventType string
+
+// Recorded account changes
+const (
+	AccountEventCreated      AccountEventType = "created"
+	AccountEventUpdated      AccountEventType = "updated"
+	AccountEventActivated    AccountEventType = "activated"
+	AccountEventDeactivated  AccountEventType = "deactivated"
+	AccountEventTokenUpdated AccountEventType = "token_updated"
+	AccountEventDeleted      AccountEventType = "deleted"
+)
+
+// AccountEventSource is what made an account change
+type AccountEventSource string
+
+// Sources of account changes
+const (
+	// AccountEventSourceBootstrap is the accounts list of the config file, applied at startup
+	AccountEventSourceBootstrap AccountEventSource = "bootstrap"
+
+	// AccountEventSourceExchange is an OAuth code exchanged for tokens (callback, invite or API)
+	AccountEventSourceExchange AccountEventSource = "exchange"
+
+	// AccountEventSourceRefresh is the automatic refresh of an expired access token
+	AccountEventSourceRefresh AccountEventSource = "refresh"
+
+	// AccountEventSourceManual is an operator using the API, web UI or CLI
+	AccountEventSourceManual AccountEventSource = "manual"
+)
+
+// AccountEvent records one change to an account mapping or its tokens. Summary names the changed
+// fields; token values in it are redacted.
+type AccountEvent struct {
+	ID        string
+	AccountID string
+	Type      AccountEventType
+	Source    AccountEventSource
+	Summary   string
+	CreatedAt time.Time
+}
+
+// AccountEventRepository stores the change history of accounts. Events outlive the account, so the
+// history of a deleted account can still be read.
+type AccountEventRepository interface {
+	// Save appends an event, assigning its ID and creation time when unset
+	Save(ctx context.Context, event *AccountEvent) error
+
+	// ListByAccount returns the events of an account, newest first
+	ListByAccount(ctx context.Context, accountID string) ([]*AccountEvent, error)
+}
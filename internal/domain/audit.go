@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Audited administrative actions
+const (
+	AuditActionForceComplete = "force_complete"
+	AuditActionForceFail     = "force_fail"
+	AuditActionApprove       = "approve"
+)
+
+// AuditEntry records an administrative change made outside the normal pipeline
+type AuditEntry struct {
+	ID string
+
+	// Action is what was done, e.g. AuditActionForceComplete
+	Action string
+
+	// VideoID and AccountID identify the video the action changed
+	VideoID   string
+	AccountID string
+
+	// Actor describes who made the change, such as the API client's address
+	Actor string
+
+	// PreviousStatus is the video's status before the change
+	PreviousStatus VideoStatus
+
+	// Detail holds the action's parameters, such as the failure reason or recorded TikTok ID
+	Detail string
+
+	CreatedAt time.Time
+}
+
+// AuditRepository stores the audit trail of administrative actions
+type AuditRepository interface {
+	// Save appends an entry, assigning its ID and creation time when unset
+	Save(ctx context.Context, entry *AuditEntry) error
+
+	// ListByVideo returns the entries of a video, newest first
+	ListByVideo(ctx context.Context, videoID string) ([]*AuditEntry, error)
+}
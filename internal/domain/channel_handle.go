@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ChannelHandle is a resolved YouTube channel handle, kept so the same handle is not looked up
+// through the Data API again
+type ChannelHandle struct {
+	// Handle is the normalized handle: lowercase with a leading @
+	Handle string
+
+	// ChannelID is the canonical UC... channel ID the handle resolved to
+	ChannelID string
+
+	// ResolvedAt is when the handle was looked up
+	ResolvedAt time.Time
+}
+
+// ChannelHandleRepository stores resolved channel handles
+type ChannelHandleRepository interface {
+	// Get returns a resolved handle, or nil when it was never resolved
+	Get(ctx context.Context, handle string) (*ChannelHandle, error)
+
+	// Save stores a resolved handle, replacing an earlier resolution
+	Save(ctx context.Context, entry *ChannelHandle) error
+}
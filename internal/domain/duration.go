@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration that is stored and exchanged as a duration string such as "90m"
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON accepts a duration string ("30m", "1h30m"); an empty string means zero
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30m\": %w", err)
+	}
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	if parsed < 0 {
+		return fmt.Errorf("duration %q must not be negative", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
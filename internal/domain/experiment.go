@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Experiment posts one source video to several TikTok accounts with different captions
+// so the variants can be compared
+type Experiment struct {
+	// ID is the unique identifier for the experiment
+	ID string
+
+	// Name is an optional label for the experiment
+	Name string
+
+	// SourceVideoID is the video every arm is posted from
+	SourceVideoID string
+
+	// Arms are the (account, caption) variants, in creation order
+	Arms []ExperimentArm
+
+	// CreatedAt is the timestamp when the experiment was created
+	CreatedAt time.Time
+}
+
+// ExperimentArm is one variant of an experiment
+type ExperimentArm struct {
+	// Label identifies the arm in results (e.g. "A", "B")
+	Label string `json:"label"`
+
+	// AccountID is the account the arm is uploaded to
+	AccountID string `json:"account_id"`
+
+	// Caption is the TikTok caption used for this arm
+	Caption string `json:"caption"`
+
+	// VideoID is the child video that uploads this arm
+	VideoID string `json:"video_id"`
+}
+
+// ExperimentRepository defines the interface for experiment data operations
+type ExperimentRepository interface {
+	// GetByID returns an experiment by its ID
+	GetByID(ctx context.Context, id string) (*Experiment, error)
+
+	// List returns all experiments, newest first
+	List(ctx context.Context) ([]*Experiment, error)
+
+	// Save creates or updates an experiment
+	Save(ctx context.Context, experiment *Experiment) error
+}
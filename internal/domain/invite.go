@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// Invite is a single-use link that lets an account owner authorize TikTok for an account
+type Invite struct {
+	// ID is the unique identifier for the invite
+	ID string
+
+	// AccountID is the account the invite is bound to
+	AccountID string
+
+	// ExpiresAt is when the invite stops being valid
+	ExpiresAt time.Time
+
+	// UsedAt is when the invite was redeemed (nil if unused)
+	UsedAt *time.Time
+
+	// RevokedAt is when the invite was revoked (nil if active)
+	RevokedAt *time.Time
+
+	// CreatedAt is the timestamp when the invite was created
+	CreatedAt time.Time
+}
+
+// IsUsable reports whether the invite can still be redeemed at the given time
+func (i *Invite) IsUsable(now time.Time) bool {
+	return i.UsedAt == nil && i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// InviteRepository defines the interface for invite data operations
+type InviteRepository interface {
+	// GetByID returns an invite by its ID
+	GetByID(id string) (*Invite, error)
+
+	// ListByAccount returns all invites for an account, newest first
+	ListByAccount(accountID string) ([]*Invite, error)
+
+	// Save creates or updates an invite
+	Save(invite *Invite) error
+
+	// MarkUsed redeems an invite; it returns false if the invite was already used or revoked
+	MarkUsed(id string, usedAt time.Time) (bool, error)
+
+	// ReleaseUse reopens an invite redeemed at usedAt, for a redemption that could not be finished
+	ReleaseUse(id string, usedAt time.Time) error
+
+	// Revoke revokes an invite
+	Revoke(id string, revokedAt time.Time) error
+}
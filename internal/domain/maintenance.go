@@ -0,0 +1,31 @@
+package domain
+
+import "context"
+
+// DatabaseStats describes the database's size on disk
+type DatabaseStats struct {
+	SizeBytes int64 // Database file plus write-ahead log
+	Pages     int64
+	FreePages int64 // Unused pages only VACUUM returns to the file system
+}
+
+// FreePercent is the share of the database's pages that are unused
+func (s *DatabaseStats) FreePercent() float64 {
+	if s.Pages == 0 {
+		return 0
+	}
+	return float64(s.FreePages) * 100 / float64(s.Pages)
+}
+
+// DatabaseMaintainer reclaims space the database holds on to
+type DatabaseMaintainer interface {
+	// Stats reports the database's current size
+	Stats(ctx context.Context) (*DatabaseStats, error)
+
+	// Checkpoint copies the write-ahead log into the database file and truncates it
+	Checkpoint(ctx context.Context) error
+
+	// Vacuum rebuilds the database file without its free pages. Other queries wait until it
+	// finishes.
+	Vacuum(ctx context.Context) error
+}
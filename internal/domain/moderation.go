@@ -0,0 +1,29 @@
+package domain
+
+import "context"
+
+// ModerationRequest is what a moderation service sees of a video before it is published
+type ModerationRequest struct {
+	VideoID   string
+	AccountID string
+
+	// Caption is the title and description the video would be posted with
+	Caption string
+
+	// Frames are JPEG images taken at even intervals through the video (may be empty)
+	Frames [][]byte
+}
+
+// ModerationResult is a moderation service's verdict
+type ModerationResult struct {
+	// Decision is SafetyDecisionAllow, SafetyDecisionDeny or SafetyDecisionReview
+	Decision SafetyDecision
+
+	// Reason explains a deny or review, e.g. the policy the video breaks
+	Reason string
+}
+
+// Moderator asks an external service whether a video is safe to publish
+type Moderator interface {
+	Moderate(ctx context.Context, req *ModerationRequest) (*ModerationResult, error)
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// NotificationEvent identifies the kind of event being reported
+type NotificationEvent string
+
+const (
+	// NotificationInviteCompleted is sent when an account owner finishes an invite link
+	NotificationInviteCompleted NotificationEvent = "invite_completed"
+
+	// NotificationReauthRequired is sent when an account's TikTok tokens stopped working and the
+	// owner has to authorize it again
+	NotificationReauthRequired NotificationEvent = "reauthorization_required"
+
+	// NotificationReauthorized is sent when new tokens were stored for an account that needed
+	// re-authorization
+	NotificationReauthorized NotificationEvent = "reauthorized"
+
+	// NotificationAccountDisabled is sent when an account was deactivated after too many
+	// consecutive failures
+	NotificationAccountDisabled NotificationEvent = "account_disabled"
+
+	// NotificationWebSessionConflict is sent when two hosts used the web upload cookies within
+	// tiktok.cookies_claim.window, which can get the TikTok session signed out everywhere
+	NotificationWebSessionConflict NotificationEvent = "web_session_conflict"
+
+	// NotificationSourceUnavailable is sent when an account's YouTube channel was found suspended,
+	// terminated or private, and monitoring of it paused
+	NotificationSourceUnavailable NotificationEvent = "source_unavailable"
+
+	// NotificationSourceRecovered is sent when an unavailable YouTube channel can be read again
+	NotificationSourceRecovered NotificationEvent = "source_recovered"
+)
+
+// Notification is a message delivered to the operator
+type Notification struct {
+	// Event is the kind of event
+	Event NotificationEvent
+
+	// AccountID is the related account (optional)
+	AccountID string
+
+	// Message is a human-readable summary
+	Message string
+
+	// URL is a link the operator can act on, e.g. the authorize page (optional)
+	URL string
+
+	// Time is when the event happened
+	Time time.Time
+}
+
+// Notifier delivers notifications to the operator
+type Notifier interface {
+	// Notify sends a notification; implementations should not block for long
+	Notify(ctx context.Context, n *Notification) error
+}
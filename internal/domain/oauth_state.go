@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// OAuth providers an account can be authorized with
+const (
+	OAuthProviderTikTok  = "tiktok"
+	OAuthProviderYouTube = "youtube"
+)
+
+// OAuthState is a pending TikTok or YouTube authorization started by an operator. The state token
+// is single-use: the callback consumes it and a second callback with the same token is rejected.
+type OAuthState struct {
+	// State is the opaque token sent to the provider and echoed back on the callback
+	State string
+
+	// AccountID is the account being authorized
+	AccountID string
+
+	// Provider is OAuthProviderTikTok or OAuthProviderYouTube; a state is only accepted by the
+	// callback of the provider it was issued for
+	Provider string
+
+	// AppName and ClientKey identify the credential set that started the authorization
+	AppName   string
+	ClientKey string
+
+	// ExpiresAt is when the state stops being accepted
+	ExpiresAt time.Time
+
+	// ConsumedAt is when the callback used the state (nil while pending)
+	ConsumedAt *time.Time
+
+	// CreatedAt is when the state was issued
+	CreatedAt time.Time
+}
+
+// IsPending reports whether the state can still be consumed at the given time
+func (s *OAuthState) IsPending(now time.Time) bool {
+	return s.ConsumedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// OAuthStateStats counts stored states by lifecycle stage
+type OAuthStateStats struct {
+	Outstanding int // Pending and not yet expired
+	Consumed    int // Used by a callback
+	Expired     int // Never used and past their expiry, awaiting the sweep
+}
+
+// OAuthStateRepository defines the interface for pending OAuth state storage
+type OAuthStateRepository interface {
+	// Get returns a state by its token, or nil when unknown
+	Get(ctx context.Context, state string) (*OAuthState, error)
+
+	// Save stores a new state
+	Save(ctx context.Context, state *OAuthState) error
+
+	// MarkConsumed uses a pending state; it returns false if the state is unknown or was already
+	// consumed, so of two concurrent callbacks only one succeeds
+	MarkConsumed(ctx context.Context, state string, consumedAt time.Time) (bool, error)
+
+	// CountPending returns the account's states that are neither consumed nor expired
+	CountPending(ctx context.Context, accountID string, now time.Time) (int, error)
+
+	// DeleteExpiredBefore removes states, consumed or not, that expired before the cutoff
+	DeleteExpiredBefore(ctx context.Context, cutoff time.Time) (int, error)
+
+	// Stats counts stored states by lifecycle stage
+	Stats(ctx context.Context, now time.Time) (*OAuthStateStats, error)
+}
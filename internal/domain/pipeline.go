@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PauseScope is the part of the pipeline a global pause stops
+type PauseScope string
+
+const (
+	// PauseScopeNone means the pipeline runs normally
+	PauseScopeNone PauseScope = ""
+
+	// PauseScopeUploads stops uploads; videos are still downloaded and wait for the resume
+	PauseScopeUploads PauseScope = "uploads"
+
+	// PauseScopeDownloads stops downloads; videos already downloaded are still uploaded
+	PauseScopeDownloads PauseScope = "downloads"
+
+	// PauseScopeAll stops account monitoring, downloads and uploads
+	PauseScopeAll PauseScope = "all"
+)
+
+// Valid reports whether the scope is one a pause can use
+func (s PauseScope) Valid() bool {
+	return s == PauseScopeUploads || s == PauseScopeDownloads || s == PauseScopeAll
+}
+
+// PausesUploads reports whether uploads are stopped under the scope
+func (s PauseScope) PausesUploads() bool {
+	return s == PauseScopeUploads || s == PauseScopeAll
+}
+
+// PausesDownloads reports whether downloads are stopped under the scope
+func (s PauseScope) PausesDownloads() bool {
+	return s == PauseScopeDownloads || s == PauseScopeAll
+}
+
+// PipelineState is the global pause switch of the pipeline
+type PipelineState struct {
+	// Scope is what is paused; PauseScopeNone when running
+	Scope PauseScope
+
+	// Reason is the note given when pausing
+	Reason string
+
+	// UpdatedAt is when the pipeline was last paused or resumed
+	UpdatedAt time.Time
+}
+
+// Paused reports whether any part of the pipeline is paused
+func (s *PipelineState) Paused() bool {
+	return s.Scope != PauseScopeNone
+}
+
+// PipelineStateRepository stores the pipeline's pause switch so it survives restarts
+type PipelineStateRepository interface {
+	// Get returns the stored state, or nil when the pipeline was never paused
+	Get(ctx context.Context) (*PipelineState, error)
+
+	// Save replaces the stored state
+	Save(ctx context.Context, state *PipelineState) error
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "context"
+
+// Repositories are the repositories a transaction reads and writes through
+type Repositories struct {
+	Accounts AccountRepository
+	Videos   VideoRepository
+	Audit    AuditRepository
+}
+
+// Transactor runs several repository writes as one unit
+type Transactor interface {
+	// WithTx calls fn with repositories bound to a new transaction. The writes commit when fn
+	// returns nil and are rolled back when it returns an error. fn must only use repos, not
+	// repositories created outside the transaction.
+	WithTx(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// TransferUsage is the data moved over the network on one day
+type TransferUsage struct {
+	// Day is the local calendar day (YYYY-MM-DD)
+	Day string
+
+	// DownloadBytes counts video data fetched from YouTube and its fallbacks
+	DownloadBytes int64
+
+	// UploadBytes counts video data sent to TikTok
+	UploadBytes int64
+
+	// UpdatedAt is when bytes were last added
+	UpdatedAt time.Time
+}
+
+// Total returns downloaded plus uploaded bytes
+func (u *TransferUsage) Total() int64 {
+	return u.DownloadBytes + u.UploadBytes
+}
+
+// TransferUsageRepository persists daily transfer totals
+type TransferUsageRepository interface {
+	// Get returns the totals for a day, or nil when nothing was transferred
+	Get(ctx context.Context, day string) (*TransferUsage, error)
+
+	// Add increments a day's totals, creating the day if needed
+	Add(ctx context.Context, day string, downloadBytes, uploadBytes int64) error
+}
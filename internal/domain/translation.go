@@ -0,0 +1,21 @@
+package domain
+
+import "context"
+
+// Translation is a text translated by a Translator
+type Translation struct {
+	// Text is the translated text
+	Text string
+
+	// SourceLanguage is the language the provider detected in the input (lower-case code, may be empty)
+	SourceLanguage string
+}
+
+// Translator translates captions into an account's target language
+type Translator interface {
+	// Name identifies the provider in logs
+	Name() string
+
+	// Translate translates text into targetLanguage (an ISO 639-1 code such as "ja")
+	Translate(ctx context.Context, text, targetLanguage string) (*Translation, error)
+}
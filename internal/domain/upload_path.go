@@ -0,0 +1,85 @@
+package domain
+
+import "time"
+
+// UploadPath is the channel used to publish a video to TikTok
+type UploadPath string
+
+// Upload paths
+const (
+	UploadPathAPI UploadPath = "api" // TikTok Content Posting API with the account's OAuth token
+	UploadPathWeb UploadPath = "web" // Browser automation with the configured session cookies
+)
+
+// IsValid reports whether the path is a known upload path
+func (p UploadPath) IsValid() bool {
+	return p == UploadPathAPI || p == UploadPathWeb
+}
+
+// PrimaryUploadPath returns the account's preferred upload path given whether web upload is enabled globally
+func (s AccountSettings) PrimaryUploadPath(webEnabled bool) UploadPath {
+	if s.UploadPath.IsValid() {
+		return s.UploadPath
+	}
+	if webEnabled {
+		return UploadPathWeb
+	}
+	return UploadPathAPI
+}
+
+// FallbackPath returns the account's fallback upload path, or "" when failover is disabled
+func (s AccountSettings) FallbackPath(webEnabled bool) UploadPath {
+	if !s.FallbackUploadPath.IsValid() || s.FallbackUploadPath == s.PrimaryUploadPath(webEnabled) {
+		return ""
+	}
+	return s.FallbackUploadPath
+}
+
+// UploadLimits caps the files an upload path accepts; zero fields fall back to the configured
+// defaults
+type UploadLimits struct {
+	// MaxSize is the largest file in bytes
+	MaxSize int64 `json:"max_size,omitempty"`
+
+	// MaxDuration is the longest video
+	MaxDuration Duration `json:"max_duration,omitempty"`
+}
+
+// UploadHealth tracks how each upload path is doing for an account and which one is in use
+type UploadHealth struct {
+	// ActivePath is the path used for the next upload; empty means the account's primary path
+	ActivePath UploadPath `json:"active_path,omitempty"`
+
+	// Reason explains why ActivePath differs from the primary path
+	Reason string `json:"reason,omitempty"`
+
+	// RetryPrimaryAt is when the primary path is tried again after a failover
+	RetryPrimaryAt *time.Time `json:"retry_primary_at,omitempty"`
+
+	// Paths holds success/failure counters per upload path
+	Paths map[UploadPath]*UploadPathStats `json:"paths,omitempty"`
+}
+
+// UploadPathStats counts upload outcomes on one path
+type UploadPathStats struct {
+	Successes           int        `json:"successes"`
+	Failures            int        `json:"failures"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorClass      string     `json:"last_error_class,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+}
+
+// Stats returns the counters for a path, creating them on first use
+func (h *UploadHealth) Stats(path UploadPath) *UploadPathStats {
+	if h.Paths == nil {
+		h.Paths = make(map[UploadPath]*UploadPathStats)
+	}
+	stats, ok := h.Paths[path]
+	if !ok {
+		stats = &UploadPathStats{}
+		h.Paths[path] = stats
+	}
+	return stats
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// UploadWindow is the time of day an account's uploads are allowed in, as minutes after midnight
+// in Location. An End before Start spans midnight.
+type UploadWindow struct {
+	Start    int
+	End      int
+	Location *time.Location
+}
+
+// UploadWindow parses the account's upload window. It returns nil when the account has none, and
+// an error for a window or timezone that cannot be used.
+func (s AccountSettings) UploadWindow() (*UploadWindow, error) {
+	if s.UploadWindowStart == "" && s.UploadWindowEnd == "" {
+		return nil, nil
+	}
+	if s.UploadWindowStart == "" || s.UploadWindowEnd == "" {
+		return nil, errors.New("upload_window_start and upload_window_end must be set together")
+	}
+	start, err := parseClockTime(s.UploadWindowStart)
+	if err != nil {
+		return nil, fmt.Errorf("upload_window_start: %w", err)
+	}
+	end, err := parseClockTime(s.UploadWindowEnd)
+	if err != nil {
+		return nil, fmt.Errorf("upload_window_end: %w", err)
+	}
+	if start == end {
+		return nil, errors.New("upload_window_start and upload_window_end must differ")
+	}
+
+	location := time.Local
+	if s.Timezone != "" {
+		if location, err = time.LoadLocation(s.Timezone); err != nil {
+			return nil, fmt.Errorf("timezone %q: %w", s.Timezone, err)
+		}
+	}
+	return &UploadWindow{Start: start, End: end, Location: location}, nil
+}
+
+// Contains reports whether t falls in the window
+func (w *UploadWindow) Contains(t time.Time) bool {
+	local := t.In(w.Location)
+	minute := local.Hour()*60 + local.Minute()
+	if w.Start < w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	return minute >= w.Start || minute < w.End
+}
+
+// NextOpen returns when the window next opens after t
+func (w *UploadWindow) NextOpen(t time.Time) time.Time {
+	local := t.In(w.Location)
+	open := time.Date(local.Year(), local.Month(), local.Day(), w.Start/60, w.Start%60, 0, 0, w.Location)
+	if !open.After(local) {
+		open = time.Date(local.Year(), local.Month(), local.Day()+1, w.Start/60, w.Start%60, 0, 0, w.Location)
+	}
+	return open
+}
+
+// String describes the window, e.g. "18:00-22:00 Asia/Tokyo"
+func (w *UploadWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d %s", w.Start/60, w.Start%60, w.End/60, w.End%60, w.Location)
+}
+
+// parseClockTime reads an "HH:MM" time of day as minutes after midnight
+func parseClockTime(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time of day like 18:00", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
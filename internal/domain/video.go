@@ -1,6 +1,11 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
 
 // VideoStatus represents the processing status of a video
 type VideoStatus string
@@ -18,11 +23,57 @@ const (
 	// VideoStatusUploading indicates the video is currently being uploaded
 	VideoStatusUploading VideoStatus = "uploading"
 
+	// VideoStatusPublishing indicates TikTok accepted the upload and is still processing it
+	VideoStatusPublishing VideoStatus = "publishing"
+
 	// VideoStatusCompleted indicates the video has been successfully uploaded
 	VideoStatusCompleted VideoStatus = "completed"
 
 	// VideoStatusFailed indicates the video processing failed
 	VideoStatusFailed VideoStatus = "failed"
+
+	// VideoStatusSkipped indicates the video was filtered out by the account settings
+	VideoStatusSkipped VideoStatus = "skipped"
+
+	// VideoStatusAwaitingReview indicates the content-safety check held the video for an operator
+	// to approve before it is published
+	VideoStatusAwaitingReview VideoStatus = "awaiting_review"
+
+	// VideoStatusGated indicates the video waits for the account's min_views on YouTube before it
+	// is queued; it moves to pending when it gets there and to skipped when the window runs out
+	VideoStatusGated VideoStatus = "gated"
+
+	// VideoStatusWaitingWindow indicates the video is downloaded and waits for its account's
+	// upload window to open; each processing run moves it back to pending once it has
+	VideoStatusWaitingWindow VideoStatus = "waiting_window"
+)
+
+// IsValid reports whether the status is one of the known video statuses
+func (s VideoStatus) IsValid() bool {
+	switch s {
+	case VideoStatusPending, VideoStatusDownloading, VideoStatusDownloaded, VideoStatusUploading,
+		VideoStatusPublishing, VideoStatusCompleted, VideoStatusFailed, VideoStatusSkipped,
+		VideoStatusAwaitingReview, VideoStatusGated, VideoStatusWaitingWindow:
+		return true
+	}
+	return false
+}
+
+// SafetyDecision is the outcome of the content-safety check before a video is published
+type SafetyDecision string
+
+const (
+	// SafetyDecisionAllow lets the video be published
+	SafetyDecisionAllow SafetyDecision = "allow"
+
+	// SafetyDecisionDeny stops the video; it is marked skipped
+	SafetyDecisionDeny SafetyDecision = "deny"
+
+	// SafetyDecisionReview holds the video in awaiting_review until an operator approves it
+	SafetyDecisionReview SafetyDecision = "review"
+
+	// SafetyDecisionApproved is an operator's override of deny or review; the check is not run again
+	SafetyDecisionApproved SafetyDecision = "approved"
 )
 
 // Video represents a video that needs to be processed
@@ -66,31 +117,355 @@ type Video struct {
 	// UpdatedAt is the timestamp when the video was last updated
 	UpdatedAt time.Time
 
-	// PublishedAt is the timestamp when the video was published on YouTube
+	// PublishedAt is the timestamp when the video was published on YouTube; zero means unknown
 	PublishedAt time.Time
+
+	// CompletedAt is the timestamp when the video finished uploading to TikTok
+	CompletedAt time.Time
+
+	// DownloadedAt is when the local file became ready (downloaded, or cut for clips)
+	DownloadedAt time.Time
+
+	// UploadedAt is when the TikTok upload returned a video ID
+	UploadedAt time.Time
+
+	// DownloadDuration and UploadDuration are the time spent in each step (zero if not recorded)
+	DownloadDuration time.Duration
+	UploadDuration   time.Duration
+
+	// ParentVideoID is set on clips cut from a longer source video and on experiment arms
+	ParentVideoID string
+
+	// ClipStart and ClipEnd bound a clip within its parent video (both zero for experiment
+	// arms, which post the whole source)
+	ClipStart time.Duration
+	ClipEnd   time.Duration
+
+	// ClipCount is the number of clips (or experiment arms) a source video was split into. A
+	// video with clips is not uploaded itself; its status reflects the progress of its clips.
+	ClipCount int
+
+	// UploadRoute is the path chosen for the latest upload and UploadRouteReason why, e.g. that
+	// the file was too large for the preferred path (both empty before the first upload)
+	UploadRoute       UploadPath
+	UploadRouteReason string
+
+	// Immediate marks a newly discovered video for processing ahead of the schedule. It is set
+	// only when the video is first saved and cleared when a worker claims the video.
+	Immediate bool
+
+	// Priority orders the pending queue: higher goes first. Discovery raises it for videos
+	// published recently, and it can be changed by hand. It is set when the video is first saved
+	// and written afterwards only through UpdatePriority.
+	Priority int
+
+	// SafetyDecision is the outcome of the content-safety check (empty until checked) and
+	// SafetyRule what triggered a deny or review, e.g. a denylist entry or the moderation
+	// service's reason. They are written only through UpdateSafetyDecision, never by Save.
+	SafetyDecision SafetyDecision
+	SafetyRule     string
+
+	// TikTokPostURL is the public link of the TikTok post, recorded when the video is completed
+	// (empty for drafts and web uploads, which have no post ID). It is written only through
+	// UpdateTikTokPostURL, never by Save.
+	TikTokPostURL string
+
+	// PostOptions overrides the account's TikTok post settings for this video (nil keeps them all).
+	// It is set when the video is first saved and written afterwards only through UpdatePostOptions.
+	PostOptions *PostOptions
+
+	// Duration is the video length when known (filled during discovery, not persisted)
+	Duration time.Duration
+
+	// Definition ("hd" or "sd"), LiveBroadcastContent ("none", "live" or "upcoming") and the
+	// region restriction are filled during discovery when a YouTube API key is set, not persisted
+	Definition           string
+	LiveBroadcastContent string
+	RegionAllowed        []string
+	RegionBlocked        []string
+
+	// CommentPosted indicates the post-publish comment was created on TikTok
+	CommentPosted bool
+
+	// CommentError contains details if the post-publish comment failed
+	CommentError string
+
+	// TitleLanguage is the detected language of Title (empty until a translation was attempted)
+	TitleLanguage string
+
+	// TranslatedTitle caches Title translated into TranslatedLanguage for the caption
+	TranslatedTitle    string
+	TranslatedLanguage string
+
+	// UploadAttemptID identifies the latest upload attempt; it is stored before TikTok is called
+	UploadAttemptID string
+
+	// UploadPublishID is the TikTok publish ID of the latest attempt, stored as soon as TikTok
+	// assigns it so a retry after a crash can check whether that attempt was published
+	UploadPublishID string
+
+	// ContentHash is the SHA-256 of the uploaded file, used to refuse uploading identical content
+	// to the same account twice
+	ContentHash string
+
+	// Cost is what processing the video consumed so far; it is written only through AddCost
+	Cost VideoCost
+
+	// ViewCount is the YouTube view count seen at ViewsCheckedAt (zero time when never read). They
+	// are set when the video is first saved and written afterwards only through UpdateViewCount.
+	ViewCount      int64
+	ViewsCheckedAt time.Time
+
+	// DownloadWidth, DownloadHeight and DownloadFPS describe the video stream of the downloaded
+	// file as probed after the download (zero when not probed); written only through
+	// UpdateDownloadFormat
+	DownloadWidth  int
+	DownloadHeight int
+	DownloadFPS    float64
+
+	// Progress is the percentage of the current download or upload done. UpdateStatus resets it,
+	// so it always refers to the phase the status names; written only through UpdateProgress.
+	Progress int
+}
+
+// Clone returns a copy of the video that shares no state with it, or nil for a nil video. The
+// video has no slice or map fields; copy them here when it gains any.
+func (v *Video) Clone() *Video {
+	if v == nil {
+		return nil
+	}
+	clone := *v
+	clone.RegionAllowed = slices.Clone(v.RegionAllowed)
+	clone.RegionBlocked = slices.Clone(v.RegionBlocked)
+	clone.PostOptions = v.PostOptions.Clone()
+	return &clone
+}
+
+// PostOptions overrides an account's TikTok post settings for one video; unset fields keep the
+// account's values
+type PostOptions struct {
+	// PrivacyLevel replaces AccountSettings.PrivacyLevel when not empty
+	PrivacyLevel string `json:"privacy_level,omitempty"`
+
+	// DisableComment, DisableDuet and DisableStitch replace the account's flags when set
+	DisableComment *bool `json:"disable_comment,omitempty"`
+	DisableDuet    *bool `json:"disable_duet,omitempty"`
+	DisableStitch  *bool `json:"disable_stitch,omitempty"`
+}
+
+// Clone returns a deep copy of the options (nil for nil)
+func (o *PostOptions) Clone() *PostOptions {
+	if o == nil {
+		return nil
+	}
+	clone := *o
+	for _, flag := range []**bool{&clone.DisableComment, &clone.DisableDuet, &clone.DisableStitch} {
+		if *flag != nil {
+			value := **flag
+			*flag = &value
+		}
+	}
+	return &clone
+}
+
+// IsZero reports whether the options override nothing
+func (o *PostOptions) IsZero() bool {
+	return o == nil || (o.PrivacyLevel == "" && o.DisableComment == nil && o.DisableDuet == nil && o.DisableStitch == nil)
+}
+
+// SourceYouTubeID returns the YouTube video the video was made from: clips and experiment arms
+// carry a "#clipN" or "#armN" suffix on their parent's ID
+func (v *Video) SourceYouTubeID() string {
+	id, _, _ := strings.Cut(v.YouTubeVideoID, "#")
+	return id
+}
+
+// PublishedOrCreatedAt returns PublishedAt, or CreatedAt when the publish time is unknown
+func (v *Video) PublishedOrCreatedAt() time.Time {
+	if v.PublishedAt.IsZero() {
+		return v.CreatedAt
+	}
+	return v.PublishedAt
+}
+
+// VideoFilter narrows and pages video listings
+type VideoFilter struct {
+	// Status restricts results to one status (optional)
+	Status VideoStatus
+
+	// Limit is the maximum number of results (0 means no limit)
+	Limit int
+
+	// Offset skips this many results
+	Offset int
+}
+
+// UploadStats summarizes an account's recent completed uploads
+type UploadStats struct {
+	// Count is the number of uploads completed since the requested time
+	Count int
+
+	// LastCompletedAt is the most recent completed upload (zero if none ever)
+	LastCompletedAt time.Time
 }
 
 // VideoRepository defines the interface for video data operations
 type VideoRepository interface {
-	// GetByYouTubeID returns a video by its YouTube ID
-	GetByYouTubeID(youtubeID string) (*Video, error)
+	// GetByID returns a video by its ID
+	GetByID(ctx context.Context, id string) (*Video, error)
+
+	// GetByYouTubeIDAndAccount returns an account's copy of a YouTube video. A video cross-posted
+	// by several mapped channels is stored once per account.
+	GetByYouTubeIDAndAccount(ctx context.Context, youtubeID, accountID string) (*Video, error)
+
+	// GetClips returns the clips cut from a parent video, in clip order
+	GetClips(ctx context.Context, parentID string) ([]*Video, error)
+
+	// GetPendingVideos returns pending videos that can be uploaded (videos split into clips are
+	// excluded): videos marked immediate first, then by priority, then newest published first
+	GetPendingVideos(ctx context.Context, limit int) ([]*Video, error)
+
+	// ClaimPending claims up to limit pending videos for owner until lease ends and returns them
+	// in GetPendingVideos order. Videos another owner claimed are skipped until that claim is
+	// released or its lease ends, so concurrent callers, also in other processes sharing the
+	// database, never get the same video.
+	ClaimPending(ctx context.Context, owner string, limit int, lease time.Duration) ([]*Video, error)
+
+	// ClaimVideo claims one pending video for owner until lease ends, unless another owner's claim
+	// on it is still running, and reports whether owner holds it. owner renews its own claim.
+	ClaimVideo(ctx context.Context, id string, owner string, lease time.Duration) (bool, error)
+
+	// ReleaseClaim ends owner's claim on a video; claims of other owners are left alone
+	ReleaseClaim(ctx context.Context, id string, owner string) error
+
+	// UpdatePriority sets a video's queue priority
+	UpdatePriority(ctx context.Context, id string, priority int) error
+
+	// UpdateSafetyDecision records the content-safety decision and the rule that triggered it
+	UpdateSafetyDecision(ctx context.Context, id string, decision SafetyDecision, rule string) error
+
+	// UpdateTikTokPostURL records the public link of the video's TikTok post
+	UpdateTikTokPostURL(ctx context.Context, id string, postURL string) error
+
+	// UpdatePostOptions replaces the video's TikTok post overrides (nil clears them)
+	UpdatePostOptions(ctx context.Context, id string, options *PostOptions) error
+
+	// UpdateViewCount records the YouTube view count read at checkedAt
+	UpdateViewCount(ctx context.Context, id string, views int64, checkedAt time.Time) error
+
+	// UpdateDownloadFormat records the resolution and frame rate of the downloaded file
+	UpdateDownloadFormat(ctx context.Context, id string, width, height int, fps float64) error
+
+	// UpdateProgress records the percentage of the video's current download or upload done
+	UpdateProgress(ctx context.Context, id string, progress int) error
 
-	// GetPendingVideos returns all videos with pending status
-	GetPendingVideos(limit int) ([]*Video, error)
+	// GetImmediateVideos returns pending videos marked immediate, oldest first
+	GetImmediateVideos(ctx context.Context, limit int) ([]*Video, error)
+
+	// ClaimImmediate clears a pending video's immediate mark and reports whether this call
+	// cleared it, so only one worker picks the video up
+	ClaimImmediate(ctx context.Context, id string) (bool, error)
 
 	// CountPending returns the total number of pending videos
-	CountPending() (int, error)
+	CountPending(ctx context.Context) (int, error)
+
+	// GetWithoutPublishedAt returns videos whose YouTube publish time is unknown, oldest first
+	GetWithoutPublishedAt(ctx context.Context) ([]*Video, error)
+
+	// GetWithFilePath returns videos whose local file path is set
+	GetWithFilePath(ctx context.Context) ([]*Video, error)
+
+	// GetByAccountID returns an account's videos, newest published first
+	GetByAccountID(ctx context.Context, accountID string, filter VideoFilter) ([]*Video, error)
+
+	// GetRecent returns videos of all accounts, most recently updated first
+	GetRecent(ctx context.Context, filter VideoFilter) ([]*Video, error)
+
+	// Iterate calls fn for every video of all accounts matching filter.Status whose ID sorts after
+	// the after cursor ("" starts at the beginning), in ID order. Videos are loaded a page at a
+	// time, so the whole table is never held in memory; filter.Limit and filter.Offset are
+	// ignored. An error from fn stops the iteration and is returned.
+	Iterate(ctx context.Context, filter VideoFilter, after string, fn func(video *Video) error) error
+
+	// CountByStatus returns the number of videos per status for an account
+	CountByStatus(ctx context.Context, accountID string) (map[VideoStatus]int, error)
+
+	// GetUploadStats counts an account's uploads completed since the given time and
+	// returns its most recent completion time (videos split into clips are not uploads)
+	GetUploadStats(ctx context.Context, accountID string, since time.Time) (*UploadStats, error)
+
+	// GetCompletedSince returns uploads (videos split into clips excluded) completed at or after
+	// the given time, oldest first
+	GetCompletedSince(ctx context.Context, since time.Time) ([]*Video, error)
+
+	// GetMissingTikTokPostURL returns completed videos that have a TikTok video ID but no post URL
+	GetMissingTikTokPostURL(ctx context.Context) ([]*Video, error)
 
 	// Save creates or updates a video
-	Save(video *Video) error
+	Save(ctx context.Context, video *Video) error
+
+	// RequeueInterrupted puts videos left downloading, downloaded or uploading by a process that
+	// stopped mid-step back to pending with the given message, clears their claim and returns how
+	// many it moved. Videos another process may still be working on are left alone: only unclaimed
+	// videos, claims whose lease ended and, when host is set, claims of owners named "host:..."
+	// from an earlier process on host are moved. Videos split into clips keep the status derived
+	// from their clips.
+	RequeueInterrupted(ctx context.Context, host string, errorMsg string) (int, error)
+
+	// PruneFinished deletes completed and failed videos last updated and published (or created,
+	// when the publish time is unknown) before the given time, and returns how many it deleted.
+	// A source split into clips and its clips are deleted together or not at all, so a video
+	// with a clip still in the queue is never removed, and neither is an experiment's source.
+	PruneFinished(ctx context.Context, before time.Time) (int, error)
 
 	// UpdateStatus updates the video status
-	UpdateStatus(id string, status VideoStatus, errorMsg string) error
+	UpdateStatus(ctx context.Context, id string, status VideoStatus, errorMsg string) error
+
+	// ForceStatus sets an administrator's outcome in one write: the status and message, the TikTok
+	// video ID when not empty, and no immediate mark. It is the explicit path for changes the
+	// pipeline would not make itself.
+	ForceStatus(ctx context.Context, id string, status VideoStatus, message string, tiktokID string) error
+
+	// UpdatePublishedAt stores the YouTube publish time of a video
+	UpdatePublishedAt(ctx context.Context, id string, publishedAt time.Time) error
 
 	// UpdateFilePath updates the local file path
-	UpdateFilePath(id string, filePath string) error
+	UpdateFilePath(ctx context.Context, id string, filePath string) error
 
 	// UpdateTikTokID updates the TikTok video ID
-	UpdateTikTokID(id string, tiktokID string) error
-}
+	UpdateTikTokID(ctx context.Context, id string, tiktokID string) error
+
+	// MarkDownloaded stores the downloaded file's path, when it became ready and how long it took,
+	// and moves the video to downloaded, in one write
+	MarkDownloaded(ctx context.Context, id string, filePath string, downloadedAt time.Time, duration time.Duration) error
+
+	// MarkUploaded stores the TikTok video ID, when the upload finished and how long it took, in
+	// one write
+	MarkUploaded(ctx context.Context, id string, tiktokID string, uploadedAt time.Time, duration time.Duration) error
+
+	// UpdateCommentResult records the outcome of the post-publish comment step
+	UpdateCommentResult(ctx context.Context, id string, posted bool, errorMsg string) error
+
+	// UpdateTranslation caches the title's detected language and its translation
+	UpdateTranslation(ctx context.Context, id string, titleLanguage, translatedLanguage, translatedTitle string) error
 
+	// StartUploadAttempt records a new upload attempt and the file's content hash, clearing the
+	// previous attempt's publish ID
+	StartUploadAttempt(ctx context.Context, id string, attemptID string, contentHash string) error
+
+	// SetUploadPublishID stores the TikTok publish ID of the current upload attempt
+	SetUploadPublishID(ctx context.Context, id string, attemptID string, publishID string) error
+
+	// SetUploadRoute records the upload path chosen for the video and why
+	SetUploadRoute(ctx context.Context, id string, path UploadPath, reason string) error
+
+	// AddCost adds to the video's cost counters
+	AddCost(ctx context.Context, id string, cost VideoCost) error
+
+	// GetAccountUsage sums the costs of an account's videos created in [from, to)
+	GetAccountUsage(ctx context.Context, accountID string, from, to time.Time) (*AccountUsage, error)
+
+	// FindCompletedByContentHash returns a completed video of the account, other than excludeID,
+	// whose file had the given hash, or nil
+	FindCompletedByContentHash(ctx context.Context, accountID string, contentHash string, excludeID string) (*Video, error)
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// VideoCost is what processing a video consumed. Counters only grow: they are added to as the
+// work happens and are never replaced by saving the video.
+type VideoCost struct {
+	// APIUnits is the YouTube Data API quota spent on the video itself (discovery, which serves
+	// the whole channel, is not attributed to single videos)
+	APIUnits int64
+
+	// DownloadBytes and UploadBytes are the bytes of successful downloads and uploads
+	DownloadBytes int64
+	UploadBytes   int64
+
+	// ProcessingTime is the wall time spent processing the video, over all attempts
+	ProcessingTime time.Duration
+
+	// Retries counts repeated work: download attempts after the first, uploads retried on the
+	// fallback path and processing runs after the first
+	Retries int
+}
+
+// Add returns the sum of two costs
+func (c VideoCost) Add(other VideoCost) VideoCost {
+	return VideoCost{
+		APIUnits:       c.APIUnits + other.APIUnits,
+		DownloadBytes:  c.DownloadBytes + other.DownloadBytes,
+		UploadBytes:    c.UploadBytes + other.UploadBytes,
+		ProcessingTime: c.ProcessingTime + other.ProcessingTime,
+		Retries:        c.Retries + other.Retries,
+	}
+}
+
+// AccountUsage is the cost of an account's videos created in a period
+type AccountUsage struct {
+	// Videos is the number of videos created in the period and Completed how many of them were
+	// published (videos split into clips are counted as neither; their clips are)
+	Videos    int
+	Completed int
+
+	// Cost is the sum of the videos' costs, including videos split into clips
+	Cost VideoCost
+}
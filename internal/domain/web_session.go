@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// WebSession is the TikTok login held by a web upload cookies file, as TikTok reported it when
+// the cookies were captured
+type WebSession struct {
+	// CookiesPath is the cookies file the session is stored in
+	CookiesPath string
+
+	// UserID is TikTok's numeric web user ID of the login
+	UserID string
+
+	// Username is the login's unique @name, without the @
+	Username string
+
+	// Nickname is the login's display name
+	Nickname string
+
+	// AccountID is the account mapping the cookies were captured for, empty when none was named
+	AccountID string
+
+	// CapturedAt is when the cookies were captured and checked
+	CapturedAt time.Time
+}
+
+// WebSessionClaim records which host last used a cookies file. TikTok signs a session out
+// everywhere when it is used from two addresses, so instances sharing the database check it
+// before each use.
+type WebSessionClaim struct {
+	// CookiesPath is the cookies file the claim is for
+	CookiesPath string
+
+	// Host identifies the instance that last used the cookies, and UsedAt when
+	Host   string
+	UsedAt time.Time
+
+	// ConflictHost is the host that contended with Host for the cookies at ConflictAt; both are
+	// empty when no conflict was seen
+	ConflictHost string
+	ConflictAt   time.Time
+}
+
+// WebSessionRepository stores the login behind each cookies file
+type WebSessionRepository interface {
+	// Get returns the session of a cookies file, or nil when none was recorded
+	Get(ctx context.Context, cookiesPath string) (*WebSession, error)
+
+	// Save records the session of a cookies file, replacing the earlier one
+	Save(ctx context.Context, session *WebSession) error
+
+	// GetClaim returns the claim of a cookies file, or nil when it was never used
+	GetClaim(ctx context.Context, cookiesPath string) (*WebSessionClaim, error)
+
+	// Claim records that host uses the cookies file at now, in one atomic step. When another
+	// host used it after since, the conflict is recorded and that host keeps the claim unless
+	// takeOver is set. It returns the claim as it was before, or nil when there was none.
+	Claim(ctx context.Context, cookiesPath, host string, now, since time.Time, takeOver bool) (*WebSessionClaim, error)
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// YouTubePlaylist is what is kept about a channel's uploads playlist so monitoring spares Data API
+// calls: its ID, which never changes, and the ETag of a first page that had nothing new
+type YouTubePlaylist struct {
+	// ChannelID is the canonical UC... channel ID
+	ChannelID string
+
+	// UploadsPlaylistID is the channel's uploads playlist
+	UploadsPlaylistID string
+
+	// ETag is the entity tag of the playlist's first page when it held no video published after
+	// CheckedAfter; empty when there is none
+	ETag string
+
+	// CheckedAfter is the cutoff the ETag's page was checked against. A 304 for a later cutoff
+	// means nothing new, for an earlier one it does not.
+	CheckedAfter time.Time
+
+	// UpdatedAt is when the entry was last written
+	UpdatedAt time.Time
+}
+
+// YouTubePlaylistRepository stores the uploads playlists of channels
+type YouTubePlaylistRepository interface {
+	// Get returns a channel's uploads playlist, or nil when it was never looked up
+	Get(ctx context.Context, channelID string) (*YouTubePlaylist, error)
+
+	// Save stores a channel's uploads playlist, replacing the earlier entry
+	Save(ctx context.Context, entry *YouTubePlaylist) error
+}
@@ -0,0 +1,147 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"auto_upload_tiktok/internal/logger"
+)
+
+// ErrInsufficientDiskSpace is returned when the download directory's filesystem is too full
+// to start a download. It is temporary: the download can succeed once space is freed.
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space")
+
+// errDiskSpaceUnsupported means free space cannot be queried on this platform
+var errDiskSpaceUnsupported = errors.New("disk space check not supported on this platform")
+
+// ensureDiskSpace trims the download directory to download.max_dir_size and then checks
+// that download.min_free_space plus the expected video size (0 if unknown) is available
+func (s *Service) ensureDiskSpace(expectedSize int64) error {
+	if s.config.MaxDownloadDirSize > 0 {
+		if err := s.trimDownloads(s.config.MaxDownloadDirSize - expectedSize); err != nil {
+			logger.Error().Printf("Failed to trim download directory: %v", err)
+		}
+	}
+
+	if s.config.MinFreeSpace <= 0 {
+		return nil
+	}
+	free, err := freeDiskSpace(s.downloadDir)
+	if err != nil {
+		if !errors.Is(err, errDiskSpaceUnsupported) {
+			logger.Error().Printf("Failed to check free space in %s: %v", s.downloadDir, err)
+		}
+		return nil
+	}
+
+	required := uint64(s.config.MinFreeSpace)
+	if expectedSize > 0 {
+		required += uint64(expectedSize)
+	}
+	if free < required {
+		return fmt.Errorf("%w: %s free in %s, need %s", ErrInsufficientDiskSpace,
+			formatBytes(free), s.downloadDir, formatBytes(required))
+	}
+	return nil
+}
+
+// CheckFreeSpace reports the free space of the download directory's filesystem, failing with
+// ErrInsufficientDiskSpace when less than download.min_free_space is left. Platforms that cannot
+// query free space pass.
+func (s *Service) CheckFreeSpace() (string, error) {
+	free, err := freeDiskSpace(s.downloadDir)
+	if err != nil {
+		if errors.Is(err, errDiskSpaceUnsupported) {
+			return "free space cannot be checked on this platform", nil
+		}
+		return "", fmt.Errorf("failed to check free space in %s: %w", s.downloadDir, err)
+	}
+	if s.config.MinFreeSpace > 0 && free < uint64(s.config.MinFreeSpace) {
+		return "", fmt.Errorf("%w: %s free in %s, need %s", ErrInsufficientDiskSpace,
+			formatBytes(free), s.downloadDir, formatBytes(uint64(s.config.MinFreeSpace)))
+	}
+	return fmt.Sprintf("%s free in %s", formatBytes(free), s.downloadDir), nil
+}
+
+// trimDownloads removes the oldest finished downloads until the directory fits in maxBytes.
+// Subdirectories (clip sources and cut clips) count towards the size but are never removed,
+// nor are files touched within the upload timeout since they may still be waiting to upload,
+// nor protected files.
+func (s *Service) trimDownloads(maxBytes int64) error {
+	type download struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var (
+		total      int64
+		candidates []download
+	)
+	cutoff := time.Now().Add(-s.config.UploadTimeout)
+	protected := make(map[string]bool)
+	if s.protectedFiles != nil {
+		for _, path := range s.protectedFiles() {
+			protected[filepath.Clean(path)] = true
+		}
+	}
+	err := filepath.WalkDir(s.downloadDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+		total += info.Size()
+		if filepath.Dir(path) == filepath.Clean(s.downloadDir) && info.ModTime().Before(cutoff) && !protected[filepath.Clean(path)] {
+			candidates = append(candidates, download{path: path, size: info.Size(), modTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if total <= maxBytes {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].modTime.Before(candidates[j].modTime) })
+	for _, candidate := range candidates {
+		if total <= maxBytes {
+			break
+		}
+		if err := os.Remove(candidate.path); err != nil {
+			logger.Error().Printf("Failed to remove old download %s: %v", candidate.path, err)
+			continue
+		}
+		total -= candidate.size
+		logger.Info().Printf("Removed old download %s (%s) to stay under download.max_dir_size", filepath.Base(candidate.path), formatBytes(uint64(candidate.size)))
+	}
+	if total > maxBytes {
+		logger.Error().Printf("Download directory is %s, still over download.max_dir_size after cleanup", formatBytes(uint64(total)))
+	}
+	return nil
+}
+
+// formatBytes renders a byte count for logs and errors
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package downloader
+
+// freeDiskSpace is not implemented on this platform; the free space check is skipped
+func freeDiskSpace(dir string) (uint64, error) {
+	return 0, errDiskSpaceUnsupported
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package downloader
+
+import "golang.org/x/sys/windows"
+
+// freeDiskSpace returns the bytes available to the current user on dir's volume
+func freeDiskSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &available, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,66 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// frameHeight is the height frames are scaled to; enough for moderation, small to send
+const frameHeight = 480
+
+// ExtractFrames grabs count JPEG frames spread evenly over the video with ffmpeg. Seeking before
+// the input makes ffmpeg start decoding at the nearest keyframe, so a frame costs little however
+// long the video is.
+func (s *Service) ExtractFrames(ctx context.Context, path string, count int) ([][]byte, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+	duration, err := s.ProbeDuration(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	ffmpegPath := s.config.FFmpegPath
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+
+	frames := make([][]byte, 0, count)
+	for i := 1; i <= count; i++ {
+		at := duration * time.Duration(i) / time.Duration(count+1)
+		args := []string{
+			"-v", "error",
+			"-ss", formatSeconds(at),
+			"-i", path,
+			"-frames:v", "1",
+			"-vf", fmt.Sprintf("scale=-2:%d", frameHeight),
+			"-f", "image2",
+			"-c:v", "mjpeg",
+			"pipe:1",
+		}
+
+		var stdout bytes.Buffer
+		var stderr strings.Builder
+		cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := s.runLimited(cmd, "ffmpeg", &stderr); err != nil {
+			if errors.Is(err, ErrResourceLimit) {
+				return nil, err
+			}
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("ffmpeg frame at %s failed: %w: %s", at.Round(time.Millisecond), err, msg)
+			}
+			return nil, fmt.Errorf("ffmpeg frame at %s failed: %w", at.Round(time.Millisecond), err)
+		}
+		if stdout.Len() > 0 {
+			frames = append(frames, stdout.Bytes())
+		}
+	}
+	return frames, nil
+}
@@ -0,0 +1,41 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProbeDuration returns the length of a video file as reported by ffprobe. ffprobe is looked up
+// next to download.ffmpeg_path, or on PATH when that is not set.
+func (s *Service) ProbeDuration(ctx context.Context, path string) (time.Duration, error) {
+	args := []string{
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	}
+	out, err := exec.CommandContext(ctx, s.ffprobePath(), args...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("ffprobe reported no duration for %s", filepath.Base(path))
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ffprobePath returns the ffprobe binary that ships with the configured ffmpeg
+func (s *Service) ffprobePath() string {
+	if s.config.FFmpegPath == "" {
+		return "ffprobe"
+	}
+	dir, name := filepath.Split(s.config.FFmpegPath)
+	return dir + strings.Replace(name, "ffmpeg", "ffprobe", 1)
+}
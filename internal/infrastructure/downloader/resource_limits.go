@@ -0,0 +1,95 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"auto_upload_tiktok/internal/logger"
+)
+
+// ErrResourceLimit is returned when yt-dlp or ffmpeg was stopped for going over
+// download.resource_limits.memory_max. Retrying the same video would hit the limit again.
+var ErrResourceLimit = errors.New("subprocess exceeded its resource limit")
+
+// errResourceLimitsUnsupported means subprocesses cannot be limited on this platform
+var errResourceLimitsUnsupported = errors.New("resource limits not supported on this platform")
+
+// limitsWarning makes sure a platform without limit support is only reported once
+var limitsWarning sync.Once
+
+// resourceLimits are the download.resource_limits settings; zero values leave a limit off
+type resourceLimits struct {
+	cpuWeight    int
+	memoryMax    int64
+	cgroupParent string
+}
+
+func (l resourceLimits) enabled() bool {
+	return l.cpuWeight > 0 || l.memoryMax > 0
+}
+
+// subprocessLimiter confines one subprocess and the processes it starts
+type subprocessLimiter interface {
+	// attach places the started process under the limits
+	attach(pid int) error
+
+	// exceeded reports, after the process exited with an error, whether it hit the memory limit
+	exceeded(stderr string) bool
+
+	// close releases the limiter, stopping anything left running under it
+	close()
+}
+
+// resourceLimits returns the configured subprocess limits
+func (s *Service) resourceLimits() resourceLimits {
+	limits := resourceLimits{
+		cpuWeight:    s.config.SubprocessCPUWeight,
+		memoryMax:    s.config.SubprocessMemoryMax,
+		cgroupParent: s.config.SubprocessCgroupParent,
+	}
+	if limits.cpuWeight > 10000 {
+		limits.cpuWeight = 10000
+	}
+	if limits.cpuWeight < 0 {
+		limits.cpuWeight = 0
+	}
+	if limits.memoryMax < 0 {
+		limits.memoryMax = 0
+	}
+	return limits
+}
+
+// runLimited runs cmd like cmd.Run, under download.resource_limits when they are set. stderr is
+// the builder cmd writes its stderr to, used to recognise allocation failures.
+func (s *Service) runLimited(cmd *exec.Cmd, name string, stderr *strings.Builder) error {
+	limits := s.resourceLimits()
+	if !limits.enabled() {
+		return cmd.Run()
+	}
+
+	limiter, err := newSubprocessLimiter(limits, name)
+	if err != nil {
+		limitsWarning.Do(func() {
+			logger.Error().Printf("download.resource_limits are not applied: %v", err)
+		})
+		return cmd.Run()
+	}
+	defer limiter.close()
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := limiter.attach(cmd.Process.Pid); err != nil {
+		logger.Error().Printf("Failed to apply resource limits to %s (pid %d): %v", name, cmd.Process.Pid, err)
+	}
+
+	err = cmd.Wait()
+	if err != nil && limits.memoryMax > 0 && limiter.exceeded(stderr.String()) {
+		return fmt.Errorf("%w: %s was stopped at download.resource_limits.memory_max (%s): %v",
+			ErrResourceLimit, name, formatBytes(uint64(limits.memoryMax)), err)
+	}
+	return err
+}
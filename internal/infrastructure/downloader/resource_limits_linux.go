@@ -0,0 +1,187 @@
+//go:build linux
+
+package downloader
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"auto_upload_tiktok/internal/logger"
+)
+
+// cgroupRoot is where the cgroup v2 hierarchy is mounted
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupSeq keeps process cgroup names unique within this run
+var cgroupSeq atomic.Uint64
+
+// cgroupFallback makes sure the switch to rlimits is only reported once
+var cgroupFallback sync.Once
+
+// newSubprocessLimiter puts the subprocess in its own cgroup v2. Without a writable cgroup
+// (cgroup v1, or no delegation) it falls back to an address space rlimit and a lower priority.
+func newSubprocessLimiter(limits resourceLimits, name string) (subprocessLimiter, error) {
+	limiter, err := newCgroupLimiter(limits, name)
+	if err == nil {
+		return limiter, nil
+	}
+	cgroupFallback.Do(func() {
+		logger.Error().Printf("Cannot limit subprocesses with a cgroup (%v); using rlimits instead", err)
+	})
+	return &rlimitLimiter{limits: limits}, nil
+}
+
+// cgroupLimiter is a cgroup created for one subprocess and removed after it exits
+type cgroupLimiter struct {
+	dir string
+}
+
+func newCgroupLimiter(limits resourceLimits, name string) (*cgroupLimiter, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("cgroup v2 is not mounted at %s", cgroupRoot)
+	}
+
+	parent := limits.cgroupParent
+	if parent == "" {
+		own, err := ownCgroup()
+		if err != nil {
+			return nil, err
+		}
+		parent = own
+	}
+	if !strings.HasPrefix(parent, cgroupRoot+"/") {
+		parent = filepath.Join(cgroupRoot, parent)
+	}
+
+	// The controllers must be enabled for children; a cgroup holding processes itself refuses this
+	var controllers []string
+	if limits.memoryMax > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if limits.cpuWeight > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte(strings.Join(controllers, " ")), 0644); err != nil {
+		return nil, fmt.Errorf("enable controllers in %s: %w", parent, err)
+	}
+
+	dir := filepath.Join(parent, fmt.Sprintf("autoupload-%s-%d-%d", name, os.Getpid(), cgroupSeq.Add(1)))
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create cgroup: %w", err)
+	}
+
+	settings := map[string]string{}
+	if limits.memoryMax > 0 {
+		settings["memory.max"] = strconv.FormatInt(limits.memoryMax, 10)
+		settings["memory.oom.group"] = "1" // ffmpeg started by yt-dlp goes down with it
+	}
+	if limits.cpuWeight > 0 {
+		settings["cpu.weight"] = strconv.Itoa(limits.cpuWeight)
+	}
+	for file, value := range settings {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+			_ = os.Remove(dir)
+			return nil, fmt.Errorf("set %s: %w", file, err)
+		}
+	}
+	if limits.memoryMax > 0 {
+		// Without this the limit only pushes the process into swap
+		_ = os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0644)
+	}
+
+	return &cgroupLimiter{dir: dir}, nil
+}
+
+// attach moves the process into the cgroup; children it starts later inherit it
+func (l *cgroupLimiter) attach(pid int) error {
+	return os.WriteFile(filepath.Join(l.dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644)
+}
+
+// exceeded reads the OOM kills recorded for the cgroup
+func (l *cgroupLimiter) exceeded(string) bool {
+	file, err := os.Open(filepath.Join(l.dir, "memory.events"))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && (fields[0] == "oom_kill" || fields[0] == "oom_group_kill") && fields[1] != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// close stops leftover processes and removes the cgroup
+func (l *cgroupLimiter) close() {
+	_ = os.WriteFile(filepath.Join(l.dir, "cgroup.kill"), []byte("1"), 0644)
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		if err = os.Remove(l.dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond) // Killed processes may still be exiting
+	}
+	logger.Error().Printf("Failed to remove cgroup %s: %v", l.dir, err)
+}
+
+// ownCgroup returns this process's cgroup v2 path from /proc/self/cgroup
+func ownCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no cgroup v2 entry in /proc/self/cgroup")
+}
+
+// rlimitLimiter caps the address space and lowers the priority of the process. Both are inherited
+// by the processes it starts afterwards.
+type rlimitLimiter struct {
+	limits resourceLimits
+}
+
+// belowNormalNice is the priority given to subprocesses with a cpu_weight under the default 100
+const belowNormalNice = 10
+
+func (l *rlimitLimiter) attach(pid int) error {
+	if l.limits.memoryMax > 0 {
+		limit := uint64(l.limits.memoryMax)
+		if err := unix.Prlimit(pid, unix.RLIMIT_AS, &unix.Rlimit{Cur: limit, Max: limit}, nil); err != nil {
+			return fmt.Errorf("set address space limit: %w", err)
+		}
+	}
+	// An unprivileged process can only lower priority, so weights of 100 and more change nothing
+	if l.limits.cpuWeight > 0 && l.limits.cpuWeight < 100 {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, pid, belowNormalNice); err != nil {
+			return fmt.Errorf("lower priority: %w", err)
+		}
+	}
+	return nil
+}
+
+// exceeded recognises the allocation failures yt-dlp (Python) and ffmpeg report at the rlimit
+func (l *rlimitLimiter) exceeded(stderr string) bool {
+	return strings.Contains(stderr, "MemoryError") ||
+		strings.Contains(stderr, "Cannot allocate memory") ||
+		strings.Contains(stderr, "std::bad_alloc") ||
+		strings.Contains(stderr, "Out of memory")
+}
+
+func (l *rlimitLimiter) close() {}
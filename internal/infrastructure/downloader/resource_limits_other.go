@@ -0,0 +1,8 @@
+//go:build !linux && !windows
+
+package downloader
+
+// newSubprocessLimiter is not implemented on this platform; subprocesses run without limits
+func newSubprocessLimiter(limits resourceLimits, name string) (subprocessLimiter, error) {
+	return nil, errResourceLimitsUnsupported
+}
@@ -0,0 +1,68 @@
+//go:build windows
+
+package downloader
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// jobLimiter is a Job Object holding one subprocess and the processes it starts
+type jobLimiter struct {
+	job    windows.Handle
+	limits resourceLimits
+}
+
+// newSubprocessLimiter creates a Job Object with the memory limit and, for a cpu_weight under
+// the default 100, a below-normal priority class
+func newSubprocessLimiter(limits resourceLimits, name string) (subprocessLimiter, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create job object: %w", err)
+	}
+
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if limits.memoryMax > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_JOB_MEMORY
+		info.JobMemoryLimit = uintptr(limits.memoryMax)
+	}
+	if limits.cpuWeight > 0 && limits.cpuWeight < 100 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_PRIORITY_CLASS
+		info.BasicLimitInformation.PriorityClass = windows.BELOW_NORMAL_PRIORITY_CLASS
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("set job limits: %w", err)
+	}
+
+	return &jobLimiter{job: job, limits: limits}, nil
+}
+
+func (l *jobLimiter) attach(pid int) error {
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("open process: %w", err)
+	}
+	defer windows.CloseHandle(process)
+	return windows.AssignProcessToJobObject(l.job, process)
+}
+
+// exceeded reports whether the job's peak memory reached the limit; Windows refuses the
+// allocation rather than killing the process, which then fails on its own
+func (l *jobLimiter) exceeded(string) bool {
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	if err := windows.QueryInformationJobObject(l.job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)), nil); err != nil {
+		return false
+	}
+	return uint64(info.PeakJobMemoryUsed) >= uint64(l.limits.memoryMax)*95/100
+}
+
+// close kills anything still in the job (JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE)
+func (l *jobLimiter) close() {
+	windows.CloseHandle(l.job)
+}
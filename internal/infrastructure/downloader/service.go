@@ -3,6 +3,7 @@ package downloader
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,12 +11,16 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"auto_upload_tiktok/config"
 	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
 	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/redact"
 )
 
 // Service handles video downloading with high performance
@@ -24,6 +29,12 @@ type Service struct {
 	httpClient  *httpclient.HTTPClient
 	downloadDir string
 	ytDlpPath   string
+
+	// protectedFiles lists files that must survive directory trimming (optional)
+	protectedFiles func() []string
+
+	// proxyTurn rotates yt-dlp downloads over the download.proxy list
+	proxyTurn atomic.Uint64
 }
 
 // NewService creates a new download service
@@ -40,27 +51,60 @@ func NewService(cfg *config.Config, httpClient *httpclient.HTTPClient) (*Service
 
 	return &Service{
 		config:      cfg,
-		httpClient:  httpClient,
+		httpClient:  httpClient.WithProxies(cfg.DownloadProxies),
 		downloadDir: cfg.DownloadDir,
 		ytDlpPath:   ytDlpPath,
 	}, nil
 }
 
+// SetProtectedFiles sets the source of files the download directory trimming must keep, such as
+// uploads TikTok is still publishing
+func (s *Service) SetProtectedFiles(files func() []string) {
+	s.protectedFiles = files
+}
+
 // DownloadOptions contains options for video download
 type DownloadOptions struct {
 	// VideoID is the YouTube video ID
 	VideoID string
 
+	// FileName is the name of the downloaded file without its extension; defaults to VideoID
+	FileName string
+
 	// Format is the desired video format (mp4, webm, etc.)
 	Format string
 
 	// Quality is the desired video quality (best, worst, 720p, etc.)
 	Quality string
 
+	// Container, MaxHeight and FPS are an account's download preferences: the container used
+	// when Format is empty, and the largest height and frame rate to pick (720p when Quality is
+	// empty, 30fps). Zero values fall back to download.format, download.max_height and
+	// download.fps.
+	Container string
+	MaxHeight int
+	FPS       int
+
 	// ProgressCallback is called with download progress (0-100)
 	ProgressCallback func(progress int)
+
+	// ExpectedSize is the video size in bytes when known, added to the free space check
+	ExpectedSize int64
+
+	// ExtraArgs, UserAgent and Retries override download.ytdlp_extra_args, download.user_agent
+	// and download.retries for this call when set
+	ExtraArgs []string
+	UserAgent string
+	Retries   *int
 }
 
+// Download methods reported in DownloadResult.Method
+const (
+	MethodYtDlp     = "yt-dlp"
+	MethodCobalt    = "cobalt"
+	MethodInvidious = "invidious"
+)
+
 // DownloadResult contains the result of a download operation
 type DownloadResult struct {
 	// FilePath is the path to the downloaded file
@@ -71,12 +115,56 @@ type DownloadResult struct {
 
 	// Duration is the time taken to download
 	Duration time.Duration
+
+	// Method is how the file was obtained (MethodYtDlp, MethodCobalt or MethodInvidious)
+	Method string
+
+	// Source is the Invidious instance that resolved the file, when Method is MethodInvidious
+	Source string
+}
+
+// Via describes the download path for logs
+func (r *DownloadResult) Via() string {
+	if r.Source != "" {
+		return fmt.Sprintf("%s (%s)", r.Method, r.Source)
+	}
+	return r.Method
+}
+
+// isBotCheck reports whether yt-dlp failed on YouTube's bot detection or rate limiting
+func isBotCheck(stderr string) bool {
+	return strings.Contains(stderr, "Sign in to confirm") ||
+		strings.Contains(stderr, "not a bot") ||
+		strings.Contains(stderr, "403: Forbidden") ||
+		strings.Contains(stderr, "429: Too Many Requests")
+}
+
+// nextProxy returns the download proxy of the next yt-dlp download, taking the download.proxy
+// list in turn; empty when downloads connect directly
+func (s *Service) nextProxy() string {
+	if s.config == nil {
+		return ""
+	}
+	proxies := s.config.DownloadProxies()
+	if len(proxies) == 0 {
+		return ""
+	}
+	turn := s.proxyTurn.Add(1) - 1
+	return proxies[turn%uint64(len(proxies))].String()
 }
 
 // DownloadVideo downloads a video using yt-dlp for high performance
 func (s *Service) DownloadVideo(ctx context.Context, opts DownloadOptions) (*DownloadResult, error) {
 	startTime := time.Now()
-	outputPath := filepath.Join(s.downloadDir, fmt.Sprintf("%s.%%(ext)s", opts.VideoID))
+	fileName := opts.FileName
+	if fileName == "" {
+		fileName = opts.VideoID
+	}
+	outputPath := filepath.Join(s.downloadDir, fmt.Sprintf("%s.%%(ext)s", fileName))
+
+	if err := s.ensureDiskSpace(opts.ExpectedSize); err != nil {
+		return nil, err
+	}
 
 	// Log download start
 	logger.Info().Printf("[DOWNLOAD START] Video ID: %s | Method: yt-dlp | Time: %s",
@@ -85,56 +173,21 @@ func (s *Service) DownloadVideo(ctx context.Context, opts DownloadOptions) (*Dow
 	// Log yt-dlp path for debugging
 	logger.Info().Printf("Using yt-dlp at: %s", s.ytDlpPath)
 
-	// Build yt-dlp command with options to bypass YouTube bot detection
-	args := []string{
-		"--no-playlist",
-		"--no-warnings",
-		"--no-check-certificates",
-
-		// Performance optimization - concurrent downloads
-		"--concurrent-fragments", "8", // Download 8 fragments simultaneously
-		"--buffer-size", "16M", // 16MB buffer for better throughput
-		"--http-chunk-size", "10M", // 10MB per chunk
-
-		// Use android_creator client which is often less restricted
-		"--extractor-args", "youtube:player_client=android_creator,android,ios",
-		"--extractor-args", "youtube:skip=hls,dash",
-		// Network options - remove force-ipv4 as it might cause issues
-		"--retries", "5",
-		"--retry-sleep", "2",
-		"--fragment-retries", "5",
-	}
-
 	// Check if aria2c is available for even faster downloads (3-10x speed improvement)
+	settings := s.resolveYtDlpSettings(opts)
+	settings.Proxy = s.nextProxy()
 	if _, err := exec.LookPath("aria2c"); err == nil {
-		args = append(args,
-			"--external-downloader", "aria2c",
-			"--external-downloader-args", "-x 16 -s 16 -k 1M",
-		)
+		settings.Aria2c = true
 		logger.Info().Printf("Using aria2c external downloader for faster downloads")
 	}
 
-	// Cookies usage removed as per user request
-	// ...
-
-	args = append(args, "-o", outputPath)
-
-	// Add format options optimized to avoid bot detection
-	if opts.Format != "" {
-		args = append(args, "-f", opts.Format)
-	} else if opts.Quality != "" {
-		args = append(args, "-f", fmt.Sprintf("bestvideo[height<=%s]+bestaudio/best[height<=%s]", opts.Quality, opts.Quality))
-	} else {
-		// Use format that bypasses bot detection better
-		// Format 18 = 360p mp4, widely available and less monitored
-		args = append(args, "-f", "18/best[height<=480]/best")
+	args, err := buildYtDlpArgs(opts.VideoID, outputPath, settings)
+	if err != nil {
+		return nil, err
 	}
 
-	videoURL := fmt.Sprintf("https://www.youtube.com/watch?v=%s", opts.VideoID)
-	args = append(args, videoURL)
-
 	// Log command for debugging
-	logger.Info().Printf("Executing: %s %s", s.ytDlpPath, strings.Join(args, " "))
+	logger.Info().Printf("Executing: %s %s", s.ytDlpPath, redact.String(strings.Join(args, " ")))
 
 	// Execute yt-dlp
 	cmd := exec.CommandContext(ctx, s.ytDlpPath, args...)
@@ -144,15 +197,17 @@ func (s *Service) DownloadVideo(ctx context.Context, opts DownloadOptions) (*Dow
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
+	if err := s.runLimited(cmd, "yt-dlp", &stderr); err != nil {
 		// Log stderr for debugging
-		stderrStr := stderr.String()
+		stderrStr := redact.String(stderr.String())
+
+		// A download stopped at the memory limit would fail the same way on any fallback
+		if errors.Is(err, ErrResourceLimit) {
+			return nil, err
+		}
 
 		// If bot detection error, try Cobalt fallback first, then Invidious
-		if strings.Contains(stderrStr, "Sign in to confirm") ||
-			strings.Contains(stderrStr, "bot") ||
-			strings.Contains(stderrStr, "403: Forbidden") ||
-			strings.Contains(stderrStr, "429: Too Many Requests") {
+		if isBotCheck(stderrStr) {
 			logger.Info().Printf("YouTube bot detection encountered (error: %s), trying Cobalt fallback...", stderrStr)
 
 			// Try Cobalt first
@@ -172,7 +227,7 @@ func (s *Service) DownloadVideo(ctx context.Context, opts DownloadOptions) (*Dow
 	}
 
 	// Find the downloaded file
-	pattern := filepath.Join(s.downloadDir, fmt.Sprintf("%s.*", opts.VideoID))
+	pattern := filepath.Join(s.downloadDir, fmt.Sprintf("%s.*", fileName))
 	matches, err := filepath.Glob(pattern)
 	if err != nil || len(matches) == 0 {
 		return nil, fmt.Errorf("downloaded file not found")
@@ -184,15 +239,6 @@ func (s *Service) DownloadVideo(ctx context.Context, opts DownloadOptions) (*Dow
 		return nil, fmt.Errorf("failed to stat downloaded file: %w", err)
 	}
 
-	// Rename to .mp4 if needed
-	if filepath.Ext(filePath) != ".mp4" {
-		newPath := filepath.Join(s.downloadDir, fmt.Sprintf("%s.mp4", opts.VideoID))
-		if err := os.Rename(filePath, newPath); err != nil {
-			return nil, fmt.Errorf("failed to rename file: %w", err)
-		}
-		filePath = newPath
-	}
-
 	duration := time.Since(startTime)
 	fileSizeMB := float64(fileInfo.Size()) / (1024 * 1024)
 	speedMBps := fileSizeMB / duration.Seconds()
@@ -205,6 +251,7 @@ func (s *Service) DownloadVideo(ctx context.Context, opts DownloadOptions) (*Dow
 		FilePath: filePath,
 		FileSize: fileInfo.Size(),
 		Duration: duration,
+		Method:   MethodYtDlp,
 	}, nil
 }
 
@@ -230,6 +277,14 @@ func (s *Service) monitorProgress(stdout, stderr io.ReadCloser, callback func(in
 	}
 }
 
+// userAgent is the browser user agent sent on direct HTTP requests
+func (s *Service) userAgent() string {
+	if s.config != nil && s.config.DownloadUserAgent != "" {
+		return s.config.DownloadUserAgent
+	}
+	return defaultUserAgent
+}
+
 // DownloadVideoStream downloads a video using streaming for better memory efficiency
 func (s *Service) DownloadVideoStream(ctx context.Context, videoURL string, outputPath string) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, videoURL, nil)
@@ -238,7 +293,7 @@ func (s *Service) DownloadVideoStream(ctx context.Context, videoURL string, outp
 	}
 
 	// Add headers to mimic a browser to avoid 403 on direct links
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("User-Agent", s.userAgent())
 	req.Header.Set("Referer", "https://www.youtube.com/")
 
 	resp, err := s.httpClient.Do(req)
@@ -299,7 +354,7 @@ func (s *Service) downloadViaCobalt(ctx context.Context, videoID string, outputP
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("User-Agent", s.userAgent())
 
 	// Execute request
 	resp, err := s.httpClient.Do(req)
@@ -332,7 +387,7 @@ func (s *Service) downloadViaCobalt(ctx context.Context, videoID string, outputP
 	}
 
 	// Download the file from the returned URL
-	logger.Info().Printf("Downloading from Cobalt URL: %s", result.URL)
+	logger.Info().Printf("Downloading from Cobalt URL: %s", redact.URL(result.URL))
 	finalPath := strings.Replace(outputPath, "%(ext)s", "mp4", 1)
 
 	if err := s.DownloadVideoStream(ctx, result.URL, finalPath); err != nil {
@@ -358,97 +413,52 @@ func (s *Service) downloadViaCobalt(ctx context.Context, videoID string, outputP
 		FilePath: finalPath,
 		FileSize: fileInfo.Size(),
 		Duration: duration,
+		Method:   MethodCobalt,
 	}, nil
 }
 
-// downloadViaInvidious downloads video using Invidious as fallback (no bot detection)
+// downloadViaInvidious resolves a direct URL through each configured Invidious instance in
+// order and streams it to the yt-dlp output path. The lookup on each instance is bounded by
+// download.invidious_timeout; the transfer itself by the caller's download timeout.
 func (s *Service) downloadViaInvidious(ctx context.Context, videoID string, outputPath string) (*DownloadResult, error) {
 	startTime := time.Now()
 	logger.Info().Printf("[DOWNLOAD START] Video ID: %s | Method: Invidious | Time: %s",
 		videoID, startTime.Format("2006-01-02 15:04:05"))
 
-	// List of public Invidious instances
-	instances := []string{
-		"https://invidious.fdn.fr",
-		"https://invidious.privacydev.net",
-		"https://inv.tux.pizza",
-		"https://invidious.io.lol",
-		"https://yt.artemislena.eu",
-		"https://invidious.drgns.space",
-		"https://invidious.lunar.icu",
-		"https://invidious.projectsegfau.lt",
+	instances := s.config.InvidiousInstances
+	if len(instances) == 0 {
+		instances = youtube.PublicInstances
 	}
+	finalPath := strings.Replace(outputPath, "%(ext)s", "mp4", 1)
 
 	var lastErr error
 	for _, instance := range instances {
 		logger.Info().Printf("Trying Invidious instance: %s", instance)
 
-		// Get video info from Invidious API
-		apiURL := fmt.Sprintf("%s/api/v1/videos/%s", instance, videoID)
-		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		lookupCtx, cancel := context.WithTimeout(ctx, s.config.InvidiousTimeout)
+		downloadURL, err := youtube.GetVideoDownloadURL(lookupCtx, s.httpClient, instance, videoID)
+		cancel()
 		if err != nil {
-			lastErr = err
-			continue
-		}
-
-		resp, err := s.httpClient.Do(req)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-
-		if resp.StatusCode != 200 {
-			resp.Body.Close()
-			lastErr = fmt.Errorf("invidious API returned status %d", resp.StatusCode)
-			continue
-		}
-
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			lastErr = err
-			continue
-		}
-
-		// Parse JSON response
-		var data map[string]interface{}
-		if err := json.Unmarshal(body, &data); err != nil {
-			lastErr = err
-			continue
-		}
-
-		// Get best video format URL
-		var downloadURL string
-		if formats, ok := data["adaptiveFormats"].([]interface{}); ok {
-			for _, f := range formats {
-				format := f.(map[string]interface{})
-				if url, ok := format["url"].(string); ok {
-					// Prefer mp4 video format
-					if typ, ok := format["type"].(string); ok {
-						if strings.Contains(typ, "video/mp4") {
-							downloadURL = url
-							break
-						}
-					}
-				}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
 			}
-		}
-
-		if downloadURL == "" {
-			lastErr = fmt.Errorf("no suitable format found")
+			logger.Error().Printf("Invidious instance %s failed: %v", instance, err)
+			lastErr = fmt.Errorf("%s: %w", instance, err)
 			continue
 		}
 
-		// Download video
-		logger.Info().Printf("Downloading from Invidious: %s", downloadURL)
-		finalPath := strings.Replace(outputPath, "%(ext)s", "mp4", 1)
-
+		logger.Info().Printf("Downloading from Invidious: %s", redact.URL(downloadURL))
 		if err := s.DownloadVideoStream(ctx, downloadURL, finalPath); err != nil {
-			lastErr = err
+			// Drop the partial file so the next instance (or a later retry) starts clean
+			_ = os.Remove(finalPath)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			logger.Error().Printf("Invidious download from %s failed: %v", instance, err)
+			lastErr = fmt.Errorf("%s: %w", instance, err)
 			continue
 		}
 
-		// Success!
 		fileInfo, err := os.Stat(finalPath)
 		if err != nil {
 			lastErr = err
@@ -460,18 +470,74 @@ func (s *Service) downloadViaInvidious(ctx context.Context, videoID string, outp
 		speedMBps := fileSizeMB / duration.Seconds()
 
 		// Log download completion with detailed metrics
-		logger.Info().Printf("[DOWNLOAD COMPLETE] Video ID: %s | Method: Invidious | Duration: %.2fs | Size: %d bytes (%.2f MB) | Speed: %.2f MB/s | File: %s",
-			videoID, duration.Seconds(), fileInfo.Size(), fileSizeMB, speedMBps, filepath.Base(finalPath))
-		logger.Info().Printf("Successfully downloaded via Invidious: %s", finalPath)
+		logger.Info().Printf("[DOWNLOAD COMPLETE] Video ID: %s | Method: Invidious (%s) | Duration: %.2fs | Size: %d bytes (%.2f MB) | Speed: %.2f MB/s | File: %s",
+			videoID, instance, duration.Seconds(), fileInfo.Size(), fileSizeMB, speedMBps, filepath.Base(finalPath))
 
 		return &DownloadResult{
 			FilePath: finalPath,
 			FileSize: fileInfo.Size(),
 			Duration: duration,
+			Method:   MethodInvidious,
+			Source:   instance,
 		}, nil
 	}
 
-	return nil, fmt.Errorf("all Invidious instances failed, last error: %v", lastErr)
+	return nil, fmt.Errorf("all Invidious instances failed, last error: %w", lastErr)
+}
+
+// CutClip writes the [start, end) range of sourcePath to outputPath with ffmpeg.
+// The clip is re-encoded so it starts exactly at start rather than the previous keyframe.
+func (s *Service) CutClip(ctx context.Context, sourcePath, outputPath string, start, end time.Duration) error {
+	if end <= start {
+		return fmt.Errorf("invalid clip range %s-%s", start, end)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create clip directory: %w", err)
+	}
+
+	ffmpegPath := s.config.FFmpegPath
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+
+	args := []string{
+		"-y",
+		"-ss", formatSeconds(start),
+		"-i", sourcePath,
+		"-t", formatSeconds(end - start),
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-c:a", "aac",
+		"-movflags", "+faststart",
+		outputPath,
+	}
+
+	startTime := time.Now()
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+	cmd.Stderr = &stderr
+	if err := s.runLimited(cmd, "ffmpeg", &stderr); err != nil {
+		_ = os.Remove(outputPath)
+		if errors.Is(err, ErrResourceLimit) {
+			return err
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			if len(msg) > 512 {
+				msg = msg[len(msg)-512:]
+			}
+			return fmt.Errorf("ffmpeg clip failed: %w\nStderr: %s", err, msg)
+		}
+		return fmt.Errorf("ffmpeg clip failed: %w", err)
+	}
+
+	logger.Info().Printf("[CLIP COMPLETE] %s [%s-%s] -> %s in %.2fs",
+		filepath.Base(sourcePath), start, end, filepath.Base(outputPath), time.Since(startTime).Seconds())
+	return nil
+}
+
+// formatSeconds renders a duration as seconds for ffmpeg arguments
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
 }
 
 // CleanupOldDownloads removes old downloaded files
@@ -504,6 +570,27 @@ func (s *Service) CleanupOldDownloads(maxAge time.Duration) error {
 	return nil
 }
 
+// YtDlpVersion asks the yt-dlp binary for its version
+func (s *Service) YtDlpVersion(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, s.ytDlpPath, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s --version: %w", s.ytDlpPath, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CheckYtDlp reports whether the yt-dlp binary found at startup is still there
+func (s *Service) CheckYtDlp() (string, error) {
+	info, err := os.Stat(s.ytDlpPath)
+	if err != nil {
+		return "", fmt.Errorf("yt-dlp binary %s: %w", s.ytDlpPath, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("yt-dlp binary %s is a directory", s.ytDlpPath)
+	}
+	return s.ytDlpPath, nil
+}
+
 // resolveYtDlpPath determines the path to the yt-dlp executable.
 func resolveYtDlpPath(cfg *config.Config) (string, error) {
 	// Helper that validates a candidate path.
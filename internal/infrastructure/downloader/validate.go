@@ -0,0 +1,254 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/logger"
+)
+
+// TikTok's accepted media: an MP4/MOV file with H.264 or H.265 video and AAC audio, 3 seconds or
+// longer, with both sides between 360 and 4096 pixels
+const (
+	minVideoDuration = 3 * time.Second
+	minVideoSide     = 360
+	maxVideoSide     = 4096
+)
+
+// MediaLimits bounds the file size and length a validated video may have; zero means no limit
+type MediaLimits struct {
+	MaxSize     int64
+	MaxDuration time.Duration
+}
+
+// ValidationResult describes a video file as ffprobe sees it and what keeps TikTok from taking it
+type ValidationResult struct {
+	Container  string // ffprobe format name, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	VideoCodec string
+	AudioCodec string // Empty when the file has no audio
+	Width      int
+	Height     int
+	FPS        float64 // Average frame rate of the video stream; zero when ffprobe does not know it
+	Duration   time.Duration
+	Size       int64
+
+	// CodecProblems can be fixed by transcoding; Problems cannot
+	CodecProblems []string
+	Problems      []string
+}
+
+// Valid reports whether TikTok accepts the file as it is
+func (r *ValidationResult) Valid() bool {
+	return len(r.CodecProblems) == 0 && len(r.Problems) == 0
+}
+
+// NeedsTranscode reports whether transcoding to H.264/AAC MP4 would make the file acceptable
+func (r *ValidationResult) NeedsTranscode() bool {
+	return len(r.CodecProblems) > 0 && len(r.Problems) == 0
+}
+
+// Summary lists the problems for error messages
+func (r *ValidationResult) Summary() string {
+	return strings.Join(append(append([]string{}, r.CodecProblems...), r.Problems...), "; ")
+}
+
+// ValidateVideo probes a video file with ffprobe and checks its container, codecs, resolution,
+// length and size against what TikTok accepts and limits
+func (s *Service) ValidateVideo(ctx context.Context, path string, limits MediaLimits) (*ValidationResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat video file: %w", err)
+	}
+
+	args := []string{
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		path,
+	}
+	out, err := exec.CommandContext(ctx, s.ffprobePath(), args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	result, err := parseProbeOutput(out)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe output for %s: %w", filepath.Base(path), err)
+	}
+	result.Size = info.Size()
+	result.check(limits)
+	return result, nil
+}
+
+// probeOutput is the part of ffprobe's JSON output used for validation
+type probeOutput struct {
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		CodecName string `json:"codec_name"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+		Duration  string `json:"duration"`
+
+		// Frame rates as fractions, e.g. "30000/1001"
+		AvgFrameRate string `json:"avg_frame_rate"`
+		RFrameRate   string `json:"r_frame_rate"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+	} `json:"format"`
+}
+
+// parseProbeOutput reads the container, the first video and audio streams and the length from
+// ffprobe's JSON output. The length comes from the container, or the video stream when the
+// container does not report one.
+func parseProbeOutput(data []byte) (*ValidationResult, error) {
+	var probe probeOutput
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+
+	result := &ValidationResult{Container: probe.Format.FormatName}
+	streamDuration := ""
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			if result.VideoCodec == "" {
+				result.VideoCodec = stream.CodecName
+				result.Width = stream.Width
+				result.Height = stream.Height
+				result.FPS = parseFrameRate(stream.AvgFrameRate)
+				if result.FPS == 0 {
+					result.FPS = parseFrameRate(stream.RFrameRate)
+				}
+				streamDuration = stream.Duration
+			}
+		case "audio":
+			if result.AudioCodec == "" {
+				result.AudioCodec = stream.CodecName
+			}
+		}
+	}
+	if result.VideoCodec == "" {
+		return nil, errors.New("no video stream")
+	}
+
+	for _, value := range []string{probe.Format.Duration, streamDuration} {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			result.Duration = time.Duration(seconds * float64(time.Second))
+			break
+		}
+	}
+	return result, nil
+}
+
+// parseFrameRate reads an ffprobe frame rate such as "30000/1001" or "25", returning zero for
+// "0/0" and anything else it cannot read
+func parseFrameRate(value string) float64 {
+	num, den, found := strings.Cut(value, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	if !found {
+		return n
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return n / d
+}
+
+// check fills in the problems of a probed file
+func (r *ValidationResult) check(limits MediaLimits) {
+	if !strings.Contains(r.Container, "mp4") && !strings.Contains(r.Container, "mov") {
+		r.CodecProblems = append(r.CodecProblems, fmt.Sprintf("container %s is not MP4", r.Container))
+	}
+	if r.VideoCodec != "h264" && r.VideoCodec != "hevc" {
+		r.CodecProblems = append(r.CodecProblems, fmt.Sprintf("video codec %s is not H.264 or H.265", r.VideoCodec))
+	}
+	if r.AudioCodec != "" && r.AudioCodec != "aac" {
+		r.CodecProblems = append(r.CodecProblems, fmt.Sprintf("audio codec %s is not AAC", r.AudioCodec))
+	}
+
+	short, long := r.Width, r.Height
+	if short > long {
+		short, long = long, short
+	}
+	if short > 0 && (short < minVideoSide || long > maxVideoSide) {
+		r.Problems = append(r.Problems, fmt.Sprintf("resolution %dx%d is outside %d-%d pixels", r.Width, r.Height, minVideoSide, maxVideoSide))
+	}
+	if r.Duration > 0 && r.Duration < minVideoDuration {
+		r.Problems = append(r.Problems, fmt.Sprintf("video is %s long, under the %s minimum", r.Duration.Round(time.Millisecond), minVideoDuration))
+	}
+	if limits.MaxDuration > 0 && r.Duration > limits.MaxDuration {
+		r.Problems = append(r.Problems, fmt.Sprintf("video is %s long, over the %s limit", r.Duration.Round(time.Second), limits.MaxDuration))
+	}
+	if limits.MaxSize > 0 && r.Size > limits.MaxSize {
+		r.Problems = append(r.Problems, fmt.Sprintf("file is %d bytes, over the %d byte limit", r.Size, limits.MaxSize))
+	}
+}
+
+// Transcode re-encodes a video to H.264/AAC in an MP4 container next to the source, removes the
+// source and returns the new path
+func (s *Service) Transcode(ctx context.Context, sourcePath string) (string, error) {
+	base := strings.TrimSuffix(sourcePath, filepath.Ext(sourcePath))
+	tmpPath := base + ".transcode.mp4"
+	outputPath := base + ".mp4"
+
+	ffmpegPath := s.config.FFmpegPath
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+
+	args := []string{
+		"-y",
+		"-i", sourcePath,
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-movflags", "+faststart",
+		tmpPath,
+	}
+
+	startTime := time.Now()
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+	cmd.Stderr = &stderr
+	if err := s.runLimited(cmd, "ffmpeg", &stderr); err != nil {
+		_ = os.Remove(tmpPath)
+		if errors.Is(err, ErrResourceLimit) {
+			return "", err
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			if len(msg) > 512 {
+				msg = msg[len(msg)-512:]
+			}
+			return "", fmt.Errorf("ffmpeg transcode failed: %w\nStderr: %s", err, msg)
+		}
+		return "", fmt.Errorf("ffmpeg transcode failed: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to rename transcoded file: %w", err)
+	}
+	if sourcePath != outputPath {
+		_ = os.Remove(sourcePath)
+	}
+
+	logger.Info().Printf("[TRANSCODE COMPLETE] %s -> %s in %.2fs",
+		filepath.Base(sourcePath), filepath.Base(outputPath), time.Since(startTime).Seconds())
+	return outputPath, nil
+}
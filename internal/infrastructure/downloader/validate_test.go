@@ -0,0 +1,145 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProbeOutput(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    ValidationResult
+		wantErr string
+	}{
+		{
+			fixture: "h264_aac.json",
+			want: ValidationResult{Container: "mov,mp4,m4a,3gp,3g2,mj2", VideoCodec: "h264", AudioCodec: "aac",
+				Width: 1080, Height: 1920, FPS: 30000.0 / 1001, Duration: 42494 * time.Millisecond},
+		},
+		{
+			fixture: "webm_renamed.json",
+			want: ValidationResult{Container: "matroska,webm", VideoCodec: "vp9", AudioCodec: "opus",
+				Width: 1920, Height: 1080, FPS: 25, Duration: 192041 * time.Millisecond},
+		},
+		{
+			// No container duration and no average frame rate: the stream's values are used
+			fixture: "hevc_no_audio.json",
+			want: ValidationResult{Container: "mov,mp4,m4a,3gp,3g2,mj2", VideoCodec: "hevc",
+				Width: 3840, Height: 2160, FPS: 60, Duration: 12500 * time.Millisecond},
+		},
+		{
+			fixture: "short_low_res.json",
+			want: ValidationResult{Container: "mov,mp4,m4a,3gp,3g2,mj2", VideoCodec: "h264", AudioCodec: "mp3",
+				Width: 320, Height: 240, FPS: 15, Duration: 2011 * time.Millisecond},
+		},
+		{fixture: "audio_only.json", wantErr: "no video stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "ffprobe", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseProbeOutput(data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseProbeOutput() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseProbeOutput() error = %v", err)
+			}
+			got.Duration = got.Duration.Round(time.Millisecond)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("parseProbeOutput() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseProbeOutput([]byte("Invalid data found when processing input")); err == nil {
+		t.Error("parseProbeOutput() of non-JSON output succeeded")
+	}
+}
+
+func TestValidationResultCheck(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		size          int64
+		limits        MediaLimits
+		wantValid     bool
+		wantTranscode bool
+		wantProblems  []string // substrings of Summary()
+	}{
+		{fixture: "h264_aac.json", size: 13906531, wantValid: true},
+		{fixture: "hevc_no_audio.json", size: 30712844, wantValid: true},
+		{
+			fixture:       "webm_renamed.json",
+			size:          48215870,
+			wantTranscode: true,
+			wantProblems:  []string{"container matroska,webm is not MP4", "video codec vp9", "audio codec opus is not AAC"},
+		},
+		{
+			fixture:      "short_low_res.json",
+			size:         94112,
+			wantProblems: []string{"audio codec mp3", "resolution 320x240 is outside 360-4096 pixels", "under the 3s minimum"},
+		},
+		{
+			fixture:      "h264_aac.json",
+			size:         13906531,
+			limits:       MediaLimits{MaxSize: 10 << 20, MaxDuration: 30 * time.Second},
+			wantProblems: []string{"over the 30s limit", "file is 13906531 bytes, over the 10485760 byte limit"},
+		},
+		{
+			// Right at the limits is accepted
+			fixture:   "h264_aac.json",
+			size:      13906531,
+			limits:    MediaLimits{MaxSize: 13906531, MaxDuration: 42494 * time.Millisecond},
+			wantValid: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "ffprobe", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := parseProbeOutput(data)
+			if err != nil {
+				t.Fatalf("parseProbeOutput() error = %v", err)
+			}
+			result.Size = tt.size
+			result.check(tt.limits)
+
+			if result.Valid() != tt.wantValid || result.NeedsTranscode() != tt.wantTranscode {
+				t.Errorf("Valid() = %v, NeedsTranscode() = %v; want %v, %v (%s)",
+					result.Valid(), result.NeedsTranscode(), tt.wantValid, tt.wantTranscode, result.Summary())
+			}
+			for _, problem := range tt.wantProblems {
+				if !strings.Contains(result.Summary(), problem) {
+					t.Errorf("Summary() = %q, want it to mention %q", result.Summary(), problem)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFrameRate(t *testing.T) {
+	for value, want := range map[string]float64{
+		"30000/1001": 30000.0 / 1001,
+		"60/1":       60,
+		"25":         25,
+		"0/0":        0,
+		"30/0":       0,
+		"":           0,
+		"N/A":        0,
+	} {
+		if got := parseFrameRate(value); got != want {
+			t.Errorf("parseFrameRate(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
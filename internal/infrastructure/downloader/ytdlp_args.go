@@ -0,0 +1,203 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultUserAgent is sent by the HTTP fallbacks when download.user_agent is not set
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// ErrFormatConflict is returned when a full format selector is combined with a Quality
+var ErrFormatConflict = errors.New("format selector conflicts with quality")
+
+// ytDlpSettings is the config merged with per-call DownloadOptions
+type ytDlpSettings struct {
+	Format    string
+	Quality   string
+	FPS       int // Largest frame rate to pick; 0 takes any
+	ExtraArgs []string
+	UserAgent string
+	Retries   int
+	Proxy     string // yt-dlp --proxy URL; empty connects directly
+	Aria2c    bool   // Use aria2c as the external downloader
+}
+
+// resolveYtDlpSettings merges the per-call options over the configured values
+func (s *Service) resolveYtDlpSettings(opts DownloadOptions) ytDlpSettings {
+	settings := ytDlpSettings{Quality: opts.Quality, FPS: opts.FPS}
+	if s.config != nil {
+		settings.Format = s.config.DownloadFormat
+		settings.ExtraArgs = s.config.YtDlpExtraArgs
+		settings.UserAgent = s.config.DownloadUserAgent
+		settings.Retries = s.config.DownloadRetries
+	}
+
+	switch {
+	case opts.Format != "":
+		settings.Format = opts.Format
+	case opts.Container != "":
+		settings.Format = opts.Container
+	case (opts.Quality != "" || opts.MaxHeight > 0 || opts.FPS > 0) && isFormatSelector(settings.Format):
+		// A configured selector gives way to an explicit per-call quality
+		settings.Format = ""
+	}
+
+	// The configured height and frame rate narrow a container; a full selector picks its own
+	maxHeight := opts.MaxHeight
+	if s.config != nil && !isFormatSelector(settings.Format) {
+		if maxHeight <= 0 {
+			maxHeight = s.config.DownloadMaxHeight
+		}
+		if settings.FPS <= 0 {
+			settings.FPS = s.config.DownloadFPS
+		}
+	}
+	if settings.Quality == "" && maxHeight > 0 {
+		settings.Quality = fmt.Sprintf("%dp", maxHeight)
+	}
+
+	if opts.ExtraArgs != nil {
+		settings.ExtraArgs = opts.ExtraArgs
+	}
+	if opts.UserAgent != "" {
+		settings.UserAgent = opts.UserAgent
+	}
+	if opts.Retries != nil {
+		settings.Retries = *opts.Retries
+	}
+	return settings
+}
+
+// buildYtDlpArgs returns the yt-dlp arguments for downloading a video to outputPath
+func buildYtDlpArgs(videoID, outputPath string, settings ytDlpSettings) ([]string, error) {
+	format, err := formatSelector(settings.Format, settings.Quality, settings.FPS)
+	if err != nil {
+		return nil, err
+	}
+
+	retries := strconv.Itoa(settings.Retries)
+	args := []string{
+		"--no-playlist",
+		"--no-warnings",
+		"--no-check-certificates",
+
+		// Performance optimization - concurrent downloads
+		"--concurrent-fragments", "8", // Download 8 fragments simultaneously
+		"--buffer-size", "16M", // 16MB buffer for better throughput
+		"--http-chunk-size", "10M", // 10MB per chunk
+
+		"--retries", retries,
+		"--retry-sleep", "2",
+		"--fragment-retries", retries,
+	}
+	if settings.UserAgent != "" {
+		args = append(args, "--user-agent", settings.UserAgent)
+	}
+	if settings.Proxy != "" {
+		args = append(args, "--proxy", settings.Proxy)
+	}
+	// Extractor tweaks (player clients etc.) come from config
+	args = append(args, settings.ExtraArgs...)
+
+	if settings.Aria2c {
+		args = append(args,
+			"--external-downloader", "aria2c",
+			"--external-downloader-args", "-x 16 -s 16 -k 1M",
+		)
+	}
+
+	args = append(args, "-o", outputPath, "-f", format)
+	args = append(args, fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID))
+	return args, nil
+}
+
+// formatSelector combines the format, quality and frame rate into a yt-dlp -f value. A bare
+// container ("mp4") with a quality selects the best stream of that container up to the height; a
+// full selector already picks its streams and cannot be combined with a quality or frame rate. A
+// frame rate limit prefers streams up to it and falls back to faster ones rather than failing.
+func formatSelector(format, quality string, fps int) (string, error) {
+	if quality == "worst" {
+		// The worst stream is as slow as any; fps does not narrow it further
+		if format != "" && isFormatSelector(format) {
+			return "", fmt.Errorf("%w: %q with quality %q", ErrFormatConflict, format, quality)
+		}
+		if format != "" {
+			return fmt.Sprintf("worst[ext=%s]/worst", format), nil
+		}
+		return "worst", nil
+	}
+
+	height := 0
+	if quality != "" && quality != "best" {
+		h, err := strconv.Atoi(strings.TrimSuffix(quality, "p"))
+		if err != nil || h <= 0 {
+			return "", fmt.Errorf("invalid quality %q: use best, worst or a height like 720p", quality)
+		}
+		height = h
+	}
+	if height == 0 && fps <= 0 {
+		if format == "" {
+			// Format 18 = 360p mp4, widely available and less monitored
+			return "18/best[height<=480]/best", nil
+		}
+		return format, nil
+	}
+	if isFormatSelector(format) {
+		if height == 0 {
+			return "", fmt.Errorf("%w: %q with fps %d", ErrFormatConflict, format, fps)
+		}
+		return "", fmt.Errorf("%w: %q with quality %q", ErrFormatConflict, format, quality)
+	}
+	return limitedSelector(format, height, fps), nil
+}
+
+// limitedSelector picks the best streams up to the height and frame rate (either may be 0 for no
+// limit), of the container when it is set. The alternatives drop the frame rate limit, then the
+// container, so a video without a matching stream still downloads.
+func limitedSelector(container string, height, fps int) string {
+	heightFilter, fpsFilter, extFilter := "", "", ""
+	if height > 0 {
+		heightFilter = fmt.Sprintf("[height<=%d]", height)
+	}
+	if fps > 0 {
+		fpsFilter = fmt.Sprintf("[fps<=%d]", fps)
+	}
+	if container != "" {
+		extFilter = fmt.Sprintf("[ext=%s]", container)
+	}
+
+	streams := func(filters string) []string {
+		return []string{"bestvideo" + filters + "+bestaudio", "best" + filters}
+	}
+	var alternatives []string
+	if fpsFilter != "" {
+		alternatives = append(alternatives, streams(heightFilter+fpsFilter+extFilter)...)
+	}
+	alternatives = append(alternatives, streams(heightFilter+extFilter)...)
+	if extFilter != "" {
+		alternatives = append(alternatives, "best"+heightFilter)
+	}
+	return strings.Join(alternatives, "/")
+}
+
+// isFormatSelector reports whether format is more than a bare container name: a format ID,
+// a filter or a combination of streams
+func isFormatSelector(format string) bool {
+	if format == "" {
+		return false
+	}
+	if strings.ContainsAny(format, "[]+/,()") {
+		return true
+	}
+	if _, err := strconv.Atoi(format); err == nil {
+		return true
+	}
+	switch format {
+	case "best", "worst", "bestvideo", "bestaudio", "worstvideo", "worstaudio", "b", "w", "bv", "ba":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package downloader
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"auto_upload_tiktok/config"
+)
+
+func TestBuildYtDlpArgs(t *testing.T) {
+	common := []string{
+		"--no-playlist",
+		"--no-warnings",
+		"--no-check-certificates",
+		"--concurrent-fragments", "8",
+		"--buffer-size", "16M",
+		"--http-chunk-size", "10M",
+	}
+	retries := func(n string) []string {
+		return []string{"--retries", n, "--retry-sleep", "2", "--fragment-retries", n}
+	}
+	tail := func(format string) []string {
+		return []string{"-o", "/downloads/abc.mp4", "-f", format, "https://www.youtube.com/watch?v=abc"}
+	}
+	join := func(parts ...[]string) []string { return slices.Concat(parts...) }
+
+	tests := []struct {
+		name     string
+		settings ytDlpSettings
+		want     []string
+		wantErr  error
+	}{
+		{
+			name:     "defaults",
+			settings: ytDlpSettings{},
+			want:     join(common, retries("0"), tail("18/best[height<=480]/best")),
+		},
+		{
+			name: "user agent, proxy and extra args before the output",
+			settings: ytDlpSettings{
+				Retries:   5,
+				UserAgent: "UA/1.0",
+				Proxy:     "socks5://127.0.0.1:1080",
+				ExtraArgs: []string{"--extractor-args", "youtube:player_client=tv_embedded"},
+			},
+			want: join(common, retries("5"),
+				[]string{"--user-agent", "UA/1.0", "--proxy", "socks5://127.0.0.1:1080"},
+				[]string{"--extractor-args", "youtube:player_client=tv_embedded"},
+				tail("18/best[height<=480]/best")),
+		},
+		{
+			name:     "aria2c",
+			settings: ytDlpSettings{Retries: 3, Aria2c: true},
+			want: join(common, retries("3"),
+				[]string{"--external-downloader", "aria2c", "--external-downloader-args", "-x 16 -s 16 -k 1M"},
+				tail("18/best[height<=480]/best")),
+		},
+		{
+			name:     "configured selector",
+			settings: ytDlpSettings{Format: "bestvideo[height<=1080]+bestaudio/best"},
+			want:     join(common, retries("0"), tail("bestvideo[height<=1080]+bestaudio/best")),
+		},
+		{
+			name:     "container with quality",
+			settings: ytDlpSettings{Format: "mp4", Quality: "720p"},
+			want:     join(common, retries("0"), tail("bestvideo[height<=720][ext=mp4]+bestaudio/best[height<=720][ext=mp4]/best[height<=720]")),
+		},
+		{
+			name:     "selector with quality",
+			settings: ytDlpSettings{Format: "137+140", Quality: "720p"},
+			wantErr:  ErrFormatConflict,
+		},
+		{
+			name:     "selector with frame rate",
+			settings: ytDlpSettings{Format: "bestvideo+bestaudio", FPS: 30},
+			wantErr:  ErrFormatConflict,
+		},
+		{
+			name:     "selector with worst",
+			settings: ytDlpSettings{Format: "best[ext=mp4]", Quality: "worst"},
+			wantErr:  ErrFormatConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildYtDlpArgs("abc", "/downloads/abc.mp4", tt.settings)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("buildYtDlpArgs() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildYtDlpArgs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildYtDlpArgs() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := buildYtDlpArgs("abc", "/downloads/abc.mp4", ytDlpSettings{Quality: "hd"}); err == nil || errors.Is(err, ErrFormatConflict) {
+		t.Errorf("buildYtDlpArgs() with quality hd error = %v, want an invalid quality error", err)
+	}
+}
+
+func TestResolveYtDlpSettings(t *testing.T) {
+	two := 2
+	cfg := &config.Config{
+		DownloadFormat:    "mp4",
+		YtDlpExtraArgs:    []string{"--extractor-args", "youtube:skip=hls,dash"},
+		DownloadUserAgent: "config-agent",
+		DownloadRetries:   10,
+	}
+	tests := []struct {
+		name   string
+		config *config.Config
+		opts   DownloadOptions
+		want   ytDlpSettings
+	}{
+		{
+			name: "no config",
+			opts: DownloadOptions{Quality: "best"},
+			want: ytDlpSettings{Quality: "best"},
+		},
+		{
+			name:   "config values",
+			config: cfg,
+			want:   ytDlpSettings{Format: "mp4", ExtraArgs: cfg.YtDlpExtraArgs, UserAgent: "config-agent", Retries: 10},
+		},
+		{
+			name:   "options override the config",
+			config: cfg,
+			opts: DownloadOptions{
+				Format:    "webm",
+				Quality:   "480p",
+				ExtraArgs: []string{},
+				UserAgent: "call-agent",
+				Retries:   &two,
+			},
+			want: ytDlpSettings{Format: "webm", Quality: "480p", ExtraArgs: []string{}, UserAgent: "call-agent", Retries: 2},
+		},
+		{
+			name:   "configured selector gives way to a per-call quality",
+			config: &config.Config{DownloadFormat: "137+140"},
+			opts:   DownloadOptions{Quality: "720p"},
+			want:   ytDlpSettings{Quality: "720p"},
+		},
+		{
+			name:   "configured selector is kept without a quality",
+			config: &config.Config{DownloadFormat: "137+140", DownloadMaxHeight: 720, DownloadFPS: 30},
+			want:   ytDlpSettings{Format: "137+140"},
+		},
+		{
+			name:   "account container narrowed by the configured height and frame rate",
+			config: &config.Config{DownloadFormat: "mp4", DownloadMaxHeight: 1080, DownloadFPS: 60},
+			opts:   DownloadOptions{Container: "webm"},
+			want:   ytDlpSettings{Format: "webm", Quality: "1080p", FPS: 60},
+		},
+		{
+			name:   "account height and frame rate override the config",
+			config: &config.Config{DownloadFormat: "mp4", DownloadMaxHeight: 1080, DownloadFPS: 60},
+			opts:   DownloadOptions{MaxHeight: 480, FPS: 30},
+			want:   ytDlpSettings{Format: "mp4", Quality: "480p", FPS: 30},
+		},
+		{
+			name:   "account container replaces a configured selector",
+			config: &config.Config{DownloadFormat: "137+140", DownloadMaxHeight: 720},
+			opts:   DownloadOptions{Container: "mp4"},
+			want:   ytDlpSettings{Format: "mp4", Quality: "720p"},
+		},
+		{
+			name:   "configured selector gives way to an account height",
+			config: &config.Config{DownloadFormat: "bestvideo+bestaudio", DownloadFPS: 30},
+			opts:   DownloadOptions{MaxHeight: 720},
+			want:   ytDlpSettings{Quality: "720p", FPS: 30},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &Service{config: tt.config}
+			if got := service.resolveYtDlpSettings(tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveYtDlpSettings() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSelector(t *testing.T) {
+	tests := []struct {
+		format  string
+		quality string
+		fps     int
+		want    string
+		wantErr error // nil with an empty want: an invalid quality
+	}{
+		{want: "18/best[height<=480]/best"},
+		{quality: "best", want: "18/best[height<=480]/best"},
+		{format: "mp4", want: "mp4"},
+		{format: "18", quality: "best", want: "18"},
+		{format: "best[ext=mp4]", want: "best[ext=mp4]"},
+		{quality: "worst", want: "worst"},
+		{format: "mp4", quality: "worst", fps: 30, want: "worst[ext=mp4]/worst"},
+		{quality: "720p", want: "bestvideo[height<=720]+bestaudio/best[height<=720]"},
+		{quality: "1080", want: "bestvideo[height<=1080]+bestaudio/best[height<=1080]"},
+		{format: "mp4", quality: "720p",
+			want: "bestvideo[height<=720][ext=mp4]+bestaudio/best[height<=720][ext=mp4]/best[height<=720]"},
+		{fps: 30, want: "bestvideo[fps<=30]+bestaudio/best[fps<=30]/bestvideo+bestaudio/best"},
+		{format: "mp4", fps: 60,
+			want: "bestvideo[fps<=60][ext=mp4]+bestaudio/best[fps<=60][ext=mp4]/bestvideo[ext=mp4]+bestaudio/best[ext=mp4]/best"},
+		{format: "webm", quality: "1080p", fps: 30,
+			want: "bestvideo[height<=1080][fps<=30][ext=webm]+bestaudio/best[height<=1080][fps<=30][ext=webm]/" +
+				"bestvideo[height<=1080][ext=webm]+bestaudio/best[height<=1080][ext=webm]/best[height<=1080]"},
+		{format: "137+140", quality: "720p", wantErr: ErrFormatConflict},
+		{format: "18", fps: 30, wantErr: ErrFormatConflict},
+		{format: "best", quality: "worst", wantErr: ErrFormatConflict},
+		{quality: "hd"},
+		{quality: "0p"},
+		{quality: "-720p"},
+	}
+	for _, tt := range tests {
+		got, err := formatSelector(tt.format, tt.quality, tt.fps)
+		switch {
+		case tt.want != "":
+			if err != nil || got != tt.want {
+				t.Errorf("formatSelector(%q, %q, %d) = %q, %v; want %q", tt.format, tt.quality, tt.fps, got, err, tt.want)
+			}
+		case tt.wantErr != nil:
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("formatSelector(%q, %q, %d) error = %v, want %v", tt.format, tt.quality, tt.fps, err, tt.wantErr)
+			}
+		default:
+			if err == nil || errors.Is(err, ErrFormatConflict) {
+				t.Errorf("formatSelector(%q, %q, %d) error = %v, want an invalid quality error", tt.format, tt.quality, tt.fps, err)
+			}
+		}
+	}
+}
+
+func TestIsFormatSelector(t *testing.T) {
+	for format, want := range map[string]bool{
+		"":                   false,
+		"mp4":                false,
+		"webm":               false,
+		"18":                 true,
+		"best":               true,
+		"bv":                 true,
+		"137+140":            true,
+		"best[height<=720]":  true,
+		"bestvideo/best":     true,
+		"(mp4,webm)[fps>30]": true,
+	} {
+		if got := isFormatSelector(format); got != want {
+			t.Errorf("isFormatSelector(%q) = %v, want %v", format, got, want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/config"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/redact"
+)
+
+// ErrAborted is returned when a hook stops processing of a video
+var ErrAborted = errors.New("aborted by hook")
+
+// maxOutput caps how much of a hook's output is read
+const maxOutput = 1 << 20
+
+// passthroughEnv are the only variables of the service's environment that commands inherit
+var passthroughEnv = []string{"PATH", "HOME", "TMPDIR", "LANG", "LC_ALL", "TZ"}
+
+// Payload is the JSON document a hook receives on stdin or as the request body
+type Payload struct {
+	Phase   string       `json:"phase"`
+	Hook    string       `json:"hook"`
+	Account AccountInfo  `json:"account"`
+	Video   VideoPayload `json:"video"`
+}
+
+// AccountInfo identifies the account the video belongs to
+type AccountInfo struct {
+	ID               string `json:"id"`
+	YouTubeChannelID string `json:"youtube_channel_id"`
+	TikTokAccountID  string `json:"tiktok_account_id"`
+}
+
+// VideoPayload describes the video being processed
+type VideoPayload struct {
+	ID             string    `json:"id"`
+	YouTubeVideoID string    `json:"youtube_video_id"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description"`
+	PublishedAt    time.Time `json:"published_at"`
+	FilePath       string    `json:"file_path"`
+	TikTokVideoID  string    `json:"tiktok_video_id,omitempty"`
+}
+
+// Result is what a hook may print on stdout or return as the response body. Empty output means
+// carry on unchanged.
+type Result struct {
+	FilePath string `json:"file_path,omitempty"` // Replacement video file (post_download and pre_upload)
+	Abort    bool   `json:"abort,omitempty"`     // Stop processing and fail the video
+	Reason   string `json:"reason,omitempty"`    // Shown in the video's error when aborting
+}
+
+// Runner executes configured hooks
+type Runner struct {
+	client *httpclient.HTTPClient
+}
+
+// NewRunner creates a hook runner; HTTP hooks are sent through httpClient
+func NewRunner(httpClient *httpclient.HTTPClient) *Runner {
+	return &Runner{client: httpClient}
+}
+
+// Run invokes the hook with the payload within the hook's timeout
+func (r *Runner) Run(ctx context.Context, hook config.Hook, payload *Payload) (*Result, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode hook payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hook.TimeoutDuration())
+	defer cancel()
+
+	var output []byte
+	if len(hook.Command) > 0 {
+		output, err = r.runCommand(ctx, hook, payload, body)
+	} else {
+		output, err = r.post(ctx, hook, body)
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("hook %s timed out after %s", hook.Name, hook.TimeoutDuration())
+		}
+		return nil, err
+	}
+
+	result := &Result{}
+	if trimmed := bytes.TrimSpace(output); len(trimmed) > 0 {
+		if err := json.Unmarshal(trimmed, result); err != nil {
+			return nil, fmt.Errorf("hook %s returned invalid JSON: %w", hook.Name, err)
+		}
+	}
+	return result, nil
+}
+
+// runCommand runs the hook command with the payload on stdin and a sanitized environment
+func (r *Runner) runCommand(ctx context.Context, hook config.Hook, payload *Payload, body []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = commandEnv(hook, payload)
+	// Do not wait forever on pipes held open by children after the hook is killed
+	cmd.WaitDelay = 5 * time.Second
+
+	var stdout, stderr limitedBuffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("hook %s failed: %w: %s", hook.Name, err, lastLine(msg))
+		}
+		return nil, fmt.Errorf("hook %s failed: %w", hook.Name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// commandEnv builds a minimal environment: a few basics from the service, the hook's own
+// variables and HOOK_* / VIDEO_* values describing the run
+func commandEnv(hook config.Hook, payload *Payload) []string {
+	env := make([]string, 0, len(passthroughEnv)+len(hook.Env)+6)
+	for _, key := range passthroughEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	for key, value := range hook.Env {
+		env = append(env, key+"="+value)
+	}
+	return append(env,
+		"HOOK_NAME="+hook.Name,
+		"HOOK_PHASE="+payload.Phase,
+		"ACCOUNT_ID="+payload.Account.ID,
+		"VIDEO_ID="+payload.Video.ID,
+		"YOUTUBE_VIDEO_ID="+payload.Video.YouTubeVideoID,
+		"VIDEO_FILE="+payload.Video.FilePath,
+	)
+}
+
+// post sends the payload to the hook URL; any non-2xx response is a failure
+func (r *Runner) post(ctx context.Context, hook config.Hook, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("hook %s: %w", hook.Name, redact.Error(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hook-Name", hook.Name)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("hook %s request failed: %w", hook.Name, err)
+	}
+	defer resp.Body.Close()
+
+	output, err := io.ReadAll(io.LimitReader(resp.Body, maxOutput))
+	if err != nil {
+		return nil, fmt.Errorf("hook %s: failed to read response: %w", hook.Name, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("hook %s returned %d: %s", hook.Name, resp.StatusCode, redact.String(lastLine(strings.TrimSpace(string(output)))))
+	}
+	return output, nil
+}
+
+// limitedBuffer keeps the first maxOutput bytes written and discards the rest
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// lastLine returns the last line of s, where tools usually print the actual error
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -0,0 +1,192 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+)
+
+// stubHook returns a hook running testdata/stub_hook.sh in mode
+func stubHook(t *testing.T, mode string, env map[string]string) config.Hook {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("stub hook is a shell script")
+	}
+	script, err := filepath.Abs("testdata/stub_hook.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hookEnv := map[string]string{"STUB_MODE": mode}
+	for key, value := range env {
+		hookEnv[key] = value
+	}
+	return config.Hook{
+		Name:    "stub-" + mode,
+		Phase:   config.HookPhasePreUpload,
+		Command: []string{"/bin/sh", script},
+		Timeout: "10s",
+		Env:     hookEnv,
+	}
+}
+
+func testPayload(filePath string) *Payload {
+	return &Payload{
+		Phase:   config.HookPhasePreUpload,
+		Hook:    "stub",
+		Account: AccountInfo{ID: "acc-1", YouTubeChannelID: "UC-1", TikTokAccountID: "tt-1"},
+		Video: VideoPayload{
+			ID:             "v1",
+			YouTubeVideoID: "yt-1",
+			Title:          "Title",
+			PublishedAt:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			FilePath:       filePath,
+		},
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	dir := t.TempDir()
+	video := filepath.Join(dir, "v1.mp4")
+	if err := os.WriteFile(video, []byte("video\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	replacement := filepath.Join(dir, "v1.watermarked.mp4")
+
+	tests := []struct {
+		name        string
+		mode        string
+		want        *Result
+		wantErrText string
+	}{
+		{name: "no output carries on", mode: "none", want: &Result{}},
+		{name: "abort", mode: "abort", want: &Result{Abort: true, Reason: "watermark missing"}},
+		{name: "replacement file", mode: "replace", want: &Result{FilePath: replacement}},
+		{name: "non-zero exit", mode: "fail", wantErrText: "exit status 3: logo.png: no such file"},
+		{name: "invalid JSON", mode: "garbage", wantErrText: "returned invalid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := stubHook(t, tt.mode, map[string]string{"STUB_OUTPUT": replacement})
+			got, err := NewRunner(nil).Run(context.Background(), hook, testPayload(video))
+			if tt.wantErrText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("Run() error = %v, want it to mention %q", err, tt.wantErrText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Run() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(replacement)
+	if err != nil || string(data) != "video\n+logo\n" {
+		t.Errorf("replacement file = %q, %v; want the video with the logo line", data, err)
+	}
+}
+
+func TestRunCommandPayloadAndEnv(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "payload.json")
+	payload := testPayload("/downloads/v1.mp4")
+	if _, err := NewRunner(nil).Run(context.Background(), stubHook(t, "payload", map[string]string{"STUB_OUTPUT": out}), payload); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Payload
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("hook stdin is not a payload: %v", err)
+	}
+	if !reflect.DeepEqual(&got, payload) {
+		t.Errorf("hook stdin = %+v, want %+v", got, payload)
+	}
+
+	// The service's own environment stays out of the hook
+	t.Setenv("SERVICE_SECRET", "act.secret")
+	result, err := NewRunner(nil).Run(context.Background(), stubHook(t, "env", nil), payload)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if want := "pre_upload|v1|/downloads/v1.mp4|unset"; result.Reason != want {
+		t.Errorf("hook environment = %q, want %q", result.Reason, want)
+	}
+}
+
+func TestRunCommandTimeout(t *testing.T) {
+	hook := stubHook(t, "sleep", nil)
+	hook.Timeout = "200ms"
+
+	start := time.Now()
+	_, err := NewRunner(nil).Run(context.Background(), hook, testPayload(""))
+	if err == nil || !strings.Contains(err.Error(), "timed out after 200ms") {
+		t.Errorf("Run() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Run() returned after %v, want the hook killed at its timeout", elapsed)
+	}
+}
+
+func TestRunHTTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		want        *Result
+		wantErrText string
+	}{
+		{name: "empty response", status: http.StatusNoContent, want: &Result{}},
+		{name: "replacement file", status: http.StatusOK, body: `{"file_path":"/tmp/new.mp4"}`, want: &Result{FilePath: "/tmp/new.mp4"}},
+		{name: "abort", status: http.StatusOK, body: `{"abort":true,"reason":"blocked"}`, want: &Result{Abort: true, Reason: "blocked"}},
+		{name: "error status", status: http.StatusBadGateway, body: "upstream down", wantErrText: "returned 502: upstream down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received Payload
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-Hook-Name") != "notify" || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("headers = %v", r.Header)
+				}
+				json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(server.Close)
+			cfg := &config.Config{HTTPClientTimeout: 5 * time.Second}
+			hook := config.Hook{Name: "notify", Phase: config.HookPhasePostDownload, URL: server.URL}
+
+			got, err := NewRunner(httpclient.NewHTTPClient(cfg)).Run(context.Background(), hook, testPayload("/downloads/v1.mp4"))
+			if tt.wantErrText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("Run() error = %v, want it to mention %q", err, tt.wantErrText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Run() = %+v, want %+v", got, tt.want)
+			}
+			if received.Video.FilePath != "/downloads/v1.mp4" {
+				t.Errorf("request body file_path = %q", received.Video.FilePath)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+#!/bin/sh
+# Stub hook for the runner tests; STUB_MODE (set through the hook's env) picks what it does
+case "$STUB_MODE" in
+abort)
+	echo '{"abort": true, "reason": "watermark missing"}'
+	;;
+replace)
+	# Writes a "watermarked" copy next to STUB_OUTPUT and points the pipeline at it
+	cat "$VIDEO_FILE" > "$STUB_OUTPUT" && echo "+logo" >> "$STUB_OUTPUT"
+	printf '{"file_path": "%s"}\n' "$STUB_OUTPUT"
+	;;
+fail)
+	echo "loading logo.png" >&2
+	echo "logo.png: no such file" >&2
+	exit 3
+	;;
+sleep)
+	exec sleep 30
+	;;
+payload)
+	cat > "$STUB_OUTPUT"
+	;;
+env)
+	printf '{"reason": "%s|%s|%s|%s"}\n' "$HOOK_PHASE" "$VIDEO_ID" "$VIDEO_FILE" "${SERVICE_SECRET:-unset}"
+	;;
+garbage)
+	echo 'not json'
+	;;
+esac
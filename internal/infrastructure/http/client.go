@@ -1,17 +1,26 @@
 package infrastructure
 
 import (
+	"context"
 	"crypto/tls"
+	"io"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/redact"
 )
 
 // HTTPClient provides a high-performance HTTP client with connection pooling
 type HTTPClient struct {
-	client *http.Client
-	config *config.Config
+	client    *http.Client
+	transport *http.Transport
+	config    *config.Config
+	metrics   *UpstreamMetrics
+	limiter   *RateLimiter // Shared by every service using this client
 }
 
 // NewHTTPClient creates a new optimized HTTP client for I/O bound operations
@@ -30,16 +39,49 @@ func NewHTTPClient(cfg *config.Config) *HTTPClient {
 		ForceAttemptHTTP2: true,       // HTTP/2 for better multiplexing
 		WriteBufferSize:   256 * 1024, // 256KB write buffer (increased from 64KB)
 		ReadBufferSize:    256 * 1024, // 256KB read buffer (increased from 64KB)
+		Proxy:             proxyFunc(cfg.HTTPProxies),
 	}
 
+	metrics := NewUpstreamMetrics()
 	client := &http.Client{
-		Transport: transport,
+		Transport: &instrumentedTransport{next: transport, metrics: metrics},
 		Timeout:   cfg.HTTPClientTimeout,
 	}
 
 	return &HTTPClient{
-		client: client,
-		config: cfg,
+		client:    client,
+		transport: transport,
+		config:    cfg,
+		metrics:   metrics,
+		limiter:   NewRateLimiter(cfg.RateLimits),
+	}
+}
+
+// WithProxies returns a client sending its requests through the first of proxies (nil = direct),
+// e.g. cfg.TikTokProxies. It has its own connection pool but shares the metrics and rate limits.
+func (c *HTTPClient) WithProxies(proxies func() []*url.URL) *HTTPClient {
+	transport := c.transport.Clone()
+	transport.Proxy = proxyFunc(proxies)
+	return &HTTPClient{
+		client: &http.Client{
+			Transport: &instrumentedTransport{next: transport, metrics: c.metrics},
+			Timeout:   c.client.Timeout,
+		},
+		transport: transport,
+		config:    c.config,
+		metrics:   c.metrics,
+		limiter:   c.limiter,
+	}
+}
+
+// proxyFunc picks the proxy of each request from the current settings, so proxy changes made
+// through the API apply to new connections
+func proxyFunc(proxies func() []*url.URL) func(*http.Request) (*url.URL, error) {
+	return func(*http.Request) (*url.URL, error) {
+		if list := proxies(); len(list) > 0 {
+			return list[0], nil
+		}
+		return nil, nil
 	}
 }
 
@@ -47,14 +89,130 @@ func NewHTTPClient(cfg *config.Config) *HTTPClient {
 func (c *HTTPClient) Get(url string) (*http.Response, error) {
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return nil, redact.Error(err)
 	}
-	return c.client.Do(req)
+	return c.Do(req)
+}
+
+// maxRetryAfter caps how long a Retry-After header can hold a request back
+const maxRetryAfter = time.Minute
+
+type retrySafeKey struct{}
+
+// WithRetrySafe marks requests made with ctx as safe to retry even if their method is not
+// idempotent, e.g. a token refresh POST. The request body must be replayable (GetBody set).
+func WithRetrySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrySafeKey{}, true)
 }
 
-// Do performs a custom HTTP request
+// Do performs a custom HTTP request. GET and HEAD requests, and requests whose context was
+// marked with WithRetrySafe, are retried on connection errors, 429 and 5xx responses.
+// Every attempt first waits for the host's performance.rate_limits allowance.
+// Transport errors quote the request URL, so credentials in the query string are masked
+// before the error is returned.
 func (c *HTTPClient) Do(req *http.Request) (*http.Response, error) {
-	return c.client.Do(req)
+	retries := 0
+	if c.canRetry(req) {
+		retries = c.config.HTTPRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, redact.Error(err)
+			}
+			req.Body = body
+		}
+
+		if err := c.limiter.Wait(req.Context(), req.URL.Hostname()); err != nil {
+			return nil, redact.Error(err)
+		}
+
+		resp, err := c.client.Do(req)
+		if attempt >= retries || !shouldRetry(req, resp, err) {
+			if err != nil {
+				return resp, redact.Error(err)
+			}
+			return resp, nil
+		}
+
+		delay := c.retryDelay(attempt, resp)
+		if resp != nil {
+			// Drain so the connection can be reused for the retry
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, redact.Error(req.Context().Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// canRetry reports whether the request may be sent more than once
+func (c *HTTPClient) canRetry(req *http.Request) bool {
+	if c.config.HTTPRetries <= 0 {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	safe, _ := req.Context().Value(retrySafeKey{}).(bool)
+	return safe
+}
+
+// shouldRetry reports whether the outcome looks transient
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// Cancellation and deadlines come from the caller, not the server
+		return req.Context().Err() == nil
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// retryDelay returns the wait before the next attempt: the server's Retry-After when given,
+// otherwise exponential backoff with jitter
+func (c *HTTPClient) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(d, maxRetryAfter)
+		}
+	}
+
+	base := c.config.HTTPRetryBackoff
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	backoff := base << attempt
+	// Full jitter in [backoff/2, backoff*3/2) spreads retries from concurrent workers
+	return backoff/2 + rand.N(backoff)
+}
+
+// parseRetryAfter reads a Retry-After value in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// Metrics returns the latency and status counts of requests sent through the client
+func (c *HTTPClient) Metrics() *UpstreamMetrics {
+	return c.metrics
 }
 
 // GetClient returns the underlying HTTP client
@@ -0,0 +1,206 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+)
+
+// flakyServer fails the first failures requests with status (0 = drops the connection) and
+// retryAfter, then answers 200 with the request body echoed back
+type flakyServer struct {
+	failures   int
+	status     int
+	retryAfter string
+
+	mu     sync.Mutex
+	bodies []string // request bodies in order
+}
+
+func (f *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	f.bodies = append(f.bodies, string(body))
+	attempt := len(f.bodies)
+	f.mu.Unlock()
+
+	if attempt > f.failures {
+		w.Write(body)
+		return
+	}
+	if f.status == 0 {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return
+	}
+	if f.retryAfter != "" {
+		w.Header().Set("Retry-After", f.retryAfter)
+	}
+	w.WriteHeader(f.status)
+}
+
+func (f *flakyServer) attempts() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.bodies)
+}
+
+func newTestClient(retries int) *HTTPClient {
+	return NewHTTPClient(&config.Config{
+		HTTPClientTimeout: 5 * time.Second,
+		HTTPRetries:       retries,
+		HTTPRetryBackoff:  time.Millisecond,
+	})
+}
+
+func TestDoRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		retries      int
+		failures     int
+		status       int
+		method       string
+		retrySafe    bool
+		wantAttempts int
+		wantStatus   int // 0 = a transport error
+	}{
+		{name: "GET succeeds after two 502s", retries: 3, failures: 2, status: http.StatusBadGateway, method: http.MethodGet, wantAttempts: 3, wantStatus: http.StatusOK},
+		{name: "GET succeeds after dropped connections", retries: 3, failures: 2, status: 0, method: http.MethodGet, wantAttempts: 3, wantStatus: http.StatusOK},
+		{name: "GET retries 429", retries: 3, failures: 1, status: http.StatusTooManyRequests, method: http.MethodGet, wantAttempts: 2, wantStatus: http.StatusOK},
+		{name: "GET gives up after the retries", retries: 2, failures: 5, status: http.StatusServiceUnavailable, method: http.MethodGet, wantAttempts: 3, wantStatus: http.StatusServiceUnavailable},
+		{name: "GET does not retry client errors", retries: 3, failures: 2, status: http.StatusNotFound, method: http.MethodGet, wantAttempts: 1, wantStatus: http.StatusNotFound},
+		{name: "HEAD is idempotent", retries: 3, failures: 1, status: http.StatusBadGateway, method: http.MethodHead, wantAttempts: 2, wantStatus: http.StatusOK},
+		{name: "retries disabled", retries: 0, failures: 2, status: http.StatusBadGateway, method: http.MethodGet, wantAttempts: 1, wantStatus: http.StatusBadGateway},
+		{name: "POST is not retried", retries: 3, failures: 2, status: http.StatusBadGateway, method: http.MethodPost, wantAttempts: 1, wantStatus: http.StatusBadGateway},
+		{name: "POST is not retried on a dropped connection", retries: 3, failures: 2, status: 0, method: http.MethodPost, wantAttempts: 1},
+		{name: "PUT is not retried", retries: 3, failures: 2, status: http.StatusBadGateway, method: http.MethodPut, wantAttempts: 1, wantStatus: http.StatusBadGateway},
+		{name: "retry-safe POST", retries: 3, failures: 2, status: http.StatusBadGateway, method: http.MethodPost, retrySafe: true, wantAttempts: 3, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &flakyServer{failures: tt.failures, status: tt.status, retryAfter: "0"}
+			server := httptest.NewServer(fake)
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			if tt.retrySafe {
+				ctx = WithRetrySafe(ctx)
+			}
+			var body io.Reader
+			if tt.method == http.MethodPost || tt.method == http.MethodPut {
+				body = strings.NewReader("grant_type=refresh_token")
+			}
+			req, err := http.NewRequestWithContext(ctx, tt.method, server.URL+"/token", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := newTestClient(tt.retries).Do(req)
+			if tt.wantStatus == 0 {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatalf("Do() status = %d, want a transport error", resp.StatusCode)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("Do() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+			if got := fake.attempts(); got != tt.wantAttempts {
+				t.Errorf("server saw %d attempts, want %d", got, tt.wantAttempts)
+			}
+			// Retried bodies are replayed in full
+			for i, got := range fake.bodies {
+				if want := fake.bodies[0]; got != want {
+					t.Errorf("attempt %d body = %q, want %q", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDoStopsRetryingWhenCancelled(t *testing.T) {
+	fake := &flakyServer{failures: 100, status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client := NewHTTPClient(&config.Config{HTTPClientTimeout: 5 * time.Second, HTTPRetries: 5, HTTPRetryBackoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	_, err := client.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Do() returned after %v, want it to stop at the deadline", elapsed)
+	}
+	if got := fake.attempts(); got != 1 {
+		t.Errorf("server saw %d attempts, want 1", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	client := NewHTTPClient(&config.Config{HTTPRetryBackoff: 100 * time.Millisecond})
+	for attempt := range 4 {
+		backoff := 100 * time.Millisecond << attempt
+		for range 50 {
+			if d := client.retryDelay(attempt, nil); d < backoff/2 || d >= backoff*3/2 {
+				t.Fatalf("retryDelay(%d) = %v, want [%v, %v)", attempt, d, backoff/2, backoff*3/2)
+			}
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"7"}}}
+	if d := client.retryDelay(0, resp); d != 7*time.Second {
+		t.Errorf("retryDelay() with Retry-After: 7 = %v, want 7s", d)
+	}
+	resp.Header.Set("Retry-After", "3600")
+	if d := client.retryDelay(0, resp); d != maxRetryAfter {
+		t.Errorf("retryDelay() with Retry-After: 3600 = %v, want %v", d, maxRetryAfter)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "0", want: 0, wantOK: true},
+		{value: "120", want: 2 * time.Minute, wantOK: true},
+		{value: "-1", wantOK: false},
+		{value: "soon", wantOK: false},
+		{value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOK: true}, // in the past
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got, ok := parseRetryAfter(future); !ok || got < 59*time.Minute || got > time.Hour {
+		t.Errorf("parseRetryAfter(%q) = %v, %v; want about an hour", future, got, ok)
+	}
+}
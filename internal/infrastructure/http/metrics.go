@@ -0,0 +1,342 @@
+package infrastructure
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UpstreamWindow is how far back the upstream summary looks
+const UpstreamWindow = time.Hour
+
+// maxUpstreamSamples bounds the samples kept per operation for the summary
+const maxUpstreamSamples = 20000
+
+// LatencyBuckets are the upper bounds of the latency histogram; slower requests only count in +Inf
+var LatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+}
+
+// Status classes counted per operation; StatusError is a request that got no response
+const (
+	Status2xx   = "2xx"
+	Status3xx   = "3xx"
+	Status4xx   = "4xx"
+	Status5xx   = "5xx"
+	StatusError = "error"
+)
+
+// UpstreamMetrics records the latency and outcome of every request sent by the HTTP client,
+// grouped by operation
+type UpstreamMetrics struct {
+	mu         sync.Mutex
+	operations map[string]*operationMetrics
+	now        func() time.Time
+
+	// Last succeeded and failed request per upstream, the first word of the operation
+	lastSuccess map[string]time.Time
+	lastFailure map[string]time.Time
+}
+
+type operationMetrics struct {
+	// buckets[i] counts requests up to LatencyBuckets[i]; the last entry is +Inf
+	buckets  []int64
+	sum      time.Duration
+	count    int64
+	statuses map[string]int64
+	samples  []upstreamSample
+}
+
+type upstreamSample struct {
+	at      time.Time
+	latency time.Duration
+	failed  bool
+}
+
+// OperationCounters are the totals of one operation since start
+type OperationCounters struct {
+	Operation string `json:"operation"`
+	Count     int64  `json:"count"`
+	SumMs     int64  `json:"sum_ms"`
+
+	// Buckets maps an upper bound ("250ms", "+Inf") to the cumulative number of requests
+	// at or below it
+	Buckets  map[string]int64 `json:"buckets"`
+	Statuses map[string]int64 `json:"statuses"`
+}
+
+// OperationSummary describes one operation over UpstreamWindow
+type OperationSummary struct {
+	Operation string  `json:"operation"`
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	P50Ms     int64   `json:"p50_ms"`
+	P95Ms     int64   `json:"p95_ms"`
+	P99Ms     int64   `json:"p99_ms"`
+	MaxMs     int64   `json:"max_ms"`
+}
+
+// NewUpstreamMetrics creates an empty recorder
+func NewUpstreamMetrics() *UpstreamMetrics {
+	return &UpstreamMetrics{
+		operations:  make(map[string]*operationMetrics),
+		now:         time.Now,
+		lastSuccess: make(map[string]time.Time),
+		lastFailure: make(map[string]time.Time),
+	}
+}
+
+// Observe records one request. status is 0 when the request failed without a response.
+func (m *UpstreamMetrics) Observe(operation string, status int, latency time.Duration) {
+	class := statusClass(status)
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op, ok := m.operations[operation]
+	if !ok {
+		op = &operationMetrics{
+			buckets:  make([]int64, len(LatencyBuckets)+1),
+			statuses: make(map[string]int64),
+		}
+		m.operations[operation] = op
+	}
+
+	bucket := sort.Search(len(LatencyBuckets), func(i int) bool { return latency <= LatencyBuckets[i] })
+	op.buckets[bucket]++
+	op.sum += latency
+	op.count++
+	op.statuses[class]++
+
+	op.prune(now)
+	if len(op.samples) >= maxUpstreamSamples {
+		op.samples = op.samples[1:]
+	}
+	op.samples = append(op.samples, upstreamSample{at: now, latency: latency, failed: failedClass(class)})
+
+	upstream, _, _ := strings.Cut(operation, " ")
+	if failedClass(class) {
+		m.lastFailure[upstream] = now
+	} else {
+		m.lastSuccess[upstream] = now
+	}
+}
+
+// LastOutcome returns when a request to the upstream (e.g. "youtube" or "tiktok") last succeeded
+// and last failed; either is zero when it never happened
+func (m *UpstreamMetrics) LastOutcome(upstream string) (succeeded, failed time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastSuccess[upstream], m.lastFailure[upstream]
+}
+
+// Counters returns the totals of every operation, sorted by operation
+func (m *UpstreamMetrics) Counters() []OperationCounters {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counters := make([]OperationCounters, 0, len(m.operations))
+	for name, op := range m.operations {
+		buckets := make(map[string]int64, len(op.buckets))
+		var cumulative int64
+		for i, n := range op.buckets {
+			cumulative += n
+			bound := "+Inf"
+			if i < len(LatencyBuckets) {
+				bound = LatencyBuckets[i].String()
+			}
+			buckets[bound] = cumulative
+		}
+		statuses := make(map[string]int64, len(op.statuses))
+		for class, n := range op.statuses {
+			statuses[class] = n
+		}
+		counters = append(counters, OperationCounters{
+			Operation: name,
+			Count:     op.count,
+			SumMs:     op.sum.Milliseconds(),
+			Buckets:   buckets,
+			Statuses:  statuses,
+		})
+	}
+	sort.Slice(counters, func(i, j int) bool { return counters[i].Operation < counters[j].Operation })
+	return counters
+}
+
+// Summary returns latency percentiles and error rates over UpstreamWindow for operations that
+// had requests in it, sorted by operation
+func (m *UpstreamMetrics) Summary() []OperationSummary {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summaries := make([]OperationSummary, 0, len(m.operations))
+	for name, op := range m.operations {
+		op.prune(now)
+		if len(op.samples) == 0 {
+			continue
+		}
+
+		latencies := make([]time.Duration, len(op.samples))
+		errors := 0
+		for i, sample := range op.samples {
+			latencies[i] = sample.latency
+			if sample.failed {
+				errors++
+			}
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		summaries = append(summaries, OperationSummary{
+			Operation: name,
+			Requests:  len(latencies),
+			Errors:    errors,
+			ErrorRate: float64(errors) / float64(len(latencies)),
+			P50Ms:     percentile(latencies, 50).Milliseconds(),
+			P95Ms:     percentile(latencies, 95).Milliseconds(),
+			P99Ms:     percentile(latencies, 99).Milliseconds(),
+			MaxMs:     latencies[len(latencies)-1].Milliseconds(),
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Operation < summaries[j].Operation })
+	return summaries
+}
+
+// prune drops samples older than UpstreamWindow; samples are kept in time order
+func (op *operationMetrics) prune(now time.Time) {
+	cutoff := now.Add(-UpstreamWindow)
+	drop := sort.Search(len(op.samples), func(i int) bool { return op.samples[i].at.After(cutoff) })
+	if drop > 0 {
+		op.samples = append(op.samples[:0], op.samples[drop:]...)
+	}
+}
+
+// percentile uses the nearest-rank method on sorted values
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func statusClass(status int) string {
+	switch {
+	case status <= 0:
+		return StatusError
+	case status < 300:
+		return Status2xx
+	case status < 400:
+		return Status3xx
+	case status < 500:
+		return Status4xx
+	default:
+		return Status5xx
+	}
+}
+
+// failedClass reports whether a status class counts towards the error rate
+func failedClass(class string) bool {
+	return class == Status4xx || class == Status5xx || class == StatusError
+}
+
+// instrumentedTransport records every round trip, retries included, in UpstreamMetrics
+type instrumentedTransport struct {
+	next    http.RoundTripper
+	metrics *UpstreamMetrics
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	t.metrics.Observe(OperationName(req), status, time.Since(start))
+	return resp, err
+}
+
+type operationKey struct{}
+
+// WithOperation names the operation requests made with ctx are recorded under, for endpoints
+// whose path does not say what they do
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationName labels a request by upstream, method and path, e.g.
+// "tiktok POST /v2/post/publish/video/init". Query strings are left out and path segments that
+// look like IDs or tokens become ":id", so the number of labels stays small.
+func OperationName(req *http.Request) string {
+	if operation, ok := req.Context().Value(operationKey{}).(string); ok && operation != "" {
+		return operation
+	}
+
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i, segment := range segments {
+		if looksLikeID(segment) {
+			segments[i] = ":id"
+		}
+	}
+	return upstreamName(req.URL.Hostname()) + " " + req.Method + " /" + strings.Join(segments, "/")
+}
+
+// upstreamName groups hosts by the service behind them
+func upstreamName(host string) string {
+	host = strings.ToLower(host)
+	switch {
+	case strings.Contains(host, "tiktok"):
+		return "tiktok"
+	case host == "oauth2.googleapis.com":
+		return "google_oauth"
+	case strings.Contains(host, "googleapis.com"), strings.Contains(host, "youtube.com"):
+		return "youtube"
+	case strings.Contains(host, "googlevideo.com"), strings.Contains(host, "ytimg.com"):
+		return "youtube_media"
+	case host == "":
+		return "unknown"
+	default:
+		return host
+	}
+}
+
+// looksLikeID reports whether a path segment is a number, a long token or otherwise mixes
+// digits into a name, as IDs, upload tokens and hashes do
+func looksLikeID(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	if len(segment) > 24 {
+		return true
+	}
+	digits := 0
+	for _, r := range segment {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits == len(segment) {
+		return true
+	}
+	// "v2" style version segments keep their name
+	if digits > 0 && !(len(segment) <= 3 && segment[0] == 'v') {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package infrastructure
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimiter is a token bucket holding up to burst requests, refilled at rate per second
+type hostLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns how long the caller must wait before it may send
+func (l *hostLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// unreserve gives back the token of a caller that stopped waiting
+func (l *hostLimiter) unreserve() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// RateLimiter spaces requests per upstream host. A configured host also covers its
+// subdomains; the most specific match wins.
+type RateLimiter struct {
+	limiters map[string]*hostLimiter
+}
+
+// NewRateLimiter creates a limiter from requests per second by host; hosts with a limit of
+// 0 or less are not limited
+func NewRateLimiter(limits map[string]float64) *RateLimiter {
+	limiters := make(map[string]*hostLimiter, len(limits))
+	now := time.Now()
+	for host, rps := range limits {
+		if rps <= 0 {
+			continue
+		}
+		// Allow a second's worth of requests at once, so short bursts are not delayed
+		burst := max(1, math.Ceil(rps))
+		limiters[strings.ToLower(host)] = &hostLimiter{rate: rps, burst: burst, tokens: burst, last: now}
+	}
+	return &RateLimiter{limiters: limiters}
+}
+
+// Wait blocks until the host's limit allows another request. It returns ctx's error if ctx
+// ends first.
+func (r *RateLimiter) Wait(ctx context.Context, host string) error {
+	limiter := r.limiterFor(host)
+	if limiter == nil {
+		return nil
+	}
+
+	delay := limiter.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		limiter.unreserve()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// limiterFor returns the limiter of the longest configured host that host equals or is a
+// subdomain of, or nil
+func (r *RateLimiter) limiterFor(host string) *hostLimiter {
+	if r == nil || len(r.limiters) == 0 {
+		return nil
+	}
+	host = strings.ToLower(host)
+	for {
+		if limiter, ok := r.limiters[host]; ok {
+			return limiter
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			return nil
+		}
+		host = parent
+	}
+}
@@ -0,0 +1,88 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+)
+
+// Client asks the HTTP moderation service at safety.moderation_url about each video
+type Client struct {
+	url    string
+	token  string
+	client *httpclient.HTTPClient
+}
+
+// NewModerator builds the moderation client. It returns nil when safety.moderation_url is not
+// set, which leaves only the accounts' denylists.
+func NewModerator(cfg *config.Config, httpClient *httpclient.HTTPClient) domain.Moderator {
+	if cfg.SafetyModerationURL == "" {
+		return nil
+	}
+	return &Client{url: cfg.SafetyModerationURL, token: cfg.SafetyModerationToken, client: httpClient}
+}
+
+// Moderate posts the caption and base64 JPEG frames as JSON and reads
+// {"decision": "allow|deny|review", "reason": "..."}
+func (c *Client) Moderate(ctx context.Context, req *domain.ModerationRequest) (*domain.ModerationResult, error) {
+	frames := make([]string, 0, len(req.Frames))
+	for _, frame := range req.Frames {
+		frames = append(frames, base64.StdEncoding.EncodeToString(frame))
+	}
+	body, err := json.Marshal(map[string]any{
+		"video_id":   req.VideoID,
+		"account_id": req.AccountID,
+		"caption":    req.Caption,
+		"frames":     frames,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("moderation service returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Decision string `json:"decision"`
+		Reason   string `json:"reason"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid moderation response: %w", err)
+	}
+	decision := domain.SafetyDecision(strings.ToLower(strings.TrimSpace(result.Decision)))
+	switch decision {
+	case domain.SafetyDecisionAllow, domain.SafetyDecisionDeny, domain.SafetyDecisionReview:
+	default:
+		return nil, fmt.Errorf("moderation service returned unknown decision %q", result.Decision)
+	}
+	return &domain.ModerationResult{Decision: decision, Reason: strings.TrimSpace(result.Reason)}, nil
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/version"
+)
+
+// NewNotifier builds the notifier chain from config: events are always logged and
+// additionally posted to the webhook when one is configured.
+func NewNotifier(cfg *config.Config, httpClient *httpclient.HTTPClient) domain.Notifier {
+	notifiers := MultiNotifier{LogNotifier{}}
+	if cfg.NotifyWebhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(cfg.NotifyWebhookURL, httpClient))
+	}
+	return notifiers
+}
+
+// LogNotifier writes notifications to the application log
+type LogNotifier struct{}
+
+// Notify logs the notification
+func (LogNotifier) Notify(ctx context.Context, n *domain.Notification) error {
+	logger.Info().Printf("[NOTIFY] %s account=%s: %s", n.Event, n.AccountID, n.Message)
+	return nil
+}
+
+// WebhookNotifier posts notifications as JSON to a URL
+type WebhookNotifier struct {
+	url    string
+	client *httpclient.HTTPClient
+}
+
+// NewWebhookNotifier creates a new webhook notifier
+func NewWebhookNotifier(url string, httpClient *httpclient.HTTPClient) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: httpClient,
+	}
+}
+
+// Notify posts the notification to the webhook
+func (w *WebhookNotifier) Notify(ctx context.Context, n *domain.Notification) error {
+	payload := map[string]any{
+		"event":      n.Event,
+		"account_id": n.AccountID,
+		"message":    n.Message,
+		"time":       n.Time.UTC().Format(time.RFC3339),
+		"version":    version.Get().Version,
+	}
+	if n.URL != "" {
+		payload["url"] = n.URL
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MultiNotifier fans a notification out to several notifiers
+type MultiNotifier []domain.Notifier
+
+// Notify delivers to every notifier and returns the first error
+func (m MultiNotifier) Notify(ctx context.Context, n *domain.Notification) error {
+	var firstErr error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
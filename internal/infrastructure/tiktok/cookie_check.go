@@ -0,0 +1,117 @@
+package tiktok
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// accountInfoURL is the web endpoint that names the login behind a set of session cookies
+const accountInfoURL = "https://www.tiktok.com/passport/web/account/info/?aid=1988"
+
+// ErrInvalidCookies is returned when cookies are not a JSON cookie export
+var ErrInvalidCookies = errors.New("invalid cookie export")
+
+// ErrCookiesSignedOut is returned when TikTok does not accept the cookies as a signed-in session
+var ErrCookiesSignedOut = errors.New("cookies are not signed in to TikTok")
+
+// WebIdentity is the TikTok login a set of web session cookies belongs to
+type WebIdentity struct {
+	UserID   string // Numeric web user ID, unrelated to the API open_id
+	Username string // Unique @name without the @
+	Nickname string // Display name, as /user/info/ reports it
+}
+
+// webCookie is one cookie of an EditThisCookie-style JSON export, the format login writes
+type webCookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expirationDate"`
+	HttpOnly bool    `json:"httpOnly"`
+	Secure   bool    `json:"secure"`
+	SameSite string  `json:"sameSite"`
+}
+
+// parseCookies reads a JSON cookie export
+func parseCookies(data []byte) ([]webCookie, error) {
+	var cookies []webCookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return nil, fmt.Errorf("%w: not a JSON array of cookies: %v", ErrInvalidCookies, err)
+	}
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("%w: no cookies", ErrInvalidCookies)
+	}
+	return cookies, nil
+}
+
+// InspectCookies asks TikTok which login the exported cookies are signed in as. It returns
+// ErrCookiesSignedOut when the session is missing, expired or rejected.
+func (s *Service) InspectCookies(ctx context.Context, data []byte) (*WebIdentity, error) {
+	cookies, err := parseCookies(data)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, accountInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", webUserAgent)
+	hasSession := false
+	for _, c := range cookies {
+		if !strings.HasSuffix(strings.TrimPrefix(c.Domain, "."), "tiktok.com") {
+			continue
+		}
+		if c.Name == "sessionid" && c.Value != "" {
+			hasSession = true
+		}
+		req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	if !hasSession {
+		return nil, fmt.Errorf("%w: no tiktok.com sessionid cookie", ErrCookiesSignedOut)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tiktok account info returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Message string `json:"message"`
+		Data    struct {
+			UserID     string `json:"user_id_str"`
+			Username   string `json:"username"`
+			ScreenName string `json:"screen_name"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode tiktok account info: %w", err)
+	}
+	if result.Message != "success" || result.Data.UserID == "" {
+		return nil, ErrCookiesSignedOut
+	}
+	return &WebIdentity{
+		UserID:   result.Data.UserID,
+		Username: result.Data.Username,
+		Nickname: result.Data.ScreenName,
+	}, nil
+}
+
+// SaveCookies replaces the web upload cookies file with a JSON cookie export
+func (s *Service) SaveCookies(data []byte) error {
+	return s.webUploader.writeCookies(data)
+}
+
+// CheckCookies returns why the web upload cookies file cannot be used, or nil; see CookiesState
+func (s *Service) CheckCookies() error {
+	return s.webUploader.CheckCookies()
+}
@@ -0,0 +1,173 @@
+package tiktok
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/logger"
+)
+
+// ErrCookiesMissing is returned when the cookies file does not exist
+var ErrCookiesMissing = errors.New("cookies file is missing")
+
+// ErrCookiesCorrupt is returned when the cookies file is empty, truncated or not a JSON cookie
+// export, e.g. after a crash in the middle of a save by an older version
+var ErrCookiesCorrupt = errors.New("cookies file is corrupt")
+
+// States of the cookies file, as reported in account health
+const (
+	CookiesStateOK         = "ok"
+	CookiesStateMissing    = "missing"
+	CookiesStateCorrupt    = "corrupt"
+	CookiesStateSignedOut  = "signed_out" // Valid, but without a live TikTok session
+	CookiesStateUnreadable = "unreadable" // Could not be read, e.g. for lack of permission
+)
+
+// cookiesBackupSuffix names the copy of the previous cookies file kept by each save
+const cookiesBackupSuffix = ".bak"
+
+// CookiesState names the cookies file state an error of readCookiesFile stands for
+func CookiesState(err error) string {
+	switch {
+	case err == nil:
+		return CookiesStateOK
+	case errors.Is(err, ErrCookiesMissing):
+		return CookiesStateMissing
+	case errors.Is(err, ErrCookiesCorrupt):
+		return CookiesStateCorrupt
+	case errors.Is(err, ErrCookiesSignedOut):
+		return CookiesStateSignedOut
+	default:
+		return CookiesStateUnreadable
+	}
+}
+
+// readCookiesFile reads and checks a cookies file. It returns ErrCookiesMissing, ErrCookiesCorrupt,
+// or ErrCookiesSignedOut when the cookies hold no unexpired tiktok.com session.
+func readCookiesFile(path string) ([]webCookie, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrCookiesMissing, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cookies, err := parseCookies(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrCookiesCorrupt, path, err)
+	}
+	if err := checkSessionCookie(cookies, time.Now()); err != nil {
+		return nil, err
+	}
+	return cookies, nil
+}
+
+// checkSessionCookie returns ErrCookiesSignedOut unless the cookies include an unexpired
+// tiktok.com sessionid
+func checkSessionCookie(cookies []webCookie, now time.Time) error {
+	for _, c := range cookies {
+		if c.Name != "sessionid" || c.Value == "" || !strings.HasSuffix(strings.TrimPrefix(c.Domain, "."), "tiktok.com") {
+			continue
+		}
+		// Session cookies have no expiry
+		if c.Expires > 0 && time.Unix(int64(c.Expires), 0).Before(now) {
+			return fmt.Errorf("%w: the sessionid cookie expired on %s", ErrCookiesSignedOut,
+				time.Unix(int64(c.Expires), 0).Format(time.DateOnly))
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: no tiktok.com sessionid cookie", ErrCookiesSignedOut)
+}
+
+// CheckCookies reads the cookies file like an upload would and returns why it cannot be used,
+// or nil
+func (u *WebUploader) CheckCookies() error {
+	if u.cookiesPath == "" {
+		return fmt.Errorf("cookies path is empty")
+	}
+	_, err := u.readCookies()
+	return err
+}
+
+// readCookies reads the cookies file, rolling a corrupt one back to the backup of the previous
+// save when that is usable
+func (u *WebUploader) readCookies() ([]webCookie, error) {
+	cookies, err := readCookiesFile(u.cookiesPath)
+	if !errors.Is(err, ErrCookiesCorrupt) {
+		return cookies, err
+	}
+
+	backupPath := u.cookiesPath + cookiesBackupSuffix
+	backup, backupErr := readCookiesFile(backupPath)
+	if backupErr != nil {
+		return nil, err
+	}
+	data, readErr := os.ReadFile(backupPath)
+	if readErr != nil {
+		return nil, err
+	}
+	if writeErr := writeFileAtomic(u.cookiesPath, data); writeErr != nil {
+		logger.Error().Printf("Failed to restore cookies file %s from its backup: %v", u.cookiesPath, writeErr)
+		return nil, err
+	}
+	logger.Error().Printf("Restored cookies file %s from its backup: %v", u.cookiesPath, err)
+	return backup, nil
+}
+
+// writeCookies replaces the cookies file with a JSON cookie export. The file is replaced
+// atomically, so a crash leaves either the old or the new cookies, and the previous file is kept
+// as a backup when it is intact.
+func (u *WebUploader) writeCookies(data []byte) error {
+	if u.cookiesPath == "" {
+		return fmt.Errorf("cookies path is empty")
+	}
+	if _, err := parseCookies(data); err != nil {
+		return err
+	}
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(u.cookiesPath), 0755); err != nil {
+		return err
+	}
+
+	if previous, err := os.ReadFile(u.cookiesPath); err == nil {
+		if _, err := parseCookies(previous); err == nil {
+			if err := writeFileAtomic(u.cookiesPath+cookiesBackupSuffix, previous); err != nil {
+				return fmt.Errorf("failed to back up cookies file: %w", err)
+			}
+		}
+	}
+
+	return writeFileAtomic(u.cookiesPath, data)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
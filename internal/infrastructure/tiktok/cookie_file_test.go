@@ -0,0 +1,221 @@
+package tiktok
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// cookieExport is a browser extension export with the given tiktok.com sessionid (none if empty)
+// expiring at expires (a session cookie if zero)
+func cookieExport(session string, expires time.Time) string {
+	sessionCookie := ""
+	if session != "" {
+		expiry := ""
+		if !expires.IsZero() {
+			expiry = fmt.Sprintf(`,"expirationDate":%d.5`, expires.Unix())
+		}
+		sessionCookie = fmt.Sprintf(`,{"name":"sessionid","value":%q,"domain":".tiktok.com","path":"/","httpOnly":true,"secure":true,"sameSite":"no_restriction"%s}`,
+			session, expiry)
+	}
+	return `[{"name":"tt_csrf_token","value":"x1y2","domain":".tiktok.com","path":"/","httpOnly":true,"secure":true,"sameSite":"lax"}` +
+		sessionCookie + `]`
+}
+
+func TestReadCookiesFile(t *testing.T) {
+	now := time.Now()
+	valid := cookieExport("9f8e7d", now.Add(30*24*time.Hour))
+	tests := []struct {
+		name      string
+		content   *string // nil: no file
+		wantErr   error
+		wantState string
+	}{
+		{name: "missing", wantErr: ErrCookiesMissing, wantState: CookiesStateMissing},
+		{name: "empty", content: ptr(""), wantErr: ErrCookiesCorrupt, wantState: CookiesStateCorrupt},
+		{name: "whitespace", content: ptr("\n  \n"), wantErr: ErrCookiesCorrupt, wantState: CookiesStateCorrupt},
+		{name: "truncated mid-save", content: ptr(valid[:len(valid)/2]), wantErr: ErrCookiesCorrupt, wantState: CookiesStateCorrupt},
+		{name: "truncated before the last bracket", content: ptr(valid[:len(valid)-1]), wantErr: ErrCookiesCorrupt, wantState: CookiesStateCorrupt},
+		{name: "NUL bytes after a crash", content: ptr("\x00\x00\x00\x00"), wantErr: ErrCookiesCorrupt, wantState: CookiesStateCorrupt},
+		{name: "not a cookie list", content: ptr(`{"cookies":[]}`), wantErr: ErrCookiesCorrupt, wantState: CookiesStateCorrupt},
+		{name: "no cookies", content: ptr(`[]`), wantErr: ErrCookiesCorrupt, wantState: CookiesStateCorrupt},
+		{name: "signed out", content: ptr(cookieExport("", time.Time{})), wantErr: ErrCookiesSignedOut, wantState: CookiesStateSignedOut},
+		{name: "empty session", content: ptr(`[{"name":"sessionid","value":"","domain":".tiktok.com"}]`),
+			wantErr: ErrCookiesSignedOut, wantState: CookiesStateSignedOut},
+		{name: "session of another site", content: ptr(`[{"name":"sessionid","value":"abc","domain":".example.com"}]`),
+			wantErr: ErrCookiesSignedOut, wantState: CookiesStateSignedOut},
+		{name: "expired session", content: ptr(cookieExport("9f8e7d", now.Add(-time.Hour))), wantErr: ErrCookiesSignedOut, wantState: CookiesStateSignedOut},
+		{name: "session cookie without expiry", content: ptr(cookieExport("9f8e7d", time.Time{})), wantState: CookiesStateOK},
+		{name: "valid", content: ptr(valid), wantState: CookiesStateOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cookies.json")
+			if tt.content != nil {
+				if err := os.WriteFile(path, []byte(*tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cookies, err := readCookiesFile(path)
+			if tt.wantErr == nil {
+				if err != nil || len(cookies) == 0 {
+					t.Fatalf("readCookiesFile() = %d cookies, %v", len(cookies), err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("readCookiesFile() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := CookiesState(err); got != tt.wantState {
+				t.Errorf("CookiesState() = %s, want %s", got, tt.wantState)
+			}
+		})
+	}
+
+	if got := CookiesState(os.ErrPermission); got != CookiesStateUnreadable {
+		t.Errorf("CookiesState(permission error) = %s, want %s", got, CookiesStateUnreadable)
+	}
+}
+
+func TestReadCookiesRollback(t *testing.T) {
+	good := cookieExport("from-backup", time.Time{})
+	current := cookieExport("current", time.Time{})
+	tests := []struct {
+		name       string
+		file       string
+		backup     *string // nil: no backup
+		wantErr    error
+		wantFile   string // content of the cookies file afterwards
+		wantCookie string // sessionid read
+	}{
+		{name: "truncated file restored", file: good[:20], backup: ptr(good), wantFile: good, wantCookie: "from-backup"},
+		{name: "empty file restored", file: "", backup: ptr(good), wantFile: good, wantCookie: "from-backup"},
+		{name: "no backup", file: "", wantErr: ErrCookiesCorrupt, wantFile: ""},
+		{name: "corrupt backup", file: good[:20], backup: ptr(good[:10]), wantErr: ErrCookiesCorrupt, wantFile: good[:20]},
+		{name: "signed-out backup", file: "", backup: ptr(cookieExport("", time.Time{})), wantErr: ErrCookiesCorrupt, wantFile: ""},
+		// Only a corrupt file is rolled back; a signed-out one is what the user exported
+		{name: "signed out is kept", file: cookieExport("", time.Time{}), backup: ptr(good),
+			wantErr: ErrCookiesSignedOut, wantFile: cookieExport("", time.Time{})},
+		{name: "valid file is kept", file: current, backup: ptr(good), wantFile: current, wantCookie: "current"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cookies.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.backup != nil {
+				if err := os.WriteFile(path+cookiesBackupSuffix, []byte(*tt.backup), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			uploader := NewWebUploader(path, true)
+
+			cookies, err := uploader.readCookies()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("readCookies() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("readCookies() error = %v", err)
+			}
+			if tt.wantCookie != "" && sessionID(cookies) != tt.wantCookie {
+				t.Errorf("sessionid = %q, want %q", sessionID(cookies), tt.wantCookie)
+			}
+			data, _ := os.ReadFile(path)
+			if string(data) != tt.wantFile {
+				t.Errorf("cookies file afterwards = %q, want %q", data, tt.wantFile)
+			}
+			// The health check sees the same state, and a restored file stays restored
+			if got := uploader.CheckCookies(); !errors.Is(got, tt.wantErr) {
+				t.Errorf("CheckCookies() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteCookies(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state", "cookies.json")
+	uploader := NewWebUploader(path, true)
+	first := cookieExport("first", time.Time{})
+	second := cookieExport("second", time.Time{})
+
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "<" + err.Error() + ">"
+		}
+		return string(data)
+	}
+
+	// The first save creates the directory and has nothing to back up
+	if err := uploader.writeCookies([]byte(first)); err != nil {
+		t.Fatalf("writeCookies() error = %v", err)
+	}
+	if read(path) != first {
+		t.Errorf("cookies file = %q, want the first export", read(path))
+	}
+	if _, err := os.Stat(path + cookiesBackupSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup after the first save: %v, want none", err)
+	}
+
+	// The next save keeps the previous file as the backup
+	if err := uploader.writeCookies([]byte(second)); err != nil {
+		t.Fatalf("writeCookies() error = %v", err)
+	}
+	if read(path) != second || read(path+cookiesBackupSuffix) != first {
+		t.Errorf("after the second save: file %q, backup %q", read(path), read(path+cookiesBackupSuffix))
+	}
+
+	// Data that is not a cookie export is refused and changes nothing
+	for _, bad := range []string{"", "[", "[]", second[:len(second)/2]} {
+		if err := uploader.writeCookies([]byte(bad)); !errors.Is(err, ErrInvalidCookies) {
+			t.Errorf("writeCookies(%q) error = %v, want %v", bad, err, ErrInvalidCookies)
+		}
+	}
+	if read(path) != second || read(path+cookiesBackupSuffix) != first {
+		t.Errorf("after refused saves: file %q, backup %q", read(path), read(path+cookiesBackupSuffix))
+	}
+
+	// A corrupt file left by an older version does not replace the good backup
+	if err := os.WriteFile(path, []byte(second[:15]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := uploader.writeCookies([]byte(first)); err != nil {
+		t.Fatalf("writeCookies() error = %v", err)
+	}
+	if read(path) != first || read(path+cookiesBackupSuffix) != first {
+		t.Errorf("after saving over a corrupt file: file %q, backup %q", read(path), read(path+cookiesBackupSuffix))
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if fmt.Sprint(names) != "[cookies.json cookies.json.bak]" {
+		t.Errorf("files next to the cookies = %v", names)
+	}
+
+	if err := NewWebUploader("", true).writeCookies([]byte(first)); err == nil {
+		t.Error("writeCookies() without a path succeeded")
+	}
+}
+
+func sessionID(cookies []webCookie) string {
+	for _, c := range cookies {
+		if c.Name == "sessionid" {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,99 @@
+package tiktok
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// CreatorInfo is what the creator of an access token may post with, as of now
+type CreatorInfo struct {
+	// PrivacyLevelOptions are the privacy levels the creator can pick; an unaudited app only
+	// gets SELF_ONLY
+	PrivacyLevelOptions []string
+
+	// CommentDisabled, DuetDisabled and StitchDisabled are set when the creator turned the
+	// interaction off in the TikTok app; posts must then disable it too
+	CommentDisabled bool
+	DuetDisabled    bool
+	StitchDisabled  bool
+
+	// MaxVideoPostDurationSec is the longest video the creator can post, in seconds
+	MaxVideoPostDurationSec int
+}
+
+// AllowsPrivacyLevel reports whether the creator can post with the privacy level. An empty
+// option list (an older API version) allows every level.
+func (c *CreatorInfo) AllowsPrivacyLevel(level string) bool {
+	return len(c.PrivacyLevelOptions) == 0 || slices.Contains(c.PrivacyLevelOptions, level)
+}
+
+// CheckPrivacyLevel returns an error naming the creator's options when it cannot post with level
+func (c *CreatorInfo) CheckPrivacyLevel(level string) error {
+	if c.AllowsPrivacyLevel(level) {
+		return nil
+	}
+	if len(c.PrivacyLevelOptions) == 1 && c.PrivacyLevelOptions[0] == PrivacySelfOnly {
+		return fmt.Errorf("%w %s: %s", ErrInvalidPrivacyLevel, level, unauditedHint)
+	}
+	return fmt.Errorf("%w %s: this TikTok account can only post with %s", ErrInvalidPrivacyLevel, level,
+		strings.Join(c.PrivacyLevelOptions, ", "))
+}
+
+// QueryCreatorInfo asks TikTok which privacy levels and interactions the access token's creator
+// can post with. TikTok expects this before every direct post.
+func (s *Service) QueryCreatorInfo(accessToken string) (*CreatorInfo, error) {
+	httpReq, err := s.newJSONRequest(http.MethodPost, s.combinePath(s.creatorInfoPath), nil, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := scopeErrorIn(bodyBytes, ScopeVideoPublish); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("creator info failed with status %d: %s", resp.StatusCode, previewBody(bodyBytes))
+	}
+
+	var result struct {
+		Data struct {
+			PrivacyLevelOptions     []string `json:"privacy_level_options"`
+			CommentDisabled         bool     `json:"comment_disabled"`
+			DuetDisabled            bool     `json:"duet_disabled"`
+			StitchDisabled          bool     `json:"stitch_disabled"`
+			MaxVideoPostDurationSec int      `json:"max_video_post_duration_sec"`
+		} `json:"data"`
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode creator info response: %w; body=%s", err, previewBody(bodyBytes))
+	}
+	if result.Error.Code != "" && result.Error.Code != "ok" {
+		return nil, fmt.Errorf("TikTok API error: %s - %s", result.Error.Code, result.Error.Message)
+	}
+
+	return &CreatorInfo{
+		PrivacyLevelOptions:     result.Data.PrivacyLevelOptions,
+		CommentDisabled:         result.Data.CommentDisabled,
+		DuetDisabled:            result.Data.DuetDisabled,
+		StitchDisabled:          result.Data.StitchDisabled,
+		MaxVideoPostDurationSec: result.Data.MaxVideoPostDurationSec,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package tiktok
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Publish statuses reported by the v2 status endpoint
+const (
+	PublishStatusComplete        = "PUBLISH_COMPLETE"
+	PublishStatusSentToInbox     = "SEND_TO_USER_INBOX"
+	PublishStatusFailed          = "FAILED"
+	PublishStatusDownloading     = "PROCESSING_DOWNLOAD"
+	PublishStatusUploading       = "PROCESSING_UPLOAD"
+	PublishStatusProcessingVideo = "PROCESSING_VIDEO"
+)
+
+// ErrPublishNotFound is returned when TikTok does not know the publish ID, e.g. because
+// the upload never got past init or the ID came from an endpoint without status tracking
+var ErrPublishNotFound = errors.New("publish id not found")
+
+// PublishStatus is the state of an API upload as reported by TikTok
+type PublishStatus struct {
+	Status     string
+	FailReason string
+	// PostID is the public post ID once the video is visible; empty for drafts and private posts
+	PostID string
+}
+
+// Done reports whether the upload reached the user's profile or inbox
+func (p *PublishStatus) Done() bool {
+	return p.Status == PublishStatusComplete || p.Status == PublishStatusSentToInbox
+}
+
+// Failed reports whether TikTok gave up on the upload
+func (p *PublishStatus) Failed() bool {
+	return p.Status == PublishStatusFailed
+}
+
+// InProgress reports whether TikTok is still receiving or processing the upload
+func (p *PublishStatus) InProgress() bool {
+	return strings.HasPrefix(p.Status, "PROCESSING_")
+}
+
+// FetchPublishStatus asks TikTok what became of an upload started with the given publish ID
+func (s *Service) FetchPublishStatus(accessToken, publishID string) (*PublishStatus, error) {
+	if publishID == "" {
+		return nil, ErrPublishNotFound
+	}
+
+	httpReq, err := s.newJSONRequest(http.MethodPost, s.combinePath(s.statusPath), map[string]string{"publish_id": publishID}, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		return nil, fmt.Errorf("%w: status %d: %s", ErrPublishNotFound, resp.StatusCode, previewBody(bodyBytes))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("publish status failed with status %d: %s", resp.StatusCode, previewBody(bodyBytes))
+	}
+
+	var result struct {
+		Data struct {
+			Status     string            `json:"status"`
+			FailReason string            `json:"fail_reason"`
+			PostIDs    []json.RawMessage `json:"publicaly_available_post_id"` // sic, as spelled by TikTok
+		} `json:"data"`
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode publish status response: %w; body=%s", err, previewBody(bodyBytes))
+	}
+
+	if result.Error.Code != "" && result.Error.Code != "ok" {
+		if strings.Contains(result.Error.Code, "invalid") || strings.Contains(result.Error.Code, "not_found") {
+			return nil, fmt.Errorf("%w: %s - %s", ErrPublishNotFound, result.Error.Code, result.Error.Message)
+		}
+		return nil, fmt.Errorf("TikTok API error: %s - %s", result.Error.Code, result.Error.Message)
+	}
+
+	status := &PublishStatus{
+		Status:     result.Data.Status,
+		FailReason: result.Data.FailReason,
+	}
+	if len(result.Data.PostIDs) > 0 {
+		// Post IDs are 64-bit numbers; keep them as text so they are not rounded
+		raw := strings.Trim(string(result.Data.PostIDs[0]), `"`)
+		if _, err := strconv.ParseUint(raw, 10, 64); err == nil {
+			status.PostID = raw
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,123 @@
+package tiktok
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Privacy levels accepted by the Content Posting API
+const (
+	PrivacyPublic        = "PUBLIC_TO_EVERYONE"
+	PrivacyMutualFriends = "MUTUAL_FOLLOW_FRIENDS"
+	PrivacyFollowers     = "FOLLOWER_OF_CREATOR"
+	PrivacySelfOnly      = "SELF_ONLY"
+)
+
+// TikTok only accepts scheduled posts between 15 minutes and 10 days ahead
+const (
+	MinScheduleLead = 15 * time.Minute
+	MaxScheduleLead = 10 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidPrivacyLevel is returned for privacy levels the API does not know
+	ErrInvalidPrivacyLevel = errors.New("invalid privacy level")
+
+	// ErrInvalidSchedule is returned for schedule times TikTok would reject
+	ErrInvalidSchedule = errors.New("invalid schedule time")
+
+	// ErrUnauditedClient is returned when TikTok refuses a post because the developer app has not
+	// passed its audit, which limits it to private (SELF_ONLY) posts
+	ErrUnauditedClient = errors.New("TikTok app is not audited")
+)
+
+// unauditedClientCode is TikTok's error code for a public post from an unaudited app
+const unauditedClientCode = "unaudited_client_can_only_post_to_private_accounts"
+
+// unauditedHint tells the operator how to post from an unaudited app
+const unauditedHint = "the TikTok app has not passed TikTok's audit yet, so it can only post SELF_ONLY videos " +
+	"to private accounts; set the account's privacy_level to SELF_ONLY and make the TikTok account private, " +
+	"or use an audited app"
+
+// unauditedErrorIn returns an ErrUnauditedClient error when a response body reports that the
+// app may only post privately, whatever the HTTP status, and nil otherwise
+func unauditedErrorIn(body []byte) error {
+	var result struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Error.Code != unauditedClientCode {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnauditedClient, unauditedHint)
+}
+
+// ValidatePrivacyLevel checks a privacy level; empty means the default (public)
+func ValidatePrivacyLevel(level string) error {
+	switch level {
+	case "", PrivacyPublic, PrivacyMutualFriends, PrivacyFollowers, PrivacySelfOnly:
+		return nil
+	}
+	return fmt.Errorf("%w %q: use %s, %s, %s or %s", ErrInvalidPrivacyLevel, level,
+		PrivacyPublic, PrivacyMutualFriends, PrivacyFollowers, PrivacySelfOnly)
+}
+
+// ValidateScheduleTime checks that a publish time is 15 minutes to 10 days after now; the zero
+// time means publish immediately
+func ValidateScheduleTime(scheduleTime, now time.Time) error {
+	if scheduleTime.IsZero() {
+		return nil
+	}
+	lead := scheduleTime.Sub(now)
+	if lead < MinScheduleLead || lead > MaxScheduleLead {
+		return fmt.Errorf("%w: %s is %s from now, must be between %s and %s ahead", ErrInvalidSchedule,
+			scheduleTime.Format(time.RFC3339), lead.Round(time.Second), MinScheduleLead, MaxScheduleLead)
+	}
+	return nil
+}
+
+// ValidatePublishOptions rejects request options the API would refuse, before anything is sent
+func ValidatePublishOptions(req *UploadRequest, now time.Time) error {
+	if err := ValidatePrivacyLevel(req.PrivacyLevel); err != nil {
+		return err
+	}
+	if err := ValidateScheduleTime(req.ScheduleTime, now); err != nil {
+		return err
+	}
+	if req.PostAsDraft && !req.ScheduleTime.IsZero() {
+		return fmt.Errorf("%w: drafts are published by the creator and cannot be scheduled", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// PostInfo returns the post_info a direct post of the request sends with publish
+func PostInfo(req *UploadRequest) map[string]any {
+	postInfo := map[string]any{
+		"disable_comment": req.DisableComment,
+		"disable_duet":    req.DisableDuet,
+		"disable_stitch":  req.DisableStitch,
+	}
+	if req.Title != "" {
+		postInfo["title"] = req.Title
+	}
+	if req.Description != "" {
+		postInfo["description"] = req.Description
+	}
+	privacyLevel := req.PrivacyLevel
+	if privacyLevel == "" {
+		privacyLevel = PrivacyPublic
+	}
+	postInfo["privacy_level"] = privacyLevel
+	if !req.ScheduleTime.IsZero() {
+		postInfo["schedule_time"] = req.ScheduleTime.Unix()
+	}
+	return postInfo
+}
+
+// WebCaption returns the caption the web uploader types for the request
+func WebCaption(req *UploadRequest) string {
+	return req.Title + " #fyp #tiktok"
+}
@@ -0,0 +1,63 @@
+package tiktok
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Scopes the integration asks TikTok for
+const (
+	ScopeUserInfoBasic = "user.info.basic"     // Reading the login's open_id and profile
+	ScopeVideoUpload   = "video.upload"        // Uploading drafts to the creator's inbox
+	ScopeVideoPublish  = "video.publish"       // Posting directly to the profile
+	ScopeComment       = "comment.list.manage" // Commenting through tiktok.comment_path
+)
+
+// DefaultScopes are requested by every authorization
+var DefaultScopes = []string{ScopeUserInfoBasic, ScopeVideoUpload}
+
+// ErrScopeInsufficient is wrapped by ScopeError, for callers that only need the class
+var ErrScopeInsufficient = errors.New("tiktok token lacks a required scope")
+
+// ScopeError is returned when TikTok accepts the access token but refuses the request because the
+// authorization did not grant the scope the endpoint needs. Refreshing the token does not help;
+// the account has to be authorized again with the scope, which the app must be approved for.
+type ScopeError struct {
+	Scope   string // The scope the endpoint needs
+	Code    string // TikTok's error code, e.g. scope_not_authorized
+	Message string
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("TikTok API error: %s - %s (missing scope %s)", e.Code, e.Message, e.Scope)
+}
+
+func (e *ScopeError) Unwrap() error {
+	return ErrScopeInsufficient
+}
+
+// isScopeErrorCode reports whether a TikTok error code means the token lacks the endpoint's scope
+func isScopeErrorCode(code string) bool {
+	switch code {
+	case "scope_not_authorized", "scope_permission_missed":
+		return true
+	default:
+		return false
+	}
+}
+
+// scopeErrorIn returns a *ScopeError when a response body reports a missing scope, whatever the
+// HTTP status, and nil otherwise. scope is the scope the endpoint needs.
+func scopeErrorIn(body []byte, scope string) error {
+	var result struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || !isScopeErrorCode(result.Error.Code) {
+		return nil
+	}
+	return &ScopeError{Scope: scope, Code: result.Error.Code, Message: result.Error.Message}
+}
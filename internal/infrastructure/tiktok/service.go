@@ -4,45 +4,62 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"auto_upload_tiktok/config"
 	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/redact"
 )
 
 // Service handles TikTok API interactions
 type Service struct {
-	apiKey         string
-	apiSecret      string
-	region         string
-	client         *httpclient.HTTPClient
-	baseURL        string
-	uploadInitPath string
-	publishPath    string
-	enableWeb      bool
-	cookiesPath    string
-	webUploader    *WebUploader
+	region          string
+	client          *httpclient.HTTPClient
+	baseURL         string
+	uploadInitPath  string
+	inboxInitPath   string
+	publishPath     string
+	uploadMethod    string
+	uploadField     string
+	commentPath     string
+	statusPath      string
+	creatorInfoPath string
+	enableWeb       bool
+	cookiesPath     string
+	webUploader     *WebUploader
+	bufferSize      int
 }
 
 // NewService creates a new TikTok service
 func NewService(cfg *config.Config, httpClient *httpclient.HTTPClient) *Service {
+	webUploader := NewWebUploader(cfg.TikTokCookiesPath, true) // Default to headless
+	webUploader.SetProxies(cfg.TikTokProxies)
 	return &Service{
-		apiKey:         cfg.TikTokAPIKey,
-		apiSecret:      cfg.TikTokAPISecret,
-		region:         cfg.TikTokRegion,
-		client:         httpClient,
-		baseURL:        cfg.TikTokBaseURL,
-		uploadInitPath: cfg.TikTokUploadInitPath,
-		publishPath:    cfg.TikTokPublishPath,
-		enableWeb:      cfg.TikTokEnableWeb,
-		cookiesPath:    cfg.TikTokCookiesPath,
-		webUploader:    NewWebUploader(cfg.TikTokCookiesPath, true), // Default to headless
+		region:          cfg.TikTokRegion,
+		client:          httpClient.WithProxies(cfg.TikTokProxies),
+		baseURL:         cfg.TikTokBaseURL,
+		uploadInitPath:  cfg.TikTokUploadInitPath,
+		inboxInitPath:   cfg.TikTokInboxInitPath,
+		publishPath:     cfg.TikTokPublishPath,
+		uploadMethod:    cfg.TikTokUploadMethod,
+		uploadField:     cfg.TikTokUploadFieldName,
+		commentPath:     cfg.TikTokCommentPath,
+		statusPath:      cfg.TikTokPublishStatusPath,
+		creatorInfoPath: cfg.TikTokCreatorInfoPath,
+		enableWeb:       cfg.TikTokEnableWeb,
+		cookiesPath:     cfg.TikTokCookiesPath,
+		webUploader:     webUploader,
+		bufferSize:      cfg.UploadBufferSize,
 	}
 }
 
@@ -63,8 +80,34 @@ type UploadRequest struct {
 	// Description is the video description
 	Description string
 
-	// PrivacyLevel sets the video privacy (PUBLIC_TO_EVERYONE, MUTUAL_FOLLOW_FRIEND, SELF_ONLY)
+	// PrivacyLevel sets the video privacy (PUBLIC_TO_EVERYONE, MUTUAL_FOLLOW_FRIENDS,
+	// FOLLOWER_OF_CREATOR or SELF_ONLY); empty means public
 	PrivacyLevel string
+
+	// DisableComment, DisableDuet and DisableStitch turn the interactions off on the post
+	DisableComment bool
+	DisableDuet    bool
+	DisableStitch  bool
+
+	// PostAsDraft sends the video to the creator's TikTok inbox for manual review and posting
+	// instead of publishing it. The returned ID is then the inbox publish_id.
+	PostAsDraft bool
+
+	// ScheduleTime, when set, asks TikTok to publish at that time (15 minutes to 10 days ahead)
+	ScheduleTime time.Time
+
+	// OnBytesSent, when set, receives the number of video bytes sent by an API upload,
+	// including partial uploads that failed
+	OnBytesSent func(n int64)
+
+	// ProgressCallback, when set, is called while an API upload sends the video, with the bytes
+	// sent so far and the size of the file. It is called from the goroutine sending the body.
+	ProgressCallback func(sent, total int64)
+
+	// OnUploadStarted, when set, receives the ID TikTok assigned to an API upload as soon as
+	// init returns, before any video bytes are sent. That ID can later be passed to
+	// FetchPublishStatus to find out whether an interrupted upload was published.
+	OnUploadStarted func(publishID string)
 }
 
 // UploadResponse represents the TikTok API upload response
@@ -78,8 +121,43 @@ type UploadResponse struct {
 	} `json:"error"`
 }
 
-// UploadVideo uploads a video to TikTok
+// UploadVideo uploads a video to TikTok through the web uploader when tiktok.enable_web is set,
+// otherwise through the Content Posting API
 func (s *Service) UploadVideo(req *UploadRequest) (string, error) {
+	if s.enableWeb {
+		return s.UploadVideoWeb(context.Background(), req)
+	}
+	return s.UploadVideoAPI(context.Background(), req)
+}
+
+// WebUploadEnabled reports whether tiktok.enable_web is set
+func (s *Service) WebUploadEnabled() bool {
+	return s.enableWeb
+}
+
+// UploadVideoWeb uploads a video through browser automation with the configured session cookies
+func (s *Service) UploadVideoWeb(ctx context.Context, req *UploadRequest) (string, error) {
+	if req == nil {
+		return "", fmt.Errorf("upload request is nil")
+	}
+	if req.VideoPath == "" {
+		return "", fmt.Errorf("video path is required for upload")
+	}
+	if req.PostAsDraft || !req.ScheduleTime.IsZero() {
+		return "", fmt.Errorf("drafts and scheduled posts are only supported by API uploads")
+	}
+	if _, err := os.Stat(req.VideoPath); err != nil {
+		return "", fmt.Errorf("failed to stat video file: %w", err)
+	}
+	if s.webUploader == nil {
+		return "", fmt.Errorf("web uploader is not initialized")
+	}
+	return s.webUploader.UploadVideo(ctx, req)
+}
+
+// UploadVideoAPI uploads a video through the Content Posting API with the account's access token.
+// Cancelling ctx aborts the request in progress, including a partly sent video.
+func (s *Service) UploadVideoAPI(ctx context.Context, req *UploadRequest) (string, error) {
 	if req == nil {
 		return "", fmt.Errorf("upload request is nil")
 	}
@@ -92,33 +170,46 @@ func (s *Service) UploadVideo(req *UploadRequest) (string, error) {
 	if req.VideoPath == "" {
 		return "", fmt.Errorf("video path is required for upload")
 	}
+	if err := ValidatePublishOptions(req, time.Now()); err != nil {
+		return "", err
+	}
 
 	fileInfo, err := os.Stat(req.VideoPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to stat video file: %w", err)
 	}
 
-	// Check if web upload is enabled
-	if s.enableWeb {
-		if s.webUploader == nil {
-			return "", fmt.Errorf("web uploader is not initialized")
+	// Drafts go to the creator's inbox: init against the inbox endpoint, upload, and stop there
+	if req.PostAsDraft {
+		target, err := s.initializeInboxUpload(ctx, req.AccessToken, fileInfo.Size())
+		if err != nil {
+			return "", fmt.Errorf("failed to initialize draft upload: %w", err)
+		}
+		if req.OnUploadStarted != nil {
+			req.OnUploadStarted(target.UploadID)
 		}
-		return s.webUploader.UploadVideo(context.Background(), req)
+		if err := s.uploadVideoFile(ctx, target, req); err != nil {
+			return "", fmt.Errorf("failed to upload video file: %w", err)
+		}
+		return target.UploadID, nil
 	}
 
 	// Step 1: Initialize upload
-	uploadURL, uploadID, err := s.initializeUpload(req.AccessToken, req.OpenID, fileInfo.Size())
+	target, err := s.initializeUpload(ctx, req.AccessToken, req.OpenID, fileInfo.Size())
 	if err != nil {
 		return "", fmt.Errorf("failed to initialize upload: %w", err)
 	}
+	if req.OnUploadStarted != nil {
+		req.OnUploadStarted(target.UploadID)
+	}
 
 	// Step 2: Upload video file
-	if err := s.uploadVideoFile(uploadURL, req.VideoPath); err != nil {
+	if err := s.uploadVideoFile(ctx, target, req); err != nil {
 		return "", fmt.Errorf("failed to upload video file: %w", err)
 	}
 
 	// Step 3: Publish video
-	videoID, err := s.publishVideo(req.AccessToken, req.OpenID, uploadID, req.Title, req.Description, req.PrivacyLevel)
+	videoID, err := s.publishVideo(ctx, req, target.UploadID)
 	if err != nil {
 		return "", fmt.Errorf("failed to publish video: %w", err)
 	}
@@ -126,10 +217,24 @@ func (s *Service) UploadVideo(req *UploadRequest) (string, error) {
 	return videoID, nil
 }
 
-// initializeUpload initializes a video upload session
-func (s *Service) initializeUpload(accessToken string, openID string, videoSize int64) (string, string, error) {
-	apiURL := s.combinePath(s.uploadInitPath)
+// Upload transports for sending the video binary to the upload URL
+const (
+	UploadMethodMultipart = "multipart" // multipart/form-data POST with a file field
+	UploadMethodPut       = "put"       // raw binary PUT body
+)
 
+// uploadTarget describes where and how the binary must be sent, as returned by init
+type uploadTarget struct {
+	URL       string
+	UploadID  string
+	Method    string            // UploadMethodMultipart or UploadMethodPut
+	FieldName string            // multipart file field name
+	Headers   map[string]string // extra headers to send with the upload
+	Params    map[string]string // extra form fields (multipart) or query parameters (put)
+}
+
+// initializeUpload initializes a video upload session
+func (s *Service) initializeUpload(ctx context.Context, accessToken string, openID string, videoSize int64) (*uploadTarget, error) {
 	payload := map[string]any{
 		"open_id":     openID,
 		"upload_type": "video",
@@ -137,12 +242,41 @@ func (s *Service) initializeUpload(accessToken string, openID string, videoSize
 	if videoSize > 0 {
 		payload["video_size"] = videoSize
 	}
+	return s.initUploadAt(ctx, s.uploadInitPath, ScopeVideoUpload, accessToken, payload)
+}
+
+// initializeInboxUpload starts a v2 inbox (draft) upload of the whole file in one chunk
+func (s *Service) initializeInboxUpload(ctx context.Context, accessToken string, videoSize int64) (*uploadTarget, error) {
+	payload := map[string]any{
+		"source_info": map[string]any{
+			"source":            "FILE_UPLOAD",
+			"video_size":        videoSize,
+			"chunk_size":        videoSize,
+			"total_chunk_count": 1,
+		},
+	}
+	target, err := s.initUploadAt(ctx, s.inboxInitPath, ScopeVideoUpload, accessToken, payload)
+	if err != nil {
+		return nil, err
+	}
+	// The v2 upload URL takes the file as a single PUT with Content-Range
+	target.Method = UploadMethodPut
+	if target.UploadID == "" {
+		return nil, fmt.Errorf("inbox init response has no publish_id")
+	}
+	return target, nil
+}
+
+// initUploadAt posts an init payload to the given path and parses the upload target. scope is
+// the scope the endpoint needs, named in the *ScopeError TikTok's refusal is turned into.
+func (s *Service) initUploadAt(ctx context.Context, path, scope, accessToken string, payload map[string]any) (*uploadTarget, error) {
+	apiURL := s.combinePath(path)
 
 	// TikTok API requires access_token as query parameter for POST requests
 	// Add access_token to URL as query parameter
 	parsedURL, err := url.Parse(apiURL)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to parse API URL: %w", err)
+		return nil, fmt.Errorf("failed to parse API URL: %w", err)
 	}
 	params := parsedURL.Query()
 	params.Set("access_token", accessToken)
@@ -151,28 +285,53 @@ func (s *Service) initializeUpload(accessToken string, openID string, videoSize
 
 	httpReq, err := s.newJSONRequest(http.MethodPost, apiURL, payload, "")
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
+	httpReq = httpReq.WithContext(ctx)
 
 	resp, err := s.client.Do(httpReq)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
+	if err := scopeErrorIn(bodyBytes, scope); err != nil {
+		return nil, err
+	}
+	if err := unauditedErrorIn(bodyBytes); err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("upload init failed with status %d: %s", resp.StatusCode, previewBody(bodyBytes))
+		return nil, fmt.Errorf("upload init failed with status %d: %s", resp.StatusCode, previewBody(bodyBytes, accessToken))
 	}
 
+	target, err := s.parseUploadTarget(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// parseUploadTarget decodes the init response, honoring any transport hints it carries.
+// Hints override the configured defaults so each upload URL gets the shape it expects.
+func (s *Service) parseUploadTarget(body []byte) (*uploadTarget, error) {
 	var result struct {
 		Data struct {
-			UploadURL string `json:"upload_url"`
-			UploadID  string `json:"upload_id"`
+			UploadURL     string            `json:"upload_url"`
+			UploadID      string            `json:"upload_id"`
+			PublishID     string            `json:"publish_id"` // v2 endpoints
+			UploadMethod  string            `json:"upload_method"`
+			HTTPMethod    string            `json:"http_method"`
+			FieldName     string            `json:"upload_field_name"`
+			FileFieldName string            `json:"file_field_name"`
+			Headers       map[string]string `json:"upload_headers"`
+			Params        map[string]string `json:"upload_params"`
+			FormData      map[string]string `json:"form_data"`
 		} `json:"data"`
 		Error struct {
 			Code    string `json:"code"`
@@ -180,20 +339,69 @@ func (s *Service) initializeUpload(accessToken string, openID string, videoSize
 		} `json:"error"`
 	}
 
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return "", "", fmt.Errorf("failed to decode upload init response: %w; body=%s", err, previewBody(bodyBytes))
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode upload init response: %w; body=%s", err, previewBody(body))
 	}
 
-	if result.Error.Code != "" {
-		return "", "", fmt.Errorf("TikTok API error: %s - %s", result.Error.Code, result.Error.Message)
+	if result.Error.Code != "" && result.Error.Code != "ok" {
+		return nil, fmt.Errorf("TikTok API error: %s - %s", result.Error.Code, result.Error.Message)
+	}
+
+	data := result.Data
+	if data.UploadURL == "" {
+		return nil, fmt.Errorf("upload init response has no upload_url; body=%s", previewBody(body))
+	}
+
+	if data.UploadID == "" {
+		data.UploadID = data.PublishID
+	}
+	target := &uploadTarget{
+		URL:       data.UploadURL,
+		UploadID:  data.UploadID,
+		Method:    s.uploadMethod,
+		FieldName: s.uploadField,
+		Headers:   map[string]string{},
+		Params:    map[string]string{},
+	}
+
+	switch {
+	case data.UploadMethod != "":
+		target.Method = strings.ToLower(data.UploadMethod)
+	case strings.EqualFold(data.HTTPMethod, http.MethodPut):
+		target.Method = UploadMethodPut
+	}
+	if target.Method == "" {
+		target.Method = UploadMethodMultipart
+	}
+	if target.Method != UploadMethodMultipart && target.Method != UploadMethodPut {
+		return nil, fmt.Errorf("unsupported upload method %q in init response", target.Method)
+	}
+
+	if data.FieldName != "" {
+		target.FieldName = data.FieldName
+	} else if data.FileFieldName != "" {
+		target.FieldName = data.FileFieldName
+	}
+	if target.FieldName == "" {
+		target.FieldName = "video"
 	}
 
-	return result.Data.UploadURL, result.Data.UploadID, nil
+	for k, v := range data.Headers {
+		target.Headers[k] = v
+	}
+	for k, v := range data.FormData {
+		target.Params[k] = v
+	}
+	for k, v := range data.Params {
+		target.Params[k] = v
+	}
+
+	return target, nil
 }
 
-// uploadVideoFile uploads the video file to TikTok
-func (s *Service) uploadVideoFile(uploadURL string, videoPath string) error {
-	file, err := os.Open(videoPath)
+// uploadVideoFile uploads the video file to TikTok using the transport the target asks for
+func (s *Service) uploadVideoFile(ctx context.Context, target *uploadTarget, req *UploadRequest) error {
+	file, err := os.Open(req.VideoPath)
 	if err != nil {
 		return err
 	}
@@ -205,15 +413,92 @@ func (s *Service) uploadVideoFile(uploadURL string, videoPath string) error {
 		return err
 	}
 
-	// Create multipart form streamed through an io.Pipe to avoid loading entire file in memory
+	body := &countingReader{r: file, total: fileInfo.Size(), onRead: req.ProgressCallback}
+	if req.OnBytesSent != nil {
+		defer func() { req.OnBytesSent(body.n.Load()) }()
+	}
+
+	var httpReq *http.Request
+	if target.Method == UploadMethodPut {
+		httpReq, err = newPutUploadRequest(target, body, fileInfo.Size())
+	} else {
+		httpReq, err = newMultipartUploadRequest(target, body, fileInfo.Name(), s.bufferSize)
+	}
+	if err != nil {
+		return err
+	}
+	httpReq = httpReq.WithContext(ctx)
+
+	for k, v := range target.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	// Perform upload with streaming for better performance
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("upload failed with status %d (%s transport): %s", resp.StatusCode, target.Method, previewBody(bodyBytes))
+	}
+
+	return nil
+}
+
+// newPutUploadRequest sends the file as a raw binary PUT body
+func newPutUploadRequest(target *uploadTarget, file io.Reader, size int64) (*http.Request, error) {
+	uploadURL := target.URL
+	if len(target.Params) > 0 {
+		parsedURL, err := url.Parse(uploadURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse upload URL: %w", err)
+		}
+		query := parsedURL.Query()
+		for k, v := range target.Params {
+			query.Set(k, v)
+		}
+		parsedURL.RawQuery = query.Encode()
+		uploadURL = parsedURL.String()
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPut, uploadURL, file)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.ContentLength = size
+	httpReq.Header.Set("Content-Type", "video/mp4")
+	if size > 0 {
+		httpReq.Header.Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", size-1, size))
+	}
+	return httpReq, nil
+}
+
+// newMultipartUploadRequest streams the file as a multipart form through an io.Pipe
+// to avoid loading the entire file in memory. The file is copied in bufferSize chunks
+// (upload.buffer_size, 1MB when unset).
+func newMultipartUploadRequest(target *uploadTarget, file io.Reader, fileName string, bufferSize int) (*http.Request, error) {
 	pr, pw := io.Pipe()
 	writer := multipart.NewWriter(pw)
 
+	if bufferSize <= 0 {
+		bufferSize = 1024 * 1024
+	}
+
 	go func() {
-		bufferSize := 1024 * 1024 // 1MB default buffer for throughput
 		buffer := make([]byte, bufferSize)
 
-		part, err := writer.CreateFormFile("video", fileInfo.Name())
+		// Extra fields go before the file; some upload endpoints reject trailing fields
+		for k, v := range target.Params {
+			if err := writer.WriteField(k, v); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+
+		part, err := writer.CreateFormFile(target.FieldName, fileName)
 		if err != nil {
 			pw.CloseWithError(err)
 			return
@@ -233,48 +518,42 @@ func (s *Service) uploadVideoFile(uploadURL string, videoPath string) error {
 	}()
 
 	// Create request with streaming body (chunked transfer)
-	httpReq, err := http.NewRequest(http.MethodPost, uploadURL, pr)
+	httpReq, err := http.NewRequest(http.MethodPost, target.URL, pr)
 	if err != nil {
-		return err
+		pr.Close()
+		return nil, err
 	}
 
 	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+	return httpReq, nil
+}
 
-	// Perform upload with streaming for better performance
-	resp, err := s.client.Do(httpReq)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+// countingReader counts the bytes read through it and reports them to onRead, when set, along
+// with total; the multipart body is read from another goroutine
+type countingReader struct {
+	r      io.Reader
+	n      atomic.Int64
+	total  int64
+	onRead func(sent, total int64)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	sent := c.n.Add(int64(n))
+	if n > 0 && c.onRead != nil {
+		c.onRead(sent, c.total)
 	}
-
-	return nil
+	return n, err
 }
 
-// publishVideo publishes the uploaded video
-func (s *Service) publishVideo(accessToken, openID, uploadID, title, description, privacyLevel string) (string, error) {
+// publishVideo publishes the uploaded video with the request's post settings
+func (s *Service) publishVideo(ctx context.Context, req *UploadRequest, uploadID string) (string, error) {
 	apiURL := s.combinePath(s.publishPath)
 
-	postInfo := map[string]any{}
-	if title != "" {
-		postInfo["title"] = title
-	}
-	if description != "" {
-		postInfo["description"] = description
-	}
-	if privacyLevel == "" {
-		privacyLevel = "PUBLIC_TO_EVERYONE"
-	}
-	postInfo["privacy_level"] = privacyLevel
-
 	payload := map[string]any{
-		"open_id":   openID,
+		"open_id":   req.OpenID,
 		"upload_id": uploadID,
-		"post_info": postInfo,
+		"post_info": PostInfo(req),
 	}
 
 	// TikTok API requires access_token as query parameter for POST requests
@@ -284,7 +563,7 @@ func (s *Service) publishVideo(accessToken, openID, uploadID, title, description
 		return "", fmt.Errorf("failed to parse API URL: %w", err)
 	}
 	params := parsedURL.Query()
-	params.Set("access_token", accessToken)
+	params.Set("access_token", req.AccessToken)
 	parsedURL.RawQuery = params.Encode()
 	apiURL = parsedURL.String()
 
@@ -292,6 +571,7 @@ func (s *Service) publishVideo(accessToken, openID, uploadID, title, description
 	if err != nil {
 		return "", err
 	}
+	httpReq = httpReq.WithContext(ctx)
 
 	resp, err := s.client.Do(httpReq)
 	if err != nil {
@@ -304,6 +584,12 @@ func (s *Service) publishVideo(accessToken, openID, uploadID, title, description
 		return "", err
 	}
 
+	if err := scopeErrorIn(bodyBytes, ScopeVideoPublish); err != nil {
+		return "", err
+	}
+	if err := unauditedErrorIn(bodyBytes); err != nil {
+		return "", err
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("publish failed with status %d: %s", resp.StatusCode, previewBody(bodyBytes))
 	}
@@ -329,8 +615,128 @@ func (s *Service) publishVideo(accessToken, openID, uploadID, title, description
 	return result.Data.VideoID, nil
 }
 
+// CommentRequest represents a request to comment on a published video
+type CommentRequest struct {
+	// AccessToken is the TikTok access token
+	AccessToken string
+
+	// OpenID is the TikTok user identifier associated with the access token
+	OpenID string
+
+	// VideoID is the TikTok video to comment on
+	VideoID string
+
+	// Text is the comment body
+	Text string
+}
+
+// PostComment posts a comment on a published video. The API is used when a
+// comment endpoint is configured, falling back to the web uploader session when the token lacks
+// the comment scope and web uploads are enabled; otherwise the web uploader session is used.
+func (s *Service) PostComment(ctx context.Context, req *CommentRequest) error {
+	if req == nil {
+		return fmt.Errorf("comment request is nil")
+	}
+	if req.VideoID == "" {
+		return fmt.Errorf("video id is required for comment")
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		return fmt.Errorf("comment text is empty")
+	}
+
+	// Web uploads return a placeholder ID that neither the API nor a TikTok page knows
+	if IsPlaceholderVideoID(req.VideoID) {
+		return fmt.Errorf("cannot comment on placeholder video id %s", req.VideoID)
+	}
+
+	if s.commentPath != "" {
+		err := s.postCommentAPI(ctx, req)
+		// A token without the comment scope can still comment as the creator through the web
+		if err == nil || !errors.Is(err, ErrScopeInsufficient) || !s.enableWeb || s.webUploader == nil {
+			return err
+		}
+		if webErr := s.webUploader.PostComment(ctx, req); webErr != nil {
+			return fmt.Errorf("%w; web fallback failed: %v", err, webErr)
+		}
+		return nil
+	}
+
+	if s.webUploader == nil {
+		return fmt.Errorf("web uploader is not initialized")
+	}
+	return s.webUploader.PostComment(ctx, req)
+}
+
+// postCommentAPI creates a comment through the TikTok API
+func (s *Service) postCommentAPI(ctx context.Context, req *CommentRequest) error {
+	if req.AccessToken == "" {
+		return fmt.Errorf("access token is required")
+	}
+
+	payload := map[string]any{
+		"open_id":  req.OpenID,
+		"video_id": req.VideoID,
+		"text":     req.Text,
+	}
+
+	httpReq, err := s.newJSONRequest(http.MethodPost, s.combinePath(s.commentPath), payload, req.AccessToken)
+	if err != nil {
+		return err
+	}
+	httpReq = httpReq.WithContext(ctx)
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := scopeErrorIn(bodyBytes, ScopeComment); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("comment failed with status %d: %s", resp.StatusCode, previewBody(bodyBytes))
+	}
+
+	var result struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return fmt.Errorf("failed to decode comment response: %w; body=%s", err, previewBody(bodyBytes))
+	}
+
+	if result.Error.Code != "" && result.Error.Code != "ok" {
+		return fmt.Errorf("TikTok API error: %s - %s", result.Error.Code, result.Error.Message)
+	}
+
+	return nil
+}
+
+// UserInfo is the TikTok profile behind an access token
+type UserInfo struct {
+	OpenID      string `json:"open_id"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
 // VerifyAccessToken verifies if an access token is valid
 func (s *Service) VerifyAccessToken(accessToken string) (bool, error) {
+	_, valid, err := s.GetUserInfo(accessToken)
+	return valid, err
+}
+
+// GetUserInfo fetches the profile for an access token from /user/info/. valid is false
+// when TikTok rejects the token; err is set when the check itself failed, or is a *ScopeError
+// when the token is valid but lacks user.info.basic.
+func (s *Service) GetUserInfo(accessToken string) (*UserInfo, bool, error) {
 	apiURL := fmt.Sprintf("%s/user/info/", s.baseURL)
 
 	params := url.Values{}
@@ -339,16 +745,44 @@ func (s *Service) VerifyAccessToken(accessToken string) (bool, error) {
 
 	httpReq, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s?%s", apiURL, params.Encode()), nil)
 	if err != nil {
-		return false, err
+		return nil, false, err
 	}
 
 	resp, err := s.client.Do(httpReq)
 	if err != nil {
-		return false, err
+		return nil, false, err
 	}
 	defer resp.Body.Close()
 
-	return resp.StatusCode == http.StatusOK, nil
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	// Refreshing would not help a token that was never granted the scope
+	if err := scopeErrorIn(body, ScopeUserInfoBasic); err != nil {
+		return nil, false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, nil
+	}
+
+	var result struct {
+		Data struct {
+			User UserInfo `json:"user"`
+		} `json:"data"`
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		// The token was accepted even if the profile could not be read
+		return &UserInfo{}, true, nil
+	}
+	if result.Error.Code != "" && result.Error.Code != "ok" {
+		return nil, false, nil
+	}
+	return &result.Data.User, true, nil
 }
 
 // TokenResponse represents the response from TikTok token exchange
@@ -367,13 +801,32 @@ type TokenResponse struct {
 	} `json:"error"`
 }
 
-// ExchangeCodeForToken exchanges an authorization code for an access token
-func (s *Service) ExchangeCodeForToken(authCode, redirectURI string) (*TokenResponse, error) {
+// AuthorizeURL builds the TikTok consent URL for a credential set. extraScopes, such as the
+// scopes an account's token turned out to lack, are requested on top of DefaultScopes.
+func AuthorizeURL(app config.TikTokApp, redirectURI, state string, extraScopes ...string) string {
+	scopes := append([]string{}, DefaultScopes...)
+	for _, scope := range extraScopes {
+		if scope != "" && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return fmt.Sprintf(
+		"https://www.tiktok.com/v2/auth/authorize/?client_key=%s&scope=%s&response_type=code&redirect_uri=%s&state=%s",
+		url.QueryEscape(app.APIKey),
+		strings.Join(scopes, ","),
+		url.QueryEscape(redirectURI),
+		url.QueryEscape(state),
+	)
+}
+
+// ExchangeCodeForToken exchanges an authorization code for an access token. The code is only
+// accepted by the credential set whose client key started the authorization.
+func (s *Service) ExchangeCodeForToken(app config.TikTokApp, authCode, redirectURI string) (*TokenResponse, error) {
 	apiURL := fmt.Sprintf("%s/v2/oauth/token/", s.baseURL)
 
 	payload := map[string]string{
-		"client_key":    s.apiKey,
-		"client_secret": s.apiSecret,
+		"client_key":    app.APIKey,
+		"client_secret": app.APISecret,
 		"code":          authCode,
 		"grant_type":    "authorization_code",
 		"redirect_uri":  redirectURI,
@@ -396,28 +849,29 @@ func (s *Service) ExchangeCodeForToken(authCode, redirectURI string) (*TokenResp
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("token exchange failed with status %d: %s", resp.StatusCode, previewBody(bodyBytes))
+		return nil, fmt.Errorf("token exchange failed with status %d: %s", resp.StatusCode, previewBody(bodyBytes, authCode, app.APISecret))
 	}
 
 	var result TokenResponse
 	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode token response: %w; body=%s", err, previewBody(bodyBytes))
+		return nil, fmt.Errorf("failed to decode token response: %w; body=%s", err, previewBody(bodyBytes, authCode, app.APISecret))
 	}
 
 	if result.Error.Code != "" {
-		return nil, fmt.Errorf("TikTok API error: %s - %s", result.Error.Code, result.Error.Message)
+		return nil, fmt.Errorf("TikTok API error: %s - %s", result.Error.Code, redact.Secrets(result.Error.Message, authCode, app.APISecret))
 	}
 
 	return &result, nil
 }
 
-// RefreshAccessToken refreshes an access token using refresh token
-func (s *Service) RefreshAccessToken(refreshToken string) (*TokenResponse, error) {
+// RefreshAccessToken refreshes an access token using refresh token. It must use the credential
+// set that issued the token.
+func (s *Service) RefreshAccessToken(app config.TikTokApp, refreshToken string) (*TokenResponse, error) {
 	apiURL := fmt.Sprintf("%s/v2/oauth/token/", s.baseURL)
 
 	payload := map[string]string{
-		"client_key":    s.apiKey,
-		"client_secret": s.apiSecret,
+		"client_key":    app.APIKey,
+		"client_secret": app.APISecret,
 		"grant_type":    "refresh_token",
 		"refresh_token": refreshToken,
 	}
@@ -426,6 +880,8 @@ func (s *Service) RefreshAccessToken(refreshToken string) (*TokenResponse, error
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	// A refresh that never reached TikTok leaves the refresh token valid, so transient failures are retried
+	httpReq = httpReq.WithContext(httpclient.WithRetrySafe(httpReq.Context()))
 
 	resp, err := s.client.Do(httpReq)
 	if err != nil {
@@ -439,23 +895,25 @@ func (s *Service) RefreshAccessToken(refreshToken string) (*TokenResponse, error
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("token refresh failed with status %d: %s", resp.StatusCode, previewBody(bodyBytes))
+		return nil, fmt.Errorf("token refresh failed with status %d: %s", resp.StatusCode, previewBody(bodyBytes, refreshToken, app.APISecret))
 	}
 
 	var result TokenResponse
 	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode token response: %w; body=%s", err, previewBody(bodyBytes))
+		return nil, fmt.Errorf("failed to decode token response: %w; body=%s", err, previewBody(bodyBytes, refreshToken, app.APISecret))
 	}
 
 	if result.Error.Code != "" {
-		return nil, fmt.Errorf("TikTok API error: %s - %s", result.Error.Code, result.Error.Message)
+		return nil, fmt.Errorf("TikTok API error: %s - %s", result.Error.Code, redact.Secrets(result.Error.Message, refreshToken, app.APISecret))
 	}
 
 	return &result, nil
 }
 
-func previewBody(body []byte) string {
-	bodyStr := strings.TrimSpace(string(body))
+// previewBody trims a response body for error messages, masking any credentials it echoes,
+// including the secrets the request sent
+func previewBody(body []byte, secrets ...string) string {
+	bodyStr := redact.String(redact.Secrets(strings.TrimSpace(string(body)), secrets...))
 	const limit = 512
 	if len(bodyStr) > limit {
 		bodyStr = bodyStr[:limit] + "..."
@@ -474,19 +932,23 @@ func (s *Service) combinePath(path string) string {
 }
 
 func (s *Service) newJSONRequest(method, url string, payload interface{}, accessToken string) (*http.Request, error) {
-	var body io.Reader
+	var jsonData []byte
 	if payload != nil {
-		jsonData, err := json.Marshal(payload)
+		var err error
+		jsonData, err = json.Marshal(payload)
 		if err != nil {
 			return nil, err
 		}
-		body = bytes.NewBuffer(jsonData)
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequest(method, url, bytes.NewReader(jsonData))
 	if err != nil {
 		return nil, err
 	}
+	// Replayable body so the HTTP client can retry retry-safe requests
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(jsonData)), nil
+	}
 	req.Header.Set("Content-Type", "application/json")
 	if accessToken != "" {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
@@ -0,0 +1,112 @@
+package tiktok
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+)
+
+// newTestService returns a service whose TikTok API is handler; tune adjusts the configuration
+// before the service is built
+func newTestService(t *testing.T, handler http.Handler, tune func(cfg *config.Config)) *Service {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{
+		TikTokBaseURL:     server.URL,
+		HTTPClientTimeout: 5 * time.Second,
+		UploadBufferSize:  32 * 1024,
+	}
+	if tune != nil {
+		tune(cfg)
+	}
+	return NewService(cfg, httpclient.NewHTTPClient(cfg))
+}
+
+func TestPostComment(t *testing.T) {
+	tests := []struct {
+		name      string
+		videoID   string
+		response  string
+		enableWeb bool
+		wantCalls int
+		wantErr   error
+		wantText  string
+	}{
+		{
+			name:      "posted through the API",
+			videoID:   "7300000000000000001",
+			response:  `{"error":{"code":"ok","message":""}}`,
+			wantCalls: 1,
+		},
+		{
+			name:      "scope error without web uploads",
+			videoID:   "7300000000000000001",
+			response:  `{"error":{"code":"scope_not_authorized","message":"comment scope missing"}}`,
+			wantCalls: 1,
+			wantErr:   ErrScopeInsufficient,
+			wantText:  ScopeComment,
+		},
+		{
+			name:      "other API errors do not fall back",
+			videoID:   "7300000000000000001",
+			response:  `{"error":{"code":"rate_limit_exceeded","message":"slow down"}}`,
+			enableWeb: true,
+			wantCalls: 1,
+			wantText:  "rate_limit_exceeded",
+		},
+		{
+			name:     "web upload placeholder",
+			videoID:  "web_upload_1700000000",
+			wantText: "placeholder",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				var payload map[string]string
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					t.Errorf("decode comment payload: %v", err)
+				}
+				if payload["video_id"] != tt.videoID || payload["text"] != "first!" {
+					t.Errorf("comment payload = %v", payload)
+				}
+				w.Write([]byte(tt.response))
+			})
+			service := newTestService(t, handler, func(cfg *config.Config) {
+				cfg.TikTokCommentPath = "/v2/comment/create/"
+				cfg.TikTokEnableWeb = tt.enableWeb
+			})
+
+			err := service.PostComment(context.Background(), &CommentRequest{
+				AccessToken: "act.test",
+				OpenID:      "open-1",
+				VideoID:     tt.videoID,
+				Text:        "first!",
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("API called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("PostComment() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantText == "" && err != nil {
+				t.Errorf("PostComment() error = %v", err)
+			}
+			if tt.wantText != "" && (err == nil || !strings.Contains(err.Error(), tt.wantText)) {
+				t.Errorf("PostComment() error = %v, want it to mention %q", err, tt.wantText)
+			}
+		})
+	}
+}
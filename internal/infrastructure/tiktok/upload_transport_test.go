@@ -0,0 +1,402 @@
+package tiktok
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"auto_upload_tiktok/config"
+)
+
+func TestParseUploadTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string // tiktok.upload_method
+		field       string // tiktok.upload_field_name
+		body        string
+		want        *uploadTarget
+		wantErrText string
+	}{
+		{
+			name: "configured defaults",
+			body: `{"data":{"upload_url":"https://up.example/u","upload_id":"up-1"}}`,
+			want: &uploadTarget{URL: "https://up.example/u", UploadID: "up-1", Method: UploadMethodMultipart, FieldName: "video",
+				Headers: map[string]string{}, Params: map[string]string{}},
+		},
+		{
+			name:   "configured put and field",
+			method: UploadMethodPut,
+			field:  "file",
+			body:   `{"data":{"upload_url":"https://up.example/u","upload_id":"up-1"},"error":{"code":"ok"}}`,
+			want: &uploadTarget{URL: "https://up.example/u", UploadID: "up-1", Method: UploadMethodPut, FieldName: "file",
+				Headers: map[string]string{}, Params: map[string]string{}},
+		},
+		{
+			name: "v2 publish ID",
+			body: `{"data":{"upload_url":"https://up.example/u","publish_id":"v_pub_1"}}`,
+			want: &uploadTarget{URL: "https://up.example/u", UploadID: "v_pub_1", Method: UploadMethodMultipart, FieldName: "video",
+				Headers: map[string]string{}, Params: map[string]string{}},
+		},
+		{
+			name:   "upload_method hint overrides the configuration",
+			method: UploadMethodPut,
+			body:   `{"data":{"upload_url":"https://up.example/u","upload_method":"MULTIPART","upload_field_name":"media"}}`,
+			want: &uploadTarget{URL: "https://up.example/u", Method: UploadMethodMultipart, FieldName: "media",
+				Headers: map[string]string{}, Params: map[string]string{}},
+		},
+		{
+			name: "http_method hint",
+			body: `{"data":{"upload_url":"https://up.example/u","http_method":"put","file_field_name":"blob"}}`,
+			want: &uploadTarget{URL: "https://up.example/u", Method: UploadMethodPut, FieldName: "blob",
+				Headers: map[string]string{}, Params: map[string]string{}},
+		},
+		{
+			name: "headers and fields",
+			body: `{"data":{"upload_url":"https://up.example/u","upload_headers":{"X-Upload-Token":"t1"},
+				"form_data":{"policy":"p","key":"from-form"},"upload_params":{"key":"from-params"}}}`,
+			want: &uploadTarget{URL: "https://up.example/u", Method: UploadMethodMultipart, FieldName: "video",
+				Headers: map[string]string{"X-Upload-Token": "t1"},
+				Params:  map[string]string{"policy": "p", "key": "from-params"}},
+		},
+		{
+			name:        "unsupported method",
+			body:        `{"data":{"upload_url":"https://up.example/u","upload_method":"ftp"}}`,
+			wantErrText: `unsupported upload method "ftp"`,
+		},
+		{
+			name:        "no upload URL",
+			body:        `{"data":{"upload_id":"up-1"}}`,
+			wantErrText: "no upload_url",
+		},
+		{
+			name:        "API error",
+			body:        `{"error":{"code":"spam_risk_too_many_posts","message":"slow down"}}`,
+			wantErrText: "spam_risk_too_many_posts",
+		},
+		{
+			name:        "not JSON",
+			body:        `<html>bad gateway</html>`,
+			wantErrText: "failed to decode upload init response",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestService(t, http.NotFoundHandler(), func(cfg *config.Config) {
+				cfg.TikTokUploadMethod = tt.method
+				cfg.TikTokUploadFieldName = tt.field
+			})
+			got, err := service.parseUploadTarget([]byte(tt.body))
+			if tt.wantErrText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("parseUploadTarget() error = %v, want it to mention %q", err, tt.wantErrText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseUploadTarget() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseUploadTarget() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// receivedUpload is what the fake upload server got
+type receivedUpload struct {
+	method string
+	header http.Header
+	query  map[string]string
+	body   []byte
+	length int64
+}
+
+func TestUploadVideoFileTransports(t *testing.T) {
+	video := bytes.Repeat([]byte("0123456789abcdef"), 4096) // 64 KiB
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(path, video, 0644); err != nil {
+		t.Fatalf("write video: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		target uploadTarget
+		check  func(t *testing.T, got receivedUpload)
+	}{
+		{
+			name: "put",
+			target: uploadTarget{Method: UploadMethodPut, Headers: map[string]string{"X-Upload-Token": "t1"},
+				Params: map[string]string{"part": "1"}},
+			check: func(t *testing.T, got receivedUpload) {
+				if got.method != http.MethodPut {
+					t.Errorf("method = %s, want PUT", got.method)
+				}
+				wantHeaders := map[string]string{
+					"Content-Type":   "video/mp4",
+					"Content-Range":  "bytes 0-65535/65536",
+					"X-Upload-Token": "t1",
+				}
+				for name, want := range wantHeaders {
+					if value := got.header.Get(name); value != want {
+						t.Errorf("%s = %q, want %q", name, value, want)
+					}
+				}
+				if got.length != int64(len(video)) {
+					t.Errorf("Content-Length = %d, want %d", got.length, len(video))
+				}
+				if got.query["part"] != "1" {
+					t.Errorf("query = %v, want part=1", got.query)
+				}
+				if !bytes.Equal(got.body, video) {
+					t.Errorf("body is %d bytes, not the video", len(got.body))
+				}
+			},
+		},
+		{
+			name: "multipart with configured field",
+			target: uploadTarget{Method: UploadMethodMultipart, FieldName: "media", Headers: map[string]string{},
+				Params: map[string]string{"policy": "p1"}},
+			check: func(t *testing.T, got receivedUpload) {
+				if got.method != http.MethodPost {
+					t.Errorf("method = %s, want POST", got.method)
+				}
+				if got.header.Get("Content-Range") != "" {
+					t.Errorf("Content-Range = %q on a multipart upload", got.header.Get("Content-Range"))
+				}
+				req := &http.Request{Header: got.header, Body: io.NopCloser(bytes.NewReader(got.body))}
+				reader, err := req.MultipartReader()
+				if err != nil {
+					t.Fatalf("multipart body: %v", err)
+				}
+				var names []string
+				for {
+					part, err := reader.NextPart()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						t.Fatalf("read part: %v", err)
+					}
+					names = append(names, part.FormName())
+					content, _ := io.ReadAll(part)
+					switch part.FormName() {
+					case "policy":
+						if string(content) != "p1" {
+							t.Errorf("policy = %q, want p1", content)
+						}
+					case "media":
+						if part.FileName() != "clip.mp4" || !bytes.Equal(content, video) {
+							t.Errorf("file part %q has %d bytes, want clip.mp4 with the video", part.FileName(), len(content))
+						}
+					}
+				}
+				// Fields come before the file
+				if !reflect.DeepEqual(names, []string{"policy", "media"}) {
+					t.Errorf("parts = %v, want [policy media]", names)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got receivedUpload
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got.method = r.Method
+				got.header = r.Header.Clone()
+				got.length = r.ContentLength
+				got.query = make(map[string]string)
+				for name := range r.URL.Query() {
+					got.query[name] = r.URL.Query().Get(name)
+				}
+				got.body, _ = io.ReadAll(r.Body)
+			})
+			service := newTestService(t, handler, nil)
+			target := tt.target
+			target.URL = service.baseURL + "/upload"
+
+			var sent, reported int64
+			err := service.uploadVideoFile(context.Background(), &target, &UploadRequest{
+				VideoPath:        path,
+				ProgressCallback: func(n, total int64) { sent = n },
+				OnBytesSent:      func(n int64) { reported = n },
+			})
+			if err != nil {
+				t.Fatalf("uploadVideoFile() error = %v", err)
+			}
+			tt.check(t, got)
+			if sent != int64(len(video)) || reported != int64(len(video)) {
+				t.Errorf("progress %d, bytes sent %d; want %d", sent, reported, len(video))
+			}
+		})
+	}
+}
+
+func TestUploadVideoFileRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(path, []byte("video"), 0644); err != nil {
+		t.Fatalf("write video: %v", err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		http.Error(w, "signature expired", http.StatusForbidden)
+	})
+	service := newTestService(t, handler, nil)
+	target := &uploadTarget{URL: service.baseURL + "/upload", Method: UploadMethodPut}
+
+	err := service.uploadVideoFile(context.Background(), target, &UploadRequest{VideoPath: path})
+	if err == nil || !strings.Contains(err.Error(), "status 403 (put transport)") || !strings.Contains(err.Error(), "signature expired") {
+		t.Errorf("uploadVideoFile() error = %v, want the status, transport and body", err)
+	}
+}
+
+// TestUploadProgressSequence uploads through a fake TikTok API: init, the upload URL and publish.
+// Progress rises with the bytes sent, reaches the file size and is all reported before publish.
+func TestUploadProgressSequence(t *testing.T) {
+	const bufferSize = 32 * 1024
+	video := bytes.Repeat([]byte("0123456789abcdef"), 20000) // 320000 bytes, not a multiple of the buffer
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(path, video, 0644); err != nil {
+		t.Fatalf("write video: %v", err)
+	}
+
+	for _, method := range []string{UploadMethodPut, UploadMethodMultipart} {
+		t.Run(method, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				events   []string // "progress", "uploaded" and "publish" in the order they happened
+				progress []int64
+				totals   = make(map[int64]bool)
+				received int
+			)
+			record := func(event string) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, event)
+			}
+
+			var service *Service
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/video/upload/":
+					fmt.Fprintf(w, `{"data":{"upload_url":"%s/upload","upload_id":"up-1","upload_method":%q},"error":{"code":"ok"}}`,
+						service.baseURL, method)
+				case "/upload":
+					body, _ := io.ReadAll(r.Body)
+					received = len(body)
+					record("uploaded")
+				case "/video/publish/":
+					record("publish")
+					w.Write([]byte(`{"data":{"video_id":"7300000000000000001"}}`))
+				default:
+					t.Errorf("unexpected TikTok request %s %s", r.Method, r.URL.Path)
+					http.NotFound(w, r)
+				}
+			})
+			service = newTestService(t, handler, func(cfg *config.Config) {
+				cfg.TikTokUploadInitPath = "/video/upload/"
+				cfg.TikTokPublishPath = "/video/publish/"
+				cfg.UploadBufferSize = bufferSize
+			})
+
+			var reported int64
+			videoID, err := service.UploadVideoAPI(context.Background(), &UploadRequest{
+				AccessToken: "token",
+				OpenID:      "open-1",
+				VideoPath:   path,
+				ProgressCallback: func(sent, total int64) {
+					mu.Lock()
+					progress = append(progress, sent)
+					totals[total] = true
+					mu.Unlock()
+					record("progress")
+				},
+				OnBytesSent: func(n int64) { reported = n },
+			})
+			if err != nil || videoID != "7300000000000000001" {
+				t.Fatalf("UploadVideoAPI() = %q, %v", videoID, err)
+			}
+
+			if len(progress) < 2 {
+				t.Fatalf("progress reported %d times, want it throughout the upload", len(progress))
+			}
+			for i := 1; i < len(progress); i++ {
+				if progress[i] <= progress[i-1] {
+					t.Fatalf("progress went from %d to %d", progress[i-1], progress[i])
+				}
+				// The multipart body is copied in upload.buffer_size chunks
+				if method == UploadMethodMultipart && progress[i]-progress[i-1] > bufferSize {
+					t.Errorf("progress step of %d bytes, over the %d byte buffer", progress[i]-progress[i-1], bufferSize)
+				}
+			}
+			if last := progress[len(progress)-1]; last != int64(len(video)) || reported != last {
+				t.Errorf("progress ended at %d, bytes sent %d; want %d", last, reported, len(video))
+			}
+			if len(totals) != 1 || !totals[int64(len(video))] {
+				t.Errorf("totals reported = %v, want only %d", totals, len(video))
+			}
+			if received < len(video) {
+				t.Errorf("upload server received %d bytes, want at least %d", received, len(video))
+			}
+
+			// Every progress call comes before publish, which is last
+			if events[len(events)-1] != "publish" {
+				t.Errorf("events end with %q, want publish", events[len(events)-1])
+			}
+			for i, event := range events[:len(events)-1] {
+				if event == "publish" {
+					t.Errorf("publish at event %d of %d, before the upload finished", i, len(events))
+				}
+			}
+		})
+	}
+}
+
+// TestUploadProgressRejected stops the upload early: the progress reported stays within the
+// file and ends where the bytes sent do
+func TestUploadProgressRejected(t *testing.T) {
+	video := bytes.Repeat([]byte("0123456789abcdef"), 65536) // 1 MiB
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(path, video, 0644); err != nil {
+		t.Fatalf("write video: %v", err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.CopyN(io.Discard, r.Body, 64*1024)
+		http.Error(w, "signature expired", http.StatusForbidden)
+	})
+	service := newTestService(t, handler, nil)
+	target := &uploadTarget{URL: service.baseURL + "/upload", Method: UploadMethodPut}
+
+	var (
+		mu       sync.Mutex
+		progress []int64
+		reported int64
+	)
+	err := service.uploadVideoFile(context.Background(), target, &UploadRequest{
+		VideoPath: path,
+		ProgressCallback: func(sent, total int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			if sent > total || (len(progress) > 0 && sent <= progress[len(progress)-1]) {
+				t.Errorf("progress %d of %d after %v", sent, total, progress)
+			}
+			progress = append(progress, sent)
+		},
+		OnBytesSent: func(n int64) { reported = n },
+	})
+	if err == nil {
+		t.Fatal("uploadVideoFile() succeeded, want the rejection")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(progress) == 0 || progress[len(progress)-1] != reported {
+		t.Errorf("progress %v, bytes sent %d; want the last progress to match", progress, reported)
+	}
+}
@@ -0,0 +1,76 @@
+package tiktok
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/chromedp"
+)
+
+// SetProxies sets the source of the browser's proxy (tiktok.proxy); the first entry is used and
+// nil connects directly
+func (u *WebUploader) SetProxies(proxies func() []*url.URL) {
+	u.proxies = proxies
+}
+
+// proxy returns the browser's current proxy, or nil
+func (u *WebUploader) proxy() *url.URL {
+	if u.proxies == nil {
+		return nil
+	}
+	if list := u.proxies(); len(list) > 0 {
+		return list[0]
+	}
+	return nil
+}
+
+// proxyOptions returns the allocator options routing the browser through proxy. Chrome takes
+// the proxy without credentials on its command line; they are answered by authenticateProxy.
+func proxyOptions(proxy *url.URL) []chromedp.ExecAllocatorOption {
+	if proxy == nil {
+		return nil
+	}
+	scheme := proxy.Scheme
+	if scheme == "socks5h" {
+		// Chrome always resolves host names through a SOCKS5 proxy
+		scheme = "socks5"
+	}
+	return []chromedp.ExecAllocatorOption{chromedp.ProxyServer(scheme + "://" + proxy.Host)}
+}
+
+// authenticateProxy answers the proxy's authentication challenges with its credentials, if it
+// has any. It must run on the browser context before the first navigation. Chrome cannot
+// authenticate to SOCKS proxies, so their credentials go unused.
+func authenticateProxy(ctx context.Context, proxy *url.URL) error {
+	if proxy == nil || proxy.User == nil {
+		return nil
+	}
+	username := proxy.User.Username()
+	password, _ := proxy.User.Password()
+
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch ev := ev.(type) {
+		case *fetch.EventRequestPaused:
+			go func() {
+				execCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+				_ = fetch.ContinueRequest(ev.RequestID).Do(execCtx)
+			}()
+		case *fetch.EventAuthRequired:
+			go func() {
+				response := &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+				if ev.AuthChallenge != nil && ev.AuthChallenge.Source == fetch.AuthChallengeSourceProxy {
+					response = &fetch.AuthChallengeResponse{
+						Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+						Username: username,
+						Password: password,
+					}
+				}
+				execCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+				_ = fetch.ContinueWithAuth(ev.RequestID, response).Do(execCtx)
+			}()
+		}
+	})
+	return chromedp.Run(ctx, fetch.Enable().WithHandleAuthRequests(true))
+}
@@ -4,18 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"net/url"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 )
 
+// webUserAgent is the browser the web uploader presents itself as
+const webUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// webPlaceholderPrefix starts the video IDs web uploads return, as they cannot read the real one
+const webPlaceholderPrefix = "web_upload_"
+
+// IsPlaceholderVideoID reports whether a TikTok video ID is a web upload placeholder, which no
+// TikTok page or API call knows
+func IsPlaceholderVideoID(id string) bool {
+	return strings.HasPrefix(id, webPlaceholderPrefix)
+}
+
 // WebUploader handles video upload via browser automation
 type WebUploader struct {
 	cookiesPath string
 	headless    bool
+	proxies     func() []*url.URL // Optional; see SetProxies
 }
 
 // NewWebUploader creates a new WebUploader
@@ -34,8 +48,10 @@ func (u *WebUploader) UploadVideo(ctx context.Context, req *UploadRequest) (stri
 		chromedp.Flag("disable-gpu", true),
 		chromedp.Flag("no-sandbox", true),
 		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		chromedp.UserAgent(webUserAgent),
 	)
+	proxy := u.proxy()
+	opts = append(opts, proxyOptions(proxy)...)
 
 	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
 	defer cancel()
@@ -43,6 +59,9 @@ func (u *WebUploader) UploadVideo(ctx context.Context, req *UploadRequest) (stri
 	// Create browser context
 	ctx, cancel = chromedp.NewContext(allocCtx)
 	defer cancel()
+	if err := authenticateProxy(ctx, proxy); err != nil {
+		return "", fmt.Errorf("failed to set up proxy: %w", err)
+	}
 
 	// Set timeout for the entire operation
 	ctx, cancel = context.WithTimeout(ctx, 10*time.Minute)
@@ -97,7 +116,7 @@ func (u *WebUploader) UploadVideo(ctx context.Context, req *UploadRequest) (stri
 		// Set caption
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			fmt.Println("[WEB UPLOAD] Setting caption...")
-			return chromedp.SendKeys(captionSel, req.Title+" #fyp #tiktok", chromedp.NodeVisible).Do(ctx)
+			return chromedp.SendKeys(captionSel, WebCaption(req), chromedp.NodeVisible).Do(ctx)
 		}),
 
 		chromedp.Sleep(2*time.Second),
@@ -118,69 +137,121 @@ func (u *WebUploader) UploadVideo(ctx context.Context, req *UploadRequest) (stri
 
 	// For now, return a placeholder ID as we can't easily extract the real ID from web
 	// In a real scenario, we might parse the success URL or response
-	videoID = fmt.Sprintf("web_upload_%d", time.Now().Unix())
+	videoID = fmt.Sprintf("%s%d", webPlaceholderPrefix, time.Now().Unix())
 
 	return videoID, nil
 }
 
-// loadCookies loads cookies from file and sets them in the browser
+// PostComment posts a comment on a published video using browser automation
+func (u *WebUploader) PostComment(ctx context.Context, req *CommentRequest) error {
+	if IsPlaceholderVideoID(req.VideoID) {
+		return fmt.Errorf("cannot comment on placeholder video id %s", req.VideoID)
+	}
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", u.headless),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.UserAgent(webUserAgent),
+	)
+	proxy := u.proxy()
+	opts = append(opts, proxyOptions(proxy)...)
+
+	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
+	defer cancel()
+
+	ctx, cancel = chromedp.NewContext(allocCtx)
+	defer cancel()
+	if err := authenticateProxy(ctx, proxy); err != nil {
+		return fmt.Errorf("failed to set up proxy: %w", err)
+	}
+
+	ctx, cancel = context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	if err := u.loadCookies(ctx); err != nil {
+		return fmt.Errorf("failed to load cookies: %w", err)
+	}
+
+	const (
+		commentInputSel = "div[data-e2e='comment-input'] .public-DraftEditor-content"
+		commentPostSel  = "div[data-e2e='comment-post']"
+	)
+
+	videoURL := fmt.Sprintf("https://www.tiktok.com/@/video/%s", req.VideoID)
+	fmt.Printf("[WEB COMMENT] Posting comment on %s\n", videoURL)
+
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(videoURL),
+		chromedp.Sleep(5*time.Second), // Wait for page load
+		chromedp.Click(commentInputSel, chromedp.NodeVisible),
+		chromedp.SendKeys(commentInputSel, req.Text, chromedp.NodeVisible),
+		chromedp.Sleep(1*time.Second),
+		chromedp.Click(commentPostSel, chromedp.NodeVisible),
+		chromedp.Sleep(3*time.Second),
+	)
+	if err != nil {
+		return fmt.Errorf("browser automation failed: %w", err)
+	}
+
+	return nil
+}
+
+// loadCookies loads cookies from file and sets them in the browser. A missing, corrupt or
+// signed-out cookies file fails the upload instead of letting it go ahead logged out.
 func (u *WebUploader) loadCookies(ctx context.Context) error {
 	if u.cookiesPath == "" {
 		return fmt.Errorf("cookies path is empty")
 	}
 
-	data, err := os.ReadFile(u.cookiesPath)
+	cookies, err := u.readCookies()
 	if err != nil {
 		return err
 	}
 
-	// Try parsing as JSON first (EditThisCookie format)
-	var cookies []struct {
-		Name     string  `json:"name"`
-		Value    string  `json:"value"`
-		Domain   string  `json:"domain"`
-		Path     string  `json:"path"`
-		Expires  float64 `json:"expirationDate"`
-		HttpOnly bool    `json:"httpOnly"`
-		Secure   bool    `json:"secure"`
-		SameSite string  `json:"sameSite"`
-	}
-
-	if err := json.Unmarshal(data, &cookies); err == nil {
-		return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
-			for _, c := range cookies {
-				// Convert SameSite string to network.CookieSameSite
-				sameSite := network.CookieSameSiteLax
-				switch c.SameSite {
-				case "Strict":
-					sameSite = network.CookieSameSiteStrict
-				case "None":
-					sameSite = network.CookieSameSiteNone
-				}
-
-				err := network.SetCookie(c.Name, c.Value).
-					WithDomain(c.Domain).
-					WithPath(c.Path).
-					WithHTTPOnly(c.HttpOnly).
-					WithSecure(c.Secure).
-					WithSameSite(sameSite).
-					Do(ctx)
-				if err != nil {
-					return err
-				}
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		for _, c := range cookies {
+			// Convert SameSite string to network.CookieSameSite
+			sameSite := network.CookieSameSiteLax
+			switch c.SameSite {
+			case "Strict":
+				sameSite = network.CookieSameSiteStrict
+			case "None":
+				sameSite = network.CookieSameSiteNone
 			}
-			return nil
-		}))
-	}
-
-	// If JSON fails, assume Netscape format (simple parsing)
-	// TODO: Implement Netscape format parsing if needed
 
-	return nil
+			err := network.SetCookie(c.Name, c.Value).
+				WithDomain(c.Domain).
+				WithPath(c.Path).
+				WithHTTPOnly(c.HttpOnly).
+				WithSecure(c.Secure).
+				WithSameSite(sameSite).
+				Do(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
 }
 
 // LoginAndSaveCookies opens a browser for the user to login and saves cookies
 func (u *WebUploader) LoginAndSaveCookies(ctx context.Context) error {
+	data, err := u.CaptureLoginCookies(ctx)
+	if err != nil {
+		return err
+	}
+	if err := u.writeCookies(data); err != nil {
+		return fmt.Errorf("failed to save cookies: %w", err)
+	}
+	fmt.Printf("[LOGIN MODE] Successfully saved cookies to %s\n", u.cookiesPath)
+	return nil
+}
+
+// CaptureLoginCookies opens a browser for the user to login and returns the session cookies as a
+// JSON cookie export, without writing them
+func (u *WebUploader) CaptureLoginCookies(ctx context.Context) ([]byte, error) {
 	// Force headless to false for interactive login
 	u.headless = false
 
@@ -190,8 +261,10 @@ func (u *WebUploader) LoginAndSaveCookies(ctx context.Context) error {
 		chromedp.Flag("disable-gpu", false),
 		chromedp.Flag("no-sandbox", true),
 		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		chromedp.UserAgent(webUserAgent),
 	)
+	proxy := u.proxy()
+	opts = append(opts, proxyOptions(proxy)...)
 
 	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
 	defer cancel()
@@ -199,6 +272,9 @@ func (u *WebUploader) LoginAndSaveCookies(ctx context.Context) error {
 	// Create browser context
 	ctx, cancel = chromedp.NewContext(allocCtx)
 	defer cancel()
+	if err := authenticateProxy(ctx, proxy); err != nil {
+		return nil, fmt.Errorf("failed to set up proxy: %w", err)
+	}
 
 	// Set a long timeout for user to login (e.g., 5 minutes)
 	ctx, cancel = context.WithTimeout(ctx, 5*time.Minute)
@@ -217,7 +293,7 @@ func (u *WebUploader) LoginAndSaveCookies(ctx context.Context) error {
 
 	// Navigate to login page
 	if err := chromedp.Run(ctx, chromedp.Navigate(loginURL)); err != nil {
-		return fmt.Errorf("failed to navigate to login page: %w", err)
+		return nil, fmt.Errorf("failed to navigate to login page: %w", err)
 	}
 
 	// Wait for user to navigate to upload page (indicating successful login)
@@ -249,7 +325,7 @@ func (u *WebUploader) LoginAndSaveCookies(ctx context.Context) error {
 		}),
 	)
 	if err != nil {
-		return fmt.Errorf("login timeout or error: %w", err)
+		return nil, fmt.Errorf("login timeout or error: %w", err)
 	}
 
 	// Get cookies
@@ -261,37 +337,17 @@ func (u *WebUploader) LoginAndSaveCookies(ctx context.Context) error {
 			return err
 		}),
 	); err != nil {
-		return fmt.Errorf("failed to get cookies: %w", err)
-	}
-
-	// Save cookies to file
-	if err := u.saveCookiesToFile(cookies); err != nil {
-		return fmt.Errorf("failed to save cookies: %w", err)
+		return nil, fmt.Errorf("failed to get cookies: %w", err)
 	}
 
-	fmt.Printf("[LOGIN MODE] Successfully saved %d cookies to %s\n", len(cookies), u.cookiesPath)
-	return nil
+	fmt.Printf("[LOGIN MODE] Captured %d cookies\n", len(cookies))
+	return encodeCookies(cookies)
 }
 
-// saveCookiesToFile saves cookies to the configured path in JSON format
-func (u *WebUploader) saveCookiesToFile(cookies []*network.Cookie) error {
-	if u.cookiesPath == "" {
-		return fmt.Errorf("cookies path is empty")
-	}
-
-	// Convert to JSON-friendly format (similar to EditThisCookie)
-	type CookieJSON struct {
-		Name     string  `json:"name"`
-		Value    string  `json:"value"`
-		Domain   string  `json:"domain"`
-		Path     string  `json:"path"`
-		Expires  float64 `json:"expirationDate"`
-		HttpOnly bool    `json:"httpOnly"`
-		Secure   bool    `json:"secure"`
-		SameSite string  `json:"sameSite"`
-	}
-
-	var cookiesJSON []CookieJSON
+// encodeCookies converts browser cookies to the JSON format (similar to EditThisCookie) of the
+// cookies file
+func encodeCookies(cookies []*network.Cookie) ([]byte, error) {
+	var cookiesJSON []webCookie
 	for _, c := range cookies {
 		sameSite := "Unspecified"
 		switch c.SameSite {
@@ -303,7 +359,7 @@ func (u *WebUploader) saveCookiesToFile(cookies []*network.Cookie) error {
 			sameSite = "None"
 		}
 
-		cookiesJSON = append(cookiesJSON, CookieJSON{
+		cookiesJSON = append(cookiesJSON, webCookie{
 			Name:     c.Name,
 			Value:    c.Value,
 			Domain:   c.Domain,
@@ -315,15 +371,5 @@ func (u *WebUploader) saveCookiesToFile(cookies []*network.Cookie) error {
 		})
 	}
 
-	data, err := json.MarshalIndent(cookiesJSON, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(u.cookiesPath), 0755); err != nil {
-		return err
-	}
-
-	return os.WriteFile(u.cookiesPath, data, 0644)
+	return json.MarshalIndent(cookiesJSON, "", "  ")
 }
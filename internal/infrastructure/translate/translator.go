@@ -0,0 +1,198 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	"auto_upload_tiktok/internal/redact"
+)
+
+// Translation providers accepted in translation.provider
+const (
+	ProviderDeepL  = "deepl"
+	ProviderGoogle = "google"
+)
+
+const (
+	deepLProURL  = "https://api.deepl.com/v2/translate"
+	deepLFreeURL = "https://api-free.deepl.com/v2/translate"
+	googleURL    = "https://translation.googleapis.com/language/translate/v2"
+)
+
+// NewTranslator builds the configured caption translator. It returns nil when translation.provider
+// is not set, which leaves captions untranslated.
+func NewTranslator(cfg *config.Config, httpClient *httpclient.HTTPClient) (domain.Translator, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.TranslationProvider))
+	if provider == "" {
+		return nil, nil
+	}
+	if cfg.TranslationAPIKey == "" {
+		return nil, fmt.Errorf("translation.api_key is required for provider %q", provider)
+	}
+
+	switch provider {
+	case ProviderDeepL:
+		return NewDeepLTranslator(cfg.TranslationAPIKey, cfg.TranslationAPIURL, httpClient), nil
+	case ProviderGoogle:
+		return NewGoogleTranslator(cfg.TranslationAPIKey, cfg.TranslationAPIURL, httpClient), nil
+	}
+	return nil, fmt.Errorf("unknown translation.provider %q (use %q or %q)", provider, ProviderDeepL, ProviderGoogle)
+}
+
+// DeepLTranslator translates through the DeepL API
+type DeepLTranslator struct {
+	apiKey string
+	apiURL string
+	client *httpclient.HTTPClient
+}
+
+// NewDeepLTranslator creates a DeepL translator. An empty apiURL picks the free or pro endpoint
+// from the key (free keys end in ":fx").
+func NewDeepLTranslator(apiKey, apiURL string, httpClient *httpclient.HTTPClient) *DeepLTranslator {
+	if apiURL == "" {
+		apiURL = deepLProURL
+		if strings.HasSuffix(apiKey, ":fx") {
+			apiURL = deepLFreeURL
+		}
+	}
+	return &DeepLTranslator{apiKey: apiKey, apiURL: apiURL, client: httpClient}
+}
+
+// Name returns the provider name
+func (t *DeepLTranslator) Name() string {
+	return ProviderDeepL
+}
+
+// Translate translates text into targetLanguage
+func (t *DeepLTranslator) Translate(ctx context.Context, text, targetLanguage string) (*domain.Translation, error) {
+	body, err := json.Marshal(map[string]any{
+		"text":        []string{text},
+		"target_lang": deepLTargetLanguage(targetLanguage),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+t.apiKey)
+
+	var result struct {
+		Translations []struct {
+			DetectedSourceLanguage string `json:"detected_source_language"`
+			Text                   string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := doJSON(t.client, req, &result); err != nil {
+		return nil, fmt.Errorf("deepl: %w", err)
+	}
+	if len(result.Translations) == 0 {
+		return nil, fmt.Errorf("deepl: response has no translations")
+	}
+	return &domain.Translation{
+		Text:           result.Translations[0].Text,
+		SourceLanguage: strings.ToLower(result.Translations[0].DetectedSourceLanguage),
+	}, nil
+}
+
+// deepLTargetLanguage maps ISO codes to DeepL target codes; English and Portuguese need a variant
+func deepLTargetLanguage(lang string) string {
+	switch strings.ToLower(lang) {
+	case "en":
+		return "EN-US"
+	case "pt":
+		return "PT-BR"
+	}
+	return strings.ToUpper(lang)
+}
+
+// GoogleTranslator translates through the Google Cloud Translation API (v2, API key)
+type GoogleTranslator struct {
+	apiKey string
+	apiURL string
+	client *httpclient.HTTPClient
+}
+
+// NewGoogleTranslator creates a Google Cloud Translation translator
+func NewGoogleTranslator(apiKey, apiURL string, httpClient *httpclient.HTTPClient) *GoogleTranslator {
+	if apiURL == "" {
+		apiURL = googleURL
+	}
+	return &GoogleTranslator{apiKey: apiKey, apiURL: apiURL, client: httpClient}
+}
+
+// Name returns the provider name
+func (t *GoogleTranslator) Name() string {
+	return ProviderGoogle
+}
+
+// Translate translates text into targetLanguage
+func (t *GoogleTranslator) Translate(ctx context.Context, text, targetLanguage string) (*domain.Translation, error) {
+	body, err := json.Marshal(map[string]any{
+		"q":      []string{text},
+		"target": targetLanguage,
+		"format": "text",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.apiURL+"?key="+url.QueryEscape(t.apiKey), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var result struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText         string `json:"translatedText"`
+				DetectedSourceLanguage string `json:"detectedSourceLanguage"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	if err := doJSON(t.client, req, &result); err != nil {
+		return nil, fmt.Errorf("google translate: %w", err)
+	}
+	if len(result.Data.Translations) == 0 {
+		return nil, fmt.Errorf("google translate: response has no translations")
+	}
+	return &domain.Translation{
+		Text:           result.Data.Translations[0].TranslatedText,
+		SourceLanguage: strings.ToLower(result.Data.Translations[0].DetectedSourceLanguage),
+	}, nil
+}
+
+// doJSON sends the request and decodes a successful JSON response into out
+func doJSON(client *httpclient.HTTPClient, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		// The Google key travels in the URL, which transport errors repeat
+		return fmt.Errorf("request failed: %w", redact.Error(err))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, redact.String(strings.TrimSpace(string(body))))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
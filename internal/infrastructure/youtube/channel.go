@@ -0,0 +1,188 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrChannelNotFound is returned when a handle or channel name matches no YouTube channel
+var ErrChannelNotFound = errors.New("youtube channel not found")
+
+// handlePattern matches what YouTube allows in a handle or legacy channel name
+var handlePattern = regexp.MustCompile(`^[0-9A-Za-z._-]{1,100}$`)
+
+// ParseChannelRef splits a channel reference into a channel ID or a normalized handle. It accepts
+// a channel ID, an @handle, or a youtube.com URL of the form /channel/UC..., /@handle, /c/name or
+// /user/name. Handles are returned lowercase with a leading @.
+func ParseChannelRef(input string) (channelID, handle string, err error) {
+	ref := strings.TrimSpace(input)
+	if ref == "" {
+		return "", "", fmt.Errorf("channel reference is empty")
+	}
+	if strings.HasPrefix(ref, "@") {
+		return normalizeHandle(ref[1:], input)
+	}
+	if !strings.Contains(ref, "/") && !strings.Contains(strings.ToLower(ref), "youtube.") {
+		return ref, "", nil
+	}
+
+	if !strings.Contains(ref, "://") {
+		ref = "https://" + ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid channel reference %q", input)
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	if host != "youtube.com" {
+		return "", "", fmt.Errorf("invalid channel reference %q: expected a channel ID, @handle or youtube.com URL", input)
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case strings.HasPrefix(segments[0], "@"):
+		return normalizeHandle(segments[0][1:], input)
+	case len(segments) >= 2 && segments[0] == "channel" && segments[1] != "":
+		return segments[1], "", nil
+	case len(segments) >= 2 && (segments[0] == "c" || segments[0] == "user"):
+		return normalizeHandle(segments[1], input)
+	default:
+		return "", "", fmt.Errorf("invalid channel reference %q: URL does not name a channel", input)
+	}
+}
+
+// normalizeHandle validates a handle without its @ and returns it in canonical form
+func normalizeHandle(name, input string) (string, string, error) {
+	if !handlePattern.MatchString(name) {
+		return "", "", fmt.Errorf("invalid channel handle %q", input)
+	}
+	return "", "@" + strings.ToLower(name), nil
+}
+
+// ResolveChannelHandle looks up the channel ID of a normalized handle. It asks the channels
+// endpoint by handle, then by legacy username, and finally searches for channels whose custom
+// URL is the handle. It returns ErrChannelNotFound when none matches.
+func (s *Service) ResolveChannelHandle(ctx context.Context, handle string) (string, error) {
+	if s.apiKey == "" {
+		return "", fmt.Errorf("youtube api key is required to resolve channel handle %s", handle)
+	}
+	name := strings.TrimPrefix(handle, "@")
+
+	for _, lookup := range []string{"forHandle", "forUsername"} {
+		params := url.Values{}
+		params.Set("part", "id")
+		params.Set(lookup, name)
+		items, err := s.getChannels(ctx, params)
+		if err != nil {
+			return "", err
+		}
+		if len(items) > 0 {
+			return items[0].ID, nil
+		}
+	}
+
+	return s.searchChannelHandle(ctx, handle)
+}
+
+// searchChannelHandle finds a channel through search, accepting only a result whose custom URL is
+// exactly the handle so a similarly named channel is never picked
+func (s *Service) searchChannelHandle(ctx context.Context, handle string) (string, error) {
+	params := url.Values{}
+	params.Set("part", "snippet")
+	params.Set("type", "channel")
+	params.Set("q", handle)
+	params.Set("maxResults", "10")
+	params.Set("key", s.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/search?%s", s.baseURL, params.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Items []struct {
+			ID struct {
+				ChannelID string `json:"channelId"`
+			} `json:"id"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	ids := make([]string, 0, len(result.Items))
+	for _, item := range result.Items {
+		if item.ID.ChannelID != "" {
+			ids = append(ids, item.ID.ChannelID)
+		}
+	}
+	if len(ids) == 0 {
+		return "", ErrChannelNotFound
+	}
+
+	details := url.Values{}
+	details.Set("part", "snippet")
+	details.Set("id", strings.Join(ids, ","))
+	items, err := s.getChannels(ctx, details)
+	if err != nil {
+		return "", err
+	}
+	for _, item := range items {
+		if strings.EqualFold(item.Snippet.CustomURL, handle) {
+			return item.ID, nil
+		}
+	}
+	return "", ErrChannelNotFound
+}
+
+// channelItem is the part of a channels resource used for handle resolution and status checks
+type channelItem struct {
+	ID      string `json:"id"`
+	Snippet struct {
+		Title     string `json:"title"`
+		CustomURL string `json:"customUrl"`
+	} `json:"snippet"`
+	Status struct {
+		PrivacyStatus string `json:"privacyStatus"`
+	} `json:"status"`
+}
+
+// getChannels fetches channels matching params from the channels endpoint
+func (s *Service) getChannels(ctx context.Context, params url.Values) ([]channelItem, error) {
+	params.Set("key", s.apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/channels?%s", s.baseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Items []channelItem `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
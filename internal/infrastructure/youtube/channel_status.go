@@ -0,0 +1,67 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// ChannelStatus is whether a channel can be read, as reported by channels.list
+type ChannelStatus struct {
+	// Unavailable is domain.SourceSuspended, domain.SourceTerminated or domain.SourcePrivate,
+	// or empty when the channel is available
+	Unavailable string
+
+	// Reason describes the unavailability
+	Reason string
+}
+
+// channelErrorStates maps the error reasons the Data API answers closed and suspended channels
+// with to the channel's state
+var channelErrorStates = map[string]string{
+	"channelClosed":    domain.SourceTerminated,
+	"accountClosed":    domain.SourceTerminated,
+	"channelSuspended": domain.SourceSuspended,
+	"accountSuspended": domain.SourceSuspended,
+}
+
+// GetChannelStatus checks a channel with channels.list (part=status,snippet, 1 quota unit).
+// Terminated and deleted channels are no longer returned at all, so a missing channel counts as
+// terminated; YouTube also hides most suspended channels this way. Other API errors, such as
+// exhausted quota, are returned as errors.
+func (s *Service) GetChannelStatus(ctx context.Context, channelID string) (ChannelStatus, error) {
+	if s.apiKey == "" {
+		return ChannelStatus{}, fmt.Errorf("youtube api key is required to check the status of channel %s", channelID)
+	}
+
+	params := url.Values{}
+	params.Set("part", "status,snippet")
+	params.Set("id", channelID)
+	items, err := s.getChannels(ctx, params)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			if state, ok := channelErrorStates[apiErr.Reason]; ok {
+				return ChannelStatus{Unavailable: state, Reason: fmt.Sprintf("YouTube reports %s: %s", apiErr.Reason, apiErr.Message)}, nil
+			}
+		}
+		return ChannelStatus{}, err
+	}
+
+	if len(items) == 0 {
+		return ChannelStatus{
+			Unavailable: domain.SourceTerminated,
+			Reason:      "YouTube no longer returns the channel; it was terminated, deleted or suspended",
+		}, nil
+	}
+	if items[0].Status.PrivacyStatus == "private" {
+		return ChannelStatus{
+			Unavailable: domain.SourcePrivate,
+			Reason:      fmt.Sprintf("the channel %q was made private", items[0].Snippet.Title),
+		}, nil
+	}
+	return ChannelStatus{}, nil
+}
@@ -0,0 +1,108 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+)
+
+// serveChannelFixture answers channels.list for channel UC-<name> with testdata/channels/<name>.json,
+// with the status code of its error when it is an error response
+func serveChannelFixture(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/channels" || query.Get("part") != "status,snippet" || query.Get("key") != "key" {
+			t.Errorf("unexpected YouTube request %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		name, _ := strings.CutPrefix(query.Get("id"), "UC-")
+		data, err := os.ReadFile(filepath.Join("testdata", "channels", name+".json"))
+		if err != nil {
+			t.Errorf("no fixture for channel %s: %v", query.Get("id"), err)
+			http.NotFound(w, r)
+			return
+		}
+		var response struct {
+			Error struct {
+				Code int `json:"code"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &response)
+		if response.Error.Code != 0 {
+			w.WriteHeader(response.Error.Code)
+		}
+		w.Write(data)
+	})
+}
+
+func TestGetChannelStatus(t *testing.T) {
+	tests := []struct {
+		fixture         string
+		wantUnavailable string
+		wantReason      string // substring of the reason
+		wantErr         error
+		wantAPIError    bool // another API error, returned as is
+	}{
+		{fixture: "public"},
+		{fixture: "unlisted"},
+		{fixture: "private", wantUnavailable: domain.SourcePrivate, wantReason: `"Travel Vlogs" was made private`},
+		{fixture: "terminated", wantUnavailable: domain.SourceTerminated, wantReason: "no longer returns the channel"},
+		{fixture: "closed", wantUnavailable: domain.SourceTerminated, wantReason: "channelClosed: The channel identified"},
+		{fixture: "suspended", wantUnavailable: domain.SourceSuspended, wantReason: "channelSuspended"},
+		{fixture: "quota", wantErr: ErrQuotaExceeded},
+		{fixture: "key_invalid", wantAPIError: true},
+	}
+
+	server := httptest.NewServer(serveChannelFixture(t))
+	t.Cleanup(server.Close)
+	cfg := &config.Config{YouTubeAPIKey: "key", HTTPClientTimeout: 5 * time.Second}
+	service := NewService(cfg, httpclient.NewHTTPClient(cfg))
+	service.baseURL = server.URL
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			status, err := service.GetChannelStatus(context.Background(), "UC-"+tt.fixture)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetChannelStatus() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.wantAPIError:
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || errors.Is(err, ErrQuotaExceeded) {
+					t.Fatalf("GetChannelStatus() error = %v, want a non-quota API error", err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("GetChannelStatus() error = %v", err)
+			}
+
+			if status.Unavailable != tt.wantUnavailable {
+				t.Errorf("GetChannelStatus() unavailable = %q, want %q", status.Unavailable, tt.wantUnavailable)
+			}
+			if tt.wantUnavailable == "" && status.Reason != "" {
+				t.Errorf("GetChannelStatus() of an available channel has reason %q", status.Reason)
+			}
+			if !strings.Contains(status.Reason, tt.wantReason) {
+				t.Errorf("GetChannelStatus() reason = %q, want it to contain %q", status.Reason, tt.wantReason)
+			}
+		})
+	}
+
+	if _, err := NewService(&config.Config{}, nil).GetChannelStatus(context.Background(), "UC-public"); err == nil {
+		t.Error("GetChannelStatus() without an API key succeeded")
+	}
+}
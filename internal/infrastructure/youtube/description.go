@@ -0,0 +1,150 @@
+package youtube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Markers around the part of a description this service manages; text outside them is never touched
+const (
+	DescriptionBlockStart = "[TikTok]"
+	DescriptionBlockEnd   = "[/TikTok]"
+)
+
+// maxDescriptionBytes is the Data API limit for video descriptions
+const maxDescriptionBytes = 5000
+
+// videoSnippet holds the writable snippet fields. videos.update replaces the whole snippet, so
+// everything that can be set must be sent back unchanged or it is cleared.
+type videoSnippet struct {
+	Title                string   `json:"title"`
+	Description          string   `json:"description"`
+	CategoryID           string   `json:"categoryId"`
+	Tags                 []string `json:"tags,omitempty"`
+	DefaultLanguage      string   `json:"defaultLanguage,omitempty"`
+	DefaultAudioLanguage string   `json:"defaultAudioLanguage,omitempty"`
+}
+
+// AddDescriptionLine makes sure line appears in the managed block of the video's description,
+// creating the block at the end when missing. It reports whether the description was changed;
+// calling it again with the same line changes nothing and costs only the read.
+func (s *Service) AddDescriptionLine(accessToken, videoID, line string) (bool, error) {
+	snippet, err := s.getSnippet(accessToken, videoID)
+	if err != nil {
+		return false, err
+	}
+
+	description := AddToManagedBlock(snippet.Description, line)
+	if description == snippet.Description {
+		return false, nil
+	}
+	if len(description) > maxDescriptionBytes {
+		return false, fmt.Errorf("description would exceed %d bytes", maxDescriptionBytes)
+	}
+
+	snippet.Description = description
+	if err := s.updateSnippet(accessToken, videoID, snippet); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AddToManagedBlock returns the description with line in the managed block. Lines already in the
+// block are kept, so several TikTok posts of one video (clips) are all listed.
+func AddToManagedBlock(description, line string) string {
+	line = strings.TrimSpace(line)
+	start := strings.Index(description, DescriptionBlockStart)
+	end := -1
+	if start >= 0 {
+		if i := strings.Index(description[start:], DescriptionBlockEnd); i >= 0 {
+			end = start + i
+		}
+	}
+
+	if start < 0 || end < 0 {
+		base := strings.TrimRight(description, " \t\r\n")
+		if base != "" {
+			base += "\n\n"
+		}
+		return base + DescriptionBlockStart + "\n" + line + "\n" + DescriptionBlockEnd
+	}
+
+	var lines []string
+	for _, existing := range strings.Split(description[start+len(DescriptionBlockStart):end], "\n") {
+		existing = strings.TrimSpace(existing)
+		if existing == line {
+			return description
+		}
+		if existing != "" {
+			lines = append(lines, existing)
+		}
+	}
+	lines = append(lines, line)
+
+	block := DescriptionBlockStart + "\n" + strings.Join(lines, "\n") + "\n" + DescriptionBlockEnd
+	return description[:start] + block + description[end+len(DescriptionBlockEnd):]
+}
+
+// getSnippet reads a video's snippet with the channel owner's token
+func (s *Service) getSnippet(accessToken, videoID string) (*videoSnippet, error) {
+	params := url.Values{}
+	params.Set("part", "snippet")
+	params.Set("id", videoID)
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/videos?%s", s.baseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Items []struct {
+			Snippet videoSnippet `json:"snippet"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Items) == 0 {
+		return nil, fmt.Errorf("video %s not found", videoID)
+	}
+	return &result.Items[0].Snippet, nil
+}
+
+// updateSnippet writes a video's snippet back through videos.update
+func (s *Service) updateSnippet(accessToken, videoID string, snippet *videoSnippet) error {
+	body, err := json.Marshal(map[string]any{
+		"id":      videoID,
+		"snippet": snippet,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/videos?part=snippet", s.baseURL), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
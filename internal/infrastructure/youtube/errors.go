@@ -0,0 +1,102 @@
+package youtube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when the Data API refuses requests because the project's daily
+// quota or rate limit is used up. It clears by itself, unlike other API errors.
+var ErrQuotaExceeded = errors.New("youtube api quota exceeded")
+
+// quotaReasons are the error reasons the Data API uses for exhausted quota and rate limits
+var quotaReasons = map[string]bool{
+	"quotaExceeded":         true,
+	"rateLimitExceeded":     true,
+	"dailyLimitExceeded":    true,
+	"userRateLimitExceeded": true,
+}
+
+// APIError is an error response from the Data API
+type APIError struct {
+	StatusCode int
+	Reason     string // First error reason, e.g. quotaExceeded or keyInvalid
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("youtube api error %d (%s): %s", e.StatusCode, e.Reason, e.Message)
+	}
+	return fmt.Sprintf("youtube api error %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap lets errors.Is match ErrQuotaExceeded for quota and rate limit responses
+func (e *APIError) Unwrap() error {
+	if e.IsQuota() {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// IsQuota reports whether the error is a quota or rate limit refusal rather than a hard failure
+// such as an invalid key, which is also sent as 403
+func (e *APIError) IsQuota() bool {
+	if quotaReasons[e.Reason] {
+		return true
+	}
+	return e.StatusCode == http.StatusTooManyRequests && e.Reason == ""
+}
+
+// checkResponse turns a non-2xx Data API response into an *APIError
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var payload struct {
+		Error struct {
+			Message string `json:"message"`
+			Errors  []struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		apiErr.Message = payload.Error.Message
+		if len(payload.Error.Errors) > 0 {
+			apiErr.Reason = payload.Error.Errors[0].Reason
+			if apiErr.Message == "" {
+				apiErr.Message = payload.Error.Errors[0].Message
+			}
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+	return apiErr
+}
+
+// NextQuotaReset returns the next midnight Pacific time after t, when the daily Data API quota resets
+func NextQuotaReset(t time.Time) time.Time {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		// No tz database: assume standard time, at worst resuming an hour late in summer
+		loc = time.FixedZone("PST", -8*60*60)
+	}
+	local := t.In(loc)
+	year, month, day := local.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+}
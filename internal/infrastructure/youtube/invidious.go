@@ -1,67 +1,83 @@
 package youtube
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
-)
 
-// InvidiousInstance represents a public Invidious instance
-type InvidiousInstance struct {
-	URL string
-}
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+)
 
-// PublicInstances lists working Invidious instances
+// PublicInstances lists public Invidious instances used when download.invidious_instances is empty
 var PublicInstances = []string{
 	"https://invidious.fdn.fr",
 	"https://invidious.privacydev.net",
 	"https://inv.tux.pizza",
 	"https://invidious.io.lol",
 	"https://yt.artemislena.eu",
+	"https://invidious.drgns.space",
+	"https://invidious.lunar.icu",
+	"https://invidious.projectsegfau.lt",
 }
 
-// GetVideoDownloadURL gets direct download URL from Invidious (no bot detection)
-func GetVideoDownloadURL(videoID string) (string, error) {
-	for _, instance := range PublicInstances {
-		url := fmt.Sprintf("%s/api/v1/videos/%s", instance, videoID)
+// invidiousFormat is one entry of formatStreams in the Invidious video API
+type invidiousFormat struct {
+	URL        string `json:"url"`
+	Type       string `json:"type"`
+	Resolution string `json:"resolution"`
+}
 
-		resp, err := http.Get(url)
-		if err != nil {
-			continue // Try next instance
-		}
-		defer resp.Body.Close()
+// GetVideoDownloadURL asks an Invidious instance for a direct mp4 URL of the video (no bot detection).
+// Only muxed formatStreams are considered; adaptive formats are video-only and would upload without sound.
+func GetVideoDownloadURL(ctx context.Context, client *httpclient.HTTPClient, instance, videoID string) (string, error) {
+	base := strings.TrimRight(instance, "/")
+	apiURL := fmt.Sprintf("%s/api/v1/videos/%s?fields=formatStreams", base, url.PathEscape(videoID))
 
-		if resp.StatusCode != 200 {
-			continue
-		}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create invidious request: %w", err)
+	}
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			continue
-		}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("invidious request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("invidious API returned status %d", resp.StatusCode)
+	}
+
+	var data struct {
+		FormatStreams []invidiousFormat `json:"formatStreams"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return "", fmt.Errorf("failed to decode invidious response: %w", err)
+	}
 
-		var data map[string]interface{}
-		if err := json.Unmarshal(body, &data); err != nil {
+	// Pick the highest resolution mp4
+	var best invidiousFormat
+	bestHeight := -1
+	for _, format := range data.FormatStreams {
+		if format.URL == "" || !strings.Contains(format.Type, "video/mp4") {
 			continue
 		}
-
-		// Get adaptive formats
-		if formats, ok := data["adaptiveFormats"].([]interface{}); ok && len(formats) > 0 {
-			// Find best video format
-			for _, f := range formats {
-				format := f.(map[string]interface{})
-				if url, ok := format["url"].(string); ok {
-					// Check if it's video format
-					if typ, ok := format["type"].(string); ok && strings.Contains(typ, "video/mp4") {
-						return url, nil
-					}
-				}
-			}
+		height, _ := strconv.Atoi(strings.TrimSuffix(format.Resolution, "p"))
+		if height > bestHeight {
+			best, bestHeight = format, height
 		}
 	}
+	if best.URL == "" {
+		return "", fmt.Errorf("no mp4 format found")
+	}
 
-	return "", fmt.Errorf("failed to get download URL from all Invidious instances")
+	// Instances with local proxying return paths relative to themselves
+	if strings.HasPrefix(best.URL, "/") {
+		return base + best.URL, nil
+	}
+	return best.URL, nil
 }
-
@@ -0,0 +1,114 @@
+package youtube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// oauthAuthorizeURL is Google's consent screen
+	oauthAuthorizeURL = "https://accounts.google.com/o/oauth2/v2/auth"
+
+	// oauthTokenURL exchanges codes and refresh tokens
+	oauthTokenURL = "https://oauth2.googleapis.com/token"
+
+	// OAuthScope lets the app edit the channel's videos
+	OAuthScope = "https://www.googleapis.com/auth/youtube.force-ssl"
+)
+
+// OAuthToken is Google's token endpoint response
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope"`
+	TokenType    string `json:"token_type"`
+}
+
+// OAuthEnabled reports whether a Google OAuth client is configured
+func (s *Service) OAuthEnabled() bool {
+	return s.oauthClientID != "" && s.oauthClientSecret != ""
+}
+
+// AuthorizeURL builds the Google consent URL. Offline access with a forced consent prompt makes
+// Google return a refresh token even when the channel owner authorized the app before.
+func (s *Service) AuthorizeURL(redirectURI, state string) string {
+	params := url.Values{}
+	params.Set("client_id", s.oauthClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("response_type", "code")
+	params.Set("scope", OAuthScope)
+	params.Set("access_type", "offline")
+	params.Set("prompt", "consent")
+	params.Set("include_granted_scopes", "true")
+	params.Set("state", state)
+	return oauthAuthorizeURL + "?" + params.Encode()
+}
+
+// ExchangeCode exchanges an authorization code for tokens. redirectURI must match the one sent
+// to the consent screen.
+func (s *Service) ExchangeCode(code, redirectURI string) (*OAuthToken, error) {
+	form := url.Values{}
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("grant_type", "authorization_code")
+	return s.requestToken(form)
+}
+
+// RefreshAccessToken gets a new access token with a refresh token. Google usually returns no new
+// refresh token, so callers keep the old one when RefreshToken is empty.
+func (s *Service) RefreshAccessToken(refreshToken string) (*OAuthToken, error) {
+	form := url.Values{}
+	form.Set("refresh_token", refreshToken)
+	form.Set("grant_type", "refresh_token")
+	return s.requestToken(form)
+}
+
+// requestToken posts a grant to the token endpoint with the client credentials
+func (s *Service) requestToken(form url.Values) (*OAuthToken, error) {
+	if !s.OAuthEnabled() {
+		return nil, fmt.Errorf("youtube oauth client is not configured")
+	}
+	form.Set("client_id", s.oauthClientID)
+	form.Set("client_secret", s.oauthClientSecret)
+
+	req, err := http.NewRequest(http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return nil, fmt.Errorf("google token request failed with status %d: %s - %s", resp.StatusCode, oauthErr.Error, oauthErr.Description)
+		}
+		return nil, fmt.Errorf("google token request failed with status %d", resp.StatusCode)
+	}
+
+	var token OAuthToken
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("google token response has no access_token")
+	}
+	return &token, nil
+}
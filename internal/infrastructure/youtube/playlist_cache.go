@@ -0,0 +1,71 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// errNotModified means a page requested with If-None-Match is unchanged
+var errNotModified = errors.New("not modified")
+
+// PlaylistCacheCounters counts since startup how often the playlist cache spared a Data API
+// call or a page download
+type PlaylistCacheCounters struct {
+	PlaylistIDHits   int64 `json:"uploads_playlist_hits"`   // Uploads playlist IDs read from the cache
+	PlaylistIDMisses int64 `json:"uploads_playlist_misses"` // channels.list calls to look one up
+	ETagHits         int64 `json:"etag_hits"`               // First pages answered 304 Not Modified
+	ETagMisses       int64 `json:"etag_misses"`             // First pages sent again despite an ETag
+}
+
+type playlistCacheStats struct {
+	idHits, idMisses, etagHits, etagMisses atomic.Int64
+}
+
+// SetPlaylistCache keeps channels' uploads playlist IDs, which never change, and the ETag of
+// first pages with nothing new in repo, so monitoring looks each playlist up once and skips
+// unchanged pages across restarts
+func (s *Service) SetPlaylistCache(repo domain.YouTubePlaylistRepository) {
+	s.playlists = repo
+}
+
+// PlaylistCacheCounters returns the playlist cache counters
+func (s *Service) PlaylistCacheCounters() PlaylistCacheCounters {
+	return PlaylistCacheCounters{
+		PlaylistIDHits:   s.playlistStats.idHits.Load(),
+		PlaylistIDMisses: s.playlistStats.idMisses.Load(),
+		ETagHits:         s.playlistStats.etagHits.Load(),
+		ETagMisses:       s.playlistStats.etagMisses.Load(),
+	}
+}
+
+// cachedPlaylist returns the cached uploads playlist of a channel, or nil without a cache or
+// entry. A failed read is logged and treated as a miss.
+func (s *Service) cachedPlaylist(ctx context.Context, channelID string) *domain.YouTubePlaylist {
+	if s.playlists == nil {
+		return nil
+	}
+	entry, err := s.playlists.Get(ctx, channelID)
+	if err != nil {
+		logger.Error().Printf("Failed to read the cached uploads playlist of channel %s: %v", channelID, err)
+		return nil
+	}
+	if entry == nil || entry.UploadsPlaylistID == "" {
+		return nil
+	}
+	return entry
+}
+
+// savePlaylist stores a channel's uploads playlist in the cache, if any. A failed write is
+// logged; the next check looks it up again.
+func (s *Service) savePlaylist(ctx context.Context, entry *domain.YouTubePlaylist) {
+	if s.playlists == nil {
+		return
+	}
+	if err := s.playlists.Save(context.WithoutCancel(ctx), entry); err != nil {
+		logger.Error().Printf("Failed to cache the uploads playlist of channel %s: %v", entry.ChannelID, err)
+	}
+}
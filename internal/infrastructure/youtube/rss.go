@@ -0,0 +1,163 @@
+package youtube
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+)
+
+// RSSFetcher discovers uploads through the public channel Atom feed.
+// The feed costs no API quota but only lists the latest 15 uploads.
+type RSSFetcher struct {
+	client  *httpclient.HTTPClient
+	feedURL string
+
+	mu    sync.Mutex
+	cache map[string]*feedCacheEntry // keyed by channel ID
+}
+
+// feedCacheEntry keeps validators for conditional GET along with the last parsed feed
+type feedCacheEntry struct {
+	etag         string
+	lastModified string
+	videos       []*domain.Video
+}
+
+// NewRSSFetcher creates a new RSS fetcher
+func NewRSSFetcher(httpClient *httpclient.HTTPClient) *RSSFetcher {
+	return &RSSFetcher{
+		client:  httpClient,
+		feedURL: "https://www.youtube.com/feeds/videos.xml",
+		cache:   make(map[string]*feedCacheEntry),
+	}
+}
+
+// atomFeed is the subset of the YouTube Atom feed we need
+type atomFeed struct {
+	Entries []struct {
+		VideoID   string    `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+		Title     string    `xml:"title"`
+		Published time.Time `xml:"published"`
+		Group     struct {
+			Description string `xml:"description"`
+			Thumbnail   struct {
+				URL string `xml:"url,attr"`
+			} `xml:"thumbnail"`
+		} `xml:"http://search.yahoo.com/mrss/ group"`
+	} `xml:"entry"`
+}
+
+// GetLatestVideos fetches uploads from the channel feed published after publishedAfter.
+// A zero publishedAfter returns every entry in the feed.
+func (f *RSSFetcher) GetLatestVideos(channelID string, publishedAfter time.Time) ([]*domain.Video, error) {
+	videos, err := f.fetchFeed(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	filtered := make([]*domain.Video, 0, len(videos))
+	for _, v := range videos {
+		if !publishedAfter.IsZero() && v.PublishedAt.Before(publishedAfter) {
+			continue
+		}
+		// Hand out copies so callers can mutate them without touching the cache
+		video := *v
+		video.CreatedAt = now
+		video.UpdatedAt = now
+		filtered = append(filtered, &video)
+	}
+	return filtered, nil
+}
+
+// fetchFeed downloads and parses the feed, reusing the cached result on 304 Not Modified
+func (f *RSSFetcher) fetchFeed(channelID string) ([]*domain.Video, error) {
+	params := url.Values{}
+	params.Set("channel_id", channelID)
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s?%s", f.feedURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	cached := f.cache[channelID]
+	f.mu.Unlock()
+
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed for channel %s: %w", channelID, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached.videos, nil
+	case resp.StatusCode == http.StatusNotFound:
+		// YouTube answers 404 for channels that have no public uploads yet
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("feed request for channel %s failed with status %d", channelID, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed for channel %s: %w", channelID, err)
+	}
+
+	videos, err := parseFeed(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed for channel %s: %w", channelID, err)
+	}
+
+	f.mu.Lock()
+	f.cache[channelID] = &feedCacheEntry{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		videos:       videos,
+	}
+	f.mu.Unlock()
+
+	return videos, nil
+}
+
+// parseFeed converts the Atom XML into videos, skipping entries without a video ID
+func parseFeed(body []byte) ([]*domain.Video, error) {
+	var feed atomFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, err
+	}
+
+	videos := make([]*domain.Video, 0, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		if entry.VideoID == "" {
+			continue
+		}
+		videos = append(videos, &domain.Video{
+			ID:             entry.VideoID,
+			YouTubeVideoID: entry.VideoID,
+			Title:          entry.Title,
+			Description:    entry.Group.Description,
+			ThumbnailURL:   entry.Group.Thumbnail.URL,
+			PublishedAt:    entry.Published,
+			Status:         domain.VideoStatusPending,
+		})
+	}
+	return videos, nil
+}
@@ -1,10 +1,13 @@
 package youtube
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"auto_upload_tiktok/config"
@@ -17,6 +20,16 @@ type Service struct {
 	apiKey  string
 	client  *httpclient.HTTPClient
 	baseURL string
+	rss     *RSSFetcher
+
+	// Google OAuth client for editing the channel owner's videos
+	oauthClientID     string
+	oauthClientSecret string
+	tokenURL          string
+
+	// Optional: uploads playlist IDs and first page ETags, see SetPlaylistCache
+	playlists     domain.YouTubePlaylistRepository
+	playlistStats playlistCacheStats
 }
 
 // NewService creates a new YouTube service
@@ -25,6 +38,11 @@ func NewService(cfg *config.Config, httpClient *httpclient.HTTPClient) *Service
 		apiKey:  cfg.YouTubeAPIKey,
 		client:  httpClient,
 		baseURL: "https://www.googleapis.com/youtube/v3",
+		rss:     NewRSSFetcher(httpClient),
+
+		oauthClientID:     cfg.YouTubeOAuthClientID,
+		oauthClientSecret: cfg.YouTubeOAuthClientSecret,
+		tokenURL:          oauthTokenURL,
 	}
 }
 
@@ -48,32 +66,86 @@ type SearchResponse struct {
 	Items []VideoItem `json:"items"`
 }
 
-// GetLatestVideos fetches the latest videos from a YouTube channel
-func (s *Service) GetLatestVideos(channelID string, maxResults int) ([]*domain.Video, error) {
+// playlistPageSize is the maximum page size accepted by the playlistItems endpoint.
+const playlistPageSize = 50
+
+// MaxVideoIDsPerCall is how many videos one videos.list call looks up
+const MaxVideoIDsPerCall = playlistPageSize
+
+// Data API quota cost of single calls
+const (
+	QuotaUnitsList   = 1  // Any list call, e.g. videos.list
+	QuotaUnitsUpdate = 50 // videos.update
+)
+
+// GetLatestVideos fetches the latest videos from a YouTube channel.
+// Uploads are paginated newest-first until an item older than publishedAfter is
+// seen or maxResults videos have been collected. A zero publishedAfter fetches a
+// single page. With a playlist cache, the uploads playlist is looked up once and
+// an unchanged first page (304 Not Modified) returns no videos without parsing.
+func (s *Service) GetLatestVideos(ctx context.Context, channelID string, maxResults int, publishedAfter time.Time) ([]*domain.Video, error) {
 	// First, get the uploads playlist ID
-	playlistID, err := s.getUploadsPlaylistID(channelID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get uploads playlist: %w", err)
+	cached := s.cachedPlaylist(ctx, channelID)
+	playlistID := ""
+	if cached != nil {
+		playlistID = cached.UploadsPlaylistID
+		s.playlistStats.idHits.Add(1)
+	} else {
+		var err error
+		playlistID, err = s.getUploadsPlaylistID(ctx, channelID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get uploads playlist: %w", err)
+		}
+		cached = &domain.YouTubePlaylist{ChannelID: channelID, UploadsPlaylistID: playlistID}
+		s.savePlaylist(ctx, cached)
 	}
 
 	// Get videos from the uploads playlist
-	videos, err := s.getPlaylistVideos(playlistID, maxResults)
+	etag := cached.ETag
+	if publishedAfter.IsZero() || publishedAfter.Before(cached.CheckedAfter) {
+		etag = ""
+	}
+	videos, pageETag, err := s.getPlaylistVideos(ctx, playlistID, maxResults, publishedAfter, etag)
+	if errors.Is(err, errNotModified) {
+		s.playlistStats.etagHits.Add(1)
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get playlist videos: %w", err)
 	}
+	if etag != "" {
+		s.playlistStats.etagMisses.Add(1)
+	}
+
+	// The ETag is only kept for a first page with nothing new, so a 304 cannot hide videos a
+	// failed scan never stored
+	if len(videos) == 0 && !publishedAfter.IsZero() && pageETag != "" {
+		cached.ETag, cached.CheckedAfter = pageETag, publishedAfter
+		s.savePlaylist(ctx, cached)
+	} else if cached.ETag != "" {
+		cached.ETag, cached.CheckedAfter = "", time.Time{}
+		s.savePlaylist(ctx, cached)
+	}
 
 	return videos, nil
 }
 
+// GetLatestVideosRSS fetches the latest uploads from the channel's public feed.
+// It costs no API quota but only sees the 15 most recent uploads.
+func (s *Service) GetLatestVideosRSS(channelID string, publishedAfter time.Time) ([]*domain.Video, error) {
+	return s.rss.GetLatestVideos(channelID, publishedAfter)
+}
+
 // getUploadsPlaylistID retrieves the uploads playlist ID for a channel
-func (s *Service) getUploadsPlaylistID(channelID string) (string, error) {
+func (s *Service) getUploadsPlaylistID(ctx context.Context, channelID string) (string, error) {
+	s.playlistStats.idMisses.Add(1)
 	apiURL := fmt.Sprintf("%s/channels", s.baseURL)
 	params := url.Values{}
 	params.Set("part", "contentDetails")
 	params.Set("id", channelID)
 	params.Set("key", s.apiKey)
 
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s?%s", apiURL, params.Encode()), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?%s", apiURL, params.Encode()), nil)
 	if err != nil {
 		return "", err
 	}
@@ -83,6 +155,9 @@ func (s *Service) getUploadsPlaylistID(channelID string) (string, error) {
 		return "", err
 	}
 	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
 
 	var result struct {
 		Items []struct {
@@ -105,66 +180,322 @@ func (s *Service) getUploadsPlaylistID(channelID string) (string, error) {
 	return result.Items[0].ContentDetails.RelatedPlaylists.Uploads, nil
 }
 
-// getPlaylistVideos retrieves videos from a playlist
-func (s *Service) getPlaylistVideos(playlistID string, maxResults int) ([]*domain.Video, error) {
+// getPlaylistVideos retrieves videos from a playlist, following pageToken until
+// the cutoff or maxResults is reached. The first page is requested with If-None-Match
+// when etag is set, and errNotModified returned when it is unchanged; the first page's
+// ETag is returned with the videos.
+func (s *Service) getPlaylistVideos(ctx context.Context, playlistID string, maxResults int, publishedAfter time.Time, etag string) ([]*domain.Video, string, error) {
+	if maxResults <= 0 {
+		maxResults = playlistPageSize
+	}
+
+	videos := make([]*domain.Video, 0, min(maxResults, playlistPageSize))
+	pageToken := ""
+	firstETag := ""
+	for {
+		pageSize := min(maxResults-len(videos), playlistPageSize)
+		page, err := s.getPlaylistPage(ctx, playlistID, pageSize, pageToken, etag)
+		if err != nil {
+			return nil, "", err
+		}
+		if pageToken == "" {
+			firstETag = page.ETag
+		}
+		etag = ""
+
+		reachedCutoff := false
+		for _, item := range page.Items {
+			if !publishedAfter.IsZero() && item.Snippet.PublishedAt.Before(publishedAfter) {
+				// Uploads are listed newest first, so anything past this point is older still.
+				reachedCutoff = true
+				break
+			}
+			videos = append(videos, &domain.Video{
+				ID:             item.ContentDetails.VideoID,
+				YouTubeVideoID: item.ContentDetails.VideoID,
+				Title:          item.Snippet.Title,
+				Description:    item.Snippet.Description,
+				ThumbnailURL:   item.Snippet.Thumbnails.Default.URL,
+				Status:         domain.VideoStatusPending,
+				PublishedAt:    item.Snippet.PublishedAt,
+				CreatedAt:      time.Now(),
+				UpdatedAt:      time.Now(),
+			})
+		}
+
+		if reachedCutoff || publishedAfter.IsZero() || page.NextPageToken == "" || len(videos) >= maxResults {
+			return videos, firstETag, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// playlistPage is a single page of the playlistItems response.
+type playlistPage struct {
+	ETag          string `json:"-"` // From the ETag header
+	NextPageToken string `json:"nextPageToken"`
+	Items         []struct {
+		Snippet struct {
+			PublishedAt time.Time `json:"publishedAt"`
+			Title       string    `json:"title"`
+			Description string    `json:"description"`
+			Thumbnails  struct {
+				Default struct {
+					URL string `json:"url"`
+				} `json:"default"`
+			} `json:"thumbnails"`
+		} `json:"snippet"`
+		ContentDetails struct {
+			VideoID string `json:"videoId"`
+		} `json:"contentDetails"`
+	} `json:"items"`
+}
+
+// getPlaylistPage fetches one page of playlist items, or returns errNotModified when etag is
+// set and still matches the page.
+func (s *Service) getPlaylistPage(ctx context.Context, playlistID string, pageSize int, pageToken string, etag string) (*playlistPage, error) {
 	apiURL := fmt.Sprintf("%s/playlistItems", s.baseURL)
 	params := url.Values{}
 	params.Set("part", "snippet,contentDetails")
 	params.Set("playlistId", playlistID)
-	params.Set("maxResults", fmt.Sprintf("%d", maxResults))
+	params.Set("maxResults", fmt.Sprintf("%d", pageSize))
 	params.Set("key", s.apiKey)
-	params.Set("order", "date")
+	if pageToken != "" {
+		params.Set("pageToken", pageToken)
+	}
 
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s?%s", apiURL, params.Encode()), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?%s", apiURL, params.Encode()), nil)
 	if err != nil {
 		return nil, err
 	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if etag != "" && resp.StatusCode == http.StatusNotModified {
+		return nil, errNotModified
+	}
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
 
-	var result struct {
-		Items []struct {
-			Snippet struct {
-				PublishedAt time.Time `json:"publishedAt"`
-				Title       string    `json:"title"`
-				Description string    `json:"description"`
-				Thumbnails  struct {
-					Default struct {
-						URL string `json:"url"`
-					} `json:"default"`
-				} `json:"thumbnails"`
-			} `json:"snippet"`
-			ContentDetails struct {
-				VideoID string `json:"videoId"`
-			} `json:"contentDetails"`
-		} `json:"items"`
+	var page playlistPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
 	}
+	page.ETag = resp.Header.Get("ETag")
+	return &page, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+// GetVideoPublishedAt looks up when each video was published, batching IDs per request.
+// Videos the API does not return (deleted or private) are absent from the result.
+func (s *Service) GetVideoPublishedAt(videoIDs []string) (map[string]time.Time, error) {
+	items, err := s.getVideoDetails(videoIDs, "snippet")
+	if err != nil {
 		return nil, err
 	}
 
-	videos := make([]*domain.Video, 0, len(result.Items))
-	for _, item := range result.Items {
-		video := &domain.Video{
-			ID:             item.ContentDetails.VideoID,
-			YouTubeVideoID: item.ContentDetails.VideoID,
-			Title:          item.Snippet.Title,
-			Description:    item.Snippet.Description,
-			ThumbnailURL:   item.Snippet.Thumbnails.Default.URL,
-			Status:         domain.VideoStatusPending,
-			PublishedAt:    item.Snippet.PublishedAt,
-			CreatedAt:      time.Now(),
-			UpdatedAt:      time.Now(),
+	published := make(map[string]time.Time, len(items))
+	for _, item := range items {
+		if !item.Snippet.PublishedAt.IsZero() {
+			published[item.ID] = item.Snippet.PublishedAt
 		}
-		videos = append(videos, video)
 	}
+	return published, nil
+}
 
-	return videos, nil
+// VideoMetadata is what discovery learns about a video from the videos endpoint
+type VideoMetadata struct {
+	PublishedAt          time.Time     // Zero when the API left it out
+	Duration             time.Duration // Zero when the API left it out or it could not be parsed
+	Definition           string        // "hd" or "sd"
+	LiveBroadcastContent string        // "none", "live" or "upcoming"
+	RegionAllowed        []string      // Region codes the video is limited to (empty: no allow list)
+	RegionBlocked        []string      // Region codes the video is blocked in
+	ViewCount            int64         // Zero when the API left it out
+}
+
+// GetVideoMetadata looks up the publish time, duration, definition, live status, region
+// restriction and view count of each video with one videos.list call per 50 IDs. Videos the API does not return
+// (deleted, private or wrong IDs) are absent from the result. When a request fails, the videos of
+// the earlier requests are returned along with the error.
+func (s *Service) GetVideoMetadata(videoIDs []string) (map[string]VideoMetadata, error) {
+	items, err := s.getVideoDetails(videoIDs, "snippet,contentDetails,statistics")
+
+	metadata := make(map[string]VideoMetadata, len(items))
+	for _, item := range items {
+		duration, _ := parseISODuration(item.ContentDetails.Duration)
+		metadata[item.ID] = VideoMetadata{
+			PublishedAt:          item.Snippet.PublishedAt,
+			Duration:             duration,
+			Definition:           item.ContentDetails.Definition,
+			LiveBroadcastContent: item.Snippet.LiveBroadcastContent,
+			RegionAllowed:        item.ContentDetails.RegionRestriction.Allowed,
+			RegionBlocked:        item.ContentDetails.RegionRestriction.Blocked,
+			ViewCount:            item.Statistics.ViewCount,
+		}
+	}
+	return metadata, err
+}
+
+// GetVideoViewCounts looks up the view count of each video with one videos.list call per 50 IDs.
+// Videos the API does not return (deleted or private) are absent from the result. When a request
+// fails, the counts of the earlier requests are returned along with the error.
+func (s *Service) GetVideoViewCounts(videoIDs []string) (map[string]int64, error) {
+	items, err := s.getVideoDetails(videoIDs, "statistics")
+
+	views := make(map[string]int64, len(items))
+	for _, item := range items {
+		views[item.ID] = item.Statistics.ViewCount
+	}
+	return views, err
+}
+
+// GetVideo looks up a single video as a pending video, along with the ID of the channel that
+// published it. The video is nil when the API does not return it (deleted, private or a wrong ID).
+func (s *Service) GetVideo(videoID string) (*domain.Video, string, error) {
+	items, err := s.getVideoDetails([]string{videoID}, "snippet")
+	if err != nil {
+		return nil, "", err
+	}
+	if len(items) == 0 {
+		return nil, "", nil
+	}
+
+	item := items[0]
+	now := time.Now()
+	video := &domain.Video{
+		ID:             item.ID,
+		YouTubeVideoID: item.ID,
+		Title:          item.Snippet.Title,
+		Description:    item.Snippet.Description,
+		ThumbnailURL:   item.Snippet.Thumbnails.Default.URL,
+		Status:         domain.VideoStatusPending,
+		PublishedAt:    item.Snippet.PublishedAt,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	return video, item.Snippet.ChannelID, nil
+}
+
+// videoDetails is an item of the videos response; only the requested parts are filled in
+type videoDetails struct {
+	ID      string `json:"id"`
+	Snippet struct {
+		PublishedAt time.Time `json:"publishedAt"`
+		ChannelID   string    `json:"channelId"`
+		Title       string    `json:"title"`
+		Description string    `json:"description"`
+		Thumbnails  struct {
+			Default struct {
+				URL string `json:"url"`
+			} `json:"default"`
+		} `json:"thumbnails"`
+		LiveBroadcastContent string `json:"liveBroadcastContent"`
+	} `json:"snippet"`
+	ContentDetails struct {
+		Duration          string `json:"duration"`
+		Definition        string `json:"definition"`
+		RegionRestriction struct {
+			Allowed []string `json:"allowed"`
+			Blocked []string `json:"blocked"`
+		} `json:"regionRestriction"`
+	} `json:"contentDetails"`
+	Statistics struct {
+		ViewCount int64 `json:"viewCount,string"`
+	} `json:"statistics"`
+}
+
+// getVideoDetails fetches the given parts of each video, 50 IDs per request. On error the items
+// of the requests that succeeded are returned with it.
+func (s *Service) getVideoDetails(videoIDs []string, part string) ([]videoDetails, error) {
+	var items []videoDetails
+	for start := 0; start < len(videoIDs); start += MaxVideoIDsPerCall {
+		end := min(start+MaxVideoIDsPerCall, len(videoIDs))
+
+		params := url.Values{}
+		params.Set("part", part)
+		params.Set("id", strings.Join(videoIDs[start:end], ","))
+		params.Set("key", s.apiKey)
+
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/videos?%s", s.baseURL, params.Encode()), nil)
+		if err != nil {
+			return items, err
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return items, err
+		}
+		if err := checkResponse(resp); err != nil {
+			resp.Body.Close()
+			return items, err
+		}
+
+		var result struct {
+			Items []videoDetails `json:"items"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return items, err
+		}
+		items = append(items, result.Items...)
+	}
+	return items, nil
+}
+
+// parseISODuration parses the ISO 8601 durations used by the Data API (e.g. PT1M5S, P1DT2H)
+func parseISODuration(value string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(value, "P")
+	if !ok || rest == "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	inTime := false
+	num := 0
+	hasNum := false
+	for _, r := range rest {
+		switch {
+		case r >= '0' && r <= '9':
+			num = num*10 + int(r-'0')
+			hasNum = true
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+
+		if !hasNum {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		n := time.Duration(num)
+		switch {
+		case r == 'W' && !inTime:
+			total += n * 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			total += n * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += n * time.Hour
+		case r == 'M' && inTime:
+			total += n * time.Minute
+		case r == 'S' && inTime:
+			total += n * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		num, hasNum = 0, false
+	}
+	if hasNum {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return total, nil
 }
 
 // DownloadVideo downloads a video from YouTube
@@ -0,0 +1,401 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+)
+
+// fakeUploads serves a channel whose uploads playlist holds uploads videos, one an hour back
+// from newest, listed newest first in pages of the requested size. Page tokens are the offset
+// of the page.
+type fakeUploads struct {
+	t       *testing.T
+	uploads int
+	newest  time.Time
+
+	mu    sync.Mutex
+	pages []pageRequest // playlistItems requests in order
+}
+
+// pageRequest is what a playlistItems request asked for
+type pageRequest struct {
+	maxResults int
+	pageToken  string
+}
+
+func (f *fakeUploads) publishedAt(i int) time.Time {
+	return f.newest.Add(-time.Duration(i) * time.Hour)
+}
+
+func (f *fakeUploads) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch r.URL.Path {
+	case "/channels":
+		w.Write([]byte(`{"items":[{"contentDetails":{"relatedPlaylists":{"uploads":"UU-test"}}}]}`))
+	case "/playlistItems":
+		if query.Get("playlistId") != "UU-test" {
+			f.t.Errorf("playlistId = %q, want UU-test", query.Get("playlistId"))
+		}
+		maxResults, _ := strconv.Atoi(query.Get("maxResults"))
+		pageToken := query.Get("pageToken")
+		f.mu.Lock()
+		f.pages = append(f.pages, pageRequest{maxResults: maxResults, pageToken: pageToken})
+		f.mu.Unlock()
+
+		start := 0
+		if pageToken != "" {
+			start, _ = strconv.Atoi(pageToken)
+		}
+		end := min(start+maxResults, f.uploads)
+		type item struct {
+			Snippet struct {
+				PublishedAt time.Time `json:"publishedAt"`
+				Title       string    `json:"title"`
+			} `json:"snippet"`
+			ContentDetails struct {
+				VideoID string `json:"videoId"`
+			} `json:"contentDetails"`
+		}
+		var page struct {
+			NextPageToken string `json:"nextPageToken,omitempty"`
+			Items         []item `json:"items"`
+		}
+		for i := start; i < end; i++ {
+			var it item
+			it.Snippet.PublishedAt = f.publishedAt(i)
+			it.Snippet.Title = fmt.Sprintf("Video %d", i)
+			it.ContentDetails.VideoID = fmt.Sprintf("vid%03d", i)
+			page.Items = append(page.Items, it)
+		}
+		if end < f.uploads {
+			page.NextPageToken = strconv.Itoa(end)
+		}
+		json.NewEncoder(w).Encode(page)
+	default:
+		f.t.Errorf("unexpected YouTube request %s", r.URL.Path)
+		http.NotFound(w, r)
+	}
+}
+
+func TestGetLatestVideosPagination(t *testing.T) {
+	newest := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		uploads        int
+		maxResults     int
+		publishedAfter time.Time
+		wantVideos     int
+		wantPages      []pageRequest
+	}{
+		{
+			name:       "no cutoff fetches one page",
+			uploads:    120,
+			maxResults: 80,
+			wantVideos: 50,
+			wantPages:  []pageRequest{{50, ""}},
+		},
+		{
+			name:       "no cutoff with a small limit",
+			uploads:    120,
+			maxResults: 10,
+			wantVideos: 10,
+			wantPages:  []pageRequest{{10, ""}},
+		},
+		{
+			name:       "zero limit means one full page",
+			uploads:    120,
+			wantVideos: 50,
+			wantPages:  []pageRequest{{50, ""}},
+		},
+		{
+			name:           "stops at the cutoff on the first page",
+			uploads:        120,
+			maxResults:     200,
+			publishedAfter: newest.Add(-10*time.Hour + time.Minute),
+			wantVideos:     10,
+			wantPages:      []pageRequest{{50, ""}},
+		},
+		{
+			name:           "follows page tokens to the cutoff",
+			uploads:        120,
+			maxResults:     200,
+			publishedAfter: newest.Add(-105*time.Hour + time.Minute),
+			wantVideos:     105,
+			wantPages:      []pageRequest{{50, ""}, {50, "50"}, {50, "100"}},
+		},
+		{
+			name:           "cutoff on a page boundary",
+			uploads:        120,
+			maxResults:     200,
+			publishedAfter: newest.Add(-50*time.Hour + time.Minute),
+			wantVideos:     50,
+			wantPages:      []pageRequest{{50, ""}, {50, "50"}},
+		},
+		{
+			name:           "limit caps the pages",
+			uploads:        120,
+			maxResults:     70,
+			publishedAfter: newest.Add(-1000 * time.Hour),
+			wantVideos:     70,
+			wantPages:      []pageRequest{{50, ""}, {20, "50"}},
+		},
+		{
+			name:           "runs out of pages",
+			uploads:        60,
+			maxResults:     200,
+			publishedAfter: newest.Add(-1000 * time.Hour),
+			wantVideos:     60,
+			wantPages:      []pageRequest{{50, ""}, {50, "50"}},
+		},
+		{
+			name:           "nothing new",
+			uploads:        60,
+			maxResults:     200,
+			publishedAfter: newest.Add(time.Minute),
+			wantVideos:     0,
+			wantPages:      []pageRequest{{50, ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeUploads{t: t, uploads: tt.uploads, newest: newest}
+			server := httptest.NewServer(fake)
+			t.Cleanup(server.Close)
+			cfg := &config.Config{YouTubeAPIKey: "key", HTTPClientTimeout: 5 * time.Second}
+			service := NewService(cfg, httpclient.NewHTTPClient(cfg))
+			service.baseURL = server.URL
+
+			videos, err := service.GetLatestVideos(context.Background(), "UC-test", tt.maxResults, tt.publishedAfter)
+			if err != nil {
+				t.Fatalf("GetLatestVideos() error = %v", err)
+			}
+			if len(videos) != tt.wantVideos {
+				t.Errorf("GetLatestVideos() returned %d videos, want %d", len(videos), tt.wantVideos)
+			}
+			for i, video := range videos {
+				if want := fmt.Sprintf("vid%03d", i); video.YouTubeVideoID != want {
+					t.Fatalf("video %d = %s, want %s newest first", i, video.YouTubeVideoID, want)
+				}
+				if !video.PublishedAt.Equal(fake.publishedAt(i)) {
+					t.Errorf("video %d published %v, want %v", i, video.PublishedAt, fake.publishedAt(i))
+				}
+				if !tt.publishedAfter.IsZero() && video.PublishedAt.Before(tt.publishedAfter) {
+					t.Errorf("video %d published %v, before the cutoff", i, video.PublishedAt)
+				}
+			}
+			if fmt.Sprint(fake.pages) != fmt.Sprint(tt.wantPages) {
+				t.Errorf("requested pages %v, want %v", fake.pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+// fakeVideos serves videos.list from the items of testdata/videos_list.json plus generated
+// videos vid000, vid001, ... Unknown IDs are left out of the response, as the API does for
+// deleted and private videos. Request failOn (counted from 1) is refused for quota.
+type fakeVideos struct {
+	t         *testing.T
+	generated int
+	failOn    int
+
+	mu       sync.Mutex
+	fixture  map[string]json.RawMessage
+	requests [][]string // the IDs of each request in order
+}
+
+func newFakeVideos(t *testing.T, generated int) *fakeVideos {
+	data, err := os.ReadFile(filepath.Join("testdata", "videos_list.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("parse fixture: %v", err)
+	}
+	fake := &fakeVideos{t: t, generated: generated, fixture: make(map[string]json.RawMessage)}
+	for _, item := range response.Items {
+		var id struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(item, &id)
+		fake.fixture[id.ID] = item
+	}
+	return fake
+}
+
+func (f *fakeVideos) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/videos" {
+		http.NotFound(w, r)
+		return
+	}
+	ids := strings.Split(r.URL.Query().Get("id"), ",")
+	f.mu.Lock()
+	f.requests = append(f.requests, ids)
+	request := len(f.requests)
+	f.mu.Unlock()
+
+	if len(ids) > MaxVideoIDsPerCall {
+		f.t.Errorf("request %d asks for %d videos, over the limit of %d", request, len(ids), MaxVideoIDsPerCall)
+	}
+	if request == f.failOn {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"code":403,"message":"The request cannot be completed because you have exceeded your quota.","errors":[{"reason":"quotaExceeded"}]}}`))
+		return
+	}
+
+	var items []json.RawMessage
+	for _, id := range ids {
+		if item, ok := f.fixture[id]; ok {
+			items = append(items, item)
+			continue
+		}
+		var n int
+		if _, err := fmt.Sscanf(id, "vid%03d", &n); err == nil && n < f.generated {
+			items = append(items, json.RawMessage(fmt.Sprintf(
+				`{"id":%q,"snippet":{"publishedAt":"2024-06-01T00:00:00Z","liveBroadcastContent":"none"},"contentDetails":{"duration":"PT%dS","definition":"hd"}}`,
+				id, n+1)))
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]any{"kind": "youtube#videoListResponse", "items": items})
+}
+
+func newVideosTestService(t *testing.T, fake *fakeVideos) *Service {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	cfg := &config.Config{YouTubeAPIKey: "key", HTTPClientTimeout: 5 * time.Second}
+	service := NewService(cfg, httpclient.NewHTTPClient(cfg))
+	service.baseURL = server.URL
+	return service
+}
+
+func TestGetVideoMetadataFixture(t *testing.T) {
+	service := newVideosTestService(t, newFakeVideos(t, 0))
+
+	metadata, err := service.GetVideoMetadata([]string{"dQw4w9WgXcQ", "live0000001", "jp_only0001", "deleted0001"})
+	if err != nil {
+		t.Fatalf("GetVideoMetadata() error = %v", err)
+	}
+	want := map[string]VideoMetadata{
+		"dQw4w9WgXcQ": {
+			PublishedAt: time.Date(2024, 5, 30, 18, 4, 12, 0, time.UTC), Duration: time.Hour + 2*time.Minute + 3*time.Second,
+			Definition: "hd", LiveBroadcastContent: "none", RegionBlocked: []string{"DE", "RU"}, ViewCount: 1523467,
+		},
+		"live0000001": {
+			PublishedAt: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC),
+			Definition:  "sd", LiveBroadcastContent: "live", ViewCount: 87,
+		},
+		"jp_only0001": {
+			PublishedAt: time.Date(2024, 5, 12, 23, 30, 45, 0, time.UTC), Duration: 45 * time.Second,
+			Definition: "hd", LiveBroadcastContent: "none", RegionAllowed: []string{"JP"}, ViewCount: 4021,
+		},
+	}
+	if len(metadata) != len(want) {
+		t.Errorf("GetVideoMetadata() returned %d videos, want %d (the deleted one left out)", len(metadata), len(want))
+	}
+	for id, w := range want {
+		got, ok := metadata[id]
+		if !ok {
+			t.Errorf("video %s missing", id)
+			continue
+		}
+		if !got.PublishedAt.Equal(w.PublishedAt) {
+			t.Errorf("video %s published %v, want %v", id, got.PublishedAt, w.PublishedAt)
+		}
+		got.PublishedAt = w.PublishedAt
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("video %s = %+v, want %+v", id, got, w)
+		}
+	}
+}
+
+func TestGetVideoMetadataBatches(t *testing.T) {
+	ids := func(from, to int) []string {
+		var out []string
+		for i := from; i < to; i++ {
+			out = append(out, fmt.Sprintf("vid%03d", i))
+		}
+		return out
+	}
+	tests := []struct {
+		name        string
+		ids         []string
+		generated   int // videos the fake knows; the rest are missing from responses
+		failOn      int
+		wantBatches []int
+		wantFound   int
+		wantQuota   bool
+	}{
+		{name: "none", wantBatches: nil},
+		{name: "one", ids: ids(0, 1), generated: 1, wantBatches: []int{1}, wantFound: 1},
+		{name: "49", ids: ids(0, 49), generated: 49, wantBatches: []int{49}, wantFound: 49},
+		{name: "exactly 50", ids: ids(0, 50), generated: 50, wantBatches: []int{50}, wantFound: 50},
+		{name: "51", ids: ids(0, 51), generated: 51, wantBatches: []int{50, 1}, wantFound: 51},
+		{name: "exactly 100", ids: ids(0, 100), generated: 100, wantBatches: []int{50, 50}, wantFound: 100},
+		{name: "101", ids: ids(0, 101), generated: 101, wantBatches: []int{50, 50, 1}, wantFound: 101},
+		{
+			// The API leaves out the last 30; every requested video is still asked for once
+			name: "missing from the response", ids: ids(0, 120), generated: 90,
+			wantBatches: []int{50, 50, 20}, wantFound: 90,
+		},
+		{
+			name: "quota runs out on the second call", ids: ids(0, 120), generated: 120, failOn: 2,
+			wantBatches: []int{50, 50}, wantFound: 50, wantQuota: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeVideos(t, tt.generated)
+			fake.failOn = tt.failOn
+			service := newVideosTestService(t, fake)
+
+			metadata, err := service.GetVideoMetadata(tt.ids)
+			if tt.wantQuota {
+				if !errors.Is(err, ErrQuotaExceeded) {
+					t.Errorf("GetVideoMetadata() error = %v, want %v", err, ErrQuotaExceeded)
+				}
+			} else if err != nil {
+				t.Fatalf("GetVideoMetadata() error = %v", err)
+			}
+			if len(metadata) != tt.wantFound {
+				t.Errorf("GetVideoMetadata() returned %d videos, want %d", len(metadata), tt.wantFound)
+			}
+			for i := range tt.wantFound {
+				id := fmt.Sprintf("vid%03d", i)
+				if got := metadata[id].Duration; got != time.Duration(i+1)*time.Second {
+					t.Errorf("video %s duration = %v, want %v", id, got, time.Duration(i+1)*time.Second)
+				}
+			}
+
+			var batches []int
+			var asked []string
+			for _, request := range fake.requests {
+				batches = append(batches, len(request))
+				asked = append(asked, request...)
+			}
+			if fmt.Sprint(batches) != fmt.Sprint(tt.wantBatches) {
+				t.Errorf("batch sizes = %v, want %v", batches, tt.wantBatches)
+			}
+			// IDs go out in order, each once
+			if want := tt.ids[:len(asked)]; fmt.Sprint(asked) != fmt.Sprint(want) {
+				t.Errorf("requested IDs = %v, want %v", asked, want)
+			}
+		})
+	}
+}
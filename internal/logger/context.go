@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"context"
+	"log"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the HTTP request it serves
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" outside a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// InfoContext returns the info logger, tagging lines with the request ID carried by ctx
+func InfoContext(ctx context.Context) *log.Logger {
+	return withRequestID(ctx, Info())
+}
+
+// ErrorContext returns the error logger, tagging lines with the request ID carried by ctx
+func ErrorContext(ctx context.Context) *log.Logger {
+	return withRequestID(ctx, Error())
+}
+
+func withRequestID(ctx context.Context, base *log.Logger) *log.Logger {
+	id := RequestID(ctx)
+	if id == "" {
+		return base
+	}
+	return log.New(base.Writer(), base.Prefix()+"[req "+id+"] ", base.Flags())
+}
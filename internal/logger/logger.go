@@ -6,16 +6,18 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/redact"
 )
 
 // Manager manages application loggers and their underlying files.
 type Manager struct {
 	infoLogger  *log.Logger
 	errorLogger *log.Logger
-	infoFile    *os.File
-	errorFile   *os.File
+	infoFile    *rotatingFile
+	errorFile   *rotatingFile
 }
 
 var global *Manager
@@ -52,19 +54,23 @@ func New(cfg *config.Config) (*Manager, error) {
 	infoPath := filepath.Join(dir, outputFile)
 	errPath := filepath.Join(dir, errorFile)
 
-	infoHandle, err := os.OpenFile(infoPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	maxSize := int64(cfg.LogMaxSizeMB) * 1024 * 1024
+	maxAge := time.Duration(cfg.LogMaxAgeDays) * 24 * time.Hour
+
+	infoHandle, err := openRotatingFile(infoPath, maxSize, cfg.LogMaxBackups, maxAge)
 	if err != nil {
 		return nil, fmt.Errorf("open info log file: %w", err)
 	}
 
-	errorHandle, err := os.OpenFile(errPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	errorHandle, err := openRotatingFile(errPath, maxSize, cfg.LogMaxBackups, maxAge)
 	if err != nil {
 		infoHandle.Close()
 		return nil, fmt.Errorf("open error log file: %w", err)
 	}
 
-	infoWriter := io.MultiWriter(os.Stdout, infoHandle)
-	errorWriter := io.MultiWriter(os.Stderr, errorHandle)
+	// Every line passes through redaction so a stray token in a message never reaches disk
+	infoWriter := &redactingWriter{w: io.MultiWriter(os.Stdout, infoHandle)}
+	errorWriter := &redactingWriter{w: io.MultiWriter(os.Stderr, errorHandle)}
 
 	infoLogger := log.New(infoWriter, "[INFO] ", log.LstdFlags|log.Lmicroseconds)
 	errorLogger := log.New(errorWriter, "[ERROR] ", log.LstdFlags|log.Lmicroseconds)
@@ -77,6 +83,18 @@ func New(cfg *config.Config) (*Manager, error) {
 	}, nil
 }
 
+// redactingWriter masks credentials in each log line before writing it.
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := r.w.Write([]byte(redact.String(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
 // Info returns the info logger.
 func (m *Manager) Info() *log.Logger {
 	return m.infoLogger
@@ -87,7 +105,7 @@ func (m *Manager) Error() *log.Logger {
 	return m.errorLogger
 }
 
-// Close releases file handles.
+// Close flushes and releases file handles.
 func (m *Manager) Close() error {
 	var firstErr error
 	if m.infoFile != nil {
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatingFile is a log file that is renamed to <name>.1 (shifting older backups up) once a write
+// would take it past maxSize. It is safe for concurrent use; the info and error loggers each own one.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64         // 0 disables rotation
+	maxBackups int           // Backups kept; 0 keeps all
+	maxAge     time.Duration // Backups older than this are removed; 0 keeps them
+	file       *os.File
+	size       int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int, maxAge time.Duration) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups, maxAge: maxAge}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.pruneBackups()
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first when p would push the file past maxSize
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			// Keep logging to the current file rather than losing lines
+			fmt.Fprintf(os.Stderr, "log rotation of %s failed: %v\n", r.path, err)
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts <path>.N to <path>.N+1, moves the active file to <path>.1 and reopens it
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	backups := r.backups()
+	for i := len(backups) - 1; i >= 0; i-- {
+		n := backups[i].index
+		if r.maxBackups > 0 && n >= r.maxBackups {
+			os.Remove(backups[i].path)
+			continue
+		}
+		os.Rename(backups[i].path, r.backupPath(n+1))
+	}
+	renameErr := os.Rename(r.path, r.backupPath(1))
+
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.pruneBackups()
+	return renameErr
+}
+
+type backupFile struct {
+	path  string
+	index int
+}
+
+// backups lists the numbered backups of the file in index order
+func (r *rotatingFile) backups() []backupFile {
+	matches, _ := filepath.Glob(r.path + ".*")
+	backups := make([]backupFile, 0, len(matches))
+	for _, match := range matches {
+		n, err := strconv.Atoi(strings.TrimPrefix(match, r.path+"."))
+		if err != nil || n < 1 {
+			continue
+		}
+		backups = append(backups, backupFile{path: match, index: n})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].index < backups[j].index })
+	return backups
+}
+
+// pruneBackups removes backups beyond maxBackups or older than maxAge
+func (r *rotatingFile) pruneBackups() {
+	cutoff := time.Time{}
+	if r.maxAge > 0 {
+		cutoff = time.Now().Add(-r.maxAge)
+	}
+	for _, backup := range r.backups() {
+		if r.maxBackups > 0 && backup.index > r.maxBackups {
+			os.Remove(backup.path)
+			continue
+		}
+		if !cutoff.IsZero() {
+			if info, err := os.Stat(backup.path); err == nil && info.ModTime().Before(cutoff) {
+				os.Remove(backup.path)
+			}
+		}
+	}
+}
+
+func (r *rotatingFile) backupPath(n int) string {
+	return r.path + "." + strconv.Itoa(n)
+}
+
+// Close flushes and closes the active file; later writes fail with os.ErrClosed
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	syncErr := r.file.Sync()
+	err := r.file.Close()
+	r.file = nil
+	if err != nil {
+		return err
+	}
+	return syncErr
+}
@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// logLine is a 22-byte line, so a 100-byte file holds four
+func logLine(n int) string {
+	return fmt.Sprintf("line %04d: test entry\n", n)
+}
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", filepath.Base(path), err)
+	}
+	return string(data)
+}
+
+func TestRotatingFile(t *testing.T) {
+	lines := func(from, to int) string {
+		var b strings.Builder
+		for n := from; n <= to; n++ {
+			b.WriteString(logLine(n))
+		}
+		return b.String()
+	}
+	tests := []struct {
+		name       string
+		maxSize    int64
+		maxBackups int
+		writes     int
+		want       map[string]string // file suffix -> content; "" is the active file
+		wantGone   []string
+	}{
+		{
+			name:     "under the threshold",
+			maxSize:  100,
+			writes:   4,
+			want:     map[string]string{"": lines(1, 4)},
+			wantGone: []string{".1"},
+		},
+		{
+			name:     "backups shift up",
+			maxSize:  100,
+			writes:   10,
+			want:     map[string]string{"": lines(9, 10), ".1": lines(5, 8), ".2": lines(1, 4)},
+			wantGone: []string{".3"},
+		},
+		{
+			name:       "max backups prunes the oldest",
+			maxSize:    100,
+			maxBackups: 2,
+			writes:     18,
+			want:       map[string]string{"": lines(17, 18), ".1": lines(13, 16), ".2": lines(9, 12)},
+			wantGone:   []string{".3", ".4"},
+		},
+		{
+			name:     "rotation disabled",
+			maxSize:  0,
+			writes:   10,
+			want:     map[string]string{"": lines(1, 10)},
+			wantGone: []string{".1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			file, err := openRotatingFile(path, tt.maxSize, tt.maxBackups, 0)
+			if err != nil {
+				t.Fatalf("openRotatingFile() error = %v", err)
+			}
+			for n := 1; n <= tt.writes; n++ {
+				if _, err := file.Write([]byte(logLine(n))); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := file.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			for suffix, want := range tt.want {
+				if got := readLog(t, path+suffix); got != want {
+					t.Errorf("app.log%s =\n%s\nwant\n%s", suffix, got, want)
+				}
+			}
+			for _, suffix := range tt.wantGone {
+				if _, err := os.Stat(path + suffix); !os.IsNotExist(err) {
+					t.Errorf("app.log%s exists, want it removed or never created", suffix)
+				}
+			}
+		})
+	}
+}
+
+func TestRotatingFilePrunesOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	for _, suffix := range []string{".1", ".2", ".3", ".old"} {
+		if err := os.WriteFile(path+suffix, []byte("backup\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// .2 is past max age; .3 is past max backups
+	old := time.Now().Add(-72 * time.Hour)
+	if err := os.Chtimes(path+".2", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := openRotatingFile(path, 100, 2, 48*time.Hour)
+	if err != nil {
+		t.Fatalf("openRotatingFile() error = %v", err)
+	}
+	defer file.Close()
+
+	for suffix, wantExists := range map[string]bool{".1": true, ".2": false, ".3": false, ".old": true} {
+		_, err := os.Stat(path + suffix)
+		if exists := err == nil; exists != wantExists {
+			t.Errorf("app.log%s exists = %v, want %v", suffix, exists, wantExists)
+		}
+	}
+}
+
+func TestRotatingFileClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := openRotatingFile(path, 100, 0, 0)
+	if err != nil {
+		t.Fatalf("openRotatingFile() error = %v", err)
+	}
+	file.Write([]byte(logLine(1)))
+	if err := file.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := readLog(t, path); got != logLine(1) {
+		t.Errorf("app.log = %q after Close, want %q", got, logLine(1))
+	}
+	if _, err := file.Write([]byte(logLine(2))); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write() after Close error = %v, want %v", err, os.ErrClosed)
+	}
+	if err := file.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+}
+
+// TestRotatingFileConcurrentWrites has 8 goroutines log through the redacting writer while
+// the file rotates: no line is lost, split or interleaved, and no file grows past the threshold
+func TestRotatingFileConcurrentWrites(t *testing.T) {
+	const goroutines, perGoroutine = 8, 250
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := openRotatingFile(path, 1000, 0, 0)
+	if err != nil {
+		t.Fatalf("openRotatingFile() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			writer := &redactingWriter{w: file}
+			for n := range perGoroutine {
+				if _, err := writer.Write([]byte(logLine(g*perGoroutine + n))); err != nil {
+					t.Errorf("Write() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := file.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	seen := make(map[string]bool)
+	files := append([]string{path}, backupPaths(file)...)
+	for _, name := range files {
+		content := readLog(t, name)
+		if len(content) > 1000 {
+			t.Errorf("%s is %d bytes, past the 1000-byte threshold", filepath.Base(name), len(content))
+		}
+		for _, line := range strings.SplitAfter(content, "\n") {
+			if line == "" {
+				continue
+			}
+			if len(line) != len(logLine(0)) || !strings.HasPrefix(line, "line ") {
+				t.Fatalf("%s has a broken line %q", filepath.Base(name), line)
+			}
+			if seen[line] {
+				t.Errorf("line %q written twice", line)
+			}
+			seen[line] = true
+		}
+	}
+	if len(seen) != goroutines*perGoroutine {
+		t.Errorf("%d lines on disk, want %d", len(seen), goroutines*perGoroutine)
+	}
+}
+
+func backupPaths(file *rotatingFile) []string {
+	var paths []string
+	for _, backup := range file.backups() {
+		paths = append(paths, backup.path)
+	}
+	return paths
+}
@@ -0,0 +1,144 @@
+// Package redact masks credentials before they reach logs, error messages or API responses.
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces a redacted value
+const Mask = "****"
+
+// sensitiveKeys are query parameter and JSON field names whose values are credentials
+var sensitiveKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"token":         true,
+	"client_key":    true,
+	"client_secret": true,
+	"code":          true,
+	"key":           true,
+	"api_key":       true,
+	"secret":        true,
+	"password":      true,
+}
+
+// sensitiveHeaders are HTTP headers whose values are credentials
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+var (
+	// credentialPattern names the fields that are credentials wherever they appear in text; short
+	// names such as code or key are only credentials as query parameters, see paramPattern
+	credentialPattern = `access_token|refresh_token|client_secret|api_key|password`
+	queryPattern      = regexp.MustCompile(`(?i)\b(` + credentialPattern + `)=([^&\s"'<>]+)`)
+	paramPattern      = regexp.MustCompile(`(?i)([?&](?:id_token|token|client_key|code|key|secret))=([^&\s"'<>#]+)`)
+	jsonPattern       = regexp.MustCompile(`(?i)"(` + credentialPattern + `)"\s*:\s*"[^"]*"`)
+	bearerPattern     = regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9\-._~+/]+=*`)
+	// userinfoPattern matches the password of user:password@ in URLs such as proxy settings
+	userinfoPattern = regexp.MustCompile(`\b([A-Za-z][A-Za-z0-9+.\-]*://[^/\s:@]*):[^/\s@]*@`)
+)
+
+// Token masks all but the last four characters of a credential
+func Token(token string) string {
+	if token == "" {
+		return ""
+	}
+	if len(token) <= 8 {
+		return Mask
+	}
+	return Mask + token[len(token)-4:]
+}
+
+// String masks credentials embedded in free text: credential fields, the parameters of query
+// strings such as an OAuth code, bearer tokens and URL passwords
+func String(s string) string {
+	if s == "" {
+		return s
+	}
+	s = queryPattern.ReplaceAllString(s, "${1}="+Mask)
+	s = paramPattern.ReplaceAllString(s, "${1}="+Mask)
+	s = jsonPattern.ReplaceAllStringFunc(s, func(match string) string {
+		key := match[:strings.Index(match[1:], `"`)+2]
+		return key + `:"` + Mask + `"`
+	})
+	s = bearerPattern.ReplaceAllString(s, "${1} "+Mask)
+	s = userinfoPattern.ReplaceAllString(s, "${1}:"+Mask+"@")
+	return s
+}
+
+// Secrets masks every occurrence of the given credentials in s, for text such as a server reply
+// that quotes a value back without its field name. Values of eight characters or fewer are left
+// alone; they are too short to be real credentials and would mask unrelated text.
+func Secrets(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if len(secret) > 8 {
+			s = strings.ReplaceAll(s, secret, Mask)
+		}
+	}
+	return s
+}
+
+// URL masks sensitive query parameters in a URL
+func URL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return String(raw)
+	}
+	if parsed.RawQuery != "" {
+		parsed.RawQuery = Query(parsed.Query())
+	}
+	if parsed.User != nil {
+		parsed.User = url.User(parsed.User.Username())
+	}
+	return parsed.String()
+}
+
+// Query encodes query values with sensitive parameters masked
+func Query(values url.Values) string {
+	masked := make(url.Values, len(values))
+	for k, v := range values {
+		if sensitiveKeys[strings.ToLower(k)] {
+			masked[k] = []string{Mask}
+			continue
+		}
+		masked[k] = v
+	}
+	return strings.ReplaceAll(masked.Encode(), url.QueryEscape(Mask), Mask)
+}
+
+// Header returns a copy of the headers with credential headers masked
+func Header(h http.Header) http.Header {
+	masked := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if masked.Get(name) != "" {
+			masked.Set(name, Mask)
+		}
+	}
+	return masked
+}
+
+// Error wraps err so its message is redacted; errors.Is and errors.As still see the original
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*redactedError); ok {
+		return err
+	}
+	return &redactedError{err: err}
+}
+
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return String(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
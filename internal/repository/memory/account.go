@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -23,14 +24,18 @@ func NewAccountRepository() *AccountRepository {
 }
 
 // GetAllActive returns all active accounts
-func (r *AccountRepository) GetAllActive() ([]*domain.Account, error) {
+func (r *AccountRepository) GetAllActive(ctx context.Context) ([]*domain.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	var activeAccounts []*domain.Account
 	for _, account := range r.accounts {
 		if account.IsActive {
-			activeAccounts = append(activeAccounts, account)
+			activeAccounts = append(activeAccounts, account.Clone())
 		}
 	}
 
@@ -38,20 +43,28 @@ func (r *AccountRepository) GetAllActive() ([]*domain.Account, error) {
 }
 
 // GetAll returns all accounts regardless of status.
-func (r *AccountRepository) GetAll() ([]*domain.Account, error) {
+func (r *AccountRepository) GetAll(ctx context.Context) ([]*domain.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	var accounts []*domain.Account
 	for _, account := range r.accounts {
-		accounts = append(accounts, account)
+		accounts = append(accounts, account.Clone())
 	}
 
 	return accounts, nil
 }
 
 // GetByID returns an account by its ID
-func (r *AccountRepository) GetByID(id string) (*domain.Account, error) {
+func (r *AccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -60,17 +73,21 @@ func (r *AccountRepository) GetByID(id string) (*domain.Account, error) {
 		return nil, nil
 	}
 
-	return account, nil
+	return account.Clone(), nil
 }
 
 // GetByYouTubeChannelID returns an account by YouTube channel ID
-func (r *AccountRepository) GetByYouTubeChannelID(channelID string) (*domain.Account, error) {
+func (r *AccountRepository) GetByYouTubeChannelID(ctx context.Context, channelID string) (*domain.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, account := range r.accounts {
 		if account.YouTubeChannelID == channelID {
-			return account, nil
+			return account.Clone(), nil
 		}
 	}
 
@@ -78,13 +95,54 @@ func (r *AccountRepository) GetByYouTubeChannelID(channelID string) (*domain.Acc
 }
 
 // GetByTikTokAccountID returns an account by TikTok account ID
-func (r *AccountRepository) GetByTikTokAccountID(tiktokID string) (*domain.Account, error) {
+func (r *AccountRepository) GetByTikTokAccountID(ctx context.Context, tiktokID string) (*domain.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, account := range r.accounts {
 		if account.TikTokAccountID == tiktokID {
-			return account, nil
+			return account.Clone(), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ListByTikTokAccountID returns every account that posts to the TikTok account
+func (r *AccountRepository) ListByTikTokAccountID(ctx context.Context, tiktokID string) ([]*domain.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var accounts []*domain.Account
+	for _, account := range r.accounts {
+		if account.TikTokAccountID == tiktokID {
+			accounts = append(accounts, account.Clone())
+		}
+	}
+
+	return accounts, nil
+}
+
+// GetByPublicSlug returns the account whose public status page uses the slug
+func (r *AccountRepository) GetByPublicSlug(ctx context.Context, slug string) (*domain.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, account := range r.accounts {
+		if slug != "" && account.PublicSlug == slug {
+			return account.Clone(), nil
 		}
 	}
 
@@ -92,13 +150,17 @@ func (r *AccountRepository) GetByTikTokAccountID(tiktokID string) (*domain.Accou
 }
 
 // GetByYouTubeAndTikTok returns an account by both YouTube channel ID and TikTok account ID
-func (r *AccountRepository) GetByYouTubeAndTikTok(youtubeChannelID, tiktokAccountID string) (*domain.Account, error) {
+func (r *AccountRepository) GetByYouTubeAndTikTok(ctx context.Context, youtubeChannelID, tiktokAccountID string) (*domain.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, account := range r.accounts {
 		if account.YouTubeChannelID == youtubeChannelID && account.TikTokAccountID == tiktokAccountID {
-			return account, nil
+			return account.Clone(), nil
 		}
 	}
 
@@ -106,7 +168,11 @@ func (r *AccountRepository) GetByYouTubeAndTikTok(youtubeChannelID, tiktokAccoun
 }
 
 // Delete removes an account
-func (r *AccountRepository) Delete(id string) error {
+func (r *AccountRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -115,7 +181,11 @@ func (r *AccountRepository) Delete(id string) error {
 }
 
 // UpdateLastChecked updates the last checked timestamp and last video ID
-func (r *AccountRepository) UpdateLastChecked(id string, lastVideoID string, checkedAt time.Time) error {
+func (r *AccountRepository) UpdateLastChecked(ctx context.Context, id string, lastVideoID string, checkedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -131,18 +201,191 @@ func (r *AccountRepository) UpdateLastChecked(id string, lastVideoID string, che
 	return nil
 }
 
+// UpdateUploadHealth stores the account's upload path health
+func (r *AccountRepository) UpdateUploadHealth(ctx context.Context, id string, health domain.UploadHealth) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, exists := r.accounts[id]
+	if !exists {
+		return nil
+	}
+
+	account.UploadHealth = health
+	return nil
+}
+
+// UpdatePublicSlug sets or revokes the account's public status page slug
+func (r *AccountRepository) UpdatePublicSlug(ctx context.Context, id string, slug string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, exists := r.accounts[id]
+	if !exists {
+		return nil
+	}
+
+	account.PublicSlug = slug
+	account.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateNeedsReauthorization sets or clears the account's re-authorization flag
+func (r *AccountRepository) UpdateNeedsReauthorization(ctx context.Context, id string, needs bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, exists := r.accounts[id]
+	if !exists {
+		return nil
+	}
+
+	account.NeedsReauthorization = needs
+	account.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateMissingScopes records or clears the scopes the account's token lacks
+func (r *AccountRepository) UpdateMissingScopes(ctx context.Context, id string, scopes []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, exists := r.accounts[id]
+	if !exists {
+		return nil
+	}
+
+	account.MissingScopes = append([]string(nil), scopes...)
+	account.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateTikTokProfile stores the display name and avatar of the account's TikTok login
+func (r *AccountRepository) UpdateTikTokProfile(ctx context.Context, id string, displayName, avatarURL string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, exists := r.accounts[id]
+	if !exists {
+		return nil
+	}
+
+	account.TikTokDisplayName = displayName
+	account.TikTokAvatarURL = avatarURL
+	account.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateReauthNotifiedAt records or clears the last re-authorization notification
+func (r *AccountRepository) UpdateReauthNotifiedAt(ctx context.Context, id string, notifiedAt *time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, exists := r.accounts[id]
+	if !exists {
+		return nil
+	}
+
+	account.ReauthNotifiedAt = notifiedAt
+	return nil
+}
+
+// UpdateFailureStreak stores the account's failure streak
+func (r *AccountRepository) UpdateFailureStreak(ctx context.Context, id string, streak domain.FailureStreak) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, exists := r.accounts[id]
+	if !exists {
+		return nil
+	}
+
+	account.FailureStreak = streak
+	return nil
+}
+
+// UpdateSourceStatus stores the outcome of the account's last channel status check
+func (r *AccountRepository) UpdateSourceStatus(ctx context.Context, id string, status domain.SourceStatus) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, exists := r.accounts[id]
+	if !exists {
+		return nil
+	}
+
+	account.SourceStatus = status
+	return nil
+}
+
 // Save creates or updates an account
-func (r *AccountRepository) Save(account *domain.Account) error {
+func (r *AccountRepository) Save(ctx context.Context, account *domain.Account) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	existing, exists := r.accounts[account.ID]
+	if exists && existing.Version != account.Version {
+		return domain.ErrStaleWrite
+	}
 	if account.ID == "" {
 		account.ID = generateID()
 		account.CreatedAt = time.Now()
 	}
 	account.UpdatedAt = time.Now()
-
-	r.accounts[account.ID] = account
+	account.Version++
+
+	stored := account.Clone()
+	if exists {
+		// These fields are written only through their Update methods
+		stored.LastVideoID = existing.LastVideoID
+		stored.LastCheckedAt = existing.LastCheckedAt
+		stored.UploadHealth = existing.UploadHealth
+		stored.PublicSlug = existing.PublicSlug
+		stored.NeedsReauthorization = existing.NeedsReauthorization
+		stored.MissingScopes = existing.MissingScopes
+		stored.TikTokDisplayName = existing.TikTokDisplayName
+		stored.TikTokAvatarURL = existing.TikTokAvatarURL
+		stored.ReauthNotifiedAt = existing.ReauthNotifiedAt
+		stored.FailureStreak = existing.FailureStreak
+		stored.SourceStatus = existing.SourceStatus
+	}
+	r.accounts[account.ID] = stored
 	return nil
 }
 
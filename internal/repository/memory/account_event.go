@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// AccountEventRepository is an in-memory implementation of AccountEventRepository
+type AccountEventRepository struct {
+	mu     sync.RWMutex
+	events []*domain.AccountEvent
+}
+
+// NewAccountEventRepository creates a new in-memory account event repository
+func NewAccountEventRepository() *AccountEventRepository {
+	return &AccountEventRepository{}
+}
+
+// Save appends an account event
+func (r *AccountEventRepository) Save(ctx context.Context, event *domain.AccountEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID == "" {
+		event.ID = generateID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	copied := *event
+	r.events = append(r.events, &copied)
+	return nil
+}
+
+// ListByAccount returns copies of the events of an account, newest first
+func (r *AccountEventRepository) ListByAccount(ctx context.Context, accountID string) ([]*domain.AccountEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*domain.AccountEvent
+	for _, event := range r.events {
+		if event.AccountID == accountID {
+			copied := *event
+			events = append(events, &copied)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+	return events, nil
+}
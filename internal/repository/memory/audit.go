@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// AuditRepository is an in-memory implementation of AuditRepository
+type AuditRepository struct {
+	mu      sync.RWMutex
+	entries []*domain.AuditEntry
+}
+
+// NewAuditRepository creates a new in-memory audit repository
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+// Save appends an audit entry
+func (r *AuditRepository) Save(ctx context.Context, entry *domain.AuditEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = generateID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	copied := *entry
+	r.entries = append(r.entries, &copied)
+	return nil
+}
+
+// ListByVideo returns copies of the audit entries of a video, newest first
+func (r *AuditRepository) ListByVideo(ctx context.Context, videoID string) ([]*domain.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*domain.AuditEntry
+	for _, entry := range r.entries {
+		if entry.VideoID == videoID {
+			copied := *entry
+			entries = append(entries, &copied)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
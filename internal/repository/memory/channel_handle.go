@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// ChannelHandleRepository is an in-memory implementation of ChannelHandleRepository
+type ChannelHandleRepository struct {
+	mu      sync.RWMutex
+	handles map[string]*domain.ChannelHandle
+}
+
+// NewChannelHandleRepository creates a new in-memory channel handle repository
+func NewChannelHandleRepository() *ChannelHandleRepository {
+	return &ChannelHandleRepository{
+		handles: make(map[string]*domain.ChannelHandle),
+	}
+}
+
+// Get returns a copy of a resolved handle
+func (r *ChannelHandleRepository) Get(ctx context.Context, handle string) (*domain.ChannelHandle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.handles[handle]
+	if !exists {
+		return nil, nil
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// Save stores a resolved handle
+func (r *ChannelHandleRepository) Save(ctx context.Context, entry *domain.ChannelHandle) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.ResolvedAt.IsZero() {
+		entry.ResolvedAt = time.Now()
+	}
+	copied := *entry
+	r.handles[entry.Handle] = &copied
+	return nil
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// ExperimentRepository is an in-memory implementation of ExperimentRepository
+type ExperimentRepository struct {
+	mu          sync.RWMutex
+	experiments map[string]*domain.Experiment
+}
+
+// NewExperimentRepository creates a new in-memory experiment repository
+func NewExperimentRepository() *ExperimentRepository {
+	return &ExperimentRepository{
+		experiments: make(map[string]*domain.Experiment),
+	}
+}
+
+// GetByID returns an experiment by its ID
+func (r *ExperimentRepository) GetByID(ctx context.Context, id string) (*domain.Experiment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.experiments[id], nil
+}
+
+// List returns all experiments, newest first
+func (r *ExperimentRepository) List(ctx context.Context) ([]*domain.Experiment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	experiments := make([]*domain.Experiment, 0, len(r.experiments))
+	for _, experiment := range r.experiments {
+		experiments = append(experiments, experiment)
+	}
+	sort.Slice(experiments, func(i, j int) bool {
+		return experiments[i].CreatedAt.After(experiments[j].CreatedAt)
+	})
+	return experiments, nil
+}
+
+// Save creates or updates an experiment
+func (r *ExperimentRepository) Save(ctx context.Context, experiment *domain.Experiment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if experiment.ID == "" {
+		experiment.ID = uuid.NewString()
+	}
+	if experiment.CreatedAt.IsZero() {
+		experiment.CreatedAt = time.Now()
+	}
+	r.experiments[experiment.ID] = experiment
+	return nil
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// InviteRepository is an in-memory implementation of InviteRepository
+type InviteRepository struct {
+	mu      sync.RWMutex
+	invites map[string]*domain.Invite
+}
+
+// NewInviteRepository creates a new in-memory invite repository
+func NewInviteRepository() *InviteRepository {
+	return &InviteRepository{
+		invites: make(map[string]*domain.Invite),
+	}
+}
+
+// GetByID returns an invite by its ID
+func (r *InviteRepository) GetByID(id string) (*domain.Invite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invite, exists := r.invites[id]
+	if !exists {
+		return nil, nil
+	}
+	return invite, nil
+}
+
+// ListByAccount returns all invites for an account, newest first
+func (r *InviteRepository) ListByAccount(accountID string) ([]*domain.Invite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var invites []*domain.Invite
+	for _, invite := range r.invites {
+		if invite.AccountID == accountID {
+			invites = append(invites, invite)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		return invites[i].CreatedAt.After(invites[j].CreatedAt)
+	})
+	return invites, nil
+}
+
+// Save creates or updates an invite
+func (r *InviteRepository) Save(invite *domain.Invite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if invite.ID == "" {
+		invite.ID = uuid.NewString()
+	}
+	if invite.CreatedAt.IsZero() {
+		invite.CreatedAt = time.Now()
+	}
+	r.invites[invite.ID] = invite
+	return nil
+}
+
+// MarkUsed redeems an invite; it returns false if the invite was already used or revoked
+func (r *InviteRepository) MarkUsed(id string, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invite, exists := r.invites[id]
+	if !exists || invite.UsedAt != nil || invite.RevokedAt != nil {
+		return false, nil
+	}
+	invite.UsedAt = &usedAt
+	return true, nil
+}
+
+// ReleaseUse reopens an invite redeemed at usedAt
+func (r *InviteRepository) ReleaseUse(id string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if invite, exists := r.invites[id]; exists && invite.UsedAt != nil && invite.UsedAt.Equal(usedAt) {
+		invite.UsedAt = nil
+	}
+	return nil
+}
+
+// Revoke revokes an invite
+func (r *InviteRepository) Revoke(id string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if invite, exists := r.invites[id]; exists && invite.RevokedAt == nil {
+		invite.RevokedAt = &revokedAt
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// OAuthStateRepository is an in-memory implementation of OAuthStateRepository
+type OAuthStateRepository struct {
+	mu     sync.RWMutex
+	states map[string]*domain.OAuthState
+}
+
+// NewOAuthStateRepository creates a new in-memory OAuth state repository
+func NewOAuthStateRepository() *OAuthStateRepository {
+	return &OAuthStateRepository{
+		states: make(map[string]*domain.OAuthState),
+	}
+}
+
+// Get returns a copy of a state by its token
+func (r *OAuthStateRepository) Get(ctx context.Context, state string) (*domain.OAuthState, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.states[state]
+	if !exists {
+		return nil, nil
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// Save stores a new state
+func (r *OAuthStateRepository) Save(ctx context.Context, state *domain.OAuthState) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if state.CreatedAt.IsZero() {
+		state.CreatedAt = time.Now()
+	}
+	copied := *state
+	r.states[state.State] = &copied
+	return nil
+}
+
+// MarkConsumed uses a pending state; only the first caller succeeds
+func (r *OAuthStateRepository) MarkConsumed(ctx context.Context, state string, consumedAt time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.states[state]
+	if !exists || entry.ConsumedAt != nil {
+		return false, nil
+	}
+	entry.ConsumedAt = &consumedAt
+	return true, nil
+}
+
+// CountPending returns the account's unconsumed, unexpired states
+func (r *OAuthStateRepository) CountPending(ctx context.Context, accountID string, now time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, entry := range r.states {
+		if entry.AccountID == accountID && entry.IsPending(now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DeleteExpiredBefore removes states that expired before the cutoff
+func (r *OAuthStateRepository) DeleteExpiredBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for key, entry := range r.states {
+		if entry.ExpiresAt.Before(cutoff) {
+			delete(r.states, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// Stats counts stored states by lifecycle stage
+func (r *OAuthStateRepository) Stats(ctx context.Context, now time.Time) (*domain.OAuthStateStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stats domain.OAuthStateStats
+	for _, entry := range r.states {
+		switch {
+		case entry.ConsumedAt != nil:
+			stats.Consumed++
+		case now.Before(entry.ExpiresAt):
+			stats.Outstanding++
+		default:
+			stats.Expired++
+		}
+	}
+	return &stats, nil
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// PipelineStateRepository is an in-memory implementation of PipelineStateRepository
+type PipelineStateRepository struct {
+	mu    sync.RWMutex
+	state *domain.PipelineState
+}
+
+// NewPipelineStateRepository creates a new in-memory pipeline state repository
+func NewPipelineStateRepository() *PipelineStateRepository {
+	return &PipelineStateRepository{}
+}
+
+// Get returns a copy of the stored pipeline state
+func (r *PipelineStateRepository) Get(ctx context.Context) (*domain.PipelineState, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.state == nil {
+		return nil, nil
+	}
+	copied := *r.state
+	return &copied, nil
+}
+
+// Save replaces the stored pipeline state
+func (r *PipelineStateRepository) Save(ctx context.Context, state *domain.PipelineState) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *state
+	r.state = &copied
+	return nil
+}
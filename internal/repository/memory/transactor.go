@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// Transactor is an in-memory implementation of Transactor. Transactions run one at a time, but
+// writes made before fn fails are not rolled back.
+type Transactor struct {
+	mu       sync.Mutex
+	accounts *AccountRepository
+	videos   *VideoRepository
+	audit    *AuditRepository
+}
+
+// NewTransactor creates a transactor over the given repositories
+func NewTransactor(accounts *AccountRepository, videos *VideoRepository, audit *AuditRepository) *Transactor {
+	return &Transactor{accounts: accounts, videos: videos, audit: audit}
+}
+
+// WithTx calls fn with the repositories while holding the transaction lock
+func (t *Transactor) WithTx(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return fn(ctx, domain.Repositories{Accounts: t.accounts, Videos: t.videos, Audit: t.audit})
+}
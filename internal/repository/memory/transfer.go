@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// TransferUsageRepository is an in-memory implementation of TransferUsageRepository
+type TransferUsageRepository struct {
+	mu   sync.RWMutex
+	days map[string]*domain.TransferUsage
+}
+
+// NewTransferUsageRepository creates a new in-memory transfer usage repository
+func NewTransferUsageRepository() *TransferUsageRepository {
+	return &TransferUsageRepository{
+		days: make(map[string]*domain.TransferUsage),
+	}
+}
+
+// Get returns the totals for a day
+func (r *TransferUsageRepository) Get(ctx context.Context, day string) (*domain.TransferUsage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.days[day], nil
+}
+
+// Add increments a day's totals
+func (r *TransferUsageRepository) Add(ctx context.Context, day string, downloadBytes, uploadBytes int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage, ok := r.days[day]
+	if !ok {
+		usage = &domain.TransferUsage{Day: day}
+		r.days[day] = usage
+	}
+	usage.DownloadBytes += downloadBytes
+	usage.UploadBytes += uploadBytes
+	usage.UpdatedAt = time.Now()
+	return nil
+}
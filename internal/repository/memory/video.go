@@ -1,88 +1,579 @@
 package memory
 
 import (
+	"context"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"auto_upload_tiktok/internal/domain"
 )
 
-// VideoRepository is an in-memory implementation of VideoRepository
+// VideoRepository is an in-memory implementation of VideoRepository. Like the SQLite
+// repository it keeps its own copies, so callers never share a video with other goroutines.
 type VideoRepository struct {
 	mu     sync.RWMutex
 	videos map[string]*domain.Video
+	claims map[string]pendingClaim
+}
+
+// pendingClaim is an owner's claim on a pending video, see ClaimPending
+type pendingClaim struct {
+	owner string
+	until time.Time
 }
 
 // NewVideoRepository creates a new in-memory video repository
 func NewVideoRepository() *VideoRepository {
 	return &VideoRepository{
 		videos: make(map[string]*domain.Video),
+		claims: make(map[string]pendingClaim),
+	}
+}
+
+// GetByID returns a video by its ID
+func (r *VideoRepository) GetByID(ctx context.Context, id string) (*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.videos[id].Clone(), nil
+}
+
+// GetClips returns the clips cut from a parent video, in clip order
+func (r *VideoRepository) GetClips(ctx context.Context, parentID string) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var clips []*domain.Video
+	for _, video := range r.videos {
+		if video.ParentVideoID == parentID {
+			clips = append(clips, video.Clone())
+		}
 	}
+
+	sort.Slice(clips, func(i, j int) bool {
+		return clips[i].ClipStart < clips[j].ClipStart
+	})
+
+	return clips, nil
 }
 
-// GetByYouTubeID returns a video by its YouTube ID
-func (r *VideoRepository) GetByYouTubeID(youtubeID string) (*domain.Video, error) {
+// GetByYouTubeIDAndAccount returns an account's copy of a YouTube video
+func (r *VideoRepository) GetByYouTubeIDAndAccount(ctx context.Context, youtubeID, accountID string) (*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, video := range r.videos {
-		if video.YouTubeVideoID == youtubeID {
-			return video, nil
+		if video.YouTubeVideoID == youtubeID && video.AccountID == accountID {
+			return video.Clone(), nil
 		}
 	}
 
 	return nil, nil
 }
 
-// GetPendingVideos returns all videos with pending status
-func (r *VideoRepository) GetPendingVideos(limit int) ([]*domain.Video, error) {
+// GetPendingVideos returns pending videos, immediate ones first, then by priority, then newest
+// published first with unknown publish times last, ties broken by creation time and ID like the
+// SQLite repository
+func (r *VideoRepository) GetPendingVideos(ctx context.Context, limit int) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	var pendingVideos []*domain.Video
 	for _, video := range r.videos {
-		if video.Status == domain.VideoStatusPending {
-			pendingVideos = append(pendingVideos, video)
-			if len(pendingVideos) >= limit {
-				break
-			}
+		if video.Status == domain.VideoStatusPending && video.ClipCount == 0 {
+			pendingVideos = append(pendingVideos, video.Clone())
 		}
 	}
 
+	sortPending(pendingVideos)
+	if limit >= 0 && len(pendingVideos) > limit {
+		pendingVideos = pendingVideos[:limit]
+	}
+
 	return pendingVideos, nil
 }
 
+// sortPending puts pending videos in queue order
+func sortPending(videos []*domain.Video) {
+	sort.Slice(videos, func(i, j int) bool {
+		if videos[i].Immediate != videos[j].Immediate {
+			return videos[i].Immediate
+		}
+		if videos[i].Priority != videos[j].Priority {
+			return videos[i].Priority > videos[j].Priority
+		}
+		if !videos[i].PublishedAt.Equal(videos[j].PublishedAt) {
+			return videos[i].PublishedAt.After(videos[j].PublishedAt)
+		}
+		if !videos[i].CreatedAt.Equal(videos[j].CreatedAt) {
+			return videos[i].CreatedAt.Before(videos[j].CreatedAt)
+		}
+		return videos[i].ID < videos[j].ID
+	})
+}
+
+// ClaimPending claims pending videos for owner under the write lock, so concurrent callers never
+// get the same video
+func (r *VideoRepository) ClaimPending(ctx context.Context, owner string, limit int, lease time.Duration) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var claimable []*domain.Video
+	for _, video := range r.videos {
+		if video.Status != domain.VideoStatusPending || video.ClipCount != 0 {
+			continue
+		}
+		if claim, ok := r.claims[video.ID]; ok && claim.owner != owner && claim.until.After(now) {
+			continue
+		}
+		claimable = append(claimable, video)
+	}
+
+	sortPending(claimable)
+	if limit >= 0 && len(claimable) > limit {
+		claimable = claimable[:limit]
+	}
+
+	videos := make([]*domain.Video, 0, len(claimable))
+	for _, video := range claimable {
+		r.claims[video.ID] = pendingClaim{owner: owner, until: now.Add(lease)}
+		videos = append(videos, video.Clone())
+	}
+	return videos, nil
+}
+
+// ClaimVideo claims one pending video for owner unless another owner's lease is still running
+func (r *VideoRepository) ClaimVideo(ctx context.Context, id string, owner string, lease time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, ok := r.videos[id]
+	if !ok || video.Status != domain.VideoStatusPending {
+		return false, nil
+	}
+	now := time.Now()
+	if claim, ok := r.claims[id]; ok && claim.owner != owner && claim.until.After(now) {
+		return false, nil
+	}
+	r.claims[id] = pendingClaim{owner: owner, until: now.Add(lease)}
+	return true, nil
+}
+
+// ReleaseClaim ends owner's claim on a video
+func (r *VideoRepository) ReleaseClaim(ctx context.Context, id string, owner string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if claim, ok := r.claims[id]; ok && claim.owner == owner {
+		delete(r.claims, id)
+	}
+	return nil
+}
+
+// GetImmediateVideos returns pending videos marked immediate, oldest first
+func (r *VideoRepository) GetImmediateVideos(ctx context.Context, limit int) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []*domain.Video
+	for _, video := range r.videos {
+		if video.Status == domain.VideoStatusPending && video.ClipCount == 0 && video.Immediate {
+			videos = append(videos, video.Clone())
+		}
+	}
+
+	sort.Slice(videos, func(i, j int) bool {
+		if !videos[i].CreatedAt.Equal(videos[j].CreatedAt) {
+			return videos[i].CreatedAt.Before(videos[j].CreatedAt)
+		}
+		return videos[i].ID < videos[j].ID
+	})
+	if limit >= 0 && len(videos) > limit {
+		videos = videos[:limit]
+	}
+
+	return videos, nil
+}
+
+// ClaimImmediate clears the immediate mark of a pending video
+func (r *VideoRepository) ClaimImmediate(ctx context.Context, id string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists || !video.Immediate || video.Status != domain.VideoStatusPending {
+		return false, nil
+	}
+	video.Immediate = false
+	return true, nil
+}
+
+// GetWithoutPublishedAt returns videos whose publish time is unknown, oldest first
+func (r *VideoRepository) GetWithoutPublishedAt(ctx context.Context) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []*domain.Video
+	for _, video := range r.videos {
+		if video.PublishedAt.IsZero() {
+			videos = append(videos, video.Clone())
+		}
+	}
+
+	sort.Slice(videos, func(i, j int) bool {
+		if !videos[i].CreatedAt.Equal(videos[j].CreatedAt) {
+			return videos[i].CreatedAt.Before(videos[j].CreatedAt)
+		}
+		return videos[i].ID < videos[j].ID
+	})
+	return videos, nil
+}
+
+// GetWithFilePath returns videos whose local file path is set
+func (r *VideoRepository) GetWithFilePath(ctx context.Context) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []*domain.Video
+	for _, video := range r.videos {
+		if video.LocalFilePath != "" {
+			videos = append(videos, video.Clone())
+		}
+	}
+
+	sort.Slice(videos, func(i, j int) bool { return videos[i].ID < videos[j].ID })
+	return videos, nil
+}
+
 // CountPending returns number of pending videos
-func (r *VideoRepository) CountPending() (int, error) {
+func (r *VideoRepository) CountPending(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	count := 0
 	for _, video := range r.videos {
-		if video.Status == domain.VideoStatusPending {
+		if video.Status == domain.VideoStatusPending && video.ClipCount == 0 {
 			count++
 		}
 	}
 	return count, nil
 }
 
+// GetByAccountID returns an account's videos, newest published first
+func (r *VideoRepository) GetByAccountID(ctx context.Context, accountID string, filter domain.VideoFilter) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []*domain.Video
+	for _, video := range r.videos {
+		if video.AccountID != accountID {
+			continue
+		}
+		if filter.Status != "" && video.Status != filter.Status {
+			continue
+		}
+		videos = append(videos, video.Clone())
+	}
+
+	sort.Slice(videos, func(i, j int) bool {
+		// Videos with an unknown publish time are placed by their creation time
+		pi, pj := videos[i].PublishedOrCreatedAt(), videos[j].PublishedOrCreatedAt()
+		if !pi.Equal(pj) {
+			return pi.After(pj)
+		}
+		if !videos[i].CreatedAt.Equal(videos[j].CreatedAt) {
+			return videos[i].CreatedAt.After(videos[j].CreatedAt)
+		}
+		return videos[i].ID > videos[j].ID
+	})
+
+	if filter.Offset >= len(videos) {
+		return nil, nil
+	}
+	videos = videos[filter.Offset:]
+	if filter.Limit > 0 && len(videos) > filter.Limit {
+		videos = videos[:filter.Limit]
+	}
+
+	return videos, nil
+}
+
+// GetRecent returns videos of all accounts, most recently updated first
+func (r *VideoRepository) GetRecent(ctx context.Context, filter domain.VideoFilter) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []*domain.Video
+	for _, video := range r.videos {
+		if filter.Status != "" && video.Status != filter.Status {
+			continue
+		}
+		videos = append(videos, video.Clone())
+	}
+
+	sort.Slice(videos, func(i, j int) bool {
+		if !videos[i].UpdatedAt.Equal(videos[j].UpdatedAt) {
+			return videos[i].UpdatedAt.After(videos[j].UpdatedAt)
+		}
+		return videos[i].ID > videos[j].ID
+	})
+
+	if filter.Offset >= len(videos) {
+		return nil, nil
+	}
+	videos = videos[filter.Offset:]
+	if filter.Limit > 0 && len(videos) > filter.Limit {
+		videos = videos[:filter.Limit]
+	}
+
+	return videos, nil
+}
+
+// iteratePageSize is how many videos Iterate copies per read lock
+const iteratePageSize = 500
+
+// Iterate calls fn for every video matching the filter's status with an ID after the cursor, in
+// ID order. fn runs without the lock held, so it may use the repository.
+func (r *VideoRepository) Iterate(ctx context.Context, filter domain.VideoFilter, after string, fn func(video *domain.Video) error) error {
+	for {
+		page, err := r.iteratePage(ctx, filter.Status, after)
+		if err != nil {
+			return err
+		}
+		for _, video := range page {
+			if err := fn(video); err != nil {
+				return err
+			}
+		}
+		if len(page) < iteratePageSize {
+			return nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+// iteratePage returns the next page of Iterate
+func (r *VideoRepository) iteratePage(ctx context.Context, status domain.VideoStatus, after string) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []string
+	for id, video := range r.videos {
+		if id > after && (status == "" || video.Status == status) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > iteratePageSize {
+		ids = ids[:iteratePageSize]
+	}
+
+	page := make([]*domain.Video, 0, len(ids))
+	for _, id := range ids {
+		page = append(page, r.videos[id].Clone())
+	}
+	return page, nil
+}
+
+// CountByStatus returns the number of videos per status for an account
+func (r *VideoRepository) CountByStatus(ctx context.Context, accountID string) (map[domain.VideoStatus]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[domain.VideoStatus]int)
+	for _, video := range r.videos {
+		if video.AccountID == accountID {
+			counts[video.Status]++
+		}
+	}
+	return counts, nil
+}
+
+// GetUploadStats counts uploads completed since the given time and returns the latest completion
+func (r *VideoRepository) GetUploadStats(ctx context.Context, accountID string, since time.Time) (*domain.UploadStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stats domain.UploadStats
+	for _, video := range r.videos {
+		if video.AccountID != accountID || video.Status != domain.VideoStatusCompleted || video.ClipCount > 0 || video.CompletedAt.IsZero() {
+			continue
+		}
+		if !video.CompletedAt.Before(since) {
+			stats.Count++
+		}
+		if video.CompletedAt.After(stats.LastCompletedAt) {
+			stats.LastCompletedAt = video.CompletedAt
+		}
+	}
+	return &stats, nil
+}
+
+// GetCompletedSince returns uploads completed at or after the given time, oldest first
+func (r *VideoRepository) GetCompletedSince(ctx context.Context, since time.Time) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []*domain.Video
+	for _, video := range r.videos {
+		if video.Status != domain.VideoStatusCompleted || video.ClipCount > 0 || video.CompletedAt.Before(since) {
+			continue
+		}
+		videos = append(videos, video.Clone())
+	}
+	sort.Slice(videos, func(i, j int) bool {
+		if !videos[i].CompletedAt.Equal(videos[j].CompletedAt) {
+			return videos[i].CompletedAt.Before(videos[j].CompletedAt)
+		}
+		return videos[i].ID < videos[j].ID
+	})
+	return videos, nil
+}
+
+// GetMissingTikTokPostURL returns completed videos that have a TikTok video ID but no post URL
+func (r *VideoRepository) GetMissingTikTokPostURL(ctx context.Context) ([]*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []*domain.Video
+	for _, video := range r.videos {
+		if video.Status != domain.VideoStatusCompleted || video.TikTokVideoID == "" || video.TikTokPostURL != "" {
+			continue
+		}
+		videos = append(videos, video.Clone())
+	}
+	sort.Slice(videos, func(i, j int) bool {
+		if !videos[i].CompletedAt.Equal(videos[j].CompletedAt) {
+			return videos[i].CompletedAt.Before(videos[j].CompletedAt)
+		}
+		return videos[i].ID < videos[j].ID
+	})
+	return videos, nil
+}
+
 // Save creates or updates a video
-func (r *VideoRepository) Save(video *domain.Video) error {
+func (r *VideoRepository) Save(ctx context.Context, video *domain.Video) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	// The same YouTube video can be saved once per account, so it cannot serve as the ID
 	if video.ID == "" {
-		video.ID = video.YouTubeVideoID
+		video.ID = generateID()
+	}
+	// Discovery sets the ID up front, so stamp any video that arrives without a creation time
+	if video.CreatedAt.IsZero() {
 		video.CreatedAt = time.Now()
 	}
 	video.UpdatedAt = time.Now()
+	// Like the SQLite repository, only a new video takes its immediate mark, priority, post
+	// options and view count from the caller, and cost counters are left to AddCost
+	if existing, exists := r.videos[video.ID]; exists {
+		video.Immediate = existing.Immediate
+		video.Priority = existing.Priority
+		video.SafetyDecision, video.SafetyRule = existing.SafetyDecision, existing.SafetyRule
+		video.TikTokPostURL = existing.TikTokPostURL
+		video.PostOptions = existing.PostOptions.Clone()
+		video.ViewCount, video.ViewsCheckedAt = existing.ViewCount, existing.ViewsCheckedAt
+		video.DownloadWidth, video.DownloadHeight, video.DownloadFPS = existing.DownloadWidth, existing.DownloadHeight, existing.DownloadFPS
+		video.Progress = existing.Progress
+		video.Cost = existing.Cost
+	} else {
+		video.Cost = domain.VideoCost{}
+	}
 
-	r.videos[video.ID] = video
+	r.videos[video.ID] = video.Clone()
 	return nil
 }
 
-// UpdateStatus updates the video status
-func (r *VideoRepository) UpdateStatus(id string, status domain.VideoStatus, errorMsg string) error {
+// UpdateSafetyDecision records the content-safety decision and the rule that triggered it
+func (r *VideoRepository) UpdateSafetyDecision(ctx context.Context, id string, decision domain.SafetyDecision, rule string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -90,16 +581,18 @@ func (r *VideoRepository) UpdateStatus(id string, status domain.VideoStatus, err
 	if !exists {
 		return nil
 	}
-
-	video.Status = status
-	video.ErrorMessage = errorMsg
+	video.SafetyDecision = decision
+	video.SafetyRule = rule
 	video.UpdatedAt = time.Now()
-
 	return nil
 }
 
-// UpdateFilePath updates the local file path
-func (r *VideoRepository) UpdateFilePath(id string, filePath string) error {
+// UpdateViewCount records the YouTube view count read at checkedAt
+func (r *VideoRepository) UpdateViewCount(ctx context.Context, id string, views int64, checkedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -107,15 +600,18 @@ func (r *VideoRepository) UpdateFilePath(id string, filePath string) error {
 	if !exists {
 		return nil
 	}
-
-	video.LocalFilePath = filePath
+	video.ViewCount = views
+	video.ViewsCheckedAt = checkedAt
 	video.UpdatedAt = time.Now()
-
 	return nil
 }
 
-// UpdateTikTokID updates the TikTok video ID
-func (r *VideoRepository) UpdateTikTokID(id string, tiktokID string) error {
+// UpdateDownloadFormat records the resolution and frame rate of the downloaded file
+func (r *VideoRepository) UpdateDownloadFormat(ctx context.Context, id string, width, height int, fps float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -123,10 +619,487 @@ func (r *VideoRepository) UpdateTikTokID(id string, tiktokID string) error {
 	if !exists {
 		return nil
 	}
-
-	video.TikTokVideoID = tiktokID
+	video.DownloadWidth = width
+	video.DownloadHeight = height
+	video.DownloadFPS = fps
 	video.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateProgress records the percentage of the video's current download or upload done
+func (r *VideoRepository) UpdateProgress(ctx context.Context, id string, progress int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+	video.Progress = progress
+	video.UpdatedAt = time.Now()
 	return nil
 }
 
+// UpdateTikTokPostURL records the public link of the video's TikTok post
+func (r *VideoRepository) UpdateTikTokPostURL(ctx context.Context, id string, postURL string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+	video.TikTokPostURL = postURL
+	video.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdatePostOptions replaces the video's TikTok post overrides
+func (r *VideoRepository) UpdatePostOptions(ctx context.Context, id string, options *domain.PostOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+	video.PostOptions = options.Clone()
+	video.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdatePriority sets a video's queue priority
+func (r *VideoRepository) UpdatePriority(ctx context.Context, id string, priority int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+	video.Priority = priority
+	video.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateStatus updates the video status and resets the progress
+func (r *VideoRepository) UpdateStatus(ctx context.Context, id string, status domain.VideoStatus, errorMsg string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.Status = status
+	video.ErrorMessage = errorMsg
+	video.Progress = 0
+	video.UpdatedAt = time.Now()
+	if status == domain.VideoStatusCompleted {
+		video.CompletedAt = video.UpdatedAt
+	}
+
+	return nil
+}
+
+// ForceStatus sets an administrator's outcome in one write
+func (r *VideoRepository) ForceStatus(ctx context.Context, id string, status domain.VideoStatus, message string, tiktokID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.Status = status
+	video.ErrorMessage = message
+	video.Immediate = false
+	if tiktokID != "" {
+		video.TikTokVideoID = tiktokID
+	}
+	video.UpdatedAt = time.Now()
+	if status == domain.VideoStatusCompleted {
+		video.CompletedAt = video.UpdatedAt
+	}
+
+	return nil
+}
+
+// RequeueInterrupted moves videos stuck in a transient status back to pending unless another
+// process's claim on them is still running
+func (r *VideoRepository) RequeueInterrupted(ctx context.Context, host string, errorMsg string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	count := 0
+	for _, video := range r.videos {
+		if video.ClipCount > 0 {
+			continue
+		}
+		if claim, ok := r.claims[video.ID]; ok && claim.until.After(now) &&
+			(host == "" || !strings.HasPrefix(claim.owner, host+":")) {
+			continue
+		}
+		switch video.Status {
+		case domain.VideoStatusDownloading, domain.VideoStatusDownloaded, domain.VideoStatusUploading:
+			delete(r.claims, video.ID)
+			video.Status = domain.VideoStatusPending
+			video.ErrorMessage = errorMsg
+			video.UpdatedAt = time.Now()
+			count++
+		}
+	}
+	return count, nil
+}
+
+// PruneFinished deletes completed and failed videos finished before the given time, keeping
+// clip families whole. The in-memory store does not see experiments.
+func (r *VideoRepository) PruneFinished(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prunable := func(video *domain.Video) bool {
+		if video.Status != domain.VideoStatusCompleted && video.Status != domain.VideoStatusFailed {
+			return false
+		}
+		finished := video.PublishedAt
+		if finished.IsZero() {
+			finished = video.CreatedAt
+		}
+		return video.UpdatedAt.Before(before) && finished.Before(before)
+	}
+
+	// A kept video holds itself and its source
+	held := make(map[string]bool)
+	for id, video := range r.videos {
+		if prunable(video) {
+			continue
+		}
+		held[id] = true
+		if video.ParentVideoID != "" {
+			held[video.ParentVideoID] = true
+		}
+	}
+
+	count := 0
+	for id, video := range r.videos {
+		if held[id] || (video.ParentVideoID != "" && held[video.ParentVideoID]) {
+			continue
+		}
+		delete(r.videos, id)
+		delete(r.claims, id)
+		count++
+	}
+	return count, nil
+}
+
+// UpdateFilePath updates the local file path
+func (r *VideoRepository) UpdateFilePath(ctx context.Context, id string, filePath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.LocalFilePath = filePath
+	video.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// UpdatePublishedAt stores the YouTube publish time
+func (r *VideoRepository) UpdatePublishedAt(ctx context.Context, id string, publishedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.PublishedAt = publishedAt
+	video.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateTikTokID updates the TikTok video ID
+func (r *VideoRepository) UpdateTikTokID(ctx context.Context, id string, tiktokID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.TikTokVideoID = tiktokID
+	video.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// MarkDownloaded stores the file path and download timing and sets the status to downloaded
+func (r *VideoRepository) MarkDownloaded(ctx context.Context, id string, filePath string, downloadedAt time.Time, duration time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.LocalFilePath = filePath
+	video.DownloadedAt = downloadedAt
+	video.DownloadDuration = duration
+	video.Status = domain.VideoStatusDownloaded
+	video.ErrorMessage = ""
+	video.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// UpdateTranslation caches the title's detected language and its translation
+func (r *VideoRepository) UpdateTranslation(ctx context.Context, id string, titleLanguage, translatedLanguage, translatedTitle string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.TitleLanguage = titleLanguage
+	video.TranslatedLanguage = translatedLanguage
+	video.TranslatedTitle = translatedTitle
+	video.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// MarkUploaded stores the TikTok video ID and upload timing
+func (r *VideoRepository) MarkUploaded(ctx context.Context, id string, tiktokID string, uploadedAt time.Time, duration time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.TikTokVideoID = tiktokID
+	video.UploadedAt = uploadedAt
+	video.UploadDuration = duration
+	video.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// UpdateCommentResult records the outcome of the post-publish comment step
+func (r *VideoRepository) UpdateCommentResult(ctx context.Context, id string, posted bool, errorMsg string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.CommentPosted = posted
+	video.CommentError = errorMsg
+	video.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// StartUploadAttempt records a new upload attempt and clears the previous publish ID
+func (r *VideoRepository) StartUploadAttempt(ctx context.Context, id string, attemptID string, contentHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.UploadAttemptID = attemptID
+	video.UploadPublishID = ""
+	video.ContentHash = contentHash
+	video.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// SetUploadPublishID stores the publish ID unless a newer attempt has started
+func (r *VideoRepository) SetUploadPublishID(ctx context.Context, id string, attemptID string, publishID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists || video.UploadAttemptID != attemptID {
+		return nil
+	}
+
+	video.UploadPublishID = publishID
+	video.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// SetUploadRoute records the upload path chosen for the video
+func (r *VideoRepository) SetUploadRoute(ctx context.Context, id string, path domain.UploadPath, reason string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.UploadRoute = path
+	video.UploadRouteReason = reason
+	video.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// AddCost adds to the video's cost counters
+func (r *VideoRepository) AddCost(ctx context.Context, id string, cost domain.VideoCost) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, exists := r.videos[id]
+	if !exists {
+		return nil
+	}
+
+	video.Cost = video.Cost.Add(cost)
+	return nil
+}
+
+// GetAccountUsage sums the costs of an account's videos created in [from, to)
+func (r *VideoRepository) GetAccountUsage(ctx context.Context, accountID string, from, to time.Time) (*domain.AccountUsage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var usage domain.AccountUsage
+	for _, video := range r.videos {
+		if video.AccountID != accountID || video.CreatedAt.Before(from) || !video.CreatedAt.Before(to) {
+			continue
+		}
+		if video.ClipCount == 0 {
+			usage.Videos++
+			if video.Status == domain.VideoStatusCompleted {
+				usage.Completed++
+			}
+		}
+		usage.Cost = usage.Cost.Add(video.Cost)
+	}
+	return &usage, nil
+}
+
+// FindCompletedByContentHash returns the account's latest completed video with the same file hash
+func (r *VideoRepository) FindCompletedByContentHash(ctx context.Context, accountID string, contentHash string, excludeID string) (*domain.Video, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var match *domain.Video
+	for _, video := range r.videos {
+		if video.ID == excludeID || video.AccountID != accountID || video.ContentHash != contentHash ||
+			video.Status != domain.VideoStatusCompleted {
+			continue
+		}
+		if match == nil || video.CompletedAt.After(match.CompletedAt) {
+			match = video
+		}
+	}
+	return match.Clone(), nil
+}
@@ -0,0 +1,441 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+)
+
+// videoBackends open an empty video repository of each kind; the SQLite one has the account acc-1
+var videoBackends = map[string]func(t *testing.T) domain.VideoRepository{
+	"memory": func(t *testing.T) domain.VideoRepository { return NewVideoRepository() },
+	"sqlite": func(t *testing.T) domain.VideoRepository {
+		repos := sqlitetest.OpenTest(t)
+		account := &domain.Account{ID: "acc-1", YouTubeChannelID: "UC-1", TikTokAccountID: "tt-1"}
+		if err := repos.Accounts.Save(context.Background(), account); err != nil {
+			t.Fatalf("save account: %v", err)
+		}
+		return repos.Videos
+	},
+}
+
+// saveTestVideos stores n pending videos of one account, IDs v00, v01, ...
+func saveTestVideos(t *testing.T, repo *VideoRepository, n int) {
+	t.Helper()
+	for i := range n {
+		video := &domain.Video{
+			ID:             fmt.Sprintf("v%02d", i),
+			AccountID:      "acc-1",
+			YouTubeVideoID: fmt.Sprintf("yt-%02d", i),
+			Status:         domain.VideoStatusPending,
+		}
+		if err := repo.Save(context.Background(), video); err != nil {
+			t.Fatalf("save video: %v", err)
+		}
+	}
+}
+
+func TestVideoRepositoryClaimPendingConcurrent(t *testing.T) {
+	const videos = 60
+	repo := NewVideoRepository()
+	saveTestVideos(t, repo, videos)
+
+	var (
+		mu     sync.Mutex
+		claims = make(map[string]int)
+		wg     sync.WaitGroup
+	)
+	for i := range 10 {
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			for {
+				claimed, err := repo.ClaimPending(context.Background(), owner, 4, time.Minute)
+				if err != nil {
+					t.Errorf("ClaimPending(%s) error = %v", owner, err)
+					return
+				}
+				if len(claimed) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, video := range claimed {
+					claims[video.ID]++
+				}
+				mu.Unlock()
+				for _, video := range claimed {
+					repo.UpdateStatus(context.Background(), video.ID, domain.VideoStatusCompleted, "")
+				}
+			}
+		}(fmt.Sprintf("worker-%d", i))
+	}
+	wg.Wait()
+
+	if len(claims) != videos {
+		t.Errorf("%d videos claimed, want %d", len(claims), videos)
+	}
+	for id, count := range claims {
+		if count != 1 {
+			t.Errorf("video %s claimed %d times, want once", id, count)
+		}
+	}
+}
+
+func TestVideoRepositoryClaimLease(t *testing.T) {
+	repo := NewVideoRepository()
+	saveTestVideos(t, repo, 3)
+	ctx := context.Background()
+
+	if got, _ := repo.ClaimPending(ctx, "host-a", 10, 100*time.Millisecond); len(got) != 3 {
+		t.Fatalf("ClaimPending(host-a) = %d videos, want 3", len(got))
+	}
+	if got, _ := repo.ClaimPending(ctx, "host-b", 10, time.Minute); len(got) != 0 {
+		t.Fatalf("ClaimPending(host-b) while leased = %d videos, want none", len(got))
+	}
+
+	repo.ReleaseClaim(ctx, "v00", "host-b")
+	if got, _ := repo.ClaimPending(ctx, "host-b", 10, time.Minute); len(got) != 0 {
+		t.Fatalf("ReleaseClaim() by another owner freed %d videos", len(got))
+	}
+	repo.ReleaseClaim(ctx, "v00", "host-a")
+	if got, _ := repo.ClaimPending(ctx, "host-b", 10, time.Minute); len(got) != 1 || got[0].ID != "v00" {
+		t.Fatalf("ClaimPending(host-b) after release = %d videos, want v00", len(got))
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	got, _ := repo.ClaimPending(ctx, "host-c", 10, time.Minute)
+	if len(got) != 2 {
+		t.Fatalf("ClaimPending(host-c) after the lease = %d videos, want 2", len(got))
+	}
+	for _, video := range got {
+		if video.ID == "v00" {
+			t.Errorf("ClaimPending(host-c) took v00, still claimed by host-b")
+		}
+	}
+}
+
+// TestVideoRepositoryConcurrentAccess is meant for go test -race: readers change the copies they
+// get while writers save and update the same videos
+func TestVideoRepositoryConcurrentAccess(t *testing.T) {
+	repo := NewVideoRepository()
+	saveTestVideos(t, repo, 10)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for worker := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				id := fmt.Sprintf("v%02d", (worker+i)%10)
+				switch i % 4 {
+				case 0:
+					video, err := repo.GetByID(ctx, id)
+					if err != nil || video == nil {
+						t.Errorf("GetByID(%s) = %v, %v", id, video, err)
+						return
+					}
+					video.Title = "changed by a reader"
+					video.RegionAllowed = append(video.RegionAllowed, "VN")
+				case 1:
+					video, _ := repo.GetByID(ctx, id)
+					video.Priority = i
+					if err := repo.Save(ctx, video); err != nil {
+						t.Errorf("Save(%s) error = %v", id, err)
+					}
+				case 2:
+					if err := repo.UpdateStatus(ctx, id, domain.VideoStatusDownloading, ""); err != nil {
+						t.Errorf("UpdateStatus(%s) error = %v", id, err)
+					}
+				case 3:
+					pending, err := repo.GetPendingVideos(ctx, 5)
+					if err != nil {
+						t.Errorf("GetPendingVideos() error = %v", err)
+					}
+					for _, video := range pending {
+						video.Status = domain.VideoStatusFailed
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range 10 {
+		video, _ := repo.GetByID(ctx, fmt.Sprintf("v%02d", i))
+		if video.Title != "" || len(video.RegionAllowed) != 0 {
+			t.Errorf("%s = %q %v, a reader's change reached the repository", video.ID, video.Title, video.RegionAllowed)
+		}
+		if video.Status == domain.VideoStatusFailed {
+			t.Errorf("%s is failed, a reader's change reached the repository", video.ID)
+		}
+	}
+}
+
+func TestVideoRepositoryReturnsCopies(t *testing.T) {
+	repo := NewVideoRepository()
+	ctx := context.Background()
+	disable := true
+	saved := &domain.Video{
+		ID:             "v1",
+		AccountID:      "acc-1",
+		YouTubeVideoID: "yt-1",
+		Title:          "original",
+		Status:         domain.VideoStatusPending,
+		RegionAllowed:  []string{"US"},
+		PostOptions:    &domain.PostOptions{PrivacyLevel: "SELF_ONLY", DisableDuet: &disable},
+	}
+	if err := repo.Save(ctx, saved); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	// Changing the saved value afterwards does not change the stored one
+	saved.Title = "changed after save"
+	saved.RegionAllowed[0] = "VN"
+
+	reads := []struct {
+		name string
+		read func() *domain.Video
+	}{
+		{"GetByID", func() *domain.Video {
+			video, _ := repo.GetByID(ctx, "v1")
+			return video
+		}},
+		{"GetByYouTubeIDAndAccount", func() *domain.Video {
+			video, _ := repo.GetByYouTubeIDAndAccount(ctx, "yt-1", "acc-1")
+			return video
+		}},
+		{"GetPendingVideos", func() *domain.Video {
+			videos, _ := repo.GetPendingVideos(ctx, 1)
+			return videos[0]
+		}},
+		{"GetRecent", func() *domain.Video {
+			videos, _ := repo.GetRecent(ctx, domain.VideoFilter{})
+			return videos[0]
+		}},
+	}
+	for _, tt := range reads {
+		t.Run(tt.name, func(t *testing.T) {
+			video := tt.read()
+			if video.Title != "original" || video.RegionAllowed[0] != "US" {
+				t.Fatalf("%s = %q %v, want the saved values", tt.name, video.Title, video.RegionAllowed)
+			}
+			video.Title = "changed"
+			video.RegionAllowed[0] = "VN"
+			video.PostOptions.PrivacyLevel = "PUBLIC_TO_EVERYONE"
+			*video.PostOptions.DisableDuet = false
+
+			stored, _ := repo.GetByID(ctx, "v1")
+			if stored.Title != "original" || stored.RegionAllowed[0] != "US" ||
+				stored.PostOptions.PrivacyLevel != "SELF_ONLY" || !*stored.PostOptions.DisableDuet {
+				t.Errorf("stored video = %q %v %+v after changing the copy from %s", stored.Title, stored.RegionAllowed, stored.PostOptions, tt.name)
+			}
+		})
+	}
+}
+
+// TestVideoRepositoryPendingTies drains 50 videos with identical timestamps in limited batches:
+// every video comes back exactly once, in ID order
+func TestVideoRepositoryPendingTies(t *testing.T) {
+	const videos, batch = 50, 7
+	at := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)
+	ctx := context.Background()
+
+	for _, claim := range []bool{false, true} {
+		name := "GetPendingVideos"
+		if claim {
+			name = "ClaimPending"
+		}
+		t.Run(name, func(t *testing.T) {
+			repo := NewVideoRepository()
+			for i := videos - 1; i >= 0; i-- {
+				video := &domain.Video{
+					ID: fmt.Sprintf("v%02d", i), AccountID: "acc-1", YouTubeVideoID: fmt.Sprintf("yt-%02d", i),
+					Status: domain.VideoStatusPending, PublishedAt: at, CreatedAt: at,
+				}
+				if err := repo.Save(ctx, video); err != nil {
+					t.Fatalf("save video: %v", err)
+				}
+			}
+
+			seen := make(map[string]bool)
+			var order []string
+			for round := 0; ; round++ {
+				var (
+					got []*domain.Video
+					err error
+				)
+				if claim {
+					got, err = repo.ClaimPending(ctx, fmt.Sprintf("worker-%d", round), batch, time.Hour)
+				} else {
+					got, err = repo.GetPendingVideos(ctx, batch)
+					for _, video := range got {
+						repo.UpdateStatus(ctx, video.ID, domain.VideoStatusDownloading, "")
+					}
+				}
+				if err != nil {
+					t.Fatalf("round %d: %v", round, err)
+				}
+				if len(got) == 0 {
+					break
+				}
+				for _, video := range got {
+					if seen[video.ID] {
+						t.Errorf("round %d: %s returned again", round, video.ID)
+					}
+					seen[video.ID] = true
+					order = append(order, video.ID)
+				}
+			}
+			if len(seen) != videos {
+				t.Errorf("%d videos returned, want %d", len(seen), videos)
+			}
+			for i, id := range order {
+				if want := fmt.Sprintf("v%02d", i); id != want {
+					t.Fatalf("video %d = %s, want %s in ID order", i, id, want)
+				}
+			}
+		})
+	}
+}
+
+// TestVideoRepositoryPendingOrder checks the memory repository queues pending videos exactly as
+// the SQLite repository does: immediate first, then priority, newest published, oldest created
+func TestVideoRepositoryPendingOrder(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	saved := []*domain.Video{
+		{ID: "old", PublishedAt: now.Add(-72 * time.Hour), CreatedAt: now.Add(-72 * time.Hour)},
+		{ID: "unpublished", CreatedAt: now.Add(-96 * time.Hour)},
+		{ID: "fresh", PublishedAt: now.Add(-time.Hour), CreatedAt: now, Priority: 10},
+		{ID: "immediate-old", PublishedAt: now.Add(-48 * time.Hour), CreatedAt: now, Immediate: true},
+		{ID: "bumped", PublishedAt: now.Add(-96 * time.Hour), CreatedAt: now}, // priority 20 by hand below
+		{ID: "newer", PublishedAt: now.Add(-2 * time.Hour), CreatedAt: now},
+		{ID: "fresher", PublishedAt: now.Add(-30 * time.Minute), CreatedAt: now, Priority: 10},
+		{ID: "tie-b", PublishedAt: now.Add(-5 * time.Hour), CreatedAt: now},
+		{ID: "tie-a", PublishedAt: now.Add(-5 * time.Hour), CreatedAt: now},
+		{ID: "tie-created-late", PublishedAt: now.Add(-5 * time.Hour), CreatedAt: now.Add(time.Minute)},
+		{ID: "immediate-new", PublishedAt: now, CreatedAt: now, Immediate: true},
+		{ID: "low", PublishedAt: now, CreatedAt: now, Priority: -1},
+		{ID: "unpublished-late", CreatedAt: now},
+		{ID: "done", PublishedAt: now, CreatedAt: now, Priority: 50, Status: domain.VideoStatusCompleted},
+	}
+	want := []string{
+		"immediate-new", "immediate-old",
+		"bumped",
+		"fresher", "fresh",
+		"newer", "tie-a", "tie-b", "tie-created-late", "old", "unpublished", "unpublished-late",
+		"low",
+	}
+
+	for name, open := range videoBackends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := open(t)
+			for _, v := range saved {
+				video := *v
+				video.AccountID, video.YouTubeVideoID = "acc-1", "yt-"+video.ID
+				if video.Status == "" {
+					video.Status = domain.VideoStatusPending
+				}
+				if err := repo.Save(ctx, &video); err != nil {
+					t.Fatalf("save video %s: %v", video.ID, err)
+				}
+			}
+			if err := repo.UpdatePriority(ctx, "bumped", 20); err != nil {
+				t.Fatalf("UpdatePriority() error = %v", err)
+			}
+
+			ids := func(videos []*domain.Video) []string {
+				out := make([]string, len(videos))
+				for i, video := range videos {
+					out[i] = video.ID
+				}
+				return out
+			}
+			pending, err := repo.GetPendingVideos(ctx, len(saved))
+			if err != nil {
+				t.Fatalf("GetPendingVideos() error = %v", err)
+			}
+			if got := ids(pending); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("GetPendingVideos() order =\n%v\nwant\n%v", got, want)
+			}
+			if got, _ := repo.GetPendingVideos(ctx, 3); fmt.Sprint(ids(got)) != fmt.Sprint(want[:3]) {
+				t.Errorf("GetPendingVideos(3) = %v, want %v", ids(got), want[:3])
+			}
+			claimed, err := repo.ClaimPending(ctx, "host-a", 4, time.Minute)
+			if err != nil {
+				t.Fatalf("ClaimPending() error = %v", err)
+			}
+			if got := ids(claimed); fmt.Sprint(got) != fmt.Sprint(want[:4]) {
+				t.Errorf("ClaimPending() = %v, want %v", got, want[:4])
+			}
+		})
+	}
+}
+
+// TestVideoRepositoryRequeueInterrupted requeues videos left mid-step by an earlier process on
+// this host or by processes whose claim lapsed, but not those other processes still work on
+func TestVideoRepositoryRequeueInterrupted(t *testing.T) {
+	for name, open := range videoBackends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := open(t)
+			for _, id := range []string{"unclaimed", "lapsed", "earlier-here", "live-elsewhere", "live-here-too"} {
+				video := &domain.Video{ID: id, AccountID: "acc-1", YouTubeVideoID: "yt-" + id, Status: domain.VideoStatusPending}
+				if err := repo.Save(ctx, video); err != nil {
+					t.Fatalf("save video: %v", err)
+				}
+			}
+			for id, claim := range map[string]struct {
+				owner string
+				lease time.Duration
+			}{
+				"lapsed":         {"host-b:7", 50 * time.Millisecond},
+				"earlier-here":   {"host-a:41", time.Hour},
+				"live-elsewhere": {"host-b:7", time.Hour},
+				"live-here-too":  {"host-ab:9", time.Hour},
+			} {
+				if held, err := repo.ClaimVideo(ctx, id, claim.owner, claim.lease); err != nil || !held {
+					t.Fatalf("ClaimVideo(%s) = %v, %v", id, held, err)
+				}
+			}
+			for _, id := range []string{"unclaimed", "lapsed", "earlier-here", "live-elsewhere", "live-here-too"} {
+				if err := repo.UpdateStatus(ctx, id, domain.VideoStatusUploading, ""); err != nil {
+					t.Fatalf("UpdateStatus(%s) error = %v", id, err)
+				}
+			}
+			time.Sleep(100 * time.Millisecond)
+
+			// Without a host only claims that lapsed count as abandoned
+			if n, err := repo.RequeueInterrupted(ctx, "", "interrupted"); err != nil || n != 2 {
+				t.Fatalf("RequeueInterrupted() = %d, %v; want 2", n, err)
+			}
+			if n, err := repo.RequeueInterrupted(ctx, "host-a", "interrupted"); err != nil || n != 1 {
+				t.Fatalf("RequeueInterrupted(host-a) = %d, %v; want 1", n, err)
+			}
+			want := map[string]domain.VideoStatus{
+				"unclaimed":      domain.VideoStatusPending,
+				"lapsed":         domain.VideoStatusPending,
+				"earlier-here":   domain.VideoStatusPending,
+				"live-elsewhere": domain.VideoStatusUploading,
+				"live-here-too":  domain.VideoStatusUploading,
+			}
+			for id, status := range want {
+				video, _ := repo.GetByID(ctx, id)
+				if video.Status != status {
+					t.Errorf("%s status = %s, want %s", id, video.Status, status)
+				}
+			}
+
+			// The requeued videos' claims went with them
+			claimed, err := repo.ClaimPending(ctx, "host-c:3", 10, time.Minute)
+			if err != nil || len(claimed) != 3 {
+				t.Errorf("ClaimPending() after requeueing = %d videos, %v; want 3", len(claimed), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// WebSessionRepository is an in-memory implementation of WebSessionRepository
+type WebSessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[string]*domain.WebSession
+	claims   map[string]*domain.WebSessionClaim
+}
+
+// NewWebSessionRepository creates a new in-memory web session repository
+func NewWebSessionRepository() *WebSessionRepository {
+	return &WebSessionRepository{
+		sessions: make(map[string]*domain.WebSession),
+		claims:   make(map[string]*domain.WebSessionClaim),
+	}
+}
+
+// Get returns a copy of the session of a cookies file
+func (r *WebSessionRepository) Get(ctx context.Context, cookiesPath string) (*domain.WebSession, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, exists := r.sessions[cookiesPath]
+	if !exists {
+		return nil, nil
+	}
+	copied := *session
+	return &copied, nil
+}
+
+// Save records the session of a cookies file
+func (r *WebSessionRepository) Save(ctx context.Context, session *domain.WebSession) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *session
+	r.sessions[session.CookiesPath] = &copied
+	return nil
+}
+
+// GetClaim returns a copy of the claim of a cookies file
+func (r *WebSessionRepository) GetClaim(ctx context.Context, cookiesPath string) (*domain.WebSessionClaim, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	claim, exists := r.claims[cookiesPath]
+	if !exists {
+		return nil, nil
+	}
+	copied := *claim
+	return &copied, nil
+}
+
+// Claim records that host uses the cookies file at now, keeping another host's claim from after
+// since unless takeOver is set
+func (r *WebSessionRepository) Claim(ctx context.Context, cookiesPath, host string, now, since time.Time, takeOver bool) (*domain.WebSessionClaim, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	claim, exists := r.claims[cookiesPath]
+	if !exists {
+		r.claims[cookiesPath] = &domain.WebSessionClaim{CookiesPath: cookiesPath, Host: host, UsedAt: now}
+		return nil, nil
+	}
+	previous := *claim
+
+	conflict := previous.Host != host && previous.UsedAt.After(since)
+	switch {
+	case conflict && !takeOver:
+		claim.ConflictHost, claim.ConflictAt = host, now
+	case conflict:
+		claim.Host, claim.UsedAt = host, now
+		claim.ConflictHost, claim.ConflictAt = previous.Host, now
+	default:
+		claim.Host, claim.UsedAt = host, now
+	}
+	return &previous, nil
+}
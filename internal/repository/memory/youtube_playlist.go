@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// YouTubePlaylistRepository is an in-memory implementation of YouTubePlaylistRepository
+type YouTubePlaylistRepository struct {
+	mu        sync.RWMutex
+	playlists map[string]*domain.YouTubePlaylist
+}
+
+// NewYouTubePlaylistRepository creates a new in-memory uploads playlist repository
+func NewYouTubePlaylistRepository() *YouTubePlaylistRepository {
+	return &YouTubePlaylistRepository{
+		playlists: make(map[string]*domain.YouTubePlaylist),
+	}
+}
+
+// Get returns a copy of a channel's uploads playlist
+func (r *YouTubePlaylistRepository) Get(ctx context.Context, channelID string) (*domain.YouTubePlaylist, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.playlists[channelID]
+	if !exists {
+		return nil, nil
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// Save stores a channel's uploads playlist
+func (r *YouTubePlaylistRepository) Save(ctx context.Context, entry *domain.YouTubePlaylist) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.UpdatedAt = time.Now()
+	copied := *entry
+	r.playlists[entry.ChannelID] = &copied
+	return nil
+}
@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// AccountEventRepository is a SQLite implementation of domain.AccountEventRepository.
+type AccountEventRepository struct {
+	db dbtx
+}
+
+// NewAccountEventRepository creates a new AccountEventRepository backed by SQLite.
+func NewAccountEventRepository(db *sql.DB) *AccountEventRepository {
+	return &AccountEventRepository{db: db}
+}
+
+// Save appends an account event.
+func (r *AccountEventRepository) Save(ctx context.Context, event *domain.AccountEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO account_events (id, account_id, event_type, source, summary, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		event.ID, event.AccountID, string(event.Type), string(event.Source), event.Summary, event.CreatedAt.UTC())
+	return err
+}
+
+// ListByAccount returns the events of an account, newest first.
+func (r *AccountEventRepository) ListByAccount(ctx context.Context, accountID string) ([]*domain.AccountEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, account_id, event_type, source, summary, created_at
+		FROM account_events WHERE account_id = ? ORDER BY created_at DESC, id DESC`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.AccountEvent
+	for rows.Next() {
+		var (
+			event     domain.AccountEvent
+			eventType string
+			source    string
+		)
+		if err := rows.Scan(&event.ID, &event.AccountID, &eventType, &source, &event.Summary, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Type = domain.AccountEventType(eventType)
+		event.Source = domain.AccountEventSource(source)
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
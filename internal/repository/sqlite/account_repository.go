@@ -1,8 +1,12 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,9 +14,18 @@ import (
 	"auto_upload_tiktok/internal/domain"
 )
 
+// accountColumns lists the columns read by scanAccount, in scan order.
+const accountColumns = `id, youtube_channel_id, tiktok_account_id, tiktok_access_token,
+	tiktok_refresh_token, tiktok_token_expires_at, last_checked_at, last_video_id, is_active, created_at, updated_at,
+	comment_template, settings, upload_health, public_slug, tiktok_app, tiktok_client_key,
+	needs_reauthorization, youtube_access_token, youtube_refresh_token, youtube_token_expires_at,
+	tiktok_display_name, tiktok_avatar_url, reauth_notified_at, consecutive_failures, last_error,
+	last_error_source, last_failure_at, disabled_reason, youtube_handle, missing_scopes, source_unavailable,
+	source_reason, source_unavailable_since, source_checked_at, version`
+
 // AccountRepository is a SQLite implementation of domain.AccountRepository.
 type AccountRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 // NewAccountRepository creates a new AccountRepository backed by SQLite.
@@ -21,10 +34,8 @@ func NewAccountRepository(db *sql.DB) *AccountRepository {
 }
 
 // GetAll returns all accounts regardless of status.
-func (r *AccountRepository) GetAll() ([]*domain.Account, error) {
-	rows, err := r.db.Query(`SELECT id, youtube_channel_id, tiktok_account_id, tiktok_access_token,
-		tiktok_refresh_token, tiktok_token_expires_at, last_checked_at, last_video_id, is_active, created_at, updated_at
-		FROM accounts ORDER BY created_at ASC`)
+func (r *AccountRepository) GetAll(ctx context.Context) ([]*domain.Account, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+accountColumns+` FROM accounts ORDER BY created_at ASC, id ASC`)
 	if err != nil {
 		return nil, err
 	}
@@ -42,10 +53,8 @@ func (r *AccountRepository) GetAll() ([]*domain.Account, error) {
 }
 
 // GetAllActive returns all active accounts.
-func (r *AccountRepository) GetAllActive() ([]*domain.Account, error) {
-	rows, err := r.db.Query(`SELECT id, youtube_channel_id, tiktok_account_id, tiktok_access_token,
-		tiktok_refresh_token, tiktok_token_expires_at, last_checked_at, last_video_id, is_active, created_at, updated_at
-		FROM accounts WHERE is_active = 1 ORDER BY created_at ASC`)
+func (r *AccountRepository) GetAllActive(ctx context.Context) ([]*domain.Account, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE is_active = 1 ORDER BY created_at ASC, id ASC`)
 	if err != nil {
 		return nil, err
 	}
@@ -63,47 +72,132 @@ func (r *AccountRepository) GetAllActive() ([]*domain.Account, error) {
 }
 
 // GetByID returns an account by ID.
-func (r *AccountRepository) GetByID(id string) (*domain.Account, error) {
-	row := r.db.QueryRow(`SELECT id, youtube_channel_id, tiktok_account_id, tiktok_access_token,
-		tiktok_refresh_token, tiktok_token_expires_at, last_checked_at, last_video_id, is_active, created_at, updated_at
-		FROM accounts WHERE id = ?`, id)
+func (r *AccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE id = ?`, id)
 	return scanAccount(row)
 }
 
 // GetByYouTubeChannelID returns an account by YouTube channel ID.
-func (r *AccountRepository) GetByYouTubeChannelID(channelID string) (*domain.Account, error) {
-	row := r.db.QueryRow(`SELECT id, youtube_channel_id, tiktok_account_id, tiktok_access_token,
-		tiktok_refresh_token, tiktok_token_expires_at, last_checked_at, last_video_id, is_active, created_at, updated_at
-		FROM accounts WHERE youtube_channel_id = ?`, channelID)
+func (r *AccountRepository) GetByYouTubeChannelID(ctx context.Context, channelID string) (*domain.Account, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE youtube_channel_id = ?`, channelID)
 	return scanAccount(row)
 }
 
-// GetByTikTokAccountID returns an account by TikTok account ID.
-func (r *AccountRepository) GetByTikTokAccountID(tiktokID string) (*domain.Account, error) {
-	row := r.db.QueryRow(`SELECT id, youtube_channel_id, tiktok_account_id, tiktok_access_token,
-		tiktok_refresh_token, tiktok_token_expires_at, last_checked_at, last_video_id, is_active, created_at, updated_at
-		FROM accounts WHERE tiktok_account_id = ?`, tiktokID)
+// GetByTikTokAccountID returns an account by TikTok account ID, the oldest when it is shared.
+func (r *AccountRepository) GetByTikTokAccountID(ctx context.Context, tiktokID string) (*domain.Account, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE tiktok_account_id = ?
+		ORDER BY created_at ASC, id ASC LIMIT 1`, tiktokID)
+	return scanAccount(row)
+}
+
+// ListByTikTokAccountID returns every account that posts to the TikTok account.
+func (r *AccountRepository) ListByTikTokAccountID(ctx context.Context, tiktokID string) ([]*domain.Account, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE tiktok_account_id = ?
+		ORDER BY created_at ASC, id ASC`, tiktokID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []*domain.Account
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// GetByPublicSlug returns the account whose public status page uses the slug.
+func (r *AccountRepository) GetByPublicSlug(ctx context.Context, slug string) (*domain.Account, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE public_slug = ?`, slug)
 	return scanAccount(row)
 }
 
 // GetByYouTubeAndTikTok returns an account by both IDs.
-func (r *AccountRepository) GetByYouTubeAndTikTok(youtubeChannelID, tiktokAccountID string) (*domain.Account, error) {
-	row := r.db.QueryRow(`SELECT id, youtube_channel_id, tiktok_account_id, tiktok_access_token,
-		tiktok_refresh_token, tiktok_token_expires_at, last_checked_at, last_video_id, is_active, created_at, updated_at
-		FROM accounts WHERE youtube_channel_id = ? AND tiktok_account_id = ?`,
+func (r *AccountRepository) GetByYouTubeAndTikTok(ctx context.Context, youtubeChannelID, tiktokAccountID string) (*domain.Account, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE youtube_channel_id = ? AND tiktok_account_id = ?`,
 		youtubeChannelID, tiktokAccountID)
 	return scanAccount(row)
 }
 
 // UpdateLastChecked updates metadata about last processed video.
-func (r *AccountRepository) UpdateLastChecked(id string, lastVideoID string, checkedAt time.Time) error {
-	_, err := r.db.Exec(`UPDATE accounts SET last_video_id = ?, last_checked_at = ?, updated_at = ?
+func (r *AccountRepository) UpdateLastChecked(ctx context.Context, id string, lastVideoID string, checkedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE accounts SET last_video_id = ?, last_checked_at = ?, updated_at = ?
 		WHERE id = ?`, lastVideoID, checkedAt.UTC(), time.Now().UTC(), id)
 	return err
 }
 
+// UpdateUploadHealth stores the account's upload path health.
+func (r *AccountRepository) UpdateUploadHealth(ctx context.Context, id string, health domain.UploadHealth) error {
+	encoded, err := json.Marshal(health)
+	if err != nil {
+		return fmt.Errorf("encode upload health: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `UPDATE accounts SET upload_health = ? WHERE id = ?`, string(encoded), id)
+	return err
+}
+
+// UpdatePublicSlug sets or revokes the account's public status page slug.
+func (r *AccountRepository) UpdatePublicSlug(ctx context.Context, id string, slug string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE accounts SET public_slug = ?, updated_at = ? WHERE id = ?`,
+		nullableString(slug), time.Now().UTC(), id)
+	return err
+}
+
+// UpdateNeedsReauthorization sets or clears the account's re-authorization flag.
+func (r *AccountRepository) UpdateNeedsReauthorization(ctx context.Context, id string, needs bool) error {
+	value := 0
+	if needs {
+		value = 1
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE accounts SET needs_reauthorization = ?, updated_at = ? WHERE id = ?`,
+		value, time.Now().UTC(), id)
+	return err
+}
+
+// UpdateMissingScopes records or clears the scopes the account's token lacks.
+func (r *AccountRepository) UpdateMissingScopes(ctx context.Context, id string, scopes []string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE accounts SET missing_scopes = ?, updated_at = ? WHERE id = ?`,
+		nullableString(strings.Join(scopes, ",")), time.Now().UTC(), id)
+	return err
+}
+
+// UpdateTikTokProfile stores the display name and avatar of the account's TikTok login.
+func (r *AccountRepository) UpdateTikTokProfile(ctx context.Context, id string, displayName, avatarURL string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE accounts SET tiktok_display_name = ?, tiktok_avatar_url = ?, updated_at = ? WHERE id = ?`,
+		nullableString(displayName), nullableString(avatarURL), time.Now().UTC(), id)
+	return err
+}
+
+// UpdateReauthNotifiedAt records or clears the last re-authorization notification.
+func (r *AccountRepository) UpdateReauthNotifiedAt(ctx context.Context, id string, notifiedAt *time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE accounts SET reauth_notified_at = ? WHERE id = ?`, nullableTimePtr(notifiedAt), id)
+	return err
+}
+
+// UpdateFailureStreak stores the account's failure streak.
+func (r *AccountRepository) UpdateFailureStreak(ctx context.Context, id string, streak domain.FailureStreak) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE accounts SET consecutive_failures = ?, last_error = ?, last_error_source = ?,
+		last_failure_at = ?, disabled_reason = ? WHERE id = ?`,
+		streak.ConsecutiveFailures, nullableString(streak.LastError), nullableString(streak.LastErrorSource),
+		nullableTimePtr(streak.LastFailureAt), nullableString(streak.DisabledReason), id)
+	return err
+}
+
+// UpdateSourceStatus stores the outcome of the account's last channel status check.
+func (r *AccountRepository) UpdateSourceStatus(ctx context.Context, id string, status domain.SourceStatus) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE accounts SET source_unavailable = ?, source_reason = ?,
+		source_unavailable_since = ?, source_checked_at = ? WHERE id = ?`,
+		nullableString(status.Unavailable), nullableString(status.Reason),
+		nullableTimePtr(status.Since), nullableTimePtr(status.CheckedAt), id)
+	return err
+}
+
 // Save inserts or updates an account.
-func (r *AccountRepository) Save(account *domain.Account) error {
+func (r *AccountRepository) Save(ctx context.Context, account *domain.Account) error {
 	now := time.Now().UTC()
 	if account.ID == "" {
 		account.ID = uuid.NewString()
@@ -111,29 +205,61 @@ func (r *AccountRepository) Save(account *domain.Account) error {
 	}
 	account.UpdatedAt = now
 
-	_, err := r.db.Exec(`INSERT INTO accounts
+	settings, err := json.Marshal(account.Settings)
+	if err != nil {
+		return fmt.Errorf("encode account settings: %w", err)
+	}
+
+	// The update only applies while the stored version is still the one the account was loaded with.
+	// The monitor's progress (last_checked_at, last_video_id) is left to UpdateLastChecked, which
+	// does not bump the version, so an edit loaded before a check cannot roll it back.
+	result, err := r.db.ExecContext(ctx, `INSERT INTO accounts
 		(id, youtube_channel_id, tiktok_account_id, tiktok_access_token, tiktok_refresh_token, tiktok_token_expires_at,
-		last_checked_at, last_video_id, is_active, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		last_checked_at, last_video_id, is_active, created_at, updated_at, comment_template, settings,
+		tiktok_app, tiktok_client_key, youtube_access_token, youtube_refresh_token, youtube_token_expires_at,
+		youtube_handle, version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			youtube_channel_id = excluded.youtube_channel_id,
 			tiktok_account_id = excluded.tiktok_account_id,
 			tiktok_access_token = excluded.tiktok_access_token,
 			tiktok_refresh_token = excluded.tiktok_refresh_token,
 			tiktok_token_expires_at = excluded.tiktok_token_expires_at,
-			last_checked_at = excluded.last_checked_at,
-			last_video_id = excluded.last_video_id,
 			is_active = excluded.is_active,
-			updated_at = excluded.updated_at`, account.ID, account.YouTubeChannelID, account.TikTokAccountID,
+			updated_at = excluded.updated_at,
+			comment_template = excluded.comment_template,
+			settings = excluded.settings,
+			tiktok_app = excluded.tiktok_app,
+			tiktok_client_key = excluded.tiktok_client_key,
+			youtube_access_token = excluded.youtube_access_token,
+			youtube_refresh_token = excluded.youtube_refresh_token,
+			youtube_token_expires_at = excluded.youtube_token_expires_at,
+			youtube_handle = excluded.youtube_handle,
+			version = excluded.version
+		WHERE accounts.version = ?`, account.ID, account.YouTubeChannelID, account.TikTokAccountID,
 		account.TikTokAccessToken, account.TikTokRefreshToken, nullableTimePtr(account.TikTokTokenExpiresAt),
 		nullableTime(account.LastCheckedAt), account.LastVideoID,
-		boolToInt(account.IsActive), account.CreatedAt.UTC(), account.UpdatedAt.UTC())
-	return err
+		boolToInt(account.IsActive), account.CreatedAt.UTC(), account.UpdatedAt.UTC(), account.CommentTemplate, string(settings),
+		nullableString(account.TikTokApp), nullableString(account.TikTokClientKey),
+		nullableString(account.YouTubeAccessToken), nullableString(account.YouTubeRefreshToken), nullableTimePtr(account.YouTubeTokenExpiresAt),
+		nullableString(account.YouTubeHandle), account.Version+1, account.Version)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrStaleWrite
+	}
+	account.Version++
+	return nil
 }
 
 // Delete removes an account.
-func (r *AccountRepository) Delete(id string) error {
-	_, err := r.db.Exec(`DELETE FROM accounts WHERE id = ?`, id)
+func (r *AccountRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM accounts WHERE id = ?`, id)
 	return err
 }
 
@@ -146,6 +272,29 @@ func scanAccount(scanner interface {
 		lastChecked     sql.NullTime
 		lastVideoID     sql.NullString
 		isActive        int
+		commentTemplate sql.NullString
+		settings        sql.NullString
+		uploadHealth    sql.NullString
+		publicSlug      sql.NullString
+		tiktokApp       sql.NullString
+		clientKey       sql.NullString
+		needsReauth     int
+		ytAccessToken   sql.NullString
+		ytRefreshToken  sql.NullString
+		ytExpiresAt     sql.NullTime
+		displayName     sql.NullString
+		avatarURL       sql.NullString
+		reauthNotified  sql.NullTime
+		lastError       sql.NullString
+		lastErrorSource sql.NullString
+		lastFailureAt   sql.NullTime
+		disabledReason  sql.NullString
+		youtubeHandle   sql.NullString
+		missingScopes   sql.NullString
+		sourceState     sql.NullString
+		sourceReason    sql.NullString
+		sourceSince     sql.NullTime
+		sourceCheckedAt sql.NullTime
 		account         domain.Account
 	)
 
@@ -161,6 +310,31 @@ func scanAccount(scanner interface {
 		&isActive,
 		&account.CreatedAt,
 		&account.UpdatedAt,
+		&commentTemplate,
+		&settings,
+		&uploadHealth,
+		&publicSlug,
+		&tiktokApp,
+		&clientKey,
+		&needsReauth,
+		&ytAccessToken,
+		&ytRefreshToken,
+		&ytExpiresAt,
+		&displayName,
+		&avatarURL,
+		&reauthNotified,
+		&account.FailureStreak.ConsecutiveFailures,
+		&lastError,
+		&lastErrorSource,
+		&lastFailureAt,
+		&disabledReason,
+		&youtubeHandle,
+		&missingScopes,
+		&sourceState,
+		&sourceReason,
+		&sourceSince,
+		&sourceCheckedAt,
+		&account.Version,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -180,7 +354,58 @@ func scanAccount(scanner interface {
 	if lastVideoID.Valid {
 		account.LastVideoID = lastVideoID.String
 	}
+	if commentTemplate.Valid {
+		account.CommentTemplate = commentTemplate.String
+	}
+	if settings.Valid && settings.String != "" {
+		if err := json.Unmarshal([]byte(settings.String), &account.Settings); err != nil {
+			return nil, fmt.Errorf("decode settings for account %s: %w", account.ID, err)
+		}
+	}
+	if uploadHealth.Valid && uploadHealth.String != "" {
+		if err := json.Unmarshal([]byte(uploadHealth.String), &account.UploadHealth); err != nil {
+			return nil, fmt.Errorf("decode upload health for account %s: %w", account.ID, err)
+		}
+	}
+	if publicSlug.Valid {
+		account.PublicSlug = publicSlug.String
+	}
+	if tiktokApp.Valid {
+		account.TikTokApp = tiktokApp.String
+	}
+	if clientKey.Valid {
+		account.TikTokClientKey = clientKey.String
+	}
+	account.YouTubeAccessToken = ytAccessToken.String
+	account.YouTubeRefreshToken = ytRefreshToken.String
+	if ytExpiresAt.Valid {
+		account.YouTubeTokenExpiresAt = &ytExpiresAt.Time
+	}
+	account.YouTubeHandle = youtubeHandle.String
+	account.TikTokDisplayName = displayName.String
+	account.TikTokAvatarURL = avatarURL.String
+	if reauthNotified.Valid {
+		account.ReauthNotifiedAt = &reauthNotified.Time
+	}
+	account.FailureStreak.LastError = lastError.String
+	account.FailureStreak.LastErrorSource = lastErrorSource.String
+	if lastFailureAt.Valid {
+		account.FailureStreak.LastFailureAt = &lastFailureAt.Time
+	}
+	account.FailureStreak.DisabledReason = disabledReason.String
+	if missingScopes.String != "" {
+		account.MissingScopes = strings.Split(missingScopes.String, ",")
+	}
+	account.SourceStatus.Unavailable = sourceState.String
+	account.SourceStatus.Reason = sourceReason.String
+	if sourceSince.Valid {
+		account.SourceStatus.Since = &sourceSince.Time
+	}
+	if sourceCheckedAt.Valid {
+		account.SourceStatus.CheckedAt = &sourceCheckedAt.Time
+	}
 	account.IsActive = isActive == 1
+	account.NeedsReauthorization = needsReauth == 1
 	return &account, nil
 }
 
@@ -198,6 +423,13 @@ func nullableTimePtr(t *time.Time) interface{} {
 	return t.UTC()
 }
 
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 func boolToInt(v bool) int {
 	if v {
 		return 1
@@ -0,0 +1,307 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+)
+
+func TestAccountRepositoryLookups(t *testing.T) {
+	repos := sqlitetest.OpenTest(t)
+	ctx := context.Background()
+	saveTestAccount(t, repos.Accounts, "a")
+	saveTestAccount(t, repos.Accounts, "b")
+	shared := &domain.Account{ID: "c", YouTubeChannelID: "UC-c", TikTokAccountID: "tt-a", IsActive: false}
+	if err := repos.Accounts.Save(ctx, shared); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := repos.Accounts.UpdatePublicSlug(ctx, "b", "slug-b"); err != nil {
+		t.Fatalf("UpdatePublicSlug() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		lookup func() (*domain.Account, error)
+		wantID string
+	}{
+		{"by ID", func() (*domain.Account, error) { return repos.Accounts.GetByID(ctx, "a") }, "a"},
+		{"by unknown ID", func() (*domain.Account, error) { return repos.Accounts.GetByID(ctx, "zz") }, ""},
+		{"by YouTube channel", func() (*domain.Account, error) { return repos.Accounts.GetByYouTubeChannelID(ctx, "UC-b") }, "b"},
+		{"by unknown YouTube channel", func() (*domain.Account, error) { return repos.Accounts.GetByYouTubeChannelID(ctx, "UC-zz") }, ""},
+		{"by TikTok account", func() (*domain.Account, error) { return repos.Accounts.GetByTikTokAccountID(ctx, "tt-b") }, "b"},
+		{"by YouTube and TikTok", func() (*domain.Account, error) { return repos.Accounts.GetByYouTubeAndTikTok(ctx, "UC-c", "tt-a") }, "c"},
+		{"by YouTube and other TikTok", func() (*domain.Account, error) { return repos.Accounts.GetByYouTubeAndTikTok(ctx, "UC-c", "tt-b") }, ""},
+		{"by public slug", func() (*domain.Account, error) { return repos.Accounts.GetByPublicSlug(ctx, "slug-b") }, "b"},
+		{"by unknown public slug", func() (*domain.Account, error) { return repos.Accounts.GetByPublicSlug(ctx, "slug-zz") }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, err := tt.lookup()
+			if err != nil {
+				t.Fatalf("lookup error = %v", err)
+			}
+			gotID := ""
+			if account != nil {
+				gotID = account.ID
+			}
+			if gotID != tt.wantID {
+				t.Errorf("lookup returned %q, want %q", gotID, tt.wantID)
+			}
+		})
+	}
+
+	lists := []struct {
+		name    string
+		list    func() ([]*domain.Account, error)
+		wantIDs []string
+	}{
+		{"all", func() ([]*domain.Account, error) { return repos.Accounts.GetAll(ctx) }, []string{"a", "b", "c"}},
+		{"active", func() ([]*domain.Account, error) { return repos.Accounts.GetAllActive(ctx) }, []string{"a", "b"}},
+		{"sharing a TikTok account", func() ([]*domain.Account, error) { return repos.Accounts.ListByTikTokAccountID(ctx, "tt-a") }, []string{"a", "c"}},
+	}
+	for _, tt := range lists {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts, err := tt.list()
+			if err != nil {
+				t.Fatalf("list error = %v", err)
+			}
+			got := make(map[string]bool)
+			for _, account := range accounts {
+				got[account.ID] = true
+			}
+			if len(got) != len(tt.wantIDs) {
+				t.Errorf("list returned %v, want %v", got, tt.wantIDs)
+			}
+			for _, id := range tt.wantIDs {
+				if !got[id] {
+					t.Errorf("list is missing %s", id)
+				}
+			}
+		})
+	}
+}
+
+func TestAccountRepositorySaveRoundTrip(t *testing.T) {
+	repos := sqlitetest.OpenTest(t)
+	ctx := context.Background()
+	expires := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	account := &domain.Account{
+		YouTubeChannelID:     "UC-round",
+		YouTubeHandle:        "@round",
+		TikTokAccountID:      "tt-round",
+		TikTokAccessToken:    "act.round",
+		TikTokRefreshToken:   "rft.round",
+		TikTokTokenExpiresAt: &expires,
+		TikTokApp:            "second",
+		TikTokClientKey:      "ck-second",
+		LastVideoID:          "yt-last",
+		LastCheckedAt:        time.Now().UTC().Truncate(time.Second),
+		IsActive:             true,
+		CommentTemplate:      "Full video: {youtube_url}",
+		Settings: domain.AccountSettings{
+			PrivacyLevel:     "SELF_ONLY",
+			PostAsDraft:      true,
+			MaxUploadsPerDay: 3,
+			TitleBlacklist:   []string{"#shorts"},
+		},
+	}
+	if err := repos.Accounts.Save(ctx, account); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if account.ID == "" || account.Version != 1 {
+		t.Fatalf("Save() left ID %q and version %d, want an ID and version 1", account.ID, account.Version)
+	}
+
+	got, err := repos.Accounts.GetByID(ctx, account.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetByID() = %v, %v", got, err)
+	}
+	checks := []struct {
+		field     string
+		got, want any
+	}{
+		{"YouTubeHandle", got.YouTubeHandle, account.YouTubeHandle},
+		{"TikTokAccessToken", got.TikTokAccessToken, account.TikTokAccessToken},
+		{"TikTokRefreshToken", got.TikTokRefreshToken, account.TikTokRefreshToken},
+		{"TikTokTokenExpiresAt", got.TikTokTokenExpiresAt != nil && got.TikTokTokenExpiresAt.Equal(expires), true},
+		{"TikTokApp", got.TikTokApp, account.TikTokApp},
+		{"TikTokClientKey", got.TikTokClientKey, account.TikTokClientKey},
+		{"LastVideoID", got.LastVideoID, account.LastVideoID},
+		{"LastCheckedAt", got.LastCheckedAt.Equal(account.LastCheckedAt), true},
+		{"IsActive", got.IsActive, true},
+		{"CommentTemplate", got.CommentTemplate, account.CommentTemplate},
+		{"Settings", got.Settings, account.Settings},
+		{"Version", got.Version, int64(1)},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %#v, want %#v", c.field, c.got, c.want)
+		}
+	}
+
+	if err := repos.Accounts.Delete(ctx, account.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got, err := repos.Accounts.GetByID(ctx, account.ID); err != nil || got != nil {
+		t.Fatalf("GetByID() after Delete() = %v, %v; want nil", got, err)
+	}
+}
+
+func TestAccountRepositoryFieldUpdates(t *testing.T) {
+	notified := time.Now().UTC().Truncate(time.Second)
+	tests := []struct {
+		name   string
+		update func(ctx context.Context, repos *sqlitetest.Repos) error
+		check  func(t *testing.T, account *domain.Account)
+	}{
+		{
+			name: "last checked",
+			update: func(ctx context.Context, repos *sqlitetest.Repos) error {
+				return repos.Accounts.UpdateLastChecked(ctx, "a", "yt-9", notified)
+			},
+			check: func(t *testing.T, account *domain.Account) {
+				if account.LastVideoID != "yt-9" || !account.LastCheckedAt.Equal(notified) {
+					t.Errorf("last checked = %s %v", account.LastVideoID, account.LastCheckedAt)
+				}
+			},
+		},
+		{
+			name: "needs reauthorization",
+			update: func(ctx context.Context, repos *sqlitetest.Repos) error {
+				return repos.Accounts.UpdateNeedsReauthorization(ctx, "a", true)
+			},
+			check: func(t *testing.T, account *domain.Account) {
+				if !account.NeedsReauthorization {
+					t.Error("NeedsReauthorization not stored")
+				}
+			},
+		},
+		{
+			name: "missing scopes",
+			update: func(ctx context.Context, repos *sqlitetest.Repos) error {
+				return repos.Accounts.UpdateMissingScopes(ctx, "a", []string{"video.publish"})
+			},
+			check: func(t *testing.T, account *domain.Account) {
+				if !reflect.DeepEqual(account.MissingScopes, []string{"video.publish"}) {
+					t.Errorf("MissingScopes = %v", account.MissingScopes)
+				}
+			},
+		},
+		{
+			name: "TikTok profile",
+			update: func(ctx context.Context, repos *sqlitetest.Repos) error {
+				return repos.Accounts.UpdateTikTokProfile(ctx, "a", "Creator", "https://example.com/a.png")
+			},
+			check: func(t *testing.T, account *domain.Account) {
+				if account.TikTokDisplayName != "Creator" || account.TikTokAvatarURL != "https://example.com/a.png" {
+					t.Errorf("profile = %q %q", account.TikTokDisplayName, account.TikTokAvatarURL)
+				}
+			},
+		},
+		{
+			name: "reauthorization notice",
+			update: func(ctx context.Context, repos *sqlitetest.Repos) error {
+				return repos.Accounts.UpdateReauthNotifiedAt(ctx, "a", &notified)
+			},
+			check: func(t *testing.T, account *domain.Account) {
+				if account.ReauthNotifiedAt == nil || !account.ReauthNotifiedAt.Equal(notified) {
+					t.Errorf("ReauthNotifiedAt = %v", account.ReauthNotifiedAt)
+				}
+			},
+		},
+		{
+			name: "failure streak",
+			update: func(ctx context.Context, repos *sqlitetest.Repos) error {
+				return repos.Accounts.UpdateFailureStreak(ctx, "a", domain.FailureStreak{ConsecutiveFailures: 2, LastError: "boom"})
+			},
+			check: func(t *testing.T, account *domain.Account) {
+				if account.FailureStreak.ConsecutiveFailures != 2 || account.FailureStreak.LastError != "boom" {
+					t.Errorf("FailureStreak = %+v", account.FailureStreak)
+				}
+			},
+		},
+		{
+			name: "upload health",
+			update: func(ctx context.Context, repos *sqlitetest.Repos) error {
+				return repos.Accounts.UpdateUploadHealth(ctx, "a", domain.UploadHealth{ActivePath: domain.UploadPathWeb, Reason: "api down"})
+			},
+			check: func(t *testing.T, account *domain.Account) {
+				if account.UploadHealth.ActivePath != domain.UploadPathWeb || account.UploadHealth.Reason != "api down" {
+					t.Errorf("UploadHealth = %+v", account.UploadHealth)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := sqlitetest.OpenTest(t)
+			ctx := context.Background()
+			saveTestAccount(t, repos.Accounts, "a")
+			if err := tt.update(ctx, repos); err != nil {
+				t.Fatalf("update error = %v", err)
+			}
+			account, err := repos.Accounts.GetByID(ctx, "a")
+			if err != nil || account == nil {
+				t.Fatalf("GetByID() = %v, %v", account, err)
+			}
+			tt.check(t, account)
+
+			// Fields with their own update survive a Save of the account
+			if err := repos.Accounts.Save(ctx, account); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			saved, _ := repos.Accounts.GetByID(ctx, "a")
+			tt.check(t, saved)
+		})
+	}
+}
+
+func TestAccountRepositorySaveStaleVersion(t *testing.T) {
+	repos := sqlitetest.OpenTest(t)
+	ctx := context.Background()
+	saveTestAccount(t, repos.Accounts, "a")
+
+	first, _ := repos.Accounts.GetByID(ctx, "a")
+	second, _ := repos.Accounts.GetByID(ctx, "a")
+	first.CommentTemplate = "first"
+	if err := repos.Accounts.Save(ctx, first); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	second.CommentTemplate = "second"
+	if err := repos.Accounts.Save(ctx, second); !errors.Is(err, domain.ErrStaleWrite) {
+		t.Fatalf("Save() of a stale copy error = %v, want %v", err, domain.ErrStaleWrite)
+	}
+	stored, _ := repos.Accounts.GetByID(ctx, "a")
+	if stored.CommentTemplate != "first" {
+		t.Errorf("CommentTemplate = %q, want the first write", stored.CommentTemplate)
+	}
+}
+
+func TestAccountRepositoryCancelledContext(t *testing.T) {
+	repos := sqlitetest.OpenTest(t)
+	account := saveTestAccount(t, repos.Accounts, "a")
+	queries := map[string]func(ctx context.Context) error{
+		"GetByID": func(ctx context.Context) error {
+			_, err := repos.Accounts.GetByID(ctx, "a")
+			return err
+		},
+		"GetAllActive": func(ctx context.Context) error {
+			_, err := repos.Accounts.GetAllActive(ctx)
+			return err
+		},
+		"Save": func(ctx context.Context) error {
+			return repos.Accounts.Save(ctx, account)
+		},
+		"UpdateLastChecked": func(ctx context.Context) error {
+			return repos.Accounts.UpdateLastChecked(ctx, "a", "yt-1", time.Now())
+		},
+	}
+	for name, query := range queries {
+		checkAborts(t, repos, name, query)
+	}
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// AuditRepository is a SQLite implementation of domain.AuditRepository.
+type AuditRepository struct {
+	db dbtx
+}
+
+// NewAuditRepository creates a new AuditRepository backed by SQLite.
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Save appends an audit entry.
+func (r *AuditRepository) Save(ctx context.Context, entry *domain.AuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO audit_log (id, action, video_id, account_id, actor, previous_status, detail, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.Action, entry.VideoID, nullableString(entry.AccountID), entry.Actor,
+		string(entry.PreviousStatus), nullableString(entry.Detail), entry.CreatedAt.UTC())
+	return err
+}
+
+// ListByVideo returns the audit entries of a video, newest first.
+func (r *AuditRepository) ListByVideo(ctx context.Context, videoID string) ([]*domain.AuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, action, video_id, account_id, actor, previous_status, detail, created_at
+		FROM audit_log WHERE video_id = ? ORDER BY created_at DESC, id DESC`, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		var (
+			entry          domain.AuditEntry
+			accountID      sql.NullString
+			detail         sql.NullString
+			previousStatus string
+		)
+		if err := rows.Scan(&entry.ID, &entry.Action, &entry.VideoID, &accountID, &entry.Actor,
+			&previousStatus, &detail, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.AccountID = accountID.String
+		entry.Detail = detail.String
+		entry.PreviousStatus = domain.VideoStatus(previousStatus)
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// ChannelHandleRepository is a SQLite implementation of domain.ChannelHandleRepository.
+type ChannelHandleRepository struct {
+	db *sql.DB
+}
+
+// NewChannelHandleRepository creates a new ChannelHandleRepository backed by SQLite.
+func NewChannelHandleRepository(db *sql.DB) *ChannelHandleRepository {
+	return &ChannelHandleRepository{db: db}
+}
+
+// Get returns a resolved handle.
+func (r *ChannelHandleRepository) Get(ctx context.Context, handle string) (*domain.ChannelHandle, error) {
+	var entry domain.ChannelHandle
+	err := r.db.QueryRowContext(ctx, `SELECT handle, channel_id, resolved_at FROM channel_handles WHERE handle = ?`, handle).
+		Scan(&entry.Handle, &entry.ChannelID, &entry.ResolvedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Save stores a resolved handle, replacing an earlier resolution.
+func (r *ChannelHandleRepository) Save(ctx context.Context, entry *domain.ChannelHandle) error {
+	if entry.ResolvedAt.IsZero() {
+		entry.ResolvedAt = time.Now().UTC()
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO channel_handles (handle, channel_id, resolved_at) VALUES (?, ?, ?)
+		ON CONFLICT(handle) DO UPDATE SET channel_id = excluded.channel_id, resolved_at = excluded.resolved_at`,
+		entry.Handle, entry.ChannelID, entry.ResolvedAt.UTC())
+	return err
+}
@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"path/filepath"
@@ -10,13 +11,28 @@ import (
 	_ "modernc.org/sqlite"
 )
 
+// defaultDatabasePath is used when the database URL is empty
+const defaultDatabasePath = "./data.db"
+
+// memoryDSN is an in-memory database private to the connection that opened it
+const memoryDSN = "file::memory:"
+
+// busyTimeoutPragma makes writers wait for a lock instead of failing with SQLITE_BUSY
+const busyTimeoutPragma = "_pragma=busy_timeout(5000)"
+
 // Open opens (or creates) a SQLite database using the configured URL.
 // Supported formats:
-//   - sqlite3:./data.db
-//   - sqlite:./data.db
-//   - file:./data.db
+//   - ./data.db, /var/lib/app/data.db, C:\data\app.db
+//   - sqlite3:./data.db, sqlite:./data.db, sqlite:///var/lib/app/data.db
+//   - file:./data.db?_pragma=..., passed through with its query parameters
+//   - :memory: (or sqlite::memory:, sqlite3::memory:, file::memory:) for a database that lives as
+//     long as the *sql.DB; file::memory:?cache=shared and file:name?mode=memory&cache=shared are
+//     shared by every *sql.DB of the process that opens the same name
 func Open(databaseURL string) (*sql.DB, error) {
-	dsn := normalizeDSN(databaseURL)
+	dsn, err := normalizeDSN(databaseURL)
+	if err != nil {
+		return nil, err
+	}
 
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
@@ -25,50 +41,128 @@ func Open(databaseURL string) (*sql.DB, error) {
 
 	// SQLite works best with a single writer connection for WAL
 	db.SetMaxOpenConns(1)
-	db.SetConnMaxIdleTime(5 * time.Minute)
 	db.SetMaxIdleConns(1)
+	if isMemoryDSN(dsn) {
+		// An in-memory database disappears with its connection, so the only one is never closed
+		db.SetConnMaxIdleTime(0)
+		db.SetConnMaxLifetime(0)
+	} else {
+		db.SetConnMaxIdleTime(5 * time.Minute)
+	}
 
 	if err := configurePragmas(db); err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	if err := ensureSchema(db); err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	return db, nil
 }
 
-func normalizeDSN(databaseURL string) string {
+// Ping runs SELECT 1 on the database, for health checks
+func Ping(ctx context.Context, db *sql.DB) error {
+	var one int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("database query failed: %w", err)
+	}
+	return nil
+}
+
+// normalizeDSN turns the configured database URL into a file: URI for the driver and makes sure
+// it sets a busy timeout
+func normalizeDSN(databaseURL string) (string, error) {
 	dsn := strings.TrimSpace(databaseURL)
-	if dsn == "" {
-		dsn = "./data.db"
+
+	// A scheme has at least two letters; a single letter before ':' is a Windows drive
+	if scheme, rest, ok := strings.Cut(dsn, ":"); ok && len(scheme) > 1 && !strings.ContainsAny(scheme, `/\.`) {
+		switch strings.ToLower(scheme) {
+		case "sqlite", "sqlite3":
+			dsn = strings.TrimSpace(rest)
+			// sqlite:///abs/path and sqlite://rel/path
+			dsn = strings.TrimPrefix(dsn, "//")
+			// sqlite3:file::memory:?cache=shared; the file: URI is not a path
+			if strings.HasPrefix(strings.ToLower(dsn), "file:") {
+				return withBusyTimeout(dsn), nil
+			}
+		case "file":
+			return withBusyTimeout(dsn), nil
+		default:
+			return "", fmt.Errorf("unsupported database URL scheme %q: use a path, sqlite:, sqlite3: or file:", scheme)
+		}
 	}
 
-	if idx := strings.Index(dsn, ":"); idx != -1 {
-		prefix := dsn[:idx]
-		if prefix == "sqlite3" || prefix == "sqlite" {
-			dsn = dsn[idx+1:]
+	// :memory:?cache=shared keeps its query parameters
+	if name, query, _ := strings.Cut(dsn, "?"); name == ":memory:" {
+		if query != "" {
+			return withBusyTimeout(memoryDSN + "?" + query), nil
 		}
+		return withBusyTimeout(memoryDSN), nil
+	}
+
+	// Query parameters given after a plain path are kept
+	path, query, _ := strings.Cut(dsn, "?")
+	if path == "" {
+		path = defaultDatabasePath
+	}
+
+	if isWindowsDrivePath(path) {
+		// file:/C:/data/app.db; SQLite drops the slash before the drive letter on Windows
+		path = "/" + strings.ReplaceAll(path, `\`, "/")
+	} else {
+		path = filepath.Clean(path)
 	}
 
-	dsn = strings.TrimSpace(dsn)
-	if dsn == "" {
-		dsn = "./data.db"
+	dsn = "file:" + escapeURIPath(path)
+	if query != "" {
+		dsn += "?" + query
+	}
+	return withBusyTimeout(dsn), nil
+}
+
+// withBusyTimeout adds the busy timeout pragma unless the DSN already sets one
+func withBusyTimeout(dsn string) string {
+	if strings.Contains(dsn, "busy_timeout") {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + busyTimeoutPragma
 	}
+	return dsn + "?" + busyTimeoutPragma
+}
 
-	if !strings.HasPrefix(dsn, "file:") {
-		if !strings.Contains(dsn, ":/") && !strings.HasPrefix(dsn, "./") && !strings.HasPrefix(dsn, "/") {
-			dsn = "./" + dsn
+// isMemoryDSN reports whether a normalized DSN names an in-memory database
+func isMemoryDSN(dsn string) bool {
+	path, query, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	if path == ":memory:" {
+		return true
+	}
+	for _, param := range strings.Split(query, "&") {
+		if param == "mode=memory" {
+			return true
 		}
-		dsn = "file:" + filepath.Clean(dsn)
 	}
+	return false
+}
 
-	if !strings.Contains(dsn, "?") {
-		dsn += "?_pragma=busy_timeout(5000)"
+// isWindowsDrivePath reports whether path starts with a drive letter such as C:\ or C:/
+func isWindowsDrivePath(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
+	}
+	letter := path[0]
+	if !('a' <= letter && letter <= 'z' || 'A' <= letter && letter <= 'Z') {
+		return false
 	}
+	return len(path) == 2 || path[2] == '\\' || path[2] == '/'
+}
 
-	return dsn
+// escapeURIPath escapes the characters that would end the path part of a file: URI
+func escapeURIPath(path string) string {
+	return strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
 }
 
 func configurePragmas(db *sql.DB) error {
@@ -86,40 +180,210 @@ func configurePragmas(db *sql.DB) error {
 	return nil
 }
 
+// accountsTableDefinition is shared by the schema and the rebuild that drops the old UNIQUE
+// constraint on tiktok_account_id
+const accountsTableDefinition = `(
+	id TEXT PRIMARY KEY,
+	youtube_channel_id TEXT NOT NULL UNIQUE,
+	tiktok_account_id TEXT NOT NULL,
+	tiktok_access_token TEXT NOT NULL,
+	tiktok_refresh_token TEXT,
+	tiktok_token_expires_at TIMESTAMP NULL,
+	last_checked_at TIMESTAMP NULL,
+	last_video_id TEXT,
+	is_active INTEGER NOT NULL DEFAULT 1,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	comment_template TEXT,
+	settings TEXT,
+	upload_health TEXT,
+	public_slug TEXT,
+	tiktok_app TEXT,
+	tiktok_client_key TEXT,
+	needs_reauthorization INTEGER NOT NULL DEFAULT 0,
+	youtube_access_token TEXT,
+	youtube_refresh_token TEXT,
+	youtube_token_expires_at TIMESTAMP NULL,
+	tiktok_display_name TEXT,
+	tiktok_avatar_url TEXT,
+	reauth_notified_at TIMESTAMP NULL,
+	consecutive_failures INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT,
+	last_error_source TEXT,
+	last_failure_at TIMESTAMP NULL,
+	disabled_reason TEXT,
+	youtube_handle TEXT,
+	missing_scopes TEXT,
+	source_unavailable TEXT,
+	source_reason TEXT,
+	source_unavailable_since TIMESTAMP NULL,
+	source_checked_at TIMESTAMP NULL,
+	version INTEGER NOT NULL DEFAULT 0
+)`
+
+// videosTableDefinition is shared by the schema and the rebuild that scopes the YouTube video ID
+// to the account, so one video cross-posted by two mapped channels is kept once per account
+const videosTableDefinition = `(
+	id TEXT PRIMARY KEY,
+	youtube_video_id TEXT NOT NULL,
+	account_id TEXT NOT NULL,
+	title TEXT,
+	description TEXT,
+	thumbnail_url TEXT,
+	video_url TEXT,
+	local_file_path TEXT,
+	status TEXT NOT NULL,
+	error_message TEXT,
+	tiktok_video_id TEXT,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	published_at TIMESTAMP,
+	comment_posted INTEGER NOT NULL DEFAULT 0,
+	comment_error TEXT,
+	completed_at TIMESTAMP NULL,
+	downloaded_at TIMESTAMP NULL,
+	uploaded_at TIMESTAMP NULL,
+	download_duration_ms INTEGER NOT NULL DEFAULT 0,
+	upload_duration_ms INTEGER NOT NULL DEFAULT 0,
+	title_language TEXT,
+	translated_title TEXT,
+	translated_language TEXT,
+	upload_attempt_id TEXT,
+	upload_publish_id TEXT,
+	content_hash TEXT,
+	parent_video_id TEXT,
+	clip_start_ms INTEGER NOT NULL DEFAULT 0,
+	clip_end_ms INTEGER NOT NULL DEFAULT 0,
+	clip_count INTEGER NOT NULL DEFAULT 0,
+	immediate INTEGER NOT NULL DEFAULT 0,
+	upload_route TEXT,
+	upload_route_reason TEXT,
+	cost_api_units INTEGER NOT NULL DEFAULT 0,
+	cost_download_bytes INTEGER NOT NULL DEFAULT 0,
+	cost_upload_bytes INTEGER NOT NULL DEFAULT 0,
+	cost_processing_ms INTEGER NOT NULL DEFAULT 0,
+	cost_retries INTEGER NOT NULL DEFAULT 0,
+	priority INTEGER NOT NULL DEFAULT 0,
+	safety_decision TEXT NOT NULL DEFAULT '',
+	safety_rule TEXT NOT NULL DEFAULT '',
+	tiktok_post_url TEXT NOT NULL DEFAULT '',
+	post_options TEXT,
+	view_count INTEGER NOT NULL DEFAULT 0,
+	views_checked_at DATETIME,
+	download_width INTEGER NOT NULL DEFAULT 0,
+	download_height INTEGER NOT NULL DEFAULT 0,
+	download_fps REAL NOT NULL DEFAULT 0,
+	progress INTEGER NOT NULL DEFAULT 0,
+	claimed_by TEXT,
+	claimed_until DATETIME,
+	UNIQUE(youtube_video_id, account_id),
+	FOREIGN KEY(account_id) REFERENCES accounts(id) ON DELETE CASCADE
+)`
+
+// videoIndexes are created with the videos table and again after it is rebuilt
+var videoIndexes = []string{
+	`CREATE INDEX IF NOT EXISTS idx_videos_status_created ON videos(status, created_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_videos_account_status ON videos(account_id, status);`,
+	`CREATE INDEX IF NOT EXISTS idx_videos_updated ON videos(updated_at);`,
+}
+
 func ensureSchema(db *sql.DB) error {
 	statements := []string{
-		`CREATE TABLE IF NOT EXISTS accounts (
+		`CREATE TABLE IF NOT EXISTS accounts ` + accountsTableDefinition + `;`,
+		`CREATE TABLE IF NOT EXISTS videos ` + videosTableDefinition + `;`,
+	}
+	statements = append(statements, videoIndexes...)
+	statements = append(statements,
+		`CREATE TABLE IF NOT EXISTS invites (
 			id TEXT PRIMARY KEY,
-			youtube_channel_id TEXT NOT NULL UNIQUE,
-			tiktok_account_id TEXT NOT NULL UNIQUE,
-			tiktok_access_token TEXT NOT NULL,
-			tiktok_refresh_token TEXT,
-			tiktok_token_expires_at TIMESTAMP NULL,
-			last_checked_at TIMESTAMP NULL,
-			last_video_id TEXT,
-			is_active INTEGER NOT NULL DEFAULT 1,
+			account_id TEXT NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP NULL,
+			revoked_at TIMESTAMP NULL,
 			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
+			FOREIGN KEY(account_id) REFERENCES accounts(id) ON DELETE CASCADE
 		);`,
-		`CREATE TABLE IF NOT EXISTS videos (
+		`CREATE INDEX IF NOT EXISTS idx_invites_account ON invites(account_id, created_at);`,
+		`CREATE TABLE IF NOT EXISTS experiments (
 			id TEXT PRIMARY KEY,
-			youtube_video_id TEXT NOT NULL UNIQUE,
+			name TEXT,
+			source_video_id TEXT NOT NULL,
+			arms TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			FOREIGN KEY(source_video_id) REFERENCES videos(id) ON DELETE CASCADE
+		);`,
+		`CREATE TABLE IF NOT EXISTS transfer_usage (
+			day TEXT PRIMARY KEY,
+			download_bytes INTEGER NOT NULL DEFAULT 0,
+			upload_bytes INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS oauth_states (
+			state TEXT PRIMARY KEY,
 			account_id TEXT NOT NULL,
-			title TEXT,
-			description TEXT,
-			thumbnail_url TEXT,
-			video_url TEXT,
-			local_file_path TEXT,
-			status TEXT NOT NULL,
-			error_message TEXT,
-			tiktok_video_id TEXT,
+			provider TEXT NOT NULL DEFAULT 'tiktok',
+			app_name TEXT,
+			client_key TEXT,
+			expires_at TIMESTAMP NOT NULL,
+			consumed_at TIMESTAMP NULL,
 			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL,
-			published_at TIMESTAMP,
 			FOREIGN KEY(account_id) REFERENCES accounts(id) ON DELETE CASCADE
 		);`,
-		`CREATE INDEX IF NOT EXISTS idx_videos_status_created ON videos(status, created_at);`,
-	}
+		`CREATE INDEX IF NOT EXISTS idx_oauth_states_account ON oauth_states(account_id, expires_at);`,
+		`CREATE TABLE IF NOT EXISTS channel_handles (
+			handle TEXT PRIMARY KEY,
+			channel_id TEXT NOT NULL,
+			resolved_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS youtube_playlists (
+			channel_id TEXT PRIMARY KEY,
+			uploads_playlist_id TEXT NOT NULL,
+			etag TEXT NOT NULL DEFAULT '',
+			checked_after TIMESTAMP NULL,
+			updated_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS web_sessions (
+			cookies_path TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			username TEXT NOT NULL,
+			nickname TEXT NOT NULL,
+			account_id TEXT,
+			captured_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS web_session_claims (
+			cookies_path TEXT PRIMARY KEY,
+			host TEXT NOT NULL,
+			used_at TIMESTAMP NOT NULL,
+			conflict_host TEXT NOT NULL DEFAULT '',
+			conflict_at TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS pipeline_state (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			scope TEXT NOT NULL DEFAULT '',
+			reason TEXT,
+			updated_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id TEXT PRIMARY KEY,
+			action TEXT NOT NULL,
+			video_id TEXT NOT NULL,
+			account_id TEXT,
+			actor TEXT NOT NULL,
+			previous_status TEXT NOT NULL,
+			detail TEXT,
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_video ON audit_log(video_id, created_at);`,
+		`CREATE TABLE IF NOT EXISTS account_events (
+			id TEXT PRIMARY KEY,
+			account_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			source TEXT NOT NULL,
+			summary TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_account_events_account ON account_events(account_id, created_at);`,
+	)
 
 	for _, stmt := range statements {
 		if _, err := db.Exec(stmt); err != nil {
@@ -141,6 +405,262 @@ func ensureSchema(db *sql.DB) error {
 			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='tiktok_token_expires_at'`,
 			addQuery:   `ALTER TABLE accounts ADD COLUMN tiktok_token_expires_at TIMESTAMP NULL`,
 		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='comment_template'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN comment_template TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='settings'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN settings TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='upload_health'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN upload_health TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='public_slug'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN public_slug TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='tiktok_app'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN tiktok_app TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='tiktok_client_key'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN tiktok_client_key TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='needs_reauthorization'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN needs_reauthorization INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='youtube_access_token'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN youtube_access_token TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='youtube_refresh_token'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN youtube_refresh_token TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='youtube_token_expires_at'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN youtube_token_expires_at TIMESTAMP NULL`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='tiktok_display_name'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN tiktok_display_name TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='tiktok_avatar_url'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN tiktok_avatar_url TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='reauth_notified_at'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN reauth_notified_at TIMESTAMP NULL`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='consecutive_failures'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='last_error'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN last_error TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='last_error_source'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN last_error_source TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='last_failure_at'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN last_failure_at TIMESTAMP NULL`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='disabled_reason'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN disabled_reason TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='youtube_handle'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN youtube_handle TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('oauth_states') WHERE name='provider'`,
+			addQuery:   `ALTER TABLE oauth_states ADD COLUMN provider TEXT NOT NULL DEFAULT 'tiktok'`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='comment_posted'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN comment_posted INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='comment_error'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN comment_error TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='completed_at'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN completed_at TIMESTAMP NULL`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='downloaded_at'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN downloaded_at TIMESTAMP NULL`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='uploaded_at'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN uploaded_at TIMESTAMP NULL`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='download_duration_ms'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN download_duration_ms INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='upload_duration_ms'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN upload_duration_ms INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='title_language'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN title_language TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='translated_title'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN translated_title TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='translated_language'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN translated_language TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='upload_attempt_id'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN upload_attempt_id TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='upload_publish_id'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN upload_publish_id TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='content_hash'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN content_hash TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='parent_video_id'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN parent_video_id TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='clip_start_ms'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN clip_start_ms INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='clip_end_ms'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN clip_end_ms INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='clip_count'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN clip_count INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='immediate'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN immediate INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='upload_route'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN upload_route TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='upload_route_reason'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN upload_route_reason TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='cost_api_units'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN cost_api_units INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='cost_download_bytes'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN cost_download_bytes INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='cost_upload_bytes'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN cost_upload_bytes INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='cost_processing_ms'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN cost_processing_ms INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='cost_retries'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN cost_retries INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='priority'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN priority INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='safety_decision'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN safety_decision TEXT NOT NULL DEFAULT ''`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='safety_rule'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN safety_rule TEXT NOT NULL DEFAULT ''`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='tiktok_post_url'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN tiktok_post_url TEXT NOT NULL DEFAULT ''`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='missing_scopes'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN missing_scopes TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='version'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN version INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='post_options'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN post_options TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='view_count'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN view_count INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='views_checked_at'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN views_checked_at DATETIME`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='download_width'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN download_width INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='download_height'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN download_height INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='download_fps'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN download_fps REAL NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='progress'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN progress INTEGER NOT NULL DEFAULT 0`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='claimed_by'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN claimed_by TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('videos') WHERE name='claimed_until'`,
+			addQuery:   `ALTER TABLE videos ADD COLUMN claimed_until DATETIME`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='source_unavailable'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN source_unavailable TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='source_reason'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN source_reason TEXT`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='source_unavailable_since'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN source_unavailable_since TIMESTAMP NULL`,
+		},
+		{
+			checkQuery: `SELECT COUNT(*) FROM pragma_table_info('accounts') WHERE name='source_checked_at'`,
+			addQuery:   `ALTER TABLE accounts ADD COLUMN source_checked_at TIMESTAMP NULL`,
+		},
 	}
 
 	for _, migration := range migrationStatements {
@@ -160,5 +680,100 @@ func ensureSchema(db *sql.DB) error {
 		}
 	}
 
+	if err := dropTikTokAccountUnique(db); err != nil {
+		return fmt.Errorf("ensure schema: %w", err)
+	}
+	if err := scopeYouTubeVideoUnique(db); err != nil {
+		return fmt.Errorf("ensure schema: %w", err)
+	}
+
+	// Indexes on migrated columns can only be created once the columns exist
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_videos_parent ON videos(parent_video_id);`); err != nil {
+		return fmt.Errorf("ensure schema: %w", err)
+	}
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_public_slug ON accounts(public_slug);`); err != nil {
+		return fmt.Errorf("ensure schema: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_accounts_tiktok ON accounts(tiktok_account_id);`); err != nil {
+		return fmt.Errorf("ensure schema: %w", err)
+	}
+
 	return nil
 }
+
+// dropTikTokAccountUnique rebuilds an accounts table created with a UNIQUE tiktok_account_id, so
+// several YouTube channels can map to one TikTok account. SQLite cannot drop a constraint in
+// place; foreign keys are off during the copy so dropping the old table keeps the videos.
+func dropTikTokAccountUnique(db *sql.DB) error {
+	var schema string
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'accounts'`).Scan(&schema)
+	if err != nil {
+		return fmt.Errorf("read accounts schema: %w", err)
+	}
+	if !strings.Contains(schema, "tiktok_account_id TEXT NOT NULL UNIQUE") {
+		return nil
+	}
+
+	if _, err := db.Exec(`PRAGMA foreign_keys=OFF;`); err != nil {
+		return fmt.Errorf("disable foreign keys: %w", err)
+	}
+	defer db.Exec(`PRAGMA foreign_keys=ON;`)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE TABLE accounts_rebuild ` + accountsTableDefinition + `;`,
+		`INSERT INTO accounts_rebuild (` + accountColumns + `) SELECT ` + accountColumns + ` FROM accounts;`,
+		`DROP TABLE accounts;`,
+		`ALTER TABLE accounts_rebuild RENAME TO accounts;`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("rebuild accounts table: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// scopeYouTubeVideoUnique rebuilds a videos table created with a UNIQUE youtube_video_id, so a
+// video cross-posted by two mapped channels is kept once per account. Existing rows are copied
+// as they are; they already satisfy the narrower (youtube_video_id, account_id) key.
+func scopeYouTubeVideoUnique(db *sql.DB) error {
+	var schema string
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'videos'`).Scan(&schema)
+	if err != nil {
+		return fmt.Errorf("read videos schema: %w", err)
+	}
+	if !strings.Contains(schema, "youtube_video_id TEXT NOT NULL UNIQUE") {
+		return nil
+	}
+
+	if _, err := db.Exec(`PRAGMA foreign_keys=OFF;`); err != nil {
+		return fmt.Errorf("disable foreign keys: %w", err)
+	}
+	defer db.Exec(`PRAGMA foreign_keys=ON;`)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE TABLE videos_rebuild ` + videosTableDefinition + `;`,
+		`INSERT INTO videos_rebuild (` + videoColumns + `) SELECT ` + videoColumns + ` FROM videos;`,
+		`DROP TABLE videos;`,
+		`ALTER TABLE videos_rebuild RENAME TO videos;`,
+	}
+	statements = append(statements, videoIndexes...)
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("rebuild videos table: %w", err)
+		}
+	}
+	return tx.Commit()
+}
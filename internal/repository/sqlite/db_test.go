@@ -0,0 +1,216 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+func TestNormalizeDSN(t *testing.T) {
+	const busy = "_pragma=busy_timeout(5000)"
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr string
+	}{
+		{"empty uses the default path", "", "file:data.db?" + busy, ""},
+		{"relative path", "./data/app.db", "file:data/app.db?" + busy, ""},
+		{"absolute path", "/var/lib/app/data.db", "file:/var/lib/app/data.db?" + busy, ""},
+		{"path with query", "./data.db?_pragma=cache_size(2000)", "file:data.db?_pragma=cache_size(2000)&" + busy, ""},
+		{"path with URI characters", "/tmp/a#b%c.db", "file:/tmp/a%23b%25c.db?" + busy, ""},
+		{"Windows drive with backslashes", `C:\data\app.db`, "file:/C:/data/app.db?" + busy, ""},
+		{"Windows drive with slashes", "d:/data/app.db", "file:/d:/data/app.db?" + busy, ""},
+		{"memory", ":memory:", "file::memory:?" + busy, ""},
+		{"memory with query", ":memory:?cache=shared", "file::memory:?cache=shared&" + busy, ""},
+		{"sqlite scheme", "sqlite:./data.db", "file:data.db?" + busy, ""},
+		{"sqlite scheme with absolute path", "sqlite:///var/lib/app/data.db", "file:/var/lib/app/data.db?" + busy, ""},
+		{"sqlite3 scheme memory", "sqlite3::memory:", "file::memory:?" + busy, ""},
+		{"sqlite3 scheme with file URI", "sqlite3:file::memory:?cache=shared", "file::memory:?cache=shared&" + busy, ""},
+		{"file URI passed through", "file:./data.db?mode=ro", "file:./data.db?mode=ro&" + busy, ""},
+		{"file URI with busy timeout", "file:app.db?_pragma=busy_timeout(100)", "file:app.db?_pragma=busy_timeout(100)", ""},
+		{"upper-case scheme", "SQLITE:app.db", "file:app.db?" + busy, ""},
+		{"surrounding space", "  sqlite: app.db \n", "file:app.db?" + busy, ""},
+		{"unknown scheme", "postgres://localhost/app", "", `unsupported database URL scheme "postgres"`},
+		{"unknown scheme without slashes", "mysql:app", "", `unsupported database URL scheme "mysql"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeDSN(tt.url)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("normalizeDSN(%q) error = %v, want %q", tt.url, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeDSN(%q) error = %v", tt.url, err)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeDSN(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsMemoryDSN(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want bool
+	}{
+		{"file::memory:", true},
+		{"file::memory:?cache=shared", true},
+		{"file:shared?mode=memory&cache=shared", true},
+		{"file:data.db?mode=ro", false},
+		{"file:memory.db", false},
+	}
+	for _, tt := range tests {
+		if got := isMemoryDSN(tt.dsn); got != tt.want {
+			t.Errorf("isMemoryDSN(%q) = %v, want %v", tt.dsn, got, tt.want)
+		}
+	}
+}
+
+func TestOpenSharedMemory(t *testing.T) {
+	const url = "sqlite3:file:normalize_dsn_test?mode=memory&cache=shared"
+	first, err := Open(url)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer first.Close()
+	if _, err := first.Exec(`CREATE TABLE marker (id INTEGER)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	second, err := Open(url)
+	if err != nil {
+		t.Fatalf("second Open() error = %v", err)
+	}
+	defer second.Close()
+	var n int
+	if err := second.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'marker'`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("second handle sees %d marker tables (%v), want the shared database", n, err)
+	}
+}
+
+// legacySchema is the accounts and videos tables as the first release created them, with
+// tiktok_account_id and youtube_video_id unique across the table
+var legacySchema = []string{
+	`CREATE TABLE accounts (
+		id TEXT PRIMARY KEY,
+		youtube_channel_id TEXT NOT NULL UNIQUE,
+		tiktok_account_id TEXT NOT NULL UNIQUE,
+		tiktok_access_token TEXT NOT NULL,
+		tiktok_refresh_token TEXT,
+		tiktok_token_expires_at TIMESTAMP NULL,
+		last_checked_at TIMESTAMP NULL,
+		last_video_id TEXT,
+		is_active INTEGER NOT NULL DEFAULT 1,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);`,
+	`CREATE TABLE videos (
+		id TEXT PRIMARY KEY,
+		youtube_video_id TEXT NOT NULL UNIQUE,
+		account_id TEXT NOT NULL,
+		title TEXT,
+		description TEXT,
+		thumbnail_url TEXT,
+		video_url TEXT,
+		local_file_path TEXT,
+		status TEXT NOT NULL,
+		error_message TEXT,
+		tiktok_video_id TEXT,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		published_at TIMESTAMP,
+		FOREIGN KEY(account_id) REFERENCES accounts(id) ON DELETE CASCADE
+	);`,
+	`INSERT INTO accounts (id, youtube_channel_id, tiktok_account_id, tiktok_access_token, created_at, updated_at)
+		VALUES ('acc-1', 'UC-1', 'tt-1', 'act.1', '2024-01-01 00:00:00', '2024-01-01 00:00:00'),
+		       ('acc-2', 'UC-2', 'tt-2', 'act.2', '2024-01-01 00:00:00', '2024-01-01 00:00:00');`,
+	`INSERT INTO videos (id, youtube_video_id, account_id, title, description, status, tiktok_video_id, created_at, updated_at)
+		VALUES ('yt-shared', 'yt-shared', 'acc-1', 'Cross-posted', '', 'completed', '7300000000000000001', '2024-01-02 00:00:00', '2024-01-02 00:00:00'),
+		       ('yt-own', 'yt-own', 'acc-2', 'Own upload', '', 'pending', NULL, '2024-01-03 00:00:00', '2024-01-03 00:00:00');`,
+}
+
+// TestOpenScopesLegacyVideoUnique opens a database of the first release and checks the videos
+// table is rebuilt with the per-account key, keeping its rows and indexes
+func TestOpenScopesLegacyVideoUnique(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range legacySchema {
+		if _, err := legacy.Exec(stmt); err != nil {
+			t.Fatalf("create legacy database: %v", err)
+		}
+	}
+	legacy.Close()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	videos := NewVideoRepository(db)
+
+	migrated, err := videos.GetByYouTubeIDAndAccount(ctx, "yt-shared", "acc-1")
+	if err != nil || migrated == nil {
+		t.Fatalf("GetByYouTubeIDAndAccount() of a migrated row = %v, %v", migrated, err)
+	}
+	if migrated.ID != "yt-shared" || migrated.Title != "Cross-posted" || migrated.Status != domain.VideoStatusCompleted ||
+		migrated.TikTokVideoID != "7300000000000000001" {
+		t.Errorf("migrated video = %+v", migrated)
+	}
+	if own, err := videos.GetByYouTubeIDAndAccount(ctx, "yt-own", "acc-2"); err != nil || own == nil {
+		t.Errorf("GetByYouTubeIDAndAccount() of acc-2's row = %v, %v", own, err)
+	}
+
+	// The second account can now store its own copy of the cross-posted video
+	crossPost := &domain.Video{ID: "acc-2-shared", YouTubeVideoID: "yt-shared", AccountID: "acc-2", Status: domain.VideoStatusPending}
+	if err := videos.Save(ctx, crossPost); err != nil {
+		t.Fatalf("Save() of the cross-post error = %v", err)
+	}
+	crossCopy, err := videos.GetByYouTubeIDAndAccount(ctx, "yt-shared", "acc-2")
+	if err != nil || crossCopy == nil || crossCopy.ID != "acc-2-shared" {
+		t.Errorf("GetByYouTubeIDAndAccount() of the cross-post = %v, %v", crossCopy, err)
+	}
+	// while one account still cannot store the same video twice
+	duplicate := &domain.Video{ID: "acc-1-again", YouTubeVideoID: "yt-shared", AccountID: "acc-1", Status: domain.VideoStatusPending}
+	if err := videos.Save(ctx, duplicate); err == nil {
+		t.Error("Save() of a second copy for the same account succeeded")
+	}
+
+	var schema string
+	if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'videos'`).Scan(&schema); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(schema, "youtube_video_id TEXT NOT NULL UNIQUE") || !strings.Contains(schema, "UNIQUE(youtube_video_id, account_id)") {
+		t.Errorf("videos schema after Open():\n%s", schema)
+	}
+	for _, index := range []string{"idx_videos_status_created", "idx_videos_account_status", "idx_videos_updated", "idx_videos_parent"} {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?`, index).Scan(&n); err != nil || n != 1 {
+			t.Errorf("index %s missing after the rebuild (%v)", index, err)
+		}
+	}
+
+	// Opening the migrated database again leaves it alone
+	db.Close()
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("second Open() error = %v", err)
+	}
+	defer reopened.Close()
+	var count int
+	if err := reopened.QueryRow(`SELECT COUNT(*) FROM videos`).Scan(&count); err != nil || count != 3 {
+		t.Errorf("videos after reopening = %d (%v), want 3", count, err)
+	}
+}
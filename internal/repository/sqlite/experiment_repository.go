@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+const experimentColumns = `id, name, source_video_id, arms, created_at`
+
+// ExperimentRepository is a SQLite implementation of domain.ExperimentRepository.
+type ExperimentRepository struct {
+	db *sql.DB
+}
+
+// NewExperimentRepository creates a new ExperimentRepository backed by SQLite.
+func NewExperimentRepository(db *sql.DB) *ExperimentRepository {
+	return &ExperimentRepository{db: db}
+}
+
+// GetByID returns an experiment by ID.
+func (r *ExperimentRepository) GetByID(ctx context.Context, id string) (*domain.Experiment, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+experimentColumns+` FROM experiments WHERE id = ?`, id)
+	return scanExperiment(row)
+}
+
+// List returns all experiments, newest first.
+func (r *ExperimentRepository) List(ctx context.Context) ([]*domain.Experiment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+experimentColumns+` FROM experiments ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var experiments []*domain.Experiment
+	for rows.Next() {
+		experiment, err := scanExperiment(rows)
+		if err != nil {
+			return nil, err
+		}
+		experiments = append(experiments, experiment)
+	}
+	return experiments, rows.Err()
+}
+
+// Save inserts or updates an experiment.
+func (r *ExperimentRepository) Save(ctx context.Context, experiment *domain.Experiment) error {
+	if experiment.ID == "" {
+		experiment.ID = uuid.NewString()
+	}
+	if experiment.CreatedAt.IsZero() {
+		experiment.CreatedAt = time.Now().UTC()
+	}
+
+	arms, err := json.Marshal(experiment.Arms)
+	if err != nil {
+		return fmt.Errorf("encode experiment arms: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `INSERT INTO experiments (`+experimentColumns+`)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			arms = excluded.arms`, experiment.ID, experiment.Name, experiment.SourceVideoID,
+		string(arms), experiment.CreatedAt.UTC())
+	return err
+}
+
+func scanExperiment(scanner interface {
+	Scan(dest ...any) error
+}) (*domain.Experiment, error) {
+	var (
+		name       sql.NullString
+		arms       string
+		experiment domain.Experiment
+	)
+
+	if err := scanner.Scan(
+		&experiment.ID,
+		&name,
+		&experiment.SourceVideoID,
+		&arms,
+		&experiment.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	experiment.Name = name.String
+	if err := json.Unmarshal([]byte(arms), &experiment.Arms); err != nil {
+		return nil, fmt.Errorf("decode experiment arms: %w", err)
+	}
+	return &experiment, nil
+}
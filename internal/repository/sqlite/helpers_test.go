@@ -0,0 +1,71 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+)
+
+// saveTestAccount stores an active account the rows of other tables can refer to
+func saveTestAccount(t testing.TB, accounts domain.AccountRepository, id string) *domain.Account {
+	t.Helper()
+	account := &domain.Account{
+		ID:               id,
+		YouTubeChannelID: "UC-" + id,
+		TikTokAccountID:  "tt-" + id,
+		IsActive:         true,
+	}
+	if err := accounts.Save(context.Background(), account); err != nil {
+		t.Fatalf("save account %s: %v", id, err)
+	}
+	return account
+}
+
+// saveTestVideo stores a pending video of the account unless video sets another status
+func saveTestVideo(t testing.TB, videos domain.VideoRepository, video *domain.Video) *domain.Video {
+	t.Helper()
+	if video.Status == "" {
+		video.Status = domain.VideoStatusPending
+	}
+	if video.YouTubeVideoID == "" {
+		video.YouTubeVideoID = "yt-" + video.ID
+	}
+	if err := videos.Save(context.Background(), video); err != nil {
+		t.Fatalf("save video %s: %v", video.ID, err)
+	}
+	return video
+}
+
+// checkAborts runs query while another write holds the database and cancels its context once the
+// query is waiting; the query must give up with context.Canceled rather than wait for the write
+func checkAborts(t *testing.T, repos *sqlitetest.Repos, name string, query func(ctx context.Context) error) {
+	t.Helper()
+	release := repos.HoldWrite(t)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- query(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s with a cancelled context error = %v, want %v", name, err, context.Canceled)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("%s still waiting after its context was cancelled", name)
+		release()
+		<-done
+	}
+
+	// Cancelled before it starts, the query does not run at all
+	if err := query(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("%s with an already cancelled context error = %v, want %v", name, err, context.Canceled)
+	}
+}
@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// InviteRepository is a SQLite implementation of domain.InviteRepository.
+type InviteRepository struct {
+	db *sql.DB
+}
+
+// NewInviteRepository creates a new InviteRepository backed by SQLite.
+func NewInviteRepository(db *sql.DB) *InviteRepository {
+	return &InviteRepository{db: db}
+}
+
+// GetByID returns an invite by ID.
+func (r *InviteRepository) GetByID(id string) (*domain.Invite, error) {
+	row := r.db.QueryRow(`SELECT id, account_id, expires_at, used_at, revoked_at, created_at
+		FROM invites WHERE id = ?`, id)
+	return scanInvite(row)
+}
+
+// ListByAccount returns all invites for an account, newest first.
+func (r *InviteRepository) ListByAccount(accountID string) ([]*domain.Invite, error) {
+	rows, err := r.db.Query(`SELECT id, account_id, expires_at, used_at, revoked_at, created_at
+		FROM invites WHERE account_id = ? ORDER BY created_at DESC`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []*domain.Invite
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+// Save inserts or updates an invite.
+func (r *InviteRepository) Save(invite *domain.Invite) error {
+	if invite.ID == "" {
+		invite.ID = uuid.NewString()
+	}
+	if invite.CreatedAt.IsZero() {
+		invite.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := r.db.Exec(`INSERT INTO invites (id, account_id, expires_at, used_at, revoked_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			expires_at = excluded.expires_at,
+			used_at = excluded.used_at,
+			revoked_at = excluded.revoked_at`, invite.ID, invite.AccountID, invite.ExpiresAt.UTC(),
+		nullableTimePtr(invite.UsedAt), nullableTimePtr(invite.RevokedAt), invite.CreatedAt.UTC())
+	return err
+}
+
+// MarkUsed redeems an invite atomically so it can only be used once.
+func (r *InviteRepository) MarkUsed(id string, usedAt time.Time) (bool, error) {
+	res, err := r.db.Exec(`UPDATE invites SET used_at = ? WHERE id = ? AND used_at IS NULL AND revoked_at IS NULL`,
+		usedAt.UTC(), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ReleaseUse reopens an invite redeemed at usedAt; a later redemption is left alone.
+func (r *InviteRepository) ReleaseUse(id string, usedAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE invites SET used_at = NULL WHERE id = ? AND used_at = ?`, id, usedAt.UTC())
+	return err
+}
+
+// Revoke revokes an invite.
+func (r *InviteRepository) Revoke(id string, revokedAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE invites SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, revokedAt.UTC(), id)
+	return err
+}
+
+func scanInvite(scanner interface {
+	Scan(dest ...any) error
+}) (*domain.Invite, error) {
+	var (
+		usedAt    sql.NullTime
+		revokedAt sql.NullTime
+		invite    domain.Invite
+	)
+
+	if err := scanner.Scan(
+		&invite.ID,
+		&invite.AccountID,
+		&invite.ExpiresAt,
+		&usedAt,
+		&revokedAt,
+		&invite.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		invite.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		invite.RevokedAt = &revokedAt.Time
+	}
+	return &invite, nil
+}
@@ -0,0 +1,44 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+)
+
+func TestInviteRepositoryMarkUsedAndRelease(t *testing.T) {
+	repos := sqlitetest.OpenTest(t)
+	saveTestAccount(t, repos.Accounts, "acc-1")
+	invite := &domain.Invite{AccountID: "acc-1", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repos.Invites.Save(invite); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	first := time.Now()
+	if ok, err := repos.Invites.MarkUsed(invite.ID, first); err != nil || !ok {
+		t.Fatalf("MarkUsed() = %v, %v; want true", ok, err)
+	}
+	if ok, err := repos.Invites.MarkUsed(invite.ID, first.Add(time.Second)); err != nil || ok {
+		t.Fatalf("second MarkUsed() = %v, %v; want false", ok, err)
+	}
+
+	// Releasing with another redemption time leaves the invite used
+	if err := repos.Invites.ReleaseUse(invite.ID, first.Add(time.Minute)); err != nil {
+		t.Fatalf("ReleaseUse() error = %v", err)
+	}
+	if got, _ := repos.Invites.GetByID(invite.ID); got.UsedAt == nil {
+		t.Fatal("ReleaseUse() with another time reopened the invite")
+	}
+
+	if err := repos.Invites.ReleaseUse(invite.ID, first); err != nil {
+		t.Fatalf("ReleaseUse() error = %v", err)
+	}
+	if got, _ := repos.Invites.GetByID(invite.ID); got.UsedAt != nil {
+		t.Fatalf("used_at = %v after release, want nil", got.UsedAt)
+	}
+	if ok, err := repos.Invites.MarkUsed(invite.ID, time.Now()); err != nil || !ok {
+		t.Fatalf("MarkUsed() after release = %v, %v; want true", ok, err)
+	}
+}
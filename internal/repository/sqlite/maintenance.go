@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// Maintainer is a SQLite implementation of domain.DatabaseMaintainer.
+type Maintainer struct {
+	db *sql.DB
+}
+
+// NewMaintainer creates a Maintainer for the database.
+func NewMaintainer(db *sql.DB) *Maintainer {
+	return &Maintainer{db: db}
+}
+
+// Stats reads the page counts and adds up the database and WAL file sizes. An in-memory database
+// reports its page size times its page count.
+func (m *Maintainer) Stats(ctx context.Context) (*domain.DatabaseStats, error) {
+	var stats domain.DatabaseStats
+	var pageSize int64
+	if err := m.db.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&stats.Pages); err != nil {
+		return nil, fmt.Errorf("read page count: %w", err)
+	}
+	if err := m.db.QueryRowContext(ctx, `PRAGMA freelist_count`).Scan(&stats.FreePages); err != nil {
+		return nil, fmt.Errorf("read freelist count: %w", err)
+	}
+	if err := m.db.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return nil, fmt.Errorf("read page size: %w", err)
+	}
+
+	var file string
+	if err := m.db.QueryRowContext(ctx, `SELECT file FROM pragma_database_list WHERE name = 'main'`).Scan(&file); err != nil {
+		return nil, fmt.Errorf("read database file: %w", err)
+	}
+	if file == "" {
+		stats.SizeBytes = stats.Pages * pageSize
+		return &stats, nil
+	}
+	for _, path := range []string{file, file + "-wal"} {
+		info, err := os.Stat(path)
+		if err == nil {
+			stats.SizeBytes += info.Size()
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return &stats, nil
+}
+
+// Checkpoint runs PRAGMA wal_checkpoint(TRUNCATE). It fails when a reader kept the checkpoint
+// from completing.
+func (m *Maintainer) Checkpoint(ctx context.Context) error {
+	var busy, logFrames, checkpointed int
+	if err := m.db.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &logFrames, &checkpointed); err != nil {
+		return fmt.Errorf("wal checkpoint: %w", err)
+	}
+	if busy != 0 {
+		return fmt.Errorf("wal checkpoint: blocked by another connection")
+	}
+	return nil
+}
+
+// Vacuum runs VACUUM. In WAL mode the rebuilt database goes through the log, so Checkpoint
+// should follow.
+func (m *Maintainer) Vacuum(ctx context.Context) error {
+	if _, err := m.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	return nil
+}
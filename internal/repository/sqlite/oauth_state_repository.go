@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// OAuthStateRepository is a SQLite implementation of domain.OAuthStateRepository.
+type OAuthStateRepository struct {
+	db *sql.DB
+}
+
+// NewOAuthStateRepository creates a new OAuthStateRepository backed by SQLite.
+func NewOAuthStateRepository(db *sql.DB) *OAuthStateRepository {
+	return &OAuthStateRepository{db: db}
+}
+
+// Get returns a state by its token.
+func (r *OAuthStateRepository) Get(ctx context.Context, state string) (*domain.OAuthState, error) {
+	var (
+		entry      domain.OAuthState
+		provider   sql.NullString
+		appName    sql.NullString
+		clientKey  sql.NullString
+		consumedAt sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT state, account_id, provider, app_name, client_key, expires_at, consumed_at, created_at
+		FROM oauth_states WHERE state = ?`, state,
+	).Scan(&entry.State, &entry.AccountID, &provider, &appName, &clientKey, &entry.ExpiresAt, &consumedAt, &entry.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry.Provider = provider.String
+	entry.AppName = appName.String
+	entry.ClientKey = clientKey.String
+	if consumedAt.Valid {
+		entry.ConsumedAt = &consumedAt.Time
+	}
+	return &entry, nil
+}
+
+// Save stores a new state.
+func (r *OAuthStateRepository) Save(ctx context.Context, state *domain.OAuthState) error {
+	if state.CreatedAt.IsZero() {
+		state.CreatedAt = time.Now().UTC()
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO oauth_states (state, account_id, provider, app_name, client_key, expires_at, consumed_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		state.State, state.AccountID, state.Provider, state.AppName, state.ClientKey, state.ExpiresAt.UTC(),
+		nullableTimePtr(state.ConsumedAt), state.CreatedAt.UTC())
+	return err
+}
+
+// MarkConsumed uses a pending state atomically so concurrent callbacks cannot both succeed.
+func (r *OAuthStateRepository) MarkConsumed(ctx context.Context, state string, consumedAt time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE oauth_states SET consumed_at = ? WHERE state = ? AND consumed_at IS NULL`,
+		consumedAt.UTC(), state)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// CountPending returns the account's unconsumed, unexpired states.
+func (r *OAuthStateRepository) CountPending(ctx context.Context, accountID string, now time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM oauth_states
+		WHERE account_id = ? AND consumed_at IS NULL AND expires_at > ?`, accountID, now.UTC(),
+	).Scan(&count)
+	return count, err
+}
+
+// DeleteExpiredBefore removes states that expired before the cutoff.
+func (r *OAuthStateRepository) DeleteExpiredBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM oauth_states WHERE expires_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// Stats counts stored states by lifecycle stage.
+func (r *OAuthStateRepository) Stats(ctx context.Context, now time.Time) (*domain.OAuthStateStats, error) {
+	var stats domain.OAuthStateStats
+	err := r.db.QueryRowContext(ctx, `SELECT
+			COALESCE(SUM(CASE WHEN consumed_at IS NULL AND expires_at > ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN consumed_at IS NOT NULL THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN consumed_at IS NULL AND expires_at <= ? THEN 1 ELSE 0 END), 0)
+		FROM oauth_states`, now.UTC(), now.UTC(),
+	).Scan(&stats.Outstanding, &stats.Consumed, &stats.Expired)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,63 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	sqliterepo "auto_upload_tiktok/internal/repository/sqlite"
+)
+
+// TestOAuthStateRepositoryMarkConsumedConcurrent races callbacks of two processes sharing the
+// database file; the state is consumed once
+func TestOAuthStateRepositoryMarkConsumedConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "states.db")
+	var handles []*sqliterepo.OAuthStateRepository
+	for range 2 {
+		db, err := sqliterepo.Open(path)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		handles = append(handles, sqliterepo.NewOAuthStateRepository(db))
+		if len(handles) == 1 {
+			saveTestAccount(t, sqliterepo.NewAccountRepository(db), "acc-1")
+		}
+	}
+	ctx := context.Background()
+	state := &domain.OAuthState{State: "s1", AccountID: "acc-1", Provider: domain.OAuthProviderTikTok, ExpiresAt: time.Now().Add(time.Minute)}
+	if err := handles[0].Save(ctx, state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int32
+	)
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := handles[i%2].MarkConsumed(ctx, "s1", time.Now())
+			if err != nil {
+				t.Errorf("MarkConsumed() error = %v", err)
+			}
+			if ok {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := accepted.Load(); got != 1 {
+		t.Errorf("MarkConsumed() succeeded %d times, want 1", got)
+	}
+	stored, err := handles[1].Get(ctx, "s1")
+	if err != nil || stored == nil || stored.ConsumedAt == nil {
+		t.Errorf("Get() = %+v, %v; want a consumed state", stored, err)
+	}
+}
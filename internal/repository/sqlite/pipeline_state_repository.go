@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// PipelineStateRepository is a SQLite implementation of domain.PipelineStateRepository. The
+// state is the single row of the pipeline_state table.
+type PipelineStateRepository struct {
+	db *sql.DB
+}
+
+// NewPipelineStateRepository creates a new PipelineStateRepository backed by SQLite.
+func NewPipelineStateRepository(db *sql.DB) *PipelineStateRepository {
+	return &PipelineStateRepository{db: db}
+}
+
+// Get returns the stored pipeline state.
+func (r *PipelineStateRepository) Get(ctx context.Context) (*domain.PipelineState, error) {
+	var (
+		state  domain.PipelineState
+		scope  string
+		reason sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT scope, reason, updated_at FROM pipeline_state WHERE id = 1`).
+		Scan(&scope, &reason, &state.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.Scope = domain.PauseScope(scope)
+	state.Reason = reason.String
+	return &state, nil
+}
+
+// Save replaces the stored pipeline state.
+func (r *PipelineStateRepository) Save(ctx context.Context, state *domain.PipelineState) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO pipeline_state (id, scope, reason, updated_at)
+		VALUES (1, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			scope = excluded.scope,
+			reason = excluded.reason,
+			updated_at = excluded.updated_at`,
+		string(state.Scope), nullableString(state.Reason), state.UpdatedAt.UTC())
+	return err
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// dbtx is what the repositories need from a connection pool or a transaction
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Transactor is a SQLite implementation of domain.Transactor.
+type Transactor struct {
+	db *sql.DB
+}
+
+// NewTransactor creates a Transactor for the database.
+func NewTransactor(db *sql.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithTx runs fn in a transaction. The database allows a single connection, so other queries
+// wait until the transaction ends; fn should not do anything slow.
+func (t *Transactor) WithTx(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	repos := domain.Repositories{
+		Accounts: &AccountRepository{db: tx},
+		Videos:   &VideoRepository{db: tx},
+		Audit:    &AuditRepository{db: tx},
+	}
+	if err := fn(ctx, repos); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
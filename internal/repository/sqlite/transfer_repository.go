@@ -0,0 +1,48 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// TransferUsageRepository is a SQLite implementation of domain.TransferUsageRepository.
+type TransferUsageRepository struct {
+	db *sql.DB
+}
+
+// NewTransferUsageRepository creates a new TransferUsageRepository backed by SQLite.
+func NewTransferUsageRepository(db *sql.DB) *TransferUsageRepository {
+	return &TransferUsageRepository{db: db}
+}
+
+// Get returns the totals for a day.
+func (r *TransferUsageRepository) Get(ctx context.Context, day string) (*domain.TransferUsage, error) {
+	usage := &domain.TransferUsage{Day: day}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT download_bytes, upload_bytes, updated_at FROM transfer_usage WHERE day = ?`, day,
+	).Scan(&usage.DownloadBytes, &usage.UploadBytes, &usage.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// Add increments a day's totals.
+func (r *TransferUsageRepository) Add(ctx context.Context, day string, downloadBytes, uploadBytes int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO transfer_usage (day, download_bytes, upload_bytes, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(day) DO UPDATE SET
+			download_bytes = download_bytes + excluded.download_bytes,
+			upload_bytes = upload_bytes + excluded.upload_bytes,
+			updated_at = excluded.updated_at
+	`, day, downloadBytes, uploadBytes, time.Now().UTC())
+	return err
+}
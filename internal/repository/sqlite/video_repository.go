@@ -1,8 +1,11 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,9 +13,21 @@ import (
 	"auto_upload_tiktok/internal/domain"
 )
 
+// videoColumns lists the columns read by scanVideo, in scan order.
+const videoColumns = `id, youtube_video_id, account_id, title, description, thumbnail_url,
+	video_url, local_file_path, status, error_message, tiktok_video_id,
+	created_at, updated_at, published_at, comment_posted, comment_error, completed_at,
+	parent_video_id, clip_start_ms, clip_end_ms, clip_count,
+	downloaded_at, uploaded_at, download_duration_ms, upload_duration_ms,
+	title_language, translated_title, translated_language,
+	upload_attempt_id, upload_publish_id, content_hash, immediate, upload_route, upload_route_reason,
+	cost_api_units, cost_download_bytes, cost_upload_bytes, cost_processing_ms, cost_retries, priority,
+	safety_decision, safety_rule, tiktok_post_url, post_options, view_count, views_checked_at,
+	download_width, download_height, download_fps, progress`
+
 // VideoRepository is a SQLite implementation of domain.VideoRepository.
 type VideoRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 // NewVideoRepository creates a new VideoRepository backed by SQLite.
@@ -20,21 +35,177 @@ func NewVideoRepository(db *sql.DB) *VideoRepository {
 	return &VideoRepository{db: db}
 }
 
-// GetByYouTubeID returns a video by YouTube ID.
-func (r *VideoRepository) GetByYouTubeID(youtubeID string) (*domain.Video, error) {
-	row := r.db.QueryRow(`SELECT id, youtube_video_id, account_id, title, description, thumbnail_url,
-		video_url, local_file_path, status, error_message, tiktok_video_id,
-		created_at, updated_at, published_at
-		FROM videos WHERE youtube_video_id = ?`, youtubeID)
+// GetByID returns a video by ID.
+func (r *VideoRepository) GetByID(ctx context.Context, id string) (*domain.Video, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+videoColumns+` FROM videos WHERE id = ?`, id)
+	return scanVideo(row)
+}
+
+// GetByYouTubeIDAndAccount returns an account's copy of a YouTube video.
+func (r *VideoRepository) GetByYouTubeIDAndAccount(ctx context.Context, youtubeID, accountID string) (*domain.Video, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+videoColumns+` FROM videos WHERE youtube_video_id = ? AND account_id = ?`, youtubeID, accountID)
 	return scanVideo(row)
 }
 
-// GetPendingVideos returns pending videos up to limit ordered by oldest first.
-func (r *VideoRepository) GetPendingVideos(limit int) ([]*domain.Video, error) {
-	rows, err := r.db.Query(`SELECT id, youtube_video_id, account_id, title, description, thumbnail_url,
-		video_url, local_file_path, status, error_message, tiktok_video_id,
-		created_at, updated_at, published_at
-		FROM videos WHERE status = ? ORDER BY created_at ASC LIMIT ?`, domain.VideoStatusPending, limit)
+// GetClips returns the clips cut from a parent video ordered by clip start.
+func (r *VideoRepository) GetClips(ctx context.Context, parentID string) ([]*domain.Video, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+videoColumns+` FROM videos WHERE parent_video_id = ? ORDER BY clip_start_ms ASC`, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*domain.Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+
+	return videos, rows.Err()
+}
+
+// GetPendingVideos returns pending videos up to limit, videos marked immediate first, then by
+// priority, then newest published first, so a backlog of old videos does not hold back a fresh
+// one. Videos without a publish time come last. Videos split into clips are skipped; their clips
+// are queued instead. The created_at and id tiebreakers keep batches stable.
+func (r *VideoRepository) GetPendingVideos(ctx context.Context, limit int) ([]*domain.Video, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+videoColumns+` FROM videos WHERE status = ? AND clip_count = 0 ORDER BY `+pendingOrder+` LIMIT ?`, domain.VideoStatusPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*domain.Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+
+	return videos, rows.Err()
+}
+
+// pendingOrder is the queue order of pending videos
+const pendingOrder = `immediate DESC, priority DESC, published_at DESC, created_at ASC, id ASC`
+
+// ClaimPending claims pending videos in a transaction whose first statement writes, which takes
+// the database's write lock, so no other connection or process can claim the same videos before
+// the claimed ones are read back. Within a transaction the claim runs in it instead.
+func (r *VideoRepository) ClaimPending(ctx context.Context, owner string, limit int, lease time.Duration) ([]*domain.Video, error) {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return claimPending(ctx, r.db, owner, limit, lease)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	videos, err := claimPending(ctx, tx, owner, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return videos, nil
+}
+
+// claimPending marks the first limit claimable pending videos with owner and a lease end that
+// identifies this claim, then reads them back by it
+func claimPending(ctx context.Context, db dbtx, owner string, limit int, lease time.Duration) ([]*domain.Video, error) {
+	now := time.Now().UTC()
+	until := now.Add(lease)
+	_, err := db.ExecContext(ctx, `UPDATE videos SET claimed_by = ?, claimed_until = ? WHERE id IN (
+			SELECT id FROM videos
+			WHERE status = ? AND clip_count = 0 AND (claimed_until IS NULL OR claimed_until <= ? OR claimed_by = ?)
+			ORDER BY `+pendingOrder+` LIMIT ?)`,
+		owner, until, domain.VideoStatusPending, now, owner, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT `+videoColumns+` FROM videos
+		WHERE status = ? AND claimed_by = ? AND claimed_until = ? ORDER BY `+pendingOrder,
+		domain.VideoStatusPending, owner, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*domain.Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+
+	return videos, rows.Err()
+}
+
+// ClaimVideo claims one pending video for owner unless another owner's lease is still running.
+func (r *VideoRepository) ClaimVideo(ctx context.Context, id string, owner string, lease time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	result, err := r.db.ExecContext(ctx, `UPDATE videos SET claimed_by = ?, claimed_until = ?
+		WHERE id = ? AND status = ? AND (claimed_until IS NULL OR claimed_until <= ? OR claimed_by = ?)`,
+		owner, now.Add(lease), id, domain.VideoStatusPending, now, owner)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// ReleaseClaim ends owner's claim on a video.
+func (r *VideoRepository) ReleaseClaim(ctx context.Context, id string, owner string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET claimed_by = NULL, claimed_until = NULL WHERE id = ? AND claimed_by = ?`,
+		id, owner)
+	return err
+}
+
+// GetImmediateVideos returns pending videos marked immediate up to limit, oldest first.
+func (r *VideoRepository) GetImmediateVideos(ctx context.Context, limit int) ([]*domain.Video, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+videoColumns+` FROM videos WHERE status = ? AND clip_count = 0 AND immediate = 1 ORDER BY created_at ASC, id ASC LIMIT ?`, domain.VideoStatusPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*domain.Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+
+	return videos, rows.Err()
+}
+
+// ClaimImmediate clears the immediate mark of a pending video in one conditional update.
+func (r *VideoRepository) ClaimImmediate(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE videos SET immediate = 0 WHERE id = ? AND immediate = 1 AND status = ?`,
+		id, domain.VideoStatusPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// GetWithoutPublishedAt returns videos with no published_at, oldest first.
+func (r *VideoRepository) GetWithoutPublishedAt(ctx context.Context) ([]*domain.Video, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+videoColumns+` FROM videos WHERE published_at IS NULL ORDER BY created_at ASC, id ASC`)
 	if err != nil {
 		return nil, err
 	}
@@ -52,9 +223,81 @@ func (r *VideoRepository) GetPendingVideos(limit int) ([]*domain.Video, error) {
 	return videos, rows.Err()
 }
 
+// GetWithFilePath returns videos whose local file path is set.
+func (r *VideoRepository) GetWithFilePath(ctx context.Context) ([]*domain.Video, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+videoColumns+` FROM videos WHERE local_file_path IS NOT NULL AND local_file_path != '' ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*domain.Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+
+	return videos, rows.Err()
+}
+
+// iteratePageSize is how many videos Iterate reads per query
+const iteratePageSize = 500
+
+// Iterate calls fn for every video matching the filter's status with an ID after the cursor, in
+// ID order. Each page is read and its rows closed before fn sees it, so a slow consumer does not
+// hold a connection open.
+func (r *VideoRepository) Iterate(ctx context.Context, filter domain.VideoFilter, after string, fn func(video *domain.Video) error) error {
+	for {
+		page, err := r.iteratePage(ctx, filter.Status, after)
+		if err != nil {
+			return err
+		}
+		for _, video := range page {
+			if err := fn(video); err != nil {
+				return err
+			}
+		}
+		if len(page) < iteratePageSize {
+			return nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+// iteratePage returns the next page of Iterate
+func (r *VideoRepository) iteratePage(ctx context.Context, status domain.VideoStatus, after string) ([]*domain.Video, error) {
+	query := `SELECT ` + videoColumns + ` FROM videos WHERE id > ?`
+	args := []any{after}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, string(status))
+	}
+	query += ` ORDER BY id LIMIT ?`
+	args = append(args, iteratePageSize)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	videos := make([]*domain.Video, 0, iteratePageSize)
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+	return videos, rows.Err()
+}
+
 // CountPending returns the number of pending videos.
-func (r *VideoRepository) CountPending() (int, error) {
-	row := r.db.QueryRow(`SELECT COUNT(*) FROM videos WHERE status = ?`, domain.VideoStatusPending)
+func (r *VideoRepository) CountPending(ctx context.Context) (int, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM videos WHERE status = ? AND clip_count = 0`, domain.VideoStatusPending)
 	var count int
 	if err := row.Scan(&count); err != nil {
 		return 0, err
@@ -62,22 +305,198 @@ func (r *VideoRepository) CountPending() (int, error) {
 	return count, nil
 }
 
-// Save inserts or updates a video.
-func (r *VideoRepository) Save(video *domain.Video) error {
+// GetByAccountID returns an account's videos ordered by published date, newest first.
+// Videos with an unknown published date are placed by their creation time.
+func (r *VideoRepository) GetByAccountID(ctx context.Context, accountID string, filter domain.VideoFilter) ([]*domain.Video, error) {
+	query := `SELECT ` + videoColumns + ` FROM videos WHERE account_id = ?`
+	args := []any{accountID}
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, string(filter.Status))
+	}
+	query += ` ORDER BY COALESCE(published_at, created_at) DESC, created_at DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, filter.Limit, filter.Offset)
+	} else if filter.Offset > 0 {
+		query += ` LIMIT -1 OFFSET ?`
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*domain.Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+
+	return videos, rows.Err()
+}
+
+// GetRecent returns videos of all accounts, most recently updated first.
+func (r *VideoRepository) GetRecent(ctx context.Context, filter domain.VideoFilter) ([]*domain.Video, error) {
+	query := `SELECT ` + videoColumns + ` FROM videos`
+	var args []any
+	if filter.Status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, string(filter.Status))
+	}
+	query += ` ORDER BY updated_at DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, filter.Limit, filter.Offset)
+	} else if filter.Offset > 0 {
+		query += ` LIMIT -1 OFFSET ?`
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*domain.Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+
+	return videos, rows.Err()
+}
+
+// CountByStatus returns the number of videos per status for an account.
+func (r *VideoRepository) CountByStatus(ctx context.Context, accountID string) (map[domain.VideoStatus]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM videos WHERE account_id = ? GROUP BY status`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.VideoStatus]int)
+	for rows.Next() {
+		var (
+			status domain.VideoStatus
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// GetUploadStats counts uploads completed since the given time and returns the latest completion.
+func (r *VideoRepository) GetUploadStats(ctx context.Context, accountID string, since time.Time) (*domain.UploadStats, error) {
+	var stats domain.UploadStats
+	row := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM videos
+		WHERE account_id = ? AND status = ? AND clip_count = 0 AND completed_at >= ?`,
+		accountID, string(domain.VideoStatusCompleted), since.UTC())
+	if err := row.Scan(&stats.Count); err != nil {
+		return nil, err
+	}
+
+	var last sql.NullTime
+	row = r.db.QueryRowContext(ctx, `SELECT completed_at FROM videos
+		WHERE account_id = ? AND status = ? AND clip_count = 0 AND completed_at IS NOT NULL
+		ORDER BY completed_at DESC LIMIT 1`,
+		accountID, string(domain.VideoStatusCompleted))
+	if err := row.Scan(&last); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if last.Valid {
+		stats.LastCompletedAt = last.Time
+	}
+	return &stats, nil
+}
+
+// GetCompletedSince returns uploads completed at or after the given time, oldest first.
+func (r *VideoRepository) GetCompletedSince(ctx context.Context, since time.Time) ([]*domain.Video, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+videoColumns+` FROM videos
+		WHERE status = ? AND clip_count = 0 AND completed_at >= ?
+		ORDER BY completed_at ASC, id ASC`,
+		string(domain.VideoStatusCompleted), since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*domain.Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+	return videos, rows.Err()
+}
+
+// GetMissingTikTokPostURL returns completed videos that have a TikTok video ID but no post URL.
+func (r *VideoRepository) GetMissingTikTokPostURL(ctx context.Context) ([]*domain.Video, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+videoColumns+` FROM videos
+		WHERE status = ? AND tiktok_video_id IS NOT NULL AND tiktok_video_id != '' AND tiktok_post_url = ''
+		ORDER BY completed_at ASC, id ASC`,
+		string(domain.VideoStatusCompleted))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*domain.Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+	return videos, rows.Err()
+}
+
+// Save inserts or updates a video. The immediate mark and priority are written only on insert;
+// updates leave them to ClaimImmediate and UpdatePriority. Cost counters are left to AddCost.
+func (r *VideoRepository) Save(ctx context.Context, video *domain.Video) error {
 	now := time.Now().UTC()
 	if video.ID == "" {
 		video.ID = uuid.NewString()
+	}
+	// Discovery sets the ID up front (RSS entries carry no creation time), so stamp it here;
+	// a zero created_at would tie every such video in the pending queue
+	if video.CreatedAt.IsZero() {
 		video.CreatedAt = now
 	}
 	if video.Status == "" {
 		video.Status = domain.VideoStatusPending
 	}
 	video.UpdatedAt = now
+	options, err := encodePostOptions(video.PostOptions)
+	if err != nil {
+		return err
+	}
 
-	_, err := r.db.Exec(`INSERT INTO videos
+	_, err = r.db.ExecContext(ctx, `INSERT INTO videos
 		(id, youtube_video_id, account_id, title, description, thumbnail_url, video_url, local_file_path,
-			status, error_message, tiktok_video_id, created_at, updated_at, published_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			status, error_message, tiktok_video_id, created_at, updated_at, published_at, completed_at,
+			parent_video_id, clip_start_ms, clip_end_ms, clip_count,
+			downloaded_at, uploaded_at, download_duration_ms, upload_duration_ms,
+			title_language, translated_title, translated_language,
+			upload_attempt_id, upload_publish_id, content_hash, immediate, upload_route, upload_route_reason, priority,
+			post_options, view_count, views_checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			youtube_video_id = excluded.youtube_video_id,
 			account_id = excluded.account_id,
@@ -90,44 +509,313 @@ func (r *VideoRepository) Save(video *domain.Video) error {
 			error_message = excluded.error_message,
 			tiktok_video_id = excluded.tiktok_video_id,
 			updated_at = excluded.updated_at,
-			published_at = excluded.published_at`, video.ID, video.YouTubeVideoID, video.AccountID, video.Title,
+			published_at = excluded.published_at,
+			completed_at = excluded.completed_at,
+			parent_video_id = excluded.parent_video_id,
+			clip_start_ms = excluded.clip_start_ms,
+			clip_end_ms = excluded.clip_end_ms,
+			clip_count = excluded.clip_count,
+			downloaded_at = excluded.downloaded_at,
+			uploaded_at = excluded.uploaded_at,
+			download_duration_ms = excluded.download_duration_ms,
+			upload_duration_ms = excluded.upload_duration_ms,
+			title_language = excluded.title_language,
+			translated_title = excluded.translated_title,
+			translated_language = excluded.translated_language,
+			upload_attempt_id = excluded.upload_attempt_id,
+			upload_publish_id = excluded.upload_publish_id,
+			content_hash = excluded.content_hash,
+			upload_route = excluded.upload_route,
+			upload_route_reason = excluded.upload_route_reason`, video.ID, video.YouTubeVideoID, video.AccountID, video.Title,
 		video.Description, video.ThumbnailURL, video.VideoURL, video.LocalFilePath, string(video.Status),
-		video.ErrorMessage, video.TikTokVideoID, video.CreatedAt.UTC(), video.UpdatedAt.UTC(), nullableTime(video.PublishedAt))
+		video.ErrorMessage, video.TikTokVideoID, video.CreatedAt.UTC(), video.UpdatedAt.UTC(), nullableTime(video.PublishedAt),
+		nullableTime(video.CompletedAt), nullableString(video.ParentVideoID), video.ClipStart.Milliseconds(),
+		video.ClipEnd.Milliseconds(), video.ClipCount, nullableTime(video.DownloadedAt), nullableTime(video.UploadedAt),
+		video.DownloadDuration.Milliseconds(), video.UploadDuration.Milliseconds(),
+		nullableString(video.TitleLanguage), nullableString(video.TranslatedTitle), nullableString(video.TranslatedLanguage),
+		nullableString(video.UploadAttemptID), nullableString(video.UploadPublishID), nullableString(video.ContentHash),
+		video.Immediate, nullableString(string(video.UploadRoute)), nullableString(video.UploadRouteReason), video.Priority,
+		options, video.ViewCount, nullableTime(video.ViewsCheckedAt))
+	return err
+}
+
+// UpdateSafetyDecision records the content-safety decision and the rule that triggered it.
+func (r *VideoRepository) UpdateSafetyDecision(ctx context.Context, id string, decision domain.SafetyDecision, rule string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET safety_decision = ?, safety_rule = ?, updated_at = ? WHERE id = ?`,
+		string(decision), rule, time.Now().UTC(), id)
 	return err
 }
 
-// UpdateStatus updates the status and optional error message.
-func (r *VideoRepository) UpdateStatus(id string, status domain.VideoStatus, errorMsg string) error {
-	_, err := r.db.Exec(`UPDATE videos SET status = ?, error_message = ?, updated_at = ? WHERE id = ?`,
-		string(status), errorMsg, time.Now().UTC(), id)
+// UpdateTikTokPostURL records the public link of the video's TikTok post.
+func (r *VideoRepository) UpdateTikTokPostURL(ctx context.Context, id string, postURL string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET tiktok_post_url = ?, updated_at = ? WHERE id = ?`,
+		postURL, time.Now().UTC(), id)
+	return err
+}
+
+// UpdatePostOptions replaces the video's TikTok post overrides.
+func (r *VideoRepository) UpdatePostOptions(ctx context.Context, id string, options *domain.PostOptions) error {
+	encoded, err := encodePostOptions(options)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `UPDATE videos SET post_options = ?, updated_at = ? WHERE id = ?`,
+		encoded, time.Now().UTC(), id)
 	return err
 }
 
+// UpdateViewCount records the YouTube view count read at checkedAt.
+func (r *VideoRepository) UpdateViewCount(ctx context.Context, id string, views int64, checkedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET view_count = ?, views_checked_at = ?, updated_at = ? WHERE id = ?`,
+		views, checkedAt.UTC(), time.Now().UTC(), id)
+	return err
+}
+
+// UpdateDownloadFormat records the resolution and frame rate of the downloaded file.
+func (r *VideoRepository) UpdateDownloadFormat(ctx context.Context, id string, width, height int, fps float64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET download_width = ?, download_height = ?, download_fps = ?, updated_at = ? WHERE id = ?`,
+		width, height, fps, time.Now().UTC(), id)
+	return err
+}
+
+// UpdateProgress records the percentage of the video's current download or upload done.
+func (r *VideoRepository) UpdateProgress(ctx context.Context, id string, progress int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET progress = ?, updated_at = ? WHERE id = ?`,
+		progress, time.Now().UTC(), id)
+	return err
+}
+
+// UpdatePriority sets a video's queue priority.
+func (r *VideoRepository) UpdatePriority(ctx context.Context, id string, priority int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET priority = ?, updated_at = ? WHERE id = ?`,
+		priority, time.Now().UTC(), id)
+	return err
+}
+
+// UpdateStatus updates the status and optional error message and resets the progress.
+// Moving to completed also stamps completed_at.
+func (r *VideoRepository) UpdateStatus(ctx context.Context, id string, status domain.VideoStatus, errorMsg string) error {
+	now := time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET status = ?, error_message = ?, progress = 0, updated_at = ?,
+		completed_at = CASE WHEN ? = ? THEN ? ELSE completed_at END
+		WHERE id = ?`,
+		string(status), errorMsg, now, string(status), string(domain.VideoStatusCompleted), now, id)
+	return err
+}
+
+// ForceStatus sets an administrator's outcome in one write.
+func (r *VideoRepository) ForceStatus(ctx context.Context, id string, status domain.VideoStatus, message string, tiktokID string) error {
+	now := time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET status = ?, error_message = ?, immediate = 0, updated_at = ?,
+		tiktok_video_id = CASE WHEN ? != '' THEN ? ELSE tiktok_video_id END,
+		completed_at = CASE WHEN ? = ? THEN ? ELSE completed_at END
+		WHERE id = ?`,
+		string(status), message, now, tiktokID, tiktokID, string(status), string(domain.VideoStatusCompleted), now, id)
+	return err
+}
+
+// RequeueInterrupted moves videos stuck in a transient status back to pending unless another
+// process's claim on them is still running.
+func (r *VideoRepository) RequeueInterrupted(ctx context.Context, host string, errorMsg string) (int, error) {
+	now := time.Now().UTC()
+	prefix := host + ":"
+	result, err := r.db.ExecContext(ctx, `UPDATE videos SET status = ?, error_message = ?, updated_at = ?,
+			claimed_by = NULL, claimed_until = NULL
+		WHERE status IN (?, ?, ?) AND clip_count = 0
+			AND (claimed_until IS NULL OR claimed_until <= ? OR (? != '' AND substr(claimed_by, 1, ?) = ?))`,
+		string(domain.VideoStatusPending), errorMsg, now,
+		string(domain.VideoStatusDownloading), string(domain.VideoStatusDownloaded), string(domain.VideoStatusUploading),
+		now, host, len(prefix), prefix)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// PruneFinished deletes completed and failed videos finished before the given time, keeping
+// clip families whole and experiment sources.
+func (r *VideoRepository) PruneFinished(ctx context.Context, before time.Time) (int, error) {
+	cutoff := before.UTC()
+	result, err := r.db.ExecContext(ctx, `WITH prunable AS (
+			SELECT id, parent_video_id FROM videos
+			WHERE status IN (?, ?) AND updated_at < ? AND COALESCE(published_at, created_at) < ?
+				AND id NOT IN (SELECT source_video_id FROM experiments)
+		),
+		kept AS (
+			SELECT id, parent_video_id FROM videos WHERE id NOT IN (SELECT id FROM prunable)
+		),
+		held AS (
+			SELECT id FROM kept
+			UNION SELECT parent_video_id FROM kept WHERE COALESCE(parent_video_id, '') != ''
+		)
+		DELETE FROM videos WHERE id IN (
+			SELECT id FROM prunable
+			WHERE id NOT IN (SELECT id FROM held)
+				AND COALESCE(parent_video_id, '') NOT IN (SELECT id FROM held)
+		)`,
+		string(domain.VideoStatusCompleted), string(domain.VideoStatusFailed), cutoff, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
 // UpdateFilePath updates local file path.
-func (r *VideoRepository) UpdateFilePath(id string, filePath string) error {
-	_, err := r.db.Exec(`UPDATE videos SET local_file_path = ?, updated_at = ? WHERE id = ?`,
+func (r *VideoRepository) UpdateFilePath(ctx context.Context, id string, filePath string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET local_file_path = ?, updated_at = ? WHERE id = ?`,
 		filePath, time.Now().UTC(), id)
 	return err
 }
 
+// MarkDownloaded stores the file path and download timing and sets the status to downloaded.
+func (r *VideoRepository) MarkDownloaded(ctx context.Context, id string, filePath string, downloadedAt time.Time, duration time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET local_file_path = ?, downloaded_at = ?, download_duration_ms = ?,
+		status = ?, error_message = '', updated_at = ? WHERE id = ?`,
+		filePath, downloadedAt.UTC(), duration.Milliseconds(), string(domain.VideoStatusDownloaded), time.Now().UTC(), id)
+	return err
+}
+
+// MarkUploaded stores the TikTok video ID and upload timing.
+func (r *VideoRepository) MarkUploaded(ctx context.Context, id string, tiktokID string, uploadedAt time.Time, duration time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET tiktok_video_id = ?, uploaded_at = ?, upload_duration_ms = ?, updated_at = ? WHERE id = ?`,
+		tiktokID, uploadedAt.UTC(), duration.Milliseconds(), time.Now().UTC(), id)
+	return err
+}
+
+// UpdateCommentResult records the outcome of the post-publish comment step.
+func (r *VideoRepository) UpdateCommentResult(ctx context.Context, id string, posted bool, errorMsg string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET comment_posted = ?, comment_error = ?, updated_at = ? WHERE id = ?`,
+		boolToInt(posted), errorMsg, time.Now().UTC(), id)
+	return err
+}
+
+// UpdateTranslation caches the title's detected language and its translation.
+func (r *VideoRepository) UpdateTranslation(ctx context.Context, id string, titleLanguage, translatedLanguage, translatedTitle string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET title_language = ?, translated_language = ?, translated_title = ?, updated_at = ? WHERE id = ?`,
+		nullableString(titleLanguage), nullableString(translatedLanguage), nullableString(translatedTitle), time.Now().UTC(), id)
+	return err
+}
+
+// UpdatePublishedAt stores the YouTube publish time.
+func (r *VideoRepository) UpdatePublishedAt(ctx context.Context, id string, publishedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET published_at = ?, updated_at = ? WHERE id = ?`,
+		nullableTime(publishedAt), time.Now().UTC(), id)
+	return err
+}
+
 // UpdateTikTokID updates TikTok video ID.
-func (r *VideoRepository) UpdateTikTokID(id string, tiktokID string) error {
-	_, err := r.db.Exec(`UPDATE videos SET tiktok_video_id = ?, updated_at = ? WHERE id = ?`,
+func (r *VideoRepository) UpdateTikTokID(ctx context.Context, id string, tiktokID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET tiktok_video_id = ?, updated_at = ? WHERE id = ?`,
 		tiktokID, time.Now().UTC(), id)
 	return err
 }
 
+// StartUploadAttempt records a new upload attempt before TikTok is called.
+func (r *VideoRepository) StartUploadAttempt(ctx context.Context, id string, attemptID string, contentHash string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET upload_attempt_id = ?, upload_publish_id = NULL, content_hash = ?, updated_at = ? WHERE id = ?`,
+		attemptID, nullableString(contentHash), time.Now().UTC(), id)
+	return err
+}
+
+// SetUploadPublishID stores the publish ID of the attempt, unless a newer attempt has started.
+func (r *VideoRepository) SetUploadPublishID(ctx context.Context, id string, attemptID string, publishID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET upload_publish_id = ?, updated_at = ? WHERE id = ? AND upload_attempt_id = ?`,
+		publishID, time.Now().UTC(), id, attemptID)
+	return err
+}
+
+// SetUploadRoute records the upload path chosen for the video and why.
+func (r *VideoRepository) SetUploadRoute(ctx context.Context, id string, path domain.UploadPath, reason string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET upload_route = ?, upload_route_reason = ?, updated_at = ? WHERE id = ?`,
+		nullableString(string(path)), nullableString(reason), time.Now().UTC(), id)
+	return err
+}
+
+// AddCost adds to the video's cost counters.
+func (r *VideoRepository) AddCost(ctx context.Context, id string, cost domain.VideoCost) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE videos SET
+		cost_api_units = cost_api_units + ?,
+		cost_download_bytes = cost_download_bytes + ?,
+		cost_upload_bytes = cost_upload_bytes + ?,
+		cost_processing_ms = cost_processing_ms + ?,
+		cost_retries = cost_retries + ?
+		WHERE id = ?`,
+		cost.APIUnits, cost.DownloadBytes, cost.UploadBytes, cost.ProcessingTime.Milliseconds(), cost.Retries, id)
+	return err
+}
+
+// GetAccountUsage sums the costs of an account's videos created in [from, to).
+func (r *VideoRepository) GetAccountUsage(ctx context.Context, accountID string, from, to time.Time) (*domain.AccountUsage, error) {
+	var (
+		usage   domain.AccountUsage
+		costMs  int64
+		fromUTC = from.UTC()
+		toUTC   = to.UTC()
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT
+			COALESCE(SUM(CASE WHEN clip_count = 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN clip_count = 0 AND status = ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(cost_api_units), 0),
+			COALESCE(SUM(cost_download_bytes), 0),
+			COALESCE(SUM(cost_upload_bytes), 0),
+			COALESCE(SUM(cost_processing_ms), 0),
+			COALESCE(SUM(cost_retries), 0)
+		FROM videos WHERE account_id = ? AND created_at >= ? AND created_at < ?`,
+		string(domain.VideoStatusCompleted), accountID, fromUTC, toUTC).
+		Scan(&usage.Videos, &usage.Completed, &usage.Cost.APIUnits, &usage.Cost.DownloadBytes,
+			&usage.Cost.UploadBytes, &costMs, &usage.Cost.Retries)
+	if err != nil {
+		return nil, err
+	}
+	usage.Cost.ProcessingTime = time.Duration(costMs) * time.Millisecond
+	return &usage, nil
+}
+
+// FindCompletedByContentHash returns the account's latest completed video with the same file hash.
+func (r *VideoRepository) FindCompletedByContentHash(ctx context.Context, accountID string, contentHash string, excludeID string) (*domain.Video, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+videoColumns+` FROM videos
+		WHERE account_id = ? AND content_hash = ? AND id != ? AND status = ?
+		ORDER BY completed_at DESC LIMIT 1`,
+		accountID, contentHash, excludeID, string(domain.VideoStatusCompleted))
+	return scanVideo(row)
+}
+
 func scanVideo(scanner interface {
 	Scan(dest ...any) error
 }) (*domain.Video, error) {
 	var video domain.Video
 	var (
-		thumbnail sql.NullString
-		videoURL  sql.NullString
-		localPath sql.NullString
-		errorMsg  sql.NullString
-		tiktokID  sql.NullString
-		published sql.NullTime
+		thumbnail  sql.NullString
+		videoURL   sql.NullString
+		localPath  sql.NullString
+		errorMsg   sql.NullString
+		tiktokID   sql.NullString
+		published  sql.NullTime
+		commented  int
+		commentErr sql.NullString
+		completed  sql.NullTime
+		parentID   sql.NullString
+		clipStart  int64
+		clipEnd    int64
+		downloaded sql.NullTime
+		uploaded   sql.NullTime
+		downloadMs int64
+		uploadMs   int64
+		titleLang  sql.NullString
+		translated sql.NullString
+		targetLang sql.NullString
+		attemptID  sql.NullString
+		publishID  sql.NullString
+		hash       sql.NullString
+		immediate  int
+		route      sql.NullString
+		reason     sql.NullString
+		costMs     int64
+		safety     string
+		options    sql.NullString
+		viewsAt    sql.NullTime
 	)
 
 	if err := scanner.Scan(
@@ -145,6 +833,42 @@ func scanVideo(scanner interface {
 		&video.CreatedAt,
 		&video.UpdatedAt,
 		&published,
+		&commented,
+		&commentErr,
+		&completed,
+		&parentID,
+		&clipStart,
+		&clipEnd,
+		&video.ClipCount,
+		&downloaded,
+		&uploaded,
+		&downloadMs,
+		&uploadMs,
+		&titleLang,
+		&translated,
+		&targetLang,
+		&attemptID,
+		&publishID,
+		&hash,
+		&immediate,
+		&route,
+		&reason,
+		&video.Cost.APIUnits,
+		&video.Cost.DownloadBytes,
+		&video.Cost.UploadBytes,
+		&costMs,
+		&video.Cost.Retries,
+		&video.Priority,
+		&safety,
+		&video.SafetyRule,
+		&video.TikTokPostURL,
+		&options,
+		&video.ViewCount,
+		&viewsAt,
+		&video.DownloadWidth,
+		&video.DownloadHeight,
+		&video.DownloadFPS,
+		&video.Progress,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -170,6 +894,57 @@ func scanVideo(scanner interface {
 	if published.Valid {
 		video.PublishedAt = published.Time
 	}
+	video.CommentPosted = commented == 1
+	video.Immediate = immediate == 1
+	video.SafetyDecision = domain.SafetyDecision(safety)
+	if commentErr.Valid {
+		video.CommentError = commentErr.String
+	}
+	if completed.Valid {
+		video.CompletedAt = completed.Time
+	}
+	if parentID.Valid {
+		video.ParentVideoID = parentID.String
+	}
+	video.ClipStart = time.Duration(clipStart) * time.Millisecond
+	video.ClipEnd = time.Duration(clipEnd) * time.Millisecond
+	if downloaded.Valid {
+		video.DownloadedAt = downloaded.Time
+	}
+	if uploaded.Valid {
+		video.UploadedAt = uploaded.Time
+	}
+	video.DownloadDuration = time.Duration(downloadMs) * time.Millisecond
+	video.UploadDuration = time.Duration(uploadMs) * time.Millisecond
+	video.TitleLanguage = titleLang.String
+	video.TranslatedTitle = translated.String
+	video.TranslatedLanguage = targetLang.String
+	video.UploadAttemptID = attemptID.String
+	video.UploadPublishID = publishID.String
+	video.ContentHash = hash.String
+	video.UploadRoute = domain.UploadPath(route.String)
+	video.UploadRouteReason = reason.String
+	video.Cost.ProcessingTime = time.Duration(costMs) * time.Millisecond
+	if viewsAt.Valid {
+		video.ViewsCheckedAt = viewsAt.Time
+	}
+	if options.Valid && options.String != "" {
+		if err := json.Unmarshal([]byte(options.String), &video.PostOptions); err != nil {
+			return nil, fmt.Errorf("decode post options of video %s: %w", video.ID, err)
+		}
+	}
 
 	return &video, nil
 }
+
+// encodePostOptions returns the post_options column value; no overrides are stored as NULL
+func encodePostOptions(options *domain.PostOptions) (sql.NullString, error) {
+	if options.IsZero() {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("encode post options: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
@@ -0,0 +1,492 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	sqliterepo "auto_upload_tiktok/internal/repository/sqlite"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+)
+
+// claimConcurrently has workers goroutines claim batches of limit until no pending video is left
+// and returns how often each video was claimed
+func claimConcurrently(t *testing.T, repos []domain.VideoRepository, workers, limit int, lease time.Duration) map[string]int {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		claims = make(map[string]int)
+		wg     sync.WaitGroup
+	)
+	for i := range workers {
+		wg.Add(1)
+		go func(owner string, repo domain.VideoRepository) {
+			defer wg.Done()
+			for {
+				videos, err := repo.ClaimPending(context.Background(), owner, limit, lease)
+				if err != nil {
+					t.Errorf("ClaimPending(%s) error = %v", owner, err)
+					return
+				}
+				if len(videos) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, video := range videos {
+					claims[video.ID]++
+				}
+				mu.Unlock()
+				// Finished videos leave pending, as processing would do
+				for _, video := range videos {
+					if err := repo.UpdateStatus(context.Background(), video.ID, domain.VideoStatusCompleted, ""); err != nil {
+						t.Errorf("UpdateStatus(%s) error = %v", video.ID, err)
+					}
+				}
+			}
+		}(fmt.Sprintf("worker-%d", i), repos[i%len(repos)])
+	}
+	wg.Wait()
+	return claims
+}
+
+func assertClaimedOnce(t *testing.T, claims map[string]int, n int) {
+	t.Helper()
+	if len(claims) != n {
+		t.Errorf("%d videos claimed, want %d", len(claims), n)
+	}
+	for id, count := range claims {
+		if count != 1 {
+			t.Errorf("video %s claimed %d times, want once", id, count)
+		}
+	}
+}
+
+func TestVideoRepositoryClaimPendingConcurrent(t *testing.T) {
+	const videos = 60
+
+	t.Run("one database handle", func(t *testing.T) {
+		repos := sqlitetest.OpenTest(t)
+		saveTestAccount(t, repos.Accounts, "acc-1")
+		for i := range videos {
+			saveTestVideo(t, repos.Videos, &domain.Video{ID: fmt.Sprintf("v%02d", i), AccountID: "acc-1"})
+		}
+
+		claims := claimConcurrently(t, []domain.VideoRepository{repos.Videos}, 10, 4, time.Minute)
+		assertClaimedOnce(t, claims, videos)
+	})
+
+	// Two handles on one file behave like two processes sharing the database
+	t.Run("two database handles", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "claims.db")
+		var handles []domain.VideoRepository
+		for range 2 {
+			db, err := sqliterepo.Open(path)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			handles = append(handles, sqliterepo.NewVideoRepository(db))
+		}
+		db, err := sqliterepo.Open(path)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		saveTestAccount(t, sqliterepo.NewAccountRepository(db), "acc-1")
+		for i := range videos {
+			saveTestVideo(t, handles[0], &domain.Video{ID: fmt.Sprintf("v%02d", i), AccountID: "acc-1"})
+		}
+
+		claims := claimConcurrently(t, handles, 10, 4, time.Minute)
+		assertClaimedOnce(t, claims, videos)
+	})
+}
+
+func TestVideoRepositoryClaimLease(t *testing.T) {
+	repos := sqlitetest.OpenTest(t)
+	saveTestAccount(t, repos.Accounts, "acc-1")
+	for i := range 3 {
+		saveTestVideo(t, repos.Videos, &domain.Video{ID: fmt.Sprintf("v%d", i), AccountID: "acc-1"})
+	}
+	ctx := context.Background()
+
+	first, err := repos.Videos.ClaimPending(ctx, "host-a", 10, 100*time.Millisecond)
+	if err != nil || len(first) != 3 {
+		t.Fatalf("ClaimPending(host-a) = %d videos, %v; want 3", len(first), err)
+	}
+	if got, err := repos.Videos.ClaimPending(ctx, "host-b", 10, time.Minute); err != nil || len(got) != 0 {
+		t.Fatalf("ClaimPending(host-b) while leased = %d videos, %v; want none", len(got), err)
+	}
+	// The owner renews its own claims
+	if got, err := repos.Videos.ClaimPending(ctx, "host-a", 10, 100*time.Millisecond); err != nil || len(got) != 3 {
+		t.Fatalf("ClaimPending(host-a) again = %d videos, %v; want 3", len(got), err)
+	}
+
+	// A released claim is free at once, the others once their lease ends
+	if err := repos.Videos.ReleaseClaim(ctx, "v0", "host-b"); err != nil {
+		t.Fatalf("ReleaseClaim() error = %v", err)
+	}
+	if got, _ := repos.Videos.ClaimPending(ctx, "host-b", 10, time.Minute); len(got) != 0 {
+		t.Fatalf("ReleaseClaim() by another owner freed %d videos", len(got))
+	}
+	if err := repos.Videos.ReleaseClaim(ctx, "v0", "host-a"); err != nil {
+		t.Fatalf("ReleaseClaim() error = %v", err)
+	}
+	if got, _ := repos.Videos.ClaimPending(ctx, "host-b", 10, time.Minute); len(got) != 1 || got[0].ID != "v0" {
+		t.Fatalf("ClaimPending(host-b) after release = %v, want v0", videoIDs(got))
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	got, err := repos.Videos.ClaimPending(ctx, "host-c", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimPending(host-c) error = %v", err)
+	}
+	if ids := videoIDs(got); len(ids) != 2 || ids[0] == "v0" || ids[1] == "v0" {
+		t.Fatalf("ClaimPending(host-c) after the lease = %v, want v1 and v2", ids)
+	}
+}
+
+func videoIDs(videos []*domain.Video) []string {
+	ids := make([]string, 0, len(videos))
+	for _, video := range videos {
+		ids = append(ids, video.ID)
+	}
+	return ids
+}
+
+func TestVideoRepositoryPendingOrder(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		videos  []*domain.Video
+		limit   int
+		wantIDs []string
+	}{
+		{
+			name: "newest published first",
+			videos: []*domain.Video{
+				{ID: "old", PublishedAt: base},
+				{ID: "new", PublishedAt: base.Add(time.Hour)},
+			},
+			limit:   10,
+			wantIDs: []string{"new", "old"},
+		},
+		{
+			name: "priority before publish time",
+			videos: []*domain.Video{
+				{ID: "new", PublishedAt: base.Add(time.Hour)},
+				{ID: "raised", PublishedAt: base, Priority: 5},
+			},
+			limit:   10,
+			wantIDs: []string{"raised", "new"},
+		},
+		{
+			name: "immediate before priority",
+			videos: []*domain.Video{
+				{ID: "raised", PublishedAt: base, Priority: 5},
+				{ID: "now", PublishedAt: base, Immediate: true},
+			},
+			limit:   10,
+			wantIDs: []string{"now", "raised"},
+		},
+		{
+			name: "unknown publish time last",
+			videos: []*domain.Video{
+				{ID: "unknown"},
+				{ID: "known", PublishedAt: base},
+			},
+			limit:   10,
+			wantIDs: []string{"known", "unknown"},
+		},
+		{
+			name: "other statuses and split sources skipped",
+			videos: []*domain.Video{
+				{ID: "done", Status: domain.VideoStatusCompleted, PublishedAt: base},
+				{ID: "split", PublishedAt: base, ClipCount: 2},
+				{ID: "queued", PublishedAt: base},
+			},
+			limit:   10,
+			wantIDs: []string{"queued"},
+		},
+		{
+			name: "limit",
+			videos: []*domain.Video{
+				{ID: "a", PublishedAt: base},
+				{ID: "b", PublishedAt: base.Add(time.Minute)},
+				{ID: "c", PublishedAt: base.Add(2 * time.Minute)},
+			},
+			limit:   2,
+			wantIDs: []string{"c", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := sqlitetest.OpenTest(t)
+			saveTestAccount(t, repos.Accounts, "acc-1")
+			for _, video := range tt.videos {
+				video.AccountID = "acc-1"
+				saveTestVideo(t, repos.Videos, video)
+			}
+			pending, err := repos.Videos.GetPendingVideos(context.Background(), tt.limit)
+			if err != nil {
+				t.Fatalf("GetPendingVideos() error = %v", err)
+			}
+			if got := videoIDs(pending); fmt.Sprint(got) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("GetPendingVideos() = %v, want %v", got, tt.wantIDs)
+			}
+		})
+	}
+}
+
+// TestVideoRepositoryPendingTies stores 50 videos discovered in one cycle, all with the same
+// timestamps, and drains them in limited batches: every video comes back exactly once
+func TestVideoRepositoryPendingTies(t *testing.T) {
+	const videos, batch = 50, 7
+	// Sub-second part survives the round trip, so it cannot separate videos saved together
+	at := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)
+	ctx := context.Background()
+
+	drains := map[string]func(repo domain.VideoRepository, round int) ([]*domain.Video, error){
+		"GetPendingVideos": func(repo domain.VideoRepository, round int) ([]*domain.Video, error) {
+			pending, err := repo.GetPendingVideos(ctx, batch)
+			if err != nil {
+				return nil, err
+			}
+			again, err := repo.GetPendingVideos(ctx, batch)
+			if err != nil {
+				return nil, err
+			}
+			if fmt.Sprint(videoIDs(again)) != fmt.Sprint(videoIDs(pending)) {
+				t.Errorf("round %d: GetPendingVideos() = %v then %v", round, videoIDs(pending), videoIDs(again))
+			}
+			for _, video := range pending {
+				if err := repo.UpdateStatus(ctx, video.ID, domain.VideoStatusDownloading, ""); err != nil {
+					return nil, err
+				}
+			}
+			return pending, nil
+		},
+		"ClaimPending": func(repo domain.VideoRepository, round int) ([]*domain.Video, error) {
+			return repo.ClaimPending(ctx, fmt.Sprintf("worker-%d", round), batch, time.Hour)
+		},
+	}
+	for name, drain := range drains {
+		t.Run(name, func(t *testing.T) {
+			repos := sqlitetest.OpenTest(t)
+			saveTestAccount(t, repos.Accounts, "acc-1")
+			// Saved in reverse so insertion order cannot stand in for the ID tiebreaker
+			for i := videos - 1; i >= 0; i-- {
+				saveTestVideo(t, repos.Videos, &domain.Video{
+					ID: fmt.Sprintf("v%02d", i), AccountID: "acc-1", PublishedAt: at, CreatedAt: at,
+				})
+			}
+			stored, err := repos.Videos.GetByID(ctx, "v00")
+			if err != nil || stored == nil || !stored.CreatedAt.Equal(at) {
+				t.Fatalf("GetByID() = %+v, %v; want created_at %v", stored, err, at)
+			}
+
+			seen := make(map[string]bool)
+			var order []string
+			for round := 0; ; round++ {
+				got, err := drain(repos.Videos, round)
+				if err != nil {
+					t.Fatalf("round %d: %v", round, err)
+				}
+				if len(got) == 0 {
+					break
+				}
+				for _, video := range got {
+					if seen[video.ID] {
+						t.Errorf("round %d: %s returned again", round, video.ID)
+					}
+					seen[video.ID] = true
+					order = append(order, video.ID)
+				}
+			}
+			if len(seen) != videos {
+				t.Errorf("%d videos returned, want %d", len(seen), videos)
+			}
+			for i, id := range order {
+				if want := fmt.Sprintf("v%02d", i); id != want {
+					t.Fatalf("video %d = %s, want %s in ID order", i, id, want)
+				}
+			}
+		})
+	}
+}
+
+func TestVideoRepositoryUpdates(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		update func(ctx context.Context, videos domain.VideoRepository) error
+		check  func(t *testing.T, video *domain.Video)
+	}{
+		{
+			name: "failed with a message",
+			update: func(ctx context.Context, videos domain.VideoRepository) error {
+				return videos.UpdateStatus(ctx, "v1", domain.VideoStatusFailed, "boom")
+			},
+			check: func(t *testing.T, video *domain.Video) {
+				if video.Status != domain.VideoStatusFailed || video.ErrorMessage != "boom" || !video.CompletedAt.IsZero() {
+					t.Errorf("status = %s %q completed %v", video.Status, video.ErrorMessage, video.CompletedAt)
+				}
+			},
+		},
+		{
+			name: "completed stamps completion",
+			update: func(ctx context.Context, videos domain.VideoRepository) error {
+				return videos.UpdateStatus(ctx, "v1", domain.VideoStatusCompleted, "")
+			},
+			check: func(t *testing.T, video *domain.Video) {
+				if video.Status != domain.VideoStatusCompleted || video.CompletedAt.IsZero() {
+					t.Errorf("status = %s completed %v", video.Status, video.CompletedAt)
+				}
+			},
+		},
+		{
+			name: "status resets progress",
+			update: func(ctx context.Context, videos domain.VideoRepository) error {
+				if err := videos.UpdateProgress(ctx, "v1", 40); err != nil {
+					return err
+				}
+				return videos.UpdateStatus(ctx, "v1", domain.VideoStatusUploading, "")
+			},
+			check: func(t *testing.T, video *domain.Video) {
+				if video.Progress != 0 {
+					t.Errorf("Progress = %d, want 0", video.Progress)
+				}
+			},
+		},
+		{
+			name: "downloaded",
+			update: func(ctx context.Context, videos domain.VideoRepository) error {
+				return videos.MarkDownloaded(ctx, "v1", "/tmp/v1.mp4", at, 3*time.Second)
+			},
+			check: func(t *testing.T, video *domain.Video) {
+				if video.Status != domain.VideoStatusDownloaded || video.LocalFilePath != "/tmp/v1.mp4" ||
+					!video.DownloadedAt.Equal(at) || video.DownloadDuration != 3*time.Second {
+					t.Errorf("download = %s %q %v %s", video.Status, video.LocalFilePath, video.DownloadedAt, video.DownloadDuration)
+				}
+			},
+		},
+		{
+			name: "uploaded keeps the status",
+			update: func(ctx context.Context, videos domain.VideoRepository) error {
+				return videos.MarkUploaded(ctx, "v1", "7300000000000000001", at, 2*time.Second)
+			},
+			check: func(t *testing.T, video *domain.Video) {
+				if video.Status != domain.VideoStatusPending || video.TikTokVideoID != "7300000000000000001" ||
+					!video.UploadedAt.Equal(at) || video.UploadDuration != 2*time.Second {
+					t.Errorf("upload = %s %q %v %s", video.Status, video.TikTokVideoID, video.UploadedAt, video.UploadDuration)
+				}
+			},
+		},
+		{
+			name: "priority",
+			update: func(ctx context.Context, videos domain.VideoRepository) error {
+				return videos.UpdatePriority(ctx, "v1", 7)
+			},
+			check: func(t *testing.T, video *domain.Video) {
+				if video.Priority != 7 {
+					t.Errorf("Priority = %d, want 7", video.Priority)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := sqlitetest.OpenTest(t)
+			ctx := context.Background()
+			saveTestAccount(t, repos.Accounts, "acc-1")
+			saveTestVideo(t, repos.Videos, &domain.Video{ID: "v1", AccountID: "acc-1"})
+			if err := tt.update(ctx, repos.Videos); err != nil {
+				t.Fatalf("update error = %v", err)
+			}
+			video, err := repos.Videos.GetByID(ctx, "v1")
+			if err != nil || video == nil {
+				t.Fatalf("GetByID() = %v, %v", video, err)
+			}
+			tt.check(t, video)
+		})
+	}
+}
+
+func TestVideoRepositoryLookupsAndCounts(t *testing.T) {
+	repos := sqlitetest.OpenTest(t)
+	ctx := context.Background()
+	saveTestAccount(t, repos.Accounts, "acc-1")
+	saveTestAccount(t, repos.Accounts, "acc-2")
+	saveTestVideo(t, repos.Videos, &domain.Video{ID: "v1", AccountID: "acc-1", YouTubeVideoID: "yt-shared"})
+	saveTestVideo(t, repos.Videos, &domain.Video{ID: "v2", AccountID: "acc-2", YouTubeVideoID: "yt-shared"})
+	saveTestVideo(t, repos.Videos, &domain.Video{ID: "v3", AccountID: "acc-1", Status: domain.VideoStatusFailed})
+	saveTestVideo(t, repos.Videos, &domain.Video{ID: "v4", AccountID: "acc-1", Status: domain.VideoStatusFailed})
+
+	lookups := []struct {
+		name               string
+		youtubeID, account string
+		wantID             string
+	}{
+		{"first account's copy", "yt-shared", "acc-1", "v1"},
+		{"second account's copy", "yt-shared", "acc-2", "v2"},
+		{"not uploaded by the account", "yt-v3", "acc-2", ""},
+		{"unknown video", "yt-none", "acc-1", ""},
+	}
+	for _, tt := range lookups {
+		t.Run(tt.name, func(t *testing.T) {
+			video, err := repos.Videos.GetByYouTubeIDAndAccount(ctx, tt.youtubeID, tt.account)
+			if err != nil {
+				t.Fatalf("GetByYouTubeIDAndAccount() error = %v", err)
+			}
+			gotID := ""
+			if video != nil {
+				gotID = video.ID
+			}
+			if gotID != tt.wantID {
+				t.Errorf("GetByYouTubeIDAndAccount() = %q, want %q", gotID, tt.wantID)
+			}
+		})
+	}
+
+	counts, err := repos.Videos.CountByStatus(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("CountByStatus() error = %v", err)
+	}
+	want := map[domain.VideoStatus]int{domain.VideoStatusPending: 1, domain.VideoStatusFailed: 2}
+	if fmt.Sprint(counts) != fmt.Sprint(want) {
+		t.Errorf("CountByStatus() = %v, want %v", counts, want)
+	}
+}
+
+func TestVideoRepositoryCancelledContext(t *testing.T) {
+	repos := sqlitetest.OpenTest(t)
+	saveTestAccount(t, repos.Accounts, "a")
+	video := saveTestVideo(t, repos.Videos, &domain.Video{ID: "v1", AccountID: "a"})
+	queries := map[string]func(ctx context.Context) error{
+		"GetByID": func(ctx context.Context) error {
+			_, err := repos.Videos.GetByID(ctx, "v1")
+			return err
+		},
+		"GetPendingVideos": func(ctx context.Context) error {
+			_, err := repos.Videos.GetPendingVideos(ctx, 10)
+			return err
+		},
+		"ClaimPending": func(ctx context.Context) error {
+			_, err := repos.Videos.ClaimPending(ctx, "worker-a", 10, time.Minute)
+			return err
+		},
+		"Iterate": func(ctx context.Context) error {
+			return repos.Videos.Iterate(ctx, domain.VideoFilter{}, "", func(*domain.Video) error { return nil })
+		},
+		"Save": func(ctx context.Context) error {
+			return repos.Videos.Save(ctx, video)
+		},
+	}
+	for name, query := range queries {
+		checkAborts(t, repos, name, query)
+	}
+}
@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// WebSessionRepository is a SQLite implementation of domain.WebSessionRepository.
+type WebSessionRepository struct {
+	db *sql.DB
+}
+
+// NewWebSessionRepository creates a new WebSessionRepository backed by SQLite.
+func NewWebSessionRepository(db *sql.DB) *WebSessionRepository {
+	return &WebSessionRepository{db: db}
+}
+
+// Get returns the session of a cookies file.
+func (r *WebSessionRepository) Get(ctx context.Context, cookiesPath string) (*domain.WebSession, error) {
+	var (
+		session   domain.WebSession
+		accountID sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT cookies_path, user_id, username, nickname, account_id, captured_at
+		FROM web_sessions WHERE cookies_path = ?`, cookiesPath).
+		Scan(&session.CookiesPath, &session.UserID, &session.Username, &session.Nickname, &accountID, &session.CapturedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	session.AccountID = accountID.String
+	return &session, nil
+}
+
+// Save records the session of a cookies file, replacing the earlier one.
+func (r *WebSessionRepository) Save(ctx context.Context, session *domain.WebSession) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO web_sessions (cookies_path, user_id, username, nickname, account_id, captured_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(cookies_path) DO UPDATE SET
+			user_id = excluded.user_id,
+			username = excluded.username,
+			nickname = excluded.nickname,
+			account_id = excluded.account_id,
+			captured_at = excluded.captured_at`,
+		session.CookiesPath, session.UserID, session.Username, session.Nickname,
+		nullableString(session.AccountID), session.CapturedAt.UTC())
+	return err
+}
+
+// GetClaim returns the claim of a cookies file.
+func (r *WebSessionRepository) GetClaim(ctx context.Context, cookiesPath string) (*domain.WebSessionClaim, error) {
+	return getWebSessionClaim(ctx, r.db, cookiesPath)
+}
+
+// Claim records that host uses the cookies file at now. The first statement writes, which takes
+// the database's write lock, so the claim read after it cannot change before it is replaced,
+// even from another process sharing the database.
+func (r *WebSessionRepository) Claim(ctx context.Context, cookiesPath, host string, now, since time.Time, takeOver bool) (*domain.WebSessionClaim, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO web_session_claims (cookies_path, host, used_at) VALUES (?, ?, ?)
+		ON CONFLICT(cookies_path) DO NOTHING`, cookiesPath, host, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	if inserted, _ := result.RowsAffected(); inserted == 1 {
+		return nil, tx.Commit()
+	}
+
+	previous, err := getWebSessionClaim(ctx, tx, cookiesPath)
+	if err != nil {
+		return nil, err
+	}
+	conflict := previous.Host != host && previous.UsedAt.After(since)
+	switch {
+	case conflict && !takeOver:
+		_, err = tx.ExecContext(ctx, `UPDATE web_session_claims SET conflict_host = ?, conflict_at = ? WHERE cookies_path = ?`,
+			host, now.UTC(), cookiesPath)
+	case conflict:
+		_, err = tx.ExecContext(ctx, `UPDATE web_session_claims SET host = ?, used_at = ?, conflict_host = ?, conflict_at = ? WHERE cookies_path = ?`,
+			host, now.UTC(), previous.Host, now.UTC(), cookiesPath)
+	default:
+		_, err = tx.ExecContext(ctx, `UPDATE web_session_claims SET host = ?, used_at = ? WHERE cookies_path = ?`,
+			host, now.UTC(), cookiesPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return previous, nil
+}
+
+// getWebSessionClaim reads the claim of a cookies file, or nil when there is none
+func getWebSessionClaim(ctx context.Context, db dbtx, cookiesPath string) (*domain.WebSessionClaim, error) {
+	var (
+		claim      domain.WebSessionClaim
+		conflictAt sql.NullTime
+	)
+	err := db.QueryRowContext(ctx, `SELECT cookies_path, host, used_at, conflict_host, conflict_at
+		FROM web_session_claims WHERE cookies_path = ?`, cookiesPath).
+		Scan(&claim.CookiesPath, &claim.Host, &claim.UsedAt, &claim.ConflictHost, &conflictAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if conflictAt.Valid {
+		claim.ConflictAt = conflictAt.Time
+	}
+	return &claim, nil
+}
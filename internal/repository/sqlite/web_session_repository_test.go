@@ -0,0 +1,133 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	sqliterepo "auto_upload_tiktok/internal/repository/sqlite"
+)
+
+// TestWebSessionRepositoryClaimConcurrent has two hosts, each with its own handle on the database
+// file, claim the same cookies at once. One of them gets the claim and the other sees it and is
+// recorded as the conflicting host, whichever wins.
+func TestWebSessionRepositoryClaimConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claims.db")
+	hosts := []string{"host-a", "host-b"}
+	repos := make([]*sqliterepo.WebSessionRepository, len(hosts))
+	for i := range hosts {
+		db, err := sqliterepo.Open(path)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		repos[i] = sqliterepo.NewWebSessionRepository(db)
+	}
+	ctx := context.Background()
+
+	for round := range 20 {
+		cookiesPath := fmt.Sprintf("/state/cookies-%d.json", round)
+		now := time.Now()
+		previous := make([]string, len(hosts)) // host of the claim each one saw, empty for none
+		var wg sync.WaitGroup
+		for i, host := range hosts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				claim, err := repos[i].Claim(ctx, cookiesPath, host, now, now.Add(-30*time.Minute), false)
+				if err != nil {
+					t.Errorf("Claim(%s) error = %v", host, err)
+					return
+				}
+				if claim != nil {
+					previous[i] = claim.Host
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Exactly one host found the cookies unclaimed; the other saw its claim
+		var winner, loser string
+		switch {
+		case previous[0] == "" && previous[1] == "host-a":
+			winner, loser = "host-a", "host-b"
+		case previous[1] == "" && previous[0] == "host-b":
+			winner, loser = "host-b", "host-a"
+		default:
+			t.Fatalf("round %d: hosts saw previous claims %q, want one none and one by the other host", round, previous)
+		}
+
+		claim, err := repos[0].GetClaim(ctx, cookiesPath)
+		if err != nil || claim == nil {
+			t.Fatalf("GetClaim() = %v, %v", claim, err)
+		}
+		if claim.Host != winner || claim.ConflictHost != loser || claim.ConflictAt.IsZero() {
+			t.Errorf("round %d: claim = %s, conflict %q at %v; want %s, conflict %s", round,
+				claim.Host, claim.ConflictHost, claim.ConflictAt, winner, loser)
+		}
+	}
+}
+
+func TestWebSessionRepositoryClaim(t *testing.T) {
+	db, err := sqliterepo.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	repo := sqliterepo.NewWebSessionRepository(db)
+	ctx := context.Background()
+	const path = "/state/cookies.json"
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	window := 30 * time.Minute
+
+	tests := []struct {
+		name         string
+		host         string
+		at           time.Duration // after start
+		takeOver     bool
+		wantPrevious string // host of the returned claim, empty for none
+		wantHost     string
+		wantConflict string
+	}{
+		{name: "first use", host: "host-a", wantHost: "host-a"},
+		{name: "same host again", host: "host-a", at: 5 * time.Minute, wantPrevious: "host-a", wantHost: "host-a"},
+		{name: "other host within the window", host: "host-b", at: 10 * time.Minute,
+			wantPrevious: "host-a", wantHost: "host-a", wantConflict: "host-b"},
+		{name: "other host takes over", host: "host-b", at: 11 * time.Minute, takeOver: true,
+			wantPrevious: "host-a", wantHost: "host-b", wantConflict: "host-a"},
+		{name: "other host after the window", host: "host-a", at: 50 * time.Minute,
+			wantPrevious: "host-b", wantHost: "host-a", wantConflict: "host-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start.Add(tt.at)
+			previous, err := repo.Claim(ctx, path, tt.host, now, now.Add(-window), tt.takeOver)
+			if err != nil {
+				t.Fatalf("Claim() error = %v", err)
+			}
+			gotPrevious := ""
+			if previous != nil {
+				gotPrevious = previous.Host
+			}
+			if gotPrevious != tt.wantPrevious {
+				t.Errorf("Claim() previous host = %q, want %q", gotPrevious, tt.wantPrevious)
+			}
+
+			claim, err := repo.GetClaim(ctx, path)
+			if err != nil {
+				t.Fatalf("GetClaim() error = %v", err)
+			}
+			// A conflict is kept on record after the claim moves on
+			if claim.Host != tt.wantHost || claim.ConflictHost != tt.wantConflict {
+				t.Errorf("claim = %s, conflict %q; want %s, conflict %q", claim.Host, claim.ConflictHost, tt.wantHost, tt.wantConflict)
+			}
+		})
+	}
+
+	if claim, err := repo.GetClaim(ctx, "/state/other.json"); claim != nil || err != nil {
+		t.Errorf("GetClaim() of unused cookies = %v, %v; want nil", claim, err)
+	}
+}
@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// YouTubePlaylistRepository is a SQLite implementation of domain.YouTubePlaylistRepository.
+type YouTubePlaylistRepository struct {
+	db *sql.DB
+}
+
+// NewYouTubePlaylistRepository creates a new YouTubePlaylistRepository backed by SQLite.
+func NewYouTubePlaylistRepository(db *sql.DB) *YouTubePlaylistRepository {
+	return &YouTubePlaylistRepository{db: db}
+}
+
+// Get returns a channel's uploads playlist.
+func (r *YouTubePlaylistRepository) Get(ctx context.Context, channelID string) (*domain.YouTubePlaylist, error) {
+	var (
+		entry        domain.YouTubePlaylist
+		checkedAfter sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT channel_id, uploads_playlist_id, etag, checked_after, updated_at
+		FROM youtube_playlists WHERE channel_id = ?`, channelID).
+		Scan(&entry.ChannelID, &entry.UploadsPlaylistID, &entry.ETag, &checkedAfter, &entry.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if checkedAfter.Valid {
+		entry.CheckedAfter = checkedAfter.Time
+	}
+	return &entry, nil
+}
+
+// Save stores a channel's uploads playlist, replacing the earlier entry.
+func (r *YouTubePlaylistRepository) Save(ctx context.Context, entry *domain.YouTubePlaylist) error {
+	entry.UpdatedAt = time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO youtube_playlists (channel_id, uploads_playlist_id, etag, checked_after, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(channel_id) DO UPDATE SET
+			uploads_playlist_id = excluded.uploads_playlist_id,
+			etag = excluded.etag,
+			checked_after = excluded.checked_after,
+			updated_at = excluded.updated_at`,
+		entry.ChannelID, entry.UploadsPlaylistID, entry.ETag, nullableTime(entry.CheckedAfter), entry.UpdatedAt)
+	return err
+}
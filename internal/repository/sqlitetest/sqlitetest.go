@@ -0,0 +1,79 @@
+// Package sqlitetest opens the SQLite repositories on a private in-memory database, for tests of
+// code that needs real repositories without juggling temporary files.
+package sqlitetest
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+
+	sqliterepo "auto_upload_tiktok/internal/repository/sqlite"
+)
+
+// Repos are the SQLite repositories of one test database
+type Repos struct {
+	DB             *sql.DB
+	Accounts       *sqliterepo.AccountRepository
+	AccountEvents  *sqliterepo.AccountEventRepository
+	Videos         *sqliterepo.VideoRepository
+	Audit          *sqliterepo.AuditRepository
+	ChannelHandles *sqliterepo.ChannelHandleRepository
+	Experiments    *sqliterepo.ExperimentRepository
+	Invites        *sqliterepo.InviteRepository
+	OAuthStates    *sqliterepo.OAuthStateRepository
+	PipelineState  *sqliterepo.PipelineStateRepository
+	TransferUsage  *sqliterepo.TransferUsageRepository
+	WebSessions    *sqliterepo.WebSessionRepository
+	Playlists      *sqliterepo.YouTubePlaylistRepository
+	Transactor     *sqliterepo.Transactor
+}
+
+// OpenTest opens a new in-memory database with the schema applied and returns its repositories.
+// Every call gets its own database, so parallel tests do not see each other's rows; it is closed
+// when the test ends.
+func OpenTest(t testing.TB) *Repos {
+	t.Helper()
+
+	// A private :memory: database lives as long as its connection, which Open never closes
+	db, err := sqliterepo.Open(":memory:")
+	if err != nil {
+		t.Fatalf("open in-memory database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &Repos{
+		DB:             db,
+		Accounts:       sqliterepo.NewAccountRepository(db),
+		AccountEvents:  sqliterepo.NewAccountEventRepository(db),
+		Videos:         sqliterepo.NewVideoRepository(db),
+		Audit:          sqliterepo.NewAuditRepository(db),
+		ChannelHandles: sqliterepo.NewChannelHandleRepository(db),
+		Experiments:    sqliterepo.NewExperimentRepository(db),
+		Invites:        sqliterepo.NewInviteRepository(db),
+		OAuthStates:    sqliterepo.NewOAuthStateRepository(db),
+		PipelineState:  sqliterepo.NewPipelineStateRepository(db),
+		TransferUsage:  sqliterepo.NewTransferUsageRepository(db),
+		WebSessions:    sqliterepo.NewWebSessionRepository(db),
+		Playlists:      sqliterepo.NewYouTubePlaylistRepository(db),
+		Transactor:     sqliterepo.NewTransactor(db),
+	}
+}
+
+// HoldWrite begins a write transaction and keeps it open, as a long pipeline write would, until
+// release is called or the test ends. The database has a single connection, so every other query
+// waits for the transaction meanwhile.
+func (r *Repos) HoldWrite(t testing.TB) (release func()) {
+	t.Helper()
+	tx, err := r.DB.Begin()
+	if err != nil {
+		t.Fatalf("begin write transaction: %v", err)
+	}
+	if _, err := tx.Exec("UPDATE accounts SET id = id WHERE 0"); err != nil {
+		tx.Rollback()
+		t.Fatalf("start write transaction: %v", err)
+	}
+	var once sync.Once
+	release = func() { once.Do(func() { tx.Rollback() }) }
+	t.Cleanup(release)
+	return release
+}
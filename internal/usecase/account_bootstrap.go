@@ -0,0 +1,453 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/redact"
+)
+
+// placeholderToken is stored for bootstrapped accounts that have no token yet
+const placeholderToken = "PLACEHOLDER_TOKEN_UPDATE_VIA_EXCHANGE_CODE_API"
+
+// HasAccessToken reports whether the account has a real access token (not empty or the bootstrap placeholder)
+func HasAccessToken(account *domain.Account) bool {
+	return account.TikTokAccessToken != "" && account.TikTokAccessToken != placeholderToken
+}
+
+// Drift winners
+const (
+	DriftWinnerConfig   = "config"
+	DriftWinnerDatabase = "database"
+)
+
+// Drift statuses
+const (
+	DriftStatusInSync            = "in_sync"
+	DriftStatusDrifted           = "drifted"
+	DriftStatusMissingInDatabase = "missing_in_database"
+	DriftStatusDatabaseOnly      = "database_only"
+	DriftStatusInvalid           = "invalid"
+)
+
+// FieldDrift describes one field that differs between the YAML entry and the database row
+type FieldDrift struct {
+	Field         string `json:"field"`
+	ConfigValue   string `json:"config_value"`
+	DatabaseValue string `json:"database_value"`
+	Winner        string `json:"winner"` // Side that would win on next restart
+	Reason        string `json:"reason,omitempty"`
+}
+
+// AccountDrift is the comparison result for one account
+type AccountDrift struct {
+	AccountID        string       `json:"account_id,omitempty"`
+	YouTubeChannelID string       `json:"youtube_channel_id"`
+	TikTokAccountID  string       `json:"tiktok_account_id"`
+	Status           string       `json:"status"`
+	Fields           []FieldDrift `json:"fields,omitempty"`
+}
+
+// bootstrapPlan is what applying one YAML entry to an existing account would change
+type bootstrapPlan struct {
+	youtubeID string
+	tiktokID  string
+	token     string
+	isActive  *bool
+	settings  *domain.AccountSettings // New settings when the publishing settings differ
+	fields    []FieldDrift
+}
+
+// needsUpdate reports whether the plan changes anything
+func (p *bootstrapPlan) needsUpdate() bool {
+	return p.mappingChanged() || p.settings != nil
+}
+
+// mappingChanged reports whether the plan changes the mapping itself rather than only settings
+func (p *bootstrapPlan) mappingChanged() bool {
+	return p.youtubeID != "" || p.tiktokID != "" || p.token != "" || p.isActive != nil
+}
+
+// AccountBootstrapper applies YAML account entries to the database and reports drift between them
+type AccountBootstrapper struct {
+	accountManager *AccountManager
+	accountRepo    domain.AccountRepository
+}
+
+// NewAccountBootstrapper creates a new account bootstrapper
+func NewAccountBootstrapper(accountManager *AccountManager, accountRepo domain.AccountRepository) *AccountBootstrapper {
+	return &AccountBootstrapper{
+		accountManager: accountManager,
+		accountRepo:    accountRepo,
+	}
+}
+
+// Import results
+const (
+	ImportStatusCreated = "created"
+	ImportStatusUpdated = "updated"
+	ImportStatusSkipped = "skipped" // Invalid for create_only mode, or nothing to change
+	ImportStatusError   = "error"
+)
+
+// ImportResult is the outcome of applying one account entry
+type ImportResult struct {
+	Row              int    `json:"row"` // 1-based position of the entry in the batch
+	AccountID        string `json:"account_id,omitempty"`
+	YouTubeChannelID string `json:"youtube_channel_id"`
+	TikTokAccountID  string `json:"tiktok_account_id"`
+	Status           string `json:"status"`
+	Error            string `json:"error,omitempty"`
+}
+
+// Apply creates missing accounts and, in sync mode, updates existing ones from the YAML entries
+func (b *AccountBootstrapper) Apply(ctx context.Context, entries []config.AccountBootstrap, mode string) {
+	for _, result := range b.Import(ctx, entries, mode) {
+		if result.Status == ImportStatusError {
+			logger.Error().Printf("Skipping bootstrap mapping for channel %s: %s", result.YouTubeChannelID, result.Error)
+		}
+	}
+}
+
+// Import applies account entries the way the YAML bootstrap does and returns one result per
+// entry. A failing entry does not stop the rest of the batch.
+func (b *AccountBootstrapper) Import(ctx context.Context, entries []config.AccountBootstrap, mode string) []ImportResult {
+	results := make([]ImportResult, 0, len(entries))
+	for i, acc := range entries {
+		result := ImportResult{
+			Row:              i + 1,
+			YouTubeChannelID: acc.YouTubeChannelID,
+			TikTokAccountID:  acc.TikTokAccountID,
+		}
+		accountID, status, err := b.apply(ctx, acc, mode)
+		result.AccountID = accountID
+		result.Status = status
+		if err != nil {
+			result.Status = ImportStatusError
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// apply creates or updates the account for one entry and returns its ID and import status
+func (b *AccountBootstrapper) apply(ctx context.Context, acc config.AccountBootstrap, mode string) (string, string, error) {
+	// Validate required fields (token is optional - can be set via exchange-code API)
+	if acc.YouTubeChannelID == "" || acc.TikTokAccountID == "" {
+		return "", "", fmt.Errorf("youtube_channel_id and tiktok_account_id are required")
+	}
+	// Exports carry redacted tokens, which must not replace anything
+	if strings.HasPrefix(acc.TikTokAccessToken, redact.Mask) {
+		acc.TikTokAccessToken = ""
+	}
+
+	// Handles resolve from the stored lookups after the first start
+	ref := acc.YouTubeChannelID
+	channelID, _, err := b.accountManager.ResolveChannel(ctx, ref)
+	if err != nil {
+		return "", "", err
+	}
+	acc.YouTubeChannelID = channelID
+
+	existing, err := b.findExisting(ctx, acc)
+	if err != nil {
+		return "", "", err
+	}
+
+	if existing == nil {
+		accountID, err := b.create(ctx, acc, ref)
+		return accountID, ImportStatusCreated, err
+	}
+
+	if mode == config.BootstrapModeCreateOnly {
+		return existing.ID, ImportStatusSkipped, nil
+	}
+
+	plan := planBootstrapUpdate(acc, existing)
+	if plan.youtubeID != "" {
+		// Keeps the handle the entry names on the account
+		plan.youtubeID = ref
+	}
+	for _, field := range plan.fields {
+		if field.Winner == DriftWinnerDatabase && field.Reason != "" {
+			logger.Info().Printf("Account %s: %s", existing.ID, field.Reason)
+		}
+	}
+	if !plan.needsUpdate() {
+		return existing.ID, ImportStatusSkipped, nil
+	}
+
+	if plan.mappingChanged() {
+		if _, err := b.accountManager.UpdateAccountMapping(ctx, existing.ID, plan.youtubeID, plan.tiktokID, plan.token, plan.isActive); err != nil {
+			return existing.ID, "", fmt.Errorf("update mapping: %w", err)
+		}
+		logger.Info().Printf("Updated bootstrap mapping %s -> %s", existing.YouTubeChannelID, existing.TikTokAccountID)
+	}
+	if plan.settings != nil {
+		if err := b.applySettings(ctx, existing.ID, acc, *plan.settings); err != nil {
+			return existing.ID, "", err
+		}
+	}
+	return existing.ID, ImportStatusUpdated, nil
+}
+
+// applySettings saves publishing settings taken from an entry
+func (b *AccountBootstrapper) applySettings(ctx context.Context, accountID string, acc config.AccountBootstrap, settings domain.AccountSettings) error {
+	if _, err := b.accountManager.UpdateAccountSettings(ctx, accountID, settings); err != nil {
+		return fmt.Errorf("apply publishing settings: %w", err)
+	}
+	logger.Info().Printf("Applied bootstrap publishing settings for channel %s", acc.YouTubeChannelID)
+	return nil
+}
+
+// Export returns every account as a bootstrap entry, the shape Import takes. Access tokens are
+// redacted unless includeTokens is set; the bootstrap placeholder is never exported.
+func (b *AccountBootstrapper) Export(ctx context.Context, includeTokens bool) ([]config.AccountBootstrap, error) {
+	accounts, err := b.accountRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	entries := make([]config.AccountBootstrap, 0, len(accounts))
+	for _, account := range accounts {
+		isActive := account.IsActive
+		postAsDraft := account.Settings.PostAsDraft
+		entry := config.AccountBootstrap{
+			YouTubeChannelID: account.YouTubeChannelID,
+			TikTokAccountID:  account.TikTokAccountID,
+			IsActive:         &isActive,
+			PrivacyLevel:     account.Settings.PrivacyLevel,
+			PostAsDraft:      &postAsDraft,
+		}
+		if HasAccessToken(account) {
+			entry.TikTokAccessToken = redact.Token(account.TikTokAccessToken)
+			if includeTokens {
+				entry.TikTokAccessToken = account.TikTokAccessToken
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// bootstrapSettings returns settings with the entry's publishing fields applied, and whether
+// anything changed
+func bootstrapSettings(acc config.AccountBootstrap, settings domain.AccountSettings) (domain.AccountSettings, bool) {
+	changed := false
+	if level := strings.ToUpper(strings.TrimSpace(acc.PrivacyLevel)); level != "" && level != settings.PrivacyLevel {
+		settings.PrivacyLevel = level
+		changed = true
+	}
+	if acc.PostAsDraft != nil && *acc.PostAsDraft != settings.PostAsDraft {
+		settings.PostAsDraft = *acc.PostAsDraft
+		changed = true
+	}
+	return settings, changed
+}
+
+// Drift compares the YAML entries with the database and reports per-field differences
+// along with which side would win on the next restart
+func (b *AccountBootstrapper) Drift(ctx context.Context, entries []config.AccountBootstrap, mode string) ([]AccountDrift, error) {
+	report := make([]AccountDrift, 0, len(entries))
+	seen := make(map[string]bool)
+
+	for _, acc := range entries {
+		drift := AccountDrift{
+			YouTubeChannelID: acc.YouTubeChannelID,
+			TikTokAccountID:  acc.TikTokAccountID,
+		}
+
+		if acc.YouTubeChannelID == "" || acc.TikTokAccountID == "" {
+			drift.Status = DriftStatusInvalid
+			report = append(report, drift)
+			continue
+		}
+
+		channelID, _, err := b.accountManager.ResolveChannel(ctx, acc.YouTubeChannelID)
+		if err != nil {
+			drift.Status = DriftStatusInvalid
+			report = append(report, drift)
+			continue
+		}
+		acc.YouTubeChannelID = channelID
+
+		existing, err := b.findExisting(ctx, acc)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing == nil {
+			drift.Status = DriftStatusMissingInDatabase
+			report = append(report, drift)
+			continue
+		}
+
+		seen[existing.ID] = true
+		drift.AccountID = existing.ID
+
+		plan := planBootstrapUpdate(acc, existing)
+		if mode == config.BootstrapModeCreateOnly {
+			for i := range plan.fields {
+				plan.fields[i].Winner = DriftWinnerDatabase
+				plan.fields[i].Reason = "accounts_bootstrap is create_only"
+			}
+		}
+
+		drift.Fields = plan.fields
+		drift.Status = DriftStatusInSync
+		if len(plan.fields) > 0 {
+			drift.Status = DriftStatusDrifted
+		}
+		report = append(report, drift)
+	}
+
+	accounts, err := b.accountRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	for _, account := range accounts {
+		if seen[account.ID] {
+			continue
+		}
+		report = append(report, AccountDrift{
+			AccountID:        account.ID,
+			YouTubeChannelID: account.YouTubeChannelID,
+			TikTokAccountID:  account.TikTokAccountID,
+			Status:           DriftStatusDatabaseOnly,
+		})
+	}
+
+	return report, nil
+}
+
+// findExisting looks up the account for a YAML entry, by TikTok account first and then by YouTube channel
+func (b *AccountBootstrapper) findExisting(ctx context.Context, acc config.AccountBootstrap) (*domain.Account, error) {
+	// A shared TikTok account does not identify one mapping, so only the channel can be matched
+	if !b.accountManager.SharedTikTokAllowed() {
+		existing, err := b.accountRepo.GetByTikTokAccountID(ctx, acc.TikTokAccountID)
+		if err != nil {
+			return nil, fmt.Errorf("lookup TikTok account %s: %w", acc.TikTokAccountID, err)
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+
+	existing, err := b.accountRepo.GetByYouTubeChannelID(ctx, acc.YouTubeChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("lookup YouTube channel %s: %w", acc.YouTubeChannelID, err)
+	}
+	return existing, nil
+}
+
+// create creates an account for an entry that has no database row yet and returns its ID. ref is
+// the channel as the entry names it, so a handle is stored with the account.
+func (b *AccountBootstrapper) create(ctx context.Context, acc config.AccountBootstrap, ref string) (string, error) {
+	// Create account even without token - token can be set later via exchange-code API
+	// But CreateAccountMapping requires a token, so we'll use a placeholder
+	token := acc.TikTokAccessToken
+	if token == "" {
+		token = placeholderToken
+		logger.Info().Printf("Creating account for channel %s without token. Token must be set via exchange-code API.", acc.YouTubeChannelID)
+	}
+
+	account, err := b.accountManager.CreateAccountMapping(ctx, ref, acc.TikTokAccountID, token)
+	if err != nil {
+		return "", fmt.Errorf("create mapping: %w", err)
+	}
+	logger.Info().Printf("Bootstrapped mapping %s -> %s (Note: Token from config has no refresh token. Use exchange-code API to get refresh token.)", acc.YouTubeChannelID, acc.TikTokAccountID)
+
+	if acc.IsActive != nil && !*acc.IsActive {
+		if err := b.accountManager.DeactivateAccountMapping(ctx, account.ID); err != nil {
+			return account.ID, fmt.Errorf("deactivate mapping: %w", err)
+		}
+	}
+	if settings, changed := bootstrapSettings(acc, account.Settings); changed {
+		if err := b.applySettings(ctx, account.ID, acc, settings); err != nil {
+			return account.ID, err
+		}
+	}
+	return account.ID, nil
+}
+
+// planBootstrapUpdate applies the sync-mode precedence rules to one YAML entry and its account
+func planBootstrapUpdate(acc config.AccountBootstrap, existing *domain.Account) bootstrapPlan {
+	var plan bootstrapPlan
+
+	if acc.YouTubeChannelID != "" && acc.YouTubeChannelID != existing.YouTubeChannelID {
+		plan.youtubeID = acc.YouTubeChannelID
+		plan.fields = append(plan.fields, FieldDrift{
+			Field:         "youtube_channel_id",
+			ConfigValue:   acc.YouTubeChannelID,
+			DatabaseValue: existing.YouTubeChannelID,
+			Winner:        DriftWinnerConfig,
+		})
+	}
+	if acc.TikTokAccountID != "" && acc.TikTokAccountID != existing.TikTokAccountID {
+		plan.tiktokID = acc.TikTokAccountID
+		plan.fields = append(plan.fields, FieldDrift{
+			Field:         "tiktok_account_id",
+			ConfigValue:   acc.TikTokAccountID,
+			DatabaseValue: existing.TikTokAccountID,
+			Winner:        DriftWinnerConfig,
+		})
+	}
+
+	// Only take the token from config when the database has none; tokens obtained
+	// through the exchange-code API must not be overwritten by a stale config value.
+	if acc.TikTokAccessToken != "" && acc.TikTokAccessToken != existing.TikTokAccessToken {
+		field := FieldDrift{
+			Field:         "tiktok_access_token",
+			ConfigValue:   redact.Token(acc.TikTokAccessToken),
+			DatabaseValue: redact.Token(existing.TikTokAccessToken),
+			Winner:        DriftWinnerDatabase,
+		}
+		switch {
+		case existing.TikTokAccessToken == "":
+			plan.token = acc.TikTokAccessToken
+			field.Winner = DriftWinnerConfig
+		case existing.TikTokRefreshToken == "":
+			field.Reason = "has token but no refresh token. Consider using exchange-code API to get a refresh token instead of config token."
+		default:
+			field.Reason = "already has token with refresh token. Skipping token update from config. Use exchange-code API if token needs updating."
+		}
+		plan.fields = append(plan.fields, field)
+	}
+
+	if acc.IsActive != nil && existing.IsActive != *acc.IsActive {
+		plan.isActive = acc.IsActive
+		plan.fields = append(plan.fields, FieldDrift{
+			Field:         "is_active",
+			ConfigValue:   fmt.Sprint(*acc.IsActive),
+			DatabaseValue: fmt.Sprint(existing.IsActive),
+			Winner:        DriftWinnerConfig,
+		})
+	}
+
+	if settings, changed := bootstrapSettings(acc, existing.Settings); changed {
+		plan.settings = &settings
+		if settings.PrivacyLevel != existing.Settings.PrivacyLevel {
+			plan.fields = append(plan.fields, FieldDrift{
+				Field:         "privacy_level",
+				ConfigValue:   settings.PrivacyLevel,
+				DatabaseValue: existing.Settings.PrivacyLevel,
+				Winner:        DriftWinnerConfig,
+			})
+		}
+		if settings.PostAsDraft != existing.Settings.PostAsDraft {
+			plan.fields = append(plan.fields, FieldDrift{
+				Field:         "post_as_draft",
+				ConfigValue:   fmt.Sprint(settings.PostAsDraft),
+				DatabaseValue: fmt.Sprint(existing.Settings.PostAsDraft),
+				Winner:        DriftWinnerConfig,
+			})
+		}
+	}
+
+	return plan
+}
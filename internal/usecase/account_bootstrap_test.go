@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/redact"
+	"auto_upload_tiktok/internal/repository/memory"
+)
+
+func TestPlanBootstrapUpdate(t *testing.T) {
+	yes, no := true, false
+	existing := func(tune func(account *domain.Account)) *domain.Account {
+		account := &domain.Account{
+			ID:                 "acc-1",
+			YouTubeChannelID:   "UC-1",
+			TikTokAccountID:    "tt-1",
+			TikTokAccessToken:  "act.database",
+			TikTokRefreshToken: "rft.database",
+			IsActive:           true,
+			Settings:           domain.AccountSettings{PrivacyLevel: "PUBLIC_TO_EVERYONE"},
+		}
+		if tune != nil {
+			tune(account)
+		}
+		return account
+	}
+	tests := []struct {
+		name      string
+		entry     config.AccountBootstrap
+		existing  *domain.Account
+		wantPlan  bootstrapPlan
+		wantDrift []FieldDrift
+	}{
+		{
+			name:     "in sync",
+			entry:    config.AccountBootstrap{YouTubeChannelID: "UC-1", TikTokAccountID: "tt-1", IsActive: &yes, PrivacyLevel: "public_to_everyone"},
+			existing: existing(nil),
+		},
+		{
+			name:     "empty YAML fields keep the database values",
+			entry:    config.AccountBootstrap{},
+			existing: existing(nil),
+		},
+		{
+			name:     "YAML mapping wins",
+			entry:    config.AccountBootstrap{YouTubeChannelID: "UC-2", TikTokAccountID: "tt-2"},
+			existing: existing(nil),
+			wantPlan: bootstrapPlan{youtubeID: "UC-2", tiktokID: "tt-2"},
+			wantDrift: []FieldDrift{
+				{Field: "youtube_channel_id", ConfigValue: "UC-2", DatabaseValue: "UC-1", Winner: DriftWinnerConfig},
+				{Field: "tiktok_account_id", ConfigValue: "tt-2", DatabaseValue: "tt-1", Winner: DriftWinnerConfig},
+			},
+		},
+		{
+			name:     "YAML token fills an empty database token",
+			entry:    config.AccountBootstrap{TikTokAccessToken: "act.config"},
+			existing: existing(func(a *domain.Account) { a.TikTokAccessToken, a.TikTokRefreshToken = "", "" }),
+			wantPlan: bootstrapPlan{token: "act.config"},
+			wantDrift: []FieldDrift{
+				{Field: "tiktok_access_token", ConfigValue: redact.Token("act.config"), DatabaseValue: "", Winner: DriftWinnerConfig},
+			},
+		},
+		{
+			name:     "database token without refresh token wins",
+			entry:    config.AccountBootstrap{TikTokAccessToken: "act.config"},
+			existing: existing(func(a *domain.Account) { a.TikTokRefreshToken = "" }),
+			wantDrift: []FieldDrift{{
+				Field: "tiktok_access_token", ConfigValue: redact.Token("act.config"), DatabaseValue: redact.Token("act.database"),
+				Winner: DriftWinnerDatabase,
+				Reason: "has token but no refresh token. Consider using exchange-code API to get a refresh token instead of config token.",
+			}},
+		},
+		{
+			name:     "database token with refresh token wins",
+			entry:    config.AccountBootstrap{TikTokAccessToken: "act.config"},
+			existing: existing(nil),
+			wantDrift: []FieldDrift{{
+				Field: "tiktok_access_token", ConfigValue: redact.Token("act.config"), DatabaseValue: redact.Token("act.database"),
+				Winner: DriftWinnerDatabase,
+				Reason: "already has token with refresh token. Skipping token update from config. Use exchange-code API if token needs updating.",
+			}},
+		},
+		{
+			name:     "same token",
+			entry:    config.AccountBootstrap{TikTokAccessToken: "act.database"},
+			existing: existing(nil),
+		},
+		{
+			name:     "YAML deactivates",
+			entry:    config.AccountBootstrap{IsActive: &no},
+			existing: existing(nil),
+			wantPlan: bootstrapPlan{isActive: &no},
+			wantDrift: []FieldDrift{
+				{Field: "is_active", ConfigValue: "false", DatabaseValue: "true", Winner: DriftWinnerConfig},
+			},
+		},
+		{
+			name:     "YAML publishing settings win",
+			entry:    config.AccountBootstrap{PrivacyLevel: " self_only ", PostAsDraft: &yes},
+			existing: existing(nil),
+			wantPlan: bootstrapPlan{settings: &domain.AccountSettings{PrivacyLevel: "SELF_ONLY", PostAsDraft: true}},
+			wantDrift: []FieldDrift{
+				{Field: "privacy_level", ConfigValue: "SELF_ONLY", DatabaseValue: "PUBLIC_TO_EVERYONE", Winner: DriftWinnerConfig},
+				{Field: "post_as_draft", ConfigValue: "true", DatabaseValue: "false", Winner: DriftWinnerConfig},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planBootstrapUpdate(tt.entry, tt.existing)
+			fields := plan.fields
+			plan.fields = nil
+			if !reflect.DeepEqual(plan, tt.wantPlan) {
+				t.Errorf("plan = %+v, want %+v", plan, tt.wantPlan)
+			}
+			if !reflect.DeepEqual(fields, tt.wantDrift) {
+				t.Errorf("drift = %+v, want %+v", fields, tt.wantDrift)
+			}
+			if plan.needsUpdate() != !reflect.DeepEqual(tt.wantPlan, bootstrapPlan{}) {
+				t.Errorf("needsUpdate() = %v", plan.needsUpdate())
+			}
+		})
+	}
+}
+
+func TestAccountBootstrapperDrift(t *testing.T) {
+	const (
+		syncedChannel  = "UCsyncedsyncedsyncedsync"
+		driftedChannel = "UCdrifteddrifteddrifted0"
+		missingChannel = "UCmissingmissingmissing0"
+		dbOnlyChannel  = "UCdbonlydbonlydbonlydb00"
+	)
+	entries := []config.AccountBootstrap{
+		{YouTubeChannelID: syncedChannel, TikTokAccountID: "tt-synced"},
+		{YouTubeChannelID: driftedChannel, TikTokAccountID: "tt-drifted", PrivacyLevel: "SELF_ONLY"},
+		{YouTubeChannelID: missingChannel, TikTokAccountID: "tt-missing"},
+		{YouTubeChannelID: "", TikTokAccountID: "tt-invalid"},
+	}
+
+	for _, mode := range []string{config.BootstrapModeSync, config.BootstrapModeCreateOnly} {
+		t.Run(mode, func(t *testing.T) {
+			ctx := context.Background()
+			accounts := memory.NewAccountRepository()
+			for _, account := range []*domain.Account{
+				{ID: "synced", YouTubeChannelID: syncedChannel, TikTokAccountID: "tt-synced"},
+				{ID: "drifted", YouTubeChannelID: driftedChannel, TikTokAccountID: "tt-drifted"},
+				{ID: "db-only", YouTubeChannelID: dbOnlyChannel, TikTokAccountID: "tt-db-only"},
+			} {
+				if err := accounts.Save(ctx, account); err != nil {
+					t.Fatalf("save account: %v", err)
+				}
+			}
+			bootstrapper := NewAccountBootstrapper(NewAccountManager(&config.Config{}, accounts), accounts)
+
+			report, err := bootstrapper.Drift(ctx, entries, mode)
+			if err != nil {
+				t.Fatalf("Drift() error = %v", err)
+			}
+			statuses := make(map[string]string)
+			for _, drift := range report {
+				statuses[drift.TikTokAccountID] = drift.Status
+				if drift.TikTokAccountID != "tt-drifted" {
+					continue
+				}
+				wantWinner, wantReason := DriftWinnerConfig, ""
+				if mode == config.BootstrapModeCreateOnly {
+					wantWinner, wantReason = DriftWinnerDatabase, "accounts_bootstrap is create_only"
+				}
+				want := []FieldDrift{{Field: "privacy_level", ConfigValue: "SELF_ONLY", Winner: wantWinner, Reason: wantReason}}
+				if !reflect.DeepEqual(drift.Fields, want) {
+					t.Errorf("drifted fields = %+v, want %+v", drift.Fields, want)
+				}
+			}
+			wantStatuses := map[string]string{
+				"tt-synced":  DriftStatusInSync,
+				"tt-drifted": DriftStatusDrifted,
+				"tt-missing": DriftStatusMissingInDatabase,
+				"tt-invalid": DriftStatusInvalid,
+				"tt-db-only": DriftStatusDatabaseOnly,
+			}
+			if !reflect.DeepEqual(statuses, wantStatuses) {
+				t.Errorf("statuses = %v, want %v", statuses, wantStatuses)
+			}
+		})
+	}
+}
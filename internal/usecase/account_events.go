@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/redact"
+)
+
+type accountEventSourceKey struct{}
+
+// WithAccountEventSource attributes the account changes made with ctx to source. Changes made
+// without one are recorded as domain.AccountEventSourceManual.
+func WithAccountEventSource(ctx context.Context, source domain.AccountEventSource) context.Context {
+	return context.WithValue(ctx, accountEventSourceKey{}, source)
+}
+
+// accountEventSource returns the source carried by ctx
+func accountEventSource(ctx context.Context) domain.AccountEventSource {
+	if source, ok := ctx.Value(accountEventSourceKey{}).(domain.AccountEventSource); ok && source != "" {
+		return source
+	}
+	return domain.AccountEventSourceManual
+}
+
+// AccountEventLog records account and token changes, so it can be reconstructed later which of the
+// bootstrap, an OAuth exchange, an auto-refresh or an operator changed what. A nil log records
+// nothing.
+type AccountEventLog struct {
+	repo domain.AccountEventRepository
+}
+
+// NewAccountEventLog creates an event log backed by repo
+func NewAccountEventLog(repo domain.AccountEventRepository) *AccountEventLog {
+	return &AccountEventLog{repo: repo}
+}
+
+// Record appends an event attributed to the source carried by ctx. A failed write is logged; it
+// never fails the change itself.
+func (l *AccountEventLog) Record(ctx context.Context, accountID string, eventType domain.AccountEventType, summary string) {
+	if l == nil {
+		return
+	}
+	event := &domain.AccountEvent{
+		AccountID: accountID,
+		Type:      eventType,
+		Source:    accountEventSource(ctx),
+		Summary:   summary,
+	}
+	if err := l.repo.Save(context.WithoutCancel(ctx), event); err != nil {
+		logger.ErrorContext(ctx).Printf("Failed to record %s event of account %s: %v", eventType, accountID, err)
+	}
+}
+
+// List returns the events of an account, newest first
+func (l *AccountEventLog) List(ctx context.Context, accountID string) ([]*domain.AccountEvent, error) {
+	if l == nil {
+		return nil, nil
+	}
+	events, err := l.repo.ListByAccount(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events of account %s: %w", accountID, err)
+	}
+	return events, nil
+}
+
+// accountChanges summarizes what differs between two versions of an account, e.g.
+// "tiktok_access_token ****abcd -> ****wxyz, is_active true -> false". Tokens are redacted and
+// long free text is only named.
+func accountChanges(before, after *domain.Account) string {
+	var changes []string
+	value := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, fmt.Sprintf("%s %s -> %s", field, orNone(from), orNone(to)))
+		}
+	}
+	token := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, fmt.Sprintf("%s %s -> %s", field, orNone(redact.Token(from)), orNone(redact.Token(to))))
+		}
+	}
+	changed := func(field string, differs bool) {
+		if differs {
+			changes = append(changes, field+" changed")
+		}
+	}
+
+	value("youtube_channel_id", before.YouTubeChannelID, after.YouTubeChannelID)
+	value("youtube_handle", before.YouTubeHandle, after.YouTubeHandle)
+	value("tiktok_account_id", before.TikTokAccountID, after.TikTokAccountID)
+	value("is_active", fmt.Sprint(before.IsActive), fmt.Sprint(after.IsActive))
+	token("tiktok_access_token", before.TikTokAccessToken, after.TikTokAccessToken)
+	token("tiktok_refresh_token", before.TikTokRefreshToken, after.TikTokRefreshToken)
+	value("tiktok_token_expires_at", formatEventTime(before.TikTokTokenExpiresAt), formatEventTime(after.TikTokTokenExpiresAt))
+	value("tiktok_app", before.TikTokApp, after.TikTokApp)
+	token("tiktok_client_key", before.TikTokClientKey, after.TikTokClientKey)
+	token("youtube_access_token", before.YouTubeAccessToken, after.YouTubeAccessToken)
+	token("youtube_refresh_token", before.YouTubeRefreshToken, after.YouTubeRefreshToken)
+	value("youtube_token_expires_at", formatEventTime(before.YouTubeTokenExpiresAt), formatEventTime(after.YouTubeTokenExpiresAt))
+	changed("comment_template", before.CommentTemplate != after.CommentTemplate)
+	changed("settings", !reflect.DeepEqual(before.Settings, after.Settings))
+
+	if len(changes) == 0 {
+		return "no changes"
+	}
+	return strings.Join(changes, ", ")
+}
+
+// tokensChanged reports whether any TikTok or YouTube credential of the account differs
+func tokensChanged(before, after *domain.Account) bool {
+	return before.TikTokAccessToken != after.TikTokAccessToken ||
+		before.TikTokRefreshToken != after.TikTokRefreshToken ||
+		before.YouTubeAccessToken != after.YouTubeAccessToken ||
+		before.YouTubeRefreshToken != after.YouTubeRefreshToken
+}
+
+// formatEventTime formats an optional timestamp for an event summary
+func formatEventTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// orNone shows an empty value in an event summary
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+)
+
+// accountLimiter bounds how many operations run at once per account. An account's slots exist
+// only while someone holds or waits for one, so removed accounts leave nothing behind.
+type accountLimiter struct {
+	limit int // 0 or less means no limit
+
+	mu       sync.Mutex
+	accounts map[string]*accountSlots
+}
+
+// accountSlots is one account's semaphore and the number of callers holding or waiting for it
+type accountSlots struct {
+	sem   chan struct{}
+	users int
+}
+
+func newAccountLimiter(limit int) *accountLimiter {
+	return &accountLimiter{limit: limit, accounts: make(map[string]*accountSlots)}
+}
+
+// acquire waits for one of the account's slots and returns the function that gives it back. It
+// returns ctx.Err() if ctx ends first.
+func (l *accountLimiter) acquire(ctx context.Context, accountID string) (func(), error) {
+	if l.limit <= 0 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	slots, ok := l.accounts[accountID]
+	if !ok {
+		slots = &accountSlots{sem: make(chan struct{}, l.limit)}
+		l.accounts[accountID] = slots
+	}
+	slots.users++
+	l.mu.Unlock()
+
+	select {
+	case slots.sem <- struct{}{}:
+		return func() {
+			<-slots.sem
+			l.leave(accountID, slots)
+		}, nil
+	case <-ctx.Done():
+		l.leave(accountID, slots)
+		return nil, ctx.Err()
+	}
+}
+
+// leave drops the account's slots once no caller holds or waits for one
+func (l *accountLimiter) leave(accountID string, slots *accountSlots) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slots.users--
+	if slots.users == 0 {
+		delete(l.accounts, accountID)
+	}
+}
@@ -0,0 +1,259 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/downloader"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+)
+
+// downloadEvent is a line of the slow fake yt-dlp's log
+type downloadEvent struct {
+	start   bool
+	videoID string // YouTube video ID, yt-<account>-<n>
+}
+
+// account is the account part of the event's video ID
+func (e downloadEvent) account() string {
+	return strings.Split(e.videoID, "-")[1]
+}
+
+// useSlowDownloader gives the processor a download service whose yt-dlp is
+// testdata/slow_ytdlp.sh, taking delay per download, and returns a function reading its log
+func useSlowDownloader(t *testing.T, tp *testProcessor, delay time.Duration) func() []downloadEvent {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake yt-dlp is a shell script")
+	}
+	script, err := filepath.Abs("testdata/slow_ytdlp.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(t.TempDir(), "ytdlp.log")
+	t.Setenv("FAKE_YTDLP_LOG", logPath)
+	t.Setenv("FAKE_YTDLP_DELAY", fmt.Sprintf("%.2f", delay.Seconds()))
+
+	tp.cfg.YtDlpPath = script
+	tp.cfg.DownloadDir = t.TempDir()
+	tp.cfg.DownloadTimeout = time.Minute
+	service, err := downloader.NewService(tp.cfg, httpclient.NewHTTPClient(tp.cfg))
+	if err != nil {
+		t.Fatalf("downloader.NewService() error = %v", err)
+	}
+	tp.downloadService = service
+
+	return func() []downloadEvent {
+		file, err := os.Open(logPath)
+		if err != nil {
+			return nil
+		}
+		defer file.Close()
+		var events []downloadEvent
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			kind, id, _ := strings.Cut(scanner.Text(), " ")
+			events = append(events, downloadEvent{start: kind == "start", videoID: id})
+		}
+		return events
+	}
+}
+
+// TestDownloadsFairAcrossAccounts queues six downloads of a busy account ahead of two of a quiet
+// one. With one download per account, the busy account holds one of the two global slots and the
+// quiet account's downloads go through the other instead of waiting behind the whole backlog.
+func TestDownloadsFairAcrossAccounts(t *testing.T) {
+	const busyVideos, quietVideos = 6, 2
+	tp := newTestProcessor(t, nil, func(cfg *config.Config) {
+		cfg.MaxConcurrentDownloads = 2
+		cfg.MaxDownloadsPerAccount = 1
+	})
+	events := useSlowDownloader(t, tp, 300*time.Millisecond)
+	tp.saveAccount(t, &domain.Account{ID: "busy"})
+	tp.saveAccount(t, &domain.Account{ID: "quiet"})
+
+	var wg sync.WaitGroup
+	download := func(account string, n int) {
+		video := tp.saveVideo(t, &domain.Video{
+			ID: fmt.Sprintf("%s-%d", account, n), AccountID: account, YouTubeVideoID: fmt.Sprintf("yt-%s-%d", account, n),
+		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := tp.fetchVideoFile(context.Background(), video)
+			if err != nil {
+				t.Errorf("fetchVideoFile(%s) error = %v", video.ID, err)
+				return
+			}
+			if filepath.Base(result.FilePath) != video.ID+".mp4" {
+				t.Errorf("fetchVideoFile(%s) file = %s", video.ID, result.FilePath)
+			}
+		}()
+	}
+
+	// The busy account's backlog is waiting for slots before the quiet account's videos arrive
+	for n := range busyVideos {
+		download("busy", n)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(events()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	for n := range quietVideos {
+		download("quiet", n)
+	}
+	wg.Wait()
+
+	log := events()
+	if len(log) != 2*(busyVideos+quietVideos) {
+		t.Fatalf("fake yt-dlp logged %d events, want %d: %v", len(log), 2*(busyVideos+quietVideos), log)
+	}
+	running := make(map[string]int)
+	total, busyStarts := 0, 0
+	var quietStartsBefore []int // busy downloads started before each quiet one
+	for _, event := range log {
+		if !event.start {
+			running[event.account()]--
+			total--
+			continue
+		}
+		running[event.account()]++
+		total++
+		if running[event.account()] > 1 {
+			t.Errorf("%s started while another download of its account was running", event.videoID)
+		}
+		if total > 2 {
+			t.Errorf("%s started with %d downloads running, over download.max_concurrent", event.videoID, total-1)
+		}
+		if event.account() == "busy" {
+			busyStarts++
+		} else {
+			quietStartsBefore = append(quietStartsBefore, busyStarts)
+		}
+	}
+	// Without the per-account cap the quiet videos would start after all six busy ones
+	for i, before := range quietStartsBefore {
+		if before > 2 {
+			t.Errorf("quiet download %d started after %d of the busy account's downloads, want at most 2", i, before)
+		}
+	}
+
+	tp.accountDownloads.mu.Lock()
+	defer tp.accountDownloads.mu.Unlock()
+	if len(tp.accountDownloads.accounts) != 0 {
+		t.Errorf("%d accounts' download slots left after the downloads", len(tp.accountDownloads.accounts))
+	}
+}
+
+func TestAccountLimiter(t *testing.T) {
+	limiter := newAccountLimiter(2)
+	ctx := context.Background()
+
+	first, err := limiter.acquire(ctx, "acc-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := limiter.acquire(ctx, "acc-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another account has its own slots
+	other, err := limiter.acquire(ctx, "acc-2")
+	if err != nil {
+		t.Fatalf("acquire() for another account error = %v", err)
+	}
+	other()
+
+	// The third caller of a full account waits until it is cancelled
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(waitCtx, "acc-1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire() on a full account error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// or until a slot is given back
+	acquired := make(chan func())
+	go func() {
+		release, err := limiter.acquire(ctx, "acc-1")
+		if err != nil {
+			t.Errorf("acquire() error = %v", err)
+		}
+		acquired <- release
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquire() returned while both slots were held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	first()
+	third := <-acquired
+	second()
+	third()
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if len(limiter.accounts) != 0 {
+		t.Errorf("slots of %d accounts left once nobody holds or waits for them", len(limiter.accounts))
+	}
+
+	// Without a limit nothing is tracked
+	unlimited := newAccountLimiter(0)
+	for range 10 {
+		if _, err := unlimited.acquire(ctx, "acc-1"); err != nil {
+			t.Fatalf("unlimited acquire() error = %v", err)
+		}
+	}
+	if len(unlimited.accounts) != 0 {
+		t.Errorf("unlimited limiter tracks %d accounts", len(unlimited.accounts))
+	}
+}
+
+func TestFairBatch(t *testing.T) {
+	queue := func(accounts ...string) []*domain.Video {
+		videos := make([]*domain.Video, len(accounts))
+		for i, account := range accounts {
+			videos[i] = &domain.Video{ID: fmt.Sprintf("%s%d", account, i), AccountID: account}
+		}
+		return videos
+	}
+	tests := []struct {
+		name       string
+		perAccount int
+		queue      []*domain.Video
+		size       int
+		want       string
+	}{
+		{"no cap keeps queue order", 0, queue("a", "a", "a", "b", "c"), 3, "[a0 a1 a2]"},
+		{"other accounts first", 1, queue("a", "a", "a", "b", "c"), 3, "[a0 b3 c4]"},
+		{"cap of two", 2, queue("a", "a", "a", "a", "b"), 3, "[a0 a1 b4]"},
+		{"filled up with the busy account", 1, queue("a", "a", "a", "b"), 3, "[a0 b3 a1]"},
+		{"short queue", 1, queue("a", "a"), 5, "[a0 a1]"},
+		{"empty queue", 1, nil, 5, "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := newTestProcessor(t, nil, func(cfg *config.Config) {
+				cfg.MaxDownloadsPerAccount = tt.perAccount
+			})
+			var ids []string
+			for _, video := range tp.fairBatch(tt.queue, tt.size) {
+				ids = append(ids, video.ID)
+			}
+			if got := fmt.Sprint(ids); got != tt.want {
+				t.Errorf("fairBatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,26 +1,98 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"auto_upload_tiktok/config"
 	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
 )
 
 // AccountManager manages YouTube-TikTok account mappings
 type AccountManager struct {
+	cfg         *config.Config
 	accountRepo domain.AccountRepository
+
+	reauthAlerter   *ReauthAlerter   // Optional: announces accounts that were re-authorized
+	channelResolver *ChannelResolver // Optional: resolves @handles and channel URLs
+	events          *AccountEventLog // Optional: records account and token changes
 }
 
 // NewAccountManager creates a new account manager
-func NewAccountManager(accountRepo domain.AccountRepository) *AccountManager {
+func NewAccountManager(cfg *config.Config, accountRepo domain.AccountRepository) *AccountManager {
 	return &AccountManager{
+		cfg:         cfg,
 		accountRepo: accountRepo,
 	}
 }
 
+// SetReauthAlerter enables the "re-authorized" notification when new tokens are stored
+func (m *AccountManager) SetReauthAlerter(alerter *ReauthAlerter) {
+	m.reauthAlerter = alerter
+}
+
+// SetChannelResolver lets mappings be created from @handles and youtube.com URLs, not only
+// channel IDs
+func (m *AccountManager) SetChannelResolver(resolver *ChannelResolver) {
+	m.channelResolver = resolver
+}
+
+// SetEventLog records every change made through the manager to the account's event history
+func (m *AccountManager) SetEventLog(events *AccountEventLog) {
+	m.events = events
+}
+
+// AccountEvents returns the recorded changes of an account, newest first. The history of a
+// deleted account is kept.
+func (m *AccountManager) AccountEvents(ctx context.Context, accountID string) ([]*domain.AccountEvent, error) {
+	return m.events.List(ctx, accountID)
+}
+
+// modify applies change through modifyAccount and records it as an event of eventType, or, when
+// eventType is empty, as token_updated or updated depending on whether credentials changed
+func (m *AccountManager) modify(
+	ctx context.Context,
+	accountID string,
+	what string,
+	eventType domain.AccountEventType,
+	change func(account *domain.Account) error,
+) (*domain.Account, error) {
+	var before domain.Account
+	account, err := modifyAccount(ctx, m.accountRepo, accountID, what, func(account *domain.Account) error {
+		before = *account
+		return change(account)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if eventType == "" {
+		eventType = domain.AccountEventUpdated
+		if tokensChanged(&before, account) {
+			eventType = domain.AccountEventTokenUpdated
+		}
+	}
+	m.events.Record(ctx, account.ID, eventType, accountChanges(&before, account))
+	return account, nil
+}
+
+// ResolveChannel returns the channel ID and the normalized handle (empty for a channel ID) of a
+// channel reference
+func (m *AccountManager) ResolveChannel(ctx context.Context, ref string) (string, string, error) {
+	return m.channelResolver.Resolve(ctx, ref)
+}
+
+// SharedTikTokAllowed reports whether several YouTube channels may map to one TikTok account
+func (m *AccountManager) SharedTikTokAllowed() bool {
+	return m.cfg != nil && m.cfg.AccountsAllowSharedTikTok
+}
+
 // CreateAccountMapping creates a new mapping between YouTube channel and TikTok account
 func (m *AccountManager) CreateAccountMapping(
+	ctx context.Context,
 	youtubeChannelID string,
 	tiktokAccountID string,
 	tiktokAccessToken string,
@@ -29,15 +101,20 @@ func (m *AccountManager) CreateAccountMapping(
 	if youtubeChannelID == "" {
 		return nil, fmt.Errorf("youtube channel ID is required")
 	}
+	youtubeChannelID, youtubeHandle, err := m.ResolveChannel(ctx, youtubeChannelID)
+	if err != nil {
+		return nil, err
+	}
 	if tiktokAccountID == "" {
-		return nil, fmt.Errorf("tiktok account ID is required")
+		// Filled in with the open_id of the first authorization
+		tiktokAccountID = pendingTikTokAccountPrefix + youtubeChannelID
 	}
 	if tiktokAccessToken == "" {
 		return nil, fmt.Errorf("tiktok access token is required")
 	}
 
 	// Check if mapping already exists
-	existing, err := m.accountRepo.GetByYouTubeAndTikTok(youtubeChannelID, tiktokAccountID)
+	existing, err := m.accountRepo.GetByYouTubeAndTikTok(ctx, youtubeChannelID, tiktokAccountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check existing mapping: %w", err)
 	}
@@ -47,7 +124,7 @@ func (m *AccountManager) CreateAccountMapping(
 	}
 
 	// Check if YouTube channel is already mapped to another TikTok account
-	existingByYouTube, err := m.accountRepo.GetByYouTubeChannelID(youtubeChannelID)
+	existingByYouTube, err := m.accountRepo.GetByYouTubeChannelID(ctx, youtubeChannelID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check YouTube channel mapping: %w", err)
 	}
@@ -57,18 +134,14 @@ func (m *AccountManager) CreateAccountMapping(
 	}
 
 	// Check if TikTok account is already mapped to another YouTube channel
-	existingByTikTok, err := m.accountRepo.GetByTikTokAccountID(tiktokAccountID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check TikTok account mapping: %w", err)
-	}
-
-	if existingByTikTok != nil {
-		return nil, fmt.Errorf("TikTok account %s is already mapped to YouTube channel %s", tiktokAccountID, existingByTikTok.YouTubeChannelID)
+	if err := m.checkTikTokAccountFree(ctx, tiktokAccountID, ""); err != nil {
+		return nil, err
 	}
 
 	// Create new account mapping
 	account := &domain.Account{
 		YouTubeChannelID:  youtubeChannelID,
+		YouTubeHandle:     youtubeHandle,
 		TikTokAccountID:   tiktokAccountID,
 		TikTokAccessToken: tiktokAccessToken,
 		IsActive:          true,
@@ -76,71 +149,119 @@ func (m *AccountManager) CreateAccountMapping(
 		UpdatedAt:         time.Now(),
 	}
 
-	if err := m.accountRepo.Save(account); err != nil {
+	// A channel added to a shared TikTok account without a token of its own uses the existing one
+	if _, err := inheritTikTokTokens(ctx, m.accountRepo, account); err != nil {
+		return nil, err
+	}
+
+	if err := m.accountRepo.Save(ctx, account); err != nil {
 		return nil, fmt.Errorf("failed to save account mapping: %w", err)
 	}
+	m.events.Record(ctx, account.ID, domain.AccountEventCreated, accountChanges(&domain.Account{}, account))
+	if err := shareTikTokTokens(ctx, m.accountRepo, m.events, account); err != nil {
+		return nil, err
+	}
 
 	return account, nil
 }
 
+// checkTikTokAccountFree rejects a TikTok account that another mapping already posts to, unless
+// accounts_allow_shared_tiktok is set. exceptID is the mapping being updated.
+func (m *AccountManager) checkTikTokAccountFree(ctx context.Context, tiktokAccountID, exceptID string) error {
+	if m.SharedTikTokAllowed() {
+		return nil
+	}
+
+	existing, err := m.accountRepo.ListByTikTokAccountID(ctx, tiktokAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to check TikTok account mapping: %w", err)
+	}
+	for _, account := range existing {
+		if account.ID != exceptID {
+			return fmt.Errorf("TikTok account %s is already mapped to YouTube channel %s (set accounts_allow_shared_tiktok to map several channels to it)", tiktokAccountID, account.YouTubeChannelID)
+		}
+	}
+	return nil
+}
+
 // UpdateAccountMapping updates an existing account mapping
 func (m *AccountManager) UpdateAccountMapping(
+	ctx context.Context,
 	accountID string,
 	youtubeChannelID string,
 	tiktokAccountID string,
 	tiktokAccessToken string,
 	isActive *bool,
 ) (*domain.Account, error) {
-	// Get existing account
-	account, err := m.accountRepo.GetByID(accountID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get account: %w", err)
+	// Resolved once; the change below may be applied more than once
+	var channelID, handle string
+	if youtubeChannelID != "" {
+		var err error
+		channelID, handle, err = m.ResolveChannel(ctx, youtubeChannelID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	if account == nil {
-		return nil, fmt.Errorf("account not found: %s", accountID)
+	account, err := m.modify(ctx, accountID, "update account mapping", "", func(account *domain.Account) error {
+		if channelID != "" {
+			account.YouTubeChannelID = channelID
+			account.YouTubeHandle = handle
+		}
+		if tiktokAccountID != "" && tiktokAccountID != account.TikTokAccountID {
+			if err := m.checkTikTokAccountFree(ctx, tiktokAccountID, account.ID); err != nil {
+				return err
+			}
+			account.TikTokAccountID = tiktokAccountID
+		}
+		if tiktokAccessToken != "" {
+			account.TikTokAccessToken = tiktokAccessToken
+		}
+		if isActive != nil {
+			account.IsActive = *isActive
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Update fields
-	if youtubeChannelID != "" {
-		account.YouTubeChannelID = youtubeChannelID
-	}
-	if tiktokAccountID != "" {
-		account.TikTokAccountID = tiktokAccountID
+	// A new token or TikTok account ID is checked again on the next upload
+	if tiktokAccountID != "" || tiktokAccessToken != "" {
+		if err := m.clearNeedsReauthorization(ctx, account); err != nil {
+			return nil, err
+		}
 	}
 	if tiktokAccessToken != "" {
-		account.TikTokAccessToken = tiktokAccessToken
-	}
-	if isActive != nil {
-		account.IsActive = *isActive
-	}
-	account.UpdatedAt = time.Now()
-
-	if err := m.accountRepo.Save(account); err != nil {
-		return nil, fmt.Errorf("failed to update account mapping: %w", err)
+		if err := clearMissingScopes(ctx, m.accountRepo, account); err != nil {
+			return nil, err
+		}
+		if err := shareTikTokTokens(ctx, m.accountRepo, m.events, account); err != nil {
+			return nil, err
+		}
 	}
 
 	return account, nil
 }
 
 // GetAccountMapping retrieves an account mapping by ID
-func (m *AccountManager) GetAccountMapping(accountID string) (*domain.Account, error) {
-	return m.accountRepo.GetByID(accountID)
+func (m *AccountManager) GetAccountMapping(ctx context.Context, accountID string) (*domain.Account, error) {
+	return m.accountRepo.GetByID(ctx, accountID)
 }
 
 // GetAllAccountMappings retrieves all account mappings
-func (m *AccountManager) GetAllAccountMappings() ([]*domain.Account, error) {
-	return m.accountRepo.GetAll()
+func (m *AccountManager) GetAllAccountMappings(ctx context.Context) ([]*domain.Account, error) {
+	return m.accountRepo.GetAll(ctx)
 }
 
 // GetActiveAccountMappings retrieves only active account mappings
-func (m *AccountManager) GetActiveAccountMappings() ([]*domain.Account, error) {
-	return m.accountRepo.GetAllActive()
+func (m *AccountManager) GetActiveAccountMappings(ctx context.Context) ([]*domain.Account, error) {
+	return m.accountRepo.GetAllActive(ctx)
 }
 
 // DeleteAccountMapping removes an account mapping
-func (m *AccountManager) DeleteAccountMapping(accountID string) error {
-	account, err := m.accountRepo.GetByID(accountID)
+func (m *AccountManager) DeleteAccountMapping(ctx context.Context, accountID string) error {
+	account, err := m.accountRepo.GetByID(ctx, accountID)
 	if err != nil {
 		return fmt.Errorf("failed to get account: %w", err)
 	}
@@ -149,74 +270,209 @@ func (m *AccountManager) DeleteAccountMapping(accountID string) error {
 		return fmt.Errorf("account not found: %s", accountID)
 	}
 
-	return m.accountRepo.Delete(accountID)
+	if err := m.accountRepo.Delete(ctx, accountID); err != nil {
+		return err
+	}
+	m.events.Record(ctx, accountID, domain.AccountEventDeleted,
+		fmt.Sprintf("youtube_channel_id %s, tiktok_account_id %s", account.YouTubeChannelID, account.TikTokAccountID))
+	return nil
 }
 
 // ActivateAccountMapping activates an account mapping
-func (m *AccountManager) ActivateAccountMapping(accountID string) error {
-	account, err := m.accountRepo.GetByID(accountID)
+func (m *AccountManager) ActivateAccountMapping(ctx context.Context, accountID string) error {
+	_, err := m.modify(ctx, accountID, "activate account", domain.AccountEventActivated, func(account *domain.Account) error {
+		account.IsActive = true
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get account: %w", err)
+		return err
 	}
-
-	if account == nil {
-		return fmt.Errorf("account not found: %s", accountID)
+	// Reactivation starts a new failure streak
+	if err := m.accountRepo.UpdateFailureStreak(ctx, accountID, domain.FailureStreak{}); err != nil {
+		return fmt.Errorf("failed to reset failure streak: %w", err)
 	}
-
-	account.IsActive = true
-	account.UpdatedAt = time.Now()
-
-	return m.accountRepo.Save(account)
+	return nil
 }
 
 // DeactivateAccountMapping deactivates an account mapping
-func (m *AccountManager) DeactivateAccountMapping(accountID string) error {
-	account, err := m.accountRepo.GetByID(accountID)
-	if err != nil {
-		return fmt.Errorf("failed to get account: %w", err)
-	}
-
-	if account == nil {
-		return fmt.Errorf("account not found: %s", accountID)
-	}
-
-	account.IsActive = false
-	account.UpdatedAt = time.Now()
-
-	return m.accountRepo.Save(account)
+func (m *AccountManager) DeactivateAccountMapping(ctx context.Context, accountID string) error {
+	_, err := m.modify(ctx, accountID, "deactivate account", domain.AccountEventDeactivated, func(account *domain.Account) error {
+		account.IsActive = false
+		return nil
+	})
+	return err
 }
 
-// UpdateAccountTokens updates access token and optionally refresh token for an account
+// UpdateAccountTokens updates access token and optionally refresh token for an account and records
+// the credential set that issued them
 func (m *AccountManager) UpdateAccountTokens(
+	ctx context.Context,
 	accountID string,
+	app config.TikTokApp,
 	accessToken string,
 	refreshToken string,
 	expiresIn *int,
 ) (*domain.Account, error) {
-	account, err := m.accountRepo.GetByID(accountID)
+	account, err := m.modify(ctx, accountID, "update account tokens", domain.AccountEventTokenUpdated, func(account *domain.Account) error {
+		if accessToken != "" {
+			account.TikTokAccessToken = accessToken
+		}
+		if refreshToken != "" {
+			account.TikTokRefreshToken = refreshToken
+		}
+		if expiresIn != nil && *expiresIn > 0 {
+			expiresAt := time.Now().Add(time.Duration(*expiresIn) * time.Second)
+			account.TikTokTokenExpiresAt = &expiresAt
+		}
+		account.TikTokApp = app.Name
+		account.TikTokClientKey = app.APIKey
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get account: %w", err)
+		return nil, err
+	}
+	if accessToken != "" {
+		if err := m.clearNeedsReauthorization(ctx, account); err != nil {
+			return nil, err
+		}
+		if err := clearMissingScopes(ctx, m.accountRepo, account); err != nil {
+			return nil, err
+		}
+	}
+	if err := shareTikTokTokens(ctx, m.accountRepo, m.events, account); err != nil {
+		return nil, err
+	}
+	if accessToken != "" && m.reauthAlerter != nil {
+		m.reauthAlerter.Resolved(ctx, account)
 	}
 
-	if account == nil {
-		return nil, fmt.Errorf("account not found: %s", accountID)
+	return account, nil
+}
+
+// clearNeedsReauthorization lifts the wrong-account flag after the token or mapping changed
+func (m *AccountManager) clearNeedsReauthorization(ctx context.Context, account *domain.Account) error {
+	if !account.NeedsReauthorization {
+		return nil
 	}
+	if err := m.accountRepo.UpdateNeedsReauthorization(ctx, account.ID, false); err != nil {
+		return fmt.Errorf("failed to clear re-authorization flag: %w", err)
+	}
+	account.NeedsReauthorization = false
+	return nil
+}
 
-	if accessToken != "" {
-		account.TikTokAccessToken = accessToken
+// UpdateYouTubeTokens stores the YouTube OAuth tokens the channel owner granted for description
+// updates. An empty refresh token keeps the stored one.
+func (m *AccountManager) UpdateYouTubeTokens(
+	ctx context.Context,
+	accountID string,
+	accessToken string,
+	refreshToken string,
+	expiresIn int,
+) (*domain.Account, error) {
+	return m.modify(ctx, accountID, "update YouTube tokens", domain.AccountEventTokenUpdated, func(account *domain.Account) error {
+		account.YouTubeAccessToken = accessToken
+		if refreshToken != "" {
+			account.YouTubeRefreshToken = refreshToken
+		}
+		account.YouTubeTokenExpiresAt = nil
+		if expiresIn > 0 {
+			expiresAt := time.Now().Add(time.Duration(expiresIn) * time.Second)
+			account.YouTubeTokenExpiresAt = &expiresAt
+		}
+		return nil
+	})
+}
+
+// SetTikTokApp records which credential set issued the account's existing tokens, for tokens
+// obtained before credential sets were tracked
+func (m *AccountManager) SetTikTokApp(ctx context.Context, accountID string, app config.TikTokApp) (*domain.Account, error) {
+	return m.modify(ctx, accountID, "update TikTok credential set", domain.AccountEventUpdated, func(account *domain.Account) error {
+		account.TikTokApp = app.Name
+		account.TikTokClientKey = app.APIKey
+		return nil
+	})
+}
+
+// SetCommentTemplate sets the first-comment template posted after each TikTok publish.
+// An empty template disables the comment step.
+func (m *AccountManager) SetCommentTemplate(ctx context.Context, accountID string, template string) (*domain.Account, error) {
+	return m.modify(ctx, accountID, "update comment template", domain.AccountEventUpdated, func(account *domain.Account) error {
+		account.CommentTemplate = template
+		return nil
+	})
+}
+
+// UpdateAccountSettings replaces the per-account settings (discovery filters and options)
+func (m *AccountManager) UpdateAccountSettings(ctx context.Context, accountID string, settings domain.AccountSettings) (*domain.Account, error) {
+	if settings.MaxUploadsPerDay < 0 {
+		return nil, fmt.Errorf("max_uploads_per_day must not be negative")
 	}
-	if refreshToken != "" {
-		account.TikTokRefreshToken = refreshToken
+	if settings.UploadPath != "" && !settings.UploadPath.IsValid() {
+		return nil, fmt.Errorf("upload_path must be %q or %q", domain.UploadPathAPI, domain.UploadPathWeb)
 	}
-	if expiresIn != nil && *expiresIn > 0 {
-		expiresAt := time.Now().Add(time.Duration(*expiresIn) * time.Second)
-		account.TikTokTokenExpiresAt = &expiresAt
+	if settings.FallbackUploadPath != "" && !settings.FallbackUploadPath.IsValid() {
+		return nil, fmt.Errorf("fallback_upload_path must be %q or %q", domain.UploadPathAPI, domain.UploadPathWeb)
 	}
-	account.UpdatedAt = time.Now()
-
-	if err := m.accountRepo.Save(account); err != nil {
-		return nil, fmt.Errorf("failed to update account tokens: %w", err)
+	for path, limits := range settings.UploadLimits {
+		if !path.IsValid() {
+			return nil, fmt.Errorf("upload_limits keys must be %q or %q", domain.UploadPathAPI, domain.UploadPathWeb)
+		}
+		if limits.MaxSize < 0 || limits.MaxDuration < 0 {
+			return nil, fmt.Errorf("upload_limits.%s must not be negative", path)
+		}
+	}
+	if settings.CaptionLanguage != "" {
+		if err := ValidateLanguage(settings.CaptionLanguage); err != nil {
+			return nil, fmt.Errorf("caption_language: %w", err)
+		}
+		settings.CaptionLanguage = NormalizeLanguage(settings.CaptionLanguage)
+	}
+	settings.PrivacyLevel = strings.ToUpper(strings.TrimSpace(settings.PrivacyLevel))
+	if err := tiktok.ValidatePrivacyLevel(settings.PrivacyLevel); err != nil {
+		return nil, fmt.Errorf("privacy_level: %w", err)
+	}
+	if delay := time.Duration(settings.PublishDelay); delay != 0 {
+		if delay < tiktok.MinScheduleLead || delay > tiktok.MaxScheduleLead {
+			return nil, fmt.Errorf("publish_delay must be between %s and %s", tiktok.MinScheduleLead, tiktok.MaxScheduleLead)
+		}
+		if settings.PostAsDraft {
+			return nil, fmt.Errorf("publish_delay cannot be combined with post_as_draft")
+		}
+	}
+	if settings.MinViews < 0 || settings.MinViewsWindow < 0 {
+		return nil, fmt.Errorf("min_views and min_views_window must not be negative")
+	}
+	if settings.MinViewsWindow > 0 && settings.MinViews == 0 {
+		return nil, fmt.Errorf("min_views_window needs min_views")
+	}
+	if settings.DownloadMaxHeight < 0 || settings.DownloadFPS < 0 {
+		return nil, fmt.Errorf("download_max_height and download_fps must not be negative")
+	}
+	if !isContainerName(settings.DownloadContainer) {
+		return nil, fmt.Errorf("download_container %q must be a container name such as mp4, not a format selector", settings.DownloadContainer)
+	}
+	if _, err := settings.UploadWindow(); err != nil {
+		return nil, err
 	}
 
-	return account, nil
+	return m.modify(ctx, accountID, "update account settings", domain.AccountEventUpdated, func(account *domain.Account) error {
+		account.Settings = settings
+		return nil
+	})
+}
+
+// isContainerName reports whether an account's download container is empty or a bare file
+// extension yt-dlp can filter on, like mp4 or webm
+func isContainerName(container string) bool {
+	if container == "" {
+		return true
+	}
+	for _, r := range container {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	// All digits would be a yt-dlp format ID
+	return strings.ContainsFunc(container, func(r rune) bool { return r >= 'a' && r <= 'z' })
 }
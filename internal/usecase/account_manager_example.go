@@ -6,6 +6,7 @@ package usecase
 //
 //	accountManager := usecase.NewAccountManager(accountRepo)
 //	account, err := accountManager.CreateAccountMapping(
+//		ctx,
 //		"UCxxxxxxxxxxxxxxxxxxxxxxxxxx",  // YouTube Channel ID
 //		"tiktok_account_123",            // TikTok Account ID
 //		"tiktok_access_token_here",      // TikTok Access Token
@@ -29,6 +30,7 @@ package usecase
 //
 //	for _, m := range mappings {
 //		account, err := accountManager.CreateAccountMapping(
+//			ctx,
 //			m.youtubeChannelID,
 //			m.tiktokAccountID,
 //			m.tiktokToken,
@@ -43,14 +45,14 @@ package usecase
 //
 // Example 3: Deactivate a mapping (pause a job)
 //
-//	err := accountManager.DeactivateAccountMapping("account_id_here")
+//	err := accountManager.DeactivateAccountMapping(ctx, "account_id_here")
 //	if err != nil {
 //		log.Fatal(err)
 //	}
 //
 // Example 4: Reactivate a mapping (resume a job)
 //
-//	err := accountManager.ActivateAccountMapping("account_id_here")
+//	err := accountManager.ActivateAccountMapping(ctx, "account_id_here")
 //	if err != nil {
 //		log.Fatal(err)
 //	}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
@@ -13,15 +14,57 @@ import (
 	"auto_upload_tiktok/internal/logger"
 )
 
+const (
+	// maxDiscoveryResults caps how many uploads a single check may return, so a burst
+	// larger than one playlist page is still picked up.
+	maxDiscoveryResults = 200
+
+	// publishedAfterOverlap re-scans a short window before the last check to tolerate
+	// clock skew and late-indexed uploads; already-known videos are filtered out anyway.
+	publishedAfterOverlap = 1 * time.Hour
+
+	// freshVideoPriority is the queue priority of videos published within cron.fresh_window
+	freshVideoPriority = 10
+)
+
+var (
+	// ErrAccountNotFound is returned when an on-demand check targets an unknown account
+	ErrAccountNotFound = errors.New("account not found")
+
+	// ErrAccountInactive is returned when an on-demand check targets a deactivated account
+	ErrAccountInactive = errors.New("account is not active")
+
+	// ErrMonitorInProgress is returned when the account is already being checked
+	ErrMonitorInProgress = errors.New("account check already in progress")
+)
+
+// MonitorResult summarizes one check of an account
+type MonitorResult struct {
+	NewVideos         int  // Videos persisted for upload
+	SkippedVideos     int  // Videos persisted as skipped by account filters
+	GatedVideos       int  // Videos persisted for upload but held until they reach min_views
+	ProcessingStarted bool // Whether the new videos were queued for immediate processing
+}
+
 // AccountMonitor monitors YouTube accounts for new videos
 type AccountMonitor struct {
-	config            *config.Config
-	accountRepo       domain.AccountRepository
-	videoRepo         domain.VideoRepository
-	youtubeService    *youtube.Service
-	videoProcessor    *VideoProcessor // Optional: for immediate processing
-	processingLimiter chan struct{}   // Controls concurrent immediate processing to avoid resource spikes
-	baseCtx           context.Context // Root context for background processing
+	config         *config.Config
+	accountRepo    domain.AccountRepository
+	videoRepo      domain.VideoRepository
+	youtubeService *youtube.Service
+	dispatcher     *ImmediateDispatcher // Optional: for immediate processing
+	transactor     domain.Transactor    // Optional: saves discovered videos and the check atomically
+	failureTracker *FailureTracker      // Optional: deactivates accounts whose channel keeps failing
+	notifier       domain.Notifier      // Optional: announces unavailable and recovered channels
+
+	checkingMu sync.Mutex
+	checking   map[string]bool // Accounts currently being checked
+
+	quotaMu          sync.Mutex
+	quotaPausedUntil time.Time // Data API discovery is skipped until then after quota runs out
+
+	publishedAtMu      sync.Mutex
+	publishedAtMissing map[string]bool // YouTube IDs the Data API returned no publish time for
 }
 
 // NewAccountMonitor creates a new account monitor
@@ -31,37 +74,50 @@ func NewAccountMonitor(
 	videoRepo domain.VideoRepository,
 	youtubeService *youtube.Service,
 ) *AccountMonitor {
-	limiterSize := cfg.WorkerPoolSize
-	if limiterSize <= 0 {
-		limiterSize = 1
-	}
-
 	return &AccountMonitor{
-		config:            cfg,
-		accountRepo:       accountRepo,
-		videoRepo:         videoRepo,
-		youtubeService:    youtubeService,
-		processingLimiter: make(chan struct{}, limiterSize),
-		baseCtx:           context.Background(),
+		config:             cfg,
+		accountRepo:        accountRepo,
+		videoRepo:          videoRepo,
+		youtubeService:     youtubeService,
+		checking:           make(map[string]bool),
+		publishedAtMissing: make(map[string]bool),
 	}
 }
 
-// SetVideoProcessor sets the video processor for immediate processing of new videos
-func (m *AccountMonitor) SetVideoProcessor(processor *VideoProcessor) {
-	m.videoProcessor = processor
+// SetDispatcher marks new videos for immediate processing by the dispatcher
+func (m *AccountMonitor) SetDispatcher(dispatcher *ImmediateDispatcher) {
+	m.dispatcher = dispatcher
+}
+
+// SetFailureTracker counts failed channel checks towards the account's failure streak
+func (m *AccountMonitor) SetFailureTracker(tracker *FailureTracker) {
+	m.failureTracker = tracker
+}
+
+// SetTransactor makes each check save its new videos and the account's last checked video in one
+// transaction
+func (m *AccountMonitor) SetTransactor(transactor domain.Transactor) {
+	m.transactor = transactor
 }
 
-// SetBaseContext configures the root context used for long-running background processing.
-func (m *AccountMonitor) SetBaseContext(ctx context.Context) {
-	if ctx == nil {
-		ctx = context.Background()
+// withTx runs fn in a transaction when a transactor is set, otherwise directly on the repositories
+func (m *AccountMonitor) withTx(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	if m.transactor == nil {
+		return fn(ctx, domain.Repositories{Accounts: m.accountRepo, Videos: m.videoRepo})
 	}
-	m.baseCtx = ctx
+	return m.transactor.WithTx(ctx, fn)
 }
 
-// MonitorAllAccounts monitors all active accounts for new videos
+// MonitorAllAccounts monitors all active accounts for new videos. The accounts are scanned
+// concurrently, then the new videos of all of them are enriched together (see enrichVideos)
+// before each account saves its own.
 func (m *AccountMonitor) MonitorAllAccounts(ctx context.Context) error {
-	accounts, err := m.accountRepo.GetAllActive()
+	if until := m.QuotaPausedUntil(); !until.IsZero() {
+		logger.Info().Printf("YouTube API quota exhausted, monitoring paused until %s", until.Format(time.RFC3339))
+		return nil
+	}
+
+	accounts, err := m.accountRepo.GetAllActive(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get active accounts: %w", err)
 	}
@@ -70,19 +126,49 @@ func (m *AccountMonitor) MonitorAllAccounts(ctx context.Context) error {
 		return nil
 	}
 
-	// Monitor accounts concurrently
+	// Scan accounts concurrently; accounts already being checked on demand are left to that check
 	var wg sync.WaitGroup
+	var scansMu sync.Mutex
+	var scans []*accountScan
 	errChan := make(chan error, len(accounts))
 
 	for _, account := range accounts {
+		if !m.beginCheck(account.ID) {
+			continue
+		}
 		wg.Add(1)
 		go func(acc *domain.Account) {
 			defer wg.Done()
-			if err := m.monitorAccount(ctx, acc); err != nil {
-				errChan <- fmt.Errorf("failed to monitor account %s: %w", acc.ID, err)
+			scan, err := m.scanAccount(ctx, acc)
+			if err != nil {
+				m.endCheck(acc.ID)
+				// Quota errors are logged once by pauseForQuota, not per account
+				if errors.Is(err, ErrSourceUnavailable) {
+					logger.Info().Printf("Skipping account %s: %v", acc.ID, err)
+				} else if !errors.Is(err, youtube.ErrQuotaExceeded) {
+					errChan <- fmt.Errorf("failed to monitor account %s: %w", acc.ID, err)
+				}
+				return
 			}
+			scansMu.Lock()
+			scans = append(scans, scan)
+			scansMu.Unlock()
 		}(account)
 	}
+	wg.Wait()
+
+	m.enrichVideos(scans)
+
+	for _, scan := range scans {
+		wg.Add(1)
+		go func(scan *accountScan) {
+			defer wg.Done()
+			defer m.endCheck(scan.account.ID)
+			if _, err := m.saveScan(ctx, scan); err != nil {
+				errChan <- fmt.Errorf("failed to monitor account %s: %w", scan.account.ID, err)
+			}
+		}(scan)
+	}
 
 	wg.Wait()
 	close(errChan)
@@ -100,164 +186,304 @@ func (m *AccountMonitor) MonitorAllAccounts(ctx context.Context) error {
 	return nil
 }
 
-// monitorAccount monitors a single account for new videos
-// Each account represents a job that links one YouTube channel to one TikTok account
-func (m *AccountMonitor) monitorAccount(ctx context.Context, account *domain.Account) error {
-	// Log which job is running (YouTube channel -> TikTok account mapping)
-	// This helps track which job is processing which pair
+// MonitorAccount checks one account for new videos right away instead of waiting for the schedule
+func (m *AccountMonitor) MonitorAccount(ctx context.Context, accountID string) (*MonitorResult, error) {
+	account, err := m.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if account == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	if !account.IsActive {
+		return nil, fmt.Errorf("%w: %s", ErrAccountInactive, accountID)
+	}
+
+	// Scheduled and on-demand checks of the same account would persist the same videos twice
+	if !m.beginCheck(account.ID) {
+		return nil, fmt.Errorf("%w: %s", ErrMonitorInProgress, account.ID)
+	}
+	defer m.endCheck(account.ID)
+
+	// An on-demand check of an unavailable channel asks YouTube again right away
+	if err := m.checkSource(ctx, account, account.SourceStatus.Unavailable != ""); err != nil {
+		return nil, err
+	}
+
+	scan, err := m.scanAccount(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	m.enrichVideos([]*accountScan{scan})
+	return m.saveScan(ctx, scan)
+}
+
+// accountScan is one account's check between discovery and saving
+type accountScan struct {
+	account         *domain.Account
+	scanSince       time.Time       // Start of the window, for logging
+	bootstrapCutoff time.Time       // Set on the first check: older videos are not imported
+	newVideos       []*domain.Video // Discovered videos the account does not have yet
+	lookupFailed    bool            // A repository lookup failed, so a new video may be missing
+}
+
+// scanAccount lists the account's recent uploads and keeps those it does not have yet.
+// Each account represents a job that links one YouTube channel to one TikTok account.
+func (m *AccountMonitor) scanAccount(ctx context.Context, account *domain.Account) (*accountScan, error) {
+	if until := m.QuotaPausedUntil(); !until.IsZero() {
+		return nil, fmt.Errorf("%w: monitoring paused until %s", youtube.ErrQuotaExceeded, until.Format(time.RFC3339))
+	}
+
+	// A suspended, terminated or private channel is not monitored; its status is checked again
+	// every youtube.channel_status_interval instead
+	if err := m.checkSource(ctx, account, false); err != nil {
+		return nil, err
+	}
 
 	// Determine the time window for logging and bootstrap filtering.
-	scanSince := account.LastCheckedAt
-	var bootstrapCutoff time.Time
-	if scanSince.IsZero() {
+	scan := &accountScan{account: account, scanSince: account.LastCheckedAt}
+	publishedAfter := scan.scanSince.Add(-publishedAfterOverlap)
+	if scan.scanSince.IsZero() {
 		// If never checked, only consider the last 24 hours to avoid importing the entire backlog.
-		bootstrapCutoff = time.Now().Add(-24 * time.Hour)
-		scanSince = bootstrapCutoff
+		scan.bootstrapCutoff = time.Now().Add(-24 * time.Hour)
+		scan.scanSince = scan.bootstrapCutoff
+		publishedAfter = scan.bootstrapCutoff
 	}
 
-	// Fetch latest videos from YouTube channel
-	videos, err := m.youtubeService.GetLatestVideos(
-		account.YouTubeChannelID,
-		50, // Max results
-	)
+	// Fetch videos published since the last check from YouTube channel
+	videos, err := m.discoverVideos(ctx, account.YouTubeChannelID, publishedAfter)
 	if err != nil {
-		return fmt.Errorf("failed to get latest videos for YouTube channel %s (TikTok account %s): %w",
+		if errors.Is(err, youtube.ErrQuotaExceeded) {
+			until := m.pauseForQuota(err)
+			return nil, fmt.Errorf("%w: monitoring paused until %s", youtube.ErrQuotaExceeded, until.Format(time.RFC3339))
+		}
+		// A channel that went away fails every check; find out whether it did before counting a failure
+		if sourceErr := m.checkSource(ctx, account, true); sourceErr != nil {
+			return nil, sourceErr
+		}
+		err = fmt.Errorf("failed to get latest videos for YouTube channel %s (TikTok account %s): %w",
 			account.YouTubeChannelID, account.TikTokAccountID, err)
+		if m.failureTracker != nil {
+			m.failureTracker.RecordFailure(ctx, account.ID, domain.FailureSourceDiscovery, err)
+		}
+		return nil, err
+	}
+	if m.failureTracker != nil && account.FailureStreak.ConsecutiveFailures > 0 {
+		m.failureTracker.RecordSuccess(ctx, account.ID, domain.FailureSourceDiscovery)
 	}
 
 	// Filter out videos we've already processed
-	newVideos := make([]*domain.Video, 0)
-	var persistedVideos []*domain.Video
-	var storageErrors []error
 	for _, video := range videos {
-		existing, err := m.videoRepo.GetByYouTubeID(video.YouTubeVideoID)
+		existing, err := m.videoRepo.GetByYouTubeIDAndAccount(ctx, video.YouTubeVideoID, account.ID)
 		if err != nil {
 			logger.Error().Printf("video repository lookup failed for channel %s video %s: %v",
 				account.YouTubeChannelID, video.YouTubeVideoID, err)
-			storageErrors = append(storageErrors, err)
+			scan.lookupFailed = true
 			continue
 		}
 
 		if existing == nil {
-			if !bootstrapCutoff.IsZero() && video.PublishedAt.Before(bootstrapCutoff) {
-				// Skip older content during the initial bootstrap window.
-				continue
-			}
-
 			// New video found
 			video.AccountID = account.ID
-			newVideos = append(newVideos, video)
+			scan.newVideos = append(scan.newVideos, video)
 		}
 	}
 
+	// Videos known to be too old are dropped before enrichment looks them up
+	scan.dropBeforeCutoff()
+	return scan, nil
+}
+
+// dropBeforeCutoff skips older content during the initial bootstrap window. Videos without a
+// publish time are kept.
+func (s *accountScan) dropBeforeCutoff() {
+	if s.bootstrapCutoff.IsZero() {
+		return
+	}
+	kept := s.newVideos[:0]
+	for _, video := range s.newVideos {
+		if video.PublishedAt.IsZero() || !video.PublishedAt.Before(s.bootstrapCutoff) {
+			kept = append(kept, video)
+		}
+	}
+	s.newVideos = kept
+}
+
+// saveScan applies the account's filters to the enriched new videos and saves them
+func (m *AccountMonitor) saveScan(ctx context.Context, scan *accountScan) (*MonitorResult, error) {
+	account := scan.account
+	result := &MonitorResult{}
+
+	// Enrichment may have filled in publish times the source left out
+	scan.dropBeforeCutoff()
+	newVideos := scan.newVideos
+
 	if len(newVideos) == 0 {
 		logger.Info().Printf("No new videos detected for YouTube channel %s (TikTok account %s) since %s",
-			account.YouTubeChannelID, account.TikTokAccountID, scanSince.Format(time.RFC3339))
+			account.YouTubeChannelID, account.TikTokAccountID, scan.scanSince.Format(time.RFC3339))
 	} else {
 		logger.Info().Printf("Discovered %d new videos for YouTube channel %s (TikTok account %s); newest video ID: %s",
 			len(newVideos), account.YouTubeChannelID, account.TikTokAccountID, newVideos[0].YouTubeVideoID)
 	}
 
-	// Save new videos
+	// Apply account filters; filtered-out videos are still persisted as skipped so
+	// they are not rediscovered on the next cycle.
+	m.applyFilters(account, newVideos)
+	m.applyViewGate(account, newVideos)
+
+	// Save new videos together with the account's last checked video, so the account never
+	// points at a video that was not stored
 	if len(newVideos) > 1 {
 		sort.Slice(newVideos, func(i, j int) bool {
-			return newVideos[i].PublishedAt.After(newVideos[j].PublishedAt)
+			return newVideos[i].PublishedOrCreatedAt().After(newVideos[j].PublishedOrCreatedAt())
 		})
 	}
-	for _, video := range newVideos {
-		if err := m.videoRepo.Save(video); err != nil {
-			logger.Error().Printf("failed to persist video %s for channel %s: %v", video.YouTubeVideoID, account.YouTubeChannelID, err)
-			storageErrors = append(storageErrors, err)
-			continue
+	lookupFailed := scan.lookupFailed
+	var persistedVideos []*domain.Video
+	skippedVideos, gatedVideos := 0, 0
+	now := time.Now()
+	err := m.withTx(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		persistedVideos, skippedVideos, gatedVideos = nil, 0, 0
+		for _, video := range newVideos {
+			video.Immediate = m.dispatcher != nil && video.Status == domain.VideoStatusPending
+			video.Priority = m.discoveryPriority(video, now)
+			if err := repos.Videos.Save(ctx, video); err != nil {
+				return fmt.Errorf("failed to persist video %s: %w", video.YouTubeVideoID, err)
+			}
+			if video.Status == domain.VideoStatusSkipped {
+				skippedVideos++
+				continue
+			}
+			if video.Status == domain.VideoStatusGated {
+				gatedVideos++
+			}
+			persistedVideos = append(persistedVideos, video)
 		}
-		persistedVideos = append(persistedVideos, video)
-	}
 
-	// Update account's last checked time
-	lastVideoID := account.LastVideoID
-	if len(persistedVideos) > 0 {
-		lastVideoID = persistedVideos[0].YouTubeVideoID
-	}
+		// A failed lookup may have hidden a new video; check again from the same point next time
+		if lookupFailed {
+			return nil
+		}
 
-	if len(storageErrors) > 0 {
-		return fmt.Errorf("storage errors occurred while processing account %s", account.ID)
+		lastVideoID := account.LastVideoID
+		if len(persistedVideos) > 0 {
+			lastVideoID = persistedVideos[0].YouTubeVideoID
+		}
+		if err := repos.Accounts.UpdateLastChecked(ctx, account.ID, lastVideoID, time.Now()); err != nil {
+			return fmt.Errorf("failed to update last checked: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error().Printf("failed to persist videos for channel %s: %v", account.YouTubeChannelID, err)
+		return result, fmt.Errorf("storage errors occurred while processing account %s: %w", account.ID, err)
 	}
 
-	now := time.Now()
-	if err := m.accountRepo.UpdateLastChecked(account.ID, lastVideoID, now); err != nil {
-		return fmt.Errorf("failed to update last checked: %w", err)
+	result.NewVideos = len(persistedVideos)
+	result.SkippedVideos = skippedVideos
+	result.GatedVideos = gatedVideos
+
+	if lookupFailed {
+		return result, fmt.Errorf("storage errors occurred while processing account %s", account.ID)
 	}
 
 	if len(persistedVideos) > 0 {
 		logger.Info().Printf("Persisted %d new videos for YouTube channel %s (TikTok account %s)",
 			len(persistedVideos), account.YouTubeChannelID, account.TikTokAccountID)
 
-		// Process new videos immediately instead of waiting for schedule
-		if m.videoProcessor != nil {
-			logger.Info().Printf("Starting immediate processing for %d new videos from channel %s",
-				len(persistedVideos), account.YouTubeChannelID)
-
-			// Process videos in background goroutines to avoid blocking monitoring
-			for _, video := range persistedVideos {
-				m.launchImmediateProcessing(video)
-			}
+		// The videos were saved marked immediate; the dispatcher picks them up within seconds
+		if m.dispatcher != nil && len(persistedVideos) > gatedVideos {
+			logger.Info().Printf("Queued %d new videos from channel %s for immediate processing",
+				len(persistedVideos)-gatedVideos, account.YouTubeChannelID)
+			m.dispatcher.Notify()
+			result.ProcessingStarted = true
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
-// launchImmediateProcessing starts asynchronous processing with concurrency safeguards to avoid leaks/spikes.
-func (m *AccountMonitor) launchImmediateProcessing(video *domain.Video) {
-	if m.videoProcessor == nil {
-		return
+// discoveryPriority returns the queue priority of a newly found video: freshVideoPriority when
+// it was published within cron.fresh_window, so it is not held up behind older pending videos
+func (m *AccountMonitor) discoveryPriority(video *domain.Video, now time.Time) int {
+	window := m.config.CronFreshWindow
+	if window <= 0 || video.PublishedAt.IsZero() || now.Sub(video.PublishedAt) > window {
+		return 0
 	}
+	return freshVideoPriority
+}
 
-	baseCtx := m.baseCtx
-	if baseCtx == nil {
-		baseCtx = context.Background()
+// beginCheck marks an account as being checked, returning false if a check is already running
+func (m *AccountMonitor) beginCheck(accountID string) bool {
+	m.checkingMu.Lock()
+	defer m.checkingMu.Unlock()
+
+	if m.checking[accountID] {
+		return false
 	}
+	m.checking[accountID] = true
+	return true
+}
 
-	go func(v *domain.Video) {
-		if !m.acquireProcessingSlot(baseCtx) {
-			logger.Error().Printf("Skipping immediate processing for video %s: context cancelled before slot available", v.YouTubeVideoID)
-			return
-		}
-		defer m.releaseProcessingSlot()
+func (m *AccountMonitor) endCheck(accountID string) {
+	m.checkingMu.Lock()
+	defer m.checkingMu.Unlock()
 
-		processCtx, cancel := context.WithTimeout(baseCtx, 30*time.Minute)
-		defer cancel()
+	delete(m.checking, accountID)
+}
 
-		if err := m.videoProcessor.ProcessVideo(processCtx, v); err != nil {
-			logger.Error().Printf("Failed to process video %s immediately: %v", v.YouTubeVideoID, err)
-		} else {
-			logger.Info().Printf("Successfully processed video %s immediately after discovery", v.YouTubeVideoID)
+// applyFilters marks videos rejected by the account settings as skipped
+func (m *AccountMonitor) applyFilters(account *domain.Account, videos []*domain.Video) {
+	for _, video := range videos {
+		if reason := filterReason(account.Settings, video); reason != "" {
+			video.Status = domain.VideoStatusSkipped
+			video.ErrorMessage = reason
+			logger.Info().Printf("Skipping video %s for channel %s: %s", video.YouTubeVideoID, account.YouTubeChannelID, reason)
 		}
-	}(video)
+	}
 }
 
-func (m *AccountMonitor) acquireProcessingSlot(ctx context.Context) bool {
-	limiter := m.processingLimiter
-	if limiter == nil {
-		return true
+// QuotaPausedUntil returns when Data API monitoring resumes, or the zero time when it is not paused.
+// The RSS discovery mode costs no quota and is never paused.
+func (m *AccountMonitor) QuotaPausedUntil() time.Time {
+	if m.config.YouTubeDiscoveryMode == config.DiscoveryModeRSS {
+		return time.Time{}
 	}
 
-	select {
-	case limiter <- struct{}{}:
-		return true
-	case <-ctx.Done():
-		return false
+	m.quotaMu.Lock()
+	defer m.quotaMu.Unlock()
+
+	if !time.Now().Before(m.quotaPausedUntil) {
+		return time.Time{}
 	}
+	return m.quotaPausedUntil
 }
 
-func (m *AccountMonitor) releaseProcessingSlot() {
-	limiter := m.processingLimiter
-	if limiter == nil {
-		return
+// pauseForQuota stops Data API monitoring until the quota resets (midnight Pacific time) or for
+// youtube.quota_cooloff, and returns the resume time
+func (m *AccountMonitor) pauseForQuota(err error) time.Time {
+	now := time.Now()
+	until := youtube.NextQuotaReset(now)
+	if m.config.YouTubeQuotaCooloff > 0 {
+		until = now.Add(m.config.YouTubeQuotaCooloff)
+	}
+
+	m.quotaMu.Lock()
+	defer m.quotaMu.Unlock()
+
+	if now.Before(m.quotaPausedUntil) {
+		// Concurrent checks of other accounts hit the same limit; keep the first pause
+		return m.quotaPausedUntil
 	}
+	m.quotaPausedUntil = until
+	logger.Error().Printf("YouTube API quota exhausted, pausing monitoring of all accounts until %s: %v", until.Format(time.RFC3339), err)
+	return until
+}
 
-	select {
-	case <-limiter:
-	default:
+// discoverVideos lists recent uploads using the configured discovery mode
+func (m *AccountMonitor) discoverVideos(ctx context.Context, channelID string, publishedAfter time.Time) ([]*domain.Video, error) {
+	if m.config.YouTubeDiscoveryMode == config.DiscoveryModeRSS {
+		return m.youtubeService.GetLatestVideosRSS(channelID, publishedAfter)
 	}
+	return m.youtubeService.GetLatestVideos(ctx, channelID, maxDiscoveryResults, publishedAfter)
 }
@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// maxStaleWriteAttempts bounds how often a change is applied to a freshly loaded account when
+// other writers keep saving it in between
+const maxStaleWriteAttempts = 5
+
+// modifyAccount loads the account, applies change and saves it. When the account was saved by
+// someone else in the meantime (domain.ErrStaleWrite) it is loaded again and change reapplied, so
+// change must derive everything it writes from its argument and the caller's inputs. what names
+// the change in the returned error, e.g. "update account tokens".
+func modifyAccount(
+	ctx context.Context,
+	accountRepo domain.AccountRepository,
+	accountID string,
+	what string,
+	change func(account *domain.Account) error,
+) (*domain.Account, error) {
+	for attempt := 1; ; attempt++ {
+		account, err := accountRepo.GetByID(ctx, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account: %w", err)
+		}
+		if account == nil {
+			return nil, fmt.Errorf("account not found: %s", accountID)
+		}
+
+		if err := change(account); err != nil {
+			return nil, err
+		}
+		account.UpdatedAt = time.Now()
+
+		err = accountRepo.Save(ctx, account)
+		if err == nil {
+			return account, nil
+		}
+		if !errors.Is(err, domain.ErrStaleWrite) || attempt == maxStaleWriteAttempts {
+			return nil, fmt.Errorf("failed to %s: %w", what, err)
+		}
+		logger.Info().Printf("Account %s was changed concurrently, retrying to %s (attempt %d)", accountID, what, attempt+1)
+	}
+}
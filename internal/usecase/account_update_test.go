@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/memory"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+)
+
+// accountBackends returns a fresh account repository of each kind holding the account acc-1
+func accountBackends(t *testing.T) map[string]domain.AccountRepository {
+	t.Helper()
+	backends := map[string]domain.AccountRepository{
+		"memory": memory.NewAccountRepository(),
+		"sqlite": sqlitetest.OpenTest(t).Accounts,
+	}
+	for name, accounts := range backends {
+		account := &domain.Account{ID: "acc-1", YouTubeChannelID: "UC-1", TikTokAccountID: "tt-1", TikTokAccessToken: "act.old", IsActive: true}
+		if err := accounts.Save(context.Background(), account); err != nil {
+			t.Fatalf("%s: save account: %v", name, err)
+		}
+	}
+	return backends
+}
+
+func TestAccountSaveRejectsStaleCopy(t *testing.T) {
+	for name, accounts := range accountBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			refresh, _ := accounts.GetByID(ctx, "acc-1")
+			edit, _ := accounts.GetByID(ctx, "acc-1")
+
+			refresh.TikTokAccessToken = "act.new"
+			if err := accounts.Save(ctx, refresh); err != nil {
+				t.Fatalf("Save() of the token refresh error = %v", err)
+			}
+			edit.CommentTemplate = "edited"
+			if err := accounts.Save(ctx, edit); !errors.Is(err, domain.ErrStaleWrite) {
+				t.Fatalf("Save() of the stale edit error = %v, want %v", err, domain.ErrStaleWrite)
+			}
+			stored, _ := accounts.GetByID(ctx, "acc-1")
+			if stored.TikTokAccessToken != "act.new" || stored.CommentTemplate != "" {
+				t.Errorf("stored account = %q %q, want only the token refresh", stored.TikTokAccessToken, stored.CommentTemplate)
+			}
+		})
+	}
+}
+
+func TestModifyAccountKeepsConcurrentChanges(t *testing.T) {
+	for name, accounts := range accountBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			// Both changes wait until both have loaded the account, so one of the saves is stale
+			var loaded sync.WaitGroup
+			loaded.Add(2)
+			var refreshCalls, editCalls atomic.Int32
+			arrive := func(calls *atomic.Int32) {
+				if calls.Add(1) == 1 {
+					loaded.Done()
+					loaded.Wait()
+				}
+			}
+
+			var wg sync.WaitGroup
+			errs := make(chan error, 2)
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := modifyAccount(ctx, accounts, "acc-1", "save refreshed token", func(account *domain.Account) error {
+					arrive(&refreshCalls)
+					account.TikTokAccessToken = "act.new"
+					return nil
+				})
+				errs <- err
+			}()
+			go func() {
+				defer wg.Done()
+				_, err := modifyAccount(ctx, accounts, "acc-1", "update account", func(account *domain.Account) error {
+					arrive(&editCalls)
+					account.CommentTemplate = "edited"
+					return nil
+				})
+				errs <- err
+			}()
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Fatalf("modifyAccount() error = %v", err)
+				}
+			}
+
+			stored, _ := accounts.GetByID(ctx, "acc-1")
+			if stored.TikTokAccessToken != "act.new" || stored.CommentTemplate != "edited" {
+				t.Errorf("stored account = %q %q, want both changes", stored.TikTokAccessToken, stored.CommentTemplate)
+			}
+			if calls := refreshCalls.Load() + editCalls.Load(); calls != 3 {
+				t.Errorf("changes applied %d times, want 3 (the losing one reapplied once)", calls)
+			}
+			if stored.Version != 3 {
+				t.Errorf("Version = %d, want 3", stored.Version)
+			}
+		})
+	}
+}
+
+func TestModifyAccountGivesUpAfterMaxAttempts(t *testing.T) {
+	for name, accounts := range accountBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			attempts := 0
+			_, err := modifyAccount(ctx, accounts, "acc-1", "update account", func(account *domain.Account) error {
+				attempts++
+				// Another writer saves the account before every save of this change
+				other, err := accounts.GetByID(ctx, "acc-1")
+				if err != nil {
+					return err
+				}
+				other.TikTokAccessToken = "act.other"
+				if err := accounts.Save(ctx, other); err != nil {
+					return err
+				}
+				account.CommentTemplate = "edited"
+				return nil
+			})
+			if !errors.Is(err, domain.ErrStaleWrite) {
+				t.Fatalf("modifyAccount() error = %v, want %v", err, domain.ErrStaleWrite)
+			}
+			if attempts != maxStaleWriteAttempts {
+				t.Errorf("change applied %d times, want %d", attempts, maxStaleWriteAttempts)
+			}
+			stored, _ := accounts.GetByID(ctx, "acc-1")
+			if stored.CommentTemplate != "" {
+				t.Errorf("CommentTemplate = %q, the failed change was saved", stored.CommentTemplate)
+			}
+		})
+	}
+}
+
+func TestAccountSaveKeepsMonitorProgress(t *testing.T) {
+	for name, accounts := range accountBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			edit, _ := accounts.GetByID(ctx, "acc-1")
+
+			// The monitor checks the channel while the edit is open
+			checkedAt := time.Now().UTC().Truncate(time.Second)
+			if err := accounts.UpdateLastChecked(ctx, "acc-1", "yt-9", checkedAt); err != nil {
+				t.Fatalf("UpdateLastChecked() error = %v", err)
+			}
+			edit.CommentTemplate = "edited"
+			if err := accounts.Save(ctx, edit); err != nil {
+				t.Fatalf("Save() of the edit error = %v", err)
+			}
+
+			stored, _ := accounts.GetByID(ctx, "acc-1")
+			if stored.LastVideoID != "yt-9" || !stored.LastCheckedAt.Equal(checkedAt) || stored.CommentTemplate != "edited" {
+				t.Errorf("stored account = %q %v %q, want the check and the edit", stored.LastVideoID, stored.LastCheckedAt, stored.CommentTemplate)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// urlPattern matches links with a scheme anywhere, also glued to the word before them; the
+	// punctuation of the sentence they end is not part of them
+	urlPattern = regexp.MustCompile(`(?i)https?://[^\s<>"'()\[\]{}]*[^\s<>"'()\[\]{}.,;:!?]`)
+
+	// wwwPattern matches scheme-less links not glued to a word, keeping what precedes them
+	wwwPattern = regexp.MustCompile(`(?i)(^|[^\p{L}\p{N}_.@/-])www\.[^\s<>"'()\[\]{}]*[^\s<>"'()\[\]{}.,;:!?]`)
+
+	// hashtagPattern matches hashtags at the start of a word, keeping what precedes them
+	hashtagPattern = regexp.MustCompile(`(^|\s)#[\p{L}\p{N}_]+`)
+
+	// emptyBracketsPattern matches brackets left empty by a removed link
+	emptyBracketsPattern = regexp.MustCompile(`\(\s*\)|\[\s*\]|\{\s*\}|<\s*>`)
+)
+
+// CaptionOptions configure SanitizeCaption
+type CaptionOptions struct {
+	UseDescription bool // false drops the description
+	StripHashtags  bool // Remove hashtags already in the text
+	MaxLength      int  // Characters per title and description; 0 means no limit
+}
+
+// SanitizeCaption cleans a YouTube title and description for a TikTok post: links are removed,
+// hashtags too when opts.StripHashtags is set, whitespace is collapsed to single spaces with at
+// most one blank line between paragraphs, and text longer than opts.MaxLength characters is cut
+// at a word boundary when one is near and ends in "…". Emoji and other text are left as they are.
+func SanitizeCaption(title, description string, opts CaptionOptions) (string, string) {
+	title = strings.Join(strings.Fields(cleanCaptionText(title, opts.StripHashtags)), " ")
+	title = truncateCaption(title, opts.MaxLength)
+
+	if !opts.UseDescription {
+		return title, ""
+	}
+	description = truncateCaption(collapseParagraphs(cleanCaptionText(description, opts.StripHashtags)), opts.MaxLength)
+	return title, description
+}
+
+// cleanCaptionText removes links, and hashtags when asked, from text
+func cleanCaptionText(text string, stripHashtags bool) string {
+	text = urlPattern.ReplaceAllString(text, "")
+	text = wwwPattern.ReplaceAllString(text, "$1")
+	if stripHashtags {
+		text = hashtagPattern.ReplaceAllString(text, "$1")
+	}
+	return emptyBracketsPattern.ReplaceAllString(text, "")
+}
+
+// collapseParagraphs collapses spaces within each line and keeps at most one blank line between
+// paragraphs, dropping blank lines at the start and end
+func collapseParagraphs(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// truncateCaption cuts text to maxLength characters including the "…" it then ends in. The cut
+// goes back to the last space when that keeps most of the text, and never splits an emoji
+// sequence, a flag or a letter from its combining marks.
+func truncateCaption(text string, maxLength int) string {
+	if maxLength <= 0 || utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+	runes := []rune(text)
+	cut := maxLength - 1
+	for cut > 0 && continuesCluster(runes, cut) {
+		cut--
+	}
+	if space := lastSpace(runes[:cut]); space > cut*3/4 {
+		cut = space
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + "…"
+}
+
+// continuesCluster reports whether cutting before runes[i] would split what is displayed as one
+// character: a joined emoji, a modifier or variation of the rune before, a combining mark, or
+// the second half of a flag
+func continuesCluster(runes []rune, i int) bool {
+	r, prev := runes[i], runes[i-1]
+	switch {
+	case r == '\u200d' || prev == '\u200d':
+		return true
+	case r >= 0xfe00 && r <= 0xfe0f, r >= 0x1f3fb && r <= 0x1f3ff, r >= 0xe0020 && r <= 0xe007f:
+		return true
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return true
+	case isRegionalIndicator(r) && isRegionalIndicator(prev):
+		// Flags are pairs; an odd run of indicators before i means i completes one
+		n := 0
+		for j := i - 1; j >= 0 && isRegionalIndicator(runes[j]); j-- {
+			n++
+		}
+		return n%2 == 1
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// lastSpace returns the index of the last whitespace rune, or -1
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return i
+		}
+	}
+	return -1
+}
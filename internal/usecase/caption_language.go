@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,4})?$`)
+
+// NormalizeLanguage lower-cases a language code ("JA" -> "ja", "pt-BR" -> "pt-br")
+func NormalizeLanguage(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// ValidateLanguage checks an ISO 639 code with an optional region, e.g. "ja" or "pt-BR"
+func ValidateLanguage(code string) error {
+	if !languageCodePattern.MatchString(NormalizeLanguage(code)) {
+		return fmt.Errorf("invalid language code %q: use an ISO 639-1 code such as \"ja\" or \"pt-BR\"", code)
+	}
+	return nil
+}
+
+// sameLanguage compares the base languages of two codes ("en-us" matches "en"); unknown never matches
+func sameLanguage(a, b string) bool {
+	a, _, _ = strings.Cut(NormalizeLanguage(a), "-")
+	b, _, _ = strings.Cut(NormalizeLanguage(b), "-")
+	return a != "" && a == b
+}
+
+// DetectLanguage guesses the language of a title from its script. It returns "" for text it cannot
+// tell apart, such as most Latin-script languages; the translation provider detects those.
+func DetectLanguage(text string) string {
+	counts := make(map[string]int)
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			counts["ja"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case isVietnameseLetter(r):
+			counts["vi"]++
+		case unicode.Is(unicode.Latin, r):
+			counts["latin"]++
+		}
+	}
+
+	// Japanese mixes kanji with kana; any kana decides it
+	if counts["ja"] > 0 {
+		return "ja"
+	}
+	// A few Vietnamese-only letters are enough, the rest of the title is plain Latin
+	if counts["vi"] > 0 {
+		return "vi"
+	}
+
+	best, bestCount := "", 0
+	for lang, n := range counts {
+		if n > bestCount || (n == bestCount && lang < best) {
+			best, bestCount = lang, n
+		}
+	}
+	if best == "latin" {
+		return ""
+	}
+	return best
+}
+
+// isVietnameseLetter reports letters used by Vietnamese but no other major Latin-script language
+func isVietnameseLetter(r rune) bool {
+	switch unicode.ToLower(r) {
+	case 'đ', 'ơ', 'ư', 'ă':
+		return true
+	}
+	// Latin Extended Additional: the stacked tone marks (ạ, ế, ộ, ...)
+	return r >= 0x1EA0 && r <= 0x1EF9
+}
+
+// SetTranslator enables caption translation for accounts with a caption language
+func (p *VideoProcessor) SetTranslator(translator domain.Translator) {
+	p.translator = translator
+}
+
+// captionTitle returns the title used in the TikTok caption: the title translated into the
+// account's caption language when translation is enabled and the languages differ, otherwise the
+// original. Translations are cached on the video; failures fall back to the original title.
+func (p *VideoProcessor) captionTitle(ctx context.Context, account *domain.Account, video *domain.Video) string {
+	target := NormalizeLanguage(account.Settings.CaptionLanguage)
+	if p.translator == nil || target == "" || strings.TrimSpace(video.Title) == "" {
+		return video.Title
+	}
+	// Experiment arm captions are written by the operator and used verbatim
+	if video.ParentVideoID != "" && video.ClipEnd == 0 {
+		return video.Title
+	}
+	if video.TranslatedLanguage == target && video.TranslatedTitle != "" {
+		return video.TranslatedTitle
+	}
+
+	detected := DetectLanguage(video.Title)
+	if sameLanguage(detected, target) {
+		return video.Title
+	}
+
+	translation, err := p.translator.Translate(ctx, video.Title, target)
+	if err != nil {
+		logger.Error().Printf("Warning: %s translation of video %s to %s failed, using the original title: %v",
+			p.translator.Name(), video.YouTubeVideoID, target, err)
+		return video.Title
+	}
+
+	source := translation.SourceLanguage
+	if source == "" {
+		source = detected
+	}
+	translated := translation.Text
+	if sameLanguage(source, target) || strings.TrimSpace(translated) == "" {
+		// Cache the original so the provider is not asked again
+		translated = video.Title
+	}
+
+	video.TitleLanguage = source
+	video.TranslatedLanguage = target
+	video.TranslatedTitle = translated
+	if err := p.videoRepo.UpdateTranslation(context.WithoutCancel(ctx), video.ID, source, target, translated); err != nil {
+		logger.Error().Printf("Failed to cache translated title for video %s: %v", video.YouTubeVideoID, err)
+	}
+	logger.Info().Printf("Translated title of video %s from %s to %s via %s", video.YouTubeVideoID, source, target, p.translator.Name())
+	return translated
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeCaption(t *testing.T) {
+	tests := []struct {
+		name            string
+		title, desc     string
+		opts            CaptionOptions
+		wantTitle       string
+		wantDescription string
+	}{
+		{
+			name:            "link glued to a word",
+			title:           "Watch:https://example.com/full now",
+			desc:            "Full video:https://youtu.be/abc123",
+			opts:            CaptionOptions{UseDescription: true},
+			wantTitle:       "Watch: now",
+			wantDescription: "Full video:",
+		},
+		{
+			name:            "punctuation after a link is kept",
+			title:           "Read https://example.com/post. Then comment!",
+			desc:            "Shop: https://example.com/shop?id=1, or https://example.com/b!",
+			opts:            CaptionOptions{UseDescription: true},
+			wantTitle:       "Read . Then comment!",
+			wantDescription: "Shop: , or !",
+		},
+		{
+			name:            "link in brackets",
+			title:           "Merch (https://example.com/merch) out now",
+			opts:            CaptionOptions{UseDescription: true},
+			wantTitle:       "Merch out now",
+			wantDescription: "",
+		},
+		{
+			name:      "scheme-less links",
+			title:     "Visit www.example.com today, mail me@www.example.com",
+			wantTitle: "Visit today, mail me@www.example.com",
+		},
+		{
+			name:            "Vietnamese text",
+			title:           "Học tiếng Việt https://youtu.be/abc #hocTiengViet",
+			desc:            "Chào các bạn!   Đăng ký kênh nhé\n\n\n\nCảm ơn đã xem ❤️",
+			opts:            CaptionOptions{UseDescription: true},
+			wantTitle:       "Học tiếng Việt #hocTiengViet",
+			wantDescription: "Chào các bạn! Đăng ký kênh nhé\n\nCảm ơn đã xem ❤️",
+		},
+		{
+			name:      "hashtags stripped",
+			title:     "#shorts Funny cat #mèo video C# tips",
+			opts:      CaptionOptions{StripHashtags: true},
+			wantTitle: "Funny cat video C# tips",
+		},
+		{
+			name:      "emoji kept",
+			title:     "🎉 Family day 👨‍👩‍👧 🇻🇳 👍🏽",
+			wantTitle: "🎉 Family day 👨‍👩‍👧 🇻🇳 👍🏽",
+		},
+		{
+			name:            "description dropped",
+			title:           "Title",
+			desc:            "Description",
+			opts:            CaptionOptions{UseDescription: false},
+			wantTitle:       "Title",
+			wantDescription: "",
+		},
+		{
+			name:            "paragraphs collapsed",
+			title:           "  spaced \t title \n",
+			desc:            "\n\nfirst   line\r\nsecond line\r\n\r\n\r\n  third  \n\n",
+			opts:            CaptionOptions{UseDescription: true},
+			wantTitle:       "spaced title",
+			wantDescription: "first line\nsecond line\n\nthird",
+		},
+		{
+			name:            "both truncated",
+			title:           "Chào mừng các bạn đến với kênh",
+			desc:            "Chào mừng các bạn đến với kênh",
+			opts:            CaptionOptions{UseDescription: true, MaxLength: 20},
+			wantTitle:       "Chào mừng các bạn…",
+			wantDescription: "Chào mừng các bạn…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, description := SanitizeCaption(tt.title, tt.desc, tt.opts)
+			if title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", title, tt.wantTitle)
+			}
+			if description != tt.wantDescription {
+				t.Errorf("description = %q, want %q", description, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestTruncateCaption(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxLength int
+		want      string
+	}{
+		{"no limit", "hello world", 0, "hello world"},
+		{"fits exactly", "hello", 5, "hello"},
+		{"cut at a word", "Chào mừng các bạn đến với kênh", 20, "Chào mừng các bạn…"},
+		{"cut inside a long word", "abcdefghijklmnop", 6, "abcde…"},
+		{"space too far back", "a bcdefghijklmnop", 10, "a bcdefgh…"},
+		{"before a ZWJ family", "abc👨‍👩‍👧def", 6, "abc…"},
+		{"after a ZWJ family", "abc👨‍👩‍👧def", 10, "abc👨‍👩‍👧d…"},
+		{"inside a flag", "ab🇻🇳🇺🇸", 4, "ab…"},
+		{"between flags", "ab🇻🇳🇺🇸", 5, "ab🇻🇳…"},
+		{"inside the second flag", "ab🇻🇳🇺🇸x", 6, "ab🇻🇳…"},
+		{"before a skin tone", "hi 👍🏽 there", 5, "hi…"},
+		{"after a skin tone", "hi 👍🏽 there", 7, "hi 👍🏽…"},
+		{"before a variation selector", "ok ❤️ love", 5, "ok…"},
+		{"combining Vietnamese marks", "Vie\u0302\u0323t Nam", 5, "Vi…"},
+		{"precomposed Vietnamese", "Việt Nam", 4, "Việ…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateCaption(tt.text, tt.maxLength)
+			if got != tt.want {
+				t.Errorf("truncateCaption(%q, %d) = %q, want %q", tt.text, tt.maxLength, got, tt.want)
+			}
+			if tt.maxLength > 0 && utf8.RuneCountInString(got) > tt.maxLength {
+				t.Errorf("truncateCaption(%q, %d) is %d characters long", tt.text, tt.maxLength, utf8.RuneCountInString(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// ChannelResolver turns the channel references accepted for account mappings (channel IDs,
+// @handles and youtube.com URLs) into channel IDs. Resolved handles are stored, so a handle in
+// the bootstrap config is looked up once and not on every restart.
+type ChannelResolver struct {
+	youtubeService *youtube.Service
+	handleRepo     domain.ChannelHandleRepository
+}
+
+// NewChannelResolver creates a new channel resolver
+func NewChannelResolver(youtubeService *youtube.Service, handleRepo domain.ChannelHandleRepository) *ChannelResolver {
+	return &ChannelResolver{
+		youtubeService: youtubeService,
+		handleRepo:     handleRepo,
+	}
+}
+
+// Resolve returns the channel ID the reference names, along with the normalized handle when the
+// reference was a handle or a handle URL
+func (r *ChannelResolver) Resolve(ctx context.Context, ref string) (string, string, error) {
+	channelID, handle, err := youtube.ParseChannelRef(ref)
+	if err != nil || channelID != "" {
+		return channelID, "", err
+	}
+
+	if r == nil {
+		return "", "", fmt.Errorf("cannot resolve channel handle %s: handle lookup is not available here, use the channel ID", handle)
+	}
+
+	cached, err := r.handleRepo.Get(ctx, handle)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get resolved channel handle: %w", err)
+	}
+	if cached != nil {
+		return cached.ChannelID, handle, nil
+	}
+
+	channelID, err = r.youtubeService.ResolveChannelHandle(ctx, handle)
+	if errors.Is(err, youtube.ErrChannelNotFound) {
+		return "", "", fmt.Errorf("channel handle %s does not match any YouTube channel", handle)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve channel handle %s: %w", handle, err)
+	}
+
+	if err := r.handleRepo.Save(ctx, &domain.ChannelHandle{Handle: handle, ChannelID: channelID}); err != nil {
+		// The mapping can still be created; the handle is looked up again next time
+		logger.Error().Printf("Failed to store resolved channel handle %s: %v", handle, err)
+	}
+	logger.Info().Printf("Resolved channel handle %s to %s", handle, channelID)
+	return channelID, handle, nil
+}
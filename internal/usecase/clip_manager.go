@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// Clip limits
+const (
+	minClipDuration  = 3 * time.Second  // TikTok rejects shorter videos
+	maxClipDuration  = 10 * time.Minute // Longest video TikTok accepts through upload
+	maxClipsPerVideo = 20
+)
+
+var (
+	// ErrInvalidClips is returned when a clip list fails validation
+	ErrInvalidClips = errors.New("invalid clips")
+
+	// ErrClipsNotAllowed is returned when the video cannot be split in its current state
+	ErrClipsNotAllowed = errors.New("video cannot be split into clips")
+)
+
+// ClipSpec describes one clip to cut from a source video
+type ClipSpec struct {
+	Start time.Duration
+	End   time.Duration
+	Title string // Optional; defaults to the source title with a part number
+}
+
+// ClipManager splits source videos into clips that are uploaded as separate TikToks
+type ClipManager struct {
+	videoRepo domain.VideoRepository
+}
+
+// NewClipManager creates a new clip manager
+func NewClipManager(videoRepo domain.VideoRepository) *ClipManager {
+	return &ClipManager{
+		videoRepo: videoRepo,
+	}
+}
+
+// CreateClips validates the ranges and queues one clip video per range. The source is
+// downloaded once when the first clip is processed; the source itself is no longer uploaded.
+func (m *ClipManager) CreateClips(ctx context.Context, videoID string, specs []ClipSpec) (*domain.Video, []*domain.Video, error) {
+	if err := validateClips(specs); err != nil {
+		return nil, nil, err
+	}
+
+	parent, err := m.videoRepo.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get video: %w", err)
+	}
+	if parent == nil {
+		return nil, nil, fmt.Errorf("video not found: %s", videoID)
+	}
+
+	switch {
+	case parent.ParentVideoID != "":
+		return nil, nil, fmt.Errorf("%w: video %s is itself a clip", ErrClipsNotAllowed, videoID)
+	case parent.ClipCount > 0:
+		return nil, nil, fmt.Errorf("%w: video %s already has %d clips", ErrClipsNotAllowed, videoID, parent.ClipCount)
+	case parent.Status == domain.VideoStatusDownloading, parent.Status == domain.VideoStatusDownloaded,
+		parent.Status == domain.VideoStatusUploading, parent.Status == domain.VideoStatusPublishing:
+		return nil, nil, fmt.Errorf("%w: video %s is being processed (%s)", ErrClipsNotAllowed, videoID, parent.Status)
+	}
+
+	ordered := make([]ClipSpec, len(specs))
+	copy(ordered, specs)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Start < ordered[j].Start })
+
+	// Mark the source as split first so the processor stops picking it up as a whole
+	parent.ClipCount = len(ordered)
+	parent.Status = domain.VideoStatusPending
+	parent.ErrorMessage = ""
+	if err := m.videoRepo.Save(ctx, parent); err != nil {
+		return nil, nil, fmt.Errorf("failed to update source video: %w", err)
+	}
+
+	clips := make([]*domain.Video, 0, len(ordered))
+	for i, spec := range ordered {
+		title := strings.TrimSpace(spec.Title)
+		if title == "" {
+			title = fmt.Sprintf("%s (part %d/%d)", parent.Title, i+1, len(ordered))
+		}
+
+		clip := &domain.Video{
+			YouTubeVideoID: fmt.Sprintf("%s#clip%d", parent.YouTubeVideoID, i+1),
+			AccountID:      parent.AccountID,
+			Title:          title,
+			Description:    parent.Description,
+			ThumbnailURL:   parent.ThumbnailURL,
+			VideoURL:       parent.VideoURL,
+			Status:         domain.VideoStatusPending,
+			PublishedAt:    parent.PublishedAt,
+			ParentVideoID:  parent.ID,
+			ClipStart:      spec.Start,
+			ClipEnd:        spec.End,
+		}
+		if err := m.videoRepo.Save(ctx, clip); err != nil {
+			return nil, nil, fmt.Errorf("failed to save clip %d: %w", i+1, err)
+		}
+		clips = append(clips, clip)
+	}
+
+	return parent, clips, nil
+}
+
+// validateClips rejects empty, malformed, overlapping and over-long ranges
+func validateClips(specs []ClipSpec) error {
+	if len(specs) == 0 {
+		return fmt.Errorf("%w: at least one clip is required", ErrInvalidClips)
+	}
+	if len(specs) > maxClipsPerVideo {
+		return fmt.Errorf("%w: at most %d clips per video", ErrInvalidClips, maxClipsPerVideo)
+	}
+
+	for i, spec := range specs {
+		length := spec.End - spec.Start
+		switch {
+		case spec.Start < 0:
+			return fmt.Errorf("%w: clip %d starts before the video", ErrInvalidClips, i+1)
+		case length <= 0:
+			return fmt.Errorf("%w: clip %d ends before it starts", ErrInvalidClips, i+1)
+		case length < minClipDuration:
+			return fmt.Errorf("%w: clip %d is shorter than %s", ErrInvalidClips, i+1, minClipDuration)
+		case length > maxClipDuration:
+			return fmt.Errorf("%w: clip %d is longer than TikTok's %s limit", ErrInvalidClips, i+1, maxClipDuration)
+		}
+	}
+
+	ordered := make([]ClipSpec, len(specs))
+	copy(ordered, specs)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Start < ordered[j].Start })
+	for i := 1; i < len(ordered); i++ {
+		prev, cur := ordered[i-1], ordered[i]
+		if cur.Start < prev.End {
+			return fmt.Errorf("%w: ranges %s-%s and %s-%s overlap", ErrInvalidClips,
+				FormatClipTimestamp(prev.Start), FormatClipTimestamp(prev.End),
+				FormatClipTimestamp(cur.Start), FormatClipTimestamp(cur.End))
+		}
+	}
+
+	return nil
+}
+
+// clipParentStatus derives a split video's status from its clips: completed once every clip
+// is done, the furthest in-flight stage while clips are moving, awaiting review while clips wait
+// for an operator, and failed when nothing is left to run but some clips failed.
+func clipParentStatus(clips []*domain.Video) (domain.VideoStatus, string) {
+	counts := make(map[domain.VideoStatus]int)
+	for _, clip := range clips {
+		counts[clip.Status]++
+	}
+
+	done := counts[domain.VideoStatusCompleted] + counts[domain.VideoStatusSkipped]
+	switch {
+	case done == len(clips):
+		return domain.VideoStatusCompleted, ""
+	case counts[domain.VideoStatusPublishing] > 0:
+		return domain.VideoStatusPublishing, ""
+	case counts[domain.VideoStatusUploading] > 0:
+		return domain.VideoStatusUploading, ""
+	case counts[domain.VideoStatusDownloaded] > 0:
+		return domain.VideoStatusDownloaded, ""
+	case counts[domain.VideoStatusWaitingWindow] > 0:
+		return domain.VideoStatusWaitingWindow, fmt.Sprintf("%d of %d clips wait for the upload window", counts[domain.VideoStatusWaitingWindow], len(clips))
+	case counts[domain.VideoStatusDownloading] > 0:
+		return domain.VideoStatusDownloading, ""
+	case counts[domain.VideoStatusPending] > 0:
+		return domain.VideoStatusPending, ""
+	case counts[domain.VideoStatusAwaitingReview] > 0:
+		return domain.VideoStatusAwaitingReview, fmt.Sprintf("%d of %d clips await review", counts[domain.VideoStatusAwaitingReview], len(clips))
+	default:
+		return domain.VideoStatusFailed, fmt.Sprintf("%d of %d clips failed", counts[domain.VideoStatusFailed], len(clips))
+	}
+}
+
+// ParseClipTimestamp parses "SS", "MM:SS" or "HH:MM:SS" (seconds may have a fraction)
+func ParseClipTimestamp(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("%w: empty timestamp", ErrInvalidClips)
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("%w: timestamp %q", ErrInvalidClips, value)
+	}
+
+	// Hours and minutes accumulate as whole seconds; the last part carries the fraction
+	wholeSeconds := 0
+	for i, part := range parts[:len(parts)-1] {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0, fmt.Errorf("%w: timestamp %q", ErrInvalidClips, value)
+		}
+		wholeSeconds = (wholeSeconds + n) * 60
+	}
+
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || seconds < 0 || (len(parts) > 1 && seconds >= 60) {
+		return 0, fmt.Errorf("%w: timestamp %q", ErrInvalidClips, value)
+	}
+	return time.Duration(wholeSeconds)*time.Second + time.Duration(seconds*float64(time.Second)), nil
+}
+
+// ParseClipRange parses "12:30-13:15" (an en dash also works) into start and end
+func ParseClipRange(value string) (time.Duration, time.Duration, error) {
+	normalized := strings.NewReplacer("–", "-", "—", "-").Replace(value)
+	start, end, ok := strings.Cut(normalized, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: range %q must look like 12:30-13:15", ErrInvalidClips, value)
+	}
+	startAt, err := ParseClipTimestamp(start)
+	if err != nil {
+		return 0, 0, err
+	}
+	endAt, err := ParseClipTimestamp(end)
+	if err != nil {
+		return 0, 0, err
+	}
+	return startAt, endAt, nil
+}
+
+// FormatClipTimestamp renders a clip offset as MM:SS or H:MM:SS
+func FormatClipTimestamp(d time.Duration) string {
+	total := int(d.Round(time.Second) / time.Second)
+	hours, minutes, seconds := total/3600, (total%3600)/60, total%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// FrameExtractor takes still frames from a video file for moderation
+type FrameExtractor interface {
+	ExtractFrames(ctx context.Context, path string, count int) ([][]byte, error)
+}
+
+// ContentSafety decides before publishing whether a video may go out: the account's denylist is
+// matched against the caption, then the moderation service is asked for accounts that enable it
+type ContentSafety struct {
+	config    *config.Config
+	moderator domain.Moderator // Optional: nil when safety.moderation_url is not set
+	frames    FrameExtractor   // Optional: without it only the caption is moderated
+
+	mu     sync.Mutex
+	counts map[domain.SafetyDecision]int64
+}
+
+// NewContentSafety creates the content-safety check
+func NewContentSafety(cfg *config.Config, moderator domain.Moderator, frames FrameExtractor) *ContentSafety {
+	return &ContentSafety{
+		config:    cfg,
+		moderator: moderator,
+		frames:    frames,
+		counts:    make(map[domain.SafetyDecision]int64),
+	}
+}
+
+// Check returns the decision for a video and, for deny or review, the rule that triggered it
+func (c *ContentSafety) Check(ctx context.Context, account *domain.Account, video *domain.Video) (domain.SafetyDecision, string) {
+	decision, rule := c.check(ctx, account, video)
+	c.mu.Lock()
+	c.counts[decision]++
+	c.mu.Unlock()
+	return decision, rule
+}
+
+func (c *ContentSafety) check(ctx context.Context, account *domain.Account, video *domain.Video) (domain.SafetyDecision, string) {
+	caption := strings.TrimSpace(video.Title + "\n" + video.Description)
+	if entry := matchDenylist(account.Settings.SafetyDenylist, caption); entry != "" {
+		return domain.SafetyDecisionDeny, fmt.Sprintf("denylist %q", entry)
+	}
+	if !account.Settings.SafetyModeration {
+		return domain.SafetyDecisionAllow, ""
+	}
+	if c.moderator == nil {
+		// The account asked for moderation; publishing unchecked would defeat it
+		return domain.SafetyDecisionReview, "safety_moderation is enabled but safety.moderation_url is not set"
+	}
+
+	req := &domain.ModerationRequest{
+		VideoID:   video.YouTubeVideoID,
+		AccountID: account.ID,
+		Caption:   caption,
+	}
+	if c.frames != nil && c.config.SafetyFrames > 0 && video.LocalFilePath != "" {
+		frames, err := c.frames.ExtractFrames(ctx, video.LocalFilePath, c.config.SafetyFrames)
+		if err != nil {
+			logger.Error().Printf("Warning: could not extract frames of video %s, moderating the caption only: %v", video.YouTubeVideoID, err)
+		}
+		req.Frames = frames
+	}
+
+	modCtx, cancel := context.WithTimeout(ctx, c.config.SafetyTimeout)
+	defer cancel()
+	result, err := c.moderator.Moderate(modCtx, req)
+	if err != nil {
+		return domain.SafetyDecisionReview, fmt.Sprintf("moderation failed: %v", err)
+	}
+	if result.Decision == domain.SafetyDecisionAllow {
+		return domain.SafetyDecisionAllow, ""
+	}
+	rule := "moderation"
+	if result.Reason != "" {
+		rule += ": " + result.Reason
+	}
+	return result.Decision, rule
+}
+
+// Counts returns how many checks ended in each decision since startup
+func (c *ContentSafety) Counts() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[string]int64, len(c.counts))
+	for decision, n := range c.counts {
+		counts[string(decision)] = n
+	}
+	return counts
+}
+
+// matchDenylist returns the first denylist entry found in text, or "" when none matches. Entries
+// written as /expr/ are case-insensitive regular expressions; invalid ones are skipped.
+func matchDenylist(denylist []string, text string) string {
+	lower := strings.ToLower(text)
+	for _, entry := range denylist {
+		if pattern, ok := denylistPattern(entry); ok {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err == nil && re.MatchString(text) {
+				return entry
+			}
+			continue
+		}
+		if word := strings.ToLower(strings.TrimSpace(entry)); word != "" && strings.Contains(lower, word) {
+			return entry
+		}
+	}
+	return ""
+}
+
+// denylistPattern returns the expression of a /expr/ denylist entry
+func denylistPattern(entry string) (string, bool) {
+	if len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+		return entry[1 : len(entry)-1], true
+	}
+	return "", false
+}
+
+// ValidateSafetyDenylist trims the entries of a denylist, drops empty ones and checks that the
+// /expr/ entries compile
+func ValidateSafetyDenylist(denylist []string) ([]string, error) {
+	var entries []string
+	for _, entry := range denylist {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if pattern, ok := denylistPattern(entry); ok {
+			if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+				return nil, fmt.Errorf("invalid safety_denylist entry %s: %w", entry, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// SetContentSafety enables the content-safety check before each upload
+func (p *VideoProcessor) SetContentSafety(safety *ContentSafety) {
+	p.contentSafety = safety
+}
+
+// checkContentSafety runs the content-safety check and records the decision on the video. It
+// returns true when the video must not be uploaded now: denied videos are skipped and videos held
+// for review wait in awaiting_review until they are approved.
+func (p *VideoProcessor) checkContentSafety(ctx context.Context, video *domain.Video) (bool, error) {
+	if p.contentSafety == nil || video.SafetyDecision == domain.SafetyDecisionApproved {
+		return false, nil
+	}
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get account for content safety: %w", err)
+	}
+	if account == nil {
+		return false, nil
+	}
+
+	decision, rule := p.contentSafety.Check(ctx, account, video)
+	if err := ctx.Err(); err != nil {
+		// A moderation call cut short by shutdown is not a verdict
+		return false, err
+	}
+	recordCtx := context.WithoutCancel(ctx)
+	if err := p.videoRepo.UpdateSafetyDecision(recordCtx, video.ID, decision, rule); err != nil {
+		return false, fmt.Errorf("failed to record content safety decision: %w", err)
+	}
+	video.SafetyDecision = decision
+	video.SafetyRule = rule
+
+	switch decision {
+	case domain.SafetyDecisionDeny:
+		p.videoRepo.UpdateStatus(recordCtx, video.ID, domain.VideoStatusSkipped, "content safety: "+rule)
+		logger.Info().Printf("Content safety denied video %s: %s", video.YouTubeVideoID, rule)
+		return true, nil
+	case domain.SafetyDecisionReview:
+		p.videoRepo.UpdateStatus(recordCtx, video.ID, domain.VideoStatusAwaitingReview, "content safety: "+rule)
+		logger.Info().Printf("Content safety holds video %s for review: %s", video.YouTubeVideoID, rule)
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// ErrMaintenanceRunning is returned by DatabaseMaintenance.Run while another run is in progress
+var ErrMaintenanceRunning = errors.New("database maintenance is already running")
+
+// MaintenanceResult is what one maintenance run did
+type MaintenanceResult struct {
+	RowsDeleted int
+	PruneCutoff time.Time // Videos finished before this were deleted; zero when pruning is off
+	Vacuumed    bool
+	FreePercent float64 // Share of free pages before the run
+	SizeBefore  int64   // Database plus write-ahead log, in bytes
+	SizeAfter   int64
+	Duration    time.Duration
+}
+
+// BytesReclaimed is how much smaller the database and its log got, never negative
+func (r *MaintenanceResult) BytesReclaimed() int64 {
+	return max(r.SizeBefore-r.SizeAfter, 0)
+}
+
+// DatabaseMaintenance keeps the database from growing without bound: it deletes finished videos
+// older than database.retention_days, truncates the write-ahead log and vacuums when enough of
+// the file is free
+type DatabaseMaintenance struct {
+	cfg         *config.Config
+	videoRepo   domain.VideoRepository
+	accountRepo domain.AccountRepository
+	maintainer  domain.DatabaseMaintainer
+
+	running sync.Mutex
+}
+
+// NewDatabaseMaintenance creates a new database maintenance use case
+func NewDatabaseMaintenance(
+	cfg *config.Config,
+	videoRepo domain.VideoRepository,
+	accountRepo domain.AccountRepository,
+	maintainer domain.DatabaseMaintainer,
+) *DatabaseMaintenance {
+	return &DatabaseMaintenance{
+		cfg:         cfg,
+		videoRepo:   videoRepo,
+		accountRepo: accountRepo,
+		maintainer:  maintainer,
+	}
+}
+
+// Run prunes, checkpoints and, past database.vacuum_free_percent, vacuums the database. Only one
+// run happens at a time; a second one returns ErrMaintenanceRunning.
+func (m *DatabaseMaintenance) Run(ctx context.Context) (*MaintenanceResult, error) {
+	if !m.running.TryLock() {
+		return nil, ErrMaintenanceRunning
+	}
+	defer m.running.Unlock()
+
+	startTime := time.Now()
+	before, err := m.maintainer.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &MaintenanceResult{SizeBefore: before.SizeBytes, FreePercent: before.FreePercent()}
+
+	if m.cfg.DatabaseRetentionDays > 0 {
+		cutoff, err := m.pruneCutoff(ctx, startTime)
+		if err != nil {
+			return nil, err
+		}
+		result.PruneCutoff = cutoff
+		if result.RowsDeleted, err = m.videoRepo.PruneFinished(ctx, cutoff); err != nil {
+			return nil, fmt.Errorf("failed to prune videos: %w", err)
+		}
+	}
+
+	if err := m.maintainer.Checkpoint(ctx); err != nil {
+		return nil, err
+	}
+
+	stats, err := m.maintainer.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	threshold := m.cfg.DatabaseVacuumFreePercent
+	if threshold > 0 && stats.FreePages > 0 && stats.FreePercent() >= float64(threshold) {
+		if err := m.maintainer.Vacuum(ctx); err != nil {
+			return nil, err
+		}
+		result.Vacuumed = true
+		// The rebuilt file was written through the log
+		if err := m.maintainer.Checkpoint(ctx); err != nil {
+			return nil, err
+		}
+		if stats, err = m.maintainer.Stats(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	result.SizeAfter = stats.SizeBytes
+	result.Duration = time.Since(startTime)
+	logger.Info().Printf("Database maintenance deleted %d videos, reclaimed %d bytes (vacuumed: %v) in %v",
+		result.RowsDeleted, result.BytesReclaimed(), result.Vacuumed, result.Duration)
+	return result, nil
+}
+
+// pruneCutoff returns the time before which finished videos are deleted. Monitoring looks back
+// to each account's last check, so the cutoff never passes the earliest one: a deleted video
+// must not show up as new again.
+func (m *DatabaseMaintenance) pruneCutoff(ctx context.Context, now time.Time) (time.Time, error) {
+	cutoff := now.AddDate(0, 0, -m.cfg.DatabaseRetentionDays)
+
+	accounts, err := m.accountRepo.GetAll(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	for _, account := range accounts {
+		if account.LastCheckedAt.IsZero() {
+			continue
+		}
+		if scanStart := account.LastCheckedAt.Add(-publishedAfterOverlap); scanStart.Before(cutoff) {
+			cutoff = scanStart
+		}
+	}
+	return cutoff, nil
+}
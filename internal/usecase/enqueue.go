@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+)
+
+var (
+	// ErrVideoAlreadyQueued is returned when the video is already stored and not failed or skipped
+	ErrVideoAlreadyQueued = errors.New("video is already queued")
+
+	// ErrVideoNotFound is returned when YouTube does not know the video
+	ErrVideoNotFound = errors.New("video not found")
+)
+
+// EnqueueVideo queues one YouTube video for an account by hand, bypassing the account filters
+// and the discovery window. A failed or skipped copy of the video is put back to pending.
+// options, when not nil, override the account's TikTok post settings for this video.
+func (m *AccountMonitor) EnqueueVideo(ctx context.Context, accountID, youtubeVideoID string, options *domain.PostOptions) (*domain.Video, error) {
+	options, err := NormalizePostOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := m.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if account == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+
+	existing, err := m.videoRepo.GetByYouTubeIDAndAccount(ctx, youtubeVideoID, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up video: %w", err)
+	}
+	if existing != nil {
+		if existing.Status != domain.VideoStatusFailed && existing.Status != domain.VideoStatusSkipped {
+			return nil, fmt.Errorf("%w: video %s is %s", ErrVideoAlreadyQueued, youtubeVideoID, existing.Status)
+		}
+		if options != nil {
+			if err := m.videoRepo.UpdatePostOptions(ctx, existing.ID, options); err != nil {
+				return nil, fmt.Errorf("failed to update post options: %w", err)
+			}
+			existing.PostOptions = options
+		}
+		if err := m.videoRepo.UpdateStatus(ctx, existing.ID, domain.VideoStatusPending, ""); err != nil {
+			return nil, fmt.Errorf("failed to requeue video: %w", err)
+		}
+		existing.Status = domain.VideoStatusPending
+		existing.ErrorMessage = ""
+		return existing, nil
+	}
+
+	if m.config.YouTubeAPIKey == "" {
+		return nil, errors.New("youtube.api_key is required to look up the video")
+	}
+	video, channelID, err := m.youtubeService.GetVideo(youtubeVideoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up video %s: %w", youtubeVideoID, err)
+	}
+	if video == nil {
+		return nil, fmt.Errorf("%w: %s", ErrVideoNotFound, youtubeVideoID)
+	}
+	if channelID != account.YouTubeChannelID {
+		logger.Info().Printf("Video %s is from channel %s, not the account's channel %s; queuing it anyway",
+			youtubeVideoID, channelID, account.YouTubeChannelID)
+	}
+
+	video.AccountID = account.ID
+	video.Priority = m.discoveryPriority(video, time.Now())
+	video.PostOptions = options
+	if err := m.videoRepo.Save(ctx, video); err != nil {
+		return nil, fmt.Errorf("failed to save video: %w", err)
+	}
+	// The lookup is the video's own quota cost; discovery is shared by the channel
+	addVideoCost(ctx, m.videoRepo, video, domain.VideoCost{APIUnits: youtube.QuotaUnitsList})
+	return video, nil
+}
@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// Experiment limits
+const (
+	minExperimentArms    = 2
+	maxExperimentArms    = 10
+	maxCaptionLength     = 2200 // TikTok caption limit in characters
+	maxExperimentNameLen = 200
+)
+
+var (
+	// ErrInvalidExperiment is returned when an experiment request fails validation
+	ErrInvalidExperiment = errors.New("invalid experiment")
+
+	// ErrExperimentNotAllowed is returned when the source video cannot be used for an experiment
+	ErrExperimentNotAllowed = errors.New("video cannot be used for an experiment")
+
+	// ErrExperimentNotFound is returned for unknown experiment IDs
+	ErrExperimentNotFound = errors.New("experiment not found")
+)
+
+// ExperimentArmSpec describes one arm to create
+type ExperimentArmSpec struct {
+	Label     string // Optional; defaults to A, B, C...
+	AccountID string
+	Caption   string
+}
+
+// ExperimentArmResult is an arm with the current outcome of its upload
+type ExperimentArmResult struct {
+	domain.ExperimentArm
+	Status        domain.VideoStatus
+	TikTokVideoID string
+	CompletedAt   time.Time
+	ErrorMessage  string
+}
+
+// ExperimentResults reports every arm of an experiment
+type ExperimentResults struct {
+	Experiment *domain.Experiment
+	Arms       []ExperimentArmResult
+	Counts     map[domain.VideoStatus]int
+}
+
+// ExperimentManager creates caption experiments and reports their results. Each arm is a
+// full-length child of the source video, so the processor downloads the source once and
+// uploads it to every arm's account with that arm's caption.
+type ExperimentManager struct {
+	experimentRepo domain.ExperimentRepository
+	videoRepo      domain.VideoRepository
+	accountRepo    domain.AccountRepository
+}
+
+// NewExperimentManager creates a new experiment manager
+func NewExperimentManager(
+	experimentRepo domain.ExperimentRepository,
+	videoRepo domain.VideoRepository,
+	accountRepo domain.AccountRepository,
+) *ExperimentManager {
+	return &ExperimentManager{
+		experimentRepo: experimentRepo,
+		videoRepo:      videoRepo,
+		accountRepo:    accountRepo,
+	}
+}
+
+// CreateExperiment validates the arms and queues one child video per arm
+func (m *ExperimentManager) CreateExperiment(ctx context.Context, sourceVideoID, name string, specs []ExperimentArmSpec) (*domain.Experiment, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxExperimentNameLen {
+		return nil, fmt.Errorf("%w: name is longer than %d characters", ErrInvalidExperiment, maxExperimentNameLen)
+	}
+	arms, err := m.validateArms(ctx, specs)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := m.videoRepo.GetByID(ctx, sourceVideoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video: %w", err)
+	}
+	if source == nil {
+		return nil, fmt.Errorf("%w: video not found: %s", ErrInvalidExperiment, sourceVideoID)
+	}
+
+	switch {
+	case source.ParentVideoID != "":
+		return nil, fmt.Errorf("%w: video %s is itself a clip or experiment arm", ErrExperimentNotAllowed, sourceVideoID)
+	case source.ClipCount > 0:
+		return nil, fmt.Errorf("%w: video %s is already split into %d clips or arms", ErrExperimentNotAllowed, sourceVideoID, source.ClipCount)
+	case source.Status == domain.VideoStatusDownloading, source.Status == domain.VideoStatusDownloaded,
+		source.Status == domain.VideoStatusUploading, source.Status == domain.VideoStatusPublishing:
+		return nil, fmt.Errorf("%w: video %s is being processed (%s)", ErrExperimentNotAllowed, sourceVideoID, source.Status)
+	}
+
+	// Mark the source as split first so the processor stops picking it up as a whole
+	source.ClipCount = len(arms)
+	source.Status = domain.VideoStatusPending
+	source.ErrorMessage = ""
+	if err := m.videoRepo.Save(ctx, source); err != nil {
+		return nil, fmt.Errorf("failed to update source video: %w", err)
+	}
+
+	for i := range arms {
+		arm := &arms[i]
+		child := &domain.Video{
+			YouTubeVideoID: fmt.Sprintf("%s#arm%d", source.YouTubeVideoID, i+1),
+			AccountID:      arm.AccountID,
+			Title:          arm.Caption,
+			Description:    source.Description,
+			ThumbnailURL:   source.ThumbnailURL,
+			VideoURL:       source.VideoURL,
+			Status:         domain.VideoStatusPending,
+			PublishedAt:    source.PublishedAt,
+			ParentVideoID:  source.ID,
+		}
+		if err := m.videoRepo.Save(ctx, child); err != nil {
+			return nil, fmt.Errorf("failed to save arm %s: %w", arm.Label, err)
+		}
+		arm.VideoID = child.ID
+	}
+
+	experiment := &domain.Experiment{
+		Name:          name,
+		SourceVideoID: source.ID,
+		Arms:          arms,
+	}
+	if err := m.experimentRepo.Save(ctx, experiment); err != nil {
+		return nil, fmt.Errorf("failed to save experiment: %w", err)
+	}
+	return experiment, nil
+}
+
+// validateArms checks arm count, captions and accounts, filling in default labels
+func (m *ExperimentManager) validateArms(ctx context.Context, specs []ExperimentArmSpec) ([]domain.ExperimentArm, error) {
+	if len(specs) < minExperimentArms {
+		return nil, fmt.Errorf("%w: at least %d arms are required", ErrInvalidExperiment, minExperimentArms)
+	}
+	if len(specs) > maxExperimentArms {
+		return nil, fmt.Errorf("%w: at most %d arms per experiment", ErrInvalidExperiment, maxExperimentArms)
+	}
+
+	arms := make([]domain.ExperimentArm, 0, len(specs))
+	labels := make(map[string]bool, len(specs))
+	accounts := make(map[string]bool, len(specs))
+	for i, spec := range specs {
+		label := strings.TrimSpace(spec.Label)
+		if label == "" {
+			label = string(rune('A' + i))
+		}
+		caption := strings.TrimSpace(spec.Caption)
+
+		switch {
+		case labels[label]:
+			return nil, fmt.Errorf("%w: duplicate arm label %q", ErrInvalidExperiment, label)
+		case spec.AccountID == "":
+			return nil, fmt.Errorf("%w: arm %s has no account_id", ErrInvalidExperiment, label)
+		case accounts[spec.AccountID]:
+			// Two arms on one account would post the same video twice to the same audience
+			return nil, fmt.Errorf("%w: account %s is used by more than one arm", ErrInvalidExperiment, spec.AccountID)
+		case caption == "":
+			return nil, fmt.Errorf("%w: arm %s has no caption", ErrInvalidExperiment, label)
+		case utf8.RuneCountInString(caption) > maxCaptionLength:
+			return nil, fmt.Errorf("%w: arm %s caption is longer than %d characters", ErrInvalidExperiment, label, maxCaptionLength)
+		}
+
+		account, err := m.accountRepo.GetByID(ctx, spec.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account: %w", err)
+		}
+		if account == nil {
+			return nil, fmt.Errorf("%w: account not found: %s", ErrInvalidExperiment, spec.AccountID)
+		}
+
+		labels[label] = true
+		accounts[spec.AccountID] = true
+		arms = append(arms, domain.ExperimentArm{
+			Label:     label,
+			AccountID: spec.AccountID,
+			Caption:   caption,
+		})
+	}
+	return arms, nil
+}
+
+// GetExperiment returns an experiment by ID
+func (m *ExperimentManager) GetExperiment(ctx context.Context, id string) (*domain.Experiment, error) {
+	experiment, err := m.experimentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiment: %w", err)
+	}
+	if experiment == nil {
+		return nil, ErrExperimentNotFound
+	}
+	return experiment, nil
+}
+
+// ListExperiments returns all experiments, newest first
+func (m *ExperimentManager) ListExperiments(ctx context.Context) ([]*domain.Experiment, error) {
+	return m.experimentRepo.List(ctx)
+}
+
+// GetResults reports the upload outcome of every arm
+func (m *ExperimentManager) GetResults(ctx context.Context, id string) (*ExperimentResults, error) {
+	experiment, err := m.GetExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &ExperimentResults{
+		Experiment: experiment,
+		Arms:       make([]ExperimentArmResult, 0, len(experiment.Arms)),
+		Counts:     make(map[domain.VideoStatus]int),
+	}
+	for _, arm := range experiment.Arms {
+		result := ExperimentArmResult{ExperimentArm: arm}
+
+		video, err := m.videoRepo.GetByID(ctx, arm.VideoID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get arm %s video: %w", arm.Label, err)
+		}
+		if video == nil {
+			// The arm's account (and its videos) was deleted
+			result.ErrorMessage = "arm video no longer exists"
+		} else {
+			result.Status = video.Status
+			result.TikTokVideoID = video.TikTokVideoID
+			result.CompletedAt = video.CompletedAt
+			result.ErrorMessage = video.ErrorMessage
+			results.Counts[video.Status]++
+		}
+		results.Arms = append(results.Arms, result)
+	}
+	return results, nil
+}
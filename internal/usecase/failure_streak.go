@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/redact"
+)
+
+// FailureTracker counts consecutive hard failures per account and deactivates accounts whose
+// streak reaches accounts_auto_disable_after, so a broken mapping stops being checked and
+// downloaded every cycle
+type FailureTracker struct {
+	config      *config.Config
+	accountRepo domain.AccountRepository
+	notifier    domain.Notifier
+
+	mu sync.Mutex // Serializes the streak read-modify-write
+}
+
+// NewFailureTracker creates a new failure tracker
+func NewFailureTracker(cfg *config.Config, accountRepo domain.AccountRepository, notifier domain.Notifier) *FailureTracker {
+	return &FailureTracker{
+		config:      cfg,
+		accountRepo: accountRepo,
+		notifier:    notifier,
+	}
+}
+
+// RecordFailure extends the account's streak and deactivates the account once the streak reaches
+// the configured threshold. Cancellation is not the account's fault and is ignored.
+func (t *FailureTracker) RecordFailure(ctx context.Context, accountID, source string, failure error) {
+	if errors.Is(failure, context.Canceled) || errors.Is(failure, context.DeadlineExceeded) {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	account, err := t.accountRepo.GetByID(ctx, accountID)
+	if err != nil || account == nil {
+		logger.Error().Printf("Failed to load account %s to record failure: %v", accountID, err)
+		return
+	}
+
+	now := time.Now()
+	streak := account.FailureStreak
+	streak.ConsecutiveFailures++
+	streak.LastError = redact.String(failure.Error())
+	streak.LastErrorSource = source
+	streak.LastFailureAt = &now
+
+	threshold := t.config.AccountsAutoDisableAfter
+	disable := threshold > 0 && streak.ConsecutiveFailures >= threshold && account.IsActive
+	if disable {
+		streak.DisabledReason = fmt.Sprintf("deactivated after %d consecutive failures, last (%s): %s",
+			streak.ConsecutiveFailures, source, streak.LastError)
+	}
+	if err := t.accountRepo.UpdateFailureStreak(ctx, accountID, streak); err != nil {
+		logger.Error().Printf("Failed to save failure streak for account %s: %v", accountID, err)
+		return
+	}
+	if !disable {
+		return
+	}
+
+	_, err = modifyAccount(ctx, t.accountRepo, accountID, "deactivate account", func(account *domain.Account) error {
+		account.IsActive = false
+		return nil
+	})
+	if err != nil {
+		logger.Error().Printf("Failed to deactivate account %s: %v", accountID, err)
+		return
+	}
+	logger.Error().Printf("Account %s %s", accountID, streak.DisabledReason)
+
+	n := &domain.Notification{
+		Event:     domain.NotificationAccountDisabled,
+		AccountID: accountID,
+		Message: fmt.Sprintf("Account %s (YouTube channel %s) was %s. Fix the cause and reactivate it with POST /api/accounts/%s/activate",
+			accountID, account.YouTubeChannelID, streak.DisabledReason, accountID),
+		Time: now,
+	}
+	if err := t.notifier.Notify(ctx, n); err != nil {
+		logger.Error().Printf("Failed to send deactivation notification for account %s: %v", accountID, err)
+	}
+}
+
+// RecordSuccess resets the account's streak when its last failure came from the same source
+func (t *FailureTracker) RecordSuccess(ctx context.Context, accountID, source string) {
+	ctx = context.WithoutCancel(ctx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	account, err := t.accountRepo.GetByID(ctx, accountID)
+	if err != nil || account == nil {
+		logger.Error().Printf("Failed to load account %s to record success: %v", accountID, err)
+		return
+	}
+
+	streak := account.FailureStreak
+	if streak.ConsecutiveFailures == 0 || streak.LastErrorSource != source {
+		return
+	}
+	// A deactivated account keeps its reason until it is reactivated
+	reset := domain.FailureStreak{DisabledReason: streak.DisabledReason}
+	if err := t.accountRepo.UpdateFailureStreak(ctx, accountID, reset); err != nil {
+		logger.Error().Printf("Failed to reset failure streak for account %s: %v", accountID, err)
+	}
+}
@@ -0,0 +1,418 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+)
+
+// FileIssueKind classifies a mismatch between the download directory and the video records
+type FileIssueKind string
+
+const (
+	FileIssueOrphan       FileIssueKind = "orphan_file"   // No video needs the file
+	FileIssueUnlinked     FileIssueKind = "unlinked_file" // Named after a video that needs it but does not point at it
+	FileIssueMissing      FileIssueKind = "missing_file"  // A video points at a file that does not exist
+	FileIssueSizeMismatch FileIssueKind = "size_mismatch" // Empty file or unfinished download
+)
+
+// FileAction is the fix Reconcile applies to an issue
+type FileAction string
+
+const (
+	FileActionNone      FileAction = "none"
+	FileActionRelink    FileAction = "relink"      // Point the video at the file
+	FileActionClearPath FileAction = "clear_path"  // Clear the path so the next run downloads again
+	FileActionDelete    FileAction = "delete_file" // Remove the file
+)
+
+// FileIssue is one mismatch found by Report
+type FileIssue struct {
+	Kind        FileIssueKind
+	Path        string
+	Size        int64
+	ModTime     time.Time // Zero for missing files
+	VideoID     string    // Empty when no video matches the file
+	VideoStatus domain.VideoStatus
+	Action      FileAction
+	Protected   bool // In flight or recently written; Reconcile leaves it alone
+	Detail      string
+}
+
+// FileReport maps the download directory against the video records
+type FileReport struct {
+	Dir         string
+	GeneratedAt time.Time
+	Files       int
+	TotalBytes  int64
+	Issues      []FileIssue
+}
+
+// FileReconcileOptions selects which fixes Reconcile applies
+type FileReconcileOptions struct {
+	DryRun         bool
+	Relink         bool
+	ClearDeadPaths bool
+
+	// DeleteOrphansOlderThan removes unreferenced files last modified longer ago; 0 keeps them
+	DeleteOrphansOlderThan time.Duration
+}
+
+// FileFix is the outcome of one issue in a reconcile run
+type FileFix struct {
+	Issue   FileIssue
+	Applied bool
+	Result  string
+}
+
+// FileReconcileResult lists what a reconcile run did, or would do in dry-run mode
+type FileReconcileResult struct {
+	DryRun bool
+	Fixes  []FileFix
+}
+
+// FileReconciler compares downloads on disk with the video records so an operator can
+// recover after a crash or a manual cleanup
+type FileReconciler struct {
+	config    *config.Config
+	videoRepo domain.VideoRepository
+}
+
+// NewFileReconciler creates a new file reconciler
+func NewFileReconciler(cfg *config.Config, videoRepo domain.VideoRepository) *FileReconciler {
+	return &FileReconciler{
+		config:    cfg,
+		videoRepo: videoRepo,
+	}
+}
+
+// Report walks the download directory and lists files and records that do not line up.
+// The records store no file size, so a size mismatch means an empty file or a partial download.
+func (r *FileReconciler) Report(ctx context.Context) (*FileReport, error) {
+	dir, err := filepath.Abs(r.config.DownloadDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve download directory: %w", err)
+	}
+
+	referenced, err := r.referencedPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FileReport{Dir: dir, GeneratedAt: time.Now()}
+	seen := make(map[string]bool)
+	unlinked := make(map[string][]FileIssue) // Candidate files per video
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		report.Files++
+		report.TotalBytes += info.Size()
+		issue := FileIssue{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+		broken := info.Size() == 0 || isPartialDownload(d.Name())
+		recent := time.Since(info.ModTime()) < r.config.UploadTimeout
+
+		if video := referenced[path]; video != nil {
+			seen[path] = true
+			if !broken {
+				return nil
+			}
+			issue.Kind = FileIssueSizeMismatch
+			issue.Detail = "the video points at an empty or unfinished file"
+			r.setVideo(&issue, video, FileActionClearPath)
+			report.Issues = append(report.Issues, issue)
+			return nil
+		}
+
+		video, err := r.videoForFile(ctx, path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case video != nil && isInFlight(video.Status):
+			// A download or upload is writing or reading this file right now
+			return nil
+		case broken:
+			issue.Kind = FileIssueSizeMismatch
+			issue.Detail = "empty or unfinished download that no video points at"
+			issue.Action = FileActionDelete
+		case video == nil:
+			issue.Kind = FileIssueOrphan
+			issue.Detail = "no video matches the file name"
+			issue.Action = FileActionDelete
+		case video.Status != domain.VideoStatusPending && video.Status != domain.VideoStatusFailed:
+			issue.Kind = FileIssueOrphan
+			issue.Detail = fmt.Sprintf("video is %s and no longer needs the file", video.Status)
+			r.setVideo(&issue, video, FileActionDelete)
+		case video.LocalFilePath != "" && fileExists(video.LocalFilePath):
+			issue.Kind = FileIssueOrphan
+			issue.Detail = "duplicate: the video uses " + video.LocalFilePath
+			r.setVideo(&issue, video, FileActionDelete)
+		default:
+			issue.Kind = FileIssueUnlinked
+			issue.Detail = "the video needs this file but does not point at it"
+			r.setVideo(&issue, video, FileActionRelink)
+			unlinked[video.ID] = append(unlinked[video.ID], issue)
+			return nil
+		}
+		if issue.Action == FileActionDelete && recent && !issue.Protected {
+			issue.Protected = true
+			issue.Detail += "; modified within upload.timeout, so it may still be in use"
+		}
+		report.Issues = append(report.Issues, issue)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan download directory: %w", err)
+	}
+
+	// Relink the largest candidate; the others are duplicates
+	for _, candidates := range unlinked {
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Size > candidates[j].Size })
+		report.Issues = append(report.Issues, candidates[0])
+		for _, dup := range candidates[1:] {
+			dup.Kind = FileIssueOrphan
+			dup.Action = FileActionDelete
+			dup.Detail = "duplicate: a larger file is relinked to the video"
+			if time.Since(dup.ModTime) < r.config.UploadTimeout {
+				dup.Protected = true
+				dup.Detail += "; modified within upload.timeout, so it may still be in use"
+			}
+			report.Issues = append(report.Issues, dup)
+		}
+	}
+
+	for path, video := range referenced {
+		if seen[path] {
+			continue
+		}
+		if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			continue // Exists outside the download directory, or cannot be checked
+		}
+		issue := FileIssue{Kind: FileIssueMissing, Path: path, Detail: "the video points at a file that does not exist"}
+		r.setVideo(&issue, video, FileActionClearPath)
+		report.Issues = append(report.Issues, issue)
+	}
+
+	sort.Slice(report.Issues, func(i, j int) bool {
+		if report.Issues[i].Kind != report.Issues[j].Kind {
+			return report.Issues[i].Kind < report.Issues[j].Kind
+		}
+		return report.Issues[i].Path < report.Issues[j].Path
+	})
+	return report, nil
+}
+
+// Reconcile applies the selected fixes from a fresh report. Videos being downloaded or
+// uploaded are never touched, and each record and file is checked again right before it
+// changes. Relinks run first so a dead path with a replacement on disk is relinked, not cleared.
+func (r *FileReconciler) Reconcile(ctx context.Context, opts FileReconcileOptions) (*FileReconcileResult, error) {
+	report, err := r.Report(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	order := map[FileAction]int{FileActionRelink: 0, FileActionClearPath: 1, FileActionDelete: 2, FileActionNone: 3}
+	issues := report.Issues
+	sort.SliceStable(issues, func(i, j int) bool { return order[issues[i].Action] < order[issues[j].Action] })
+
+	result := &FileReconcileResult{DryRun: opts.DryRun}
+	for _, issue := range issues {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		fix := FileFix{Issue: issue}
+		switch {
+		case issue.Action == FileActionNone:
+			continue
+		case issue.Protected:
+			fix.Result = "skipped: protected"
+		default:
+			fix.Applied, fix.Result = r.apply(ctx, issue, opts)
+		}
+		result.Fixes = append(result.Fixes, fix)
+	}
+	return result, nil
+}
+
+// apply runs one fix and reports whether it changed anything
+func (r *FileReconciler) apply(ctx context.Context, issue FileIssue, opts FileReconcileOptions) (bool, string) {
+	var video *domain.Video
+	if issue.VideoID != "" {
+		v, err := r.videoRepo.GetByID(ctx, issue.VideoID)
+		if err != nil {
+			return false, fmt.Sprintf("failed: %v", err)
+		}
+		if v == nil {
+			return false, "skipped: video no longer exists"
+		}
+		if isInFlight(v.Status) {
+			return false, fmt.Sprintf("skipped: video is %s", v.Status)
+		}
+		video = v
+	}
+
+	switch issue.Action {
+	case FileActionRelink:
+		if !opts.Relink {
+			return false, "skipped: relink not selected"
+		}
+		if video.LocalFilePath != "" && fileExists(video.LocalFilePath) {
+			return false, "skipped: video now uses " + video.LocalFilePath
+		}
+		if !fileExists(issue.Path) {
+			return false, "skipped: file no longer exists"
+		}
+		if opts.DryRun {
+			return false, "would relink"
+		}
+		if err := r.videoRepo.UpdateFilePath(ctx, video.ID, issue.Path); err != nil {
+			return false, fmt.Sprintf("failed: %v", err)
+		}
+		return true, "relinked"
+
+	case FileActionClearPath:
+		if !opts.ClearDeadPaths {
+			return false, "skipped: clearing dead paths not selected"
+		}
+		if video.LocalFilePath != issue.Path {
+			return false, "skipped: video now uses " + video.LocalFilePath
+		}
+		if info, err := os.Stat(issue.Path); err == nil && info.Size() > 0 && !isPartialDownload(filepath.Base(issue.Path)) {
+			return false, "skipped: file is usable again"
+		}
+		if opts.DryRun {
+			return false, "would clear path"
+		}
+		if err := r.videoRepo.UpdateFilePath(ctx, video.ID, ""); err != nil {
+			return false, fmt.Sprintf("failed: %v", err)
+		}
+		return true, "cleared path"
+
+	case FileActionDelete:
+		if opts.DeleteOrphansOlderThan <= 0 {
+			return false, "skipped: deleting orphans not selected"
+		}
+		info, err := os.Stat(issue.Path)
+		if err != nil {
+			return false, "skipped: file no longer exists"
+		}
+		if age := time.Since(info.ModTime()); age < opts.DeleteOrphansOlderThan {
+			return false, fmt.Sprintf("skipped: modified %s ago", age.Round(time.Minute))
+		}
+		referenced, err := r.referencedPaths(ctx)
+		if err != nil {
+			return false, fmt.Sprintf("failed: %v", err)
+		}
+		if referenced[issue.Path] != nil {
+			return false, "skipped: a video now points at the file"
+		}
+		if opts.DryRun {
+			return false, "would delete"
+		}
+		if err := os.Remove(issue.Path); err != nil {
+			return false, fmt.Sprintf("failed: %v", err)
+		}
+		return true, "deleted"
+	}
+	return false, "skipped: nothing to do"
+}
+
+// referencedPaths maps each absolute local file path to the video that points at it
+func (r *FileReconciler) referencedPaths(ctx context.Context) (map[string]*domain.Video, error) {
+	videos, err := r.videoRepo.GetWithFilePath(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get videos with files: %w", err)
+	}
+	paths := make(map[string]*domain.Video, len(videos))
+	for _, video := range videos {
+		path, err := filepath.Abs(video.LocalFilePath)
+		if err != nil {
+			continue
+		}
+		if _, ok := paths[path]; !ok {
+			paths[path] = video
+		}
+	}
+	return paths, nil
+}
+
+// videoForFile finds the video a download is named after: clips/<clip id>.mp4,
+// sources/<video id>.<ext> or <video id>.<ext>
+func (r *FileReconciler) videoForFile(ctx context.Context, path string) (*domain.Video, error) {
+	name := filepath.Base(path)
+	id, _, _ := strings.Cut(name, ".")
+	if id == "" {
+		return nil, nil
+	}
+	video, err := r.videoRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video %s: %w", id, err)
+	}
+	return video, nil
+}
+
+// setVideo records the video behind an issue; in-flight videos are protected
+func (r *FileReconciler) setVideo(issue *FileIssue, video *domain.Video, action FileAction) {
+	issue.VideoID = video.ID
+	issue.VideoStatus = video.Status
+	issue.Action = action
+	if isInFlight(video.Status) {
+		issue.Protected = true
+		issue.Detail += fmt.Sprintf("; video is %s", video.Status)
+	}
+}
+
+// isInFlight reports whether a download or upload may be using the video's file. Publishing
+// videos keep theirs until TikTok reports the outcome, and videos waiting for their upload
+// window until they are uploaded.
+func isInFlight(status domain.VideoStatus) bool {
+	switch status {
+	case domain.VideoStatusDownloading, domain.VideoStatusDownloaded, domain.VideoStatusUploading,
+		domain.VideoStatusPublishing, domain.VideoStatusWaitingWindow:
+		return true
+	}
+	return false
+}
+
+// isPartialDownload reports whether a file name is a yt-dlp or ffmpeg temporary file
+func isPartialDownload(name string) bool {
+	for _, suffix := range []string{".part", ".ytdl", ".temp", ".tmp"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return strings.Contains(name, ".part-Frag")
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
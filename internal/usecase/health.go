@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/config"
+)
+
+// Health checks selectable with health.checks
+const (
+	HealthCheckDatabase          = "database"
+	HealthCheckYtDlp             = "yt_dlp"
+	HealthCheckDiskSpace         = "disk_space"
+	HealthCheckTikTokCredentials = "tiktok_credentials"
+	HealthCheckYouTubeAPI        = "youtube_api"
+	HealthCheckTikTokAPI         = "tiktok_api"
+	HealthCheckYouTubeSources    = "youtube_sources"
+)
+
+// healthCheckTimeout bounds a single check, so a stuck dependency cannot hang the endpoint
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheckFunc checks one dependency and returns a short description of its state, or an error
+// when it is broken
+type HealthCheckFunc func(ctx context.Context) (string, error)
+
+// HealthCheckResult is the outcome of one check
+type HealthCheckResult struct {
+	Name      string
+	Healthy   bool
+	Critical  bool   // A failing critical check makes the whole service unhealthy
+	Message   string // The check's description, or its error when it failed
+	CheckedAt time.Time
+}
+
+type healthCheck struct {
+	name     string
+	critical bool
+	run      HealthCheckFunc
+}
+
+// HealthChecker runs the dependency checks behind the health endpoint. Results are reused for
+// health.cache_ttl, so load balancers probing every few seconds do not reach the database or the
+// filesystem on each probe.
+type HealthChecker struct {
+	cfg    *config.Config
+	checks []healthCheck
+
+	mu       sync.Mutex
+	results  []HealthCheckResult
+	cachedAt time.Time
+}
+
+// NewHealthChecker creates a checker without checks; see Add
+func NewHealthChecker(cfg *config.Config) *HealthChecker {
+	return &HealthChecker{cfg: cfg}
+}
+
+// Add registers a check. Checks left out of a non-empty health.checks are not registered.
+// critical checks fail the service; the others are reported only.
+func (h *HealthChecker) Add(name string, critical bool, run HealthCheckFunc) {
+	if len(h.cfg.HealthChecks) > 0 && !slices.Contains(h.cfg.HealthChecks, name) {
+		return
+	}
+	h.checks = append(h.checks, healthCheck{name: name, critical: critical, run: run})
+}
+
+// Check runs the checks, or returns the results of the last run while they are younger than
+// health.cache_ttl, and reports whether every critical check passed
+func (h *HealthChecker) Check(ctx context.Context) ([]HealthCheckResult, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if h.results == nil || now.Sub(h.cachedAt) >= h.cfg.HealthCacheTTL {
+		results := make([]HealthCheckResult, len(h.checks))
+		var wg sync.WaitGroup
+		for i, check := range h.checks {
+			wg.Add(1)
+			go func(i int, check healthCheck) {
+				defer wg.Done()
+				results[i] = runHealthCheck(ctx, check)
+			}(i, check)
+		}
+		wg.Wait()
+		h.results, h.cachedAt = results, now
+	}
+
+	healthy := true
+	for _, result := range h.results {
+		if result.Critical && !result.Healthy {
+			healthy = false
+		}
+	}
+	return slices.Clone(h.results), healthy
+}
+
+func runHealthCheck(ctx context.Context, check healthCheck) HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	result := HealthCheckResult{Name: check.name, Critical: check.critical}
+	message, err := check.run(ctx)
+	result.CheckedAt = time.Now()
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Healthy = true
+	result.Message = message
+	return result
+}
+
+// TikTokCredentialsCheck passes when some upload path has credentials: the default TikTok app,
+// an extra app from tiktok.apps, or web upload with a cookies file
+func TikTokCredentialsCheck(cfg *config.Config) HealthCheckFunc {
+	return func(context.Context) (string, error) {
+		switch {
+		case cfg.TikTokAPIKey != "" && cfg.TikTokAPISecret != "":
+			return "tiktok.api_key and tiktok.api_secret are set", nil
+		case len(cfg.TikTokApps) > 0:
+			return fmt.Sprintf("%d TikTok apps in tiktok.apps", len(cfg.TikTokApps)), nil
+		case cfg.TikTokEnableWeb && cfg.TikTokCookiesPath != "":
+			return "web upload with " + cfg.TikTokCookiesPath, nil
+		default:
+			return "", fmt.Errorf("no TikTok credentials: set tiktok.api_key and tiktok.api_secret, tiktok.apps or tiktok.enable_web")
+		}
+	}
+}
+
+// UpstreamOutcomes returns when requests to an upstream last succeeded and failed
+type UpstreamOutcomes interface {
+	LastOutcome(upstream string) (succeeded, failed time.Time)
+}
+
+// UpstreamHealthCheck reports the last API call to the upstream (e.g. "youtube") the app made
+// anyway, without calling it: it fails while the latest call failed
+func UpstreamHealthCheck(outcomes UpstreamOutcomes, upstream string) HealthCheckFunc {
+	return func(context.Context) (string, error) {
+		succeeded, failed := outcomes.LastOutcome(upstream)
+		switch {
+		case succeeded.IsZero() && failed.IsZero():
+			return "no requests yet", nil
+		case failed.After(succeeded):
+			if succeeded.IsZero() {
+				return "", fmt.Errorf("last request failed at %s, none succeeded since start", failed.Format(time.RFC3339))
+			}
+			return "", fmt.Errorf("last request failed at %s, last success at %s", failed.Format(time.RFC3339), succeeded.Format(time.RFC3339))
+		default:
+			return "last success at " + succeeded.Format(time.RFC3339), nil
+		}
+	}
+}
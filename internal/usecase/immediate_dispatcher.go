@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+const (
+	// immediatePollInterval is how often the dispatcher looks for marked videos nobody told it
+	// about, such as those a previous process saved before it stopped
+	immediatePollInterval = 5 * time.Second
+
+	// immediateProcessTimeout bounds a single video started by the dispatcher
+	immediateProcessTimeout = 30 * time.Minute
+)
+
+// ImmediateDispatcher processes videos the monitor marked immediate instead of leaving them for
+// the next processing run. The marks live in the videos table, so videos saved just before a
+// restart are picked up when the dispatcher starts again.
+type ImmediateDispatcher struct {
+	videoRepo domain.VideoRepository
+	processor *VideoProcessor
+	workers   chan struct{} // Limits concurrent immediate videos
+	wake      chan struct{}
+}
+
+// NewImmediateDispatcher creates a dispatcher with worker_pool_size workers
+func NewImmediateDispatcher(cfg *config.Config, videoRepo domain.VideoRepository, processor *VideoProcessor) *ImmediateDispatcher {
+	workers := cfg.WorkerPoolSize
+	if workers <= 0 {
+		workers = 1
+	}
+
+	return &ImmediateDispatcher{
+		videoRepo: videoRepo,
+		processor: processor,
+		workers:   make(chan struct{}, workers),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Notify wakes the dispatcher so newly marked videos start without waiting for the next poll
+func (d *ImmediateDispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches marked videos until ctx ends. Videos run under workCtx, so those already
+// started keep going after ctx ends until they finish or workCtx is cancelled.
+func (d *ImmediateDispatcher) Run(ctx, workCtx context.Context) {
+	ticker := time.NewTicker(immediatePollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx, workCtx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch starts marked videos while workers are free
+func (d *ImmediateDispatcher) dispatch(ctx, workCtx context.Context) {
+	for ctx.Err() == nil {
+		// Marked videos keep their mark while downloads are paused and start after the resume
+		if d.processor.pipeline.DownloadsPaused() {
+			return
+		}
+
+		// Claim only with a free worker, so a claimed video never waits in memory
+		select {
+		case d.workers <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		if !d.processor.beginWork() {
+			<-d.workers
+			return
+		}
+
+		video, err := d.claimNext(ctx)
+		if video == nil {
+			d.processor.endWork()
+			<-d.workers
+			if err != nil && ctx.Err() == nil {
+				logger.Error().Printf("Failed to claim immediate videos: %v", err)
+			}
+			return
+		}
+
+		go func(v *domain.Video) {
+			defer func() { <-d.workers }()
+			defer d.processor.endWork()
+
+			processCtx, cancel := context.WithTimeout(workCtx, immediateProcessTimeout)
+			defer cancel()
+
+			ran, err := d.processor.processClaimed(processCtx, v)
+			switch {
+			case !ran && err == nil:
+				logger.Info().Printf("Video %s is already being processed", v.YouTubeVideoID)
+			case errors.Is(err, errUploadDeferred):
+				logger.Info().Printf("Video %s left pending by account upload limits", v.YouTubeVideoID)
+			case errors.Is(err, errTransferDeferred):
+				logger.Info().Printf("Video %s left pending by the daily data cap", v.YouTubeVideoID)
+			case errors.Is(err, errPipelinePaused):
+				logger.Info().Printf("Video %s left pending by the pipeline pause", v.YouTubeVideoID)
+			case errors.Is(err, errOutsideUploadWindow):
+				logger.Info().Printf("Video %s downloaded; it waits for its account's upload window", v.YouTubeVideoID)
+			case err != nil:
+				logger.Error().Printf("Failed to process video %s immediately: %v", v.YouTubeVideoID, err)
+			default:
+				logger.Info().Printf("Successfully processed video %s immediately after discovery", v.YouTubeVideoID)
+			}
+		}(video)
+	}
+}
+
+// claimNext clears the mark of the oldest marked video and returns it, or nil when none is left.
+// A video whose mark another worker cleared first is passed over. Videos left pending after
+// their attempt, such as deferred ones, are not marked again and wait for the processing run.
+func (d *ImmediateDispatcher) claimNext(ctx context.Context) (*domain.Video, error) {
+	videos, err := d.videoRepo.GetImmediateVideos(ctx, cap(d.workers))
+	if err != nil {
+		return nil, err
+	}
+	for _, video := range videos {
+		claimed, err := d.videoRepo.ClaimImmediate(ctx, video.ID)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return video, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+)
+
+const testStatusPath = "/v2/post/publish/status/fetch/"
+
+// publishedAPI accepts the token of acc-1 and answers every publish status request with a
+// completed upload, counting them. An interrupted upload that TikTok reports as published is
+// finished without a download, so each status request is one run of the video through the
+// processor.
+func publishedAPI(t *testing.T, checks *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/user/info/" {
+			w.Write([]byte(`{"data":{"user":{"open_id":"open-acc-1"}},"error":{"code":"ok"}}`))
+			return
+		}
+		if r.URL.Path != testStatusPath {
+			t.Errorf("unexpected TikTok request %s %s", r.Method, r.URL.Path)
+			http.Error(w, "unexpected", http.StatusInternalServerError)
+			return
+		}
+		checks.Add(1)
+		// Keep the video in progress long enough for a second worker to reach it
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`{"data":{"status":"PUBLISH_COMPLETE","publicaly_available_post_id":[7300000000000000001]},"error":{"code":"ok"}}`))
+	})
+}
+
+// saveImmediateVideo stores a video marked immediate whose upload was interrupted after TikTok got it
+func saveImmediateVideo(t *testing.T, tp *testProcessor) *domain.Video {
+	t.Helper()
+	account := tp.saveAccount(t, &domain.Account{ID: "acc-1"})
+	return tp.saveVideo(t, &domain.Video{
+		ID:              "vid-1",
+		AccountID:       account.ID,
+		Immediate:       true,
+		UploadPublishID: "v_pub_1",
+		PublishedAt:     time.Now(),
+	})
+}
+
+// waitForStatus polls the video until it has the status or the deadline passes
+func waitForStatus(t *testing.T, tp *testProcessor, id string, status domain.VideoStatus) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		video, _ := tp.videos.GetByID(context.Background(), id)
+		if video != nil && video.Status == status {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("video %s is %v, want %s", id, video, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestImmediateDispatcherRacesProcessingRun(t *testing.T) {
+	var checks atomic.Int32
+	tp := newTestProcessor(t, publishedAPI(t, &checks), func(cfg *config.Config) {
+		cfg.TikTokPublishStatusPath = testStatusPath
+	})
+	video := saveImmediateVideo(t, tp)
+	dispatcher := NewImmediateDispatcher(tp.cfg, tp.videos, tp.VideoProcessor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		dispatcher.Run(ctx, context.Background())
+	}()
+	go func() {
+		defer wg.Done()
+		if err := tp.ProcessPendingVideos(context.Background()); err != nil {
+			t.Errorf("ProcessPendingVideos() error = %v", err)
+		}
+	}()
+
+	waitForStatus(t, tp, video.ID, domain.VideoStatusCompleted)
+	cancel()
+	wg.Wait()
+	idleCtx, idleCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer idleCancel()
+	if err := tp.WaitForIdle(idleCtx); err != nil {
+		t.Fatalf("WaitForIdle() error = %v", err)
+	}
+
+	if got := checks.Load(); got != 1 {
+		t.Errorf("video processed %d times, want once", got)
+	}
+}
+
+func TestImmediateDispatcherPicksUpMarksAfterRestart(t *testing.T) {
+	var checks atomic.Int32
+	tp := newTestProcessor(t, publishedAPI(t, &checks), func(cfg *config.Config) {
+		cfg.TikTokPublishStatusPath = testStatusPath
+	})
+
+	// The previous process marked the video and stopped before dispatching it
+	video := saveImmediateVideo(t, tp)
+	stopped := NewImmediateDispatcher(tp.cfg, tp.videos, tp.VideoProcessor)
+	stopped.Notify()
+
+	// The new dispatcher gets no Notify, only the mark in the repository
+	dispatcher := NewImmediateDispatcher(tp.cfg, tp.videos, tp.VideoProcessor)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.Run(ctx, context.Background())
+	}()
+
+	waitForStatus(t, tp, video.ID, domain.VideoStatusCompleted)
+	cancel()
+	<-done
+	if got := checks.Load(); got != 1 {
+		t.Errorf("video processed %d times, want once", got)
+	}
+}
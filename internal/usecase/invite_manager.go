@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// ErrInviteInvalid is returned for invite tokens that are malformed, forged, expired, used or revoked
+var ErrInviteInvalid = errors.New("invite link is invalid or has expired")
+
+// ErrInviteSecretMissing is returned when no secret is configured to sign invite tokens with
+var ErrInviteSecretMissing = errors.New("invites need invites.secret or tiktok.api_secret to sign links")
+
+// InviteManager issues and redeems single-use authorization links for account owners
+type InviteManager struct {
+	config      *config.Config
+	inviteRepo  domain.InviteRepository
+	accountRepo domain.AccountRepository
+	notifier    domain.Notifier
+}
+
+// NewInviteManager creates a new invite manager
+func NewInviteManager(
+	cfg *config.Config,
+	inviteRepo domain.InviteRepository,
+	accountRepo domain.AccountRepository,
+	notifier domain.Notifier,
+) *InviteManager {
+	return &InviteManager{
+		config:      cfg,
+		inviteRepo:  inviteRepo,
+		accountRepo: accountRepo,
+		notifier:    notifier,
+	}
+}
+
+// CreateInvite creates an invite for the account and returns it with its signed token
+func (m *InviteManager) CreateInvite(ctx context.Context, accountID string) (*domain.Invite, string, error) {
+	if m.secret() == "" {
+		return nil, "", ErrInviteSecretMissing
+	}
+	account, err := m.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get account: %w", err)
+	}
+	if account == nil {
+		return nil, "", fmt.Errorf("account not found: %s", accountID)
+	}
+
+	now := time.Now()
+	invite := &domain.Invite{
+		AccountID: accountID,
+		ExpiresAt: now.Add(m.config.InviteTTL),
+		CreatedAt: now,
+	}
+	if err := m.inviteRepo.Save(invite); err != nil {
+		return nil, "", fmt.Errorf("failed to save invite: %w", err)
+	}
+
+	return invite, m.sign(invite), nil
+}
+
+// InviteURL returns the public link for an invite token
+func (m *InviteManager) InviteURL(token string) string {
+	return strings.TrimRight(m.config.ServerPublicURL, "/") + "/authorize/" + token
+}
+
+// ListInvites returns all invites for an account
+func (m *InviteManager) ListInvites(accountID string) ([]*domain.Invite, error) {
+	return m.inviteRepo.ListByAccount(accountID)
+}
+
+// RevokeInvite revokes an invite that belongs to the account
+func (m *InviteManager) RevokeInvite(accountID, inviteID string) error {
+	invite, err := m.inviteRepo.GetByID(inviteID)
+	if err != nil {
+		return fmt.Errorf("failed to get invite: %w", err)
+	}
+	if invite == nil || invite.AccountID != accountID {
+		return fmt.Errorf("invite not found: %s", inviteID)
+	}
+	return m.inviteRepo.Revoke(inviteID, time.Now())
+}
+
+// ResolveInvite verifies the token signature and returns the invite if it is still usable
+func (m *InviteManager) ResolveInvite(token string) (*domain.Invite, error) {
+	id, _, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return nil, ErrInviteInvalid
+	}
+
+	invite, err := m.inviteRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+	if m.secret() == "" {
+		return nil, ErrInviteSecretMissing
+	}
+	if invite == nil || !hmac.Equal([]byte(token), []byte(m.sign(invite))) {
+		return nil, ErrInviteInvalid
+	}
+	if !invite.IsUsable(time.Now()) {
+		return nil, ErrInviteInvalid
+	}
+	return invite, nil
+}
+
+// ClaimInvite redeems the invite before its authorization code is exchanged, so a token can only
+// be exchanged once; it returns ErrInviteInvalid if the invite was already used or revoked
+func (m *InviteManager) ClaimInvite(invite *domain.Invite) error {
+	now := time.Now()
+	ok, err := m.inviteRepo.MarkUsed(invite.ID, now)
+	if err != nil {
+		return fmt.Errorf("failed to redeem invite: %w", err)
+	}
+	if !ok {
+		return ErrInviteInvalid
+	}
+	invite.UsedAt = &now
+	return nil
+}
+
+// ReleaseInvite reopens an invite claimed by ClaimInvite so its owner can use it again
+func (m *InviteManager) ReleaseInvite(invite *domain.Invite) error {
+	if invite.UsedAt == nil {
+		return nil
+	}
+	if err := m.inviteRepo.ReleaseUse(invite.ID, *invite.UsedAt); err != nil {
+		return fmt.Errorf("failed to reopen invite: %w", err)
+	}
+	invite.UsedAt = nil
+	return nil
+}
+
+// CompleteInvite notifies the operator that a claimed invite connected its account
+func (m *InviteManager) CompleteInvite(ctx context.Context, invite *domain.Invite) {
+	if m.notifier != nil {
+		n := &domain.Notification{
+			Event:     domain.NotificationInviteCompleted,
+			AccountID: invite.AccountID,
+			Message:   fmt.Sprintf("Account owner completed TikTok authorization via invite %s", invite.ID),
+			Time:      time.Now(),
+		}
+		if err := m.notifier.Notify(ctx, n); err != nil {
+			logger.Error().Printf("Failed to send invite notification for account %s: %v", invite.AccountID, err)
+		}
+	}
+}
+
+// sign builds the invite token: the invite ID followed by an HMAC over the ID and expiry
+func (m *InviteManager) sign(invite *domain.Invite) string {
+	mac := hmac.New(sha256.New, []byte(m.secret()))
+	fmt.Fprintf(mac, "%s|%d", invite.ID, invite.ExpiresAt.Unix())
+	return invite.ID + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// secret returns the key invite tokens are signed with, empty when none is configured
+func (m *InviteManager) secret() string {
+	if m.config.InviteSecret != "" {
+		return m.config.InviteSecret
+	}
+	return m.config.TikTokAPISecret
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/memory"
+)
+
+func newTestInviteManager(t *testing.T, cfg *config.Config) (*InviteManager, domain.InviteRepository) {
+	t.Helper()
+	accounts := memory.NewAccountRepository()
+	if err := accounts.Save(context.Background(), &domain.Account{ID: "acc-1"}); err != nil {
+		t.Fatalf("save account: %v", err)
+	}
+	invites := memory.NewInviteRepository()
+	return NewInviteManager(cfg, invites, accounts, nil), invites
+}
+
+func TestInviteManagerRequiresSecret(t *testing.T) {
+	tests := []struct {
+		name          string
+		inviteSecret  string
+		apiSecret     string
+		wantErr       error
+		resolveErrNil bool
+	}{
+		{name: "invite secret", inviteSecret: "invite-secret", resolveErrNil: true},
+		{name: "api secret fallback", apiSecret: "api-secret", resolveErrNil: true},
+		{name: "no secret", wantErr: ErrInviteSecretMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{InviteSecret: tt.inviteSecret, TikTokAPISecret: tt.apiSecret, InviteTTL: time.Hour}
+			manager, _ := newTestInviteManager(t, cfg)
+
+			_, token, err := manager.CreateInvite(context.Background(), "acc-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateInvite() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if _, err := manager.ResolveInvite(token); err != nil {
+				t.Fatalf("ResolveInvite() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestInviteManagerRefusesToVerifyWithoutSecret(t *testing.T) {
+	cfg := &config.Config{InviteSecret: "invite-secret", InviteTTL: time.Hour}
+	manager, _ := newTestInviteManager(t, cfg)
+	_, token, err := manager.CreateInvite(context.Background(), "acc-1")
+	if err != nil {
+		t.Fatalf("CreateInvite() error = %v", err)
+	}
+
+	cfg.InviteSecret = ""
+	if _, err := manager.ResolveInvite(token); !errors.Is(err, ErrInviteSecretMissing) {
+		t.Fatalf("ResolveInvite() error = %v, want %v", err, ErrInviteSecretMissing)
+	}
+}
+
+func TestInviteManagerClaimOnce(t *testing.T) {
+	manager, invites := newTestInviteManager(t, &config.Config{InviteSecret: "s", InviteTTL: time.Hour})
+	invite, _, err := manager.CreateInvite(context.Background(), "acc-1")
+	if err != nil {
+		t.Fatalf("CreateInvite() error = %v", err)
+	}
+
+	var claimed atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := manager.ClaimInvite(&domain.Invite{ID: invite.ID})
+			switch {
+			case err == nil:
+				claimed.Add(1)
+			case !errors.Is(err, ErrInviteInvalid):
+				t.Errorf("ClaimInvite() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := claimed.Load(); got != 1 {
+		t.Fatalf("claimed %d times, want 1", got)
+	}
+
+	stored, _ := invites.GetByID(invite.ID)
+	if stored.UsedAt == nil {
+		t.Fatal("claimed invite has no used_at")
+	}
+}
+
+func TestInviteManagerReleaseReopens(t *testing.T) {
+	manager, _ := newTestInviteManager(t, &config.Config{InviteSecret: "s", InviteTTL: time.Hour})
+	invite, token, err := manager.CreateInvite(context.Background(), "acc-1")
+	if err != nil {
+		t.Fatalf("CreateInvite() error = %v", err)
+	}
+
+	claim := &domain.Invite{ID: invite.ID}
+	if err := manager.ClaimInvite(claim); err != nil {
+		t.Fatalf("ClaimInvite() error = %v", err)
+	}
+	if _, err := manager.ResolveInvite(token); !errors.Is(err, ErrInviteInvalid) {
+		t.Fatalf("ResolveInvite() of a claimed invite error = %v, want %v", err, ErrInviteInvalid)
+	}
+
+	if err := manager.ReleaseInvite(claim); err != nil {
+		t.Fatalf("ReleaseInvite() error = %v", err)
+	}
+	if _, err := manager.ResolveInvite(token); err != nil {
+		t.Fatalf("ResolveInvite() of a released invite error = %v", err)
+	}
+	if err := manager.ClaimInvite(&domain.Invite{ID: invite.ID}); err != nil {
+		t.Fatalf("ClaimInvite() after release error = %v", err)
+	}
+}
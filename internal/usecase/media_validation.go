@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/downloader"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// errInvalidMedia means the downloaded file is not something TikTok accepts, so retrying the
+// download cannot help
+var errInvalidMedia = errors.New("video file is not accepted by TikTok")
+
+// validateDownload checks a downloaded file with ffprobe and returns the path to upload. Files in
+// the wrong container or codec are transcoded when download.transcode is on; otherwise, and for
+// problems transcoding cannot fix, the error wraps errInvalidMedia. A file that cannot be probed
+// is uploaded as it is.
+func (p *VideoProcessor) validateDownload(ctx context.Context, video *domain.Video, filePath string) (string, error) {
+	limits := p.mediaLimits(ctx, video)
+	result, err := p.downloadService.ValidateVideo(ctx, filePath, limits)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+		logger.Error().Printf("Failed to validate video %s, uploading it unchecked: %v", video.YouTubeVideoID, err)
+		return filePath, nil
+	}
+	p.recordDownloadFormat(ctx, video, result)
+	if result.Valid() {
+		return filePath, nil
+	}
+	if !result.NeedsTranscode() || !p.config.DownloadTranscode {
+		_ = os.Remove(filePath)
+		return "", fmt.Errorf("%w: %s", errInvalidMedia, result.Summary())
+	}
+
+	logger.Info().Printf("Transcoding video %s: %s", video.YouTubeVideoID, result.Summary())
+	transcoded, err := p.downloadService.Transcode(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+	return transcoded, nil
+}
+
+// recordDownloadFormat stores the resolution and frame rate the download actually got, which can
+// be below the account's preferences when YouTube has no such stream
+func (p *VideoProcessor) recordDownloadFormat(ctx context.Context, video *domain.Video, result *downloader.ValidationResult) {
+	if err := p.videoRepo.UpdateDownloadFormat(ctx, video.ID, result.Width, result.Height, result.FPS); err != nil {
+		logger.Error().Printf("Failed to record download format of video %s: %v", video.YouTubeVideoID, err)
+		return
+	}
+	video.DownloadWidth, video.DownloadHeight, video.DownloadFPS = result.Width, result.Height, result.FPS
+}
+
+// mediaLimits returns the widest size and length limits of the account's upload paths, since
+// routing can move a video to whichever path takes it
+func (p *VideoProcessor) mediaLimits(ctx context.Context, video *domain.Video) downloader.MediaLimits {
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
+	if err != nil || account == nil {
+		account = &domain.Account{}
+	}
+
+	var limits downloader.MediaLimits
+	for _, path := range []domain.UploadPath{domain.UploadPathAPI, domain.UploadPathWeb} {
+		pathLimits := p.uploadLimits(account, path)
+		if pathLimits.MaxSize > limits.MaxSize {
+			limits.MaxSize = pathLimits.MaxSize
+		}
+		if d := time.Duration(pathLimits.MaxDuration); d > limits.MaxDuration {
+			limits.MaxDuration = d
+		}
+	}
+	return limits
+}
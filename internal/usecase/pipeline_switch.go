@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// ErrInvalidPauseScope is returned by Pause for a scope other than uploads, downloads or all
+var ErrInvalidPauseScope = errors.New("pause scope must be uploads, downloads or all")
+
+// errPipelinePaused means the video stayed pending because the pipeline is paused
+var errPipelinePaused = errors.New("pipeline paused")
+
+// PipelineSwitch is the global pause of the pipeline. The state is stored so a pause survives
+// restarts, and kept in memory for the checks made before every download and upload. A nil
+// switch never pauses.
+type PipelineSwitch struct {
+	repo domain.PipelineStateRepository
+
+	mu    sync.RWMutex
+	state domain.PipelineState
+}
+
+// NewPipelineSwitch creates a switch persisting its state in repo; call Load before use
+func NewPipelineSwitch(repo domain.PipelineStateRepository) *PipelineSwitch {
+	return &PipelineSwitch{repo: repo}
+}
+
+// Load reads the stored state, so a pause from before a restart still applies
+func (s *PipelineSwitch) Load(ctx context.Context) error {
+	state, err := s.repo.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load pipeline state: %w", err)
+	}
+	if state == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	s.state = *state
+	s.mu.Unlock()
+	if state.Paused() {
+		logger.Info().Printf("Pipeline is paused (%s) since %s: %s", state.Scope, state.UpdatedAt.Format(time.RFC3339), state.Reason)
+	}
+	return nil
+}
+
+// Pause stops the scope's part of the pipeline until Resume. Work already running finishes;
+// videos reaching a paused step stay pending.
+func (s *PipelineSwitch) Pause(ctx context.Context, scope domain.PauseScope, reason string) (domain.PipelineState, error) {
+	if !scope.Valid() {
+		return domain.PipelineState{}, ErrInvalidPauseScope
+	}
+	state := domain.PipelineState{Scope: scope, Reason: reason, UpdatedAt: time.Now()}
+	if err := s.save(ctx, state); err != nil {
+		return domain.PipelineState{}, err
+	}
+	logger.Info().Printf("Pipeline paused (%s): %s", scope, reason)
+	return state, nil
+}
+
+// Resume lets the whole pipeline run again
+func (s *PipelineSwitch) Resume(ctx context.Context) (domain.PipelineState, error) {
+	state := domain.PipelineState{UpdatedAt: time.Now()}
+	if err := s.save(ctx, state); err != nil {
+		return domain.PipelineState{}, err
+	}
+	logger.Info().Println("Pipeline resumed")
+	return state, nil
+}
+
+// save stores the state before it takes effect, so a failed write changes nothing
+func (s *PipelineSwitch) save(ctx context.Context, state domain.PipelineState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.repo.Save(ctx, &state); err != nil {
+		return fmt.Errorf("failed to save pipeline state: %w", err)
+	}
+	s.state = state
+	return nil
+}
+
+// State returns the current state
+func (s *PipelineSwitch) State() domain.PipelineState {
+	if s == nil {
+		return domain.PipelineState{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// UploadsPaused reports whether uploads are paused
+func (s *PipelineSwitch) UploadsPaused() bool {
+	return s.State().Scope.PausesUploads()
+}
+
+// DownloadsPaused reports whether downloads are paused
+func (s *PipelineSwitch) DownloadsPaused() bool {
+	return s.State().Scope.PausesDownloads()
+}
+
+// AllPaused reports whether the whole pipeline, account monitoring included, is paused
+func (s *PipelineSwitch) AllPaused() bool {
+	return s.State().Scope == domain.PauseScopeAll
+}
+
+// Why a pause left a video pending. A video stopped with pausedUploadMessage was downloaded and
+// keeps its file for the upload after the resume.
+const (
+	pausedDownloadMessage = "downloads are paused"
+	pausedUploadMessage   = "uploads are paused, the downloaded file is kept"
+)
+
+// deferPaused keeps a video pending while the pipeline is paused; the error is stored so the
+// pause shows in the video's status
+func (p *VideoProcessor) deferPaused(ctx context.Context, video *domain.Video, message string) error {
+	err := fmt.Errorf("%w: %s", errPipelinePaused, message)
+	p.videoRepo.UpdateStatus(ctx, video.ID, domain.VideoStatusPending, err.Error())
+	logger.Info().Printf("Video %s left pending: %v", video.YouTubeVideoID, err)
+	return err
+}
+
+// keptForUploadPause reports whether an upload pause stopped the video after its download and
+// the file is still there
+func (p *VideoProcessor) keptForUploadPause(video *domain.Video) bool {
+	return video.ErrorMessage == fmt.Sprintf("%v: %s", errPipelinePaused, pausedUploadMessage) &&
+		video.LocalFilePath != "" && fileExists(video.LocalFilePath)
+}
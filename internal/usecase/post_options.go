@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// applyPostOptions sets the request's privacy level and interaction flags from the account's
+// settings, overridden by the video's post options
+func applyPostOptions(req *tiktok.UploadRequest, settings domain.AccountSettings, options *domain.PostOptions) {
+	req.PrivacyLevel = settings.PrivacyLevel
+	req.DisableComment = settings.DisableComment
+	req.DisableDuet = settings.DisableDuet
+	req.DisableStitch = settings.DisableStitch
+	if options != nil {
+		if options.PrivacyLevel != "" {
+			req.PrivacyLevel = options.PrivacyLevel
+		}
+		if options.DisableComment != nil {
+			req.DisableComment = *options.DisableComment
+		}
+		if options.DisableDuet != nil {
+			req.DisableDuet = *options.DisableDuet
+		}
+		if options.DisableStitch != nil {
+			req.DisableStitch = *options.DisableStitch
+		}
+	}
+	if req.PrivacyLevel == "" {
+		req.PrivacyLevel = tiktok.PrivacyPublic
+	}
+}
+
+// NormalizePostOptions upper-cases and checks the privacy level of per-video post options and
+// returns nil when they override nothing
+func NormalizePostOptions(options *domain.PostOptions) (*domain.PostOptions, error) {
+	if options.IsZero() {
+		return nil, nil
+	}
+	options = options.Clone()
+	options.PrivacyLevel = strings.ToUpper(strings.TrimSpace(options.PrivacyLevel))
+	if err := tiktok.ValidatePrivacyLevel(options.PrivacyLevel); err != nil {
+		return nil, fmt.Errorf("privacy_level: %w", err)
+	}
+	return options, nil
+}
+
+// checkCreatorInfo asks TikTok what the account's creator may post with before a direct post. A
+// privacy level the creator cannot use fails the upload with the levels it can; interactions the
+// creator turned off in TikTok are turned off on the post too. When TikTok cannot be asked for
+// another reason than a missing scope, the post goes ahead unchecked.
+func (p *VideoProcessor) checkCreatorInfo(ctx context.Context, account *domain.Account, req *tiktok.UploadRequest) error {
+	info, err := p.tiktokService.QueryCreatorInfo(req.AccessToken)
+	if err != nil {
+		if errors.Is(err, tiktok.ErrScopeInsufficient) {
+			p.recordMissingScope(ctx, account, err)
+			return err
+		}
+		logger.Error().Printf("Failed to query TikTok creator info for account %s, posting unchecked: %v", account.ID, err)
+		return nil
+	}
+
+	if err := info.CheckPrivacyLevel(req.PrivacyLevel); err != nil {
+		return err
+	}
+	req.DisableComment = req.DisableComment || info.CommentDisabled
+	req.DisableDuet = req.DisableDuet || info.DuetDisabled
+	req.DisableStitch = req.DisableStitch || info.StitchDisabled
+	return nil
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+)
+
+// publicPageVideoLimit is how many recent uploads a public status page lists
+const publicPageVideoLimit = 20
+
+// ErrPublicPageNotFound is returned for unknown or revoked public page slugs
+var ErrPublicPageNotFound = errors.New("public page not found")
+
+// PublicPage is everything a public status page may show. It is built field by field from
+// the account and its videos so tokens, errors and internal IDs never reach the template.
+type PublicPage struct {
+	YouTubeChannelURL string        `json:"youtube_channel_url"`
+	Videos            []PublicVideo `json:"videos"`
+}
+
+// PublicVideo is one mirrored upload on a public status page
+type PublicVideo struct {
+	Title       string    `json:"title"`
+	YouTubeURL  string    `json:"youtube_url"`
+	TikTokURL   string    `json:"tiktok_url,omitempty"` // Empty when the upload has no linkable TikTok ID
+	PublishedAt time.Time `json:"published_at"`
+	MirroredAt  time.Time `json:"mirrored_at"`
+}
+
+// PublicPageManager issues, revokes and renders read-only per-account status pages
+type PublicPageManager struct {
+	config      *config.Config
+	accountRepo domain.AccountRepository
+	videoRepo   domain.VideoRepository
+}
+
+// NewPublicPageManager creates a new public page manager
+func NewPublicPageManager(
+	cfg *config.Config,
+	accountRepo domain.AccountRepository,
+	videoRepo domain.VideoRepository,
+) *PublicPageManager {
+	return &PublicPageManager{
+		config:      cfg,
+		accountRepo: accountRepo,
+		videoRepo:   videoRepo,
+	}
+}
+
+// EnablePublicPage gives the account a new public page slug, revoking any previous one
+func (m *PublicPageManager) EnablePublicPage(ctx context.Context, accountID string) (string, error) {
+	account, err := m.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get account: %w", err)
+	}
+	if account == nil {
+		return "", fmt.Errorf("account not found: %s", accountID)
+	}
+
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate slug: %w", err)
+	}
+	slug := base64.RawURLEncoding.EncodeToString(buf)
+
+	if err := m.accountRepo.UpdatePublicSlug(ctx, accountID, slug); err != nil {
+		return "", fmt.Errorf("failed to save public page slug: %w", err)
+	}
+	return slug, nil
+}
+
+// DisablePublicPage revokes the account's public page
+func (m *PublicPageManager) DisablePublicPage(ctx context.Context, accountID string) error {
+	account, err := m.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	if account == nil {
+		return fmt.Errorf("account not found: %s", accountID)
+	}
+
+	if err := m.accountRepo.UpdatePublicSlug(ctx, accountID, ""); err != nil {
+		return fmt.Errorf("failed to revoke public page: %w", err)
+	}
+	return nil
+}
+
+// PublicPageURL returns the shareable link for a slug
+func (m *PublicPageManager) PublicPageURL(slug string) string {
+	return strings.TrimRight(m.config.ServerPublicURL, "/") + "/public/accounts/" + slug
+}
+
+// GetPublicPage returns the page data for a slug
+func (m *PublicPageManager) GetPublicPage(ctx context.Context, slug string) (*PublicPage, error) {
+	if slug == "" {
+		return nil, ErrPublicPageNotFound
+	}
+
+	account, err := m.accountRepo.GetByPublicSlug(ctx, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if account == nil {
+		return nil, ErrPublicPageNotFound
+	}
+
+	// Fetch extra rows since split sources are completed but were never uploaded themselves
+	videos, err := m.videoRepo.GetByAccountID(ctx, account.ID, domain.VideoFilter{
+		Status: domain.VideoStatusCompleted,
+		Limit:  publicPageVideoLimit * 2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get videos: %w", err)
+	}
+
+	page := &PublicPage{
+		YouTubeChannelURL: "https://www.youtube.com/channel/" + url.PathEscape(account.YouTubeChannelID),
+		Videos:            make([]PublicVideo, 0, publicPageVideoLimit),
+	}
+	for _, video := range videos {
+		if video.ClipCount > 0 || video.TikTokVideoID == "" {
+			continue
+		}
+		page.Videos = append(page.Videos, PublicVideo{
+			Title:       video.Title,
+			YouTubeURL:  publicYouTubeURL(video),
+			TikTokURL:   publicTikTokURL(video),
+			PublishedAt: video.PublishedAt,
+			MirroredAt:  video.CompletedAt,
+		})
+		if len(page.Videos) == publicPageVideoLimit {
+			break
+		}
+	}
+
+	return page, nil
+}
+
+// publicYouTubeURL links to the source video, at the clip's start for clips
+func publicYouTubeURL(video *domain.Video) string {
+	youtubeID, _, _ := strings.Cut(video.YouTubeVideoID, "#")
+	link := "https://www.youtube.com/watch?v=" + url.QueryEscape(youtubeID)
+	if video.ParentVideoID != "" && video.ClipStart > 0 {
+		link += fmt.Sprintf("&t=%ds", int(video.ClipStart/time.Second))
+	}
+	return link
+}
+
+// publicTikTokURL links to the TikTok post; web uploads only have placeholder IDs
+func publicTikTokURL(video *domain.Video) string {
+	if video.TikTokPostURL != "" {
+		return video.TikTokPostURL
+	}
+	return TikTokPostURL(video.TikTokVideoID)
+}
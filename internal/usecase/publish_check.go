@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// publishCheckTimeout is how long TikTok may keep processing an upload, counted from its
+// scheduled publish time, before the video is failed
+const publishCheckTimeout = 2 * time.Hour
+
+// awaitPublish leaves an API upload in publishing until CheckPublishing sees TikTok's outcome.
+// The file stays on disk so the video can be retried if TikTok rejects it.
+func (p *VideoProcessor) awaitPublish(ctx context.Context, video *domain.Video) error {
+	if err := p.videoRepo.UpdateStatus(ctx, video.ID, domain.VideoStatusPublishing, ""); err != nil {
+		return err
+	}
+	logger.Info().Printf("Video %s uploaded as %s, waiting for TikTok to publish it", video.YouTubeVideoID, video.UploadPublishID)
+	return nil
+}
+
+// CheckPublishing asks TikTok about every video in publishing and moves those with a final
+// outcome to completed or failed. Videos TikTok is still processing, or that cannot be checked
+// right now, stay in publishing for the next run.
+func (p *VideoProcessor) CheckPublishing(ctx context.Context) error {
+	videos, err := p.videoRepo.GetRecent(ctx, domain.VideoFilter{Status: domain.VideoStatusPublishing})
+	if err != nil {
+		return fmt.Errorf("failed to get publishing videos: %w", err)
+	}
+
+	var failures []error
+	for _, video := range videos {
+		if ctx.Err() != nil {
+			break
+		}
+		if !p.beginWork() {
+			return ErrShuttingDown
+		}
+		err := p.checkClaimed(ctx, video)
+		p.endWork()
+		if err != nil {
+			logger.Error().Printf("Failed to check publish status of video %s: %v", video.YouTubeVideoID, err)
+			failures = append(failures, err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("publish check errors: %v", failures)
+	}
+	return nil
+}
+
+// checkClaimed checks one video unless another worker has it or it left publishing
+func (p *VideoProcessor) checkClaimed(ctx context.Context, video *domain.Video) error {
+	ctx, ok := p.claim(ctx, video.ID)
+	if !ok {
+		return nil
+	}
+	defer p.unclaim(video.ID)
+
+	current, err := p.videoRepo.GetByID(ctx, video.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get video: %w", err)
+	}
+	if current == nil || current.Status != domain.VideoStatusPublishing {
+		return nil
+	}
+
+	done, err := p.checkPublishStatus(ctx, current)
+	if done && current.ParentVideoID != "" {
+		p.refreshClipParent(context.WithoutCancel(ctx), current.ParentVideoID)
+	}
+	return err
+}
+
+// checkPublishStatus fetches the video's publish status and records a final outcome. It reports
+// whether the video left publishing.
+func (p *VideoProcessor) checkPublishStatus(ctx context.Context, video *domain.Video) (bool, error) {
+	// A known outcome is recorded even if ctx ends meanwhile; failVideo would requeue the video
+	recordCtx := context.WithoutCancel(ctx)
+
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get account mapping: %w", err)
+	}
+	if account == nil {
+		return true, p.failVideo(recordCtx, video, fmt.Errorf("account %s no longer exists", video.AccountID))
+	}
+	if err := p.ensureAccessToken(ctx, account); err != nil {
+		return false, err
+	}
+
+	status, err := p.tiktokService.FetchPublishStatus(account.TikTokAccessToken, video.UploadPublishID)
+	switch {
+	case errors.Is(err, tiktok.ErrPublishNotFound):
+		return true, p.failVideo(recordCtx, video, fmt.Errorf("TikTok does not know upload %s: %w", video.UploadPublishID, err))
+	case err != nil:
+		return false, err
+	case status.Failed():
+		reason := status.FailReason
+		if reason == "" {
+			reason = "no reason given"
+		}
+		logger.Error().Printf("TikTok failed to publish video %s: %s", video.YouTubeVideoID, reason)
+		return true, p.failVideo(recordCtx, video, fmt.Errorf("TikTok failed to publish upload %s: %s", video.UploadPublishID, reason))
+	case !status.Done():
+		deadline := video.UploadedAt.Add(time.Duration(account.Settings.PublishDelay) + publishCheckTimeout)
+		if !video.UploadedAt.IsZero() && time.Now().After(deadline) {
+			return true, p.failVideo(recordCtx, video, fmt.Errorf("upload %s still %s after %s", video.UploadPublishID, status.Status, publishCheckTimeout))
+		}
+		return false, nil
+	}
+
+	if status.PostID != "" && status.PostID != video.TikTokVideoID {
+		if err := p.videoRepo.UpdateTikTokID(recordCtx, video.ID, status.PostID); err != nil {
+			return false, err
+		}
+		video.TikTokVideoID = status.PostID
+	}
+	logger.Info().Printf("TikTok published video %s (%s)", video.YouTubeVideoID, status.Status)
+	return true, p.finishPublished(ctx, video)
+}
+
+// PublishingFiles returns the local files of videos in publishing, which must stay on disk
+// until TikTok reports their outcome, and of videos in waiting_window, which are uploaded from
+// them once their upload window opens
+func (p *VideoProcessor) PublishingFiles(ctx context.Context) ([]string, error) {
+	var files []string
+	for _, status := range []domain.VideoStatus{domain.VideoStatusPublishing, domain.VideoStatusWaitingWindow} {
+		videos, err := p.videoRepo.GetRecent(ctx, domain.VideoFilter{Status: status})
+		if err != nil {
+			return nil, err
+		}
+		for _, video := range videos {
+			if video.LocalFilePath != "" {
+				files = append(files, video.LocalFilePath)
+			}
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// maxPublishedAtLookups bounds the YouTube videos looked up per backfill run (one quota unit per 50)
+const maxPublishedAtLookups = 500
+
+// BackfillPublishedAt asks the YouTube Data API for the publish time of stored videos that have
+// none, e.g. rows written by older versions. Clips and experiment arms take their source's time.
+// It returns the number of videos updated. Videos the API does not know are not asked for again
+// until restart.
+func (m *AccountMonitor) BackfillPublishedAt(ctx context.Context) (int, error) {
+	if m.config.YouTubeAPIKey == "" {
+		return 0, nil
+	}
+	if until := m.QuotaPausedUntil(); !until.IsZero() {
+		logger.Info().Printf("Skipping published date backfill: YouTube quota exhausted until %s", until.Format(time.RFC3339))
+		return 0, nil
+	}
+
+	videos, err := m.videoRepo.GetWithoutPublishedAt(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list videos without published date: %w", err)
+	}
+
+	var ids []string
+	seen := make(map[string]bool)
+	m.publishedAtMu.Lock()
+	for _, video := range videos {
+		id := video.SourceYouTubeID()
+		if id == "" || seen[id] || m.publishedAtMissing[id] || len(ids) >= maxPublishedAtLookups {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	m.publishedAtMu.Unlock()
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	published, err := m.lookupPublishedAt(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, video := range videos {
+		publishedAt, ok := published[video.SourceYouTubeID()]
+		if !ok {
+			continue
+		}
+		if err := m.videoRepo.UpdatePublishedAt(ctx, video.ID, publishedAt); err != nil {
+			return updated, fmt.Errorf("failed to store published date of video %s: %w", video.ID, err)
+		}
+		updated++
+	}
+
+	logger.Info().Printf("Published date backfill: %d of %d videos updated, %d YouTube videos looked up, %d not found",
+		updated, len(videos), len(ids), len(ids)-len(published))
+	return updated, nil
+}
+
+// lookupPublishedAt queries the Data API and remembers the IDs it did not return
+func (m *AccountMonitor) lookupPublishedAt(ids []string) (map[string]time.Time, error) {
+	published, err := m.youtubeService.GetVideoPublishedAt(ids)
+	if err != nil {
+		if errors.Is(err, youtube.ErrQuotaExceeded) {
+			m.pauseForQuota(err)
+		}
+		return nil, fmt.Errorf("failed to look up published dates: %w", err)
+	}
+
+	m.publishedAtMu.Lock()
+	defer m.publishedAtMu.Unlock()
+	for _, id := range ids {
+		if _, ok := published[id]; !ok {
+			m.publishedAtMissing[id] = true
+		}
+	}
+	return published, nil
+}
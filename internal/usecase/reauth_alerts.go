@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// reauthAlertInterval is the minimum time between two re-authorization notifications per account
+const reauthAlertInterval = 24 * time.Hour
+
+// ReauthAlerter tells the operator when an account's TikTok tokens stopped working, with a link
+// to authorize it again, and once more when new tokens were stored
+type ReauthAlerter struct {
+	config      *config.Config
+	accountRepo domain.AccountRepository
+	notifier    domain.Notifier
+
+	inviteManager *InviteManager // Optional: adds a single-use invite link for the account owner
+
+	mu sync.Mutex // Serializes the throttle read-modify-write
+}
+
+// NewReauthAlerter creates a new re-authorization alerter
+func NewReauthAlerter(cfg *config.Config, accountRepo domain.AccountRepository, notifier domain.Notifier) *ReauthAlerter {
+	return &ReauthAlerter{
+		config:      cfg,
+		accountRepo: accountRepo,
+		notifier:    notifier,
+	}
+}
+
+// SetInviteManager adds an invite link for the account owner to every alert
+func (a *ReauthAlerter) SetInviteManager(inviteManager *InviteManager) {
+	a.inviteManager = inviteManager
+}
+
+// AuthorizeURL returns the local authorize endpoint of an account. It builds the TikTok URL
+// server-side, so the client key never ends up in logs, notifications or stored video errors.
+func AuthorizeURL(cfg *config.Config, accountID string) string {
+	return fmt.Sprintf("%s/api/tiktok/authorize/%s",
+		strings.TrimRight(cfg.ServerPublicURL, "/"), url.PathEscape(accountID))
+}
+
+// Alert notifies that the account needs re-authorization, at most once per reauthAlertInterval.
+// Failures are logged; they never stop the caller from reporting the original error.
+func (a *ReauthAlerter) Alert(ctx context.Context, accountID, reason string) {
+	ctx = context.WithoutCancel(ctx)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	account, err := a.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		logger.Error().Printf("Failed to get account %s for re-authorization alert: %v", accountID, err)
+		return
+	}
+	if account == nil {
+		return
+	}
+	now := time.Now()
+	if account.ReauthNotifiedAt != nil && now.Sub(*account.ReauthNotifiedAt) < reauthAlertInterval {
+		return
+	}
+
+	link := AuthorizeURL(a.config, account.ID)
+	message := fmt.Sprintf("TikTok account %s needs re-authorization: %s. Authorize it again at %s",
+		account.TikTokAccountID, reason, link)
+	if a.inviteManager != nil {
+		if _, token, err := a.inviteManager.CreateInvite(ctx, account.ID); err != nil {
+			logger.Error().Printf("Failed to create re-authorization invite for account %s: %v", account.ID, err)
+		} else {
+			message += fmt.Sprintf(" or send the owner this invite link: %s", a.inviteManager.InviteURL(token))
+		}
+	}
+
+	n := &domain.Notification{
+		Event:     domain.NotificationReauthRequired,
+		AccountID: account.ID,
+		Message:   message,
+		URL:       link,
+		Time:      now,
+	}
+	if err := a.notifier.Notify(ctx, n); err != nil {
+		logger.Error().Printf("Failed to send re-authorization notification for account %s: %v", account.ID, err)
+		return
+	}
+	if err := a.accountRepo.UpdateReauthNotifiedAt(ctx, account.ID, &now); err != nil {
+		logger.Error().Printf("Failed to record re-authorization notification for account %s: %v", account.ID, err)
+	}
+}
+
+// Resolved notifies that new tokens were stored for the account and the accounts sharing its
+// TikTok account, for those an alert was sent for, and resets their throttle
+func (a *ReauthAlerter) Resolved(ctx context.Context, account *domain.Account) {
+	ctx = context.WithoutCancel(ctx)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	accounts := []*domain.Account{account}
+	siblings, err := a.accountRepo.ListByTikTokAccountID(ctx, account.TikTokAccountID)
+	if err != nil {
+		logger.Error().Printf("Failed to list accounts sharing TikTok account %s: %v", account.TikTokAccountID, err)
+	}
+	for _, sibling := range siblings {
+		if sibling.ID != account.ID {
+			accounts = append(accounts, sibling)
+		}
+	}
+
+	for _, acc := range accounts {
+		current, err := a.accountRepo.GetByID(ctx, acc.ID)
+		if err != nil {
+			logger.Error().Printf("Failed to get account %s for re-authorization notification: %v", acc.ID, err)
+			continue
+		}
+		if current == nil || current.ReauthNotifiedAt == nil {
+			continue
+		}
+
+		if err := a.accountRepo.UpdateReauthNotifiedAt(ctx, current.ID, nil); err != nil {
+			logger.Error().Printf("Failed to clear re-authorization notification for account %s: %v", current.ID, err)
+			continue
+		}
+		n := &domain.Notification{
+			Event:     domain.NotificationReauthorized,
+			AccountID: current.ID,
+			Message:   fmt.Sprintf("TikTok account %s was re-authorized; uploads resume", current.TikTokAccountID),
+			Time:      time.Now(),
+		}
+		if err := a.notifier.Notify(ctx, n); err != nil {
+			logger.Error().Printf("Failed to send re-authorized notification for account %s: %v", current.ID, err)
+		}
+	}
+}
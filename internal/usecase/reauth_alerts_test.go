@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+)
+
+// TestReauthAlerts fails the token refresh of an account several times, then stores new tokens
+// for it twice, and checks each transition is notified once
+func TestReauthAlerts(t *testing.T) {
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/info/":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":"access_token_invalid"}}`))
+		case "/v2/oauth/token/":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	tp := newTestProcessor(t, api, func(cfg *config.Config) {
+		cfg.TikTokAPIKey = "ck"
+		cfg.TikTokAPISecret = "cs"
+		cfg.ServerPublicURL = "https://uploader.example"
+	})
+	notifier := &recordingNotifier{}
+	alerter := NewReauthAlerter(tp.cfg, tp.accounts, notifier)
+	tp.SetReauthAlerter(alerter)
+	manager := NewAccountManager(tp.cfg, tp.accounts)
+	manager.SetReauthAlerter(alerter)
+	ctx := context.Background()
+	tp.saveAccount(t, &domain.Account{ID: "acc-1", TikTokAccountID: "tt-1", TikTokRefreshToken: "rft.old"})
+
+	failRefresh := func() {
+		t.Helper()
+		account, _ := tp.accounts.GetByID(ctx, "acc-1")
+		if err := tp.ensureAccessToken(ctx, account); !errors.Is(err, errUploadAuth) {
+			t.Fatalf("ensureAccessToken() error = %v, want %v", err, errUploadAuth)
+		}
+	}
+	storeTokens := func() {
+		t.Helper()
+		app := config.TikTokApp{Name: config.DefaultTikTokApp, APIKey: "ck", APISecret: "cs"}
+		if _, err := manager.UpdateAccountTokens(ctx, "acc-1", app, "act.new", "rft.new", nil); err != nil {
+			t.Fatalf("UpdateAccountTokens() error = %v", err)
+		}
+	}
+	wantEvents := func(step string, want ...domain.NotificationEvent) {
+		t.Helper()
+		if got := notifier.events(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("notifications after %s = %v, want %v", step, got, want)
+		}
+	}
+
+	failRefresh()
+	wantEvents("the refresh failed", domain.NotificationReauthRequired)
+	if n := notifier.sent[0]; n.AccountID != "acc-1" || n.URL != "https://uploader.example/api/tiktok/authorize/acc-1" {
+		t.Errorf("reauthorization_required for %q links %q", n.AccountID, n.URL)
+	}
+	failRefresh()
+	failRefresh()
+	wantEvents("repeated failures", domain.NotificationReauthRequired)
+
+	storeTokens()
+	wantEvents("new tokens", domain.NotificationReauthRequired, domain.NotificationReauthorized)
+	storeTokens()
+	wantEvents("the same tokens again", domain.NotificationReauthRequired, domain.NotificationReauthorized)
+
+	// Resolving reset the throttle, so the next breakage is notified right away
+	failRefresh()
+	wantEvents("breaking again", domain.NotificationReauthRequired, domain.NotificationReauthorized, domain.NotificationReauthRequired)
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// shareTikTokTokens copies the account's TikTok credentials to the other mappings that post to the
+// same TikTok account (accounts_allow_shared_tiktok). TikTok rotates refresh tokens, so a sibling
+// keeping its old one would fail its next refresh. The copies are recorded to events (may be nil).
+func shareTikTokTokens(ctx context.Context, accountRepo domain.AccountRepository, events *AccountEventLog, account *domain.Account) error {
+	if !HasAccessToken(account) {
+		return nil
+	}
+
+	siblings, err := accountRepo.ListByTikTokAccountID(ctx, account.TikTokAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to list accounts sharing TikTok account %s: %w", account.TikTokAccountID, err)
+	}
+
+	for _, sibling := range siblings {
+		if sibling.ID == account.ID || sameTikTokTokens(sibling, account) {
+			continue
+		}
+
+		var before domain.Account
+		sibling, err := modifyAccount(ctx, accountRepo, sibling.ID, "share tokens with account "+sibling.ID, func(sibling *domain.Account) error {
+			before = *sibling
+			sibling.TikTokAccessToken = account.TikTokAccessToken
+			sibling.TikTokRefreshToken = account.TikTokRefreshToken
+			sibling.TikTokTokenExpiresAt = account.TikTokTokenExpiresAt
+			sibling.TikTokApp = account.TikTokApp
+			sibling.TikTokClientKey = account.TikTokClientKey
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		events.Record(ctx, sibling.ID, domain.AccountEventTokenUpdated,
+			fmt.Sprintf("shared from account %s: %s", account.ID, accountChanges(&before, sibling)))
+
+		// The shared token is checked again on the sibling's next upload
+		if sibling.NeedsReauthorization && !account.NeedsReauthorization {
+			if err := accountRepo.UpdateNeedsReauthorization(ctx, sibling.ID, false); err != nil {
+				return fmt.Errorf("failed to clear re-authorization flag of account %s: %w", sibling.ID, err)
+			}
+		}
+		if len(account.MissingScopes) == 0 {
+			if err := clearMissingScopes(ctx, accountRepo, sibling); err != nil {
+				return fmt.Errorf("account %s: %w", sibling.ID, err)
+			}
+		}
+		logger.Info().Printf("Shared TikTok tokens of account %s with account %s (TikTok account %s)", account.ID, sibling.ID, account.TikTokAccountID)
+	}
+	return nil
+}
+
+// inheritTikTokTokens gives an account without a real token the credentials of another mapping
+// that posts to the same TikTok account. It reports whether tokens were copied.
+func inheritTikTokTokens(ctx context.Context, accountRepo domain.AccountRepository, account *domain.Account) (bool, error) {
+	if HasAccessToken(account) {
+		return false, nil
+	}
+
+	siblings, err := accountRepo.ListByTikTokAccountID(ctx, account.TikTokAccountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list accounts sharing TikTok account %s: %w", account.TikTokAccountID, err)
+	}
+
+	for _, sibling := range siblings {
+		if sibling.ID == account.ID || !HasAccessToken(sibling) {
+			continue
+		}
+		account.TikTokAccessToken = sibling.TikTokAccessToken
+		account.TikTokRefreshToken = sibling.TikTokRefreshToken
+		account.TikTokTokenExpiresAt = sibling.TikTokTokenExpiresAt
+		account.TikTokApp = sibling.TikTokApp
+		account.TikTokClientKey = sibling.TikTokClientKey
+		return true, nil
+	}
+	return false, nil
+}
+
+// sameTikTokTokens reports whether two accounts hold the same TikTok credentials
+func sameTikTokTokens(a, b *domain.Account) bool {
+	return a.TikTokAccessToken == b.TikTokAccessToken &&
+		a.TikTokRefreshToken == b.TikTokRefreshToken &&
+		a.TikTokApp == b.TikTokApp &&
+		a.TikTokClientKey == b.TikTokClientKey
+}
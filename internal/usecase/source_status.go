@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// ErrSourceUnavailable is returned for a check of an account whose YouTube channel is suspended,
+// terminated or private
+var ErrSourceUnavailable = errors.New("youtube channel is unavailable")
+
+// SetNotifier announces channels that became unavailable and channels that came back
+func (m *AccountMonitor) SetNotifier(notifier domain.Notifier) {
+	m.notifier = notifier
+}
+
+// channelStatusEnabled reports whether channel status checks can run: they need the Data API key
+// and a non-zero youtube.channel_status_interval
+func (m *AccountMonitor) channelStatusEnabled() bool {
+	return m.config.YouTubeAPIKey != "" && m.config.YouTubeStatusInterval > 0
+}
+
+// channelStatusDue reports whether the account's channel status should be checked again
+func (m *AccountMonitor) channelStatusDue(account *domain.Account, now time.Time) bool {
+	checkedAt := account.SourceStatus.CheckedAt
+	return checkedAt == nil || now.Sub(*checkedAt) >= m.config.YouTubeStatusInterval
+}
+
+// checkSource checks the account's channel status when it is due, or always with force, and
+// returns ErrSourceUnavailable while the channel cannot be read. A failed status check leaves the
+// stored status as it was; the regular check then decides.
+func (m *AccountMonitor) checkSource(ctx context.Context, account *domain.Account, force bool) error {
+	if !m.channelStatusEnabled() {
+		return nil
+	}
+
+	now := time.Now()
+	if force || m.channelStatusDue(account, now) {
+		status, err := m.youtubeService.GetChannelStatus(ctx, account.YouTubeChannelID)
+		if err != nil {
+			if errors.Is(err, youtube.ErrQuotaExceeded) {
+				m.pauseForQuota(err)
+			}
+			logger.Error().Printf("Failed to check the status of YouTube channel %s (account %s): %v",
+				account.YouTubeChannelID, account.ID, err)
+		} else {
+			m.recordSourceStatus(ctx, account, status, now)
+		}
+	}
+
+	return sourceUnavailableError(account, m.config.YouTubeStatusInterval)
+}
+
+// sourceUnavailableError returns ErrSourceUnavailable with the reason and the next status check
+// while the account's channel is unavailable
+func sourceUnavailableError(account *domain.Account, interval time.Duration) error {
+	status := account.SourceStatus
+	if status.Unavailable == "" {
+		return nil
+	}
+	next := ""
+	if status.CheckedAt != nil {
+		next = fmt.Sprintf("; checked again after %s", status.CheckedAt.Add(interval).Format(time.RFC3339))
+	}
+	return fmt.Errorf("%w: YouTube channel %s is %s (%s)%s",
+		ErrSourceUnavailable, account.YouTubeChannelID, status.Unavailable, status.Reason, next)
+}
+
+// recordSourceStatus stores the outcome of a channel status check on the account and notifies
+// when the channel became unavailable or came back
+func (m *AccountMonitor) recordSourceStatus(ctx context.Context, account *domain.Account, checked youtube.ChannelStatus, now time.Time) {
+	previous := account.SourceStatus
+	status := domain.SourceStatus{
+		Unavailable: checked.Unavailable,
+		Reason:      checked.Reason,
+		CheckedAt:   &now,
+	}
+	if status.Unavailable != "" {
+		status.Since = &now
+		if previous.Unavailable != "" && previous.Since != nil {
+			status.Since = previous.Since
+		}
+	}
+
+	if err := m.accountRepo.UpdateSourceStatus(context.WithoutCancel(ctx), account.ID, status); err != nil {
+		logger.Error().Printf("Failed to save the channel status of account %s: %v", account.ID, err)
+		return
+	}
+	account.SourceStatus = status
+
+	switch {
+	case status.Unavailable != "" && status.Unavailable != previous.Unavailable:
+		logger.Error().Printf("YouTube channel %s of account %s is %s, monitoring is paused: %s",
+			account.YouTubeChannelID, account.ID, status.Unavailable, status.Reason)
+		m.notifySource(ctx, account, domain.NotificationSourceUnavailable,
+			fmt.Sprintf("YouTube channel %s of account %s is %s (%s). The account is checked again every %s and resumes by itself when the channel is back.",
+				account.YouTubeChannelID, account.ID, status.Unavailable, status.Reason, m.config.YouTubeStatusInterval))
+	case status.Unavailable == "" && previous.Unavailable != "":
+		logger.Info().Printf("YouTube channel %s of account %s is available again, monitoring resumes", account.YouTubeChannelID, account.ID)
+		m.notifySource(ctx, account, domain.NotificationSourceRecovered,
+			fmt.Sprintf("YouTube channel %s of account %s is available again after being %s; monitoring resumed.",
+				account.YouTubeChannelID, account.ID, previous.Unavailable))
+	}
+}
+
+func (m *AccountMonitor) notifySource(ctx context.Context, account *domain.Account, event domain.NotificationEvent, message string) {
+	if m.notifier == nil {
+		return
+	}
+	n := &domain.Notification{
+		Event:     event,
+		AccountID: account.ID,
+		Message:   message,
+		Time:      time.Now(),
+	}
+	if err := m.notifier.Notify(context.WithoutCancel(ctx), n); err != nil {
+		logger.Error().Printf("Failed to send %s notification for account %s: %v", event, account.ID, err)
+	}
+}
+
+// SourceHealthCheck fails while the YouTube channel of an active account is suspended, terminated
+// or private, naming the accounts and why
+func SourceHealthCheck(accountRepo domain.AccountRepository) HealthCheckFunc {
+	return func(ctx context.Context) (string, error) {
+		accounts, err := accountRepo.GetAllActive(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list accounts: %w", err)
+		}
+
+		var unavailable []string
+		for _, account := range accounts {
+			status := account.SourceStatus
+			if status.Unavailable == "" {
+				continue
+			}
+			since := ""
+			if status.Since != nil {
+				since = " since " + status.Since.Format(time.RFC3339)
+			}
+			unavailable = append(unavailable, fmt.Sprintf("account %s (channel %s) %s%s: %s",
+				account.ID, account.YouTubeChannelID, status.Unavailable, since, status.Reason))
+		}
+		if len(unavailable) > 0 {
+			return "", fmt.Errorf("%d of %d active channels unavailable: %s", len(unavailable), len(accounts), strings.Join(unavailable, "; "))
+		}
+		return fmt.Sprintf("%d active channels available", len(accounts)), nil
+	}
+}
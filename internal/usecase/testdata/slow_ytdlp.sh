@@ -0,0 +1,24 @@
+#!/bin/sh
+# Stands in for yt-dlp in the download tests. Each download takes FAKE_YTDLP_DELAY seconds, is
+# logged as "start <id>" and "end <id>" lines in FAKE_YTDLP_LOG and leaves a small file at the
+# -o path. The video ID comes from the watch URL, the last argument.
+out=""
+url=""
+while [ $# -gt 0 ]; do
+	case "$1" in
+	-o)
+		out="$2"
+		shift
+		;;
+	*)
+		url="$1"
+		;;
+	esac
+	shift
+done
+id="${url##*v=}"
+
+echo "start $id" >> "$FAKE_YTDLP_LOG"
+sleep "$FAKE_YTDLP_DELAY"
+printf 'fake video %s\n' "$id" > "$(printf '%s' "$out" | sed 's/%(ext)s$/mp4/')"
+echo "end $id" >> "$FAKE_YTDLP_LOG"
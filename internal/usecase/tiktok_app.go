@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"fmt"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+)
+
+// TikTokAppName returns the account's credential set name, reporting legacy accounts as the default set
+func TikTokAppName(account *domain.Account) string {
+	if account.TikTokApp == "" {
+		return config.DefaultTikTokApp
+	}
+	return account.TikTokApp
+}
+
+// TikTokAppFor resolves the credential set the account's tokens belong to
+func TikTokAppFor(cfg *config.Config, account *domain.Account) (config.TikTokApp, error) {
+	return cfg.TikTokAppByName(account.TikTokApp)
+}
+
+// TikTokAppMismatch explains why the account's tokens cannot be refreshed with its credential set,
+// or returns "" when they match (or the issuing client key was never recorded)
+func TikTokAppMismatch(cfg *config.Config, account *domain.Account) string {
+	app, err := TikTokAppFor(cfg, account)
+	if err != nil {
+		return fmt.Sprintf("credential set %q is not configured", TikTokAppName(account))
+	}
+	if account.TikTokClientKey != "" && account.TikTokClientKey != app.APIKey {
+		return fmt.Sprintf("tokens were issued under a different client key than credential set %q now uses; re-authorize the account", app.Name)
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// TikTokPostURL builds the public link of a TikTok post from its video ID. TikTok redirects the
+// empty @name to the creator's profile, so the account's username is not needed. Publish IDs of
+// drafts and placeholder IDs of web uploads are not post IDs and have no link.
+func TikTokPostURL(tiktokVideoID string) string {
+	if tiktokVideoID == "" || strings.Trim(tiktokVideoID, "0123456789") != "" {
+		return ""
+	}
+	return "https://www.tiktok.com/@/video/" + url.PathEscape(tiktokVideoID)
+}
+
+// recordTikTokPostURL stores the post link of a video that has a TikTok post ID (best-effort)
+func (p *VideoProcessor) recordTikTokPostURL(ctx context.Context, video *domain.Video) {
+	postURL := TikTokPostURL(video.TikTokVideoID)
+	if postURL == "" || postURL == video.TikTokPostURL {
+		return
+	}
+	if err := p.videoRepo.UpdateTikTokPostURL(ctx, video.ID, postURL); err != nil {
+		logger.Error().Printf("Failed to record TikTok post URL of video %s: %v", video.YouTubeVideoID, err)
+		return
+	}
+	video.TikTokPostURL = postURL
+}
+
+// PostURLBackfill is the outcome of BackfillTikTokPostURLs
+type PostURLBackfill struct {
+	// Updated counts the videos whose post URL was recorded
+	Updated int
+
+	// Unresolved lists the videos whose TikTok ID is not a post ID (drafts, web uploads)
+	Unresolved []string
+}
+
+// BackfillTikTokPostURLs records the post URL of completed videos uploaded before URLs were
+// stored, built from their TikTok video IDs
+func BackfillTikTokPostURLs(ctx context.Context, videoRepo domain.VideoRepository) (*PostURLBackfill, error) {
+	videos, err := videoRepo.GetMissingTikTokPostURL(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list videos without post URL: %w", err)
+	}
+
+	result := &PostURLBackfill{Unresolved: []string{}}
+	for _, video := range videos {
+		postURL := TikTokPostURL(video.TikTokVideoID)
+		if postURL == "" {
+			result.Unresolved = append(result.Unresolved, video.ID)
+			continue
+		}
+		if err := videoRepo.UpdateTikTokPostURL(ctx, video.ID, postURL); err != nil {
+			return result, fmt.Errorf("failed to record post URL of video %s: %w", video.ID, err)
+		}
+		result.Updated++
+	}
+	logger.Info().Printf("Backfilled TikTok post URLs: %d recorded, %d without a post ID", result.Updated, len(result.Unresolved))
+	return result, nil
+}
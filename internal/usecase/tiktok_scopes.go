@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// recordMissingScope stores the scope a *tiktok.ScopeError names on the account and tells the
+// operator which scope to fix. Other errors are ignored. Unlike an expired token, a missing scope
+// is not helped by a refresh: the app must be approved for the scope and the account authorized
+// again, and the authorize endpoint then asks for the recorded scopes.
+func (p *VideoProcessor) recordMissingScope(ctx context.Context, account *domain.Account, err error) {
+	var scopeErr *tiktok.ScopeError
+	if !errors.As(err, &scopeErr) || slices.Contains(account.MissingScopes, scopeErr.Scope) {
+		return
+	}
+
+	scopes := append(slices.Clone(account.MissingScopes), scopeErr.Scope)
+	if updateErr := p.accountRepo.UpdateMissingScopes(context.WithoutCancel(ctx), account.ID, scopes); updateErr != nil {
+		logger.Error().Printf("Failed to record missing scope %s for account %s: %v", scopeErr.Scope, account.ID, updateErr)
+	}
+	account.MissingScopes = scopes
+
+	reason := fmt.Sprintf("its token lacks the %s scope; make sure the TikTok app is approved for %s, then authorize the account again", scopeErr.Scope, scopeErr.Scope)
+	logger.Error().Printf("TikTok refused a request of account %s: %s", account.ID, reason)
+	if p.reauthAlerter != nil {
+		p.reauthAlerter.Alert(ctx, account.ID, reason)
+	}
+}
+
+// clearMissingScopes forgets the scopes the old token lacked once new tokens are stored; the
+// next refusal records them again
+func clearMissingScopes(ctx context.Context, accountRepo domain.AccountRepository, account *domain.Account) error {
+	if len(account.MissingScopes) == 0 {
+		return nil
+	}
+	if err := accountRepo.UpdateMissingScopes(ctx, account.ID, nil); err != nil {
+		return fmt.Errorf("failed to clear missing scopes: %w", err)
+	}
+	account.MissingScopes = nil
+	return nil
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// ErrTikTokAccountMismatch is returned when a token was issued to another TikTok account than the
+// one the mapping posts to, e.g. because an authorization code from the wrong login was exchanged
+var ErrTikTokAccountMismatch = errors.New("token belongs to a different TikTok account")
+
+// pendingTikTokAccountPrefix starts the TikTok account ID of a mapping created without one; the
+// channel ID keeps it unique until the first code exchange replaces it with the login's open_id
+const pendingTikTokAccountPrefix = "pending:"
+
+// tokenCheckTTL is how long a verified token is trusted before TikTok is asked again
+const tokenCheckTTL = 10 * time.Minute
+
+// tokenCheck is a cached /user/info/ answer for an account's access token
+type tokenCheck struct {
+	accessToken string
+	openID      string
+	checkedAt   time.Time
+}
+
+// CheckTokenOwner compares the open_id a token was issued to with the account's TikTok account ID.
+// An empty openID (TikTok did not say) passes.
+func CheckTokenOwner(account *domain.Account, openID string) error {
+	if openID == "" || openID == account.TikTokAccountID {
+		return nil
+	}
+	return fmt.Errorf("%w: authorized as open_id %s but account %s posts to %s; re-authorize while signed in to that TikTok account",
+		ErrTikTokAccountMismatch, openID, account.ID, account.TikTokAccountID)
+}
+
+// PendingTikTokAccountID reports whether the account still waits for its first authorization to
+// learn which TikTok account it posts to
+func PendingTikTokAccountID(account *domain.Account) bool {
+	return account.TikTokAccountID == "" || strings.HasPrefix(account.TikTokAccountID, pendingTikTokAccountPrefix)
+}
+
+// RecordTikTokLogin stores what /user/info/ said about a newly exchanged token before the tokens
+// are saved: a mapping with a pending TikTok account ID takes the login's open_id, and the display
+// name and avatar are kept for the web UI. The caller has already refused tokens of another login.
+func (m *AccountManager) RecordTikTokLogin(ctx context.Context, accountID, openID, displayName, avatarURL string) (*domain.Account, error) {
+	account, err := m.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if account == nil {
+		return nil, fmt.Errorf("account not found: %s", accountID)
+	}
+
+	if openID != "" && PendingTikTokAccountID(account) {
+		if err := m.checkTikTokAccountFree(ctx, openID, account.ID); err != nil {
+			return nil, err
+		}
+		logger.Info().Printf("Account %s authorized as TikTok open_id %s (%s); using it as the TikTok account ID", account.ID, openID, displayName)
+		account, err = modifyAccount(ctx, m.accountRepo, accountID, "update account mapping", func(account *domain.Account) error {
+			if PendingTikTokAccountID(account) {
+				account.TikTokAccountID = openID
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if displayName != account.TikTokDisplayName || avatarURL != account.TikTokAvatarURL {
+		if err := m.accountRepo.UpdateTikTokProfile(ctx, account.ID, displayName, avatarURL); err != nil {
+			return nil, fmt.Errorf("failed to store TikTok profile: %w", err)
+		}
+		account.TikTokDisplayName = displayName
+		account.TikTokAvatarURL = avatarURL
+	}
+	return account, nil
+}
+
+// cachedTokenOwner returns the open_id recorded for the account's current access token, if still fresh
+func (p *VideoProcessor) cachedTokenOwner(account *domain.Account) (string, bool) {
+	p.tokenMu.Lock()
+	defer p.tokenMu.Unlock()
+
+	check, ok := p.tokenChecks[account.ID]
+	if !ok || check.accessToken != account.TikTokAccessToken || time.Since(check.checkedAt) > tokenCheckTTL {
+		return "", false
+	}
+	return check.openID, true
+}
+
+// rememberTokenOwner caches the open_id a verified access token belongs to
+func (p *VideoProcessor) rememberTokenOwner(account *domain.Account, openID string) {
+	p.tokenMu.Lock()
+	defer p.tokenMu.Unlock()
+
+	p.tokenChecks[account.ID] = tokenCheck{
+		accessToken: account.TikTokAccessToken,
+		openID:      openID,
+		checkedAt:   time.Now(),
+	}
+}
+
+// verifyTokenOwner refuses a token issued to another TikTok account and flags the account for
+// re-authorization so the mismatch shows up in the API and web UI
+func (p *VideoProcessor) verifyTokenOwner(ctx context.Context, account *domain.Account, openID string) error {
+	err := CheckTokenOwner(account, openID)
+	if err == nil {
+		return nil
+	}
+
+	logger.Error().Printf("Refusing uploads for account %s: %v", account.ID, err)
+	if !account.NeedsReauthorization {
+		if flagErr := p.accountRepo.UpdateNeedsReauthorization(context.WithoutCancel(ctx), account.ID, true); flagErr != nil {
+			logger.Error().Printf("Failed to flag account %s for re-authorization: %v", account.ID, flagErr)
+		}
+		account.NeedsReauthorization = true
+		if p.reauthAlerter != nil {
+			p.reauthAlerter.Alert(ctx, account.ID, "its token belongs to a different TikTok account")
+		}
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+)
+
+// ErrDataCapReached means a daily transfer cap would be exceeded; the video is retried the next day
+var ErrDataCapReached = errors.New("data cap reached")
+
+// TransferMeter counts bytes downloaded and uploaded per local calendar day and enforces the
+// transfer.* caps. Caps are checked before each transfer starts, so transfers already running
+// can overshoot a cap by at most their own size.
+type TransferMeter struct {
+	config *config.Config
+	repo   domain.TransferUsageRepository
+}
+
+// NewTransferMeter creates a meter persisting daily totals in repo
+func NewTransferMeter(cfg *config.Config, repo domain.TransferUsageRepository) *TransferMeter {
+	return &TransferMeter{config: cfg, repo: repo}
+}
+
+// TransferBudget is today's usage against the configured caps. Remaining values are -1 when
+// the corresponding cap is disabled.
+type TransferBudget struct {
+	Day           string
+	DownloadBytes int64
+	UploadBytes   int64
+
+	DailyCap    int64
+	DownloadCap int64
+	UploadCap   int64
+
+	Remaining         int64
+	DownloadRemaining int64
+	UploadRemaining   int64
+
+	// ResetsAt is the start of the next day, when usage starts over
+	ResetsAt time.Time
+}
+
+// TotalBytes returns downloaded plus uploaded bytes
+func (b *TransferBudget) TotalBytes() int64 {
+	return b.DownloadBytes + b.UploadBytes
+}
+
+// transferDay is the usage key of t: caps reset at local midnight
+func transferDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// Usage returns today's totals and remaining budget
+func (m *TransferMeter) Usage(ctx context.Context) (*TransferBudget, error) {
+	now := time.Now()
+	usage, err := m.repo.Get(ctx, transferDay(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer usage: %w", err)
+	}
+
+	year, month, day := now.Date()
+	budget := &TransferBudget{
+		Day:         transferDay(now),
+		DailyCap:    m.config.DailyTransferCap,
+		DownloadCap: m.config.DailyDownloadCap,
+		UploadCap:   m.config.DailyUploadCap,
+		ResetsAt:    time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()),
+	}
+	if usage != nil {
+		budget.DownloadBytes = usage.DownloadBytes
+		budget.UploadBytes = usage.UploadBytes
+	}
+	budget.Remaining = remainingBytes(budget.DailyCap, budget.TotalBytes())
+	budget.DownloadRemaining = remainingBytes(budget.DownloadCap, budget.DownloadBytes)
+	budget.UploadRemaining = remainingBytes(budget.UploadCap, budget.UploadBytes)
+	return budget, nil
+}
+
+func remainingBytes(limit, used int64) int64 {
+	if limit <= 0 {
+		return -1
+	}
+	return max(limit-used, 0)
+}
+
+// CheckTransfer returns an error wrapping ErrDataCapReached when downloading and uploading the
+// given number of bytes today would exceed a cap. Unknown sizes are passed as 0, in which case only
+// an exhausted cap blocks. A transfer larger than a whole cap still runs on a day with no usage,
+// otherwise it could never run at all.
+func (m *TransferMeter) CheckTransfer(ctx context.Context, downloadBytes, uploadBytes int64) error {
+	if m == nil || (m.config.DailyTransferCap <= 0 && m.config.DailyDownloadCap <= 0 && m.config.DailyUploadCap <= 0) {
+		return nil
+	}
+	budget, err := m.Usage(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := checkCap("daily", budget.DailyCap, budget.TotalBytes(), downloadBytes+uploadBytes); err != nil {
+		return fmt.Errorf("%w until %s", err, budget.ResetsAt.Format(time.RFC3339))
+	}
+	if err := checkCap("daily download", budget.DownloadCap, budget.DownloadBytes, downloadBytes); err != nil {
+		return fmt.Errorf("%w until %s", err, budget.ResetsAt.Format(time.RFC3339))
+	}
+	if err := checkCap("daily upload", budget.UploadCap, budget.UploadBytes, uploadBytes); err != nil {
+		return fmt.Errorf("%w until %s", err, budget.ResetsAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func checkCap(name string, limit, used, planned int64) error {
+	if limit <= 0 {
+		return nil
+	}
+	if used >= limit || (used > 0 && used+planned > limit) {
+		return fmt.Errorf("%w: %s cap of %d bytes (%d used, %d needed); deferred", ErrDataCapReached, name, limit, used, planned)
+	}
+	return nil
+}
+
+// RecordDownload adds downloaded bytes to today's total
+func (m *TransferMeter) RecordDownload(ctx context.Context, n int64) error {
+	if m == nil || n <= 0 {
+		return nil
+	}
+	return m.repo.Add(ctx, transferDay(time.Now()), n, 0)
+}
+
+// RecordUpload adds uploaded bytes to today's total
+func (m *TransferMeter) RecordUpload(ctx context.Context, n int64) error {
+	if m == nil || n <= 0 {
+		return nil
+	}
+	return m.repo.Add(ctx, transferDay(time.Now()), 0, n)
+}
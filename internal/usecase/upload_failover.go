@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/logger"
+	"auto_upload_tiktok/internal/redact"
+)
+
+// errUploadAuth marks API uploads that could not start because the account has no usable token
+var errUploadAuth = errors.New("tiktok authorization unavailable")
+
+// Upload failure classes recorded per path
+const (
+	uploadErrorAuth          = "auth"               // Token missing, expired or revoked
+	uploadErrorScope         = "scope_insufficient" // Token is valid but lacks the video.publish/upload scope
+	uploadErrorAppRestricted = "app_restricted"     // App not audited or otherwise blocked from posting
+	uploadErrorSession       = "session"            // Web session cookies missing, rejected or in use by another host
+	uploadErrorOther         = "other"              // Anything else, including transient network errors
+)
+
+// classifyUploadError maps an upload error to a failure class
+func classifyUploadError(path domain.UploadPath, err error) string {
+	if errors.Is(err, errUploadAuth) {
+		return uploadErrorAuth
+	}
+	if errors.Is(err, tiktok.ErrScopeInsufficient) {
+		return uploadErrorScope
+	}
+	if errors.Is(err, ErrWebSessionInUse) {
+		return uploadErrorSession
+	}
+
+	msg := strings.ToLower(err.Error())
+	if path == domain.UploadPathWeb {
+		if strings.Contains(msg, "failed to load cookies") || strings.Contains(msg, "login") {
+			return uploadErrorSession
+		}
+		return uploadErrorOther
+	}
+
+	switch {
+	case strings.Contains(msg, "scope_not_authorized"), strings.Contains(msg, "scope_permission"):
+		return uploadErrorScope
+	case strings.Contains(msg, "unaudited_client"), strings.Contains(msg, "app_not_approved"):
+		return uploadErrorAppRestricted
+	case strings.Contains(msg, "access_token_invalid"), strings.Contains(msg, "invalid_token"),
+		strings.Contains(msg, "status 401"):
+		return uploadErrorAuth
+	case strings.Contains(msg, "status 403"):
+		return uploadErrorScope
+	default:
+		return uploadErrorOther
+	}
+}
+
+// triggersFailover reports whether a failure class means the path is unusable until someone intervenes
+func triggersFailover(class string) bool {
+	switch class {
+	case uploadErrorAuth, uploadErrorScope, uploadErrorAppRestricted, uploadErrorSession:
+		return true
+	default:
+		return false
+	}
+}
+
+// selectUploadPath picks the path for the next upload: the fallback while a failover cool-down
+// is running, otherwise the primary
+func (p *VideoProcessor) selectUploadPath(account *domain.Account, now time.Time) domain.UploadPath {
+	primary := account.Settings.PrimaryUploadPath(p.config.TikTokEnableWeb)
+	fallback := account.Settings.FallbackPath(p.config.TikTokEnableWeb)
+	health := account.UploadHealth
+
+	if fallback == "" || health.ActivePath != fallback || health.RetryPrimaryAt == nil {
+		return primary
+	}
+	if now.Before(*health.RetryPrimaryAt) {
+		return fallback
+	}
+
+	logger.Info().Printf("Failover cool-down over for account %s, retrying %s upload", account.ID, primary)
+	return primary
+}
+
+// recordUploadOutcome updates the path counters and failover state after an upload attempt.
+// It returns the path to retry on when the failure should fail over right away.
+func (p *VideoProcessor) recordUploadOutcome(ctx context.Context, accountID string, path domain.UploadPath, uploadErr error) (domain.UploadPath, bool) {
+	// Shutdown interrupting an upload says nothing about the path's health
+	if errors.Is(uploadErr, context.Canceled) {
+		return "", false
+	}
+
+	p.healthMu.Lock()
+	defer p.healthMu.Unlock()
+
+	// Re-read so concurrent uploads for the same account do not lose each other's counts
+	account, err := p.accountRepo.GetByID(ctx, accountID)
+	if err != nil || account == nil {
+		logger.Error().Printf("Failed to load account %s to record upload outcome: %v", accountID, err)
+		return "", false
+	}
+
+	now := time.Now()
+	health := account.UploadHealth
+	stats := health.Stats(path)
+	primary := account.Settings.PrimaryUploadPath(p.config.TikTokEnableWeb)
+	fallback := account.Settings.FallbackPath(p.config.TikTokEnableWeb)
+
+	var failoverTo domain.UploadPath
+	if uploadErr == nil {
+		stats.Successes++
+		stats.ConsecutiveFailures = 0
+		stats.LastSuccessAt = &now
+		if path == primary && health.ActivePath != "" {
+			logger.Info().Printf("Account %s is back on %s upload", accountID, primary)
+			health.ActivePath = ""
+			health.Reason = ""
+			health.RetryPrimaryAt = nil
+		}
+	} else {
+		class := classifyUploadError(path, uploadErr)
+		stats.Failures++
+		stats.ConsecutiveFailures++
+		stats.LastFailureAt = &now
+		stats.LastError = redact.String(uploadErr.Error())
+		stats.LastErrorClass = class
+
+		if path == primary && fallback != "" && triggersFailover(class) {
+			retryAt := now.Add(p.config.FailoverCooldown)
+			health.ActivePath = fallback
+			health.Reason = fmt.Sprintf("%s upload failed (%s): %s", primary, class, stats.LastError)
+			health.RetryPrimaryAt = &retryAt
+			failoverTo = fallback
+			logger.Error().Printf("Account %s failing over from %s to %s upload until %s: %s",
+				accountID, primary, fallback, retryAt.Format(time.RFC3339), class)
+		}
+	}
+
+	if err := p.accountRepo.UpdateUploadHealth(ctx, accountID, health); err != nil {
+		logger.Error().Printf("Failed to save upload health for account %s: %v", accountID, err)
+	}
+	return failoverTo, failoverTo != ""
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/uuid"
+
+	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// errDuplicateContent means an identical file was already uploaded to the account, so the video is skipped
+var errDuplicateContent = errors.New("identical content already uploaded to this account")
+
+// errPublishPending means an earlier upload of the video may still be published by TikTok, so the
+// video stays pending instead of being uploaded a second time
+var errPublishPending = errors.New("previous upload still pending on TikTok")
+
+// resumeUpload checks the TikTok upload recorded for the video by an earlier attempt that did
+// not finish, e.g. because the process crashed after TikTok accepted the file. It reports true
+// when that upload was published, in which case the video must not be uploaded again.
+// Attempts that failed or that TikTok does not know are left to a fresh upload.
+func (p *VideoProcessor) resumeUpload(ctx context.Context, video *domain.Video) (bool, error) {
+	if video.UploadPublishID == "" || video.TikTokVideoID != "" {
+		return false, nil
+	}
+
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get account mapping: %w", err)
+	}
+	if account == nil {
+		return false, nil
+	}
+	if err := p.ensureAccessToken(ctx, account); err != nil {
+		return false, err
+	}
+
+	status, err := p.tiktokService.FetchPublishStatus(account.TikTokAccessToken, video.UploadPublishID)
+	switch {
+	case errors.Is(err, tiktok.ErrPublishNotFound):
+		logger.Info().Printf("Previous upload %s of video %s is unknown to TikTok, uploading again", video.UploadPublishID, video.YouTubeVideoID)
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("%w: failed to check upload %s: %v", errPublishPending, video.UploadPublishID, err)
+	case status.InProgress():
+		return false, fmt.Errorf("%w: upload %s is %s", errPublishPending, video.UploadPublishID, status.Status)
+	case !status.Done():
+		logger.Info().Printf("Previous upload %s of video %s ended as %s (%s), uploading again",
+			video.UploadPublishID, video.YouTubeVideoID, status.Status, status.FailReason)
+		return false, nil
+	}
+
+	tiktokVideoID := status.PostID
+	if tiktokVideoID == "" {
+		tiktokVideoID = video.UploadPublishID
+	}
+	if err := p.videoRepo.UpdateTikTokID(context.WithoutCancel(ctx), video.ID, tiktokVideoID); err != nil {
+		return false, err
+	}
+	video.TikTokVideoID = tiktokVideoID
+	logger.Info().Printf("Previous upload of video %s was published as TikTok video %s, not uploading again", video.YouTubeVideoID, tiktokVideoID)
+	return true, nil
+}
+
+// deferPublishCheck keeps a video pending while an earlier upload of it cannot be ruled out
+func (p *VideoProcessor) deferPublishCheck(ctx context.Context, video *domain.Video, err error) error {
+	p.videoRepo.UpdateStatus(ctx, video.ID, domain.VideoStatusPending, err.Error())
+	logger.Info().Printf("Deferring video %s: %v", video.YouTubeVideoID, err)
+	return err
+}
+
+// checkDuplicateContent hashes the video file and refuses it when the account already has a
+// completed video with the same content. The hash is kept on the video for later checks.
+func (p *VideoProcessor) checkDuplicateContent(ctx context.Context, video *domain.Video) error {
+	hash, err := fileSHA256(video.LocalFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to hash video file: %w", err)
+	}
+	video.ContentHash = hash
+
+	dup, err := p.videoRepo.FindCompletedByContentHash(ctx, video.AccountID, hash, video.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check for duplicate content: %w", err)
+	}
+	if dup != nil {
+		return fmt.Errorf("%w: same file as video %s (TikTok video %s)", errDuplicateContent, dup.YouTubeVideoID, dup.TikTokVideoID)
+	}
+	return nil
+}
+
+// startUploadAttempt records a new attempt before TikTok is called and returns the callback that
+// stores the publish ID TikTok assigns to it. Both writes survive a cancelled ctx: losing them
+// would let a retry upload the video again.
+func (p *VideoProcessor) startUploadAttempt(ctx context.Context, video *domain.Video) (func(publishID string), error) {
+	recordCtx := context.WithoutCancel(ctx)
+	attemptID := uuid.NewString()
+	if err := p.videoRepo.StartUploadAttempt(recordCtx, video.ID, attemptID, video.ContentHash); err != nil {
+		return nil, fmt.Errorf("failed to record upload attempt: %w", err)
+	}
+	video.UploadAttemptID = attemptID
+	video.UploadPublishID = ""
+
+	return func(publishID string) {
+		if err := p.videoRepo.SetUploadPublishID(recordCtx, video.ID, attemptID, publishID); err != nil {
+			logger.Error().Printf("Failed to record publish ID %s for video %s: %v", publishID, video.YouTubeVideoID, err)
+			return
+		}
+		video.UploadPublishID = publishID
+	}, nil
+}
+
+// fileSHA256 returns the hex SHA-256 of a file's contents
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
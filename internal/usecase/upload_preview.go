@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+)
+
+// Layers a previewed upload value can come from
+const (
+	PreviewSourceOverride    = "override"    // the video's post options
+	PreviewSourceAccount     = "account"     // the account's settings
+	PreviewSourceGlobal      = "global"      // the configuration or TikTok's default
+	PreviewSourceVideo       = "video"       // the YouTube video
+	PreviewSourceTranslation = "translation" // the title translated into the account's caption language
+	PreviewSourceExperiment  = "experiment"  // the caption of an experiment arm
+)
+
+// placeholderPattern finds template placeholders such as {title} that were left unfilled
+var placeholderPattern = regexp.MustCompile(`\{[A-Za-z_]+\}`)
+
+// UploadPreview is what uploading a video now would send to TikTok, without sending anything
+type UploadPreview struct {
+	VideoID    string
+	AccountID  string
+	Path       domain.UploadPath
+	PathReason string
+
+	// Request is the resolved upload request; its credentials are left out
+	Request *tiktok.UploadRequest
+
+	// PostInfo is the post_info of a direct API post, Caption what the web uploader types; the
+	// other is empty. API drafts send no post_info: the creator writes it in TikTok.
+	PostInfo map[string]any
+	Caption  string
+
+	// Comment is the first comment posted after publishing, empty when none is posted
+	Comment string
+
+	// Sources names the layer each resolved value came from, keyed by request field
+	Sources map[string]string
+
+	// Problems are what would break or fail the upload, such as unfilled template placeholders
+	Problems []string
+}
+
+// PreviewUpload resolves the caption, post settings and upload path of a video the way an upload
+// started now would, and reports problems with them instead of failing. Nothing is uploaded and
+// TikTok is not asked: interactions the creator turned off in TikTok are only applied at upload.
+// A title translation is cached on the video like at upload, so the upload uses the previewed one.
+func (p *VideoProcessor) PreviewUpload(ctx context.Context, id string) (*UploadPreview, error) {
+	video, err := p.videoRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video: %w", err)
+	}
+	if video == nil {
+		return nil, fmt.Errorf("%w: %s", ErrVideoNotFound, id)
+	}
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if account == nil {
+		return nil, fmt.Errorf("%w: %s (video %s)", ErrAccountNotFound, video.AccountID, video.ID)
+	}
+
+	now := time.Now()
+	preview := &UploadPreview{
+		VideoID:   video.ID,
+		AccountID: account.ID,
+		Sources:   uploadSources(account, video),
+	}
+	problem := func(format string, args ...any) {
+		preview.Problems = append(preview.Problems, fmt.Sprintf(format, args...))
+	}
+
+	selected := p.selectUploadPath(account, now)
+	path, reason, err := p.routeUpload(account, selected, p.previewMedia(ctx, video))
+	if err != nil {
+		problem("%v", err)
+		path, reason = selected, "preferred path"
+	}
+	if why := p.pathUnavailable(account, path); why != "" {
+		problem("%s upload is unavailable: %s", path, why)
+	}
+	preview.Path = path
+	preview.PathReason = reason
+
+	req := p.buildUploadRequest(ctx, account, video, now)
+	if original, _ := SanitizeCaption(video.Title, "", p.captionOptions()); req.Title != original {
+		preview.Sources["title"] = PreviewSourceTranslation
+	}
+	if !p.config.CaptionUseDescription {
+		preview.Sources["description"] = PreviewSourceGlobal
+	}
+	if err := tiktok.ValidatePublishOptions(req, now); err != nil {
+		problem("%v", err)
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		problem("title is empty")
+	}
+	if n := utf8.RuneCountInString(req.Title); n > maxCaptionLength {
+		problem("title is %d characters, TikTok accepts %d", n, maxCaptionLength)
+	}
+	checkPlaceholders(problem, "title", req.Title)
+	checkPlaceholders(problem, "description", req.Description)
+
+	switch {
+	case path == domain.UploadPathWeb:
+		preview.Caption = tiktok.WebCaption(req)
+	case !req.PostAsDraft:
+		preview.PostInfo = tiktok.PostInfo(req)
+	}
+
+	// Same conditions as postFirstComment: drafts and scheduled posts get no comment
+	if strings.TrimSpace(account.CommentTemplate) != "" && !req.PostAsDraft && req.ScheduleTime.IsZero() {
+		preview.Comment = renderCommentTemplate(account.CommentTemplate, video)
+		checkPlaceholders(problem, "comment_template", preview.Comment)
+	}
+
+	req.AccessToken = ""
+	preview.Request = req
+	return preview, nil
+}
+
+// previewMedia measures the video like an upload does, once it is downloaded; before that only the
+// known length is checked against the path limits
+func (p *VideoProcessor) previewMedia(ctx context.Context, video *domain.Video) uploadMedia {
+	if video.LocalFilePath != "" {
+		return p.measureUpload(ctx, video)
+	}
+	media := uploadMedia{duration: video.Duration}
+	if video.ClipEnd > video.ClipStart {
+		media.duration = video.ClipEnd - video.ClipStart
+	}
+	return media
+}
+
+// uploadSources names the layer each post setting of the video's upload comes from, mirroring
+// applyPostOptions. The title starts out as the video's; PreviewUpload marks translations.
+func uploadSources(account *domain.Account, video *domain.Video) map[string]string {
+	settings := account.Settings
+	options := video.PostOptions
+	if options == nil {
+		options = &domain.PostOptions{}
+	}
+
+	// Account flags cannot be told apart from an unset default when off
+	layer := func(override, account bool) string {
+		switch {
+		case override:
+			return PreviewSourceOverride
+		case account:
+			return PreviewSourceAccount
+		default:
+			return PreviewSourceGlobal
+		}
+	}
+
+	title := PreviewSourceVideo
+	if video.ParentVideoID != "" && video.ClipEnd == 0 {
+		title = PreviewSourceExperiment
+	}
+	return map[string]string{
+		"title":           title,
+		"description":     PreviewSourceVideo,
+		"privacy_level":   layer(options.PrivacyLevel != "", settings.PrivacyLevel != ""),
+		"disable_comment": layer(options.DisableComment != nil, settings.DisableComment),
+		"disable_duet":    layer(options.DisableDuet != nil, settings.DisableDuet),
+		"disable_stitch":  layer(options.DisableStitch != nil, settings.DisableStitch),
+		"post_as_draft":   layer(false, settings.PostAsDraft),
+		"schedule_time":   layer(false, settings.PublishDelay > 0),
+		"upload_path":     layer(false, settings.UploadPath.IsValid()),
+	}
+}
+
+// checkPlaceholders reports placeholders left in text, such as a literal {title} from a template
+// or a placeholder the comment template does not know
+func checkPlaceholders(problem func(format string, args ...any), field, text string) {
+	if found := placeholderPattern.FindAllString(text, -1); len(found) > 0 {
+		problem("%s contains unfilled placeholders: %s", field, strings.Join(found, ", "))
+	}
+}
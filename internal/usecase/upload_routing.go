@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// errNoUploadPath means no upload path available to the account accepts the video's file, so
+// retrying cannot help until the limits or the account's paths change
+var errNoUploadPath = errors.New("no upload path accepts the video")
+
+// uploadMedia is what the upload path limits are checked against; zero means unknown
+type uploadMedia struct {
+	size     int64
+	duration time.Duration
+}
+
+// measureUpload reads the file size and the video length. Clips know their length; other videos
+// are probed, and a video whose length cannot be probed is routed by size alone.
+func (p *VideoProcessor) measureUpload(ctx context.Context, video *domain.Video) uploadMedia {
+	var media uploadMedia
+	if info, err := os.Stat(video.LocalFilePath); err == nil {
+		media.size = info.Size()
+	}
+
+	switch {
+	case video.ClipEnd > video.ClipStart:
+		media.duration = video.ClipEnd - video.ClipStart
+	case video.Duration > 0:
+		media.duration = video.Duration
+	default:
+		duration, err := p.downloadService.ProbeDuration(ctx, video.LocalFilePath)
+		if err != nil {
+			logger.Error().Printf("Failed to probe length of video %s, routing by size only: %v", video.YouTubeVideoID, err)
+		}
+		media.duration = duration
+	}
+	return media
+}
+
+// uploadLimits returns the account's limits for the path, falling back to the configured ones
+func (p *VideoProcessor) uploadLimits(account *domain.Account, path domain.UploadPath) domain.UploadLimits {
+	limits := account.Settings.UploadLimits[path]
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = p.config.TikTokAPIMaxSize
+		if path == domain.UploadPathWeb {
+			limits.MaxSize = p.config.TikTokWebMaxSize
+		}
+	}
+	if limits.MaxDuration <= 0 {
+		limits.MaxDuration = domain.Duration(p.config.TikTokAPIMaxDuration)
+		if path == domain.UploadPathWeb {
+			limits.MaxDuration = domain.Duration(p.config.TikTokWebMaxDuration)
+		}
+	}
+	return limits
+}
+
+// exceedsLimits explains why the path cannot take the media, or returns "" when it can
+func (p *VideoProcessor) exceedsLimits(account *domain.Account, path domain.UploadPath, media uploadMedia) string {
+	limits := p.uploadLimits(account, path)
+	switch {
+	case limits.MaxSize > 0 && media.size > limits.MaxSize:
+		return fmt.Sprintf("file is %s, over the %s limit of %s", formatBytes(media.size), path, formatBytes(limits.MaxSize))
+	case limits.MaxDuration > 0 && media.duration > time.Duration(limits.MaxDuration):
+		return fmt.Sprintf("video is %s long, over the %s limit of %s", media.duration.Round(time.Second), path, time.Duration(limits.MaxDuration))
+	default:
+		return ""
+	}
+}
+
+// pathUnavailable explains why the account cannot upload through the path, or returns "" when it can
+func (p *VideoProcessor) pathUnavailable(account *domain.Account, path domain.UploadPath) string {
+	if path == domain.UploadPathAPI {
+		if account.TikTokAccessToken == "" && account.TikTokRefreshToken == "" {
+			return "the account has no API authorization"
+		}
+		return ""
+	}
+	switch {
+	case !p.config.TikTokEnableWeb:
+		return "tiktok.enable_web is off"
+	case account.Settings.PostAsDraft:
+		return "drafts need the API"
+	case account.Settings.PublishDelay > 0:
+		return "scheduled posts need the API"
+	default:
+		return ""
+	}
+}
+
+// routeUpload checks the media against the selected path's limits and moves it to the other path
+// when only that one accepts it. It returns the path with the reason it was chosen, or an error
+// wrapping errNoUploadPath when no available path accepts the media.
+func (p *VideoProcessor) routeUpload(account *domain.Account, selected domain.UploadPath, media uploadMedia) (domain.UploadPath, string, error) {
+	reason := "preferred path"
+	if selected != account.Settings.PrimaryUploadPath(p.config.TikTokEnableWeb) {
+		reason = "fallback path while the preferred one cools down after a failover"
+	}
+
+	over := p.exceedsLimits(account, selected, media)
+	if over == "" {
+		return selected, reason, nil
+	}
+
+	other := domain.UploadPathWeb
+	if selected == domain.UploadPathWeb {
+		other = domain.UploadPathAPI
+	}
+	if why := p.pathUnavailable(account, other); why != "" {
+		return "", "", fmt.Errorf("%w: %s, and %s upload is unavailable: %s", errNoUploadPath, over, other, why)
+	}
+	if otherOver := p.exceedsLimits(account, other, media); otherOver != "" {
+		return "", "", fmt.Errorf("%w: %s; %s", errNoUploadPath, over, otherOver)
+	}
+	return other, fmt.Sprintf("%s, routed to %s", over, other), nil
+}
+
+// recordUploadRoute stores the chosen path on the video; a failed write only costs the record
+func (p *VideoProcessor) recordUploadRoute(ctx context.Context, video *domain.Video, path domain.UploadPath, reason string) {
+	if err := p.videoRepo.SetUploadRoute(context.WithoutCancel(ctx), video.ID, path, reason); err != nil {
+		logger.Error().Printf("Failed to record upload route of video %s: %v", video.YouTubeVideoID, err)
+	}
+	video.UploadRoute = path
+	video.UploadRouteReason = reason
+}
+
+// formatBytes renders a byte count for route reasons and errors
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
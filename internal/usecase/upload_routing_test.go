@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+)
+
+func TestRouteUpload(t *testing.T) {
+	// API takes 100 bytes / 10 minutes, web 1000 bytes / 60 minutes
+	const apiSize, webSize = 100, 1000
+	apiLength, webLength := 10*time.Minute, 60*time.Minute
+
+	withToken := func(settings domain.AccountSettings) *domain.Account {
+		return &domain.Account{ID: "acc-1", TikTokAccessToken: "act.1", Settings: settings}
+	}
+	tests := []struct {
+		name       string
+		webEnabled bool
+		account    *domain.Account
+		selected   domain.UploadPath
+		media      uploadMedia
+		wantPath   domain.UploadPath
+		wantReason string // substring
+		wantErr    string // substring of an errNoUploadPath error
+	}{
+		{
+			name:     "API at its size limit",
+			account:  withToken(domain.AccountSettings{}),
+			selected: domain.UploadPathAPI,
+			media:    uploadMedia{size: apiSize, duration: apiLength},
+			wantPath: domain.UploadPathAPI, wantReason: "preferred path",
+		},
+		{
+			name:       "one byte over the API limit goes to web",
+			webEnabled: true,
+			account:    withToken(domain.AccountSettings{UploadPath: domain.UploadPathAPI}),
+			selected:   domain.UploadPathAPI,
+			media:      uploadMedia{size: apiSize + 1},
+			wantPath:   domain.UploadPathWeb, wantReason: "file is 101 B, over the api limit of 100 B, routed to web",
+		},
+		{
+			name:       "one second over the API length goes to web",
+			webEnabled: true,
+			account:    withToken(domain.AccountSettings{UploadPath: domain.UploadPathAPI}),
+			selected:   domain.UploadPathAPI,
+			media:      uploadMedia{size: 10, duration: apiLength + time.Second},
+			wantPath:   domain.UploadPathWeb, wantReason: "video is 10m1s long, over the api limit of 10m0s, routed to web",
+		},
+		{
+			name:       "unknown length routes by size",
+			webEnabled: true,
+			account:    withToken(domain.AccountSettings{}),
+			selected:   domain.UploadPathWeb,
+			media:      uploadMedia{size: webSize},
+			wantPath:   domain.UploadPathWeb, wantReason: "preferred path",
+		},
+		{
+			name:       "web over its limit goes to the API",
+			webEnabled: true,
+			account:    withToken(domain.AccountSettings{UploadLimits: map[domain.UploadPath]domain.UploadLimits{domain.UploadPathAPI: {MaxSize: 5000}}}),
+			selected:   domain.UploadPathWeb,
+			media:      uploadMedia{size: webSize + 1},
+			wantPath:   domain.UploadPathAPI, wantReason: "over the web limit of 1000 B, routed to api",
+		},
+		{
+			name:       "account override raises the API limit",
+			webEnabled: true,
+			account: withToken(domain.AccountSettings{
+				UploadPath:   domain.UploadPathAPI,
+				UploadLimits: map[domain.UploadPath]domain.UploadLimits{domain.UploadPathAPI: {MaxSize: 500, MaxDuration: domain.Duration(time.Hour)}},
+			}),
+			selected: domain.UploadPathAPI,
+			media:    uploadMedia{size: 500, duration: time.Hour},
+			wantPath: domain.UploadPathAPI, wantReason: "preferred path",
+		},
+		{
+			name:       "fallback path reason",
+			webEnabled: true,
+			account:    withToken(domain.AccountSettings{UploadPath: domain.UploadPathWeb}),
+			selected:   domain.UploadPathAPI,
+			media:      uploadMedia{size: 10},
+			wantPath:   domain.UploadPathAPI, wantReason: "fallback path",
+		},
+		{
+			name:     "web disabled",
+			account:  withToken(domain.AccountSettings{}),
+			selected: domain.UploadPathAPI,
+			media:    uploadMedia{size: apiSize + 1},
+			wantErr:  "web upload is unavailable: tiktok.enable_web is off",
+		},
+		{
+			name:       "drafts cannot move to web",
+			webEnabled: true,
+			account:    withToken(domain.AccountSettings{UploadPath: domain.UploadPathAPI, PostAsDraft: true}),
+			selected:   domain.UploadPathAPI,
+			media:      uploadMedia{size: apiSize + 1},
+			wantErr:    "drafts need the API",
+		},
+		{
+			name:       "no API authorization",
+			webEnabled: true,
+			account:    &domain.Account{ID: "acc-1"},
+			selected:   domain.UploadPathWeb,
+			media:      uploadMedia{size: webSize + 1},
+			wantErr:    "api upload is unavailable: the account has no API authorization",
+		},
+		{
+			name:       "too large for both paths",
+			webEnabled: true,
+			account:    withToken(domain.AccountSettings{UploadPath: domain.UploadPathAPI}),
+			selected:   domain.UploadPathAPI,
+			media:      uploadMedia{size: webSize + 1},
+			wantErr:    "over the api limit of 100 B; file is 1001 B, over the web limit of 1000 B",
+		},
+		{
+			name:       "too long for both paths",
+			webEnabled: true,
+			account:    withToken(domain.AccountSettings{}),
+			selected:   domain.UploadPathWeb,
+			media:      uploadMedia{size: 10, duration: webLength + time.Second},
+			wantErr:    "over the web limit of 1h0m0s; video is 1h0m1s long, over the api limit of 10m0s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := newTestProcessor(t, nil, func(cfg *config.Config) {
+				cfg.TikTokEnableWeb = tt.webEnabled
+				cfg.TikTokAPIMaxSize, cfg.TikTokAPIMaxDuration = apiSize, apiLength
+				cfg.TikTokWebMaxSize, cfg.TikTokWebMaxDuration = webSize, webLength
+			})
+
+			path, reason, err := tp.routeUpload(tt.account, tt.selected, tt.media)
+			if tt.wantErr != "" {
+				if !errors.Is(err, errNoUploadPath) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("routeUpload() error = %v, want %v mentioning %q", err, errNoUploadPath, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("routeUpload() error = %v", err)
+			}
+			if path != tt.wantPath || !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("routeUpload() = %s, %q; want %s, %q", path, reason, tt.wantPath, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestMeasureUpload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "v1.mp4")
+	if err := os.WriteFile(file, make([]byte, 1234), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tp := newTestProcessor(t, nil, nil)
+
+	tests := []struct {
+		name  string
+		video *domain.Video
+		want  uploadMedia
+	}{
+		{
+			name:  "clip length",
+			video: &domain.Video{LocalFilePath: file, ClipStart: time.Minute, ClipEnd: 3 * time.Minute, Duration: time.Hour},
+			want:  uploadMedia{size: 1234, duration: 2 * time.Minute},
+		},
+		{
+			name:  "known length",
+			video: &domain.Video{LocalFilePath: file, Duration: 90 * time.Second},
+			want:  uploadMedia{size: 1234, duration: 90 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tp.measureUpload(t.Context(), tt.video); got != tt.want {
+				t.Errorf("measureUpload() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// errOutsideUploadWindow means the video was downloaded and waits in waiting_window for its
+// account's upload window to open
+var errOutsideUploadWindow = errors.New("outside the account's upload window")
+
+// uploadWindowOpenedMessage marks a video moved back to pending once its upload window opened;
+// the file it was downloaded to is reused
+const uploadWindowOpenedMessage = "upload window opened, the downloaded file is kept"
+
+// waitForUploadWindow moves a downloaded video to waiting_window while its account's upload
+// window is closed and returns errOutsideUploadWindow. The file is kept as downloaded, before any
+// hook changed it, for the upload once the window opens.
+func (p *VideoProcessor) waitForUploadWindow(ctx context.Context, video *domain.Video) error {
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
+	if err != nil || account == nil {
+		// The upload loads the account again and reports what is wrong with it
+		return nil
+	}
+	window, err := account.Settings.UploadWindow()
+	if err != nil {
+		// Settings are checked when saved, so this is an account stored by an older version
+		logger.Error().Printf("Ignoring the upload window of account %s: %v", account.ID, err)
+		return nil
+	}
+	now := time.Now()
+	if window == nil || window.Contains(now) {
+		return nil
+	}
+
+	err = fmt.Errorf("%w %s, waiting until %s", errOutsideUploadWindow, window, window.NextOpen(now).Format(time.RFC3339))
+	p.videoRepo.UpdateStatus(ctx, video.ID, domain.VideoStatusWaitingWindow, err.Error())
+	logger.Info().Printf("Video %s is downloaded and waits: %v", video.YouTubeVideoID, err)
+	return err
+}
+
+// keptForUploadWindow reports whether the video came back from waiting_window and its file is
+// still there
+func (p *VideoProcessor) keptForUploadWindow(video *domain.Video) bool {
+	return video.ErrorMessage == uploadWindowOpenedMessage &&
+		video.LocalFilePath != "" && fileExists(video.LocalFilePath)
+}
+
+// ReleaseUploadWindows moves the videos waiting in waiting_window back to pending once their
+// account's upload window is open, or the account no longer has one, and returns how many moved.
+// Each processing run calls it first, so the videos are uploaded in the same run.
+func (p *VideoProcessor) ReleaseUploadWindows(ctx context.Context) (int, error) {
+	var waiting []*domain.Video
+	err := p.videoRepo.Iterate(ctx, domain.VideoFilter{Status: domain.VideoStatusWaitingWindow}, "", func(video *domain.Video) error {
+		waiting = append(waiting, video)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list videos waiting for their upload window: %w", err)
+	}
+
+	now := time.Now()
+	accounts := make(map[string]*domain.Account)
+	released := 0
+	for _, video := range waiting {
+		account, ok := accounts[video.AccountID]
+		if !ok {
+			if account, err = p.accountRepo.GetByID(ctx, video.AccountID); err != nil {
+				return released, fmt.Errorf("failed to get account %s: %w", video.AccountID, err)
+			}
+			accounts[video.AccountID] = account
+		}
+		if account == nil {
+			continue
+		}
+		if window, err := account.Settings.UploadWindow(); err == nil && window != nil && !window.Contains(now) {
+			continue
+		}
+
+		if err := p.videoRepo.UpdateStatus(ctx, video.ID, domain.VideoStatusPending, uploadWindowOpenedMessage); err != nil {
+			return released, fmt.Errorf("failed to queue video %s: %w", video.ID, err)
+		}
+		released++
+		logger.Info().Printf("Upload window of account %s is open; video %s queued for upload", account.ID, video.YouTubeVideoID)
+	}
+	return released, nil
+}
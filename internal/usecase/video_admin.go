@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+var (
+	// ErrForceNotAllowed is returned for videos whose status is derived from their clips
+	ErrForceNotAllowed = errors.New("video status cannot be forced")
+
+	// ErrInvalidForce is returned for a force request missing what it must record
+	ErrInvalidForce = errors.New("invalid force request")
+
+	// ErrNotAwaitingApproval is returned when approving a video the content-safety check did not stop
+	ErrNotAwaitingApproval = errors.New("video is not held by the content-safety check")
+)
+
+// VideoAdmin applies an administrator's decision about a video outside the normal pipeline,
+// such as marking a video uploaded by hand during an outage completed. Every change is written
+// to the audit log together with the new status.
+type VideoAdmin struct {
+	videoRepo  domain.VideoRepository
+	auditRepo  domain.AuditRepository
+	transactor domain.Transactor // Optional: writes the change and its audit entry in one transaction
+	processor  *VideoProcessor
+}
+
+// NewVideoAdmin creates a VideoAdmin that stops the processor's work on a video before changing it
+func NewVideoAdmin(videoRepo domain.VideoRepository, auditRepo domain.AuditRepository, processor *VideoProcessor) *VideoAdmin {
+	return &VideoAdmin{videoRepo: videoRepo, auditRepo: auditRepo, processor: processor}
+}
+
+// SetTransactor writes each forced status and its audit entry in one transaction
+func (a *VideoAdmin) SetTransactor(transactor domain.Transactor) {
+	a.transactor = transactor
+}
+
+// withTx runs fn in a transaction when a transactor is set, otherwise directly on the repositories
+func (a *VideoAdmin) withTx(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	if a.transactor == nil {
+		return fn(ctx, domain.Repositories{Videos: a.videoRepo, Audit: a.auditRepo})
+	}
+	return a.transactor.WithTx(ctx, fn)
+}
+
+// ForceComplete marks a video completed, recording the TikTok video ID given directly or taken
+// from a TikTok video URL
+func (a *VideoAdmin) ForceComplete(ctx context.Context, id, tiktokVideoID, tiktokURL, actor string) (*domain.Video, error) {
+	if tiktokVideoID == "" && tiktokURL != "" {
+		parsed, err := tiktokVideoIDFromURL(tiktokURL)
+		if err != nil {
+			return nil, err
+		}
+		tiktokVideoID = parsed
+	}
+
+	detail := []string{}
+	if tiktokVideoID != "" {
+		detail = append(detail, "tiktok_video_id="+tiktokVideoID)
+	}
+	if tiktokURL != "" {
+		detail = append(detail, "url="+tiktokURL)
+	}
+	postURL := strings.TrimSpace(tiktokURL)
+	if postURL == "" {
+		postURL = TikTokPostURL(tiktokVideoID)
+	}
+	return a.force(ctx, id, domain.AuditActionForceComplete, domain.VideoStatusCompleted, "", tiktokVideoID, postURL, actor, strings.Join(detail, " "))
+}
+
+// ForceFail marks a video failed with the reason as its error message
+func (a *VideoAdmin) ForceFail(ctx context.Context, id, reason, actor string) (*domain.Video, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidForce)
+	}
+	return a.force(ctx, id, domain.AuditActionForceFail, domain.VideoStatusFailed, "forced: "+reason, "", "", actor, reason)
+}
+
+// Approve overrides the content-safety check for a video held for review or denied: the video goes
+// back to pending and is published without being checked again
+func (a *VideoAdmin) Approve(ctx context.Context, id, actor string) (*domain.Video, error) {
+	video, err := a.videoRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video: %w", err)
+	}
+	if video == nil {
+		return nil, fmt.Errorf("%w: %s", ErrVideoNotFound, id)
+	}
+	held := video.Status == domain.VideoStatusAwaitingReview ||
+		(video.Status == domain.VideoStatusSkipped && video.SafetyDecision == domain.SafetyDecisionDeny)
+	if !held {
+		return nil, fmt.Errorf("%w: video %s is %s", ErrNotAwaitingApproval, id, video.Status)
+	}
+
+	entry := &domain.AuditEntry{
+		Action:         domain.AuditActionApprove,
+		VideoID:        video.ID,
+		AccountID:      video.AccountID,
+		Actor:          actor,
+		PreviousStatus: video.Status,
+		Detail:         video.SafetyRule,
+	}
+	err = a.withTx(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		if err := repos.Videos.UpdateSafetyDecision(ctx, video.ID, domain.SafetyDecisionApproved, "approved by "+actor); err != nil {
+			return fmt.Errorf("failed to record approval: %w", err)
+		}
+		if err := repos.Videos.ForceStatus(ctx, video.ID, domain.VideoStatusPending, "", ""); err != nil {
+			return fmt.Errorf("failed to requeue video: %w", err)
+		}
+		if err := repos.Audit.Save(ctx, entry); err != nil {
+			return fmt.Errorf("failed to write audit entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info().Printf("[AUDIT] %s video %s (%s -> %s) by %s: %s", entry.Action, video.YouTubeVideoID, video.Status, domain.VideoStatusPending, actor, video.SafetyRule)
+	if video.ParentVideoID != "" {
+		a.processor.refreshClipParent(ctx, video.ParentVideoID)
+	}
+
+	return a.videoRepo.GetByID(ctx, id)
+}
+
+// force stops any worker on the video, then writes the new status (with the TikTok post URL, when
+// given) and the audit entry while holding the video, so the worker's last write cannot land
+// after the forced one
+func (a *VideoAdmin) force(ctx context.Context, id, action string, status domain.VideoStatus, message, tiktokID, postURL, actor, detail string) (*domain.Video, error) {
+	video, err := a.videoRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video: %w", err)
+	}
+	if video == nil {
+		return nil, fmt.Errorf("%w: %s", ErrVideoNotFound, id)
+	}
+	if video.ClipCount > 0 {
+		return nil, fmt.Errorf("%w: video %s was split into clips; force the clips instead", ErrForceNotAllowed, id)
+	}
+
+	release, err := a.processor.TakeOver(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stop processing of video %s: %w", id, err)
+	}
+	defer release()
+
+	// The worker may have changed the video while it was being stopped
+	video, err = a.videoRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video: %w", err)
+	}
+	if video == nil {
+		return nil, fmt.Errorf("%w: %s", ErrVideoNotFound, id)
+	}
+
+	entry := &domain.AuditEntry{
+		Action:         action,
+		VideoID:        video.ID,
+		AccountID:      video.AccountID,
+		Actor:          actor,
+		PreviousStatus: video.Status,
+		Detail:         detail,
+	}
+	err = a.withTx(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		if err := repos.Videos.ForceStatus(ctx, video.ID, status, message, tiktokID); err != nil {
+			return fmt.Errorf("failed to force status: %w", err)
+		}
+		if postURL != "" {
+			if err := repos.Videos.UpdateTikTokPostURL(ctx, video.ID, postURL); err != nil {
+				return fmt.Errorf("failed to record TikTok post URL: %w", err)
+			}
+		}
+		if err := repos.Audit.Save(ctx, entry); err != nil {
+			return fmt.Errorf("failed to write audit entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info().Printf("[AUDIT] %s video %s (%s -> %s) by %s: %s", action, video.YouTubeVideoID, video.Status, status, actor, detail)
+	if video.ParentVideoID != "" {
+		a.processor.refreshClipParent(ctx, video.ParentVideoID)
+	}
+
+	return a.videoRepo.GetByID(ctx, id)
+}
+
+// AuditTrail returns the audit entries of a video, newest first
+func (a *VideoAdmin) AuditTrail(ctx context.Context, id string) ([]*domain.AuditEntry, error) {
+	return a.auditRepo.ListByVideo(ctx, id)
+}
+
+// tiktokVideoIDFromURL reads the video ID from a TikTok video URL such as
+// https://www.tiktok.com/@name/video/7234567890123456789
+func tiktokVideoIDFromURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || !strings.HasSuffix(strings.ToLower(u.Hostname()), "tiktok.com") {
+		return "", fmt.Errorf("%w: %q is not a TikTok URL", ErrInvalidForce, raw)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "video" && segments[i+1] != "" {
+			return segments[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q does not name a TikTok video", ErrInvalidForce, raw)
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/memory"
+)
+
+// slowPublishAPI accepts the token of acc-1 and answers publish status requests with an upload
+// still processing, after holding them for a while. entered is signalled when a worker is inside
+// one. The worker then keeps the video pending: its last write, which a force must not lose to.
+func slowPublishAPI(t *testing.T, entered chan<- struct{}, checks *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/user/info/" {
+			w.Write([]byte(`{"data":{"user":{"open_id":"open-acc-1"}},"error":{"code":"ok"}}`))
+			return
+		}
+		if r.URL.Path != testStatusPath {
+			t.Errorf("unexpected TikTok request %s %s", r.Method, r.URL.Path)
+			http.Error(w, "unexpected", http.StatusInternalServerError)
+			return
+		}
+		checks.Add(1)
+		entered <- struct{}{}
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{"data":{"status":"PROCESSING_UPLOAD"},"error":{"code":"ok"}}`))
+	})
+}
+
+func TestForceWhileWorkerHoldsVideo(t *testing.T) {
+	tests := []struct {
+		name       string
+		force      func(admin *VideoAdmin) (*domain.Video, error)
+		wantStatus domain.VideoStatus
+		wantAction string
+		wantTikTok string
+		wantError  string
+	}{
+		{
+			name: "force-complete",
+			force: func(admin *VideoAdmin) (*domain.Video, error) {
+				return admin.ForceComplete(context.Background(), "vid-1", "", "https://www.tiktok.com/@me/video/7311111111111111111", "admin")
+			},
+			wantStatus: domain.VideoStatusCompleted,
+			wantAction: domain.AuditActionForceComplete,
+			wantTikTok: "7311111111111111111",
+		},
+		{
+			name: "force-fail",
+			force: func(admin *VideoAdmin) (*domain.Video, error) {
+				return admin.ForceFail(context.Background(), "vid-1", "uploaded by hand", "admin")
+			},
+			wantStatus: domain.VideoStatusFailed,
+			wantAction: domain.AuditActionForceFail,
+			wantError:  "forced: uploaded by hand",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entered := make(chan struct{}, 4)
+			var checks atomic.Int32
+			tp := newTestProcessor(t, slowPublishAPI(t, entered, &checks), func(cfg *config.Config) {
+				cfg.TikTokPublishStatusPath = testStatusPath
+			})
+			saveImmediateVideo(t, tp)
+			audit := memory.NewAuditRepository()
+			admin := NewVideoAdmin(tp.videos, audit, tp.VideoProcessor)
+
+			workerDone := make(chan error, 1)
+			go func() {
+				video, _ := tp.videos.GetByID(context.Background(), "vid-1")
+				workerDone <- tp.ProcessVideo(context.Background(), video)
+			}()
+			select {
+			case <-entered:
+			case <-time.After(5 * time.Second):
+				t.Fatal("worker never reached TikTok")
+			}
+
+			forced, err := tt.force(admin)
+			if err != nil {
+				t.Fatalf("force error = %v", err)
+			}
+			// TakeOver waited for the worker, so it has let go of the video already
+			select {
+			case err := <-workerDone:
+				if err != nil {
+					t.Errorf("ProcessVideo() error = %v", err)
+				}
+			default:
+				t.Fatal("force returned while the worker still held the video")
+			}
+
+			stored, _ := tp.videos.GetByID(context.Background(), "vid-1")
+			for _, video := range []*domain.Video{forced, stored} {
+				if video.Status != tt.wantStatus || video.TikTokVideoID != tt.wantTikTok || video.ErrorMessage != tt.wantError {
+					t.Errorf("video = %s %q %q, want %s %q %q", video.Status, video.TikTokVideoID, video.ErrorMessage,
+						tt.wantStatus, tt.wantTikTok, tt.wantError)
+				}
+			}
+
+			trail, err := admin.AuditTrail(context.Background(), "vid-1")
+			if err != nil || len(trail) != 1 {
+				t.Fatalf("AuditTrail() = %v, %v; want one entry", trail, err)
+			}
+			if trail[0].Action != tt.wantAction || trail[0].Actor != "admin" || trail[0].PreviousStatus == tt.wantStatus {
+				t.Errorf("audit entry = %+v", trail[0])
+			}
+
+			// The claim is gone and the forced video is not picked up again
+			tp.claimMu.Lock()
+			claims := len(tp.claimed)
+			tp.claimMu.Unlock()
+			if claims != 0 {
+				t.Errorf("%d claims left after the force", claims)
+			}
+			if err := tp.ProcessVideo(context.Background(), stored); err != nil {
+				t.Errorf("ProcessVideo() after the force error = %v", err)
+			}
+			if got := checks.Load(); got != 1 {
+				t.Errorf("TikTok status checked %d times, want only the interrupted run", got)
+			}
+		})
+	}
+}
+
+func TestForceRejections(t *testing.T) {
+	tp := newTestProcessor(t, nil, nil)
+	tp.saveAccount(t, &domain.Account{ID: "acc-1"})
+	tp.saveVideo(t, &domain.Video{ID: "source", AccountID: "acc-1", ClipCount: 2})
+	tp.saveVideo(t, &domain.Video{ID: "vid-1", AccountID: "acc-1"})
+	admin := NewVideoAdmin(tp.videos, memory.NewAuditRepository(), tp.VideoProcessor)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		videoID   string
+		reason    string // ForceFail with this reason when set, ForceComplete with tiktokURL otherwise
+		tiktokURL string
+		wantErr   error
+	}{
+		{name: "unknown video", videoID: "missing", reason: "gone", wantErr: ErrVideoNotFound},
+		{name: "split source", videoID: "source", tiktokURL: "https://www.tiktok.com/@me/video/7311111111111111111", wantErr: ErrForceNotAllowed},
+		{name: "fail without reason", videoID: "vid-1", reason: "  ", wantErr: ErrInvalidForce},
+		{name: "not a TikTok URL", videoID: "vid-1", tiktokURL: "https://example.com/video/1", wantErr: ErrInvalidForce},
+		{name: "URL without a video", videoID: "vid-1", tiktokURL: "https://www.tiktok.com/@me", wantErr: ErrInvalidForce},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.reason != "" {
+				_, err = admin.ForceFail(ctx, tt.videoID, tt.reason, "admin")
+			} else {
+				_, err = admin.ForceComplete(ctx, tt.videoID, "", tt.tiktokURL, "admin")
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	stored, _ := tp.videos.GetByID(ctx, "vid-1")
+	if stored.Status != domain.VideoStatusPending {
+		t.Errorf("rejected forces changed the video to %s", stored.Status)
+	}
+}
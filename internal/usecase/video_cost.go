@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// addVideoCost adds to a video's cost counters once the work is done. The write outlives ctx, and a
+// failed write only loses the record. video.Cost is left as read; the repository holds the sums.
+func addVideoCost(ctx context.Context, repo domain.VideoRepository, video *domain.Video, cost domain.VideoCost) {
+	if cost == (domain.VideoCost{}) {
+		return
+	}
+	if err := repo.AddCost(context.WithoutCancel(ctx), video.ID, cost); err != nil {
+		logger.Error().Printf("Failed to record cost of video %s: %v", video.YouTubeVideoID, err)
+	}
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/memory"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+)
+
+// TestAccountUsageMatchesVideoCosts adds costs in small steps, as the pipeline does, and checks
+// the monthly rollup against the sums of the videos' own counters
+func TestAccountUsageMatchesVideoCosts(t *testing.T) {
+	month := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	nextMonth := month.AddDate(0, 1, 0)
+
+	type testVideo struct {
+		video   *domain.Video
+		inMonth bool // counted in acc-1's January usage
+	}
+	videos := []testVideo{
+		{&domain.Video{ID: "done", AccountID: "acc-1", Status: domain.VideoStatusCompleted, CreatedAt: month.Add(time.Hour)}, true},
+		{&domain.Video{ID: "failed", AccountID: "acc-1", Status: domain.VideoStatusFailed, CreatedAt: month.Add(48 * time.Hour)}, true},
+		{&domain.Video{ID: "last-moment", AccountID: "acc-1", Status: domain.VideoStatusCompleted, CreatedAt: nextMonth.Add(-time.Millisecond)}, true},
+		{&domain.Video{ID: "source", AccountID: "acc-1", Status: domain.VideoStatusCompleted, ClipCount: 2, CreatedAt: month.Add(72 * time.Hour)}, true},
+		{&domain.Video{ID: "clip-1", AccountID: "acc-1", Status: domain.VideoStatusCompleted, ParentVideoID: "source", CreatedAt: month.Add(73 * time.Hour)}, true},
+		{&domain.Video{ID: "clip-2", AccountID: "acc-1", Status: domain.VideoStatusPending, ParentVideoID: "source", CreatedAt: month.Add(73 * time.Hour)}, true},
+		{&domain.Video{ID: "december", AccountID: "acc-1", Status: domain.VideoStatusCompleted, CreatedAt: month.Add(-time.Millisecond)}, false},
+		{&domain.Video{ID: "february", AccountID: "acc-1", Status: domain.VideoStatusCompleted, CreatedAt: nextMonth}, false},
+		{&domain.Video{ID: "other-account", AccountID: "acc-2", Status: domain.VideoStatusCompleted, CreatedAt: month.Add(time.Hour)}, false},
+	}
+
+	backends := map[string]func(t *testing.T) (domain.VideoRepository, domain.AccountRepository){
+		"memory": func(t *testing.T) (domain.VideoRepository, domain.AccountRepository) {
+			return memory.NewVideoRepository(), memory.NewAccountRepository()
+		},
+		"sqlite": func(t *testing.T) (domain.VideoRepository, domain.AccountRepository) {
+			repos := sqlitetest.OpenTest(t)
+			return repos.Videos, repos.Accounts
+		},
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo, accounts := open(t)
+			for _, id := range []string{"acc-1", "acc-2"} {
+				account := &domain.Account{ID: id, YouTubeChannelID: "UC-" + id, TikTokAccountID: "tt-" + id}
+				if err := accounts.Save(ctx, account); err != nil {
+					t.Fatalf("save account: %v", err)
+				}
+			}
+
+			for i, tv := range videos {
+				video := *tv.video
+				video.YouTubeVideoID = "yt-" + video.ID
+				if err := repo.Save(ctx, &video); err != nil {
+					t.Fatalf("save video %s: %v", video.ID, err)
+				}
+				// Costs arrive piecemeal: a lookup, two download attempts, the upload, the run
+				steps := []domain.VideoCost{
+					{APIUnits: 1},
+					{DownloadBytes: int64(1000 * (i + 1))},
+					{DownloadBytes: int64(1000 * (i + 1)), Retries: 1},
+					{UploadBytes: int64(700 * (i + 1))},
+					{ProcessingTime: time.Duration(i+1) * 1500 * time.Millisecond, APIUnits: 50},
+				}
+				for _, cost := range steps[:1+i%len(steps)] {
+					addVideoCost(ctx, repo, &video, cost)
+				}
+				// Saving the video again must not reset its counters
+				if err := repo.Save(ctx, &video); err != nil {
+					t.Fatalf("save video %s again: %v", video.ID, err)
+				}
+			}
+
+			var want domain.AccountUsage
+			for _, tv := range videos {
+				if !tv.inMonth {
+					continue
+				}
+				stored, err := repo.GetByID(ctx, tv.video.ID)
+				if err != nil || stored == nil {
+					t.Fatalf("GetByID(%s) = %v, %v", tv.video.ID, stored, err)
+				}
+				if stored.Cost == (domain.VideoCost{}) {
+					t.Errorf("video %s has no cost recorded", stored.ID)
+				}
+				want.Cost = want.Cost.Add(stored.Cost)
+				if stored.ClipCount == 0 {
+					want.Videos++
+					if stored.Status == domain.VideoStatusCompleted {
+						want.Completed++
+					}
+				}
+			}
+
+			got, err := repo.GetAccountUsage(ctx, "acc-1", month, nextMonth)
+			if err != nil {
+				t.Fatalf("GetAccountUsage() error = %v", err)
+			}
+			if *got != want {
+				t.Errorf("GetAccountUsage() = %+v, want the per-video sums %+v", *got, want)
+			}
+			// Spot check the sums themselves so a rollup and a read that both drop a field cannot agree
+			c := want.Cost
+			if want.Videos != 5 || want.Completed != 3 ||
+				c.APIUnits == 0 || c.DownloadBytes == 0 || c.UploadBytes == 0 || c.ProcessingTime == 0 || c.Retries == 0 {
+				t.Errorf("per-video sums = %+v, want 5 videos, 3 completed and every counter set", want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// enrichVideos fills in the Data API metadata (publish time, duration, definition, live status,
+// region restriction and view count) of the new videos of every scan. Each YouTube video is
+// looked up once however many accounts found it, with one videos.list call per 50 videos, so a
+// cycle costs one quota unit per 50 new videos instead of one per account. Without an API key, or while the quota
+// is exhausted, videos keep what their source gave; videos the API does not return keep it too,
+// and when a call fails the videos of the calls that succeeded are still filled in.
+func (m *AccountMonitor) enrichVideos(scans []*accountScan) {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, scan := range scans {
+		for _, video := range scan.newVideos {
+			if !seen[video.YouTubeVideoID] {
+				seen[video.YouTubeVideoID] = true
+				ids = append(ids, video.YouTubeVideoID)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	var metadata map[string]youtube.VideoMetadata
+	if m.config.YouTubeAPIKey != "" && m.QuotaPausedUntil().IsZero() {
+		var err error
+		metadata, err = m.youtubeService.GetVideoMetadata(ids)
+		switch {
+		case err != nil:
+			if errors.Is(err, youtube.ErrQuotaExceeded) {
+				m.pauseForQuota(err)
+			}
+			logger.Error().Printf("failed to look up metadata of new videos (%d of %d found before the error): %v",
+				len(metadata), len(ids), err)
+		case len(metadata) < len(ids):
+			logger.Info().Printf("YouTube returned metadata for %d of %d new videos", len(metadata), len(ids))
+			m.rememberMissingPublishedAt(ids, metadata)
+		}
+	}
+
+	for _, scan := range scans {
+		for _, video := range scan.newVideos {
+			if meta, ok := metadata[video.YouTubeVideoID]; ok {
+				applyVideoMetadata(video, meta)
+			}
+			if video.PublishedAt.IsZero() {
+				logger.Info().Printf("Published date of video %s (channel %s) is unknown; its discovery time is used for ordering",
+					video.YouTubeVideoID, scan.account.YouTubeChannelID)
+			}
+		}
+	}
+}
+
+// applyVideoMetadata copies looked-up metadata onto a discovered video, keeping the source's
+// publish time when it had one
+func applyVideoMetadata(video *domain.Video, meta youtube.VideoMetadata) {
+	if video.PublishedAt.IsZero() {
+		video.PublishedAt = meta.PublishedAt
+	}
+	if meta.Duration > 0 {
+		video.Duration = meta.Duration
+	}
+	video.Definition = meta.Definition
+	video.LiveBroadcastContent = meta.LiveBroadcastContent
+	video.RegionAllowed = meta.RegionAllowed
+	video.RegionBlocked = meta.RegionBlocked
+	video.ViewCount = meta.ViewCount
+	video.ViewsCheckedAt = time.Now()
+}
+
+// rememberMissingPublishedAt keeps the backfill from asking again for videos the API did not return
+func (m *AccountMonitor) rememberMissingPublishedAt(ids []string, metadata map[string]youtube.VideoMetadata) {
+	m.publishedAtMu.Lock()
+	defer m.publishedAtMu.Unlock()
+	for _, id := range ids {
+		if _, ok := metadata[id]; !ok {
+			m.publishedAtMissing[id] = true
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// shortsMaxDuration is the longest video still treated as a YouTube Short
+const shortsMaxDuration = 60 * time.Second
+
+// filterReason evaluates the account filters and returns why the video should be
+// skipped, or an empty string when it should be mirrored.
+func filterReason(settings domain.AccountSettings, video *domain.Video) string {
+	title := strings.ToLower(video.Title)
+
+	for _, word := range settings.TitleBlacklist {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && strings.Contains(title, word) {
+			return fmt.Sprintf("title contains blacklisted word %q", word)
+		}
+	}
+
+	if len(settings.TitleWhitelist) > 0 {
+		matched := false
+		for _, word := range settings.TitleWhitelist {
+			word = strings.ToLower(strings.TrimSpace(word))
+			if word != "" && strings.Contains(title, word) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return "title does not contain any whitelisted word"
+		}
+	}
+
+	if settings.ShortsOnly && !isShort(video) {
+		return "not a YouTube Short"
+	}
+
+	return ""
+}
+
+// isShort reports whether a video looks like a YouTube Short, using the duration
+// when known and the #shorts marker in the title or description otherwise.
+func isShort(video *domain.Video) bool {
+	if video.Duration > 0 && video.Duration <= shortsMaxDuration {
+		return true
+	}
+	marker := "#shorts"
+	return strings.Contains(strings.ToLower(video.Title), marker) ||
+		strings.Contains(strings.ToLower(video.Description), marker)
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/hooks"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// SetHookRunner enables the hooks configured under hooks for accounts that list them in settings.hooks
+func (p *VideoProcessor) SetHookRunner(runner *hooks.Runner) {
+	p.hookRunner = runner
+}
+
+// runHooks runs the account's hooks for a phase in the order the account lists them. A hook can
+// replace the video file (before the upload) or abort, which returns an error wrapping
+// hooks.ErrAborted; after publishing, results are only logged.
+func (p *VideoProcessor) runHooks(ctx context.Context, phase string, video *domain.Video) error {
+	if p.hookRunner == nil || len(p.config.Hooks) == 0 {
+		return nil
+	}
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account for hooks: %w", err)
+	}
+	if account == nil || len(account.Settings.Hooks) == 0 {
+		return nil
+	}
+
+	canChange := phase != config.HookPhasePostPublish
+	for _, name := range account.Settings.Hooks {
+		hook, ok := p.config.HookByName(name)
+		if !ok {
+			logger.Error().Printf("Warning: account %s enables unknown hook %q, skipping", account.ID, name)
+			continue
+		}
+		if hook.Phase != phase {
+			continue
+		}
+
+		result, err := p.hookRunner.Run(ctx, hook, hookPayload(phase, name, account, video))
+		if err != nil {
+			if hook.AbortOnFailure && canChange {
+				return fmt.Errorf("%w %s: %v", hooks.ErrAborted, name, err)
+			}
+			logger.Error().Printf("Hook %s failed for video %s, continuing: %v", name, video.YouTubeVideoID, err)
+			continue
+		}
+
+		if result.Abort {
+			if !canChange {
+				logger.Error().Printf("Hook %s asked to abort video %s after it was published; ignoring", name, video.YouTubeVideoID)
+				continue
+			}
+			reason := result.Reason
+			if reason == "" {
+				reason = "no reason given"
+			}
+			return fmt.Errorf("%w %s: %s", hooks.ErrAborted, name, reason)
+		}
+
+		if result.FilePath != "" && result.FilePath != video.LocalFilePath && canChange {
+			if err := p.replaceVideoFile(ctx, video, result.FilePath); err != nil {
+				return fmt.Errorf("%w %s: %v", hooks.ErrAborted, name, err)
+			}
+			logger.Info().Printf("Hook %s replaced the file of video %s with %s", name, video.YouTubeVideoID, result.FilePath)
+		}
+	}
+	return nil
+}
+
+// replaceVideoFile points the video at a file produced by a hook
+func (p *VideoProcessor) replaceVideoFile(ctx context.Context, video *domain.Video, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("replacement file: %w", err)
+	}
+	if !info.Mode().IsRegular() || info.Size() == 0 {
+		return fmt.Errorf("replacement file %s is not a non-empty regular file", path)
+	}
+	if err := p.videoRepo.UpdateFilePath(ctx, video.ID, path); err != nil {
+		return fmt.Errorf("failed to store replacement file: %w", err)
+	}
+	video.LocalFilePath = path
+	return nil
+}
+
+func hookPayload(phase, name string, account *domain.Account, video *domain.Video) *hooks.Payload {
+	return &hooks.Payload{
+		Phase: phase,
+		Hook:  name,
+		Account: hooks.AccountInfo{
+			ID:               account.ID,
+			YouTubeChannelID: account.YouTubeChannelID,
+			TikTokAccountID:  account.TikTokAccountID,
+		},
+		Video: hooks.VideoPayload{
+			ID:             video.ID,
+			YouTubeVideoID: video.YouTubeVideoID,
+			Title:          video.Title,
+			Description:    video.Description,
+			PublishedAt:    video.PublishedAt,
+			FilePath:       video.LocalFilePath,
+			TikTokVideoID:  video.TikTokVideoID,
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/hooks"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+)
+
+func TestRunHooks(t *testing.T) {
+	replacement := filepath.Join(t.TempDir(), "v1.watermarked.mp4")
+	if err := os.WriteFile(replacement, []byte("watermarked"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name           string
+		phase          string // phase the hook is configured for and run in
+		status         int
+		response       string
+		abortOnFailure bool
+		notEnabled     bool // the account does not list the hook
+		wantCalls      int32
+		wantAborted    bool
+		wantErrText    string
+		wantFile       string
+	}{
+		{name: "replacement file", phase: config.HookPhasePostDownload, status: http.StatusOK,
+			response: `{"file_path":"` + replacement + `"}`, wantCalls: 1, wantFile: replacement},
+		{name: "abort", phase: config.HookPhasePreUpload, status: http.StatusOK,
+			response: `{"abort":true,"reason":"watermark missing"}`, wantCalls: 1, wantAborted: true, wantErrText: "watermark missing"},
+		{name: "missing replacement aborts", phase: config.HookPhasePreUpload, status: http.StatusOK,
+			response: `{"file_path":"/nonexistent/v1.mp4"}`, wantCalls: 1, wantAborted: true, wantErrText: "replacement file"},
+		{name: "failure aborts when configured", phase: config.HookPhasePreUpload, status: http.StatusInternalServerError,
+			response: "logo.png missing", abortOnFailure: true, wantCalls: 1, wantAborted: true, wantErrText: "logo.png missing"},
+		{name: "failure continues by default", phase: config.HookPhasePreUpload, status: http.StatusInternalServerError,
+			response: "logo.png missing", wantCalls: 1},
+		{name: "post-publish results are ignored", phase: config.HookPhasePostPublish, status: http.StatusOK,
+			response: `{"abort":true,"file_path":"` + replacement + `"}`, wantCalls: 1},
+		{name: "not enabled for the account", phase: config.HookPhasePreUpload, status: http.StatusOK,
+			response: `{"abort":true}`, notEnabled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			hookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			t.Cleanup(hookServer.Close)
+
+			tp := newTestProcessor(t, nil, func(cfg *config.Config) {
+				cfg.Hooks = []config.Hook{{Name: "watermark", Phase: tt.phase, URL: hookServer.URL, AbortOnFailure: tt.abortOnFailure}}
+			})
+			tp.SetHookRunner(hooks.NewRunner(httpclient.NewHTTPClient(tp.cfg)))
+			account := &domain.Account{ID: "acc-1"}
+			if !tt.notEnabled {
+				account.Settings.Hooks = []string{"watermark", "unknown"}
+			}
+			tp.saveAccount(t, account)
+			video := tp.saveVideo(t, &domain.Video{ID: "v1", AccountID: "acc-1", LocalFilePath: "/downloads/v1.mp4"})
+
+			err := tp.runHooks(context.Background(), tt.phase, video)
+			if got := errors.Is(err, hooks.ErrAborted); got != tt.wantAborted {
+				t.Fatalf("runHooks() error = %v, aborted = %v, want %v", err, got, tt.wantAborted)
+			}
+			if tt.wantErrText != "" && !strings.Contains(err.Error(), tt.wantErrText) {
+				t.Errorf("runHooks() error = %v, want it to mention %q", err, tt.wantErrText)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("hook called %d times, want %d", got, tt.wantCalls)
+			}
+
+			wantFile := tt.wantFile
+			if wantFile == "" {
+				wantFile = "/downloads/v1.mp4"
+			}
+			stored, _ := tp.videos.GetByID(context.Background(), "v1")
+			if video.LocalFilePath != wantFile || stored.LocalFilePath != wantFile {
+				t.Errorf("file = %s, stored %s; want %s", video.LocalFilePath, stored.LocalFilePath, wantFile)
+			}
+		})
+	}
+}
+
+func TestRunHooksOtherPhase(t *testing.T) {
+	var calls atomic.Int32
+	hookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	t.Cleanup(hookServer.Close)
+	tp := newTestProcessor(t, nil, func(cfg *config.Config) {
+		cfg.Hooks = []config.Hook{{Name: "notify", Phase: config.HookPhasePostPublish, URL: hookServer.URL}}
+	})
+	tp.SetHookRunner(hooks.NewRunner(httpclient.NewHTTPClient(tp.cfg)))
+	tp.saveAccount(t, &domain.Account{ID: "acc-1", Settings: domain.AccountSettings{Hooks: []string{"notify"}}})
+	video := tp.saveVideo(t, &domain.Video{ID: "v1", AccountID: "acc-1"})
+
+	for _, phase := range []string{config.HookPhasePostDownload, config.HookPhasePreUpload} {
+		if err := tp.runHooks(context.Background(), phase, video); err != nil {
+			t.Errorf("runHooks(%s) error = %v", phase, err)
+		}
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("post_publish hook called %d times before publishing", got)
+	}
+}
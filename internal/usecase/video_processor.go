@@ -4,21 +4,54 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/url"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"auto_upload_tiktok/config"
 	"auto_upload_tiktok/internal/domain"
 	"auto_upload_tiktok/internal/infrastructure/downloader"
+	"auto_upload_tiktok/internal/infrastructure/hooks"
 	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
 	"auto_upload_tiktok/internal/infrastructure/youtube"
 	"auto_upload_tiktok/internal/logger"
 )
 
+// ErrShuttingDown is returned when new work is refused because shutdown has begun
+var ErrShuttingDown = errors.New("video processor is shutting down")
+
+// errUploadDeferred means the account's upload cap or spacing kept the video pending for a later cycle
+var errUploadDeferred = errors.New("upload deferred by account limits")
+
+// errDownloadDeferred means the video stayed pending because the download disk is full
+var errDownloadDeferred = errors.New("download deferred until disk space is available")
+
+// errTransferDeferred means the video stayed pending because a daily data cap was reached
+var errTransferDeferred = errors.New("transfer deferred until the daily data cap resets")
+
+// errInterrupted means the video was put back to pending because the run's context ended mid-step
+var errInterrupted = errors.New("processing interrupted")
+
+// maxVideosPerRun bounds how many videos one ProcessPendingVideos call attempts; the rest wait
+// for the next run
+const maxVideosPerRun = 500
+
+// pendingClaimLease is how long this process's claim on pending videos keeps other instances
+// sharing the database away from them when it stops without releasing them
+const pendingClaimLease = 10 * time.Minute
+
+// isDeferral reports whether err left the video pending for a later cycle rather than failing it
+func isDeferral(err error) bool {
+	return errors.Is(err, errUploadDeferred) || errors.Is(err, errDownloadDeferred) || errors.Is(err, errTransferDeferred) ||
+		errors.Is(err, errPublishPending) || errors.Is(err, errInterrupted) || errors.Is(err, errPipelinePaused) ||
+		errors.Is(err, errOutsideUploadWindow)
+}
+
 // VideoProcessor handles video processing workflow with optimized I/O parallelism
 type VideoProcessor struct {
 	config          *config.Config
@@ -30,6 +63,45 @@ type VideoProcessor struct {
 	workerPool      chan struct{} // General worker pool
 	downloadSem     chan struct{} // Semaphore for download operations
 	uploadSem       chan struct{} // Semaphore for upload operations
+
+	accountDownloads *accountLimiter // Downloads per account, taken before downloadSem
+	accountUploads   *accountLimiter // Uploads per TikTok account, taken before uploadSem
+
+	transferMeter *TransferMeter    // Optional: daily byte accounting and data caps
+	translator    domain.Translator // Optional: caption translation
+	hookRunner    *hooks.Runner     // Optional: custom processing steps
+	contentSafety *ContentSafety    // Optional: denylist and moderation before publishing
+
+	reauthAlerter  *ReauthAlerter  // Optional: notifies when an account needs re-authorization
+	failureTracker *FailureTracker // Optional: deactivates accounts that keep failing
+	pipeline       *PipelineSwitch // Optional: global pause of downloads and uploads
+
+	webSessions   *WebSessionManager // Optional: claims the web upload cookies for this host
+	accountEvents *AccountEventLog   // Optional: records refreshed tokens in the account's history
+
+	commentMu     sync.Mutex
+	lastCommentAt map[string]time.Time // Last scheduled comment per TikTok account
+
+	uploadMu        sync.Mutex
+	uploadsInFlight map[string]int // Reserved upload slots per account
+
+	healthMu sync.Mutex // Serializes upload health read-modify-write
+
+	tokenMu     sync.Mutex
+	tokenChecks map[string]tokenCheck // Verified access token owner per account
+
+	clipMu          sync.Mutex
+	clipSourceLocks map[string]*sync.Mutex // Serializes source downloads per split video
+
+	claimMu sync.Mutex
+	claimed map[string]*videoClaim // Videos a worker is processing, whichever entry point started it
+	owner   string                 // Names this process in the database's claims on pending videos
+
+	// In-flight tracking for graceful shutdown
+	drainMu  sync.Mutex
+	draining bool
+	active   int
+	inflight sync.WaitGroup
 }
 
 // NewVideoProcessor creates a new video processor with optimized I/O parallelism
@@ -48,20 +120,69 @@ func NewVideoProcessor(
 	uploadSem := make(chan struct{}, cfg.MaxConcurrentUploads)
 
 	return &VideoProcessor{
-		config:          cfg,
-		videoRepo:       videoRepo,
-		accountRepo:     accountRepo,
-		youtubeService:  youtubeService,
-		downloadService: downloadService,
-		tiktokService:   tiktokService,
-		workerPool:      workerPool,
-		downloadSem:     downloadSem,
-		uploadSem:       uploadSem,
+		config:           cfg,
+		videoRepo:        videoRepo,
+		accountRepo:      accountRepo,
+		youtubeService:   youtubeService,
+		downloadService:  downloadService,
+		tiktokService:    tiktokService,
+		workerPool:       workerPool,
+		downloadSem:      downloadSem,
+		uploadSem:        uploadSem,
+		accountDownloads: newAccountLimiter(cfg.MaxDownloadsPerAccount),
+		accountUploads:   newAccountLimiter(cfg.MaxUploadsPerAccount),
+		lastCommentAt:    make(map[string]time.Time),
+		uploadsInFlight:  make(map[string]int),
+		clipSourceLocks:  make(map[string]*sync.Mutex),
+		tokenChecks:      make(map[string]tokenCheck),
+		claimed:          make(map[string]*videoClaim),
+		owner:            pendingClaimOwner(cfg),
 	}
 }
 
+// pendingClaimOwner names this process in claims on pending videos: the host, as in cookies
+// claims, and the process ID, so two processes on one host do not share claims
+func pendingClaimOwner(cfg *config.Config) string {
+	return fmt.Sprintf("%s:%d", CookiesClaimHost(cfg), os.Getpid())
+}
+
+// SetTransferMeter enables metering of downloaded and uploaded bytes and the daily data caps
+func (p *VideoProcessor) SetTransferMeter(meter *TransferMeter) {
+	p.transferMeter = meter
+}
+
+// SetReauthAlerter enables notifications when an account has to be authorized again
+func (p *VideoProcessor) SetReauthAlerter(alerter *ReauthAlerter) {
+	p.reauthAlerter = alerter
+}
+
+// SetFailureTracker counts failed videos towards the account's failure streak
+func (p *VideoProcessor) SetFailureTracker(tracker *FailureTracker) {
+	p.failureTracker = tracker
+}
+
+// SetPipelineSwitch makes downloads and uploads stop while the pipeline is paused
+func (p *VideoProcessor) SetPipelineSwitch(pipeline *PipelineSwitch) {
+	p.pipeline = pipeline
+}
+
+// SetAccountEventLog records automatic token refreshes as token_updated events
+func (p *VideoProcessor) SetAccountEventLog(events *AccountEventLog) {
+	p.accountEvents = events
+}
+
+// SetWebSessionManager makes web uploads claim the cookies for this host first, to notice
+// another host using them at the same time
+func (p *VideoProcessor) SetWebSessionManager(manager *WebSessionManager) {
+	p.webSessions = manager
+}
+
 // ProcessPendingVideos processes all pending videos concurrently with optimized I/O parallelism
-// Uses separate semaphores for download and upload to maximize I/O throughput
+// Uses separate semaphores for download and upload to maximize I/O throughput.
+// Each video is attempted at most once per call and at most maxVideosPerRun are attempted, so
+// videos that keep failing before their status is written cannot make it loop. When ctx ends
+// between batches it returns what was done so far instead of failing. Batches are claimed in the
+// database, so instances sharing it never pick up the same video.
 func (p *VideoProcessor) ProcessPendingVideos(ctx context.Context) error {
 	batchSize := p.config.MaxConcurrentDownloads + p.config.MaxConcurrentUploads
 	if batchSize <= 0 {
@@ -71,99 +192,610 @@ func (p *VideoProcessor) ProcessPendingVideos(ctx context.Context) error {
 		}
 	}
 
+	// Videos attempted in this run, whatever the outcome; they are not picked up again until the next run
+	var (
+		resultMu  sync.Mutex
+		attempted = make(map[string]bool)
+		processed int
+		skipped   int
+		failures  []error
+	)
+	stopReason := "no pending videos left"
+
+	// Videos whose upload window opened join this run
+	if _, err := p.ReleaseUploadWindows(ctx); err != nil {
+		logger.Error().Printf("Failed to release videos waiting for their upload window: %v", err)
+	}
+	// So do videos a process that died mid-step left behind, once its claim lapsed
+	if _, err := p.requeueInterrupted(ctx, ""); err != nil {
+		logger.Error().Printf("%v", err)
+	}
+
 	for {
 		if err := ctx.Err(); err != nil {
-			return err
+			stopReason = fmt.Sprintf("run ended (%v)", err)
+			break
+		}
+		if p.isDraining() {
+			stopReason = "shutting down"
+			break
+		}
+		if len(attempted) >= maxVideosPerRun {
+			stopReason = fmt.Sprintf("reached %d videos per run", maxVideosPerRun)
+			break
 		}
 
-		videos, err := p.videoRepo.GetPendingVideos(batchSize)
+		fetched, err := p.videoRepo.ClaimPending(ctx, p.owner, batchSize*p.batchLookahead()+len(attempted), pendingClaimLease)
 		if err != nil {
-			return fmt.Errorf("failed to get pending videos: %w", err)
+			if ctx.Err() != nil {
+				stopReason = fmt.Sprintf("run ended (%v)", ctx.Err())
+				break
+			}
+			return fmt.Errorf("failed to claim pending videos: %w", err)
+		}
+
+		candidates := make([]*domain.Video, 0, len(fetched))
+		for _, video := range fetched {
+			if !attempted[video.ID] {
+				candidates = append(candidates, video)
+			}
+		}
+		videos := p.fairBatch(candidates, min(batchSize, maxVideosPerRun-len(attempted)))
+
+		// Claimed videos left out of the batch go back to other instances right away, the batch
+		// once it is done
+		inBatch := make(map[string]bool, len(videos))
+		for _, video := range videos {
+			inBatch[video.ID] = true
+		}
+		for _, video := range fetched {
+			if !inBatch[video.ID] {
+				p.releaseClaim(ctx, video)
+			}
 		}
 
 		if len(videos) == 0 {
-			return nil
+			break
 		}
 
 		var wg sync.WaitGroup
-		errChan := make(chan error, len(videos))
-
 		for _, video := range videos {
+			if !p.beginWork() {
+				break
+			}
+			attempted[video.ID] = true
 			wg.Add(1)
 			go func(v *domain.Video) {
 				defer wg.Done()
+				defer p.endWork()
 
 				// Acquire general worker slot
 				p.workerPool <- struct{}{}
 				defer func() { <-p.workerPool }()
 
-				if err := p.processVideo(ctx, v); err != nil {
-					errChan <- fmt.Errorf("failed to process video %s: %w", v.ID, err)
+				ran, err := p.processClaimed(ctx, v)
+
+				resultMu.Lock()
+				defer resultMu.Unlock()
+				switch {
+				case !ran:
+					skipped++
+				case err == nil:
+					processed++
+				case isDeferral(err):
+					skipped++
+				default:
+					failures = append(failures, fmt.Errorf("failed to process video %s: %w", v.ID, err))
 				}
 			}(video)
 		}
 
 		wg.Wait()
-		close(errChan)
-
-		var errors []error
-		for err := range errChan {
-			errors = append(errors, err)
+		for _, video := range videos {
+			p.releaseClaim(ctx, video)
 		}
+	}
+
+	remaining, err := p.videoRepo.CountPending(context.WithoutCancel(ctx))
+	if err != nil {
+		logger.Error().Printf("Failed to count pending videos: %v", err)
+	}
+	logger.Info().Printf("Video processing run finished: %s; processed=%d failed=%d skipped=%d remaining=%d",
+		stopReason, processed, len(failures), skipped, remaining)
+
+	if len(failures) > 0 {
+		return fmt.Errorf("processing errors: %v", failures)
+	}
+	return nil
+}
 
-		if len(errors) > 0 {
-			return fmt.Errorf("processing errors: %v", errors)
+// releaseClaim ends this process's claim on a video. A claim that cannot be released lapses
+// after pendingClaimLease.
+func (p *VideoProcessor) releaseClaim(ctx context.Context, video *domain.Video) {
+	if err := p.videoRepo.ReleaseClaim(context.WithoutCancel(ctx), video.ID, p.owner); err != nil {
+		logger.Error().Printf("Failed to release the claim on video %s: %v", video.ID, err)
+	}
+}
+
+// batchLookahead is how many batches worth of pending videos fairBatch chooses from
+func (p *VideoProcessor) batchLookahead() int {
+	if p.config.MaxDownloadsPerAccount > 0 {
+		return 4
+	}
+	return 1
+}
+
+// fairBatch picks up to size videos in queue order, taking no more videos of one account than
+// download.max_concurrent_per_account while other accounts have videos waiting. Only when they
+// do not is the batch filled with more of the same account.
+func (p *VideoProcessor) fairBatch(candidates []*domain.Video, size int) []*domain.Video {
+	perAccount := p.config.MaxDownloadsPerAccount
+	if perAccount <= 0 || len(candidates) <= size {
+		return candidates[:min(size, len(candidates))]
+	}
+
+	batch := make([]*domain.Video, 0, size)
+	var deferred []*domain.Video
+	picked := make(map[string]int)
+	for _, video := range candidates {
+		if len(batch) == size {
+			break
+		}
+		if picked[video.AccountID] >= perAccount {
+			deferred = append(deferred, video)
+			continue
+		}
+		picked[video.AccountID]++
+		batch = append(batch, video)
+	}
+	for _, video := range deferred {
+		if len(batch) == size {
+			break
 		}
+		batch = append(batch, video)
 	}
+	return batch
 }
 
 // ProcessVideo processes a single video through the complete workflow
 // This is public so it can be called immediately after video discovery
 func (p *VideoProcessor) ProcessVideo(ctx context.Context, video *domain.Video) error {
-	return p.processVideo(ctx, video)
+	if !p.beginWork() {
+		return ErrShuttingDown
+	}
+	defer p.endWork()
+	if _, err := p.processClaimed(ctx, video); err != nil && !isDeferral(err) {
+		return err
+	}
+	return nil
+}
+
+// processClaimed processes a video unless another worker already has it or it left pending
+// since it was fetched, and reports whether it ran. Every entry point goes through here, so a
+// video is processed at most once at a time.
+func (p *VideoProcessor) processClaimed(ctx context.Context, video *domain.Video) (bool, error) {
+	ctx, ok := p.claim(ctx, video.ID)
+	if !ok {
+		return false, nil
+	}
+	defer p.unclaim(video.ID)
+
+	// The caller's copy may predate another worker finishing the video
+	current, err := p.videoRepo.GetByID(ctx, video.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get video: %w", err)
+	}
+	if current == nil || current.Status != domain.VideoStatusPending {
+		return false, nil
+	}
+
+	// The database claim keeps other processes from taking the video or requeueing it mid-step
+	held, err := p.videoRepo.ClaimVideo(ctx, current.ID, p.owner, p.workClaimLease(ctx))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim video: %w", err)
+	}
+	if !held {
+		return false, nil
+	}
+	defer p.releaseClaim(ctx, current)
+	return true, p.processVideo(ctx, current)
+}
+
+// workClaimLease is how long the claim on a video processed under ctx lasts: past ctx's deadline
+// by the shutdown grace period, so it does not lapse while the video is still being worked on
+func (p *VideoProcessor) workClaimLease(ctx context.Context) time.Duration {
+	lease := pendingClaimLease
+	if deadline, ok := ctx.Deadline(); ok {
+		lease = max(lease, time.Until(deadline)+p.config.ShutdownGrace)
+	}
+	return lease
+}
+
+// videoClaim is a worker's hold on a video: cancel stops the worker and done is closed once it
+// let go of the video
+type videoClaim struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// claim takes a video for the caller and returns the context to work on it under, which
+// TakeOver cancels; false means another worker has the video. Pair it with unclaim.
+func (p *VideoProcessor) claim(ctx context.Context, id string) (context.Context, bool) {
+	p.claimMu.Lock()
+	defer p.claimMu.Unlock()
+	if p.claimed[id] != nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p.claimed[id] = &videoClaim{cancel: cancel, done: make(chan struct{})}
+	return ctx, true
+}
+
+func (p *VideoProcessor) unclaim(id string) {
+	p.claimMu.Lock()
+	defer p.claimMu.Unlock()
+	if c := p.claimed[id]; c != nil {
+		c.cancel()
+		close(c.done)
+		delete(p.claimed, id)
+	}
+}
+
+// TakeOver stops the worker processing the video, if any, waits until it has written its last
+// status and released its upload slot and semaphores, and then holds the video so no worker
+// starts it. Call release once the video's new state is written.
+func (p *VideoProcessor) TakeOver(ctx context.Context, id string) (release func(), err error) {
+	for {
+		p.claimMu.Lock()
+		current := p.claimed[id]
+		if current == nil {
+			held := &videoClaim{cancel: func() {}, done: make(chan struct{})}
+			p.claimed[id] = held
+			p.claimMu.Unlock()
+			return func() { p.unclaim(id) }, nil
+		}
+		p.claimMu.Unlock()
+
+		logger.Info().Printf("Stopping the worker processing video %s", id)
+		current.cancel()
+		select {
+		case <-current.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// RequeueInterrupted puts videos a previous process left mid-download or mid-upload back in the
+// queue; call it when the service starts, before any processing. Besides videos whose claim
+// lapsed it takes back those an earlier process on this host still claims, so it must not run
+// while another process on this host processes videos. Videos other hosts are working on are
+// left alone. Uploads that may already have reached TikTok are checked through the
+// duplicate-upload guard on their next attempt instead of being repeated.
+func (p *VideoProcessor) RequeueInterrupted(ctx context.Context) (int, error) {
+	return p.requeueInterrupted(ctx, CookiesClaimHost(p.config))
+}
+
+// requeueInterrupted puts videos an ended process left mid-step back in the queue. With no host
+// only videos whose claim lapsed are moved, which is safe while other processes are running.
+func (p *VideoProcessor) requeueInterrupted(ctx context.Context, host string) (int, error) {
+	n, err := p.videoRepo.RequeueInterrupted(ctx, host, fmt.Sprintf("%v: the previous run stopped before this video finished", errInterrupted))
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue interrupted videos: %w", err)
+	}
+	if n > 0 {
+		logger.Info().Printf("Requeued %d videos interrupted by an earlier run", n)
+	}
+	return n, nil
+}
+
+// BeginShutdown stops accepting new work; videos already in flight keep running.
+// It returns the number of videos in flight at that moment.
+func (p *VideoProcessor) BeginShutdown() int {
+	p.drainMu.Lock()
+	defer p.drainMu.Unlock()
+	p.draining = true
+	return p.active
+}
+
+// WaitForIdle blocks until all in-flight videos finish or ctx is done
+func (p *VideoProcessor) WaitForIdle(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// InFlight returns the number of videos currently being processed
+func (p *VideoProcessor) InFlight() int {
+	p.drainMu.Lock()
+	defer p.drainMu.Unlock()
+	return p.active
+}
+
+// beginWork registers a unit of in-flight work; it fails once shutdown has begun
+func (p *VideoProcessor) beginWork() bool {
+	p.drainMu.Lock()
+	defer p.drainMu.Unlock()
+	if p.draining {
+		return false
+	}
+	p.active++
+	p.inflight.Add(1)
+	return true
+}
+
+// endWork marks a unit of in-flight work as finished
+func (p *VideoProcessor) endWork() {
+	p.drainMu.Lock()
+	p.active--
+	p.drainMu.Unlock()
+	p.inflight.Done()
+}
+
+func (p *VideoProcessor) isDraining() bool {
+	p.drainMu.Lock()
+	defer p.drainMu.Unlock()
+	return p.draining
 }
 
 // processVideo processes a single video through the complete workflow
 func (p *VideoProcessor) processVideo(ctx context.Context, video *domain.Video) error {
+	// Respect the account's daily cap and spacing before spending time on the download
+	reserved, err := p.reserveUploadSlot(ctx, video)
+	if err != nil {
+		return err
+	}
+	if !reserved {
+		return errUploadDeferred
+	}
+	defer p.releaseUploadSlot(video.AccountID)
+
+	// Every run counts towards the video's processing time; a video processed before is a retry
+	start := time.Now()
+	rerun := video.Cost.ProcessingTime > 0
+	defer func() {
+		cost := domain.VideoCost{ProcessingTime: time.Since(start)}
+		if rerun {
+			cost.Retries = 1
+		}
+		addVideoCost(ctx, p.videoRepo, video, cost)
+	}()
+
 	logger.Info().Printf("Processing video %s (account %s)", video.YouTubeVideoID, video.AccountID)
 	// Step 1: Download video
-	if err := p.downloadVideo(ctx, video); err != nil {
-		p.videoRepo.UpdateStatus(video.ID, domain.VideoStatusFailed, err.Error())
+	// Outcomes are recorded even if ctx was cancelled mid-step, so videos never stay stuck in a transient status
+	recordCtx := context.WithoutCancel(ctx)
+
+	// An upload interrupted by a crash may already be on TikTok; finish it instead of uploading again
+	resumed, err := p.resumeUpload(ctx, video)
+	if err != nil {
+		if errors.Is(err, errPublishPending) {
+			return p.deferPublishCheck(recordCtx, video, err)
+		}
+		return p.failVideo(ctx, video, err)
+	}
+	if resumed {
+		return p.finishPublished(ctx, video)
+	}
+
+	// Clips are cut from their source video instead of being downloaded
+	download := p.downloadVideo
+	if video.ParentVideoID != "" {
+		download = p.prepareClip
+		defer p.refreshClipParent(recordCtx, video.ParentVideoID)
+	}
+
+	// A video an upload pause or its upload window stopped after its download still has its file
+	downloaded := p.keptForUploadPause(video) || p.keptForUploadWindow(video)
+	if !downloaded && p.pipeline.DownloadsPaused() {
+		return p.deferPaused(recordCtx, video, pausedDownloadMessage)
+	}
+
+	// Nothing is fetched once today's data cap is used up
+	if err := p.transferMeter.CheckTransfer(ctx, 0, 0); err != nil {
+		return p.deferTransfer(recordCtx, video, err)
+	}
+
+	if downloaded {
+		logger.Info().Printf("Video %s was downloaded in an earlier run, reusing %s", video.YouTubeVideoID, video.LocalFilePath)
+	} else if err := download(ctx, video); err != nil {
+		if errors.Is(err, ErrDataCapReached) {
+			return p.deferTransfer(recordCtx, video, err)
+		}
+		if errors.Is(err, downloader.ErrInsufficientDiskSpace) {
+			// Not the video's fault: keep it pending so a later cycle retries once space is freed
+			p.videoRepo.UpdateStatus(recordCtx, video.ID, domain.VideoStatusPending, err.Error())
+			logger.Error().Printf("Download postponed for video %s: %v", video.YouTubeVideoID, err)
+			return fmt.Errorf("%w: %v", errDownloadDeferred, err)
+		}
+		if errors.Is(err, errInvalidMedia) {
+			// The file is at fault, not the account, so this does not count towards its failure streak
+			p.videoRepo.UpdateStatus(recordCtx, video.ID, domain.VideoStatusFailed, err.Error())
+			logger.Error().Printf("Cannot upload video %s: %v", video.YouTubeVideoID, err)
+			return err
+		}
+		err = p.failVideo(ctx, video, err)
 		logger.Error().Printf("Download failed for video %s: %v", video.YouTubeVideoID, err)
 		return err
 	}
 
+	// The file is kept as downloaded, before any hook changed it, until uploads resume
+	if p.pipeline.UploadsPaused() {
+		return p.deferPaused(recordCtx, video, pausedUploadMessage)
+	}
+	if err := p.waitForUploadWindow(recordCtx, video); err != nil {
+		return err
+	}
+
+	// Custom steps such as watermarking may replace the file or stop the video here
+	for _, phase := range []string{config.HookPhasePostDownload, config.HookPhasePreUpload} {
+		if err := p.runHooks(ctx, phase, video); err != nil {
+			err = p.failVideo(ctx, video, err)
+			logger.Error().Printf("Processing of video %s stopped at %s: %v", video.YouTubeVideoID, phase, err)
+			return err
+		}
+	}
+
+	if held, err := p.checkContentSafety(ctx, video); err != nil {
+		err = p.failVideo(ctx, video, err)
+		logger.Error().Printf("Content safety check failed for video %s: %v", video.YouTubeVideoID, err)
+		return err
+	} else if held {
+		return nil
+	}
+
 	// Step 2: Upload to TikTok
-	if err := p.uploadVideo(ctx, video); err != nil {
-		p.videoRepo.UpdateStatus(video.ID, domain.VideoStatusFailed, err.Error())
+	publishing, err := p.uploadVideo(ctx, video)
+	if err != nil {
+		if errors.Is(err, ErrDataCapReached) {
+			return p.deferTransfer(recordCtx, video, err)
+		}
+		if errors.Is(err, errDuplicateContent) {
+			p.videoRepo.UpdateStatus(recordCtx, video.ID, domain.VideoStatusSkipped, err.Error())
+			logger.Info().Printf("Skipping video %s: %v", video.YouTubeVideoID, err)
+			return nil
+		}
+		if errors.Is(err, errNoUploadPath) {
+			// The file is at fault, not the account, so this does not count towards its failure streak
+			p.videoRepo.UpdateStatus(recordCtx, video.ID, domain.VideoStatusFailed, err.Error())
+			logger.Error().Printf("Cannot upload video %s: %v", video.YouTubeVideoID, err)
+			return err
+		}
+		err = p.failVideo(ctx, video, err)
 		logger.Error().Printf("Upload failed for video %s: %v", video.YouTubeVideoID, err)
 		return err
 	}
+	if publishing {
+		return p.awaitPublish(recordCtx, video)
+	}
+
+	return p.finishPublished(ctx, video)
+}
+
+// finishPublished runs the steps after a video reached TikTok and marks it completed
+func (p *VideoProcessor) finishPublished(ctx context.Context, video *domain.Video) error {
+	recordCtx := context.WithoutCancel(ctx)
+	p.recordTikTokPostURL(recordCtx, video)
+
+	// Step 3: Post the first comment (best-effort, never fails the video)
+	p.postFirstCommentAsync(ctx, video)
+
+	// Link the TikTok post from the YouTube description (best-effort, opt-in per account)
+	p.linkOnYouTube(ctx, video)
+
+	// Published videos cannot be stopped anymore; post_publish hooks are informational
+	if err := p.runHooks(recordCtx, config.HookPhasePostPublish, video); err != nil {
+		logger.Error().Printf("post_publish hooks for video %s: %v", video.YouTubeVideoID, err)
+	}
 
-	// Step 3: Mark as completed
+	// Step 4: Mark as completed
 	logger.Info().Printf("Completed processing video %s (TikTok video ID: %s)", video.YouTubeVideoID, video.TikTokVideoID)
-	return p.videoRepo.UpdateStatus(video.ID, domain.VideoStatusCompleted, "")
+	if p.failureTracker != nil {
+		p.failureTracker.RecordSuccess(recordCtx, video.AccountID, domain.FailureSourceVideo)
+	}
+	return p.videoRepo.UpdateStatus(recordCtx, video.ID, domain.VideoStatusCompleted, "")
+}
+
+// failVideo marks the video failed, or puts it back to pending when err came from ctx ending
+// (job timeout or shutdown) rather than from the video itself
+func (p *VideoProcessor) failVideo(ctx context.Context, video *domain.Video, err error) error {
+	recordCtx := context.WithoutCancel(ctx)
+	if ctx.Err() != nil {
+		p.videoRepo.UpdateStatus(recordCtx, video.ID, domain.VideoStatusPending, fmt.Sprintf("%v: %v", errInterrupted, err))
+		logger.Info().Printf("Processing of video %s interrupted, it stays pending: %v", video.YouTubeVideoID, err)
+		return fmt.Errorf("%w: %v", errInterrupted, err)
+	}
+	p.videoRepo.UpdateStatus(recordCtx, video.ID, domain.VideoStatusFailed, err.Error())
+	if p.failureTracker != nil {
+		p.failureTracker.RecordFailure(recordCtx, video.AccountID, domain.FailureSourceVideo, err)
+	}
+	return err
+}
+
+// deferTransfer keeps a video pending because a daily data cap was reached; the error is stored so
+// the deferral shows in the video's status
+func (p *VideoProcessor) deferTransfer(ctx context.Context, video *domain.Video, err error) error {
+	p.videoRepo.UpdateStatus(ctx, video.ID, domain.VideoStatusPending, err.Error())
+	logger.Info().Printf("Deferring video %s: %v", video.YouTubeVideoID, err)
+	return fmt.Errorf("%w: %v", errTransferDeferred, err)
 }
 
 // downloadVideo downloads a video from YouTube with optimized I/O parallelism
 func (p *VideoProcessor) downloadVideo(ctx context.Context, video *domain.Video) error {
 	// Update status to downloading
-	if err := p.videoRepo.UpdateStatus(video.ID, domain.VideoStatusDownloading, ""); err != nil {
+	if err := p.videoRepo.UpdateStatus(ctx, video.ID, domain.VideoStatusDownloading, ""); err != nil {
 		return err
 	}
 	logger.Info().Printf("Starting download for video %s (account %s)", video.YouTubeVideoID, video.AccountID)
 
+	result, err := p.fetchVideoFile(ctx, video)
+	if err != nil {
+		return err
+	}
+
+	// TikTok only takes MP4 with H.264/H.265 and AAC within its limits
+	filePath, err := p.validateDownload(ctx, video, result.FilePath)
+	if err != nil {
+		return err
+	}
+	result.FilePath = filePath
+
+	// Store the file path and timing and mark the video downloaded
+	if err := p.markDownloaded(ctx, video, result.FilePath, result.Duration); err != nil {
+		return err
+	}
+	logger.Info().Printf("Download completed for video %s via %s -> %s", video.YouTubeVideoID, result.Via(), result.FilePath)
+
+	// Enforce retention policy for downloads directory.
+	go p.cleanupDownloadDirectory(result.FilePath)
+
+	return nil
+}
+
+// fetchVideoFile downloads a YouTube video with retries, bounded by the account's download slots
+// and the download semaphore. The bytes and retries are added to the video's cost.
+func (p *VideoProcessor) fetchVideoFile(ctx context.Context, video *domain.Video) (*downloader.DownloadResult, error) {
+	youtubeVideoID := video.YouTubeVideoID
+
+	// The account's slot comes first, so videos of a busy account wait without holding global slots
+	release, err := p.accountDownloads.acquire(ctx, video.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Acquire download semaphore to limit concurrent downloads
 	p.downloadSem <- struct{}{}
 	defer func() { <-p.downloadSem }()
 
+	// The account's container, resolution and frame rate preferences; unset ones, format and
+	// retries come from the download config
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
+	if err != nil || account == nil {
+		account = &domain.Account{}
+	}
+
 	// Download video with optimized settings for I/O bound operation
+	recordProgress := p.progressRecorder(ctx, video)
 	opts := downloader.DownloadOptions{
-		VideoID: video.YouTubeVideoID,
-		Format:  "mp4",
-		Quality: "720p", // Optimize for TikTok (balance quality vs download time)
+		VideoID: youtubeVideoID,
+		// Named after the video rather than the YouTube ID, since a cross-posted video is
+		// downloaded once per account
+		FileName:  video.ID,
+		Container: account.Settings.DownloadContainer,
+		MaxHeight: account.Settings.DownloadMaxHeight,
+		FPS:       account.Settings.DownloadFPS,
 		ProgressCallback: func(progress int) {
-			// Progress tracking can be logged here
+			recordProgress(progress)
 		},
 	}
 
@@ -172,14 +804,19 @@ func (p *VideoProcessor) downloadVideo(ctx context.Context, video *domain.Video)
 	deadline := time.Now().Add(p.config.DownloadTimeout)
 
 	var (
-		result  *downloader.DownloadResult
-		lastErr error
+		result   *downloader.DownloadResult
+		lastErr  error
+		attempts int
 	)
+	defer func() {
+		addVideoCost(ctx, p.videoRepo, video, domain.VideoCost{Retries: max(attempts-1, 0)})
+	}()
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if err := ctx.Err(); err != nil {
-			return err
+			return nil, err
 		}
+		attempts = attempt
 
 		remaining := time.Until(deadline)
 		if remaining <= 0 {
@@ -188,7 +825,7 @@ func (p *VideoProcessor) downloadVideo(ctx context.Context, video *domain.Video)
 		}
 
 		attemptCtx, cancel := context.WithTimeout(ctx, remaining)
-		logger.Info().Printf("Attempt %d/%d downloading video %s", attempt, maxRetries, video.YouTubeVideoID)
+		logger.Info().Printf("Attempt %d/%d downloading video %s", attempt, maxRetries, youtubeVideoID)
 
 		result, lastErr = p.downloadService.DownloadVideo(attemptCtx, opts)
 		cancel()
@@ -197,17 +834,19 @@ func (p *VideoProcessor) downloadVideo(ctx context.Context, video *domain.Video)
 			break
 		}
 
-		logger.Error().Printf("Download attempt %d failed for video %s: %v", attempt, video.YouTubeVideoID, lastErr)
+		logger.Error().Printf("Download attempt %d failed for video %s: %v", attempt, youtubeVideoID, lastErr)
 
-		// Do not retry if context was cancelled or deadline exceeded.
-		if errors.Is(lastErr, context.Canceled) || errors.Is(lastErr, context.DeadlineExceeded) {
+		// Do not retry if context was cancelled or deadline exceeded, the disk is full or the
+		// download needs more memory than download.resource_limits allows.
+		if errors.Is(lastErr, context.Canceled) || errors.Is(lastErr, context.DeadlineExceeded) ||
+			errors.Is(lastErr, downloader.ErrInsufficientDiskSpace) || errors.Is(lastErr, downloader.ErrResourceLimit) {
 			break
 		}
 
 		if attempt < maxRetries {
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
+				return nil, ctx.Err()
 			case <-time.After(retryDelay):
 			}
 			retryDelay *= 2
@@ -215,72 +854,462 @@ func (p *VideoProcessor) downloadVideo(ctx context.Context, video *domain.Video)
 	}
 
 	if lastErr != nil {
-		return fmt.Errorf("download failed after %d attempts: %w", maxRetries, lastErr)
+		return nil, fmt.Errorf("download failed after %d attempts: %w", maxRetries, lastErr)
+	}
+
+	// Metered after the fact from the file size; bytes of failed attempts are not counted
+	if err := p.transferMeter.RecordDownload(context.WithoutCancel(ctx), result.FileSize); err != nil {
+		logger.Error().Printf("Failed to record download of %d bytes for video %s: %v", result.FileSize, youtubeVideoID, err)
+	}
+	addVideoCost(ctx, p.videoRepo, video, domain.VideoCost{DownloadBytes: result.FileSize})
+
+	return result, nil
+}
+
+// prepareClip cuts a clip out of its source video, downloading the source on first use
+func (p *VideoProcessor) prepareClip(ctx context.Context, clip *domain.Video) error {
+	start := time.Now()
+	if err := p.videoRepo.UpdateStatus(ctx, clip.ID, domain.VideoStatusDownloading, ""); err != nil {
+		return err
 	}
+	p.refreshClipParent(ctx, clip.ParentVideoID)
 
-	// Update video with file path
-	if err := p.videoRepo.UpdateFilePath(video.ID, result.FilePath); err != nil {
+	parent, err := p.videoRepo.GetByID(ctx, clip.ParentVideoID)
+	if err != nil {
+		return fmt.Errorf("failed to get source video: %w", err)
+	}
+	if parent == nil {
+		return fmt.Errorf("source video not found: %s", clip.ParentVideoID)
+	}
+
+	sourcePath, err := p.ensureClipSource(ctx, parent)
+	if err != nil {
 		return err
 	}
-	video.LocalFilePath = result.FilePath
 
-	// Update status to downloaded
-	if err := p.videoRepo.UpdateStatus(video.ID, domain.VideoStatusDownloaded, ""); err != nil {
+	clipPath := filepath.Join(p.config.DownloadDir, "clips", clip.ID+".mp4")
+	if clip.ClipEnd == 0 {
+		// Experiment arms post the whole source, each with its own caption
+		logger.Info().Printf("Copying source %s for experiment arm %s", filepath.Base(sourcePath), clip.YouTubeVideoID)
+		if err := linkOrCopyFile(sourcePath, clipPath); err != nil {
+			return fmt.Errorf("failed to copy source for experiment arm: %w", err)
+		}
+	} else {
+		logger.Info().Printf("Cutting clip %s [%s-%s] from %s",
+			clip.YouTubeVideoID, FormatClipTimestamp(clip.ClipStart), FormatClipTimestamp(clip.ClipEnd), filepath.Base(sourcePath))
+		if err := p.downloadService.CutClip(ctx, sourcePath, clipPath, clip.ClipStart, clip.ClipEnd); err != nil {
+			return err
+		}
+	}
+
+	// Includes waiting for the shared source download, which is what the clip actually waited
+	return p.markDownloaded(ctx, clip, clipPath, time.Since(start))
+}
+
+// ensureClipSource returns the local path of a split video's source, downloading it once.
+// Sources live in their own subdirectory so the download retention sweep leaves them alone
+// until every clip has been cut.
+func (p *VideoProcessor) ensureClipSource(ctx context.Context, parent *domain.Video) (string, error) {
+	lock := p.clipSourceLock(parent.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Another clip may have fetched the source while we waited
+	current, err := p.videoRepo.GetByID(ctx, parent.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source video: %w", err)
+	}
+	if current != nil && current.LocalFilePath != "" {
+		if _, err := os.Stat(current.LocalFilePath); err == nil {
+			return current.LocalFilePath, nil
+		}
+	}
+
+	logger.Info().Printf("Downloading source %s for %d clips", parent.YouTubeVideoID, parent.ClipCount)
+	result, err := p.fetchVideoFile(ctx, parent)
+	if err != nil {
+		return "", fmt.Errorf("failed to download source video: %w", err)
+	}
+
+	sourceDir := filepath.Join(p.config.DownloadDir, "sources")
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create source directory: %w", err)
+	}
+	sourcePath := filepath.Join(sourceDir, filepath.Base(result.FilePath))
+	if err := os.Rename(result.FilePath, sourcePath); err != nil {
+		return "", fmt.Errorf("failed to move source video: %w", err)
+	}
+
+	if err := p.videoRepo.UpdateFilePath(ctx, parent.ID, sourcePath); err != nil {
+		return "", err
+	}
+	return sourcePath, nil
+}
+
+// linkOrCopyFile gives dst the contents of src, hard linking when the filesystem allows it.
+// Each arm needs its own path because uploads and cleanup remove files independently.
+func linkOrCopyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
 		return err
 	}
-	logger.Info().Printf("Download completed for video %s -> %s", video.YouTubeVideoID, result.FilePath)
+	_ = os.Remove(dst)
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
 
-	// Enforce retention policy for downloads directory.
-	go p.cleanupDownloadDirectory(result.FilePath)
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
 
-	return nil
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// clipSourceLock returns the mutex guarding a split video's source download
+func (p *VideoProcessor) clipSourceLock(parentID string) *sync.Mutex {
+	p.clipMu.Lock()
+	defer p.clipMu.Unlock()
+
+	lock, ok := p.clipSourceLocks[parentID]
+	if !ok {
+		lock = &sync.Mutex{}
+		p.clipSourceLocks[parentID] = lock
+	}
+	return lock
+}
+
+// refreshClipParent rolls a split video's status up from its clips and deletes the
+// source file once every clip is done
+func (p *VideoProcessor) refreshClipParent(ctx context.Context, parentID string) {
+	clips, err := p.videoRepo.GetClips(ctx, parentID)
+	if err != nil {
+		logger.Error().Printf("Failed to load clips of video %s: %v", parentID, err)
+		return
+	}
+	if len(clips) == 0 {
+		return
+	}
+
+	status, errMsg := clipParentStatus(clips)
+	if err := p.videoRepo.UpdateStatus(ctx, parentID, status, errMsg); err != nil {
+		logger.Error().Printf("Failed to update status of video %s: %v", parentID, err)
+		return
+	}
+	if status != domain.VideoStatusCompleted {
+		return
+	}
+
+	lock := p.clipSourceLock(parentID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	parent, err := p.videoRepo.GetByID(ctx, parentID)
+	if err != nil || parent == nil || parent.LocalFilePath == "" {
+		return
+	}
+	if err := os.Remove(parent.LocalFilePath); err != nil && !os.IsNotExist(err) {
+		logger.Error().Printf("Failed to remove source %s: %v", parent.LocalFilePath, err)
+		return
+	}
+	if err := p.videoRepo.UpdateFilePath(ctx, parentID, ""); err != nil {
+		logger.Error().Printf("Failed to clear source path of video %s: %v", parentID, err)
+	}
+	logger.Info().Printf("All clips of video %s completed, removed source %s", parent.YouTubeVideoID, parent.LocalFilePath)
+
+	p.clipMu.Lock()
+	delete(p.clipSourceLocks, parentID)
+	p.clipMu.Unlock()
 }
 
 // uploadVideo uploads a video to TikTok with optimized I/O parallelism
 // Each video is linked to an account which maps YouTube channel -> TikTok account
-func (p *VideoProcessor) uploadVideo(ctx context.Context, video *domain.Video) error {
+func (p *VideoProcessor) uploadVideo(ctx context.Context, video *domain.Video) (bool, error) {
 	// Get account mapping (YouTube channel -> TikTok account) for this video
-	account, err := p.accountRepo.GetByID(video.AccountID)
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
 	if err != nil {
-		return fmt.Errorf("failed to get account mapping: %w", err)
+		return false, fmt.Errorf("failed to get account mapping: %w", err)
 	}
 
 	if account == nil {
-		return fmt.Errorf("account mapping not found for video %s (account ID: %s)", video.ID, video.AccountID)
+		return false, fmt.Errorf("account mapping not found for video %s (account ID: %s)", video.ID, video.AccountID)
 	}
 
 	// Validate that account has TikTok credentials
 	if account.TikTokAccountID == "" {
-		return fmt.Errorf("TikTok account ID not configured for account %s", account.ID)
+		return false, fmt.Errorf("TikTok account ID not configured for account %s", account.ID)
 	}
 
-	// If Web Upload is enabled, we skip API token validation
-	if !p.config.TikTokEnableWeb {
-		if account.TikTokAccessToken == "" {
-			authorizeURL := p.promptManualAuthorization(account.ID)
-			return fmt.Errorf("TikTok access token not configured for account %s. Re-authorize via %s and exchange the returned code for a token", account.ID, authorizeURL)
+	// Files too large or too long for the selected path go to the other one when it accepts them
+	media := p.measureUpload(ctx, video)
+	path, reason, err := p.routeUpload(account, p.selectUploadPath(account, time.Now()), media)
+	if err != nil {
+		return false, err
+	}
+
+	// The download may have used up the budget the upload needed
+	if media.size > 0 {
+		if err := p.transferMeter.CheckTransfer(ctx, 0, media.size); err != nil {
+			return false, err
 		}
+	}
 
-		// Validate and refresh access token if needed
-		logger.Info().Printf("Validating TikTok access token for account %s", account.ID)
-		isValid, err := p.tiktokService.VerifyAccessToken(account.TikTokAccessToken)
-		if err != nil {
-			logger.Error().Printf("Failed to verify access token for account %s: %v", account.ID, err)
-			return fmt.Errorf("failed to verify access token: %w", err)
-		}
-		if !isValid {
-			logger.Info().Printf("Access token is invalid or expired for account %s, attempting to refresh", account.ID)
-
-			// Try to refresh token if refresh token is available
-			if account.TikTokRefreshToken != "" {
-				logger.Info().Printf("Attempting to refresh access token for account %s", account.ID)
-				tokenResp, err := p.tiktokService.RefreshAccessToken(account.TikTokRefreshToken)
-				if err != nil {
-					logger.Error().Printf("Failed to refresh access token for account %s: %v", account.ID, err)
-					return fmt.Errorf("TikTok access token is invalid and refresh failed for account %s: %w. Please update the token", account.ID, err)
+	// Posts to one TikTok account are limited separately, before the global upload slots; the wait
+	// comes before the duplicate check so that sees the uploads it waited for
+	release, err := p.accountUploads.acquire(ctx, account.TikTokAccountID)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	// Identical content is never sent to the same account twice, e.g. when a retry would repeat a
+	// web upload whose outcome was lost
+	if err := p.checkDuplicateContent(ctx, video); err != nil {
+		return false, err
+	}
+
+	// Update status to uploading
+	if err := p.videoRepo.UpdateStatus(ctx, video.ID, domain.VideoStatusUploading, ""); err != nil {
+		return false, err
+	}
+	p.recordUploadRoute(ctx, video, path, reason)
+	logger.Info().Printf("Starting %s upload for video %s (account %s, %s)", path, video.YouTubeVideoID, account.ID, reason)
+
+	// Acquire upload semaphore to limit concurrent uploads
+	p.uploadSem <- struct{}{}
+	defer func() { <-p.uploadSem }()
+
+	// Perform upload to the linked TikTok account
+	// Each job uploads to its specific TikTok account
+	uploadStart := time.Now()
+	tiktokVideoID, err := p.uploadVia(ctx, account, video, path)
+	fallback, failover := p.recordUploadOutcome(context.WithoutCancel(ctx), account.ID, path, err)
+	if failover {
+		// The fallback must accept the file too, or the retry fails for another reason
+		if over := p.exceedsLimits(account, fallback, media); over != "" {
+			logger.Error().Printf("Not retrying video %s on %s: %s", video.YouTubeVideoID, fallback, over)
+			failover = false
+		}
+	}
+	if failover {
+		logger.Error().Printf("%s upload failed for video %s, retrying on %s: %v", path, video.YouTubeVideoID, fallback, err)
+		p.recordUploadRoute(ctx, video, fallback, fmt.Sprintf("failed over after the %s upload failed", path))
+		addVideoCost(ctx, p.videoRepo, video, domain.VideoCost{Retries: 1})
+		path = fallback
+		tiktokVideoID, err = p.uploadVia(ctx, account, video, path)
+		p.recordUploadOutcome(context.WithoutCancel(ctx), account.ID, path, err)
+	}
+	if err != nil {
+		logger.Error().Printf("Upload failed for video %s: %v", video.YouTubeVideoID, err)
+		return false, fmt.Errorf("%s upload failed: %w", path, err)
+	}
+
+	// Store the TikTok ID with the upload timing. The duration covers a failover retry too, but
+	// not the wait for an upload slot
+	uploadedAt := time.Now()
+	uploadDuration := uploadedAt.Sub(uploadStart)
+	if err := p.videoRepo.MarkUploaded(context.WithoutCancel(ctx), video.ID, tiktokVideoID, uploadedAt, uploadDuration); err != nil {
+		return false, err
+	}
+	video.TikTokVideoID = tiktokVideoID
+	video.UploadedAt = uploadedAt
+	video.UploadDuration = uploadDuration
+	logger.Info().Printf("Upload completed for video %s -> TikTok video %s in %s", video.YouTubeVideoID, tiktokVideoID, video.UploadDuration.Round(time.Millisecond))
+
+	// TikTok processes API uploads after the publish call returns; their outcome is checked later
+	return path == domain.UploadPathAPI && video.UploadPublishID != "", nil
+}
+
+// markDownloaded stores the video's file path and download timing and moves it to downloaded
+// in one write
+func (p *VideoProcessor) markDownloaded(ctx context.Context, video *domain.Video, filePath string, duration time.Duration) error {
+	downloadedAt := time.Now()
+	if err := p.videoRepo.MarkDownloaded(ctx, video.ID, filePath, downloadedAt, duration); err != nil {
+		return err
+	}
+	video.LocalFilePath = filePath
+	video.DownloadedAt = downloadedAt
+	video.DownloadDuration = duration
+	return nil
+}
+
+// uploadVia uploads a video through one path. API uploads first make sure the account
+// has a valid access token, refreshing it when possible; web uploads claim the cookies.
+func (p *VideoProcessor) uploadVia(ctx context.Context, account *domain.Account, video *domain.Video, path domain.UploadPath) (string, error) {
+	if path == domain.UploadPathAPI {
+		if err := p.ensureAccessToken(ctx, account); err != nil {
+			return "", err
+		}
+	}
+	if path == domain.UploadPathWeb && p.webSessions != nil {
+		if err := p.webSessions.ClaimCookies(ctx, account.ID); err != nil {
+			return "", err
+		}
+	}
+
+	// The attempt is on record before TikTok sees the file, so a retry after a crash can check it
+	onUploadStarted, err := p.startUploadAttempt(ctx, video)
+	if err != nil {
+		return "", err
+	}
+
+	// Create upload request for the specific TikTok account
+	// Job context: Uploading video from YouTube channel %s to TikTok account %s
+	uploadReq := p.buildUploadRequest(ctx, account, video, time.Now())
+	uploadReq.OnBytesSent = func(n int64) {
+		p.recordUploadBytes(ctx, video, n)
+	}
+	uploadReq.OnUploadStarted = onUploadStarted
+	recordProgress := p.progressRecorder(ctx, video)
+	uploadReq.ProgressCallback = func(sent, total int64) {
+		if total > 0 {
+			recordProgress(int(sent * 100 / total))
+		}
+	}
+
+	if path == domain.UploadPathWeb {
+		// The browser sends the file itself, so a successful upload counts the whole file
+		videoID, err := p.tiktokService.UploadVideoWeb(ctx, uploadReq)
+		if err == nil {
+			if info, statErr := os.Stat(video.LocalFilePath); statErr == nil {
+				p.recordUploadBytes(ctx, video, info.Size())
+			}
+		}
+		return videoID, err
+	}
+	// Drafts get their privacy and interactions from the creator when posted in TikTok
+	if !uploadReq.PostAsDraft {
+		if err := p.checkCreatorInfo(ctx, account, uploadReq); err != nil {
+			return "", err
+		}
+	}
+	videoID, err := p.tiktokService.UploadVideoAPI(ctx, uploadReq)
+	p.recordMissingScope(ctx, account, err)
+	return videoID, err
+}
+
+// buildUploadRequest resolves the caption and post settings of the video's upload: the caption
+// title and description cleaned as configured under caption, the account's settings overridden
+// by the video's post options, and the publish delay. Upload previews use it too, so they show
+// exactly what an upload at now would send.
+func (p *VideoProcessor) buildUploadRequest(ctx context.Context, account *domain.Account, video *domain.Video, now time.Time) *tiktok.UploadRequest {
+	req := &tiktok.UploadRequest{
+		AccessToken: account.TikTokAccessToken,
+		OpenID:      account.TikTokAccountID,
+		VideoPath:   video.LocalFilePath,
+		Title:       p.captionTitle(ctx, account, video),
+		Description: video.Description,
+		PostAsDraft: account.Settings.PostAsDraft,
+	}
+	req.Title, req.Description = SanitizeCaption(req.Title, req.Description, p.captionOptions())
+	applyPostOptions(req, account.Settings, video.PostOptions)
+	if delay := time.Duration(account.Settings.PublishDelay); delay > 0 {
+		req.ScheduleTime = now.Add(delay)
+	}
+	return req
+}
+
+// captionOptions returns the caption cleanup configured under caption
+func (p *VideoProcessor) captionOptions() CaptionOptions {
+	return CaptionOptions{
+		UseDescription: p.config.CaptionUseDescription,
+		StripHashtags:  p.config.CaptionStripHashtags,
+		MaxLength:      p.config.CaptionMaxLength,
+	}
+}
+
+// recordUploadBytes adds bytes sent to TikTok to today's transfer total and the video's cost
+func (p *VideoProcessor) recordUploadBytes(ctx context.Context, video *domain.Video, n int64) {
+	if err := p.transferMeter.RecordUpload(context.WithoutCancel(ctx), n); err != nil {
+		logger.Error().Printf("Failed to record upload of %d bytes for video %s: %v", n, video.YouTubeVideoID, err)
+	}
+	addVideoCost(ctx, p.videoRepo, video, domain.VideoCost{UploadBytes: n})
+}
+
+// progressRecorder returns a callback that stores the percentage of the video's current download
+// or upload done, writing only when it changes so a large file costs at most a hundred writes
+func (p *VideoProcessor) progressRecorder(ctx context.Context, video *domain.Video) func(progress int) {
+	var last atomic.Int64
+	last.Store(-1)
+	return func(progress int) {
+		progress = min(max(progress, 0), 100)
+		if last.Swap(int64(progress)) == int64(progress) {
+			return
+		}
+		if err := p.videoRepo.UpdateProgress(context.WithoutCancel(ctx), video.ID, progress); err != nil {
+			logger.Error().Printf("Failed to record progress of video %s: %v", video.YouTubeVideoID, err)
+		}
+	}
+}
+
+// ensureAccessToken validates the account's API access token and refreshes it if needed.
+// Errors wrap errUploadAuth when the account must be re-authorized, and ErrTikTokAccountMismatch
+// when the token belongs to another TikTok account.
+func (p *VideoProcessor) ensureAccessToken(ctx context.Context, account *domain.Account) error {
+	if account.TikTokAccessToken == "" {
+		authorizeURL := p.promptManualAuthorization(ctx, account.ID, "no access token is configured")
+		return fmt.Errorf("%w: TikTok access token not configured for account %s. Re-authorize via %s and exchange the returned code for a token", errUploadAuth, account.ID, authorizeURL)
+	}
+
+	// The TikTok account is only known once a code has been exchanged
+	if PendingTikTokAccountID(account) {
+		authorizeURL := p.promptManualAuthorization(ctx, account.ID, "it has never been authorized")
+		return fmt.Errorf("%w: TikTok account of account %s is not known yet. Authorize via %s so it is filled in", errUploadAuth, account.ID, authorizeURL)
+	}
+
+	// Posting with another account's token would publish to the wrong profile
+	if account.NeedsReauthorization {
+		authorizeURL := p.promptManualAuthorization(ctx, account.ID, "its token belongs to a different TikTok account")
+		return fmt.Errorf("%w: account %s needs re-authorization via %s", ErrTikTokAccountMismatch, account.ID, authorizeURL)
+	}
+	if openID, ok := p.cachedTokenOwner(account); ok {
+		return p.verifyTokenOwner(ctx, account, openID)
+	}
+
+	// Validate and refresh access token if needed
+	logger.Info().Printf("Validating TikTok access token for account %s", account.ID)
+	info, isValid, err := p.tiktokService.GetUserInfo(account.TikTokAccessToken)
+	if err != nil {
+		// A token lacking a scope is still valid, so it is not refreshed
+		logger.Error().Printf("Failed to verify access token for account %s: %v", account.ID, err)
+		p.recordMissingScope(ctx, account, err)
+		return fmt.Errorf("failed to verify access token: %w", err)
+	}
+	openID := ""
+	if isValid {
+		openID = info.OpenID
+	} else {
+		logger.Info().Printf("Access token is invalid or expired for account %s, attempting to refresh", account.ID)
+
+		// Try to refresh token if refresh token is available
+		if account.TikTokRefreshToken != "" {
+			// Refresh tokens are bound to the developer app that issued them
+			app, err := TikTokAppFor(p.config, account)
+			if err != nil {
+				return fmt.Errorf("%w: cannot refresh token for account %s: %w", errUploadAuth, account.ID, err)
+			}
+			logger.Info().Printf("Attempting to refresh access token for account %s with credential set %s", account.ID, app.Name)
+			tokenResp, err := p.tiktokService.RefreshAccessToken(app, account.TikTokRefreshToken)
+			if err != nil {
+				logger.Error().Printf("Failed to refresh access token for account %s: %v", account.ID, err)
+				p.promptManualAuthorization(ctx, account.ID, fmt.Sprintf("refreshing the access token failed (%v)", err))
+				if mismatch := TikTokAppMismatch(p.config, account); mismatch != "" {
+					return fmt.Errorf("%w: TikTok access token refresh failed for account %s (%s): %w", errUploadAuth, account.ID, mismatch, err)
 				}
+				return fmt.Errorf("%w: TikTok access token is invalid and refresh failed for account %s: %w. Please update the token", errUploadAuth, account.ID, err)
+			}
 
-				// Update account with new tokens
+			// Update account with new tokens
+			applyTokens := func(account *domain.Account) error {
 				account.TikTokAccessToken = tokenResp.Data.AccessToken
 				if tokenResp.Data.RefreshToken != "" {
 					account.TikTokRefreshToken = tokenResp.Data.RefreshToken
@@ -289,83 +1318,233 @@ func (p *VideoProcessor) uploadVideo(ctx context.Context, video *domain.Video) e
 					expiresAt := time.Now().Add(time.Duration(tokenResp.Data.ExpiresIn) * time.Second)
 					account.TikTokTokenExpiresAt = &expiresAt
 				}
+				// A successful refresh proves which client key the tokens belong to
+				account.TikTokApp = app.Name
+				account.TikTokClientKey = app.APIKey
+				return nil
+			}
+			applyTokens(account)
+
+			// Save updated account; TikTok has already rotated the refresh token, so a
+			// concurrent save must not make us drop it
+			var before domain.Account
+			saved, err := modifyAccount(ctx, p.accountRepo, account.ID, "save refreshed token", func(stored *domain.Account) error {
+				before = *stored
+				return applyTokens(stored)
+			})
+			if err != nil {
+				logger.Error().Printf("Failed to save refreshed token for account %s: %v", account.ID, err)
+				return err
+			}
+			refreshCtx := WithAccountEventSource(ctx, domain.AccountEventSourceRefresh)
+			p.accountEvents.Record(refreshCtx, account.ID, domain.AccountEventTokenUpdated, accountChanges(&before, saved))
 
-				// Save updated account
-				if err := p.accountRepo.Save(account); err != nil {
-					logger.Error().Printf("Failed to save refreshed token for account %s: %v", account.ID, err)
-					return fmt.Errorf("failed to save refreshed token: %w", err)
-				}
-
-				logger.Info().Printf("Successfully refreshed access token for account %s", account.ID)
-			} else {
-				logger.Error().Printf("Access token is invalid or expired for account %s and no refresh token available", account.ID)
-				authorizeURL := p.promptManualAuthorization(account.ID)
-				return fmt.Errorf("TikTok access token is invalid or expired for account %s and no refresh token available. Re-authorize via %s and exchange the returned code for a new token", account.ID, authorizeURL)
+			logger.Info().Printf("Successfully refreshed access token for account %s", account.ID)
+			if err := shareTikTokTokens(refreshCtx, p.accountRepo, p.accountEvents, account); err != nil {
+				logger.Error().Printf("Failed to share refreshed token of account %s: %v", account.ID, err)
 			}
+			openID = tokenResp.Data.OpenID
+		} else {
+			logger.Error().Printf("Access token is invalid or expired for account %s and no refresh token available", account.ID)
+			authorizeURL := p.promptManualAuthorization(ctx, account.ID, "the access token expired and there is no refresh token")
+			return fmt.Errorf("%w: TikTok access token is invalid or expired for account %s and no refresh token available. Re-authorize via %s and exchange the returned code for a new token", errUploadAuth, account.ID, authorizeURL)
 		}
-		logger.Info().Printf("Access token validated successfully for account %s", account.ID)
+	}
+	if err := p.verifyTokenOwner(ctx, account, openID); err != nil {
+		return err
+	}
+	p.rememberTokenOwner(account, openID)
+	logger.Info().Printf("Access token validated successfully for account %s", account.ID)
+
+	return nil
+}
+
+// postFirstComment posts the account's comment template on the published TikTok video.
+// Failures are recorded on the video but never fail processing.
+func (p *VideoProcessor) postFirstComment(ctx context.Context, video *domain.Video) {
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
+	if err != nil || account == nil || strings.TrimSpace(account.CommentTemplate) == "" {
+		return
+	}
+	if video.TikTokVideoID == "" {
+		return
+	}
+	// Drafts and scheduled posts are not live yet, so there is nothing to comment on
+	if account.Settings.PostAsDraft || account.Settings.PublishDelay > 0 {
+		return
+	}
+
+	text := renderCommentTemplate(account.CommentTemplate, video)
+
+	if err := p.waitForCommentSlot(ctx, account.TikTokAccountID); err != nil {
+		p.recordCommentResult(ctx, video, false, err)
+		return
+	}
+
+	err = p.tiktokService.PostComment(ctx, &tiktok.CommentRequest{
+		AccessToken: account.TikTokAccessToken,
+		OpenID:      account.TikTokAccountID,
+		VideoID:     video.TikTokVideoID,
+		Text:        text,
+	})
+	p.recordCommentResult(ctx, video, err == nil, err)
+}
+
+// postFirstCommentAsync posts the first comment in the background, so waiting for the account's
+// comment interval holds neither the upload slot nor the worker. It runs as in-flight work that
+// shutdown waits for; once shutdown has begun it posts inline instead.
+func (p *VideoProcessor) postFirstCommentAsync(ctx context.Context, video *domain.Video) {
+	if !p.beginWork() {
+		p.postFirstComment(ctx, video)
+		return
+	}
+	// The worker's context ends with the video and the caller keeps using video
+	commentCtx := context.WithoutCancel(ctx)
+	published := *video
+	go func() {
+		defer p.endWork()
+		p.postFirstComment(commentCtx, &published)
+	}()
+}
+
+// recordCommentResult stores the outcome of the comment step on the video
+func (p *VideoProcessor) recordCommentResult(ctx context.Context, video *domain.Video, posted bool, err error) {
+	errorMsg := ""
+	if err != nil {
+		errorMsg = err.Error()
+		logger.Error().Printf("Failed to post comment for video %s: %v", video.YouTubeVideoID, err)
 	} else {
-		logger.Info().Printf("Web upload enabled, skipping API token validation for account %s", account.ID)
+		logger.Info().Printf("Posted comment on TikTok video %s", video.TikTokVideoID)
 	}
 
-	// Update status to uploading
-	if err := p.videoRepo.UpdateStatus(video.ID, domain.VideoStatusUploading, ""); err != nil {
-		return err
+	video.CommentPosted = posted
+	video.CommentError = errorMsg
+	if err := p.videoRepo.UpdateCommentResult(context.WithoutCancel(ctx), video.ID, posted, errorMsg); err != nil {
+		logger.Error().Printf("Failed to record comment result for video %s: %v", video.ID, err)
 	}
-	logger.Info().Printf("Starting upload for video %s (account %s)", video.YouTubeVideoID, account.ID)
+}
 
-	// Acquire upload semaphore to limit concurrent uploads
-	p.uploadSem <- struct{}{}
-	defer func() { <-p.uploadSem }()
+// waitForCommentSlot blocks until the TikTok account may comment again.
+// Slots are reserved up front so concurrent uploads are spaced out as well.
+func (p *VideoProcessor) waitForCommentSlot(ctx context.Context, tiktokAccountID string) error {
+	interval := p.config.TikTokCommentMinInterval
+	if interval <= 0 {
+		return nil
+	}
 
-	// Create upload request for the specific TikTok account
-	// Job context: Uploading video from YouTube channel %s to TikTok account %s
-	uploadReq := &tiktok.UploadRequest{
-		AccessToken:  account.TikTokAccessToken,
-		OpenID:       account.TikTokAccountID,
-		VideoPath:    video.LocalFilePath,
-		Title:        video.Title,
-		Description:  video.Description,
-		PrivacyLevel: "PUBLIC_TO_EVERYONE",
+	p.commentMu.Lock()
+	now := time.Now()
+	next := p.lastCommentAt[tiktokAccountID].Add(interval)
+	if next.Before(now) {
+		next = now
 	}
+	p.lastCommentAt[tiktokAccountID] = next
+	p.commentMu.Unlock()
 
-	// Perform upload to the linked TikTok account
-	// Each job uploads to its specific TikTok account
-	tiktokVideoID, err := p.tiktokService.UploadVideo(uploadReq)
+	wait := time.Until(next)
+	if wait <= 0 {
+		return nil
+	}
+
+	logger.Info().Printf("Waiting %s before commenting as TikTok account %s", wait.Round(time.Second), tiktokAccountID)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// reserveUploadSlot checks the account's MaxUploadsPerDay and MinGapBetweenUploads settings.
+// It returns false, leaving the video pending, when the account must wait; otherwise it reserves
+// a slot that is released with releaseUploadSlot. Uploads still in flight count against both limits.
+func (p *VideoProcessor) reserveUploadSlot(ctx context.Context, video *domain.Video) (bool, error) {
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
 	if err != nil {
-		logger.Error().Printf("Upload failed for video %s: %v", video.YouTubeVideoID, err)
-		return fmt.Errorf("upload failed: %w", err)
+		return false, fmt.Errorf("failed to get account: %w", err)
 	}
 
-	// Update video with TikTok ID
-	if err := p.videoRepo.UpdateTikTokID(video.ID, tiktokVideoID); err != nil {
-		return err
+	p.uploadMu.Lock()
+	defer p.uploadMu.Unlock()
+
+	inFlight := p.uploadsInFlight[video.AccountID]
+	if account != nil {
+		// Videos of an account deactivated for repeated failures wait until it is reactivated
+		if !account.IsActive && account.FailureStreak.DisabledReason != "" {
+			logger.Info().Printf("Deferring video %s: account %s was %s", video.YouTubeVideoID, account.ID, account.FailureStreak.DisabledReason)
+			return false, nil
+		}
+
+		maxPerDay := account.Settings.MaxUploadsPerDay
+		minGap := time.Duration(account.Settings.MinGapBetweenUploads)
+
+		if maxPerDay > 0 || minGap > 0 {
+			if minGap > 0 && inFlight > 0 {
+				logger.Info().Printf("Deferring video %s: another upload for account %s is in progress and min gap is %s", video.YouTubeVideoID, account.ID, minGap)
+				return false, nil
+			}
+
+			now := time.Now()
+			stats, err := p.videoRepo.GetUploadStats(ctx, account.ID, now.Add(-24*time.Hour))
+			if err != nil {
+				return false, fmt.Errorf("failed to get upload stats: %w", err)
+			}
+			if maxPerDay > 0 && stats.Count+inFlight >= maxPerDay {
+				logger.Info().Printf("Deferring video %s: account %s reached its cap of %d uploads per day", video.YouTubeVideoID, account.ID, maxPerDay)
+				return false, nil
+			}
+			if minGap > 0 && !stats.LastCompletedAt.IsZero() {
+				if wait := stats.LastCompletedAt.Add(minGap).Sub(now); wait > 0 {
+					logger.Info().Printf("Deferring video %s: account %s uploaded %s ago, next upload allowed in %s", video.YouTubeVideoID, account.ID, now.Sub(stats.LastCompletedAt).Round(time.Second), wait.Round(time.Second))
+					return false, nil
+				}
+			}
+		}
 	}
-	logger.Info().Printf("Upload completed for video %s -> TikTok video %s", video.YouTubeVideoID, tiktokVideoID)
 
-	return nil
+	p.uploadsInFlight[video.AccountID] = inFlight + 1
+	return true, nil
 }
 
-// promptManualAuthorization logs instructions for manually re-authorizing a TikTok account and returns the authorize URL.
-func (p *VideoProcessor) promptManualAuthorization(accountID string) string {
-	scopes := "user.info.basic,video.upload,video.publish"
-	state := "12345"
-	authorizeURL := fmt.Sprintf(
-		"https://www.tiktok.com/v2/auth/authorize?client_key=%s&scope=%s&response_type=code&redirect_uri=%s&state=%s",
-		url.QueryEscape(p.config.TikTokAPIKey),
-		url.QueryEscape(scopes),
-		url.QueryEscape(p.config.TikTokRedirectURI),
-		url.QueryEscape(state),
+// releaseUploadSlot frees a slot taken by reserveUploadSlot
+func (p *VideoProcessor) releaseUploadSlot(accountID string) {
+	p.uploadMu.Lock()
+	defer p.uploadMu.Unlock()
+	if p.uploadsInFlight[accountID] <= 1 {
+		delete(p.uploadsInFlight, accountID)
+		return
+	}
+	p.uploadsInFlight[accountID]--
+}
+
+// renderCommentTemplate fills the {youtube_url} and {title} placeholders
+func renderCommentTemplate(template string, video *domain.Video) string {
+	replacer := strings.NewReplacer(
+		"{youtube_url}", "https://www.youtube.com/watch?v="+video.YouTubeVideoID,
+		"{title}", video.Title,
 	)
+	return replacer.Replace(template)
+}
+
+// promptManualAuthorization logs instructions for manually re-authorizing a TikTok account, notifies
+// the operator when an alerter is set and returns the authorize URL.
+// The URL points at the local authorize endpoint, which builds the TikTok URL server-side, so the
+// client key never ends up in logs or stored video errors.
+func (p *VideoProcessor) promptManualAuthorization(ctx context.Context, accountID, reason string) string {
+	authorizeURL := AuthorizeURL(p.config, accountID)
 
 	logger.Error().Printf("To re-authorize TikTok account %s open: %s", accountID, authorizeURL)
 	logger.Error().Printf("After login TikTok will redirect to %s with ?code=NEW_CODE", p.config.TikTokRedirectURI)
 	logger.Error().Printf("Call https://open.tiktokapis.com/v2/oauth/token/ (or POST /api/tiktok/exchange-code) with client_key, client_secret, redirect_uri=%s and code=NEW_CODE to store the new access/refresh tokens", p.config.TikTokRedirectURI)
+	if p.reauthAlerter != nil {
+		p.reauthAlerter.Alert(ctx, accountID, reason)
+	}
 
 	return authorizeURL
 }
 
 // cleanupDownloadDirectory keeps only the two newest files in the download directory and removes the rest.
+// Files of videos TikTok is still publishing are kept as well.
 func (p *VideoProcessor) cleanupDownloadDirectory(latestFile string) {
 	const retentionCount = 2
 
@@ -383,6 +1562,16 @@ func (p *VideoProcessor) cleanupDownloadDirectory(latestFile string) {
 		return
 	}
 
+	publishing, err := p.PublishingFiles(context.Background())
+	if err != nil {
+		logger.Error().Printf("Failed to list files of publishing videos, skipping cleanup: %v", err)
+		return
+	}
+	protected := make(map[string]bool, len(publishing))
+	for _, path := range publishing {
+		protected[filepath.Base(path)] = true
+	}
+
 	type fileMeta struct {
 		path    string
 		modTime time.Time
@@ -390,7 +1579,7 @@ func (p *VideoProcessor) cleanupDownloadDirectory(latestFile string) {
 
 	files := make([]fileMeta, 0, len(entries))
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() || protected[entry.Name()] {
 			continue
 		}
 		info, err := entry.Info()
@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/downloader"
+	httpclient "auto_upload_tiktok/internal/infrastructure/http"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/repository/memory"
+)
+
+// testProcessor is a video processor on in-memory repositories whose TikTok API is a test server
+type testProcessor struct {
+	*VideoProcessor
+	cfg      *config.Config
+	videos   *memory.VideoRepository
+	accounts *memory.AccountRepository
+}
+
+// newTestProcessor builds a processor; tiktokAPI serves its TikTok requests (nil refuses them)
+// and tune adjusts the configuration first
+func newTestProcessor(t *testing.T, tiktokAPI http.Handler, tune func(cfg *config.Config)) *testProcessor {
+	t.Helper()
+	if tiktokAPI == nil {
+		tiktokAPI = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected TikTok request %s %s", r.Method, r.URL.Path)
+			http.Error(w, "unexpected", http.StatusInternalServerError)
+		})
+	}
+	server := httptest.NewServer(tiktokAPI)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{
+		TikTokBaseURL:          server.URL,
+		HTTPClientTimeout:      5 * time.Second,
+		WorkerPoolSize:         4,
+		MaxConcurrentDownloads: 2,
+		MaxConcurrentUploads:   2,
+		UploadBufferSize:       32 * 1024,
+	}
+	if tune != nil {
+		tune(cfg)
+	}
+	videos := memory.NewVideoRepository()
+	accounts := memory.NewAccountRepository()
+	processor := NewVideoProcessor(cfg, videos, accounts, nil, nil,
+		tiktok.NewService(cfg, httpclient.NewHTTPClient(cfg)))
+	return &testProcessor{VideoProcessor: processor, cfg: cfg, videos: videos, accounts: accounts}
+}
+
+// saveAccount stores an active account with a TikTok token
+func (tp *testProcessor) saveAccount(t *testing.T, account *domain.Account) *domain.Account {
+	t.Helper()
+	account.IsActive = true
+	if account.TikTokAccountID == "" {
+		account.TikTokAccountID = "open-" + account.ID
+	}
+	if account.TikTokAccessToken == "" {
+		account.TikTokAccessToken = "act." + account.ID
+	}
+	if err := tp.accounts.Save(context.Background(), account); err != nil {
+		t.Fatalf("save account: %v", err)
+	}
+	return account
+}
+
+// saveVideo stores a video of the account
+func (tp *testProcessor) saveVideo(t *testing.T, video *domain.Video) *domain.Video {
+	t.Helper()
+	if video.Status == "" {
+		video.Status = domain.VideoStatusPending
+	}
+	if video.YouTubeVideoID == "" {
+		video.YouTubeVideoID = "yt-" + video.ID
+	}
+	if err := tp.videos.Save(context.Background(), video); err != nil {
+		t.Fatalf("save video: %v", err)
+	}
+	return video
+}
+
+func TestFinishPublishedPostsCommentInBackground(t *testing.T) {
+	var comments atomic.Int32
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		comments.Add(1)
+		w.Write([]byte(`{"error":{"code":"ok"}}`))
+	})
+	tp := newTestProcessor(t, api, func(cfg *config.Config) {
+		cfg.TikTokCommentPath = "/v2/comment/create/"
+		cfg.TikTokCommentMinInterval = 300 * time.Millisecond
+	})
+	account := tp.saveAccount(t, &domain.Account{ID: "acc-1", CommentTemplate: "Full video: {youtube_url}"})
+	video := tp.saveVideo(t, &domain.Video{ID: "vid-1", AccountID: account.ID, Status: domain.VideoStatusUploading, TikTokVideoID: "7300000000000000001"})
+
+	// The account just commented, so this comment has to wait for the interval
+	tp.lastCommentAt[account.TikTokAccountID] = time.Now()
+
+	start := time.Now()
+	if err := tp.finishPublished(context.Background(), video); err != nil {
+		t.Fatalf("finishPublished() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("finishPublished() took %s, it waited for the comment interval", elapsed)
+	}
+	stored, _ := tp.videos.GetByID(context.Background(), video.ID)
+	if stored.Status != domain.VideoStatusCompleted {
+		t.Errorf("status = %s before the comment, want %s", stored.Status, domain.VideoStatusCompleted)
+	}
+	if got := tp.InFlight(); got != 1 {
+		t.Errorf("InFlight() = %d while the comment waits, want 1", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tp.WaitForIdle(ctx); err != nil {
+		t.Fatalf("WaitForIdle() error = %v", err)
+	}
+	if got := comments.Load(); got != 1 {
+		t.Errorf("posted %d comments, want 1", got)
+	}
+	stored, _ = tp.videos.GetByID(context.Background(), video.ID)
+	if !stored.CommentPosted || stored.CommentError != "" {
+		t.Errorf("comment result = %v %q, want posted", stored.CommentPosted, stored.CommentError)
+	}
+}
+
+func TestFinishPublishedCommentsInlineDuringShutdown(t *testing.T) {
+	var comments atomic.Int32
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		comments.Add(1)
+		w.Write([]byte(`{"error":{"code":"ok"}}`))
+	})
+	tp := newTestProcessor(t, api, func(cfg *config.Config) {
+		cfg.TikTokCommentPath = "/v2/comment/create/"
+	})
+	account := tp.saveAccount(t, &domain.Account{ID: "acc-1", CommentTemplate: "{title}"})
+	video := tp.saveVideo(t, &domain.Video{ID: "vid-1", AccountID: account.ID, Title: "t", TikTokVideoID: "7300000000000000001"})
+
+	tp.BeginShutdown()
+	if err := tp.finishPublished(context.Background(), video); err != nil {
+		t.Fatalf("finishPublished() error = %v", err)
+	}
+	if got := comments.Load(); got != 1 {
+		t.Errorf("posted %d comments before returning, want 1", got)
+	}
+}
+
+func TestBeginShutdownCountsWorkInFlight(t *testing.T) {
+	tp := newTestProcessor(t, nil, nil)
+	for range 2 {
+		if !tp.beginWork() {
+			t.Fatal("beginWork() refused before shutdown")
+		}
+	}
+	if got := tp.BeginShutdown(); got != 2 {
+		t.Errorf("BeginShutdown() = %d, want 2", got)
+	}
+	// No work starts after the count was taken, so it cannot grow behind the caller's back
+	if tp.beginWork() {
+		t.Error("beginWork() accepted work after BeginShutdown()")
+	}
+	if got := tp.InFlight(); got != 2 {
+		t.Errorf("InFlight() = %d, want 2", got)
+	}
+
+	tp.endWork()
+	tp.endWork()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := tp.WaitForIdle(ctx); err != nil {
+		t.Errorf("WaitForIdle() error = %v", err)
+	}
+}
+
+// progressWrites records the progress written to the video repository
+type progressWrites struct {
+	domain.VideoRepository
+	mu     sync.Mutex
+	writes []int
+}
+
+func (r *progressWrites) UpdateProgress(ctx context.Context, id string, progress int) error {
+	r.mu.Lock()
+	r.writes = append(r.writes, progress)
+	r.mu.Unlock()
+	return r.VideoRepository.UpdateProgress(ctx, id, progress)
+}
+
+func TestProgressRecorder(t *testing.T) {
+	tp := newTestProcessor(t, nil, nil)
+	tp.saveAccount(t, &domain.Account{ID: "acc-1"})
+	video := tp.saveVideo(t, &domain.Video{ID: "vid-1", AccountID: "acc-1", Status: domain.VideoStatusUploading})
+	repo := &progressWrites{VideoRepository: tp.videos}
+	tp.videoRepo = repo
+
+	// An upload's callbacks as bytes go out: repeats are not written and values are kept to 0-100
+	record := tp.progressRecorder(context.Background(), video)
+	for _, progress := range []int{-3, 0, 0, 12, 12, 12, 50, 99, 100, 100, 140} {
+		record(progress)
+	}
+	if want := []int{0, 12, 50, 99, 100}; !reflect.DeepEqual(repo.writes, want) {
+		t.Errorf("progress written %v, want %v", repo.writes, want)
+	}
+	stored, _ := tp.videos.GetByID(context.Background(), "vid-1")
+	if stored.Progress != 100 {
+		t.Errorf("stored progress = %d, want 100", stored.Progress)
+	}
+
+	// Each download or upload starts its own recorder
+	repo.writes = nil
+	tp.progressRecorder(context.Background(), video)(100)
+	if want := []int{100}; !reflect.DeepEqual(repo.writes, want) {
+		t.Errorf("progress written by a new recorder %v, want %v", repo.writes, want)
+	}
+}
+
+// TestShutdownInterruptsUploadResumably stops the processor while an upload hangs: the upload
+// outlasts the grace period, is cancelled and must leave its video pending for any instance
+func TestShutdownInterruptsUploadResumably(t *testing.T) {
+	uploading := make(chan struct{})
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/info/":
+			w.Write([]byte(`{"data":{"user":{"open_id":"open-acc-1"}},"error":{"code":"ok"}}`))
+		case "/video/upload/":
+			io.Copy(io.Discard, r.Body)
+			close(uploading)
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	tp := newTestProcessor(t, api, func(cfg *config.Config) {
+		cfg.TikTokUploadInitPath = "/video/upload/"
+		cfg.ShutdownGrace = 100 * time.Millisecond
+	})
+	// No ffprobe: the upload goes ahead without measuring the file
+	ytdlp, err := filepath.Abs("testdata/slow_ytdlp.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp.cfg.YtDlpPath = ytdlp
+	tp.cfg.FFmpegPath = filepath.Join(t.TempDir(), "ffmpeg")
+	tp.cfg.DownloadDir = t.TempDir()
+	service, err := downloader.NewService(tp.cfg, httpclient.NewHTTPClient(tp.cfg))
+	if err != nil {
+		t.Fatalf("downloader.NewService() error = %v", err)
+	}
+	tp.downloadService = service
+	tp.saveAccount(t, &domain.Account{ID: "acc-1"})
+	// Downloaded before an upload pause, so the run goes straight to the upload
+	file := filepath.Join(t.TempDir(), "vid-1.mp4")
+	if err := os.WriteFile(file, []byte("video"), 0644); err != nil {
+		t.Fatalf("write video: %v", err)
+	}
+	tp.saveVideo(t, &domain.Video{ID: "vid-1", AccountID: "acc-1", LocalFilePath: file,
+		ErrorMessage: fmt.Sprintf("%v: %s", errPipelinePaused, pausedUploadMessage)})
+
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+	done := make(chan error, 1)
+	go func() { done <- tp.ProcessPendingVideos(workCtx) }()
+	select {
+	case <-uploading:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload did not start")
+	}
+
+	// Another instance starting meanwhile leaves the video alone
+	ctx := context.Background()
+	if n, err := tp.videos.RequeueInterrupted(ctx, "host-b", "interrupted"); err != nil || n != 0 {
+		t.Fatalf("RequeueInterrupted() by another host = %d, %v; want 0", n, err)
+	}
+
+	if got := tp.BeginShutdown(); got != 1 {
+		t.Errorf("BeginShutdown() = %d in flight, want 1", got)
+	}
+	graceCtx, cancelGrace := context.WithTimeout(ctx, tp.cfg.ShutdownGrace)
+	defer cancelGrace()
+	if err := tp.WaitForIdle(graceCtx); err == nil {
+		t.Fatal("WaitForIdle() returned before the hanging upload ended")
+	}
+	cancelWork()
+	flushCtx, cancelFlush := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelFlush()
+	if err := tp.WaitForIdle(flushCtx); err != nil {
+		t.Fatalf("WaitForIdle() after cancelling error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("ProcessPendingVideos() error = %v", err)
+	}
+
+	video, _ := tp.videos.GetByID(ctx, "vid-1")
+	if video.Status != domain.VideoStatusPending || !strings.HasPrefix(video.ErrorMessage, errInterrupted.Error()) {
+		t.Errorf("video = %s %q, want pending and interrupted", video.Status, video.ErrorMessage)
+	}
+	if video.TikTokVideoID != "" || video.CompletedAt != (time.Time{}) {
+		t.Errorf("interrupted video recorded a result: %q %v", video.TikTokVideoID, video.CompletedAt)
+	}
+	// Its claim was released, so another instance takes it right away
+	claimed, err := tp.videos.ClaimPending(ctx, "host-b:1", 10, time.Minute)
+	if err != nil || len(claimed) != 1 {
+		t.Errorf("ClaimPending() by another host = %d videos, %v; want the interrupted one", len(claimed), err)
+	}
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"sort"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+)
+
+// DurationStats summarizes one processing interval across videos
+type DurationStats struct {
+	Count int
+	Avg   time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// ProcessingStats aggregates how long completed uploads took
+type ProcessingStats struct {
+	// Videos is the number of uploads completed in the window
+	Videos int
+
+	// PublishToPost runs from the YouTube publish time to TikTok completion
+	PublishToPost DurationStats
+
+	// DiscoveryToPost runs from when the video was queued to TikTok completion
+	DiscoveryToPost DurationStats
+
+	// Download and Upload are the recorded step durations
+	Download DurationStats
+	Upload   DurationStats
+}
+
+// ComputeProcessingStats summarizes completed uploads. Videos missing a timestamp (e.g. finished
+// before timings were recorded) are left out of the intervals that need it.
+func ComputeProcessingStats(videos []*domain.Video) *ProcessingStats {
+	var publish, discovery, download, upload []time.Duration
+	for _, video := range videos {
+		if video.CompletedAt.IsZero() {
+			continue
+		}
+		if !video.PublishedAt.IsZero() && video.CompletedAt.After(video.PublishedAt) {
+			publish = append(publish, video.CompletedAt.Sub(video.PublishedAt))
+		}
+		if !video.CreatedAt.IsZero() && video.CompletedAt.After(video.CreatedAt) {
+			discovery = append(discovery, video.CompletedAt.Sub(video.CreatedAt))
+		}
+		if video.DownloadDuration > 0 {
+			download = append(download, video.DownloadDuration)
+		}
+		if video.UploadDuration > 0 {
+			upload = append(upload, video.UploadDuration)
+		}
+	}
+
+	return &ProcessingStats{
+		Videos:          len(videos),
+		PublishToPost:   summarizeDurations(publish),
+		DiscoveryToPost: summarizeDurations(discovery),
+		Download:        summarizeDurations(download),
+		Upload:          summarizeDurations(upload),
+	}
+}
+
+func summarizeDurations(values []time.Duration) DurationStats {
+	if len(values) == 0 {
+		return DurationStats{}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var total time.Duration
+	for _, v := range values {
+		total += v
+	}
+	return DurationStats{
+		Count: len(values),
+		Avg:   total / time.Duration(len(values)),
+		P50:   percentile(values, 50),
+		P90:   percentile(values, 90),
+		P95:   percentile(values, 95),
+		P99:   percentile(values, 99),
+		Max:   values[len(values)-1],
+	}
+}
+
+// percentile uses the nearest-rank method on sorted values
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
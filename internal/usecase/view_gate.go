@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// ViewGateResult is what one view gate check did
+type ViewGateResult struct {
+	Checked  int // Gated videos whose view count was read
+	Promoted int // Videos that reached min_views (or whose account dropped it) and were queued
+	Expired  int // Videos still short of min_views when the window ran out, now skipped
+}
+
+// applyViewGate holds the pending videos of an account with min_views in gated. Videos discovery
+// already saw at min_views stay pending. Without an API key views cannot be read, so nothing is held.
+func (m *AccountMonitor) applyViewGate(account *domain.Account, videos []*domain.Video) {
+	minViews := account.Settings.MinViews
+	if minViews <= 0 {
+		return
+	}
+	if m.config.YouTubeAPIKey == "" {
+		logger.Error().Printf("Account %s sets min_views but youtube.api_key is empty; its videos are queued at once", account.ID)
+		return
+	}
+
+	for _, video := range videos {
+		if video.Status != domain.VideoStatusPending {
+			continue
+		}
+		if !video.ViewsCheckedAt.IsZero() && video.ViewCount >= minViews {
+			continue
+		}
+		video.Status = domain.VideoStatusGated
+		video.ErrorMessage = viewGateMessage(video, account.Settings)
+		logger.Info().Printf("Holding video %s for channel %s until it reaches %d views", video.YouTubeVideoID, account.YouTubeChannelID, minViews)
+	}
+}
+
+// CheckViewGates reads the YouTube view count of every gated video, one quota unit per 50 videos.
+// Videos at their account's min_views move to pending; videos still short of it once the window
+// has passed are skipped. The unit of each videos.list call is charged to the first video it
+// checked, so account usage adds up to the units spent.
+func (m *AccountMonitor) CheckViewGates(ctx context.Context) (*ViewGateResult, error) {
+	result := &ViewGateResult{}
+	if m.config.YouTubeAPIKey == "" {
+		return result, nil
+	}
+	if until := m.QuotaPausedUntil(); !until.IsZero() {
+		logger.Info().Printf("Skipping view gate check: YouTube quota exhausted until %s", until.Format(time.RFC3339))
+		return result, nil
+	}
+
+	var gated []*domain.Video
+	err := m.videoRepo.Iterate(ctx, domain.VideoFilter{Status: domain.VideoStatusGated}, "", func(video *domain.Video) error {
+		gated = append(gated, video)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gated videos: %w", err)
+	}
+	if len(gated) == 0 {
+		return result, nil
+	}
+
+	accounts := make(map[string]*domain.Account)
+	var checked []*domain.Video
+	var ids []string
+	seen := make(map[string]bool)
+	for _, video := range gated {
+		account, ok := accounts[video.AccountID]
+		if !ok {
+			if account, err = m.accountRepo.GetByID(ctx, video.AccountID); err != nil {
+				return nil, fmt.Errorf("failed to get account %s: %w", video.AccountID, err)
+			}
+			accounts[video.AccountID] = account
+		}
+		if account == nil {
+			continue
+		}
+		// The account no longer gates its videos
+		if account.Settings.MinViews <= 0 {
+			if err := m.videoRepo.UpdateStatus(ctx, video.ID, domain.VideoStatusPending, ""); err != nil {
+				return result, fmt.Errorf("failed to queue video %s: %w", video.ID, err)
+			}
+			result.Promoted++
+			continue
+		}
+		checked = append(checked, video)
+		if id := video.SourceYouTubeID(); !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	views, lookupErr := m.youtubeService.GetVideoViewCounts(ids)
+	if lookupErr != nil && errors.Is(lookupErr, youtube.ErrQuotaExceeded) {
+		m.pauseForQuota(lookupErr)
+	}
+	m.chargeViewGateLookups(ctx, checked, ids, views, lookupErr)
+
+	now := time.Now()
+	for _, video := range checked {
+		settings := accounts[video.AccountID].Settings
+		count, found := views[video.SourceYouTubeID()]
+		// After a failed call, a missing video may just not have been asked for
+		if !found && lookupErr != nil {
+			continue
+		}
+		if found {
+			if err := m.videoRepo.UpdateViewCount(ctx, video.ID, count, now); err != nil {
+				return result, fmt.Errorf("failed to store view count of video %s: %w", video.ID, err)
+			}
+			video.ViewCount, video.ViewsCheckedAt = count, now
+			result.Checked++
+		}
+
+		var status domain.VideoStatus
+		var message string
+		switch {
+		case found && count >= settings.MinViews:
+			status = domain.VideoStatusPending
+			result.Promoted++
+			logger.Info().Printf("Video %s reached %d views; queued for upload", video.YouTubeVideoID, count)
+		case now.After(viewGateDeadline(video, settings)):
+			status = domain.VideoStatusSkipped
+			message = fmt.Sprintf("view gate: %d of %d views within %s", video.ViewCount, settings.MinViews, settings.ViewGateWindow())
+			result.Expired++
+			logger.Info().Printf("Skipping video %s: %s", video.YouTubeVideoID, message)
+		default:
+			status = domain.VideoStatusGated
+			message = viewGateMessage(video, settings)
+		}
+		if err := m.videoRepo.UpdateStatus(ctx, video.ID, status, message); err != nil {
+			return result, fmt.Errorf("failed to update status of video %s: %w", video.ID, err)
+		}
+	}
+
+	logger.Info().Printf("View gate check: %d of %d gated videos read, %d queued, %d expired",
+		result.Checked, len(gated), result.Promoted, result.Expired)
+	if lookupErr != nil {
+		return result, fmt.Errorf("failed to look up view counts: %w", lookupErr)
+	}
+	return result, nil
+}
+
+// chargeViewGateLookups records the quota unit of each videos.list call on the first checked video
+// it asked for. After a failed call, only the calls that returned videos are charged.
+func (m *AccountMonitor) chargeViewGateLookups(ctx context.Context, videos []*domain.Video, ids []string, views map[string]int64, lookupErr error) {
+	for start := 0; start < len(ids); start += youtube.MaxVideoIDsPerCall {
+		batch := ids[start:min(start+youtube.MaxVideoIDsPerCall, len(ids))]
+		if lookupErr != nil && !anyViewCount(batch, views) {
+			continue
+		}
+		for _, video := range videos {
+			if video.SourceYouTubeID() == batch[0] {
+				addVideoCost(ctx, m.videoRepo, video, domain.VideoCost{APIUnits: youtube.QuotaUnitsList})
+				break
+			}
+		}
+	}
+}
+
+// anyViewCount reports whether views has a count for any of the IDs
+func anyViewCount(ids []string, views map[string]int64) bool {
+	for _, id := range ids {
+		if _, ok := views[id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// viewGateDeadline returns when a gated video is skipped if still short of min_views: the window
+// counts from the YouTube publish time, or from discovery when that is unknown
+func viewGateDeadline(video *domain.Video, settings domain.AccountSettings) time.Time {
+	start := video.PublishedOrCreatedAt()
+	if start.IsZero() {
+		start = time.Now()
+	}
+	return start.Add(settings.ViewGateWindow())
+}
+
+// viewGateMessage describes what a gated video waits for, shown as its status detail
+func viewGateMessage(video *domain.Video, settings domain.AccountSettings) string {
+	deadline := viewGateDeadline(video, settings).UTC().Format(time.RFC3339)
+	if video.ViewsCheckedAt.IsZero() {
+		return fmt.Sprintf("view gate: waiting for %d views until %s", settings.MinViews, deadline)
+	}
+	return fmt.Sprintf("view gate: %d of %d views, waiting until %s", video.ViewCount, settings.MinViews, deadline)
+}
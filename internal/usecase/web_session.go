@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	tiktok "auto_upload_tiktok/internal/infrastructure/tiktok"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// Results of comparing a web session with an account's TikTok identity
+const (
+	WebSessionMatch      = "match"
+	WebSessionMismatch   = "mismatch"
+	WebSessionUnverified = "unverified" // The account's TikTok display name is not known yet
+)
+
+// ErrWebSessionMismatch is returned when cookies are signed in to another TikTok login than the
+// account posts to
+var ErrWebSessionMismatch = errors.New("cookies belong to a different TikTok account")
+
+// ErrWebSessionInUse is returned when another host used the web upload cookies within
+// tiktok.cookies_claim.window and tiktok.cookies_claim.refuse is set
+var ErrWebSessionInUse = errors.New("web upload cookies are in use by another host")
+
+// WebSessionManager checks web upload cookies before they are stored and records which TikTok
+// login they belong to
+type WebSessionManager struct {
+	cfg           *config.Config
+	accountRepo   domain.AccountRepository
+	sessionRepo   domain.WebSessionRepository
+	tiktokService *tiktok.Service
+
+	notifier domain.Notifier // Optional: reports cookies used by two hosts
+}
+
+// NewWebSessionManager creates a new web session manager
+func NewWebSessionManager(cfg *config.Config, accountRepo domain.AccountRepository, sessionRepo domain.WebSessionRepository, tiktokService *tiktok.Service) *WebSessionManager {
+	return &WebSessionManager{
+		cfg:           cfg,
+		accountRepo:   accountRepo,
+		sessionRepo:   sessionRepo,
+		tiktokService: tiktokService,
+	}
+}
+
+// SetNotifier reports cookies used by two hosts through the notifier
+func (m *WebSessionManager) SetNotifier(notifier domain.Notifier) {
+	m.notifier = notifier
+}
+
+// ImportCookies asks TikTok which login the cookie export is signed in as and, unless it is
+// another login than the account posts to, writes it to tiktok.cookies_path and records the
+// session. accountID may be empty when the cookies are not meant for one account; force stores
+// mismatched cookies anyway. On a mismatch the detected session is returned with the error.
+func (m *WebSessionManager) ImportCookies(ctx context.Context, accountID string, data []byte, force bool) (*domain.WebSession, error) {
+	if m.cfg.TikTokCookiesPath == "" {
+		return nil, errors.New("tiktok.cookies_path is not set")
+	}
+
+	var account *domain.Account
+	if accountID != "" {
+		var err error
+		account, err = m.accountRepo.GetByID(ctx, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account: %w", err)
+		}
+		if account == nil {
+			return nil, fmt.Errorf("account not found: %s", accountID)
+		}
+	}
+
+	identity, err := m.tiktokService.InspectCookies(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check cookies with TikTok: %w", err)
+	}
+	session := &domain.WebSession{
+		CookiesPath: m.cfg.TikTokCookiesPath,
+		UserID:      identity.UserID,
+		Username:    identity.Username,
+		Nickname:    identity.Nickname,
+		AccountID:   accountID,
+		CapturedAt:  time.Now(),
+	}
+
+	switch match := MatchWebSession(account, session); {
+	case match == WebSessionMismatch:
+		if !force {
+			return session, fmt.Errorf("%w: signed in as %s, but account %s posts to %s", ErrWebSessionMismatch, describeWebSession(session), account.ID, account.TikTokDisplayName)
+		}
+		logger.Error().Printf("Storing cookies of %s for account %s, which posts to %s, because the import was forced", describeWebSession(session), account.ID, account.TikTokDisplayName)
+	case match == WebSessionUnverified && account != nil:
+		logger.Info().Printf("Cannot verify cookies of %s against account %s: its TikTok display name is not known until it is authorized", describeWebSession(session), account.ID)
+	}
+
+	if err := m.tiktokService.SaveCookies(data); err != nil {
+		return nil, fmt.Errorf("failed to save cookies: %w", err)
+	}
+	if err := m.sessionRepo.Save(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to record web session: %w", err)
+	}
+	logger.Info().Printf("Stored web upload cookies of %s", describeWebSession(session))
+	return session, nil
+}
+
+// Session returns the recorded login of the configured cookies file, or nil when none was
+// recorded
+func (m *WebSessionManager) Session(ctx context.Context) (*domain.WebSession, error) {
+	if m.cfg.TikTokCookiesPath == "" {
+		return nil, nil
+	}
+	return m.sessionRepo.Get(ctx, m.cfg.TikTokCookiesPath)
+}
+
+// CookiesHealth reports the state of the cookies file (see tiktok.CookiesState) and the
+// problem with it, if any. The state is empty when no cookies path is configured.
+func (m *WebSessionManager) CookiesHealth() (string, error) {
+	if m.cfg.TikTokCookiesPath == "" {
+		return "", nil
+	}
+	err := m.tiktokService.CheckCookies()
+	return tiktok.CookiesState(err), err
+}
+
+// ClaimCookies records that this host is about to use the web upload cookies for the account.
+// When another host used them within tiktok.cookies_claim.window, TikTok may sign the session out
+// on both: the conflict is logged and notified, and with tiktok.cookies_claim.refuse
+// ErrWebSessionInUse is returned instead of taking the cookies over. A claim that cannot be
+// recorded is logged and does not stop the upload.
+func (m *WebSessionManager) ClaimCookies(ctx context.Context, accountID string) error {
+	window := m.cfg.TikTokCookiesClaimWindow
+	if m.cfg.TikTokCookiesPath == "" || window <= 0 {
+		return nil
+	}
+
+	host := CookiesClaimHost(m.cfg)
+	now := time.Now()
+	since := now.Add(-window)
+	refuse := m.cfg.TikTokCookiesClaimRefuse
+	previous, err := m.sessionRepo.Claim(ctx, m.cfg.TikTokCookiesPath, host, now, since, !refuse)
+	if err != nil {
+		logger.Error().Printf("Failed to record the use of web upload cookies by %s: %v", host, err)
+		return nil
+	}
+	if previous == nil || previous.Host == host || !previous.UsedAt.After(since) {
+		return nil
+	}
+
+	message := fmt.Sprintf("Web upload cookies %s were used by %s at %s and now by %s for account %s; TikTok signs a session out everywhere when it is used from two addresses",
+		m.cfg.TikTokCookiesPath, previous.Host, previous.UsedAt.Format(time.RFC3339), host, accountID)
+	if refuse {
+		logger.Error().Printf("Refusing web upload: %s", message)
+	} else {
+		logger.Error().Printf("WARNING: %s", message)
+	}
+	// Each host records the conflict, so only the first to see it within the window notifies
+	if m.notifier != nil && !previous.ConflictAt.After(since) {
+		n := &domain.Notification{
+			Event:     domain.NotificationWebSessionConflict,
+			AccountID: accountID,
+			Message:   message,
+			Time:      now,
+		}
+		if err := m.notifier.Notify(context.WithoutCancel(ctx), n); err != nil {
+			logger.Error().Printf("Failed to send web session conflict notification for account %s: %v", accountID, err)
+		}
+	}
+
+	if refuse {
+		return fmt.Errorf("%w: %s used them at %s", ErrWebSessionInUse, previous.Host, previous.UsedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Claim returns the recorded use of the configured cookies file, or nil when it was never used
+// or claims are off
+func (m *WebSessionManager) Claim(ctx context.Context) (*domain.WebSessionClaim, error) {
+	if m.cfg.TikTokCookiesPath == "" || m.cfg.TikTokCookiesClaimWindow <= 0 {
+		return nil, nil
+	}
+	return m.sessionRepo.GetClaim(ctx, m.cfg.TikTokCookiesPath)
+}
+
+// CookiesClaimHost names this instance in cookies claims: tiktok.cookies_claim.host, or the
+// hostname
+func CookiesClaimHost(cfg *config.Config) string {
+	if cfg.TikTokCookiesClaimHost != "" {
+		return cfg.TikTokCookiesClaimHost
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "unknown"
+}
+
+// MatchWebSession compares a web session with the TikTok login an account posts to. The web
+// user ID and the API open_id are unrelated, so the display name /user/info/ reported for the
+// account is compared with the session's nickname; a nil account is never a mismatch.
+func MatchWebSession(account *domain.Account, session *domain.WebSession) string {
+	if account == nil || account.TikTokDisplayName == "" {
+		return WebSessionUnverified
+	}
+	if strings.EqualFold(strings.TrimSpace(account.TikTokDisplayName), strings.TrimSpace(session.Nickname)) {
+		return WebSessionMatch
+	}
+	return WebSessionMismatch
+}
+
+// describeWebSession names a session's login for logs and errors
+func describeWebSession(session *domain.WebSession) string {
+	if session.Username == "" {
+		return fmt.Sprintf("%q (user %s)", session.Nickname, session.UserID)
+	}
+	return fmt.Sprintf("@%s %q", session.Username, session.Nickname)
+}
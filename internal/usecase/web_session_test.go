@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"auto_upload_tiktok/config"
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/repository/sqlitetest"
+)
+
+// recordingNotifier keeps the notifications it is sent
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []*domain.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification *domain.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func (n *recordingNotifier) events() []domain.NotificationEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var events []domain.NotificationEvent
+	for _, notification := range n.sent {
+		events = append(events, notification.Event)
+	}
+	return events
+}
+
+// TestClaimCookiesTwoHosts runs two workers on different hosts sharing the database and the
+// cookies, as a multi-role deployment with the same cookies copied to two machines would
+func TestClaimCookiesTwoHosts(t *testing.T) {
+	tests := []struct {
+		name       string
+		refuse     bool
+		wantSecond error
+		wantHost   string // holding the claim after both
+	}{
+		{name: "warn", wantHost: "worker-b"},
+		{name: "refuse", refuse: true, wantSecond: ErrWebSessionInUse, wantHost: "worker-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := sqlitetest.OpenTest(t)
+			notifier := &recordingNotifier{}
+			worker := func(host string) *WebSessionManager {
+				cfg := &config.Config{
+					TikTokCookiesPath:        "/state/cookies.json",
+					TikTokCookiesClaimHost:   host,
+					TikTokCookiesClaimWindow: 30 * time.Minute,
+					TikTokCookiesClaimRefuse: tt.refuse,
+				}
+				manager := NewWebSessionManager(cfg, repos.Accounts, repos.WebSessions, nil)
+				manager.SetNotifier(notifier)
+				return manager
+			}
+			a, b := worker("worker-a"), worker("worker-b")
+			ctx := context.Background()
+
+			if err := a.ClaimCookies(ctx, "acc-1"); err != nil {
+				t.Fatalf("first host ClaimCookies() error = %v", err)
+			}
+			if err := a.ClaimCookies(ctx, "acc-1"); err != nil {
+				t.Fatalf("first host ClaimCookies() again error = %v", err)
+			}
+			if events := notifier.events(); len(events) != 0 {
+				t.Fatalf("notifications before the second host = %v", events)
+			}
+
+			if err := b.ClaimCookies(ctx, "acc-2"); !errors.Is(err, tt.wantSecond) {
+				t.Fatalf("second host ClaimCookies() error = %v, want %v", err, tt.wantSecond)
+			}
+			events := notifier.events()
+			if len(events) != 1 || events[0] != domain.NotificationWebSessionConflict {
+				t.Fatalf("notifications = %v, want one %s", events, domain.NotificationWebSessionConflict)
+			}
+			if got := notifier.sent[0].AccountID; got != "acc-2" {
+				t.Errorf("conflict notified for account %q, want acc-2", got)
+			}
+
+			// Both hosts see the conflict in account health
+			for _, manager := range []*WebSessionManager{a, b} {
+				claim, err := manager.Claim(ctx)
+				if err != nil || claim == nil {
+					t.Fatalf("Claim() = %v, %v", claim, err)
+				}
+				if claim.Host != tt.wantHost || claim.ConflictHost == "" || claim.ConflictHost == claim.Host {
+					t.Errorf("Claim() = %s, conflict %q; want %s and the other host", claim.Host, claim.ConflictHost, tt.wantHost)
+				}
+			}
+
+			// The hosts keep contending, but the conflict is notified once per window
+			a.ClaimCookies(ctx, "acc-1")
+			b.ClaimCookies(ctx, "acc-2")
+			if events := notifier.events(); len(events) != 1 {
+				t.Errorf("notifications after more contention = %v, want still one", events)
+			}
+		})
+	}
+}
+
+func TestClaimCookiesOff(t *testing.T) {
+	repos := sqlitetest.OpenTest(t)
+	ctx := context.Background()
+	for _, cfg := range []*config.Config{
+		{TikTokCookiesClaimWindow: 30 * time.Minute}, // No cookies
+		{TikTokCookiesPath: "/state/cookies.json"},   // No window
+	} {
+		for _, host := range []string{"worker-a", "worker-b"} {
+			cfg.TikTokCookiesClaimHost = host
+			cfg.TikTokCookiesClaimRefuse = true
+			manager := NewWebSessionManager(cfg, repos.Accounts, repos.WebSessions, nil)
+			if err := manager.ClaimCookies(ctx, "acc-1"); err != nil {
+				t.Errorf("ClaimCookies() with claims off error = %v", err)
+			}
+		}
+	}
+	if claim, _ := repos.WebSessions.GetClaim(ctx, "/state/cookies.json"); claim != nil {
+		t.Errorf("claim recorded with claims off: %+v", claim)
+	}
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"auto_upload_tiktok/internal/domain"
+	"auto_upload_tiktok/internal/infrastructure/youtube"
+	"auto_upload_tiktok/internal/logger"
+)
+
+// youtubeTokenRefreshMargin refreshes a YouTube access token this long before it expires
+const youtubeTokenRefreshMargin = 2 * time.Minute
+
+// youtubeLinkPrefix starts the line added to the YouTube description for each TikTok post
+const youtubeLinkPrefix = "Also on TikTok: "
+
+// linkOnYouTube adds the TikTok link to the YouTube video's description for accounts with
+// settings.update_youtube_description (best-effort, never fails the video). Re-runs are harmless:
+// a link already in the description is not added again.
+func (p *VideoProcessor) linkOnYouTube(ctx context.Context, video *domain.Video) {
+	account, err := p.accountRepo.GetByID(ctx, video.AccountID)
+	if err != nil || account == nil || !account.Settings.UpdateYouTubeDescription {
+		return
+	}
+	// Drafts and scheduled posts have no public page yet
+	if account.Settings.PostAsDraft || account.Settings.PublishDelay > 0 {
+		return
+	}
+	link := publicTikTokURL(video)
+	if link == "" {
+		return
+	}
+	if !p.youtubeService.OAuthEnabled() {
+		logger.Error().Printf("Not linking video %s on YouTube: youtube.oauth_client_id/oauth_client_secret are not configured", video.YouTubeVideoID)
+		return
+	}
+	if account.YouTubeAccessToken == "" && account.YouTubeRefreshToken == "" {
+		logger.Error().Printf("Not linking video %s on YouTube: account %s has no YouTube authorization, use /api/youtube/authorize/%s", video.YouTubeVideoID, account.ID, account.ID)
+		return
+	}
+
+	accessToken, err := p.youtubeAccessToken(ctx, account)
+	if err != nil {
+		logger.Error().Printf("Not linking video %s on YouTube: %v", video.YouTubeVideoID, err)
+		return
+	}
+
+	// Clips and experiment arms link back to their source video
+	youtubeID, _, _ := strings.Cut(video.YouTubeVideoID, "#")
+	changed, err := p.youtubeService.AddDescriptionLine(accessToken, youtubeID, youtubeLinkPrefix+link)
+	units := int64(youtube.QuotaUnitsList)
+	if changed {
+		units += youtube.QuotaUnitsUpdate
+	}
+	addVideoCost(ctx, p.videoRepo, video, domain.VideoCost{APIUnits: units})
+	switch {
+	case err != nil:
+		logger.Error().Printf("Failed to add TikTok link to YouTube video %s: %v", youtubeID, err)
+	case changed:
+		logger.Info().Printf("Added TikTok link %s to YouTube video %s", link, youtubeID)
+	default:
+		logger.Info().Printf("YouTube video %s already links to %s", youtubeID, link)
+	}
+}
+
+// youtubeAccessToken returns a usable YouTube access token, refreshing and saving it when it has
+// expired or is about to
+func (p *VideoProcessor) youtubeAccessToken(ctx context.Context, account *domain.Account) (string, error) {
+	expiresAt := account.YouTubeTokenExpiresAt
+	fresh := expiresAt == nil || time.Until(*expiresAt) > youtubeTokenRefreshMargin
+	if account.YouTubeAccessToken != "" && fresh {
+		return account.YouTubeAccessToken, nil
+	}
+	if account.YouTubeRefreshToken == "" {
+		return "", fmt.Errorf("YouTube access token of account %s expired and no refresh token is stored, re-authorize via /api/youtube/authorize/%s", account.ID, account.ID)
+	}
+
+	token, err := p.youtubeService.RefreshAccessToken(account.YouTubeRefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh YouTube access token of account %s: %w", account.ID, err)
+	}
+
+	applyToken := func(account *domain.Account) error {
+		account.YouTubeAccessToken = token.AccessToken
+		if token.RefreshToken != "" {
+			account.YouTubeRefreshToken = token.RefreshToken
+		}
+		if token.ExpiresIn > 0 {
+			expiresAt := time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+			account.YouTubeTokenExpiresAt = &expiresAt
+		}
+		return nil
+	}
+	applyToken(account)
+	if _, err := modifyAccount(context.WithoutCancel(ctx), p.accountRepo, account.ID, "save refreshed YouTube token", applyToken); err != nil {
+		logger.Error().Printf("Failed to save refreshed YouTube token for account %s: %v", account.ID, err)
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,63 @@
+// Package version describes the running build. Release builds set the variables with -ldflags:
+//
+//	go build -ldflags "-X auto_upload_tiktok/internal/version.Version=v1.4.0 \
+//	  -X auto_upload_tiktok/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X auto_upload_tiktok/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with -ldflags "-X auto_upload_tiktok/internal/version.<Name>=<value>"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info is the version of the running build
+type Info struct {
+	Version   string
+	Commit    string
+	BuildDate string
+	GoVersion string
+}
+
+// Get returns the build's version. Without -ldflags, the commit and date come from the VCS
+// stamp go build embeds when run inside the git checkout, and are "unknown" otherwise.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+	if build, ok := debug.ReadBuildInfo(); ok && (info.Commit == "" || info.BuildDate == "") {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+				if len(info.Commit) > 12 {
+					info.Commit = info.Commit[:12]
+				}
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+// String formats the version for --version and the startup banner
+func (i Info) String() string {
+	return fmt.Sprintf("auto_upload_tiktok %s (commit %s, built %s, %s)", i.Version, i.Commit, i.BuildDate, i.GoVersion)
+}